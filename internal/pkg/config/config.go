@@ -15,7 +15,8 @@ var (
 // NewConfig creates a new config manager with default providers
 // serviceDir should be the absolute or relative directory path of the service
 // (e.g., "internal/service/auth" or absolute path)
-func NewConfig(serviceDir string) ConfigManager {
+// fileOpts are passed to the file provider, e.g. WithDevelopmentEnvFiles(true)
+func NewConfig(serviceDir string, fileOpts ...FileProviderOption) ConfigManager {
 	// Resolve service directory to absolute path
 	var servicePath string
 	if serviceDir != "" {
@@ -37,7 +38,7 @@ func NewConfig(serviceDir string) ConfigManager {
 	// Create providers in priority order (last one wins)
 	providers := []Provider{
 		NewDefaultProvider(getDefaultConfig()),
-		NewFileProvider(servicePath),
+		NewFileProvider(servicePath, fileOpts...),
 		NewEnvProvider("APP_"),
 	}
 
@@ -58,6 +59,11 @@ func getDefaultConfig() map[string]any {
 			"read_timeout":     10,
 			"write_timeout":    10,
 			"shutdown_timeout": 10,
+			"maintenance": map[string]any{
+				"enabled":         false,
+				"retry_after_sec": 120,
+				"admin_token":     "",
+			},
 		},
 		"database": map[string]any{
 			"host":              "localhost",
@@ -69,6 +75,12 @@ func getDefaultConfig() map[string]any {
 			"max_open_conns":    25,
 			"max_idle_conns":    5,
 			"conn_max_lifetime": 300,
+			"connect_retry": map[string]any{
+				"max_attempts":        10,
+				"initial_backoff_sec": 1,
+				"max_backoff_sec":     10,
+				"timeout_sec":         60,
+			},
 		},
 		"jwt": map[string]any{
 			"secret":      "your-super-secret-jwt-key-change-this-in-production",
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ErrUnsetVariable is returned when a config file references an environment
+// variable that is not set and has no default
+var ErrUnsetVariable = errors.New("environment variable is not set")
+
+// interpolateEnv replaces ${VAR} and ${VAR:-default} in every string value of
+// data, including values nested in maps and lists, and turns $$ into $
+// Like the shell, the default is used when VAR is unset or empty. Every
+// reference to an unset variable without a default is reported.
+func interpolateEnv(data map[string]any) (map[string]any, error) {
+	var errs []error
+	for key, value := range data {
+		data[key] = interpolateValue(key, value, &errs)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return data, nil
+}
+
+// interpolateValue expands the strings in value, which is at config key path
+func interpolateValue(path string, value any, errs *[]error) any {
+	switch v := value.(type) {
+	case string:
+		expanded, err := expandEnv(v)
+		if err != nil {
+			*errs = append(*errs, fmt.Errorf("config key %s: %w", path, err))
+			return v
+		}
+		return expanded
+	case map[string]any:
+		for key, item := range v {
+			v[key] = interpolateValue(path+"."+key, item, errs)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = interpolateValue(fmt.Sprintf("%s[%d]", path, i), item, errs)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// expandEnv expands the variable references in a single string
+func expandEnv(s string) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	var errs []error
+	for i := 0; i < len(s); i++ {
+		if s[i] != '$' || i+1 == len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			i++
+		case '{':
+			end := strings.IndexByte(s[i+2:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated ${ in %q", s)
+			}
+			name, def, hasDefault := strings.Cut(s[i+2:i+2+end], ":-")
+			if !isEnvName(name) {
+				return "", fmt.Errorf("invalid environment variable name %q", name)
+			}
+
+			value, ok := os.LookupEnv(name)
+			switch {
+			case hasDefault && value == "":
+				value = def
+			case !ok:
+				errs = append(errs, fmt.Errorf("%w: %s", ErrUnsetVariable, name))
+			}
+			b.WriteString(value)
+			i += 2 + end
+		default:
+			b.WriteByte('$')
+		}
+	}
+
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	return b.String(), nil
+}
+
+// isEnvName reports whether name is a valid environment variable name
+func isEnvName(name string) bool {
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return false
+		}
+	}
+	return true
+}
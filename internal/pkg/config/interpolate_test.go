@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "s3cret")
+	t.Setenv("EMPTY_VAR", "")
+
+	tests := []struct {
+		in, want string
+	}{
+		{"plain", "plain"},
+		{"${DB_PASSWORD}", "s3cret"},
+		{"user:${DB_PASSWORD}@host", "user:s3cret@host"},
+		{"${MISSING_VAR:-fallback}", "fallback"},
+		{"${EMPTY_VAR:-fallback}", "fallback"},
+		{"${DB_PASSWORD:-fallback}", "s3cret"},
+		{"${MISSING_VAR:-}", ""},
+		{"${EMPTY_VAR}", ""},
+		{"cost $$5", "cost $5"},
+		{"$${DB_PASSWORD}", "${DB_PASSWORD}"},
+		{"$HOME and $", "$HOME and $"},
+	}
+	for _, tt := range tests {
+		got, err := expandEnv(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
+
+func TestExpandEnv_Errors(t *testing.T) {
+	_, err := expandEnv("${MISSING_VAR}")
+	assert.ErrorIs(t, err, ErrUnsetVariable)
+	assert.ErrorContains(t, err, "MISSING_VAR")
+
+	_, err = expandEnv("${UNTERMINATED")
+	assert.ErrorContains(t, err, "unterminated")
+
+	_, err = expandEnv("${1BAD}")
+	assert.ErrorContains(t, err, "invalid environment variable name")
+}
+
+func TestFileProvider_Load_InterpolatesEnv(t *testing.T) {
+	root := t.TempDir()
+	serviceDir := filepath.Join(root, "service")
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.yaml"), `
+database:
+  password: ${DB_PASSWORD}
+  host: ${DB_HOST:-localhost}
+  port: 5432
+hosts:
+  - ${DB_HOST:-localhost}
+  - name: ${REPLICA}
+overridden: ${NOT_SET_BUT_OVERRIDDEN}
+`)
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.production.yaml"), `
+overridden: literal
+`)
+	t.Chdir(root)
+	t.Setenv("APP_ENV", "production")
+	t.Setenv("DB_PASSWORD", "s3cret")
+	t.Setenv("REPLICA", "replica-1")
+
+	data, err := NewFileProvider(serviceDir).Load()
+	require.NoError(t, err)
+
+	db := data["database"].(map[string]any)
+	assert.Equal(t, "s3cret", db["password"])
+	assert.Equal(t, "localhost", db["host"])
+	assert.Equal(t, 5432, db["port"])
+	hosts := data["hosts"].([]any)
+	assert.Equal(t, "localhost", hosts[0])
+	assert.Equal(t, "replica-1", hosts[1].(map[string]any)["name"])
+	assert.Equal(t, "literal", data["overridden"])
+}
+
+func TestFileProvider_Load_UnsetVariable(t *testing.T) {
+	root := t.TempDir()
+	serviceDir := filepath.Join(root, "service")
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.yaml"), `
+database:
+  password: ${UNSET_DB_PASSWORD}
+jwt:
+  secret: ${UNSET_JWT_SECRET}
+`)
+	t.Chdir(root)
+	t.Setenv("APP_ENV", "production")
+
+	_, err := NewFileProvider(serviceDir).Load()
+	require.ErrorIs(t, err, ErrUnsetVariable)
+	assert.ErrorContains(t, err, "config key database.password")
+	assert.ErrorContains(t, err, "UNSET_DB_PASSWORD")
+	assert.ErrorContains(t, err, "UNSET_JWT_SECRET")
+
+	// The manager refuses to load rather than dropping the file config
+	mgr := New(WithProvider(NewDefaultProvider(getDefaultConfig())), WithProvider(NewFileProvider(serviceDir)))
+	assert.ErrorIs(t, mgr.Load(), ErrUnsetVariable)
+}
+
+func TestManager_Load_SkipsFailingProvider(t *testing.T) {
+	defaults := NewDefaultProvider(getDefaultConfig())
+
+	// An unset variable fails even the default manager
+	unset := New(WithProvider(defaults), WithProvider(unsetVariableProvider{}))
+	assert.ErrorIs(t, unset.Load(), ErrUnsetVariable)
+
+	// Other provider failures are skipped unless loading is strict
+	mgr := New(WithProvider(defaults), WithProvider(failingProvider{}))
+	require.NoError(t, mgr.Load())
+	assert.Equal(t, "localhost", mgr.Get("database.host"))
+
+	strict := New(WithProvider(defaults), WithProvider(failingProvider{}), WithStrictLoad())
+	assert.ErrorContains(t, strict.Load(), "failed to load failing config")
+}
+
+// unsetVariableProvider fails like a file referencing an unset ${VAR}
+type unsetVariableProvider struct{}
+
+func (unsetVariableProvider) Name() string { return "file" }
+func (unsetVariableProvider) Load() (map[string]any, error) {
+	return nil, fmt.Errorf("%w: DB_PASSWORD", ErrUnsetVariable)
+}
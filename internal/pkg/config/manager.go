@@ -1,13 +1,13 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/go-playground/validator/v10"
-	"github.com/mitchellh/mapstructure"
 )
 
 // ConfigManager manages configuration loading and access
@@ -28,6 +28,8 @@ type manager struct {
 	validator   *validator.Validate
 	watchActive bool
 	watchStop   chan struct{}
+	// strict fails Load when any provider fails instead of skipping it
+	strict bool
 }
 
 // New creates a new config manager with the given providers
@@ -56,6 +58,14 @@ func WithProvider(provider Provider) Option {
 	}
 }
 
+// WithStrictLoad makes Load fail when any provider fails instead of skipping
+// that provider. An unset ${VAR} fails Load with or without it.
+func WithStrictLoad() Option {
+	return func(m *manager) {
+		m.strict = true
+	}
+}
+
 // Load loads configuration from all providers in priority order
 // Priority: env > file > default (last provider wins)
 func (m *manager) Load() error {
@@ -68,26 +78,22 @@ func (m *manager) Load() error {
 	for _, provider := range m.providers {
 		data, err := provider.Load()
 		if err != nil {
-			// Log warning but continue with other providers
+			// Skipping a file over an unset variable would silently run on
+			// the defaults (e.g. a localhost database), so that always fails
+			if m.strict || errors.Is(err, ErrUnsetVariable) {
+				return fmt.Errorf("failed to load %s config: %w", provider.Name(), err)
+			}
+			// Skip the failing provider and continue with the others
 			continue
 		}
 		merged = mergeMaps(merged, data)
 	}
 
-	m.data = merged
-
 	// Unmarshal into Config struct
 	var cfg Config
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		Result:           &cfg,
-		WeaklyTypedInput: true,
-		DecodeHook: mapstructure.ComposeDecodeHookFunc(
-			mapstructure.StringToTimeDurationHookFunc(),
-			mapstructure.StringToSliceHookFunc(","),
-		),
-	})
+	decoder, err := newDecoder(&cfg)
 	if err != nil {
-		return fmt.Errorf("failed to create decoder: %w", err)
+		return err
 	}
 
 	if err := decoder.Decode(merged); err != nil {
@@ -99,6 +105,9 @@ func (m *manager) Load() error {
 		return fmt.Errorf("config validation failed: %w", err)
 	}
 
+	// Keep the previous data if the new one is invalid, so a bad reload
+	// doesn't leave Get and Config disagreeing
+	m.data = merged
 	m.config = &cfg
 	return nil
 }
@@ -146,51 +155,57 @@ func (m *manager) Unmarshal(target any) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
-		Result:           target,
-		WeaklyTypedInput: true,
-		DecodeHook: mapstructure.ComposeDecodeHookFunc(
-			mapstructure.StringToTimeDurationHookFunc(),
-			mapstructure.StringToSliceHookFunc(","),
-		),
-	})
+	decoder, err := newDecoder(target)
 	if err != nil {
-		return fmt.Errorf("failed to create decoder: %w", err)
+		return err
 	}
 
 	return decoder.Decode(m.data)
 }
 
-// Watch watches for configuration file changes and reloads
-// This is a simplified implementation - in production, you'd use fsnotify
+// Watch reloads the configuration when a watchable provider's source
+// changes and then calls callback. Watching is opt-in: until Watch is
+// called the configuration is loaded once.
+// A reload that fails, e.g. on an invalid value, keeps the previous
+// configuration and skips the callback.
 func (m *manager) Watch(callback func()) error {
 	m.mu.Lock()
+	defer m.mu.Unlock()
+
 	if m.watchActive {
-		m.mu.Unlock()
 		return fmt.Errorf("watch is already active")
 	}
-	m.watchActive = true
-	m.mu.Unlock()
 
-	go func() {
-		ticker := time.NewTicker(5 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ticker.C:
-				// Check if files changed (simplified - in production use fsnotify)
-				if err := m.Reload(); err == nil {
-					if callback != nil {
-						callback()
-					}
-				}
-			case <-m.watchStop:
-				return
-			}
+	ctx, cancel := context.WithCancel(context.Background())
+	onChange := func(map[string]any) {
+		// Every provider is re-merged so their priority order is kept
+		if err := m.Reload(); err == nil && callback != nil {
+			callback()
 		}
-	}()
+	}
+
+	watching := 0
+	for _, provider := range m.providers {
+		watchable, ok := provider.(WatchableProvider)
+		if !ok {
+			continue
+		}
+		if err := watchable.Watch(ctx, onChange); err != nil {
+			cancel()
+			return fmt.Errorf("failed to watch %s config: %w", provider.Name(), err)
+		}
+		watching++
+	}
+	if watching == 0 {
+		cancel()
+		return fmt.Errorf("no config provider supports watching")
+	}
 
+	go func() {
+		<-m.watchStop
+		cancel()
+	}()
+	m.watchActive = true
 	return nil
 }
 
@@ -5,8 +5,10 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"github.com/subosito/gotenv"
 )
 
 // Provider defines the interface for configuration providers
@@ -38,26 +40,54 @@ func (p *DefaultProvider) Load() (map[string]any, error) {
 	return p.defaults, nil
 }
 
-// FileProvider loads configuration from files (YAML, JSON, .env)
+// configExtensions are the supported config file formats, in the order the
+// files of one layer are merged (later files win)
+var configExtensions = []string{".yaml", ".yml", ".json", ".toml", ".env"}
+
+// FileProvider loads configuration from files (YAML, JSON, TOML, .env)
 type FileProvider struct {
 	paths      []string
 	env        string
 	serviceDir string
+	// loadDevEnvFiles loads config.development.yaml when env is "development"
+	loadDevEnvFiles bool
+	// watchDebounce is how long Watch waits for writes to settle
+	watchDebounce time.Duration
+}
+
+// FileProviderOption is a functional option for FileProvider
+type FileProviderOption func(*FileProvider)
+
+// WithDevelopmentEnvFiles enables loading config.development.yaml files.
+// By default env-specific files are skipped in the "development" env.
+func WithDevelopmentEnvFiles(enabled bool) FileProviderOption {
+	return func(p *FileProvider) {
+		p.loadDevEnvFiles = enabled
+	}
 }
 
 // NewFileProvider creates a new file provider
-// It searches for config files in the following order:
+// It searches for config files in the following order (later files win):
 // 1. Global base: config/config.yaml
 // 2. Global env: config/config.<env>.yaml
 // 3. Service base: <serviceDir>/config/config.yaml
 // 4. Service env: <serviceDir>/config/config.<env>.yaml
-func NewFileProvider(serviceDir string) *FileProvider {
+// Each layer may also be a .yml, .json, .toml or .env file; when a layer has
+// several, they are merged in that order.
+func NewFileProvider(serviceDir string, opts ...FileProviderOption) *FileProvider {
 	env := getEnv()
-	return &FileProvider{
-		paths:      []string{},
-		env:        env,
-		serviceDir: serviceDir,
+	p := &FileProvider{
+		paths:         []string{},
+		env:           env,
+		serviceDir:    serviceDir,
+		watchDebounce: DefaultWatchDebounce,
 	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
 }
 
 // Name returns the provider name
@@ -66,56 +96,80 @@ func (p *FileProvider) Name() string {
 }
 
 // Load loads configuration from files
+// ${VAR} references in values are resolved once the files are merged, so a
+// value overridden by a later file doesn't need its variables set.
 func (p *FileProvider) Load() (map[string]any, error) {
 	result := make(map[string]any)
-
-	// Find global config directory
-	globalConfigDir := findGlobalConfigDir()
-
-	// Load global base config
-	if globalConfigDir != "" {
-		globalBase := filepath.Join(globalConfigDir, "config.yaml")
-		if data, err := loadFile(globalBase); err == nil {
+	for _, path := range p.files() {
+		if data, err := loadFile(path); err == nil {
 			result = mergeMaps(result, data)
 		}
-
-		// Load global env config
-		if p.env != "" && p.env != "development" {
-			globalEnv := filepath.Join(globalConfigDir, fmt.Sprintf("config.%s.yaml", p.env))
-			if data, err := loadFile(globalEnv); err == nil {
-				result = mergeMaps(result, data)
-			}
-		}
 	}
+	return interpolateEnv(result)
+}
 
-	// Load service base config
+// files returns the candidate config files in merge order (later files win)
+// Files that don't exist are included; Load skips them.
+func (p *FileProvider) files() []string {
+	var dirs []string
+	if globalConfigDir := findGlobalConfigDir(); globalConfigDir != "" {
+		dirs = append(dirs, globalConfigDir)
+	}
 	if p.serviceDir != "" {
-		serviceBase := filepath.Join(p.serviceDir, "config", "config.yaml")
-		if data, err := loadFile(serviceBase); err == nil {
-			result = mergeMaps(result, data)
-		}
+		dirs = append(dirs, filepath.Join(p.serviceDir, "config"))
+	}
 
-		// Load service env config
-		if p.env != "" && p.env != "development" {
-			serviceEnv := filepath.Join(p.serviceDir, "config", fmt.Sprintf("config.%s.yaml", p.env))
-			if data, err := loadFile(serviceEnv); err == nil {
-				result = mergeMaps(result, data)
-			}
+	// Base then env config, global before service
+	var files []string
+	for _, dir := range dirs {
+		files = append(files, layerFiles(dir, "config")...)
+		if p.shouldLoadEnvFiles() {
+			files = append(files, layerFiles(dir, "config."+p.env)...)
 		}
 	}
+	return files
+}
+
+// layerFiles returns dir/name with every supported extension
+func layerFiles(dir, name string) []string {
+	files := make([]string, 0, len(configExtensions))
+	for _, ext := range configExtensions {
+		files = append(files, filepath.Join(dir, name+ext))
+	}
+	return files
+}
 
-	return result, nil
+// shouldLoadEnvFiles reports whether config.<env>.yaml files should be loaded
+func (p *FileProvider) shouldLoadEnvFiles() bool {
+	if p.env == "" {
+		return false
+	}
+	return p.env != "development" || p.loadDevEnvFiles
 }
 
-// loadFile loads a single config file
+// loadFile loads a single config file, choosing the format by extension
 func loadFile(path string) (map[string]any, error) {
 	if _, err := os.Stat(path); os.IsNotExist(err) {
 		return nil, fmt.Errorf("file not found: %s", path)
 	}
 
+	var configType string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		configType = "yaml"
+	case ".json":
+		configType = "json"
+	case ".toml":
+		configType = "toml"
+	case ".env":
+		return loadEnvFile(path)
+	default:
+		return nil, fmt.Errorf("unsupported config file type %q: %s", ext, path)
+	}
+
 	v := viper.New()
 	v.SetConfigFile(path)
-	v.SetConfigType("yaml")
+	v.SetConfigType(configType)
 
 	if err := v.ReadInConfig(); err != nil {
 		return nil, err
@@ -124,6 +178,28 @@ func loadFile(path string) (map[string]any, error) {
 	return v.AllSettings(), nil
 }
 
+// loadEnvFile loads a KEY=value .env file
+// Keys are lower-cased and nested on double underscores like EnvProvider,
+// so SERVER__PORT=8080 sets server.port. Values are strings.
+func loadEnvFile(path string) (map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	env, err := gotenv.StrictParse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	result := make(map[string]any)
+	for key, value := range env {
+		setNestedValue(result, strings.Split(strings.ToLower(key), "__"), value)
+	}
+	return result, nil
+}
+
 // EnvProvider loads configuration from environment variables
 type EnvProvider struct {
 	prefix string
@@ -241,9 +317,10 @@ func findGlobalConfigDir() string {
 
 	dir := wd
 	for {
-		configPath := filepath.Join(dir, "config", "config.yaml")
-		if _, err := os.Stat(configPath); err == nil {
-			return filepath.Join(dir, "config")
+		for _, configPath := range layerFiles(filepath.Join(dir, "config"), "config") {
+			if _, err := os.Stat(configPath); err == nil {
+				return filepath.Join(dir, "config")
+			}
 		}
 
 		parent := filepath.Dir(dir)
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfigFile writes a YAML file, creating parent directories as needed
+func writeConfigFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// setupConfigTree creates a global and service config tree where every layer
+// sets "layer" and one key unique to it, and changes into the temp root
+func setupConfigTree(t *testing.T, env string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	serviceDir := filepath.Join(root, "internal", "service", "demo")
+
+	writeConfigFile(t, filepath.Join(root, "config", "config.yaml"), `
+layer: global-base
+app:
+  name: global-base
+  global_base_only: true
+  shared: global-base
+`)
+	writeConfigFile(t, filepath.Join(root, "config", "config."+env+".yaml"), `
+layer: global-env
+app:
+  global_env_only: true
+  shared: global-env
+`)
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.yaml"), `
+layer: service-base
+app:
+  service_base_only: true
+  shared: service-base
+`)
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config."+env+".yaml"), `
+layer: service-env
+app:
+  service_env_only: true
+`)
+
+	t.Chdir(root)
+	t.Setenv("APP_ENV", env)
+
+	return serviceDir
+}
+
+func TestFileProvider_Load_MergePrecedence(t *testing.T) {
+	serviceDir := setupConfigTree(t, "staging")
+
+	data, err := NewFileProvider(serviceDir).Load()
+	require.NoError(t, err)
+
+	// Service env is the last layer and wins
+	assert.Equal(t, "service-env", data["layer"])
+
+	app, ok := data["app"].(map[string]any)
+	require.True(t, ok)
+
+	// Nested keys are deep-merged across every layer
+	assert.Equal(t, "global-base", app["name"])
+	assert.Equal(t, true, app["global_base_only"])
+	assert.Equal(t, true, app["global_env_only"])
+	assert.Equal(t, true, app["service_base_only"])
+	assert.Equal(t, true, app["service_env_only"])
+
+	// Service base overrides global env, which overrides global base
+	assert.Equal(t, "service-base", app["shared"])
+}
+
+func TestFileProvider_Load_GlobalEnvOverridesGlobalBase(t *testing.T) {
+	root := t.TempDir()
+	writeConfigFile(t, filepath.Join(root, "config", "config.yaml"), "layer: global-base\n")
+	writeConfigFile(t, filepath.Join(root, "config", "config.production.yaml"), "layer: global-env\n")
+
+	t.Chdir(root)
+	t.Setenv("APP_ENV", "production")
+
+	data, err := NewFileProvider("").Load()
+	require.NoError(t, err)
+	assert.Equal(t, "global-env", data["layer"])
+}
+
+func TestFileProvider_Load_SkipsEnvFilesInDevelopmentByDefault(t *testing.T) {
+	serviceDir := setupConfigTree(t, "development")
+
+	data, err := NewFileProvider(serviceDir).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "service-base", data["layer"])
+
+	app := data["app"].(map[string]any)
+	assert.NotContains(t, app, "global_env_only")
+	assert.NotContains(t, app, "service_env_only")
+}
+
+func TestFileProvider_Load_DevelopmentEnvFilesEnabled(t *testing.T) {
+	serviceDir := setupConfigTree(t, "development")
+
+	data, err := NewFileProvider(serviceDir, WithDevelopmentEnvFiles(true)).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "service-env", data["layer"])
+
+	app := data["app"].(map[string]any)
+	assert.Equal(t, true, app["global_env_only"])
+	assert.Equal(t, true, app["service_env_only"])
+}
+
+func TestNewConfig_DevelopmentEnvFiles(t *testing.T) {
+	serviceDir := setupConfigTree(t, "development")
+
+	mgr := NewConfig(serviceDir)
+	require.NoError(t, mgr.Load())
+	assert.Equal(t, "service-base", mgr.Get("layer"))
+
+	mgr = NewConfig(serviceDir, WithDevelopmentEnvFiles(true))
+	require.NoError(t, mgr.Load())
+	assert.Equal(t, "service-env", mgr.Get("layer"))
+	assert.Equal(t, true, mgr.Get("app.service_env_only"))
+}
+
+// formatSample is decoded from each config format to compare them
+type formatSample struct {
+	Server struct {
+		Host    string `mapstructure:"host"`
+		Port    int    `mapstructure:"port"`
+		Enabled bool   `mapstructure:"enabled"`
+	} `mapstructure:"server"`
+	Logger struct {
+		Level string `mapstructure:"level"`
+	} `mapstructure:"logger"`
+	Name string `mapstructure:"name"`
+}
+
+var formatFiles = map[string]string{
+	"config.yaml": `
+name: demo
+server:
+  host: 0.0.0.0
+  port: 8082
+  enabled: true
+logger:
+  level: debug
+`,
+	"config.yml": `
+name: demo
+server: {host: 0.0.0.0, port: 8082, enabled: true}
+logger: {level: debug}
+`,
+	"config.json": `{
+  "name": "demo",
+  "server": {"host": "0.0.0.0", "port": 8082, "enabled": true},
+  "logger": {"level": "debug"}
+}`,
+	"config.toml": `
+name = "demo"
+
+[server]
+host = "0.0.0.0"
+port = 8082
+enabled = true
+
+[logger]
+level = "debug"
+`,
+	"config.env": `
+# Nested keys use double underscores
+NAME=demo
+SERVER__HOST=0.0.0.0
+SERVER__PORT=8082
+export SERVER__ENABLED=true
+LOGGER__LEVEL="debug"
+`,
+}
+
+// decodeSample decodes loaded config the way the manager does
+func decodeSample(t *testing.T, data map[string]any) formatSample {
+	t.Helper()
+
+	var sample formatSample
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{Result: &sample, WeaklyTypedInput: true})
+	require.NoError(t, err)
+	require.NoError(t, decoder.Decode(data))
+	return sample
+}
+
+func TestFileProvider_Load_Formats(t *testing.T) {
+	var want formatSample
+	want.Name = "demo"
+	want.Server.Host = "0.0.0.0"
+	want.Server.Port = 8082
+	want.Server.Enabled = true
+	want.Logger.Level = "debug"
+
+	for name, content := range formatFiles {
+		t.Run(name, func(t *testing.T) {
+			root := t.TempDir()
+			serviceDir := filepath.Join(root, "service")
+			writeConfigFile(t, filepath.Join(serviceDir, "config", name), content)
+			t.Chdir(root)
+			t.Setenv("APP_ENV", "production")
+
+			data, err := NewFileProvider(serviceDir).Load()
+			require.NoError(t, err)
+			assert.Equal(t, want, decodeSample(t, data))
+		})
+	}
+}
+
+func TestFileProvider_Load_MixedFormatsKeepPrecedence(t *testing.T) {
+	root := t.TempDir()
+	serviceDir := filepath.Join(root, "internal", "service", "demo")
+
+	writeConfigFile(t, filepath.Join(root, "config", "config.toml"), `
+layer = "global-base"
+[app]
+global_base_only = true
+`)
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.yaml"), `
+layer: service-base
+app:
+  shared: service-base
+`)
+	// Within a layer JSON is merged after YAML
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.json"), `{"app": {"shared": "service-base-json"}}`)
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.staging.env"), "LAYER=service-env\n")
+
+	t.Chdir(root)
+	t.Setenv("APP_ENV", "staging")
+
+	data, err := NewFileProvider(serviceDir).Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "service-env", data["layer"])
+	app := data["app"].(map[string]any)
+	assert.Equal(t, true, app["global_base_only"])
+	assert.Equal(t, "service-base-json", app["shared"])
+}
+
+func TestLoadFile_UnsupportedType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	writeConfigFile(t, path, "[server]\nport=1\n")
+
+	_, err := loadFile(path)
+	assert.ErrorContains(t, err, "unsupported config file type")
+}
@@ -16,6 +16,19 @@ type ServerConfig struct {
 	ReadTimeout     int    `mapstructure:"read_timeout" validate:"gte=0"`
 	WriteTimeout    int    `mapstructure:"write_timeout" validate:"gte=0"`
 	ShutdownTimeout int    `mapstructure:"shutdown_timeout" validate:"gte=0"`
+
+	Maintenance MaintenanceConfig `mapstructure:"maintenance"`
+}
+
+// MaintenanceConfig holds maintenance mode configuration
+type MaintenanceConfig struct {
+	// Enabled starts the server in maintenance mode
+	Enabled bool `mapstructure:"enabled"`
+	// RetryAfterSec is sent as Retry-After on rejected requests
+	RetryAfterSec int `mapstructure:"retry_after_sec" validate:"gte=0"`
+	// AdminToken enables the /admin/maintenance toggle for requests carrying
+	// it in X-Admin-Token; empty leaves the toggle unregistered
+	AdminToken string `mapstructure:"admin_token"`
 }
 
 // DatabaseConfig holds database configuration
@@ -29,6 +42,20 @@ type DatabaseConfig struct {
 	MaxOpenConns    int    `mapstructure:"max_open_conns" validate:"gte=1"`
 	MaxIdleConns    int    `mapstructure:"max_idle_conns" validate:"gte=0"`
 	ConnMaxLifetime int    `mapstructure:"conn_max_lifetime" validate:"gte=0"`
+
+	ConnectRetry ConnectRetryConfig `mapstructure:"connect_retry"`
+}
+
+// ConnectRetryConfig holds how startup waits for the database to come up
+type ConnectRetryConfig struct {
+	// MaxAttempts is how many times to try connecting; 1 fails fast
+	MaxAttempts int `mapstructure:"max_attempts" validate:"gte=0"`
+	// InitialBackoffSec is the wait after the first failure, doubling after each (+/-20% jitter)
+	InitialBackoffSec int `mapstructure:"initial_backoff_sec" validate:"gte=0"`
+	// MaxBackoffSec caps the wait between attempts
+	MaxBackoffSec int `mapstructure:"max_backoff_sec" validate:"gte=0"`
+	// TimeoutSec bounds the total time spent connecting (0 = no limit)
+	TimeoutSec int `mapstructure:"timeout_sec" validate:"gte=0"`
 }
 
 // JWTConfig holds JWT configuration
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ErrValidation is wrapped by Unmarshal when the decoded config fails its
+// validate tags
+var ErrValidation = errors.New("config validation failed")
+
+// structValidator validates decoded configs, naming fields by their
+// mapstructure keys so errors match the config files
+var structValidator = newStructValidator()
+
+func newStructValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+	return v
+}
+
+// newDecoder creates the decoder used for every config struct
+// Duration strings such as "30s" and comma-separated lists are converted.
+func newDecoder(result any) (*mapstructure.Decoder, error) {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           result,
+		WeaklyTypedInput: true,
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			mapstructure.StringToSliceHookFunc(","),
+		),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create decoder: %w", err)
+	}
+	return decoder, nil
+}
+
+// Unmarshal merges the providers in order, later ones overriding earlier
+// ones, decodes the result into a T and checks its validate tags
+// Unlike ConfigManager.Load, a provider that fails to load is an error.
+// Every failed field is reported, e.g. "server.port: failed gt".
+func Unmarshal[T any](providers ...Provider) (*T, error) {
+	merged := make(map[string]any)
+	for _, provider := range providers {
+		data, err := provider.Load()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s config: %w", provider.Name(), err)
+		}
+		merged = mergeMaps(merged, data)
+	}
+
+	target := new(T)
+	decoder, err := newDecoder(target)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(merged); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+
+	if err := validateStruct(target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// validateStruct checks the validate tags of target, if it is a struct
+func validateStruct(target any) error {
+	if reflect.Indirect(reflect.ValueOf(target)).Kind() != reflect.Struct {
+		return nil
+	}
+
+	err := structValidator.Struct(target)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+
+	errs := make([]error, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		errs = append(errs, fmt.Errorf("%s: failed %s", fieldPath(fe.Namespace()), describeTag(fe)))
+	}
+	return fmt.Errorf("%w: %w", ErrValidation, errors.Join(errs...))
+}
+
+// fieldPath drops the root type name from a validator namespace
+func fieldPath(namespace string) string {
+	if _, path, ok := strings.Cut(namespace, "."); ok {
+		return path
+	}
+	return namespace
+}
+
+// describeTag formats the failed rule with its parameter, e.g. "gte=1"
+func describeTag(fe validator.FieldError) string {
+	if fe.Param() == "" {
+		return fe.Tag()
+	}
+	return fe.Tag() + "=" + fe.Param()
+}
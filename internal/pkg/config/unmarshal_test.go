@@ -0,0 +1,78 @@
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedSample struct {
+	Name  string `mapstructure:"name" validate:"required"`
+	Cache struct {
+		Addr    string        `mapstructure:"addr" validate:"required"`
+		Timeout time.Duration `mapstructure:"timeout" validate:"gt=0"`
+	} `mapstructure:"cache"`
+	Workers int `mapstructure:"workers" validate:"gte=1,lte=100"`
+}
+
+// failingProvider is a Provider whose Load always fails
+type failingProvider struct{}
+
+func (failingProvider) Name() string                  { return "failing" }
+func (failingProvider) Load() (map[string]any, error) { return nil, errors.New("unreachable") }
+
+func TestUnmarshal_MergesProvidersIntoNestedStruct(t *testing.T) {
+	defaults := NewDefaultProvider(map[string]any{
+		"name":    "demo",
+		"workers": 4,
+		"cache":   map[string]any{"addr": "localhost:6379", "timeout": "5s"},
+	})
+	overrides := NewDefaultProvider(map[string]any{
+		"workers": "8",
+		"cache":   map[string]any{"timeout": "1m30s"},
+	})
+
+	cfg, err := Unmarshal[typedSample](defaults, overrides)
+	require.NoError(t, err)
+
+	assert.Equal(t, "demo", cfg.Name)
+	assert.Equal(t, 8, cfg.Workers)
+	assert.Equal(t, "localhost:6379", cfg.Cache.Addr)
+	assert.Equal(t, 90*time.Second, cfg.Cache.Timeout)
+}
+
+func TestUnmarshal_ReportsEveryInvalidField(t *testing.T) {
+	_, err := Unmarshal[typedSample](NewDefaultProvider(map[string]any{
+		"workers": 0,
+		"cache":   map[string]any{"timeout": "0s"},
+	}))
+
+	require.ErrorIs(t, err, ErrValidation)
+	assert.ErrorContains(t, err, "name: failed required")
+	assert.ErrorContains(t, err, "cache.addr: failed required")
+	assert.ErrorContains(t, err, "cache.timeout: failed gt=0")
+	assert.ErrorContains(t, err, "workers: failed gte=1")
+}
+
+func TestUnmarshal_Errors(t *testing.T) {
+	t.Run("provider fails", func(t *testing.T) {
+		_, err := Unmarshal[typedSample](failingProvider{})
+		assert.ErrorContains(t, err, "failed to load failing config")
+	})
+
+	t.Run("bad duration", func(t *testing.T) {
+		_, err := Unmarshal[typedSample](NewDefaultProvider(map[string]any{
+			"cache": map[string]any{"timeout": "soon"},
+		}))
+		assert.ErrorContains(t, err, "failed to decode config")
+	})
+}
+
+func TestUnmarshal_NonStructSkipsValidation(t *testing.T) {
+	cfg, err := Unmarshal[map[string]any](NewDefaultProvider(map[string]any{"a": 1}))
+	require.NoError(t, err)
+	assert.Equal(t, 1, (*cfg)["a"])
+}
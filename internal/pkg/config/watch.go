@@ -0,0 +1,143 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long FileProvider.Watch waits after the last
+// file event before reloading, so an editor's burst of writes reloads once
+const DefaultWatchDebounce = 200 * time.Millisecond
+
+// WatchableProvider is a Provider that can report changes to its source
+type WatchableProvider interface {
+	Provider
+
+	// Watch calls onChange with the newly loaded data whenever the source
+	// changes, until ctx is done. It returns once watching has started.
+	Watch(ctx context.Context, onChange func(map[string]any)) error
+}
+
+// WithWatchDebounce sets how long Watch waits for writes to settle
+func WithWatchDebounce(d time.Duration) FileProviderOption {
+	return func(p *FileProvider) {
+		if d > 0 {
+			p.watchDebounce = d
+		}
+	}
+}
+
+// Watch reloads the config files when they change and calls onChange with
+// the merged result. The directories holding the files are watched rather
+// than the files, so saves that write a temp file and rename it over the
+// config, as most editors do, are picked up too.
+func (p *FileProvider) Watch(ctx context.Context, onChange func(map[string]any)) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	files := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, file := range p.files() {
+		file = filepath.Clean(file)
+		files[file] = true
+
+		dir := filepath.Dir(file)
+		if dirs[dir] {
+			continue
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch config directory %s: %w", dir, err)
+		}
+		dirs[dir] = true
+	}
+	if len(dirs) == 0 {
+		watcher.Close()
+		return fmt.Errorf("no config directories to watch")
+	}
+
+	go p.watchLoop(ctx, watcher, files, onChange)
+	return nil
+}
+
+// watchLoop debounces events for the config files and reloads once they settle
+func (p *FileProvider) watchLoop(ctx context.Context, watcher *fsnotify.Watcher, files map[string]bool, onChange func(map[string]any)) {
+	defer watcher.Close()
+
+	present := existingFiles(files)
+	timer := time.NewTimer(p.watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	// awaitingFile is set while a file that existed has gone missing, which
+	// is usually a save in progress; the reload waits one more period for it
+	awaitingFile := false
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if !files[filepath.Clean(event.Name)] || event.Op == fsnotify.Chmod {
+				continue
+			}
+			timer.Reset(p.watchDebounce)
+
+		case <-timer.C:
+			current := existingFiles(files)
+			if !awaitingFile && !containsAll(current, present) {
+				awaitingFile = true
+				timer.Reset(p.watchDebounce)
+				continue
+			}
+			awaitingFile = false
+			present = current
+
+			data, err := p.Load()
+			if err != nil {
+				continue
+			}
+			onChange(data)
+
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// existingFiles returns the subset of files that exist
+func existingFiles(files map[string]bool) map[string]bool {
+	existing := make(map[string]bool, len(files))
+	for file := range files {
+		if _, err := os.Stat(file); err == nil {
+			existing[file] = true
+		}
+	}
+	return existing
+}
+
+// containsAll reports whether every key of subset is in set
+func containsAll(set, subset map[string]bool) bool {
+	for key := range subset {
+		if !set[key] {
+			return false
+		}
+	}
+	return true
+}
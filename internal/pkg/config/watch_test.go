@@ -0,0 +1,145 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// changeRecorder collects the maps passed to a Watch callback
+type changeRecorder struct {
+	mu      sync.Mutex
+	changes []map[string]any
+}
+
+func (r *changeRecorder) record(data map[string]any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, data)
+}
+
+func (r *changeRecorder) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.changes)
+}
+
+func (r *changeRecorder) last() map[string]any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.changes[len(r.changes)-1]
+}
+
+// startWatch watches serviceDir with a short debounce until the test ends
+func startWatch(t *testing.T, serviceDir string) *changeRecorder {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	recorder := &changeRecorder{}
+	provider := NewFileProvider(serviceDir, WithWatchDebounce(50*time.Millisecond))
+	require.NoError(t, provider.Watch(ctx, recorder.record))
+	return recorder
+}
+
+func TestFileProvider_Watch_ReloadsMergedConfig(t *testing.T) {
+	serviceDir := setupConfigTree(t, "staging")
+	recorder := startWatch(t, serviceDir)
+
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.yaml"), `
+layer: service-base
+app:
+  shared: edited
+`)
+
+	require.Eventually(t, func() bool { return recorder.count() == 1 }, 2*time.Second, 10*time.Millisecond)
+
+	app := recorder.last()["app"].(map[string]any)
+	assert.Equal(t, "edited", app["shared"])
+	// The other layers are merged in as on startup
+	assert.Equal(t, "service-env", recorder.last()["layer"])
+	assert.Equal(t, true, app["global_base_only"])
+}
+
+func TestFileProvider_Watch_DebouncesRapidWrites(t *testing.T) {
+	serviceDir := setupConfigTree(t, "staging")
+	recorder := startWatch(t, serviceDir)
+
+	path := filepath.Join(serviceDir, "config", "config.yaml")
+	for _, value := range []string{"one", "two", "three"} {
+		writeConfigFile(t, path, "app:\n  shared: "+value+"\n")
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	require.Eventually(t, func() bool { return recorder.count() >= 1 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Equal(t, 1, recorder.count())
+	assert.Equal(t, "three", recorder.last()["app"].(map[string]any)["shared"])
+}
+
+func TestFileProvider_Watch_RenameOnSave(t *testing.T) {
+	serviceDir := setupConfigTree(t, "staging")
+	recorder := startWatch(t, serviceDir)
+
+	// Editors write a temp file and rename it over the original
+	path := filepath.Join(serviceDir, "config", "config.yaml")
+	tmp := path + ".swp"
+	writeConfigFile(t, tmp, "app:\n  shared: renamed\n")
+	require.NoError(t, os.Rename(tmp, path))
+
+	require.Eventually(t, func() bool { return recorder.count() == 1 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "renamed", recorder.last()["app"].(map[string]any)["shared"])
+
+	// The file is still watched after being replaced
+	writeConfigFile(t, path, "app:\n  shared: again\n")
+	require.Eventually(t, func() bool { return recorder.count() == 2 }, 2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "again", recorder.last()["app"].(map[string]any)["shared"])
+}
+
+func TestFileProvider_Watch_IgnoresOtherFiles(t *testing.T) {
+	serviceDir := setupConfigTree(t, "staging")
+	recorder := startWatch(t, serviceDir)
+
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "notes.txt"), "not config")
+	time.Sleep(200 * time.Millisecond)
+
+	assert.Zero(t, recorder.count())
+}
+
+func TestManager_Watch_ReloadsAndNotifies(t *testing.T) {
+	serviceDir := setupConfigTree(t, "staging")
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.staging.yaml"), "logger:\n  level: info\n")
+
+	m := New(
+		WithProvider(NewDefaultProvider(getDefaultConfig())),
+		WithProvider(NewFileProvider(serviceDir, WithWatchDebounce(50*time.Millisecond))),
+	)
+	require.NoError(t, m.Load())
+
+	changed := make(chan struct{}, 1)
+	require.NoError(t, m.Watch(func() { changed <- struct{}{} }))
+	t.Cleanup(func() { close(m.(*manager).watchStop) })
+	assert.Error(t, m.Watch(nil), "a second watch is rejected")
+
+	writeConfigFile(t, filepath.Join(serviceDir, "config", "config.staging.yaml"), "logger:\n  level: debug\n")
+
+	select {
+	case <-changed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("config change was not reported")
+	}
+	assert.Equal(t, "debug", m.Get("logger.level"))
+}
+
+func TestManager_Watch_NeedsWatchableProvider(t *testing.T) {
+	m := New(WithProvider(NewDefaultProvider(nil)))
+	assert.Error(t, m.Watch(func() {}))
+}
@@ -0,0 +1,110 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testKeys struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        int64     `json:"id"`
+}
+
+func TestCodec_RoundTrip(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	want := testKeys{CreatedAt: time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC), ID: 42}
+
+	token, err := codec.Encode(want)
+	require.NoError(t, err)
+	assert.NotContains(t, token, "=", "token should be URL-safe without padding")
+
+	var got testKeys
+	require.NoError(t, codec.Decode(token, &got))
+	assert.True(t, want.CreatedAt.Equal(got.CreatedAt))
+	assert.Equal(t, want.ID, got.ID)
+}
+
+func TestCodec_RoundTripSlice(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+
+	token, err := codec.Encode([]interface{}{"widget", 9.5, 7})
+	require.NoError(t, err)
+
+	var got []interface{}
+	require.NoError(t, codec.Decode(token, &got))
+	assert.Equal(t, []interface{}{"widget", 9.5, float64(7)}, got)
+}
+
+func TestCodec_DetectsTampering(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	token, err := codec.Encode(testKeys{ID: 42})
+	require.NoError(t, err)
+	encoded, sig, _ := strings.Cut(token, ".")
+
+	// Re-encode the payload with a different ID but keep the original signature
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"v":1,"k":{"id":1}}`)) + "." + sig
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{name: "edited payload", token: forged},
+		{name: "edited signature", token: encoded + "." + base64.RawURLEncoding.EncodeToString([]byte("not-the-signature"))},
+		{name: "signed with another secret", token: mustEncode(t, NewCodec([]byte("other")), testKeys{ID: 42})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got testKeys
+			assert.ErrorIs(t, codec.Decode(tt.token, &got), ErrTampered)
+			assert.Zero(t, got.ID)
+		})
+	}
+}
+
+func TestCodec_Malformed(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+
+	for _, token := range []string{"", "abc", "abc.", ".abc", "abc.!!!"} {
+		t.Run(token, func(t *testing.T) {
+			var got testKeys
+			assert.ErrorIs(t, codec.Decode(token, &got), ErrInvalidCursor)
+		})
+	}
+}
+
+func TestCodec_WrongKeyShape(t *testing.T) {
+	codec := NewCodec([]byte("secret"))
+	token := mustEncode(t, codec, []string{"not", "an", "object"})
+
+	var got testKeys
+	assert.ErrorIs(t, codec.Decode(token, &got), ErrInvalidCursor)
+}
+
+func TestCodec_VersionMismatch(t *testing.T) {
+	v1 := NewCodec([]byte("secret"))
+	v2 := NewCodec([]byte("secret"), WithVersion(2))
+
+	token := mustEncode(t, v1, testKeys{ID: 42})
+
+	var got testKeys
+	err := v2.Decode(token, &got)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+	assert.Zero(t, got.ID)
+
+	// The same version still decodes
+	require.NoError(t, v1.Decode(token, &got))
+	assert.Equal(t, int64(42), got.ID)
+}
+
+func mustEncode(t *testing.T, codec *Codec, keys interface{}) string {
+	t.Helper()
+	token, err := codec.Encode(keys)
+	require.NoError(t, err)
+	return token
+}
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
@@ -43,12 +44,13 @@ func NewDatabase(cfg *config.Config, log *logger.Logger) (*Database, error) {
 		},
 	)
 
-	// Open connection
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLog,
-	})
+	// Open connection, waiting for the database to come up if configured
+	connect := func(ctx context.Context) (*gorm.DB, error) {
+		return openDatabase(ctx, dsn, gormLog)
+	}
+	db, err := connectWithRetry(context.Background(), connect, newRetryPolicy(cfg.Database.ConnectRetry), log)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to database: %w", err)
+		return nil, err
 	}
 
 	// Get underlying SQL DB
@@ -62,11 +64,6 @@ func NewDatabase(cfg *config.Config, log *logger.Logger) (*Database, error) {
 	sqlDB.SetMaxIdleConns(cfg.Database.MaxIdleConns)
 	sqlDB.SetConnMaxLifetime(time.Duration(cfg.Database.ConnMaxLifetime) * time.Second)
 
-	// Test connection
-	if err := sqlDB.Ping(); err != nil {
-		return nil, fmt.Errorf("failed to ping database: %w", err)
-	}
-
 	log.Info("Database connection established",
 		zap.String("host", cfg.Database.Host),
 		zap.Int("port", cfg.Database.Port),
@@ -76,6 +73,30 @@ func NewDatabase(cfg *config.Config, log *logger.Logger) (*Database, error) {
 	return &Database{DB: db}, nil
 }
 
+// openDatabase opens a connection and pings it, closing it again on failure
+func openDatabase(ctx context.Context, dsn string, gormLog gormlogger.Interface) (*gorm.DB, error) {
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger:               gormLog,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	// Test connection
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return db, nil
+}
+
 // gormLogWriter implements gorm logger.Writer interface
 type gormLogWriter struct {
 	logger *logger.Logger
@@ -100,6 +121,32 @@ func (d *Database) Begin() *gorm.DB {
 	return d.DB.Begin()
 }
 
+// txKey is the context key under which WithinTransaction passes its transaction
+type txKey struct{}
+
+// WithinTransaction runs fn in a transaction, committing it when fn returns
+// nil and rolling it back when fn returns an error or panics. fn gets a
+// context carrying the transaction: WithinTransaction called with that
+// context, on any Database, runs in a savepoint of it instead, so a failed
+// inner call only undoes its own writes and the caller decides whether the
+// outer transaction goes on.
+func (d *Database) WithinTransaction(ctx context.Context, fn func(ctx context.Context, tx *gorm.DB) error) error {
+	return d.WithContext(ctx).DB.Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx), tx)
+	})
+}
+
+// WithContext returns a Database whose queries run under ctx, so a
+// cancelled or timed-out request aborts them. When ctx carries a
+// transaction from WithinTransaction, the queries run in it.
+func (d *Database) WithContext(ctx context.Context) *Database {
+	db := d.DB
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		db = tx
+	}
+	return &Database{DB: db.WithContext(ctx)}
+}
+
 // RunMigrations runs database migrations (GORM AutoMigrate)
 // Deprecated: Use migration package for version-controlled migrations
 func (d *Database) RunMigrations(models ...interface{}) error {
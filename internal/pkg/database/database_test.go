@@ -0,0 +1,271 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// recordingConn is a driver.Conn that records every statement, BEGIN,
+// COMMIT and ROLLBACK included, and answers from scripted results
+type recordingConn struct {
+	// answer scripts the rows of each query; nil answers with no rows
+	answer func(query string, args []driver.NamedValue) (driver.Rows, error)
+	// rowsAffected is reported for every statement that is not a query
+	rowsAffected int64
+
+	statements []string
+	args       [][]driver.NamedValue
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.record("BEGIN", nil)
+	return c, nil
+}
+
+func (c *recordingConn) Commit() error {
+	c.record("COMMIT", nil)
+	return nil
+}
+
+func (c *recordingConn) Rollback() error {
+	c.record("ROLLBACK", nil)
+	return nil
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	return driver.RowsAffected(c.rowsAffected), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	if c.answer == nil {
+		return &scriptedRows{columns: []string{"id"}}, nil
+	}
+	return c.answer(query, args)
+}
+
+func (c *recordingConn) record(query string, args []driver.NamedValue) {
+	c.statements = append(c.statements, query)
+	c.args = append(c.args, args)
+}
+
+// last returns the most recent statement and its arguments
+func (c *recordingConn) last() (string, []driver.NamedValue) {
+	if len(c.statements) == 0 {
+		return "", nil
+	}
+	return c.statements[len(c.statements)-1], c.args[len(c.args)-1]
+}
+
+type recordingConnector struct{ conn *recordingConn }
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c recordingConnector) Driver() driver.Driver                        { return nil }
+
+// scriptedRows is a fixed result set
+type scriptedRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *scriptedRows) Columns() []string { return r.columns }
+func (r *scriptedRows) Close() error      { return nil }
+func (r *scriptedRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// itemRows is an id, name result set with one row per id
+func itemRows(ids ...int64) *scriptedRows {
+	rows := &scriptedRows{columns: []string{"id", "name"}}
+	for _, id := range ids {
+		rows.values = append(rows.values, []driver.Value{id, "item"})
+	}
+	return rows
+}
+
+// answerItems answers every query with the given ids
+func answerItems(ids ...int64) func(string, []driver.NamedValue) (driver.Rows, error) {
+	return func(string, []driver.NamedValue) (driver.Rows, error) {
+		return itemRows(ids...), nil
+	}
+}
+
+// answerCount answers every query with a single count
+func answerCount(count int64) func(string, []driver.NamedValue) (driver.Rows, error) {
+	return func(string, []driver.NamedValue) (driver.Rows, error) {
+		return &scriptedRows{columns: []string{"count"}, values: [][]driver.Value{{count}}}, nil
+	}
+}
+
+func newTestDB(t *testing.T, conn *recordingConn) *Database {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(recordingConnector{conn: conn})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing:   true,
+		SkipDefaultTransaction: true,
+		Logger:                 gormlogger.Discard,
+	})
+	require.NoError(t, err)
+	return &Database{DB: db}
+}
+
+type auditRow struct {
+	ID     uint
+	Action string
+}
+
+// kinds reduces statements to their leading keyword, keeping savepoint names
+func kinds(statements []string) []string {
+	out := make([]string, len(statements))
+	for i, stmt := range statements {
+		stmt = strings.TrimSpace(stmt)
+		if strings.Contains(stmt, "SAVEPOINT") {
+			out[i] = stmt
+			continue
+		}
+		out[i], _, _ = strings.Cut(stmt, " ")
+	}
+	return out
+}
+
+func TestWithinTransaction_Commits(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(1)}
+	db := newTestDB(t, conn)
+	items := NewBaseRepository[pagedItem](db)
+	audits := NewBaseRepository[auditRow](db)
+
+	err := db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := items.WithTx(tx).Insert(&pagedItem{Name: "widget"}); err != nil {
+			return err
+		}
+		return audits.WithTx(tx).Insert(&auditRow{Action: "created"})
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"BEGIN", "INSERT", "INSERT", "COMMIT"}, kinds(conn.statements))
+}
+
+func TestWithinTransaction_RollsBackOnError(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(1)}
+	db := newTestDB(t, conn)
+	items := NewBaseRepository[pagedItem](db)
+
+	errAudit := errors.New("audit failed")
+	err := db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := items.WithTx(tx).Insert(&pagedItem{Name: "widget"}); err != nil {
+			return err
+		}
+		return errAudit
+	})
+	assert.ErrorIs(t, err, errAudit)
+
+	assert.Equal(t, []string{"BEGIN", "INSERT", "ROLLBACK"}, kinds(conn.statements))
+}
+
+func TestWithinTransaction_RollsBackOnPanic(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(1)}
+	db := newTestDB(t, conn)
+
+	assert.Panics(t, func() {
+		_ = db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+			panic("boom")
+		})
+	})
+
+	assert.Equal(t, []string{"BEGIN", "ROLLBACK"}, kinds(conn.statements))
+}
+
+func TestWithinTransaction_NestedUsesSavepoint(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(1)}
+	db := newTestDB(t, conn)
+	items := NewBaseRepository[pagedItem](db)
+	audits := NewBaseRepository[auditRow](db)
+
+	// The inner call is on another Database, as in a separate repository:
+	// the context alone makes it join the outer transaction
+	other := &Database{DB: db.DB}
+
+	errInner := errors.New("inner failed")
+	err := db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := items.WithTx(tx).Insert(&pagedItem{Name: "widget"}); err != nil {
+			return err
+		}
+
+		// The inner failure only rolls back to its savepoint, and the outer
+		// transaction carries on
+		innerErr := other.WithinTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			if err := audits.WithTx(tx).Insert(&auditRow{Action: "created"}); err != nil {
+				return err
+			}
+			return errInner
+		})
+		assert.ErrorIs(t, innerErr, errInner)
+		return nil
+	})
+	require.NoError(t, err)
+
+	statements := kinds(conn.statements)
+	require.Len(t, statements, 6)
+	assert.Equal(t, []string{"BEGIN", "INSERT"}, statements[:2])
+	assert.True(t, strings.HasPrefix(statements[2], "SAVEPOINT "))
+	assert.Equal(t, "INSERT", statements[3])
+	assert.True(t, strings.HasPrefix(statements[4], "ROLLBACK TO SAVEPOINT "))
+	assert.Equal(t, "COMMIT", statements[5], "only one real transaction is committed")
+}
+
+func TestWithinTransaction_SeparateContextsAreSeparateTransactions(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(1)}
+	db := newTestDB(t, conn)
+
+	for i := 0; i < 2; i++ {
+		require.NoError(t, db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+			return nil
+		}))
+	}
+
+	assert.Equal(t, []string{"BEGIN", "COMMIT", "BEGIN", "COMMIT"}, kinds(conn.statements))
+}
+
+func TestWithContext_JoinsTransactionFromContext(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(1)}
+	db := newTestDB(t, conn)
+
+	inTx := func(db *Database) bool {
+		_, ok := db.Statement.ConnPool.(gorm.TxCommitter)
+		return ok
+	}
+
+	assert.False(t, inTx(db.WithContext(context.Background())))
+	require.NoError(t, db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		scoped := db.WithContext(ctx)
+		assert.True(t, inTx(scoped), "queries under the context run in its transaction")
+		assert.Equal(t, ctx, scoped.Statement.Context)
+		return nil
+	}))
+}
@@ -0,0 +1,69 @@
+package database_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myapp/internal/pkg/database"
+
+	"gorm.io/gorm"
+)
+
+type Product struct {
+	ID    uint
+	Name  string
+	Price float64
+}
+
+type AuditLog struct {
+	ID       uint
+	Action   string
+	EntityID uint
+}
+
+// Example_withinTransaction creates a product and its audit row together:
+// if either insert fails, neither row is kept.
+func Example_withinTransaction() {
+	var db *database.Database // provided by database.Module
+	products := database.NewBaseRepository[Product](db)
+	audits := database.NewBaseRepository[AuditLog](db)
+
+	product := &Product{Name: "Keyboard", Price: 49.9}
+	err := db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := products.WithTx(tx).Insert(product); err != nil {
+			return err
+		}
+
+		audit := &AuditLog{Action: "product.created", EntityID: product.ID}
+		if err := audits.WithTx(tx).Insert(audit); err != nil {
+			return err // the product insert is rolled back too
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Println("create product:", err)
+	}
+}
+
+// Example_nestedTransaction runs an optional step in a savepoint, so its
+// failure is logged without losing the outer transaction's work. The step
+// could be any code given the context, such as another service's repository.
+func Example_nestedTransaction() {
+	var db *database.Database // provided by database.Module
+	products := database.NewBaseRepository[Product](db)
+
+	_ = db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		if err := products.WithTx(tx).Insert(&Product{Name: "Mouse"}); err != nil {
+			return err
+		}
+
+		err := db.WithinTransaction(ctx, func(ctx context.Context, tx *gorm.DB) error {
+			return errors.New("optional step failed")
+		})
+		if err != nil {
+			fmt.Println("skipped:", err) // rolled back to the savepoint only
+		}
+		return nil
+	})
+}
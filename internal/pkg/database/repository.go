@@ -5,8 +5,13 @@ import (
 	"fmt"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrVersionConflict is returned by UpdateFieldsVersioned when the entity
+// exists but its version changed since the caller read it
+var ErrVersionConflict = errors.New("version conflict")
+
 // BaseRepository provides common CRUD operations for entities
 type BaseRepository[T any] struct {
 	db *gorm.DB
@@ -74,6 +79,36 @@ func (r *BaseRepository[T]) UpdateFields(id uint, updates map[string]interface{}
 	return nil
 }
 
+// UpdateFieldsVersioned updates specific fields of an entity only if its
+// version column still equals version, and increments version in the same
+// statement. This guards read-modify-write updates against lost updates:
+// when another writer got there first it returns ErrVersionConflict, and
+// gorm.ErrRecordNotFound when the entity doesn't exist.
+func (r *BaseRepository[T]) UpdateFieldsVersioned(id uint, version int64, updates map[string]interface{}) error {
+	versioned := make(map[string]interface{}, len(updates)+1)
+	for field, value := range updates {
+		versioned[field] = value
+	}
+	versioned["version"] = gorm.Expr("version + 1")
+
+	result := r.db.Model(new(T)).Where("id = ? AND version = ?", id, version).Updates(versioned)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update fields: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	exists, err := r.Exists(map[string]interface{}{"id": id})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return ErrVersionConflict
+	}
+	return gorm.ErrRecordNotFound
+}
+
 // GetByID retrieves an entity by its ID
 func (r *BaseRepository[T]) GetByID(id uint) (*T, error) {
 	var entity T
@@ -142,7 +177,92 @@ func (r *BaseRepository[T]) GetWhere(conditions map[string]interface{}, limit, o
 	return entities, nil
 }
 
+// SortOrder is the direction of a keyset page
+type SortOrder string
+
+const (
+	// Ascending pages from the smallest cursor value up
+	Ascending SortOrder = "asc"
+	// Descending pages from the largest cursor value down
+	Descending SortOrder = "desc"
+)
+
+// GetAfter retrieves up to limit entities whose cursorField is greater than
+// cursorValue, in ascending order. A nil cursorValue returns the first page.
+// Unlike GetAll's offset, the page stays stable while rows are written, and
+// it costs the same at any depth as long as cursorField is indexed. The field
+// must also be unique, such as id, or rows sharing a value across a page
+// boundary are skipped.
+func (r *BaseRepository[T]) GetAfter(cursorField string, cursorValue any, limit int) ([]*T, error) {
+	return r.GetAfterOrdered(cursorField, cursorValue, limit, Ascending)
+}
+
+// GetAfterOrdered is GetAfter with a sort order; in Descending order it
+// returns the entities whose cursorField is less than cursorValue.
+func (r *BaseRepository[T]) GetAfterOrdered(cursorField string, cursorValue any, limit int, order SortOrder) ([]*T, error) {
+	if order != Ascending && order != Descending {
+		return nil, fmt.Errorf("invalid sort order %q", order)
+	}
+
+	column := clause.Column{Name: cursorField}
+	query := r.db.Model(new(T)).Order(clause.OrderByColumn{Column: column, Desc: order == Descending})
+
+	if cursorValue != nil {
+		if order == Descending {
+			query = query.Where(clause.Lt{Column: column, Value: cursorValue})
+		} else {
+			query = query.Where(clause.Gt{Column: column, Value: cursorValue})
+		}
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var entities []*T
+	if err := query.Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("failed to get entities after cursor: %w", err)
+	}
+	return entities, nil
+}
+
+// NextCursor returns the cursor value for the page after page, read from its
+// last entity with key. ok is false when page is shorter than limit, meaning
+// there are no more entities.
+func NextCursor[T any, K any](page []*T, limit int, key func(*T) K) (next K, ok bool) {
+	if len(page) == 0 || len(page) < limit {
+		return next, false
+	}
+	return key(page[len(page)-1]), true
+}
+
+// EachBatch calls fn with every entity, batchSize at a time in primary key
+// order, so a whole table can be processed without loading it into memory.
+// It stops at the first error from fn and returns that error unwrapped.
+// batchSize must be positive.
+func (r *BaseRepository[T]) EachBatch(batchSize int, fn func(batch []*T) error) error {
+	// FindInBatches panics on a batch size of zero, and a negative one drops
+	// the LIMIT and loads the whole table at once
+	if batchSize <= 0 {
+		return fmt.Errorf("invalid batch size %d", batchSize)
+	}
+
+	var batch []*T
+	var fnErr error
+	err := r.db.Model(new(T)).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		fnErr = fn(batch)
+		return fnErr
+	}).Error
+	if fnErr != nil {
+		return fnErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read batch: %w", err)
+	}
+	return nil
+}
+
 // DeleteByID deletes an entity by its ID
+// Models with a gorm.DeletedAt field are soft-deleted; see HardDelete and Restore.
 func (r *BaseRepository[T]) DeleteByID(id uint) error {
 	result := r.db.Delete(new(T), id)
 	if result.Error != nil {
@@ -176,6 +296,64 @@ func (r *BaseRepository[T]) DeleteWhere(conditions map[string]interface{}) error
 	return nil
 }
 
+// GetByIDWithDeleted retrieves an entity by its ID, including a soft-deleted one
+func (r *BaseRepository[T]) GetByIDWithDeleted(id uint) (*T, error) {
+	var entity T
+	err := r.db.Unscoped().First(&entity, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, gorm.ErrRecordNotFound
+		}
+		return nil, fmt.Errorf("failed to get entity: %w", err)
+	}
+	return &entity, nil
+}
+
+// ListWithDeleted retrieves entities with pagination, including soft-deleted ones
+func (r *BaseRepository[T]) ListWithDeleted(limit, offset int) ([]*T, error) {
+	var entities []*T
+	query := r.db.Unscoped().Model(new(T))
+
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if offset > 0 {
+		query = query.Offset(offset)
+	}
+
+	if err := query.Find(&entities).Error; err != nil {
+		return nil, fmt.Errorf("failed to list entities: %w", err)
+	}
+	return entities, nil
+}
+
+// Restore undoes a soft delete by clearing deleted_at
+// It returns gorm.ErrRecordNotFound if no soft-deleted entity has the ID.
+func (r *BaseRepository[T]) Restore(id uint) error {
+	result := r.db.Unscoped().Model(new(T)).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return fmt.Errorf("failed to restore entity: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// HardDelete permanently deletes an entity by its ID, even if soft-deleted
+func (r *BaseRepository[T]) HardDelete(id uint) error {
+	result := r.db.Unscoped().Delete(new(T), id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to hard delete entity: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 // Count counts entities matching conditions
 func (r *BaseRepository[T]) Count(conditions map[string]interface{}) (int64, error) {
 	var count int64
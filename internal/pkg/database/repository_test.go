@@ -0,0 +1,267 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type pagedItem struct {
+	ID   uint
+	Name string
+}
+
+type archivedItem struct {
+	ID        uint
+	Name      string
+	DeletedAt gorm.DeletedAt
+}
+
+func newPagedRepository(t *testing.T, conn *recordingConn) *BaseRepository[pagedItem] {
+	t.Helper()
+	return NewBaseRepository[pagedItem](newTestDB(t, conn))
+}
+
+func TestBaseRepository_GetAfter(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(11, 12, 13)}
+	repo := newPagedRepository(t, conn)
+
+	page, err := repo.GetAfter("id", 10, 3)
+	require.NoError(t, err)
+
+	query, args := conn.last()
+	assert.Equal(t, `SELECT * FROM "paged_items" WHERE "id" > $1 ORDER BY "id" LIMIT 3`, query)
+	require.Len(t, args, 1)
+	assert.EqualValues(t, 10, args[0].Value)
+
+	next, ok := NextCursor(page, 3, func(item *pagedItem) uint { return item.ID })
+	assert.True(t, ok)
+	assert.Equal(t, uint(13), next)
+}
+
+func TestBaseRepository_GetAfterOrdered(t *testing.T) {
+	t.Run("descending", func(t *testing.T) {
+		conn := &recordingConn{}
+		repo := newPagedRepository(t, conn)
+
+		_, err := repo.GetAfterOrdered("id", 10, 5, Descending)
+		require.NoError(t, err)
+		query, _ := conn.last()
+		assert.Equal(t, `SELECT * FROM "paged_items" WHERE "id" < $1 ORDER BY "id" DESC LIMIT 5`, query)
+	})
+
+	t.Run("first page", func(t *testing.T) {
+		conn := &recordingConn{}
+		repo := newPagedRepository(t, conn)
+
+		_, err := repo.GetAfterOrdered("id", nil, 5, Descending)
+		require.NoError(t, err)
+		query, _ := conn.last()
+		assert.Equal(t, `SELECT * FROM "paged_items" ORDER BY "id" DESC LIMIT 5`, query)
+	})
+
+	t.Run("invalid order", func(t *testing.T) {
+		repo := newPagedRepository(t, &recordingConn{})
+		_, err := repo.GetAfterOrdered("id", nil, 5, SortOrder("sideways"))
+		assert.ErrorContains(t, err, "invalid sort order")
+	})
+}
+
+func TestNextCursor_LastPage(t *testing.T) {
+	key := func(item *pagedItem) uint { return item.ID }
+
+	_, ok := NextCursor([]*pagedItem{{ID: 1}, {ID: 2}}, 3, key)
+	assert.False(t, ok, "a short page is the last one")
+
+	_, ok = NextCursor([]*pagedItem{}, 3, key)
+	assert.False(t, ok)
+}
+
+func TestBaseRepository_SoftDelete(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 1}
+	repo := NewBaseRepository[archivedItem](newTestDB(t, conn))
+
+	require.NoError(t, repo.DeleteByID(7))
+	query, args := conn.last()
+	assert.Equal(t, `UPDATE "archived_items" SET "deleted_at"=$1 WHERE "archived_items"."id" = $2 AND "archived_items"."deleted_at" IS NULL`, query)
+	assert.IsType(t, time.Time{}, args[0].Value)
+
+	require.NoError(t, repo.Restore(7))
+	query, args = conn.last()
+	assert.Equal(t, `UPDATE "archived_items" SET "deleted_at"=$1 WHERE id = $2 AND deleted_at IS NOT NULL`, query)
+	assert.Nil(t, args[0].Value)
+
+	require.NoError(t, repo.HardDelete(7))
+	query, _ = conn.last()
+	assert.Equal(t, `DELETE FROM "archived_items" WHERE "archived_items"."id" = $1`, query)
+}
+
+func TestBaseRepository_RestoreMissing(t *testing.T) {
+	repo := NewBaseRepository[archivedItem](newTestDB(t, &recordingConn{rowsAffected: 0}))
+
+	assert.ErrorIs(t, repo.Restore(7), gorm.ErrRecordNotFound)
+	assert.ErrorIs(t, repo.HardDelete(7), gorm.ErrRecordNotFound)
+}
+
+func TestBaseRepository_WithDeleted(t *testing.T) {
+	conn := &recordingConn{answer: answerItems(7)}
+	repo := NewBaseRepository[archivedItem](newTestDB(t, conn))
+
+	item, err := repo.GetByIDWithDeleted(7)
+	require.NoError(t, err)
+	assert.Equal(t, uint(7), item.ID)
+	query, _ := conn.last()
+	assert.NotContains(t, query, "deleted_at")
+
+	_, err = repo.ListWithDeleted(10, 20)
+	require.NoError(t, err)
+	query, _ = conn.last()
+	assert.Equal(t, `SELECT * FROM "archived_items" LIMIT 10 OFFSET 20`, query)
+
+	// The scoped queries still hide soft-deleted rows
+	_, err = repo.GetAll(10, 0)
+	require.NoError(t, err)
+	query, _ = conn.last()
+	assert.Contains(t, query, `"archived_items"."deleted_at" IS NULL`)
+}
+
+var limitPattern = regexp.MustCompile(`LIMIT (\d+)`)
+
+// answerTable serves keyset batches from a table of ids 1..size, honouring
+// the "id" > $1 condition and LIMIT of each query
+func answerTable(size int64) func(string, []driver.NamedValue) (driver.Rows, error) {
+	return func(query string, args []driver.NamedValue) (driver.Rows, error) {
+		var after int64
+		if len(args) > 0 {
+			after = args[0].Value.(int64)
+		}
+		match := limitPattern.FindStringSubmatch(query)
+		if match == nil {
+			return nil, fmt.Errorf("unbounded query: %s", query)
+		}
+		limit, _ := strconv.ParseInt(match[1], 10, 64)
+
+		var ids []int64
+		for id := after + 1; id <= size && id <= after+limit; id++ {
+			ids = append(ids, id)
+		}
+		return itemRows(ids...), nil
+	}
+}
+
+func TestBaseRepository_EachBatch(t *testing.T) {
+	conn := &recordingConn{answer: answerTable(2500)}
+	repo := newPagedRepository(t, conn)
+
+	seen := make(map[uint]int)
+	var sizes []int
+	err := repo.EachBatch(500, func(batch []*pagedItem) error {
+		sizes = append(sizes, len(batch))
+		for _, item := range batch {
+			seen[item.ID]++
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{500, 500, 500, 500, 500}, sizes)
+	require.Len(t, seen, 2500)
+	for id := uint(1); id <= 2500; id++ {
+		assert.Equal(t, 1, seen[id], "row %d", id)
+	}
+
+	// Batches follow the primary key, and an empty batch ends the scan
+	assert.Equal(t, `SELECT * FROM "paged_items" ORDER BY "paged_items"."id" LIMIT 500`, conn.statements[0])
+	assert.Contains(t, conn.statements[1], `WHERE "paged_items"."id" > $1`)
+	assert.Len(t, conn.statements, 6)
+}
+
+func TestBaseRepository_EachBatchStopsOnError(t *testing.T) {
+	conn := &recordingConn{answer: answerTable(2500)}
+	repo := newPagedRepository(t, conn)
+
+	errStop := errors.New("export failed")
+	batches := 0
+	err := repo.EachBatch(500, func(batch []*pagedItem) error {
+		batches++
+		if batches == 2 {
+			return errStop
+		}
+		return nil
+	})
+
+	assert.ErrorIs(t, err, errStop)
+	assert.Equal(t, 2, batches)
+	assert.Len(t, conn.statements, 2, "no batch is read after fn fails")
+}
+
+func TestBaseRepository_EachBatchRejectsInvalidBatchSize(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		conn := &recordingConn{answer: answerTable(10)}
+		repo := newPagedRepository(t, conn)
+
+		called := false
+		err := repo.EachBatch(size, func(batch []*pagedItem) error {
+			called = true
+			return nil
+		})
+
+		assert.ErrorContains(t, err, "invalid batch size", "size %d", size)
+		assert.False(t, called, "size %d", size)
+		assert.Empty(t, conn.statements, "size %d", size)
+	}
+}
+
+type versionedItem struct {
+	ID      uint
+	Name    string
+	Version int64
+}
+
+func newVersionedRepository(t *testing.T, conn *recordingConn) *BaseRepository[versionedItem] {
+	t.Helper()
+	return NewBaseRepository[versionedItem](newTestDB(t, conn))
+}
+
+func TestBaseRepository_UpdateFieldsVersioned(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 1}
+	repo := newVersionedRepository(t, conn)
+
+	require.NoError(t, repo.UpdateFieldsVersioned(7, 3, map[string]interface{}{"name": "renamed"}))
+
+	require.Len(t, conn.statements, 1)
+	assert.Equal(t, `UPDATE "versioned_items" SET "name"=$1,"version"=version + 1 WHERE id = $2 AND version = $3`, conn.statements[0])
+	args := conn.args[0]
+	require.Len(t, args, 3)
+	assert.EqualValues(t, 7, args[1].Value)
+	assert.EqualValues(t, 3, args[2].Value)
+}
+
+func TestBaseRepository_UpdateFieldsVersionedConflict(t *testing.T) {
+	t.Run("stale version", func(t *testing.T) {
+		conn := &recordingConn{rowsAffected: 0, answer: answerCount(1)}
+		repo := newVersionedRepository(t, conn)
+
+		err := repo.UpdateFieldsVersioned(7, 3, map[string]interface{}{"name": "renamed"})
+		assert.ErrorIs(t, err, ErrVersionConflict)
+		require.Len(t, conn.statements, 2)
+		assert.Contains(t, conn.statements[1], "SELECT count(*)")
+	})
+
+	t.Run("missing entity", func(t *testing.T) {
+		conn := &recordingConn{rowsAffected: 0, answer: answerCount(0)}
+		repo := newVersionedRepository(t, conn)
+
+		err := repo.UpdateFieldsVersioned(7, 3, map[string]interface{}{"name": "renamed"})
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+		assert.NotErrorIs(t, err, ErrVersionConflict)
+	})
+}
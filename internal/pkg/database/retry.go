@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"myapp/internal/pkg/config"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/retry"
+
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// connectFunc opens and verifies one database connection
+type connectFunc func(ctx context.Context) (*gorm.DB, error)
+
+// retryPolicy is the connect retry config as a shared retry.Policy, plus
+// the bound on the total time spent connecting
+type retryPolicy struct {
+	backoff retry.Policy
+	timeout time.Duration
+}
+
+// newRetryPolicy converts the config; zero attempts means a single attempt.
+// Waits grow exponentially with jitter, so instances starting together
+// don't retry in step.
+func newRetryPolicy(cfg config.ConnectRetryConfig) retryPolicy {
+	maxAttempts := max(cfg.MaxAttempts, 1)
+	initialBackoff := time.Duration(cfg.InitialBackoffSec) * time.Second
+	maxBackoff := max(time.Duration(cfg.MaxBackoffSec)*time.Second, initialBackoff)
+
+	return retryPolicy{
+		backoff: retry.ExponentialBackoff(initialBackoff, maxBackoff, true, maxAttempts),
+		timeout: time.Duration(cfg.TimeoutSec) * time.Second,
+	}
+}
+
+// connectWithRetry calls connect until it succeeds, attempts run out or the
+// total timeout passes, waiting with exponential backoff in between.
+// A wait that would overrun the timeout is not started.
+func connectWithRetry(ctx context.Context, connect connectFunc, policy retryPolicy, log *logger.Logger) (*gorm.DB, error) {
+	if policy.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, policy.timeout)
+		defer cancel()
+	}
+
+	var (
+		lastErr error
+		attempt int
+	)
+	for attempt = 1; ; attempt++ {
+		db, err := connect(ctx)
+		if err == nil {
+			return db, nil
+		}
+		lastErr = err
+
+		if !policy.backoff.Allow(attempt) {
+			break
+		}
+
+		wait := policy.backoff.Delay(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			break
+		}
+
+		log.Warn("Database not ready, retrying",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", policy.backoff.MaxAttempts),
+			zap.Duration("backoff", wait),
+			zap.Error(err),
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("gave up connecting to database after %d attempts: %w (last error: %v)",
+				attempt, ctx.Err(), lastErr)
+		}
+	}
+
+	if attempt == 1 {
+		return nil, lastErr
+	}
+	return nil, fmt.Errorf("failed to connect to database after %d attempts: %w", attempt, lastErr)
+}
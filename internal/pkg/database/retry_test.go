@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/config"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+var errNotReady = errors.New("connection refused")
+
+// stubConnector fails the first failures connects, then succeeds
+type stubConnector struct {
+	failures int
+	calls    int
+}
+
+func (c *stubConnector) connect(ctx context.Context) (*gorm.DB, error) {
+	c.calls++
+	if c.calls <= c.failures {
+		return nil, errNotReady
+	}
+	return &gorm.DB{}, nil
+}
+
+func testLogger() *logger.Logger {
+	return &logger.Logger{Logger: zap.NewNop()}
+}
+
+func TestConnectWithRetry_EventuallyConnects(t *testing.T) {
+	stub := &stubConnector{failures: 3}
+	policy := retryPolicy{backoff: retry.ExponentialBackoff(time.Millisecond, 5*time.Millisecond, false, 5)}
+
+	db, err := connectWithRetry(context.Background(), stub.connect, policy, testLogger())
+	require.NoError(t, err)
+	assert.NotNil(t, db)
+	assert.Equal(t, 4, stub.calls)
+}
+
+func TestConnectWithRetry_AttemptsExhausted(t *testing.T) {
+	stub := &stubConnector{failures: 10}
+	policy := retryPolicy{backoff: retry.ExponentialBackoff(time.Millisecond, time.Millisecond, false, 3)}
+
+	_, err := connectWithRetry(context.Background(), stub.connect, policy, testLogger())
+	require.ErrorIs(t, err, errNotReady)
+	assert.Contains(t, err.Error(), "after 3 attempts")
+	assert.Equal(t, 3, stub.calls)
+}
+
+func TestConnectWithRetry_FailFast(t *testing.T) {
+	stub := &stubConnector{failures: 1}
+
+	_, err := connectWithRetry(context.Background(), stub.connect, newRetryPolicy(config.ConnectRetryConfig{MaxAttempts: 1}), testLogger())
+	assert.Equal(t, errNotReady, err)
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestConnectWithRetry_TotalTimeout(t *testing.T) {
+	stub := &stubConnector{failures: 100}
+	policy := retryPolicy{
+		backoff: retry.ExponentialBackoff(20*time.Millisecond, 20*time.Millisecond, false, 100),
+		timeout: 50 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := connectWithRetry(context.Background(), stub.connect, policy, testLogger())
+	require.ErrorIs(t, err, errNotReady)
+	// Gives up once the next wait would overrun the deadline
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	assert.GreaterOrEqual(t, stub.calls, 2)
+	assert.LessOrEqual(t, stub.calls, 3)
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy := newRetryPolicy(config.ConnectRetryConfig{MaxAttempts: 10, InitialBackoffSec: 1, MaxBackoffSec: 10, TimeoutSec: 60})
+
+	assert.Equal(t, retry.ExponentialBackoff(time.Second, 10*time.Second, true, 10), policy.backoff)
+	assert.Equal(t, time.Minute, policy.timeout)
+
+	// Jittered around 1s, 2s, 4s... and never past the cap
+	assert.InDelta(t, float64(time.Second), float64(policy.backoff.Delay(1)), float64(200*time.Millisecond))
+	assert.InDelta(t, float64(4*time.Second), float64(policy.backoff.Delay(3)), float64(800*time.Millisecond))
+	for i := 0; i < 100; i++ {
+		assert.LessOrEqual(t, policy.backoff.Delay(30), 10*time.Second)
+	}
+}
+
+func TestNewRetryPolicy_ZeroAttemptsConnectsOnce(t *testing.T) {
+	assert.Equal(t, 1, newRetryPolicy(config.ConnectRetryConfig{}).backoff.MaxAttempts)
+}
+
+func TestNewRetryPolicy_CapBelowInitialBackoff(t *testing.T) {
+	policy := newRetryPolicy(config.ConnectRetryConfig{MaxAttempts: 3, InitialBackoffSec: 5, MaxBackoffSec: 1})
+	assert.Equal(t, 5*time.Second, policy.backoff.MaxDelay)
+}
@@ -6,9 +6,10 @@ import (
 )
 
 // HTTPHandler returns an HTTP handler for health checks
+// Details are redacted for unauthorized callers when RedactDetails is set.
 func HTTPHandler(service *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		response := service.GetHealthResponse(r.Context())
+		response := service.GetHealthResponse(r.Context(), service.responseOptionsFor(r)...)
 
 		// Set appropriate status code
 		statusCode := http.StatusOK
@@ -27,13 +28,11 @@ func HTTPHandler(service *Service) http.HandlerFunc {
 }
 
 // ReadinessHandler returns a readiness probe handler
-// This is useful for Kubernetes readiness probes
+// Only readiness providers are checked, e.g. for a Kubernetes /readyz probe.
 func ReadinessHandler(service *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		response := service.GetHealthResponse(r.Context())
-
 		// For readiness, we're strict: only UP is ready
-		if response.Status == StatusUp {
+		if service.probe(r.Context(), KindReadiness) == StatusUp {
 			w.WriteHeader(http.StatusOK)
 			w.Write([]byte("OK"))
 		} else {
@@ -44,12 +43,17 @@ func ReadinessHandler(service *Service) http.HandlerFunc {
 }
 
 // LivenessHandler returns a liveness probe handler
-// This should only check if the application is alive, not dependencies
-func LivenessHandler() http.HandlerFunc {
+// Only liveness providers are checked, never dependencies, so a database
+// outage doesn't get the process restarted. DEGRADED still counts as alive.
+func LivenessHandler(service *Service) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Simple alive check - if we can respond, we're alive
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
+		if service.probe(r.Context(), KindLiveness) != StatusDown {
+			w.WriteHeader(http.StatusOK)
+			w.Write([]byte("OK"))
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("NOT ALIVE"))
+		}
 	}
 }
 
@@ -59,7 +63,7 @@ func DetailedHealthHandler(service *Service) http.HandlerFunc {
 		ctx := r.Context()
 
 		// Get health response
-		response := service.GetHealthResponse(ctx)
+		response := service.GetHealthResponse(ctx, service.responseOptionsFor(r)...)
 
 		// Add additional runtime information
 		if response.Details == nil {
@@ -45,7 +45,7 @@ func NewHealthService(params HealthServiceParams) *Service {
 	// Auto-register database provider if available
 	if params.DB != nil {
 		dbProvider := NewPostgresProvider("database", params.DB)
-		service.RegisterProvider(dbProvider)
+		service.RegisterProviderWithKind(dbProvider, KindReadiness)
 		params.Logger.Info("Registered database health provider")
 	}
 
@@ -56,7 +56,7 @@ func NewHealthService(params HealthServiceParams) *Service {
 			Client:     params.RedisClient,
 			DegradedMS: 100,
 		})
-		service.RegisterProvider(redisProvider)
+		service.RegisterProviderWithKind(redisProvider, KindReadiness)
 		params.Logger.Info("Registered Redis health provider")
 	}
 
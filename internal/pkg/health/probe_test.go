@@ -0,0 +1,105 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newProbeService(config ServiceConfig) *Service {
+	svc := NewService(config)
+	svc.RegisterProviderWithKind(&stubProvider{name: "worker", status: StatusUp}, KindLiveness)
+	svc.RegisterProviderWithKind(&stubProvider{name: "database", status: StatusDown}, KindReadiness)
+	svc.RegisterProviderWithKind(&stubProvider{name: "config", status: StatusUp}, KindBoth)
+	return svc
+}
+
+func resultNames(results []HealthCheckResult) []string {
+	names := make([]string, 0, len(results))
+	for _, result := range results {
+		names = append(names, result.Name)
+	}
+	return names
+}
+
+func TestService_CheckLivenessAndReadiness(t *testing.T) {
+	svc := newProbeService(DefaultServiceConfig())
+	ctx := context.Background()
+
+	// The database outage makes the instance unready, not dead
+	results, status := svc.CheckLiveness(ctx)
+	assert.Equal(t, StatusUp, status)
+	assert.Equal(t, []string{"worker", "config"}, resultNames(results))
+
+	results, status = svc.CheckReadiness(ctx)
+	assert.Equal(t, StatusDown, status)
+	assert.Equal(t, []string{"database", "config"}, resultNames(results))
+
+	// Check still aggregates every provider
+	results, status = svc.Check(ctx)
+	assert.Equal(t, StatusDown, status)
+	assert.Len(t, results, 3)
+}
+
+func TestService_CheckKind_NoProviders(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProviderWithKind(&stubProvider{name: "database", status: StatusUp}, KindReadiness)
+
+	results, status := svc.CheckLiveness(context.Background())
+	assert.Empty(t, results)
+	assert.Equal(t, StatusUp, status)
+}
+
+func TestProbeHandlers(t *testing.T) {
+	svc := newProbeService(DefaultServiceConfig())
+
+	rec := httptest.NewRecorder()
+	LivenessHandler(svc)(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	ReadinessHandler(svc)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "NOT READY", rec.Body.String())
+}
+
+func TestProbeHandlers_AsyncUsesCache(t *testing.T) {
+	svc := newProbeService(ServiceConfig{AsyncMode: true, CheckInterval: time.Hour})
+	defer svc.Stop()
+
+	// Populate the cache; the ticker will not fire again within the test
+	svc.Check(context.Background())
+
+	assert.Equal(t, StatusUp, svc.probe(context.Background(), KindLiveness))
+	assert.Equal(t, StatusDown, svc.probe(context.Background(), KindReadiness))
+}
+
+func TestService_RegisterProviderDefaultsToReadiness(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProvider(&stubProvider{name: "database", status: StatusDown})
+
+	results, status := svc.CheckLiveness(context.Background())
+	assert.Empty(t, results)
+	assert.Equal(t, StatusUp, status, "a dependency outage must not fail liveness")
+
+	_, status = svc.CheckReadiness(context.Background())
+	assert.Equal(t, StatusDown, status)
+}
+
+func TestProbeHandlers_AsyncBeforeFirstCheck(t *testing.T) {
+	// Read the cache as async mode does, before any check has filled it
+	svc := newProbeService(DefaultServiceConfig())
+	svc.config.AsyncMode = true
+
+	rec := httptest.NewRecorder()
+	LivenessHandler(svc)(rec, httptest.NewRequest(http.MethodGet, "/livez", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	ReadinessHandler(svc)(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
@@ -0,0 +1,70 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedProvider wraps a provider and reuses its last result for a TTL, so
+// an expensive check doesn't run on every health request
+type CachedProvider struct {
+	provider HealthProvider
+	ttl      time.Duration
+
+	// mu is held while checking, so concurrent requests after expiry wait
+	// for one check instead of all running it
+	mu       sync.Mutex
+	result   HealthCheckResult
+	cachedAt time.Time
+}
+
+// NewCachedProvider caches the results of provider for ttl
+func NewCachedProvider(provider HealthProvider, ttl time.Duration) *CachedProvider {
+	return &CachedProvider{
+		provider: provider,
+		ttl:      ttl,
+	}
+}
+
+// Name returns the wrapped provider's name
+func (p *CachedProvider) Name() string {
+	return p.provider.Name()
+}
+
+// CheckTimeout returns the wrapped provider's timeout, if it has one
+func (p *CachedProvider) CheckTimeout() time.Duration {
+	if tp, ok := p.provider.(TimeoutProvider); ok {
+		return tp.CheckTimeout()
+	}
+	return 0
+}
+
+// Check returns the cached result while it is fresh, and otherwise runs
+// the wrapped check. Cached results report their age in Details.
+func (p *CachedProvider) Check(ctx context.Context) HealthCheckResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.cachedAt.IsZero() {
+		if age := time.Since(p.cachedAt); age < p.ttl {
+			return p.cached(age)
+		}
+	}
+
+	p.result = p.provider.Check(ctx)
+	p.cachedAt = time.Now()
+	return p.result
+}
+
+// cached returns a copy of the cached result, leaving its Details untouched
+func (p *CachedProvider) cached(age time.Duration) HealthCheckResult {
+	result := p.result
+	result.Details = make(map[string]interface{}, len(p.result.Details)+2)
+	for key, value := range p.result.Details {
+		result.Details[key] = value
+	}
+	result.Details["cached"] = true
+	result.Details["cache_age_ms"] = age.Milliseconds()
+	return result
+}
@@ -0,0 +1,81 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingProvider counts how often it is checked
+type countingProvider struct {
+	checks atomic.Int32
+	status HealthStatus
+}
+
+func (p *countingProvider) Name() string { return "database" }
+
+func (p *countingProvider) Check(ctx context.Context) HealthCheckResult {
+	p.checks.Add(1)
+	return HealthCheckResult{
+		Name:      "database",
+		Status:    p.status,
+		Details:   map[string]interface{}{"latency_ms": int64(1)},
+		CheckedAt: time.Now(),
+	}
+}
+
+func TestCachedProvider_ReusesResultUntilExpiry(t *testing.T) {
+	inner := &countingProvider{status: StatusUp}
+	provider := NewCachedProvider(inner, 50*time.Millisecond)
+	ctx := context.Background()
+
+	first := provider.Check(ctx)
+	assert.Equal(t, "database", provider.Name())
+	assert.Equal(t, StatusUp, first.Status)
+	assert.NotContains(t, first.Details, "cached")
+
+	inner.status = StatusDown
+	second := provider.Check(ctx)
+	assert.Equal(t, int32(1), inner.checks.Load())
+	assert.Equal(t, StatusUp, second.Status, "cached result is served until the TTL passes")
+	assert.Equal(t, true, second.Details["cached"])
+	assert.Equal(t, first.CheckedAt, second.CheckedAt)
+
+	time.Sleep(60 * time.Millisecond)
+	third := provider.Check(ctx)
+	assert.Equal(t, int32(2), inner.checks.Load())
+	assert.Equal(t, StatusDown, third.Status)
+}
+
+func TestCachedProvider_ConcurrentRequestsCheckOnce(t *testing.T) {
+	inner := &countingProvider{status: StatusUp}
+	provider := NewCachedProvider(inner, time.Minute)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			provider.Check(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), inner.checks.Load())
+}
+
+func TestCachedProvider_WithService(t *testing.T) {
+	inner := &countingProvider{status: StatusUp}
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProvider(NewCachedProvider(inner, time.Minute))
+
+	for i := 0; i < 5; i++ {
+		svc.GetHealthResponse(context.Background())
+	}
+
+	assert.Equal(t, int32(1), inner.checks.Load())
+}
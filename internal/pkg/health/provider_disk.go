@@ -0,0 +1,119 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// errDiskStatsUnsupported is returned by diskUsage where it isn't implemented
+var errDiskStatsUnsupported = errors.New("disk usage is not supported on this platform")
+
+// DiskProvider checks free space on the filesystem holding a path
+type DiskProvider struct {
+	name            string
+	path            string
+	minFreeBytes    uint64
+	minFreePercent  float64
+	warnFreeBytes   uint64
+	warnFreePercent float64
+}
+
+// DiskProviderConfig configures the disk health provider
+// Below either Min threshold the provider is DOWN, below either Warn
+// threshold it is DEGRADED. A zero threshold is not checked.
+type DiskProviderConfig struct {
+	Name            string
+	Path            string  // Any path on the filesystem to check (default: ".")
+	MinFreeBytes    uint64  // Critical free space in bytes
+	MinFreePercent  float64 // Critical free space, 0-100
+	WarnFreeBytes   uint64  // Warning free space in bytes (default: 2x MinFreeBytes)
+	WarnFreePercent float64 // Warning free space, 0-100 (default: 2x MinFreePercent)
+}
+
+// NewDiskProvider creates a new disk health provider
+func NewDiskProvider(config DiskProviderConfig) *DiskProvider {
+	if config.Name == "" {
+		config.Name = "disk"
+	}
+	if config.Path == "" {
+		config.Path = "."
+	}
+	if config.WarnFreeBytes == 0 {
+		config.WarnFreeBytes = 2 * config.MinFreeBytes
+	}
+	if config.WarnFreePercent == 0 {
+		config.WarnFreePercent = math.Min(2*config.MinFreePercent, 100)
+	}
+
+	return &DiskProvider{
+		name:            config.Name,
+		path:            config.Path,
+		minFreeBytes:    config.MinFreeBytes,
+		minFreePercent:  config.MinFreePercent,
+		warnFreeBytes:   config.WarnFreeBytes,
+		warnFreePercent: config.WarnFreePercent,
+	}
+}
+
+// Name returns the provider name
+func (p *DiskProvider) Name() string {
+	return p.name
+}
+
+// Check compares the filesystem's free space with the thresholds
+func (p *DiskProvider) Check(ctx context.Context) HealthCheckResult {
+	result := HealthCheckResult{
+		Name:      p.name,
+		CheckedAt: time.Now(),
+		Details:   make(map[string]interface{}),
+	}
+
+	result.Details["path"] = p.path
+
+	free, total, err := diskUsage(p.path)
+	if errors.Is(err, errDiskStatsUnsupported) {
+		// Nothing to measure, which is no reason to fail the service
+		result.Status = StatusUp
+		result.Details["message"] = err.Error()
+		return result
+	}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = fmt.Sprintf("statfs failed: %v", err)
+		result.Details["error"] = err.Error()
+		return result
+	}
+
+	return p.evaluate(result, free, total)
+}
+
+// evaluate sets the status from free and total bytes
+func (p *DiskProvider) evaluate(result HealthCheckResult, free, total uint64) HealthCheckResult {
+	var percent float64
+	if total > 0 {
+		percent = float64(free) / float64(total) * 100
+	}
+
+	result.Details["free_bytes"] = free
+	result.Details["total_bytes"] = total
+	result.Details["free_percent"] = math.Round(percent*100) / 100
+
+	below := func(minBytes uint64, minPercent float64) bool {
+		return (minBytes > 0 && free < minBytes) || (minPercent > 0 && percent < minPercent)
+	}
+
+	switch {
+	case below(p.minFreeBytes, p.minFreePercent):
+		result.Status = StatusDown
+		result.Error = "disk space critically low"
+	case below(p.warnFreeBytes, p.warnFreePercent):
+		result.Status = StatusDegraded
+		result.Details["message"] = "disk space low"
+	default:
+		result.Status = StatusUp
+	}
+	return result
+}
@@ -0,0 +1,8 @@
+//go:build !(linux || darwin || freebsd)
+
+package health
+
+// diskUsage is unavailable here; the provider reports UP with a note
+func diskUsage(path string) (free, total uint64, err error) {
+	return 0, 0, errDiskStatsUnsupported
+}
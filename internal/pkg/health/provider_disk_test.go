@@ -0,0 +1,62 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskProvider_Thresholds(t *testing.T) {
+	const gb = 1 << 30
+	p := NewDiskProvider(DiskProviderConfig{Name: "buffer", MinFreeBytes: 1 * gb})
+
+	tests := []struct {
+		name   string
+		free   uint64
+		status HealthStatus
+	}{
+		{"plenty", 50 * gb, StatusUp},
+		// Under the default warning of 2x the minimum
+		{"low", 1.5 * gb, StatusDegraded},
+		{"critical", gb / 2, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := p.evaluate(HealthCheckResult{Details: map[string]interface{}{}}, tt.free, 100*gb)
+			assert.Equal(t, tt.status, result.Status)
+			assert.Equal(t, tt.free, result.Details["free_bytes"])
+			assert.Equal(t, uint64(100*gb), result.Details["total_bytes"])
+		})
+	}
+}
+
+func TestDiskProvider_CriticalPercent(t *testing.T) {
+	p := NewDiskProvider(DiskProviderConfig{MinFreePercent: 10, WarnFreePercent: 20})
+
+	result := p.evaluate(HealthCheckResult{Details: map[string]interface{}{}}, 9, 100)
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Equal(t, 9.0, result.Details["free_percent"])
+
+	result = p.evaluate(HealthCheckResult{Details: map[string]interface{}{}}, 15, 100)
+	assert.Equal(t, StatusDegraded, result.Status)
+}
+
+func TestDiskProvider_Check(t *testing.T) {
+	p := NewDiskProvider(DiskProviderConfig{Path: t.TempDir()})
+
+	result := p.Check(context.Background())
+	assert.Equal(t, "disk", result.Name)
+	// No thresholds, so any real filesystem is UP
+	assert.Equal(t, StatusUp, result.Status)
+	assert.Contains(t, result.Details, "free_bytes")
+	assert.Greater(t, result.Details["total_bytes"], uint64(0))
+}
+
+func TestDiskProvider_MissingPath(t *testing.T) {
+	p := NewDiskProvider(DiskProviderConfig{Path: "/nonexistent/buffer"})
+
+	result := p.Check(context.Background())
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Contains(t, result.Error, "statfs failed")
+}
@@ -0,0 +1,182 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCProvider checks gRPC service health with grpc.health.v1.Health/Check
+type GRPCProvider struct {
+	name       string
+	address    string
+	service    string
+	timeout    time.Duration
+	degradedMS int64
+	tlsConfig  *tls.Config
+
+	// conn is dialed on the first check and reused after that
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+}
+
+// GRPCProviderConfig configures the gRPC health provider
+type GRPCProviderConfig struct {
+	Name       string
+	Address    string        // gRPC server address
+	Service    string        // Service name to check (default: "" for the whole server)
+	Timeout    time.Duration // Default: 5s
+	DegradedMS int64         // Latency threshold for degraded (default: 500ms)
+	TLS        *tls.Config   // Optional; nil connects without TLS
+}
+
+// NewGRPCProvider creates a new gRPC health provider
+func NewGRPCProvider(config GRPCProviderConfig) *GRPCProvider {
+	if config.Name == "" {
+		config.Name = "grpc"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.DegradedMS == 0 {
+		config.DegradedMS = 500
+	}
+
+	return &GRPCProvider{
+		name:       config.Name,
+		address:    config.Address,
+		service:    config.Service,
+		timeout:    config.Timeout,
+		degradedMS: config.DegradedMS,
+		tlsConfig:  config.TLS,
+	}
+}
+
+// Name returns the provider name
+func (p *GRPCProvider) Name() string {
+	return p.name
+}
+
+// CheckTimeout returns the configured RPC timeout
+func (p *GRPCProvider) CheckTimeout() time.Duration {
+	return p.timeout
+}
+
+// Check performs the health check
+func (p *GRPCProvider) Check(ctx context.Context) HealthCheckResult {
+	result := HealthCheckResult{
+		Name:      p.name,
+		CheckedAt: time.Now(),
+		Details:   make(map[string]interface{}),
+	}
+
+	result.Details["address"] = p.address
+	if p.service != "" {
+		result.Details["service"] = p.service
+	}
+
+	conn, err := p.client()
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = fmt.Sprintf("failed to create client: %v", err)
+		result.Details["error"] = err.Error()
+		return result
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	// Measure latency
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(conn).Check(checkCtx, &healthpb.HealthCheckRequest{Service: p.service})
+	latency := time.Since(start)
+
+	result.Details["latency_ms"] = latency.Milliseconds()
+
+	if err != nil {
+		result.Details["error"] = err.Error()
+		switch status.Code(err) {
+		case codes.DeadlineExceeded:
+			result.Status = StatusDegraded
+			result.Error = fmt.Sprintf("health check timed out: %v", err)
+		case codes.NotFound:
+			// The server doesn't know the service
+			result.Status = StatusDegraded
+			result.Error = fmt.Sprintf("service unknown: %v", err)
+		default:
+			result.Status = StatusDown
+			result.Error = fmt.Sprintf("health check failed: %v", err)
+		}
+		return result
+	}
+
+	result.Details["serving_status"] = resp.GetStatus().String()
+
+	switch resp.GetStatus() {
+	case healthpb.HealthCheckResponse_SERVING:
+	case healthpb.HealthCheckResponse_NOT_SERVING:
+		result.Status = StatusDown
+		result.Error = "service is not serving"
+		return result
+	default:
+		result.Status = StatusDegraded
+		result.Details["message"] = "unknown serving status"
+		return result
+	}
+
+	// Check latency threshold
+	if latency.Milliseconds() > p.degradedMS {
+		result.Status = StatusDegraded
+		result.Details["message"] = "high latency detected"
+		return result
+	}
+
+	result.Status = StatusUp
+	return result
+}
+
+// Close closes the connection to the server
+func (p *GRPCProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn = nil
+	return err
+}
+
+// client returns the shared connection, creating it on first use. The
+// connection is established lazily and reconnects on its own, so a server
+// that is down at startup is picked up once it comes back.
+func (p *GRPCProvider) client() (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn != nil {
+		return p.conn, nil
+	}
+
+	creds := insecure.NewCredentials()
+	if p.tlsConfig != nil {
+		creds = credentials.NewTLS(p.tlsConfig)
+	}
+
+	conn, err := grpc.NewClient(p.address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return nil, err
+	}
+	p.conn = conn
+	return conn, nil
+}
@@ -0,0 +1,87 @@
+package health
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// startHealthServer serves the standard gRPC health service on a local port
+func startHealthServer(t *testing.T) (string, *grpchealth.Server) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	return listener.Addr().String(), healthServer
+}
+
+func TestGRPCProvider_ServingStatus(t *testing.T) {
+	address, healthServer := startHealthServer(t)
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_SERVING)
+	healthServer.SetServingStatus("billing", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	tests := []struct {
+		service string
+		want    HealthStatus
+	}{
+		{service: "orders", want: StatusUp},
+		{service: "", want: StatusUp},
+		{service: "billing", want: StatusDown},
+		{service: "missing", want: StatusDegraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.service, func(t *testing.T) {
+			provider := NewGRPCProvider(GRPCProviderConfig{Address: address, Service: tt.service, Timeout: time.Second})
+			defer provider.Close()
+
+			result := provider.Check(context.Background())
+			assert.Equal(t, tt.want, result.Status, result.Error)
+			assert.Contains(t, result.Details, "latency_ms")
+		})
+	}
+}
+
+func TestGRPCProvider_ReusesConnection(t *testing.T) {
+	address, _ := startHealthServer(t)
+
+	provider := NewGRPCProvider(GRPCProviderConfig{Address: address})
+	defer provider.Close()
+
+	require.Equal(t, StatusUp, provider.Check(context.Background()).Status)
+	conn := provider.conn
+	require.NotNil(t, conn)
+
+	require.Equal(t, StatusUp, provider.Check(context.Background()).Status)
+	assert.Same(t, conn, provider.conn)
+}
+
+func TestGRPCProvider_DownWhenUnreachable(t *testing.T) {
+	// Grab a free port, then close it so nothing answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	provider := NewGRPCProvider(GRPCProviderConfig{Address: address, Timeout: time.Second})
+	defer provider.Close()
+
+	result := provider.Check(context.Background())
+	assert.Equal(t, StatusDown, result.Status)
+	assert.NotEmpty(t, result.Error)
+}
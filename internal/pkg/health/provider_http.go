@@ -75,6 +75,12 @@ func (p *HTTPProvider) Name() string {
 	return p.name
 }
 
+// CheckTimeout returns the configured timeout, so the service doesn't cut
+// the check short with a lower DefaultTimeout
+func (p *HTTPProvider) CheckTimeout() time.Duration {
+	return p.timeout
+}
+
 // Check performs the health check
 func (p *HTTPProvider) Check(ctx context.Context) HealthCheckResult {
 	result := HealthCheckResult{
@@ -156,99 +162,3 @@ func (p *HTTPProvider) Check(ctx context.Context) HealthCheckResult {
 	result.Status = StatusUp
 	return result
 }
-
-// GRPCProvider checks gRPC service health (using HTTP/2 health check)
-type GRPCProvider struct {
-	name       string
-	address    string
-	timeout    time.Duration
-	degradedMS int64
-}
-
-// GRPCProviderConfig configures the gRPC health provider
-type GRPCProviderConfig struct {
-	Name       string
-	Address    string        // gRPC server address
-	Timeout    time.Duration // Default: 5s
-	DegradedMS int64         // Latency threshold for degraded (default: 500ms)
-}
-
-// NewGRPCProvider creates a new gRPC health provider
-func NewGRPCProvider(config GRPCProviderConfig) *GRPCProvider {
-	if config.Name == "" {
-		config.Name = "grpc"
-	}
-	if config.Timeout == 0 {
-		config.Timeout = 5 * time.Second
-	}
-	if config.DegradedMS == 0 {
-		config.DegradedMS = 500
-	}
-
-	return &GRPCProvider{
-		name:       config.Name,
-		address:    config.Address,
-		timeout:    config.Timeout,
-		degradedMS: config.DegradedMS,
-	}
-}
-
-// Name returns the provider name
-func (p *GRPCProvider) Name() string {
-	return p.name
-}
-
-// Check performs the health check
-func (p *GRPCProvider) Check(ctx context.Context) HealthCheckResult {
-	result := HealthCheckResult{
-		Name:      p.name,
-		CheckedAt: time.Now(),
-		Details:   make(map[string]interface{}),
-	}
-
-	result.Details["address"] = p.address
-
-	// For now, this is a simplified check
-	// In production, you would use grpc.health.v1.Health/Check RPC
-	// This requires importing google.golang.org/grpc/health/grpc_health_v1
-
-	// Simple TCP dial check as placeholder
-	start := time.Now()
-
-	// Create a basic HTTP client to check if the server is responding
-	client := &http.Client{
-		Timeout: p.timeout,
-	}
-
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s", p.address), nil)
-	if err != nil {
-		result.Status = StatusDown
-		result.Error = fmt.Sprintf("failed to create request: %v", err)
-		result.Details["error"] = err.Error()
-		return result
-	}
-
-	resp, err := client.Do(req)
-	latency := time.Since(start)
-
-	result.Details["latency_ms"] = latency.Milliseconds()
-
-	if err != nil {
-		result.Status = StatusDown
-		result.Error = fmt.Sprintf("connection failed: %v", err)
-		result.Details["error"] = err.Error()
-		return result
-	}
-	defer resp.Body.Close()
-
-	// Check latency threshold
-	if latency.Milliseconds() > p.degradedMS {
-		result.Status = StatusDegraded
-		result.Details["message"] = "high latency detected"
-		return result
-	}
-
-	result.Status = StatusUp
-	result.Details["message"] = "basic connectivity check passed"
-	return result
-}
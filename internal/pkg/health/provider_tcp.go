@@ -0,0 +1,91 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// TCPProvider checks that a TCP endpoint accepts connections
+type TCPProvider struct {
+	name       string
+	address    string
+	timeout    time.Duration
+	degradedMS int64
+}
+
+// TCPProviderConfig configures the TCP health provider
+type TCPProviderConfig struct {
+	Name       string
+	Address    string        // host:port to dial
+	Timeout    time.Duration // Default: 5s
+	DegradedMS int64         // Latency threshold for degraded (default: 500ms)
+}
+
+// NewTCPProvider creates a new TCP health provider
+func NewTCPProvider(config TCPProviderConfig) *TCPProvider {
+	if config.Name == "" {
+		config.Name = "tcp"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Second
+	}
+	if config.DegradedMS == 0 {
+		config.DegradedMS = 500
+	}
+
+	return &TCPProvider{
+		name:       config.Name,
+		address:    config.Address,
+		timeout:    config.Timeout,
+		degradedMS: config.DegradedMS,
+	}
+}
+
+// Name returns the provider name
+func (p *TCPProvider) Name() string {
+	return p.name
+}
+
+// CheckTimeout returns the configured dial timeout
+func (p *TCPProvider) CheckTimeout() time.Duration {
+	return p.timeout
+}
+
+// Check dials the address and closes the connection straight away
+func (p *TCPProvider) Check(ctx context.Context) HealthCheckResult {
+	result := HealthCheckResult{
+		Name:      p.name,
+		CheckedAt: time.Now(),
+		Details:   make(map[string]interface{}),
+	}
+
+	result.Details["address"] = p.address
+
+	dialer := net.Dialer{Timeout: p.timeout}
+
+	start := time.Now()
+	conn, err := dialer.DialContext(ctx, "tcp", p.address)
+	latency := time.Since(start)
+
+	result.Details["latency_ms"] = latency.Milliseconds()
+
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = fmt.Sprintf("connection failed: %v", err)
+		result.Details["error"] = err.Error()
+		return result
+	}
+	conn.Close()
+
+	// Check latency threshold
+	if latency.Milliseconds() > p.degradedMS {
+		result.Status = StatusDegraded
+		result.Details["message"] = "high latency detected"
+		return result
+	}
+
+	result.Status = StatusUp
+	return result
+}
@@ -0,0 +1,49 @@
+package health
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTCPProvider_Up(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	provider := NewTCPProvider(TCPProviderConfig{Name: "broker", Address: listener.Addr().String()})
+	result := provider.Check(context.Background())
+
+	assert.Equal(t, "broker", result.Name)
+	assert.Equal(t, StatusUp, result.Status)
+	assert.Empty(t, result.Error)
+	assert.Equal(t, listener.Addr().String(), result.Details["address"])
+	assert.Contains(t, result.Details, "latency_ms")
+}
+
+func TestTCPProvider_DownWhenNothingListens(t *testing.T) {
+	// Grab a free port, then close it so the dial is refused
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	result := NewTCPProvider(TCPProviderConfig{Address: address, Timeout: time.Second}).Check(context.Background())
+
+	assert.Equal(t, "tcp", result.Name)
+	assert.Equal(t, StatusDown, result.Status)
+	assert.Contains(t, result.Error, "connection failed")
+}
+
+func TestTCPProvider_RespectsContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := NewTCPProvider(TCPProviderConfig{Address: "127.0.0.1:1"}).Check(ctx)
+
+	assert.Equal(t, StatusDown, result.Status)
+}
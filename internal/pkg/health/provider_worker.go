@@ -2,6 +2,7 @@ package health
 
 import (
 	"context"
+	"fmt"
 	"time"
 )
 
@@ -18,6 +19,23 @@ type WorkerHealthChecker interface {
 	GetQueueCapacity() int
 }
 
+// WorkerInFlightReporter is optionally implemented by a WorkerHealthChecker
+// to report how many tasks are currently being processed
+type WorkerInFlightReporter interface {
+	// GetInFlight returns the number of tasks currently being processed
+	GetInFlight() int64
+	// GetMaxInFlight returns the in-flight limit, or 0 if unlimited
+	GetMaxInFlight() int64
+}
+
+// WorkerSuccessRateReporter is optionally implemented by a WorkerHealthChecker
+// to report its rolling task success rate
+type WorkerSuccessRateReporter interface {
+	// GetSuccessRate returns successes/attempts (0-1) over a recent window
+	// and the number of attempts in that window
+	GetSuccessRate() (float64, int64)
+}
+
 // WorkerProviderConfig configures the worker health provider
 type WorkerProviderConfig struct {
 	// Name is the name of the worker provider
@@ -31,6 +49,19 @@ type WorkerProviderConfig struct {
 	// If queue length exceeds this, status will be DEGRADED
 	// If 0, uses 80% of MaxQueueLength
 	DegradedQueueLength int
+	// DegradedSuccessRate marks the worker DEGRADED when the reported success
+	// rate falls below it. If 0, the success rate only appears in details
+	DegradedSuccessRate float64
+	// DownSuccessRate marks the worker DOWN when the reported success rate
+	// falls below it. If 0, the success rate never marks the worker DOWN
+	DownSuccessRate float64
+	// MinSuccessRateAttempts is the number of attempts needed before the
+	// success rate affects the status, so a few early failures don't flap it
+	MinSuccessRateAttempts int64
+	// RequireQueueMetrics treats a queue length or capacity of -1 as unknown
+	// and reports DEGRADED, for workers that always have a queue. Without it
+	// -1 means the worker has no queue and the metrics are simply omitted
+	RequireQueueMetrics bool
 }
 
 // WorkerProvider provides health checking for workers
@@ -78,15 +109,58 @@ func (p *WorkerProvider) Check(ctx context.Context) HealthCheckResult {
 	queueLength := p.config.Checker.GetQueueLength()
 	queueCapacity := p.config.Checker.GetQueueCapacity()
 
+	queueKnown := queueLength >= 0 && queueCapacity >= 0
+
 	if queueLength >= 0 {
 		result.Details["queue_length"] = queueLength
+	} else if p.config.RequireQueueMetrics {
+		result.Details["queue_length"] = "unknown"
 	}
 
 	if queueCapacity >= 0 {
 		result.Details["queue_capacity"] = queueCapacity
+	} else if p.config.RequireQueueMetrics {
+		result.Details["queue_capacity"] = "unknown"
+	}
+
+	// Usage is only meaningful when both values are known
+	if queueKnown && queueCapacity > 0 {
 		result.Details["queue_usage_percent"] = float64(queueLength) / float64(queueCapacity) * 100
 	}
 
+	if reporter, ok := p.config.Checker.(WorkerInFlightReporter); ok {
+		result.Details["in_flight"] = reporter.GetInFlight()
+		if maxInFlight := reporter.GetMaxInFlight(); maxInFlight > 0 {
+			result.Details["max_in_flight"] = maxInFlight
+		}
+	}
+
+	// A missing queue can't be told apart from an empty one, so don't report UP
+	status := StatusUp
+	if p.config.RequireQueueMetrics && !queueKnown {
+		status = StatusDegraded
+		result.Details["reason"] = "queue metrics unavailable"
+	}
+
+	// A falling success rate means tasks fail quietly even though the worker runs
+	if reporter, ok := p.config.Checker.(WorkerSuccessRateReporter); ok {
+		rate, attempts := reporter.GetSuccessRate()
+		result.Details["success_rate"] = rate
+		result.Details["success_rate_attempts"] = attempts
+
+		if attempts >= p.config.MinSuccessRateAttempts {
+			if rate < p.config.DownSuccessRate {
+				result.Status = StatusDown
+				result.Error = fmt.Sprintf("success rate %.2f is below %.2f", rate, p.config.DownSuccessRate)
+				return result
+			}
+			if rate < p.config.DegradedSuccessRate {
+				status = StatusDegraded
+				result.Details["reason"] = "success rate below threshold"
+			}
+		}
+	}
+
 	// Determine status based on queue metrics
 	if p.config.MaxQueueLength > 0 && queueLength >= 0 {
 		if queueLength >= p.config.MaxQueueLength {
@@ -103,6 +177,6 @@ func (p *WorkerProvider) Check(ctx context.Context) HealthCheckResult {
 	}
 
 	// Worker is running and queue is healthy
-	result.Status = StatusUp
+	result.Status = status
 	return result
 }
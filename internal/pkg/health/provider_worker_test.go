@@ -0,0 +1,142 @@
+package health
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubWorker is a running worker reporting a fixed queue and success rate
+type stubWorker struct {
+	queueLength int
+	rate        float64
+	attempts    int64
+}
+
+func (w *stubWorker) IsRunning() bool                  { return true }
+func (w *stubWorker) GetQueueLength() int              { return w.queueLength }
+func (w *stubWorker) GetQueueCapacity() int            { return 100 }
+func (w *stubWorker) GetSuccessRate() (float64, int64) { return w.rate, w.attempts }
+
+func TestWorkerProvider_SuccessRate(t *testing.T) {
+	tests := []struct {
+		name        string
+		worker      *stubWorker
+		want        HealthStatus
+		wantReason  string
+		wantErrText string
+	}{
+		{name: "healthy rate", worker: &stubWorker{rate: 0.99, attempts: 100}, want: StatusUp},
+		{name: "no attempts", worker: &stubWorker{rate: 1, attempts: 0}, want: StatusUp},
+		{name: "too few attempts", worker: &stubWorker{rate: 0, attempts: 5}, want: StatusUp},
+		{
+			name:       "degraded rate",
+			worker:     &stubWorker{rate: 0.8, attempts: 100},
+			want:       StatusDegraded,
+			wantReason: "success rate below threshold",
+		},
+		{
+			name:        "down rate",
+			worker:      &stubWorker{rate: 0.2, attempts: 100},
+			want:        StatusDown,
+			wantErrText: "success rate 0.20 is below 0.50",
+		},
+		{
+			name:   "full queue outranks degraded rate",
+			worker: &stubWorker{rate: 0.8, attempts: 100, queueLength: 100},
+			want:   StatusDown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := NewWorkerProvider(WorkerProviderConfig{
+				Name:                   "worker",
+				Checker:                tt.worker,
+				MaxQueueLength:         100,
+				DegradedSuccessRate:    0.9,
+				DownSuccessRate:        0.5,
+				MinSuccessRateAttempts: 10,
+			})
+
+			result := provider.Check(context.Background())
+			assert.Equal(t, tt.want, result.Status)
+			assert.Equal(t, tt.worker.rate, result.Details["success_rate"])
+			assert.Equal(t, tt.worker.attempts, result.Details["success_rate_attempts"])
+			if tt.wantReason != "" {
+				assert.Equal(t, tt.wantReason, result.Details["reason"])
+			}
+			if tt.wantErrText != "" {
+				assert.Equal(t, tt.wantErrText, result.Error)
+			}
+		})
+	}
+}
+
+func TestWorkerProvider_SuccessRateThresholdsDisabled(t *testing.T) {
+	provider := NewWorkerProvider(WorkerProviderConfig{
+		Name:    "worker",
+		Checker: &stubWorker{rate: 0, attempts: 100},
+	})
+
+	result := provider.Check(context.Background())
+	assert.Equal(t, StatusUp, result.Status)
+	assert.Equal(t, 0.0, result.Details["success_rate"])
+}
+
+// queuelessWorker is a running worker whose queue metrics are unavailable
+type queuelessWorker struct{}
+
+func (queuelessWorker) IsRunning() bool       { return true }
+func (queuelessWorker) GetQueueLength() int   { return -1 }
+func (queuelessWorker) GetQueueCapacity() int { return -1 }
+
+func TestWorkerProvider_QueueMetricsUnavailable(t *testing.T) {
+	t.Run("required", func(t *testing.T) {
+		provider := NewWorkerProvider(WorkerProviderConfig{
+			Name:                "worker",
+			Checker:             queuelessWorker{},
+			MaxQueueLength:      100,
+			RequireQueueMetrics: true,
+		})
+
+		result := provider.Check(context.Background())
+		assert.Equal(t, StatusDegraded, result.Status)
+		assert.Equal(t, "queue metrics unavailable", result.Details["reason"])
+		assert.Equal(t, "unknown", result.Details["queue_length"])
+		assert.Equal(t, "unknown", result.Details["queue_capacity"])
+		assert.NotContains(t, result.Details, "queue_usage_percent")
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		provider := NewWorkerProvider(WorkerProviderConfig{
+			Name:    "worker",
+			Checker: queuelessWorker{},
+		})
+
+		result := provider.Check(context.Background())
+		assert.Equal(t, StatusUp, result.Status)
+		assert.NotContains(t, result.Details, "queue_length")
+		assert.NotContains(t, result.Details, "queue_capacity")
+	})
+}
+
+func TestWorkerProvider_ZeroQueueCapacity(t *testing.T) {
+	provider := NewWorkerProvider(WorkerProviderConfig{
+		Name:    "worker",
+		Checker: &zeroCapacityWorker{},
+	})
+
+	result := provider.Check(context.Background())
+	assert.Equal(t, StatusUp, result.Status)
+	assert.Equal(t, 0, result.Details["queue_capacity"])
+	assert.NotContains(t, result.Details, "queue_usage_percent", "usage must not divide by zero")
+}
+
+// zeroCapacityWorker reports an empty queue with no capacity
+type zeroCapacityWorker struct{}
+
+func (*zeroCapacityWorker) IsRunning() bool       { return true }
+func (*zeroCapacityWorker) GetQueueLength() int   { return 0 }
+func (*zeroCapacityWorker) GetQueueCapacity() int { return 0 }
@@ -0,0 +1,74 @@
+package health
+
+import "net/http"
+
+// DefaultSensitiveDetails are the Details keys that can reveal internal
+// topology: endpoints, driver errors and raw upstream responses
+var DefaultSensitiveDetails = []string{
+	"url",
+	"address",
+	"host",
+	"dsn",
+	"error",
+	"validation_error",
+	"response",
+}
+
+// redactedError replaces a failed check's error text in redacted responses
+const redactedError = "health check failed"
+
+// ResponseOption customizes a health response
+type ResponseOption func(*responseOptions)
+
+type responseOptions struct {
+	redact bool
+}
+
+// WithRedaction strips sensitive Details fields and replaces error text,
+// for responses served to unauthenticated callers
+func WithRedaction() ResponseOption {
+	return func(o *responseOptions) {
+		o.redact = true
+	}
+}
+
+// responseOptionsFor redacts the response for requests not allowed full detail
+func (s *Service) responseOptionsFor(r *http.Request) []ResponseOption {
+	if !s.config.RedactDetails {
+		return nil
+	}
+	if s.config.AuthorizeDetails != nil && s.config.AuthorizeDetails(r) {
+		return nil
+	}
+	return []ResponseOption{WithRedaction()}
+}
+
+// redact returns copies of results without sensitive details. Results are
+// copied because async mode shares Details maps with the cache.
+func (s *Service) redact(results []HealthCheckResult) []HealthCheckResult {
+	sensitive := make(map[string]bool, len(s.config.SensitiveDetails))
+	for _, key := range s.config.SensitiveDetails {
+		sensitive[key] = true
+	}
+
+	redacted := make([]HealthCheckResult, len(results))
+	for i, result := range results {
+		if result.Error != "" {
+			result.Error = redactedError
+		}
+
+		if result.Details != nil {
+			details := make(map[string]interface{}, len(result.Details))
+			for key, value := range result.Details {
+				if !sensitive[key] {
+					details[key] = value
+				}
+			}
+			result.Details = details
+		}
+
+		redacted[i] = result
+	}
+
+	return redacted
+}
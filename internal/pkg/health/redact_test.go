@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leakyProvider reports a failure with internal endpoints in its details
+type leakyProvider struct{}
+
+func (p *leakyProvider) Name() string { return "database" }
+
+func (p *leakyProvider) Check(ctx context.Context) HealthCheckResult {
+	return HealthCheckResult{
+		Name:   "database",
+		Status: StatusDown,
+		Details: map[string]interface{}{
+			"address":    "10.0.3.7:5432",
+			"url":        "postgres://app@10.0.3.7/orders",
+			"error":      "dial tcp 10.0.3.7:5432: connection refused",
+			"latency_ms": int64(3),
+		},
+		CheckedAt: time.Now(),
+		Error:     "failed to ping database: dial tcp 10.0.3.7:5432: connection refused",
+	}
+}
+
+func TestGetHealthResponse_Redaction(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProvider(&leakyProvider{})
+
+	public := svc.GetHealthResponse(context.Background(), WithRedaction())
+	require.Len(t, public.Checks, 1)
+	check := public.Checks[0]
+	assert.Equal(t, StatusDown, check.Status)
+	assert.Equal(t, "health check failed", check.Error)
+	assert.NotContains(t, check.Details, "address")
+	assert.NotContains(t, check.Details, "url")
+	assert.NotContains(t, check.Details, "error")
+	assert.Equal(t, int64(3), check.Details["latency_ms"])
+
+	full := svc.GetHealthResponse(context.Background())
+	require.Len(t, full.Checks, 1)
+	assert.Contains(t, full.Checks[0].Error, "10.0.3.7")
+	assert.Equal(t, "10.0.3.7:5432", full.Checks[0].Details["address"])
+	assert.Equal(t, "postgres://app@10.0.3.7/orders", full.Checks[0].Details["url"])
+}
+
+func TestGetHealthResponse_RedactionLeavesCacheIntact(t *testing.T) {
+	svc := NewService(ServiceConfig{AsyncMode: true, CheckInterval: time.Hour})
+	defer svc.Stop()
+	svc.RegisterProvider(&leakyProvider{})
+	svc.Check(context.Background())
+
+	svc.GetHealthResponse(context.Background(), WithRedaction())
+
+	cached, _ := svc.GetCachedResults()
+	require.Len(t, cached, 1)
+	assert.Equal(t, "10.0.3.7:5432", cached[0].Details["address"])
+}
+
+func TestHTTPHandler_RedactsUnauthorizedCallers(t *testing.T) {
+	svc := NewService(ServiceConfig{
+		RedactDetails: true,
+		AuthorizeDetails: func(r *http.Request) bool {
+			return r.Header.Get("X-Ops-Token") == "secret"
+		},
+	})
+	svc.RegisterProvider(&leakyProvider{})
+	handler := HTTPHandler(svc)
+
+	get := func(token string) HealthResponse {
+		req := httptest.NewRequest(http.MethodGet, "/health", nil)
+		if token != "" {
+			req.Header.Set("X-Ops-Token", token)
+		}
+		rec := httptest.NewRecorder()
+		handler(rec, req)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+		var resp HealthResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Checks, 1)
+		return resp
+	}
+
+	public := get("")
+	assert.NotContains(t, public.Checks[0].Details, "address")
+	assert.Equal(t, "health check failed", public.Checks[0].Error)
+
+	ops := get("secret")
+	assert.Equal(t, "10.0.3.7:5432", ops.Checks[0].Details["address"])
+	assert.Contains(t, ops.Checks[0].Error, "connection refused")
+}
+
+func TestHTTPHandler_NoRedactionByDefault(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProvider(&leakyProvider{})
+
+	rec := httptest.NewRecorder()
+	HTTPHandler(svc)(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+
+	var resp HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Checks, 1)
+	assert.Equal(t, "10.0.3.7:5432", resp.Checks[0].Details["address"])
+}
@@ -2,6 +2,8 @@ package health
 
 import (
 	"context"
+	"fmt"
+	"net/http"
 	"sync"
 	"time"
 )
@@ -18,6 +20,18 @@ type ServiceConfig struct {
 	AggregationStrategy AggregationStrategy
 	// CriticalProviders are providers that must be UP for overall UP status
 	CriticalProviders []string
+	// MaxStaleness is the maximum age of cached results before they are
+	// reported as stale (async mode only). Defaults to 3x CheckInterval.
+	MaxStaleness time.Duration
+	// RedactDetails strips sensitive Details fields and error text from
+	// HTTP responses unless AuthorizeDetails allows the request
+	RedactDetails bool
+	// AuthorizeDetails reports whether a request may see full details,
+	// e.g. by checking an ops token. Nil means every request is redacted.
+	AuthorizeDetails func(r *http.Request) bool
+	// SensitiveDetails lists the Details keys removed by redaction.
+	// Defaults to DefaultSensitiveDetails.
+	SensitiveDetails []string
 }
 
 // DefaultServiceConfig returns default configuration
@@ -35,13 +49,17 @@ func DefaultServiceConfig() ServiceConfig {
 type Service struct {
 	config    ServiceConfig
 	providers []HealthProvider
+	kinds     []ProviderKind
 	mu        sync.RWMutex
 
 	// For async mode
 	cachedResults []HealthCheckResult
+	cachedKinds   []ProviderKind
 	cachedStatus  HealthStatus
 	lastCheck     time.Time
+	lastDuration  time.Duration
 	stopCh        chan struct{}
+	stopOnce      sync.Once
 	wg            sync.WaitGroup
 }
 
@@ -56,6 +74,12 @@ func NewService(config ServiceConfig) *Service {
 	if config.AggregationStrategy == "" {
 		config.AggregationStrategy = StrategyAll
 	}
+	if config.MaxStaleness == 0 {
+		config.MaxStaleness = 3 * config.CheckInterval
+	}
+	if config.SensitiveDetails == nil {
+		config.SensitiveDetails = DefaultSensitiveDetails
+	}
 
 	s := &Service{
 		config:        config,
@@ -72,23 +96,123 @@ func NewService(config ServiceConfig) *Service {
 	return s
 }
 
-// RegisterProvider registers a health provider
+// RegisterProvider registers a health provider for readiness
+// A failing check then takes the instance out of traffic without getting the
+// process restarted; use RegisterProviderWithKind to opt into liveness.
 func (s *Service) RegisterProvider(p HealthProvider) {
+	s.RegisterProviderWithKind(p, KindReadiness)
+}
+
+// RegisterProviderWithKind registers a health provider for the given probes
+// Every provider still counts toward Check.
+func (s *Service) RegisterProviderWithKind(p HealthProvider, kind ProviderKind) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.providers = append(s.providers, p)
+	s.kinds = append(s.kinds, kind)
 }
 
 // Check runs all health checks synchronously
 func (s *Service) Check(ctx context.Context) ([]HealthCheckResult, HealthStatus) {
 	s.mu.RLock()
-	providers := s.providers
+	providers, kinds := s.providers, s.kinds
 	s.mu.RUnlock()
 
 	if len(providers) == 0 {
 		return []HealthCheckResult{}, StatusDown
 	}
 
+	start := time.Now()
+	results := s.runChecks(ctx, providers)
+	duration := time.Since(start)
+
+	// Aggregate status
+	overallStatus := s.aggregateStatus(results)
+
+	// Update cache if in async mode
+	if s.config.AsyncMode {
+		s.mu.Lock()
+		s.cachedResults = results
+		s.cachedKinds = kinds
+		s.cachedStatus = overallStatus
+		s.lastCheck = time.Now()
+		s.lastDuration = duration
+		s.mu.Unlock()
+	}
+
+	return results, overallStatus
+}
+
+// CheckLiveness runs the liveness and both-kind checks synchronously
+// Without any such providers the process is considered alive.
+func (s *Service) CheckLiveness(ctx context.Context) ([]HealthCheckResult, HealthStatus) {
+	return s.checkKind(ctx, KindLiveness)
+}
+
+// CheckReadiness runs the readiness and both-kind checks synchronously
+// Without any such providers there is nothing to wait for, so it is UP.
+func (s *Service) CheckReadiness(ctx context.Context) ([]HealthCheckResult, HealthStatus) {
+	return s.checkKind(ctx, KindReadiness)
+}
+
+// checkKind runs the providers included in the kind probe
+func (s *Service) checkKind(ctx context.Context, kind ProviderKind) ([]HealthCheckResult, HealthStatus) {
+	s.mu.RLock()
+	var providers []HealthProvider
+	for i, p := range s.providers {
+		if s.kinds[i].includes(kind) {
+			providers = append(providers, p)
+		}
+	}
+	s.mu.RUnlock()
+
+	if len(providers) == 0 {
+		return []HealthCheckResult{}, StatusUp
+	}
+
+	results := s.runChecks(ctx, providers)
+	return results, s.aggregateStatus(results)
+}
+
+// cachedKind returns the cached results included in the kind probe
+// Like checkKind, no matching results is UP. Before the first background
+// check completes readiness is DOWN, but liveness is UP so a slow first
+// check doesn't get a starting process restarted.
+func (s *Service) cachedKind(kind ProviderKind) ([]HealthCheckResult, HealthStatus) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	results := []HealthCheckResult{}
+	if s.lastCheck.IsZero() {
+		if kind == KindLiveness {
+			return results, StatusUp
+		}
+		return results, StatusDown
+	}
+	for i, result := range s.cachedResults {
+		if s.cachedKinds[i].includes(kind) {
+			results = append(results, result)
+		}
+	}
+	if len(results) == 0 {
+		return results, StatusUp
+	}
+	return results, s.aggregateStatus(results)
+}
+
+// probe returns the kind probe's status, from the cache in async mode
+func (s *Service) probe(ctx context.Context, kind ProviderKind) HealthStatus {
+	var status HealthStatus
+	if s.config.AsyncMode {
+		_, status = s.cachedKind(kind)
+	} else {
+		_, status = s.checkKind(ctx, kind)
+	}
+	return status
+}
+
+// runChecks runs providers in parallel, each bounded by its timeout
+func (s *Service) runChecks(ctx context.Context, providers []HealthProvider) []HealthCheckResult {
 	results := make([]HealthCheckResult, len(providers))
 	var wg sync.WaitGroup
 
@@ -99,12 +223,24 @@ func (s *Service) Check(ctx context.Context) ([]HealthCheckResult, HealthStatus)
 			defer wg.Done()
 
 			// Create a timeout context
-			checkCtx, cancel := context.WithTimeout(ctx, s.config.DefaultTimeout)
+			checkCtx, cancel := context.WithTimeout(ctx, s.checkTimeout(p))
 			defer cancel()
 
 			// Run check with timeout
 			resultCh := make(chan HealthCheckResult, 1)
 			go func() {
+				// Convert a panicking provider into a DOWN result
+				defer func() {
+					if r := recover(); r != nil {
+						resultCh <- HealthCheckResult{
+							Name:      p.Name(),
+							Status:    StatusDown,
+							Details:   map[string]interface{}{"error": "panic"},
+							CheckedAt: time.Now(),
+							Error:     fmt.Sprintf("health check panicked: %v", r),
+						}
+					}
+				}()
 				resultCh <- p.Check(checkCtx)
 			}()
 
@@ -124,20 +260,17 @@ func (s *Service) Check(ctx context.Context) ([]HealthCheckResult, HealthStatus)
 	}
 
 	wg.Wait()
+	return results
+}
 
-	// Aggregate status
-	overallStatus := s.aggregateStatus(results)
-
-	// Update cache if in async mode
-	if s.config.AsyncMode {
-		s.mu.Lock()
-		s.cachedResults = results
-		s.cachedStatus = overallStatus
-		s.lastCheck = time.Now()
-		s.mu.Unlock()
+// checkTimeout returns the provider's own timeout, or DefaultTimeout
+func (s *Service) checkTimeout(p HealthProvider) time.Duration {
+	if tp, ok := p.(TimeoutProvider); ok {
+		if timeout := tp.CheckTimeout(); timeout > 0 {
+			return timeout
+		}
 	}
-
-	return results, overallStatus
+	return s.config.DefaultTimeout
 }
 
 // GetCachedResults returns cached results if async mode is enabled
@@ -157,6 +290,21 @@ func (s *Service) GetCachedResults() ([]HealthCheckResult, HealthStatus) {
 	return resultsCopy, s.cachedStatus
 }
 
+// CacheAge returns the age of the cached results and whether they are
+// older than MaxStaleness. Results that were never populated are stale.
+func (s *Service) CacheAge() (time.Duration, bool) {
+	s.mu.RLock()
+	lastCheck := s.lastCheck
+	s.mu.RUnlock()
+
+	if lastCheck.IsZero() {
+		return 0, true
+	}
+
+	age := time.Since(lastCheck)
+	return age, age > s.config.MaxStaleness
+}
+
 // aggregateStatus aggregates multiple health check results
 func (s *Service) aggregateStatus(results []HealthCheckResult) HealthStatus {
 	if len(results) == 0 {
@@ -292,32 +440,64 @@ func (s *Service) startAsyncChecking() {
 	}()
 }
 
-// Stop stops the health service (for async mode)
+// Stop stops the health service. It is safe to call multiple times
+// and in non-async mode.
 func (s *Service) Stop() {
-	if s.config.AsyncMode {
+	s.stopOnce.Do(func() {
 		close(s.stopCh)
-		s.wg.Wait()
-	}
+	})
+	s.wg.Wait()
 }
 
 // GetHealthResponse returns a formatted health response
-func (s *Service) GetHealthResponse(ctx context.Context) HealthResponse {
+func (s *Service) GetHealthResponse(ctx context.Context, opts ...ResponseOption) HealthResponse {
+	var options responseOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	var results []HealthCheckResult
 	var status HealthStatus
 
-	if s.config.AsyncMode {
-		results, status = s.GetCachedResults()
-	} else {
+	if !s.config.AsyncMode {
+		start := time.Now()
 		results, status = s.Check(ctx)
+		duration := time.Since(start)
+		if options.redact {
+			results = s.redact(results)
+		}
+		return HealthResponse{
+			Status:    status,
+			Timestamp: time.Now(),
+			Checks:    results,
+			Details: map[string]interface{}{
+				"total_checks":      len(results),
+				"strategy":          s.config.AggregationStrategy,
+				"check_duration_ms": duration.Milliseconds(),
+			},
+		}
 	}
 
+	results, status = s.GetCachedResults()
+	if options.redact {
+		results = s.redact(results)
+	}
+	age, stale := s.CacheAge()
+
+	s.mu.RLock()
+	duration := s.lastDuration
+	s.mu.RUnlock()
+
 	return HealthResponse{
 		Status:    status,
 		Timestamp: time.Now(),
 		Checks:    results,
+		Stale:     stale,
 		Details: map[string]interface{}{
-			"total_checks": len(results),
-			"strategy":     s.config.AggregationStrategy,
+			"total_checks":      len(results),
+			"strategy":          s.config.AggregationStrategy,
+			"cache_age_ms":      age.Milliseconds(),
+			"check_duration_ms": duration.Milliseconds(),
 		},
 	}
 }
@@ -0,0 +1,163 @@
+package health
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type stubProvider struct {
+	name   string
+	status HealthStatus
+}
+
+func (p *stubProvider) Name() string { return p.name }
+
+func (p *stubProvider) Check(ctx context.Context) HealthCheckResult {
+	return HealthCheckResult{
+		Name:      p.name,
+		Status:    p.status,
+		CheckedAt: time.Now(),
+	}
+}
+
+func TestService_Stop_Idempotent(t *testing.T) {
+	svc := NewService(ServiceConfig{
+		AsyncMode:     true,
+		CheckInterval: 10 * time.Millisecond,
+	})
+	svc.RegisterProvider(&stubProvider{name: "stub", status: StatusUp})
+
+	assert.NotPanics(t, func() {
+		svc.Stop()
+		svc.Stop()
+	})
+}
+
+func TestService_Stop_NonAsync(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+
+	assert.NotPanics(t, func() {
+		svc.Stop()
+		svc.Stop()
+	})
+}
+
+func TestService_CacheAge_StalledChecker(t *testing.T) {
+	svc := NewService(ServiceConfig{
+		AsyncMode:     true,
+		CheckInterval: time.Hour,
+		MaxStaleness:  50 * time.Millisecond,
+	})
+	defer svc.Stop()
+	svc.RegisterProvider(&stubProvider{name: "stub", status: StatusUp})
+
+	// Populate the cache once; the ticker will not fire again within the test
+	svc.Check(context.Background())
+
+	resp := svc.GetHealthResponse(context.Background())
+	assert.False(t, resp.Stale)
+	assert.Contains(t, resp.Details, "cache_age_ms")
+
+	time.Sleep(100 * time.Millisecond)
+
+	_, stale := svc.CacheAge()
+	assert.True(t, stale)
+
+	resp = svc.GetHealthResponse(context.Background())
+	assert.True(t, resp.Stale)
+	assert.GreaterOrEqual(t, resp.Details["cache_age_ms"], int64(50))
+}
+
+func TestService_CacheAge_NeverChecked(t *testing.T) {
+	svc := NewService(ServiceConfig{
+		AsyncMode:     true,
+		CheckInterval: time.Hour,
+		MaxStaleness:  time.Hour,
+	})
+	svc.Stop()
+
+	_, stale := svc.CacheAge()
+	assert.True(t, stale)
+}
+
+type panicProvider struct {
+	name string
+}
+
+func (p *panicProvider) Name() string { return p.name }
+
+func (p *panicProvider) Check(ctx context.Context) HealthCheckResult {
+	panic("boom")
+}
+
+func TestService_Check_ProviderPanic(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProvider(&panicProvider{name: "bad"})
+	svc.RegisterProvider(&stubProvider{name: "good", status: StatusUp})
+
+	var results []HealthCheckResult
+	var status HealthStatus
+	assert.NotPanics(t, func() {
+		results, status = svc.Check(context.Background())
+	})
+
+	assert.Equal(t, StatusDown, status)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "bad", results[0].Name)
+	assert.Equal(t, StatusDown, results[0].Status)
+	assert.Contains(t, results[0].Error, "boom")
+	assert.Equal(t, "good", results[1].Name)
+	assert.Equal(t, StatusUp, results[1].Status)
+}
+
+// slowProvider takes delay to answer and asks for its own timeout
+type slowProvider struct {
+	name    string
+	delay   time.Duration
+	timeout time.Duration
+}
+
+func (p *slowProvider) Name() string                { return p.name }
+func (p *slowProvider) CheckTimeout() time.Duration { return p.timeout }
+
+func (p *slowProvider) Check(ctx context.Context) HealthCheckResult {
+	select {
+	case <-time.After(p.delay):
+		return HealthCheckResult{Name: p.name, Status: StatusUp, CheckedAt: time.Now()}
+	case <-ctx.Done():
+		return HealthCheckResult{Name: p.name, Status: StatusDown, CheckedAt: time.Now()}
+	}
+}
+
+func TestService_Check_PerProviderTimeout(t *testing.T) {
+	svc := NewService(ServiceConfig{DefaultTimeout: 20 * time.Millisecond})
+	// Needs longer than the default and says so
+	svc.RegisterProvider(&slowProvider{name: "partner-api", delay: 50 * time.Millisecond, timeout: time.Second})
+	// Falls back to the default
+	svc.RegisterProvider(&slowProvider{name: "cache", delay: 50 * time.Millisecond})
+
+	results, _ := svc.Check(context.Background())
+
+	assert.Equal(t, StatusUp, results[0].Status)
+	assert.Equal(t, StatusDown, results[1].Status)
+	assert.Equal(t, "health check timeout", results[1].Error)
+}
+
+func TestService_GetHealthResponse_CheckDuration(t *testing.T) {
+	svc := NewService(DefaultServiceConfig())
+	svc.RegisterProvider(&slowProvider{name: "database", delay: 30 * time.Millisecond})
+
+	resp := svc.GetHealthResponse(context.Background())
+	assert.GreaterOrEqual(t, resp.Details["check_duration_ms"], int64(30))
+
+	async := NewService(ServiceConfig{AsyncMode: true, CheckInterval: time.Hour})
+	defer async.Stop()
+	async.RegisterProvider(&slowProvider{name: "database", delay: 30 * time.Millisecond})
+	async.Check(context.Background())
+
+	resp = async.GetHealthResponse(context.Background())
+	assert.GreaterOrEqual(t, resp.Details["check_duration_ms"], int64(30))
+}
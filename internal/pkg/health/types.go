@@ -34,12 +34,44 @@ type HealthProvider interface {
 	Check(ctx context.Context) HealthCheckResult
 }
 
+// TimeoutProvider is implemented by providers that need a different
+// timeout than the service's DefaultTimeout, e.g. a slow external API
+type TimeoutProvider interface {
+	// CheckTimeout returns the timeout for Check; zero uses DefaultTimeout
+	CheckTimeout() time.Duration
+}
+
+// ProviderKind says which probes a provider takes part in
+type ProviderKind string
+
+const (
+	// KindLiveness is for checks that the process itself is working, e.g. a
+	// stuck worker; failing them means the process should be restarted
+	KindLiveness ProviderKind = "LIVENESS"
+	// KindReadiness is for dependencies such as databases; failing them
+	// means the instance should stop receiving traffic
+	KindReadiness ProviderKind = "READINESS"
+	// KindBoth counts toward liveness and readiness
+	KindBoth ProviderKind = "BOTH"
+)
+
+// includes reports whether a provider of kind k belongs to the kind probe
+func (k ProviderKind) includes(kind ProviderKind) bool {
+	return k == KindBoth || k == kind
+}
+
 // HealthAggregator aggregates multiple health providers
 type HealthAggregator interface {
-	// RegisterProvider registers a health provider
+	// RegisterProvider registers a health provider for readiness
 	RegisterProvider(p HealthProvider)
+	// RegisterProviderWithKind registers a health provider for the given probes
+	RegisterProviderWithKind(p HealthProvider, kind ProviderKind)
 	// Check runs all health checks and returns aggregated results
 	Check(ctx context.Context) ([]HealthCheckResult, HealthStatus)
+	// CheckLiveness runs the liveness checks and returns aggregated results
+	CheckLiveness(ctx context.Context) ([]HealthCheckResult, HealthStatus)
+	// CheckReadiness runs the readiness checks and returns aggregated results
+	CheckReadiness(ctx context.Context) ([]HealthCheckResult, HealthStatus)
 	// GetCachedResults returns cached results if async mode is enabled
 	GetCachedResults() ([]HealthCheckResult, HealthStatus)
 }
@@ -61,5 +93,6 @@ type HealthResponse struct {
 	Status    HealthStatus           `json:"status"`
 	Timestamp time.Time              `json:"timestamp"`
 	Checks    []HealthCheckResult    `json:"checks"`
+	Stale     bool                   `json:"stale,omitempty"`
 	Details   map[string]interface{} `json:"details,omitempty"`
 }
@@ -1,7 +1,11 @@
 package idempotency
 
 import (
+	"bytes"
+	"encoding/gob"
 	"encoding/json"
+
+	"github.com/vmihailenco/msgpack/v5"
 )
 
 // jsonSerializer implements Serializer using JSON encoding
@@ -21,3 +25,56 @@ func (s *jsonSerializer) Marshal(v any) ([]byte, error) {
 func (s *jsonSerializer) Unmarshal(data []byte, v any) error {
 	return json.Unmarshal(data, v)
 }
+
+// gobSerializer implements Serializer using encoding/gob
+type gobSerializer struct{}
+
+// NewGobSerializer creates a new gob-based serializer
+// Gob keeps Go types exactly (ints stay ints, []byte stays binary) but only
+// decodes into a concrete type, so use it with ExecuteTyped: Execute decodes
+// cached results into an interface and fails on gob data.
+func NewGobSerializer() Serializer {
+	return &gobSerializer{}
+}
+
+// Marshal serializes a value to gob bytes
+func (s *gobSerializer) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserializes gob bytes to a value
+func (s *gobSerializer) Unmarshal(data []byte, v any) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// msgpackSerializer implements Serializer using MessagePack encoding
+type msgpackSerializer struct{}
+
+// NewMsgpackSerializer creates a new MessagePack-based serializer
+// Output is smaller and faster to encode than JSON and stores []byte without
+// base64. Struct fields use `msgpack` tags, falling back to `json` tags.
+func NewMsgpackSerializer() Serializer {
+	return &msgpackSerializer{}
+}
+
+// Marshal serializes a value to MessagePack bytes
+func (s *msgpackSerializer) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal deserializes MessagePack bytes to a value
+func (s *msgpackSerializer) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
@@ -0,0 +1,86 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type serializerLineItem struct {
+	SKU      string  `json:"sku"`
+	Quantity int     `json:"quantity"`
+	Price    float64 `json:"price"`
+}
+
+type serializerOrder struct {
+	ID        int64                `json:"id"`
+	Customer  *string              `json:"customer"`
+	Items     []serializerLineItem `json:"items"`
+	Tags      map[string]string    `json:"tags"`
+	Receipt   []byte               `json:"receipt"`
+	Paid      bool                 `json:"paid"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+func TestSerializers_ExecuteTypedRoundTrip(t *testing.T) {
+	serializers := map[string]Serializer{
+		"json":    NewJSONSerializer(),
+		"gob":     NewGobSerializer(),
+		"msgpack": NewMsgpackSerializer(),
+	}
+
+	customer := "alice"
+	want := serializerOrder{
+		ID:       42,
+		Customer: &customer,
+		Items: []serializerLineItem{
+			{SKU: "A-1", Quantity: 2, Price: 9.99},
+			{SKU: "B-7", Quantity: 1, Price: 120.5},
+		},
+		Tags:      map[string]string{"channel": "web", "coupon": "SPRING"},
+		Receipt:   []byte{0x00, 0xff, 0x10, 0x80},
+		Paid:      true,
+		CreatedAt: time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC),
+	}
+
+	for name, serializer := range serializers {
+		t.Run(name, func(t *testing.T) {
+			svc := NewService(NewMemoryStorage(), serializer)
+			ctx := context.Background()
+			ttl := 5 * time.Minute
+
+			callCount := 0
+			fn := func(ctx context.Context) (serializerOrder, error) {
+				callCount++
+				return want, nil
+			}
+
+			first, err := ExecuteTyped(svc, ctx, "order-"+name, ttl, fn)
+			require.NoError(t, err)
+			assert.Equal(t, want, first)
+
+			// The cached result goes through the serializer
+			cached, err := ExecuteTyped(svc, ctx, "order-"+name, ttl, fn)
+			require.NoError(t, err)
+			assert.Equal(t, 1, callCount)
+
+			assert.True(t, want.CreatedAt.Equal(cached.CreatedAt), "created_at: want %v, got %v", want.CreatedAt, cached.CreatedAt)
+			cached.CreatedAt = want.CreatedAt
+			assert.Equal(t, want, cached)
+		})
+	}
+}
+
+func TestGobSerializer_RejectsUntypedDecode(t *testing.T) {
+	serializer := NewGobSerializer()
+
+	data, err := serializer.Marshal(serializerLineItem{SKU: "A-1"})
+	require.NoError(t, err)
+
+	// Execute decodes cached results into any, which gob can't do
+	var result any
+	assert.Error(t, serializer.Unmarshal(data, &result))
+}
@@ -2,33 +2,110 @@ package idempotency
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"time"
 )
 
 // service implements the Service interface
 type service struct {
-	storage    Storage
-	serializer Serializer
+	storage     Storage
+	serializer  Serializer
+	failureMode FailureMode
+	maxRetries  int
+}
+
+// Option is a functional option for configuring the service
+type Option func(*service)
+
+// WithFailureMode sets what happens when a key's last run failed
+// The default is BlockForever.
+func WithFailureMode(mode FailureMode) Option {
+	return func(s *service) {
+		s.failureMode = mode
+	}
+}
+
+// WithMaxRetries caps how many times AllowRetry re-runs a failed key before
+// the failure becomes terminal (0 = unlimited)
+func WithMaxRetries(n int) Option {
+	return func(s *service) {
+		s.maxRetries = n
+	}
 }
 
 // NewService creates a new idempotency service
-func NewService(storage Storage, serializer Serializer) Service {
+func NewService(storage Storage, serializer Serializer, opts ...Option) Service {
 	if serializer == nil {
 		serializer = NewJSONSerializer()
 	}
-	return &service{
-		storage:    storage,
-		serializer: serializer,
+	s := &service{
+		storage:     storage,
+		serializer:  serializer,
+		failureMode: BlockForever,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
 }
 
 // Execute ensures idempotent execution of fn for the given key
+// A cached result is decoded into the context's decode target when
+// ExecuteTyped set one, and into a generic value otherwise.
 func (s *service) Execute(
 	ctx context.Context,
 	key string,
 	ttl time.Duration,
 	fn func(ctx context.Context) (any, error),
+) (any, error) {
+	out := decodeTarget(ctx)
+	// fn may run its own Execute, which must not decode into out
+	ctx = withDecodeTarget(ctx, nil)
+
+	return s.run(ctx, key, ttl, fn, func(data []byte) (any, error) {
+		if out == nil {
+			var result any
+			err := s.serializer.Unmarshal(data, &result)
+			return result, err
+		}
+		if err := s.serializer.Unmarshal(data, out); err != nil {
+			return nil, err
+		}
+		return reflect.ValueOf(out).Elem().Interface(), nil
+	})
+}
+
+// decodeTargetKey is the context key for the pointer a cached result is decoded into
+type decodeTargetKey struct{}
+
+// withDecodeTarget returns a context asking Execute to decode a cached
+// result into out, a non-nil pointer; nil clears it
+func withDecodeTarget(ctx context.Context, out any) context.Context {
+	return context.WithValue(ctx, decodeTargetKey{}, out)
+}
+
+// decodeTarget returns the context's decode target, or nil
+func decodeTarget(ctx context.Context) any {
+	out := ctx.Value(decodeTargetKey{})
+	if out == nil {
+		return nil
+	}
+	if target := reflect.ValueOf(out); target.Kind() != reflect.Pointer || target.IsNil() {
+		return nil
+	}
+	return out
+}
+
+// run executes fn once per key, returning the cached result decoded with
+// decode when the key already completed
+func (s *service) run(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	fn func(ctx context.Context) (any, error),
+	decode func(data []byte) (any, error),
 ) (any, error) {
 	// Step 1: Load existing record
 	record, err := s.storage.Load(ctx, key)
@@ -37,12 +114,14 @@ func (s *service) Execute(
 	}
 
 	// Step 2: Handle existing states
+	failures := 0
+	marked := false
 	if record != nil {
 		switch record.Status {
 		case StatusCompleted:
 			// Return cached result
-			var result any
-			if err := s.serializer.Unmarshal(record.Result, &result); err != nil {
+			result, err := decode(record.Result)
+			if err != nil {
 				return nil, fmt.Errorf("%w: failed to unmarshal cached result: %v", ErrSerializationFailure, err)
 			}
 			return result, nil
@@ -53,18 +132,30 @@ func (s *service) Execute(
 
 		case StatusFailed:
 			// Previous attempt failed
-			return nil, fmt.Errorf("%w: %s", ErrPreviouslyFailed, record.ErrorMsg)
+			if !s.canRetry(record) {
+				return nil, fmt.Errorf("%w: %s", ErrPreviouslyFailed, record.ErrorMsg)
+			}
+			if marked, err = s.storage.TryMarkRetry(ctx, key, ttl); err != nil {
+				return nil, fmt.Errorf("%w: failed to mark retry: %v", ErrStorageFailure, err)
+			}
+			if !marked {
+				// Another process is already retrying
+				return nil, ErrAlreadyProcessing
+			}
+			failures = record.Attempts
 		}
 	}
 
 	// Step 3: Try to mark as processing
-	marked, err := s.storage.TryMarkProcessing(ctx, key, ttl)
-	if err != nil {
-		return nil, fmt.Errorf("%w: failed to mark processing: %v", ErrStorageFailure, err)
-	}
 	if !marked {
-		// Another process won the race
-		return nil, ErrAlreadyProcessing
+		marked, err = s.storage.TryMarkProcessing(ctx, key, ttl)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to mark processing: %v", ErrStorageFailure, err)
+		}
+		if !marked {
+			// Another process won the race
+			return nil, ErrAlreadyProcessing
+		}
 	}
 
 	// Step 4: Execute the function
@@ -72,7 +163,7 @@ func (s *service) Execute(
 
 	// Step 5: Save the result or error
 	if execErr != nil {
-		if saveErr := s.storage.SaveError(ctx, key, execErr.Error(), ttl); saveErr != nil {
+		if saveErr := s.storage.SaveError(ctx, key, execErr.Error(), failures+1, ttl); saveErr != nil {
 			return nil, fmt.Errorf("%w: failed to save error state: %v (original error: %v)", ErrStorageFailure, saveErr, execErr)
 		}
 		return nil, execErr
@@ -81,7 +172,7 @@ func (s *service) Execute(
 	// Serialize the result
 	resultBytes, err := s.serializer.Marshal(result)
 	if err != nil {
-		saveErr := s.storage.SaveError(ctx, key, fmt.Sprintf("serialization failed: %v", err), ttl)
+		saveErr := s.storage.SaveError(ctx, key, fmt.Sprintf("serialization failed: %v", err), failures+1, ttl)
 		if saveErr != nil {
 			return nil, fmt.Errorf("%w: failed to serialize result: %v (save error: %v)", ErrSerializationFailure, err, saveErr)
 		}
@@ -96,7 +187,24 @@ func (s *service) Execute(
 	return result, nil
 }
 
+// canRetry reports whether a failed record may be run again
+// Records from before attempts were tracked count as one failure.
+func (s *service) canRetry(record *Record) bool {
+	if s.failureMode != AllowRetry {
+		return false
+	}
+	if s.maxRetries <= 0 {
+		return true
+	}
+	return max(record.Attempts, 1) <= s.maxRetries
+}
+
 // ExecuteTyped is a generic helper function for type-safe execution
+// It goes through svc.Execute, so it works with any Service, including
+// decorators, and follows the service's failure mode like Execute. Services
+// built by NewService decode a cached result straight into T with their
+// serializer; a generic result from any other Service is converted to T with
+// a JSON round trip.
 func ExecuteTyped[T any](
 	svc Service,
 	ctx context.Context,
@@ -104,76 +212,29 @@ func ExecuteTyped[T any](
 	ttl time.Duration,
 	fn func(ctx context.Context) (T, error),
 ) (T, error) {
-	var zero T
-
-	// Get the underlying service implementation to access storage directly
-	serviceImpl, ok := svc.(*service)
-	if !ok {
-		return zero, fmt.Errorf("ExecuteTyped requires *service implementation")
-	}
-
-	// Step 1: Load existing record
-	record, err := serviceImpl.storage.Load(ctx, key)
+	var zero, cached T
+	result, err := svc.Execute(withDecodeTarget(ctx, &cached), key, ttl, func(ctx context.Context) (any, error) {
+		return fn(ctx)
+	})
 	if err != nil {
-		return zero, fmt.Errorf("%w: failed to load record: %v", ErrStorageFailure, err)
-	}
-
-	// Step 2: Handle existing states
-	if record != nil {
-		switch record.Status {
-		case StatusCompleted:
-			// Return cached result with proper deserialization
-			var result T
-			if err := serviceImpl.serializer.Unmarshal(record.Result, &result); err != nil {
-				return zero, fmt.Errorf("%w: failed to unmarshal cached result: %v", ErrSerializationFailure, err)
-			}
-			return result, nil
-
-		case StatusProcessing:
-			// Another process is handling this
-			return zero, ErrAlreadyProcessing
-
-		case StatusFailed:
-			// Previous attempt failed
-			return zero, fmt.Errorf("%w: %s", ErrPreviouslyFailed, record.ErrorMsg)
-		}
+		return zero, err
 	}
 
-	// Step 3: Try to mark as processing
-	marked, err := serviceImpl.storage.TryMarkProcessing(ctx, key, ttl)
-	if err != nil {
-		return zero, fmt.Errorf("%w: failed to mark processing: %v", ErrStorageFailure, err)
+	// A nil result of an interface type T is returned as its zero value
+	if result == nil {
+		return zero, nil
 	}
-	if !marked {
-		// Another process won the race
-		return zero, ErrAlreadyProcessing
+	if typed, ok := result.(T); ok {
+		return typed, nil
 	}
 
-	// Step 4: Execute the function
-	result, execErr := fn(ctx)
-
-	// Step 5: Save the result or error
-	if execErr != nil {
-		if saveErr := serviceImpl.storage.SaveError(ctx, key, execErr.Error(), ttl); saveErr != nil {
-			return zero, fmt.Errorf("%w: failed to save error state: %v (original error: %v)", ErrStorageFailure, saveErr, execErr)
-		}
-		return zero, execErr
-	}
-
-	// Serialize the result
-	resultBytes, err := serviceImpl.serializer.Marshal(result)
+	data, err := json.Marshal(result)
 	if err != nil {
-		saveErr := serviceImpl.storage.SaveError(ctx, key, fmt.Sprintf("serialization failed: %v", err), ttl)
-		if saveErr != nil {
-			return zero, fmt.Errorf("%w: failed to serialize result: %v (save error: %v)", ErrSerializationFailure, err, saveErr)
-		}
-		return zero, fmt.Errorf("%w: %v", ErrSerializationFailure, err)
+		return zero, fmt.Errorf("%w: expected %T, got %T: %v", ErrSerializationFailure, zero, result, err)
 	}
-
-	// Save the successful result
-	if err := serviceImpl.storage.SaveResult(ctx, key, resultBytes, ttl); err != nil {
-		return zero, fmt.Errorf("%w: failed to save result: %v", ErrStorageFailure, err)
+	var typed T
+	if err := json.Unmarshal(data, &typed); err != nil {
+		return zero, fmt.Errorf("%w: expected %T, got %T: %v", ErrSerializationFailure, zero, result, err)
 	}
-
-	return result, nil
+	return typed, nil
 }
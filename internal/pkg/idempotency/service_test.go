@@ -199,3 +199,245 @@ func TestMemoryStorage_Expiry(t *testing.T) {
 	require.NoError(t, err)
 	assert.Nil(t, record)
 }
+
+func TestService_Execute_AllowRetry(t *testing.T) {
+	svc := NewService(NewMemoryStorage(), nil, WithFailureMode(AllowRetry))
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(ctx context.Context) (any, error) {
+		calls++
+		if calls < 3 {
+			return nil, errors.New("downstream unavailable")
+		}
+		return "ok", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		_, err := svc.Execute(ctx, "retry-key", time.Minute, fn)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrPreviouslyFailed)
+	}
+
+	result, err := svc.Execute(ctx, "retry-key", time.Minute, fn)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+
+	// Once completed the result is cached as usual
+	result, err = svc.Execute(ctx, "retry-key", time.Minute, fn)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", result)
+	assert.Equal(t, 3, calls)
+}
+
+func TestService_Execute_MaxRetries(t *testing.T) {
+	storage := NewMemoryStorage()
+	svc := NewService(storage, nil, WithFailureMode(AllowRetry), WithMaxRetries(2))
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(ctx context.Context) (any, error) {
+		calls++
+		return nil, errors.New("downstream unavailable")
+	}
+
+	// The first run and two retries execute fn
+	for i := 0; i < 3; i++ {
+		_, err := svc.Execute(ctx, "max-key", time.Minute, fn)
+		assert.NotErrorIs(t, err, ErrPreviouslyFailed)
+	}
+
+	_, err := svc.Execute(ctx, "max-key", time.Minute, fn)
+	assert.ErrorIs(t, err, ErrPreviouslyFailed)
+	assert.Equal(t, 3, calls)
+
+	record, err := storage.Load(ctx, "max-key")
+	require.NoError(t, err)
+	assert.Equal(t, 3, record.Attempts)
+}
+
+func TestService_Execute_BlockForeverIsDefault(t *testing.T) {
+	svc := NewService(NewMemoryStorage(), nil, WithMaxRetries(5))
+	ctx := context.Background()
+
+	calls := 0
+	fn := func(ctx context.Context) (any, error) {
+		calls++
+		return nil, errors.New("failed")
+	}
+
+	_, _ = svc.Execute(ctx, "block-key", time.Minute, fn)
+	_, err := svc.Execute(ctx, "block-key", time.Minute, fn)
+	assert.ErrorIs(t, err, ErrPreviouslyFailed)
+	assert.Equal(t, 1, calls)
+}
+
+func TestService_ExecuteTyped_AllowRetry(t *testing.T) {
+	svc := NewService(NewMemoryStorage(), nil, WithFailureMode(AllowRetry), WithMaxRetries(1))
+	ctx := context.Background()
+
+	type Receipt struct {
+		ID string `json:"id"`
+	}
+
+	calls := 0
+	fn := func(ctx context.Context) (Receipt, error) {
+		calls++
+		if calls == 1 {
+			return Receipt{}, errors.New("timeout")
+		}
+		return Receipt{ID: "r-1"}, nil
+	}
+
+	_, err := ExecuteTyped(svc, ctx, "typed-key", time.Minute, fn)
+	require.Error(t, err)
+
+	receipt, err := ExecuteTyped(svc, ctx, "typed-key", time.Minute, fn)
+	require.NoError(t, err)
+	assert.Equal(t, "r-1", receipt.ID)
+
+	cached, err := ExecuteTyped(svc, ctx, "typed-key", time.Minute, fn)
+	require.NoError(t, err)
+	assert.Equal(t, Receipt{ID: "r-1"}, cached)
+	assert.Equal(t, 2, calls)
+}
+
+func TestMemoryStorage_TryMarkRetry(t *testing.T) {
+	storage := NewMemoryStorage()
+	ctx := context.Background()
+
+	marked, err := storage.TryMarkRetry(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, marked, "missing keys are not retried")
+
+	require.NoError(t, storage.SaveError(ctx, "k", "boom", 2, time.Minute))
+
+	marked, err = storage.TryMarkRetry(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.True(t, marked)
+
+	// Only one caller wins the retry
+	marked, err = storage.TryMarkRetry(ctx, "k", time.Minute)
+	require.NoError(t, err)
+	assert.False(t, marked)
+
+	record, err := storage.Load(ctx, "k")
+	require.NoError(t, err)
+	assert.Equal(t, StatusProcessing, record.Status)
+	assert.Equal(t, 2, record.Attempts)
+}
+
+// loggingService decorates a Service the way callers wrap it for logging
+type loggingService struct {
+	Service
+	calls []string
+}
+
+func (l *loggingService) Execute(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) (any, error) {
+	l.calls = append(l.calls, "Execute "+key)
+	return l.Service.Execute(ctx, key, ttl, fn)
+}
+
+func TestExecuteTyped_WrappedService(t *testing.T) {
+	type Order struct {
+		ID     string `json:"id"`
+		Amount int    `json:"amount"`
+	}
+
+	for name, serializer := range map[string]Serializer{
+		"json": NewJSONSerializer(),
+		"gob":  NewGobSerializer(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			svc := &loggingService{Service: NewService(NewMemoryStorage(), serializer)}
+			ctx := context.Background()
+
+			calls := 0
+			fn := func(ctx context.Context) (Order, error) {
+				calls++
+				return Order{ID: "o-1", Amount: 42}, nil
+			}
+
+			order, err := ExecuteTyped[Order](svc, ctx, "order-key", time.Minute, fn)
+			require.NoError(t, err)
+			assert.Equal(t, Order{ID: "o-1", Amount: 42}, order)
+
+			// The cached result is decoded into Order, not a map
+			order, err = ExecuteTyped[Order](svc, ctx, "order-key", time.Minute, fn)
+			require.NoError(t, err)
+			assert.Equal(t, Order{ID: "o-1", Amount: 42}, order)
+
+			assert.Equal(t, 1, calls)
+			assert.Equal(t, []string{"Execute order-key", "Execute order-key"}, svc.calls)
+		})
+	}
+}
+
+func TestTypedService_CachedResult(t *testing.T) {
+	typed := NewTypedService[[]string](NewService(NewMemoryStorage(), nil))
+	ctx := context.Background()
+
+	fn := func(ctx context.Context) ([]string, error) {
+		return []string{"a", "b"}, nil
+	}
+
+	_, err := typed.Execute(ctx, "list-key", time.Minute, fn)
+	require.NoError(t, err)
+
+	cached, err := typed.Execute(ctx, "list-key", time.Minute, fn)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, cached)
+}
+
+// genericService returns cached results as generic values, like a Service
+// that doesn't come from NewService
+type genericService struct {
+	result any
+}
+
+func (g *genericService) Execute(ctx context.Context, key string, ttl time.Duration, fn func(ctx context.Context) (any, error)) (any, error) {
+	return g.result, nil
+}
+
+func TestExecuteTyped_ConvertsGenericResult(t *testing.T) {
+	type Order struct {
+		ID     string `json:"id"`
+		Amount int    `json:"amount"`
+	}
+	svc := &genericService{result: map[string]any{"id": "o-1", "amount": float64(42)}}
+
+	order, err := ExecuteTyped(svc, context.Background(), "order-key", time.Minute, func(ctx context.Context) (Order, error) {
+		return Order{}, errors.New("not called")
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Order{ID: "o-1", Amount: 42}, order)
+
+	svc.result = "not an order"
+	_, err = ExecuteTyped(svc, context.Background(), "order-key", time.Minute, func(ctx context.Context) (Order, error) {
+		return Order{}, nil
+	})
+	assert.ErrorIs(t, err, ErrSerializationFailure)
+}
+
+func TestExecuteTyped_NestedExecuteIsGeneric(t *testing.T) {
+	svc := NewService(NewMemoryStorage(), nil)
+	ctx := context.Background()
+
+	_, err := svc.Execute(ctx, "inner", time.Minute, func(ctx context.Context) (any, error) {
+		return "cached", nil
+	})
+	require.NoError(t, err)
+
+	// The inner call must not decode into the outer call's int
+	var inner any
+	total, err := ExecuteTyped(svc, ctx, "outer", time.Minute, func(ctx context.Context) (int, error) {
+		var err error
+		inner, err = svc.Execute(ctx, "inner", time.Minute, func(ctx context.Context) (any, error) {
+			return nil, errors.New("not called")
+		})
+		return 7, err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 7, total)
+	assert.Equal(t, "cached", inner)
+}
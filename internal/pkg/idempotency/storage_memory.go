@@ -118,7 +118,7 @@ func (s *memoryStorage) SaveResult(ctx context.Context, key string, result []byt
 }
 
 // SaveError saves the error state
-func (s *memoryStorage) SaveError(ctx context.Context, key string, errMsg string, ttl time.Duration) error {
+func (s *memoryStorage) SaveError(ctx context.Context, key string, errMsg string, attempts int, ttl time.Duration) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -126,6 +126,7 @@ func (s *memoryStorage) SaveError(ctx context.Context, key string, errMsg string
 		Key:       key,
 		Status:    StatusFailed,
 		ErrorMsg:  errMsg,
+		Attempts:  attempts,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		TTL:       ttl,
@@ -138,3 +139,30 @@ func (s *memoryStorage) SaveError(ctx context.Context, key string, errMsg string
 
 	return nil
 }
+
+// TryMarkRetry atomically moves a failed key back to processing
+func (s *memoryStorage) TryMarkRetry(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mr, exists := s.records[key]
+	if !exists || time.Now().After(mr.expiresAt) || mr.record.Status != StatusFailed {
+		return false, nil
+	}
+
+	record := &Record{
+		Key:       key,
+		Status:    StatusProcessing,
+		Attempts:  mr.record.Attempts,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		TTL:       ttl,
+	}
+
+	s.records[key] = &memoryRecord{
+		record:    record,
+		expiresAt: time.Now().Add(ttl),
+	}
+
+	return true, nil
+}
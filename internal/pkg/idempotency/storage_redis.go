@@ -103,13 +103,14 @@ func (s *redisStorage) SaveResult(ctx context.Context, key string, result []byte
 }
 
 // SaveError saves the error state
-func (s *redisStorage) SaveError(ctx context.Context, key string, errMsg string, ttl time.Duration) error {
+func (s *redisStorage) SaveError(ctx context.Context, key string, errMsg string, attempts int, ttl time.Duration) error {
 	redisKey := s.makeKey(key)
 
 	record := Record{
 		Key:       key,
 		Status:    StatusFailed,
 		ErrorMsg:  errMsg,
+		Attempts:  attempts,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 		TTL:       ttl,
@@ -127,3 +128,59 @@ func (s *redisStorage) SaveError(ctx context.Context, key string, errMsg string,
 
 	return nil
 }
+
+// TryMarkRetry atomically moves a failed key back to processing
+// The key is watched so a concurrent retry or save aborts the transaction.
+func (s *redisStorage) TryMarkRetry(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	redisKey := s.makeKey(key)
+	marked := false
+
+	err := s.client.Watch(ctx, func(tx *redis.Tx) error {
+		data, err := tx.Get(ctx, redisKey).Bytes()
+		if err != nil {
+			if err == redis.Nil {
+				return nil
+			}
+			return err
+		}
+
+		var existing Record
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return fmt.Errorf("failed to unmarshal record: %w", err)
+		}
+		if existing.Status != StatusFailed {
+			return nil
+		}
+
+		record := Record{
+			Key:       key,
+			Status:    StatusProcessing,
+			Attempts:  existing.Attempts,
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+			TTL:       ttl,
+		}
+		updated, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal record: %w", err)
+		}
+
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, redisKey, updated, ttl)
+			return nil
+		})
+		if err == nil {
+			marked = true
+		}
+		return err
+	}, redisKey)
+
+	if err == redis.TxFailedErr {
+		// Another process changed the key first
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("redis retry mark failed: %w", err)
+	}
+	return marked, nil
+}
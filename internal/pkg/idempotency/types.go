@@ -2,7 +2,6 @@ package idempotency
 
 import (
 	"context"
-	"fmt"
 	"time"
 )
 
@@ -16,12 +15,24 @@ const (
 	StatusFailed     Status = "failed"
 )
 
+// FailureMode decides what Execute does with a key whose last run failed
+type FailureMode string
+
+const (
+	// BlockForever returns ErrPreviouslyFailed until the record expires
+	BlockForever FailureMode = "block_forever"
+	// AllowRetry clears the failed record and runs fn again
+	AllowRetry FailureMode = "allow_retry"
+)
+
 // Record represents an idempotency record with state and result
 type Record struct {
-	Key       string
-	Status    Status
-	Result    []byte
-	ErrorMsg  string
+	Key      string
+	Status   Status
+	Result   []byte
+	ErrorMsg string
+	// Attempts is how many runs have failed, set on failed records
+	Attempts  int
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	TTL       time.Duration
@@ -39,8 +50,12 @@ type Storage interface {
 	// SaveResult saves the successful result as completed
 	SaveResult(ctx context.Context, key string, result []byte, ttl time.Duration) error
 
-	// SaveError saves the error state as failed
-	SaveError(ctx context.Context, key string, errMsg string, ttl time.Duration) error
+	// SaveError saves the error state as failed after attempts failed runs
+	SaveError(ctx context.Context, key string, errMsg string, attempts int, ttl time.Duration) error
+
+	// TryMarkRetry atomically replaces a failed record with a processing one,
+	// keeping its Attempts. It returns false if the record is no longer failed.
+	TryMarkRetry(ctx context.Context, key string, ttl time.Duration) (bool, error)
 }
 
 // Serializer defines the interface for serializing/deserializing results
@@ -82,20 +97,5 @@ func (ts *TypedService[T]) Execute(
 	ttl time.Duration,
 	fn func(ctx context.Context) (T, error),
 ) (T, error) {
-	var zero T
-
-	result, err := ts.svc.Execute(ctx, key, ttl, func(ctx context.Context) (any, error) {
-		return fn(ctx)
-	})
-
-	if err != nil {
-		return zero, err
-	}
-
-	typed, ok := result.(T)
-	if !ok {
-		return zero, fmt.Errorf("%w: type assertion failed", ErrSerializationFailure)
-	}
-
-	return typed, nil
+	return ExecuteTyped(ts.svc, ctx, key, ttl, fn)
 }
@@ -0,0 +1,92 @@
+package pgnotify
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"strings"
+)
+
+// compressedPrefix marks a payload as gzip-compressed and base64-encoded.
+const compressedPrefix = "gz64:"
+
+// compressPayload gzips and base64-encodes a payload, adding compressedPrefix.
+func compressPayload(payload string) (string, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := zw.Write([]byte(payload)); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+
+	return compressedPrefix + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// decompressPayload reverses compressPayload. Payloads without the prefix are returned as is.
+func decompressPayload(payload string) (string, error) {
+	encoded, ok := strings.CutPrefix(payload, compressedPrefix)
+	if !ok {
+		return payload, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", err
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+
+	return string(decoded), nil
+}
+
+// encodePayload compresses a payload when compression is enabled and it is
+// over the threshold. The payload is sent as is if compressing doesn't make it
+// smaller, unless it starts with compressedPrefix and would be misread.
+func (c *Config) encodePayload(payload string) (string, error) {
+	if !c.Compression {
+		if len(payload) > c.MaxPayloadSize {
+			return "", ErrPayloadTooLarge
+		}
+		return payload, nil
+	}
+
+	ambiguous := strings.HasPrefix(payload, compressedPrefix)
+	if len(payload) <= c.CompressionThreshold && !ambiguous {
+		return payload, nil
+	}
+
+	compressed, err := compressPayload(payload)
+	if err != nil {
+		return "", err
+	}
+
+	if len(compressed) >= len(payload) && !ambiguous {
+		// Compression didn't help, e.g. already compressed or random data
+		if len(payload) > c.MaxPayloadSize {
+			return "", ErrCompressedPayloadTooLarge(len(payload), len(compressed), c.MaxPayloadSize)
+		}
+		return payload, nil
+	}
+
+	if len(compressed) > c.MaxPayloadSize {
+		return "", ErrCompressedPayloadTooLarge(len(payload), len(compressed), c.MaxPayloadSize)
+	}
+
+	return compressed, nil
+}
@@ -0,0 +1,132 @@
+package pgnotify
+
+import (
+	"context"
+	"crypto/rand"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// randomPayload returns incompressible data of the given size
+func randomPayload(t *testing.T, size int) string {
+	t.Helper()
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	require.NoError(t, err)
+	return string(buf)
+}
+
+func TestCompressPayload_RoundTrip(t *testing.T) {
+	payload := strings.Repeat(`{"id":1,"name":"widget"},`, 1000)
+
+	compressed, err := compressPayload(payload)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(compressed, compressedPrefix))
+	assert.Less(t, len(compressed), len(payload))
+
+	decoded, err := decompressPayload(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, payload, decoded)
+}
+
+func TestDecompressPayload_PlainPassesThrough(t *testing.T) {
+	decoded, err := decompressPayload("hello")
+	require.NoError(t, err)
+	assert.Equal(t, "hello", decoded)
+}
+
+func TestDecompressPayload_Corrupt(t *testing.T) {
+	_, err := decompressPayload(compressedPrefix + "not base64!")
+	assert.Error(t, err)
+}
+
+func TestConfig_EncodePayload(t *testing.T) {
+	large := strings.Repeat("a", 20000)
+	random := randomPayload(t, 2000)
+	randomTooLarge := randomPayload(t, 9000)
+
+	tests := []struct {
+		name           string
+		compression    bool
+		payload        string
+		wantCompressed bool
+		wantTooLarge   bool
+	}{
+		{name: "disabled small", payload: "hello"},
+		{name: "disabled too large", payload: large, wantTooLarge: true},
+		{name: "below threshold", compression: true, payload: "hello"},
+		{name: "large compressible", compression: true, payload: large, wantCompressed: true},
+		{name: "incompressible kept as is", compression: true, payload: random},
+		{name: "incompressible too large", compression: true, payload: randomTooLarge, wantTooLarge: true},
+		{name: "marker prefix always encoded", compression: true, payload: compressedPrefix + "x", wantCompressed: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Compression = tt.compression
+
+			encoded, err := config.encodePayload(tt.payload)
+			if tt.wantTooLarge {
+				assert.ErrorIs(t, err, ErrPayloadTooLarge)
+				return
+			}
+			require.NoError(t, err)
+			assert.LessOrEqual(t, len(encoded), config.MaxPayloadSize)
+
+			if !tt.wantCompressed {
+				assert.Equal(t, tt.payload, encoded)
+				return
+			}
+			assert.True(t, strings.HasPrefix(encoded, compressedPrefix))
+			decoded, err := decompressPayload(encoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.payload, decoded)
+		})
+	}
+}
+
+func TestConfig_EncodePayload_StillTooLargeAfterCompression(t *testing.T) {
+	config := DefaultConfig()
+	config.Compression = true
+	config.MaxPayloadSize = 100
+
+	// Compressible, but not down to 100 bytes
+	payload := randomPayload(t, 200) + strings.Repeat("a", 2000)
+	_, err := config.encodePayload(payload)
+	require.ErrorIs(t, err, ErrPayloadTooLarge)
+	assert.Contains(t, err.Error(), "limit is 100")
+}
+
+func TestDispatcher_DecompressesPayload(t *testing.T) {
+	var errs atomic.Int32
+	d, subMgr, _ := newTestDispatcher(t,
+		WithCompression(true),
+		WithHooks(&Hooks{OnError: func(err error, channel string) { errs.Add(1) }}),
+	)
+
+	var got atomic.Value
+	subMgr.Add("events", func(ctx context.Context, n *Notification) error {
+		got.Store(n.Payload)
+		return nil
+	}, nil)
+
+	payload := strings.Repeat("event ", 500)
+	compressed, err := compressPayload(payload)
+	require.NoError(t, err)
+
+	d.Dispatch(context.Background(), &Notification{Channel: "events", Payload: compressed})
+	d.Wait()
+	assert.Equal(t, payload, got.Load())
+
+	// Corrupt payloads are reported and not delivered
+	got.Store("")
+	d.Dispatch(context.Background(), &Notification{Channel: "events", Payload: compressedPrefix + "###"})
+	d.Wait()
+	assert.Equal(t, "", got.Load())
+	assert.Equal(t, int32(1), errs.Load())
+}
@@ -34,14 +34,38 @@ type Config struct {
 	// BufferSize is the size of the internal notification buffer channel
 	BufferSize int
 
+	// Compression enables transparent gzip+base64 encoding of large payloads.
+	// Both publishers and subscribers must enable it.
+	Compression bool
+
+	// CompressionThreshold is the payload size in bytes above which payloads are compressed
+	CompressionThreshold int
+
+	// DispatchWorkers is the number of goroutines delivering notifications to callbacks.
+	// Set to 0 to spawn one goroutine per subscription per notification (unbounded).
+	// When positive, deliveries are queued in a channel of BufferSize and dropped when it is full.
+	DispatchWorkers int
+
 	// Logger is the structured logger for the notifier
 	Logger *slog.Logger
 
 	// Hooks provides callbacks for monitoring and observability
 	Hooks *Hooks
 
+	// MetricsObserver receives metric events, e.g. to export them to Prometheus
+	MetricsObserver MetricsObserver
+
 	// ShutdownTimeout is the maximum time to wait for graceful shutdown
 	ShutdownTimeout time.Duration
+
+	// RelistenConcurrency is how many channels are re-LISTENed at once after a reconnect.
+	// Providers that implement BatchListener re-LISTEN every channel in one command instead.
+	RelistenConcurrency int
+
+	// RelistenTimeout bounds the LISTEN of a single channel, or of the whole batch
+	// for a BatchListener, after a reconnect.
+	// The whole re-registration is bounded by the time each worker needs for its share.
+	RelistenTimeout time.Duration
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -55,9 +79,15 @@ func DefaultConfig() *Config {
 		PingInterval:               30 * time.Second,
 		CallbackTimeout:            30 * time.Second,
 		BufferSize:                 100,
+		Compression:                false,
+		CompressionThreshold:       1024,
+		DispatchWorkers:            0, // unbounded
 		Logger:                     slog.Default(),
 		Hooks:                      &Hooks{},
+		MetricsObserver:            NoOpMetricsObserver{},
 		ShutdownTimeout:            10 * time.Second,
+		RelistenConcurrency:        8,
+		RelistenTimeout:            5 * time.Second,
 	}
 }
 
@@ -91,6 +121,14 @@ func (c *Config) Validate() error {
 		return ErrInvalidConfig("buffer_size must be positive")
 	}
 
+	if c.Compression && c.CompressionThreshold < 0 {
+		return ErrInvalidConfig("compression_threshold must be >= 0")
+	}
+
+	if c.DispatchWorkers < 0 {
+		return ErrInvalidConfig("dispatch_workers must be >= 0")
+	}
+
 	if c.Logger == nil {
 		return ErrInvalidConfig("logger cannot be nil")
 	}
@@ -99,10 +137,22 @@ func (c *Config) Validate() error {
 		c.Hooks = &Hooks{}
 	}
 
+	if c.MetricsObserver == nil {
+		c.MetricsObserver = NoOpMetricsObserver{}
+	}
+
 	if c.ShutdownTimeout <= 0 {
 		return ErrInvalidConfig("shutdown_timeout must be positive")
 	}
 
+	if c.RelistenConcurrency <= 0 {
+		return ErrInvalidConfig("relisten_concurrency must be positive")
+	}
+
+	if c.RelistenTimeout <= 0 {
+		return ErrInvalidConfig("relisten_timeout must be positive")
+	}
+
 	return nil
 }
 
@@ -158,6 +208,27 @@ func WithBufferSize(size int) Option {
 	}
 }
 
+// WithCompression enables transparent payload compression.
+func WithCompression(enabled bool) Option {
+	return func(c *Config) {
+		c.Compression = enabled
+	}
+}
+
+// WithCompressionThreshold sets the payload size above which payloads are compressed.
+func WithCompressionThreshold(size int) Option {
+	return func(c *Config) {
+		c.CompressionThreshold = size
+	}
+}
+
+// WithDispatchWorkers enables bounded dispatch with n worker goroutines.
+func WithDispatchWorkers(n int) Option {
+	return func(c *Config) {
+		c.DispatchWorkers = n
+	}
+}
+
 // WithLogger sets the structured logger.
 func WithLogger(logger *slog.Logger) Option {
 	return func(c *Config) {
@@ -172,6 +243,22 @@ func WithHooks(hooks *Hooks) Option {
 	}
 }
 
+// WithMetricsObserver sets the observer that receives metric events.
+func WithMetricsObserver(observer MetricsObserver) Option {
+	return func(c *Config) {
+		c.MetricsObserver = observer
+	}
+}
+
+// WithRelisten sets how many channels are re-LISTENed at once after a
+// reconnect and the timeout for each.
+func WithRelisten(concurrency int, timeout time.Duration) Option {
+	return func(c *Config) {
+		c.RelistenConcurrency = concurrency
+		c.RelistenTimeout = timeout
+	}
+}
+
 // WithShutdownTimeout sets the graceful shutdown timeout.
 func WithShutdownTimeout(timeout time.Duration) Option {
 	return func(c *Config) {
@@ -8,6 +8,13 @@ import (
 	"time"
 )
 
+// dispatchJob is a single delivery of a notification to a subscription.
+type dispatchJob struct {
+	ctx          context.Context
+	sub          *subscription
+	notification *Notification
+}
+
 // dispatcher handles incoming notifications and dispatches them to registered callbacks.
 type dispatcher struct {
 	config  *Config
@@ -15,20 +22,58 @@ type dispatcher struct {
 	subMgr  *subscriptionManager
 	wg      sync.WaitGroup
 	metrics *metricsCollector
+
+	// Bounded dispatch mode (DispatchWorkers > 0)
+	jobs      chan dispatchJob
+	stop      chan struct{}
+	stopOnce  sync.Once
+	workersWg sync.WaitGroup
 }
 
 // newDispatcher creates a new dispatcher.
+// When DispatchWorkers is positive, the worker pool is started immediately.
 func newDispatcher(config *Config, subMgr *subscriptionManager, metrics *metricsCollector) *dispatcher {
-	return &dispatcher{
+	d := &dispatcher{
 		config:  config,
 		logger:  config.Logger,
 		subMgr:  subMgr,
 		metrics: metrics,
 	}
+
+	if config.DispatchWorkers > 0 {
+		d.jobs = make(chan dispatchJob, config.BufferSize)
+		d.stop = make(chan struct{})
+		for i := 0; i < config.DispatchWorkers; i++ {
+			d.workersWg.Add(1)
+			go d.worker()
+		}
+	}
+
+	return d
 }
 
 // Dispatch dispatches a notification to all subscribed callbacks.
 func (d *dispatcher) Dispatch(ctx context.Context, notification *Notification) {
+	// Decode compressed payloads before hooks and callbacks see them
+	if d.config.Compression {
+		payload, err := decompressPayload(notification.Payload)
+		if err != nil {
+			d.metrics.IncrementErrors(notification.Channel)
+			d.logger.Error("failed to decompress payload",
+				slog.String("channel", notification.Channel),
+				slog.String("error", err.Error()))
+
+			// Call error hook if provided
+			if d.config.Hooks.OnError != nil {
+				d.safeCallHook(func() {
+					d.config.Hooks.OnError(ErrDecompress(notification.Channel, err), notification.Channel)
+				})
+			}
+			return
+		}
+		notification.Payload = payload
+	}
+
 	// Call hook if provided
 	if d.config.Hooks.OnNotification != nil {
 		d.safeCallHook(func() {
@@ -49,6 +94,14 @@ func (d *dispatcher) Dispatch(ctx context.Context, notification *Notification) {
 		slog.String("channel", notification.Channel),
 		slog.Int("subscribers", len(subs)))
 
+	// Queue for the worker pool in bounded mode
+	if d.jobs != nil {
+		for _, sub := range subs {
+			d.enqueue(dispatchJob{ctx: ctx, sub: sub, notification: notification})
+		}
+		return
+	}
+
 	// Dispatch to each subscription in a separate goroutine
 	for _, sub := range subs {
 		d.wg.Add(1)
@@ -56,6 +109,41 @@ func (d *dispatcher) Dispatch(ctx context.Context, notification *Notification) {
 	}
 }
 
+// enqueue buffers a job for the worker pool without blocking.
+// The job is dropped if the buffer is full.
+func (d *dispatcher) enqueue(job dispatchJob) {
+	d.wg.Add(1)
+	select {
+	case d.jobs <- job:
+	default:
+		d.wg.Done()
+		d.metrics.IncrementDropped(job.notification.Channel)
+		d.logger.Warn("dispatch buffer full, dropping notification",
+			slog.String("channel", job.notification.Channel))
+
+		// Call drop hook if provided
+		if d.config.Hooks.OnDrop != nil {
+			d.safeCallHook(func() {
+				d.config.Hooks.OnDrop(job.notification)
+			})
+		}
+	}
+}
+
+// worker delivers buffered jobs until the dispatcher is closed.
+func (d *dispatcher) worker() {
+	defer d.workersWg.Done()
+
+	for {
+		select {
+		case <-d.stop:
+			return
+		case job := <-d.jobs:
+			d.dispatchToSubscription(job.ctx, job.sub, job.notification)
+		}
+	}
+}
+
 // dispatchToSubscription dispatches a notification to a single subscription.
 func (d *dispatcher) dispatchToSubscription(ctx context.Context, sub *subscription, notification *Notification) {
 	defer d.wg.Done()
@@ -76,7 +164,7 @@ func (d *dispatcher) dispatchToSubscription(ctx context.Context, sub *subscripti
 	duration := time.Since(start)
 
 	if err != nil {
-		d.metrics.IncrementErrors()
+		d.metrics.IncrementErrors(notification.Channel)
 		d.logger.Error("callback error",
 			slog.String("channel", notification.Channel),
 			slog.String("error", err.Error()),
@@ -98,7 +186,7 @@ func (d *dispatcher) dispatchToSubscription(ctx context.Context, sub *subscripti
 // recoverPanic recovers from panics in callback execution.
 func (d *dispatcher) recoverPanic(channel string) {
 	if r := recover(); r != nil {
-		d.metrics.IncrementErrors()
+		d.metrics.IncrementErrors(channel)
 
 		err := fmt.Errorf("panic in callback: %v", r)
 		d.logger.Error("callback panic",
@@ -125,59 +213,115 @@ func (d *dispatcher) safeCallHook(fn func()) {
 }
 
 // Wait waits for all dispatched callbacks to complete.
+// In bounded mode this includes jobs still waiting in the buffer.
 func (d *dispatcher) Wait() {
 	d.wg.Wait()
 }
 
-// metricsCollector collects runtime metrics.
+// Close stops the worker pool. It should be called after Wait so that
+// buffered jobs are not abandoned. Close is a no-op in unbounded mode.
+func (d *dispatcher) Close() {
+	if d.stop == nil {
+		return
+	}
+	d.stopWorkers()
+	d.workersWg.Wait()
+}
+
+// stopWorkers tells the worker pool to exit without waiting for it; a worker
+// busy with a callback exits once the callback returns.
+func (d *dispatcher) stopWorkers() {
+	if d.stop == nil {
+		return
+	}
+	d.stopOnce.Do(func() {
+		close(d.stop)
+	})
+}
+
+// metricsCollector collects runtime metrics and forwards events to the observer.
 type metricsCollector struct {
 	mu                 sync.RWMutex
 	totalNotifications int64
 	totalErrors        int64
 	totalReconnects    int64
+	totalDropped       int64
 	lastNotification   time.Time
 	lastError          time.Time
 	connectedAt        time.Time
 	isConnected        bool
+
+	observer MetricsObserver
 }
 
 // newMetricsCollector creates a new metrics collector.
-func newMetricsCollector() *metricsCollector {
-	return &metricsCollector{}
+// A nil observer is replaced by NoOpMetricsObserver.
+func newMetricsCollector(observer MetricsObserver) *metricsCollector {
+	if observer == nil {
+		observer = NoOpMetricsObserver{}
+	}
+	return &metricsCollector{observer: observer}
+}
+
+// observe calls the observer outside the lock, recovering from panics.
+func (m *metricsCollector) observe(fn func(MetricsObserver)) {
+	defer func() {
+		_ = recover()
+	}()
+	fn(m.observer)
 }
 
 // IncrementNotifications increments the notification counter.
-func (m *metricsCollector) IncrementNotifications() {
+func (m *metricsCollector) IncrementNotifications(channel string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.totalNotifications++
 	m.lastNotification = time.Now()
+	m.mu.Unlock()
+
+	m.observe(func(o MetricsObserver) { o.OnNotification(channel) })
 }
 
 // IncrementErrors increments the error counter.
-func (m *metricsCollector) IncrementErrors() {
+func (m *metricsCollector) IncrementErrors(channel string) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.totalErrors++
 	m.lastError = time.Now()
+	m.mu.Unlock()
+
+	m.observe(func(o MetricsObserver) { o.OnError(channel) })
 }
 
 // IncrementReconnects increments the reconnect counter.
 func (m *metricsCollector) IncrementReconnects() {
 	m.mu.Lock()
-	defer m.mu.Unlock()
 	m.totalReconnects++
+	m.mu.Unlock()
+
+	m.observe(func(o MetricsObserver) { o.OnReconnect() })
+}
+
+// IncrementDropped increments the dropped delivery counter.
+func (m *metricsCollector) IncrementDropped(channel string) {
+	m.mu.Lock()
+	m.totalDropped++
+	m.mu.Unlock()
+
+	m.observe(func(o MetricsObserver) { o.OnDropped(channel) })
 }
 
 // SetConnected updates the connection status.
 func (m *metricsCollector) SetConnected(connected bool) {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
+	changed := m.isConnected != connected
 	if connected && !m.isConnected {
 		m.connectedAt = time.Now()
 	}
 	m.isConnected = connected
+	m.mu.Unlock()
+
+	if changed {
+		m.observe(func(o MetricsObserver) { o.OnConnectedChanged(connected) })
+	}
 }
 
 // GetStatistics returns current statistics.
@@ -189,6 +333,7 @@ func (m *metricsCollector) GetStatistics(activeSubscriptions int) Statistics {
 		TotalNotifications:  m.totalNotifications,
 		TotalErrors:         m.totalErrors,
 		TotalReconnects:     m.totalReconnects,
+		TotalDropped:        m.totalDropped,
 		ActiveSubscriptions: activeSubscriptions,
 		IsConnected:         m.isConnected,
 		LastNotificationAt:  m.lastNotification,
@@ -0,0 +1,113 @@
+package pgnotify
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(t *testing.T, opts ...Option) (*dispatcher, *subscriptionManager, *metricsCollector) {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, opt := range opts {
+		opt(config)
+	}
+	require.NoError(t, config.Validate())
+
+	subMgr := newSubscriptionManager()
+	metrics := newMetricsCollector(config.MetricsObserver)
+	d := newDispatcher(config, subMgr, metrics)
+	t.Cleanup(d.Close)
+	return d, subMgr, metrics
+}
+
+func TestDispatcher_UnboundedByDefault(t *testing.T) {
+	d, subMgr, metrics := newTestDispatcher(t)
+	assert.Nil(t, d.jobs)
+
+	var calls atomic.Int32
+	for i := 0; i < 3; i++ {
+		subMgr.Add("events", func(ctx context.Context, n *Notification) error {
+			calls.Add(1)
+			return nil
+		}, nil)
+	}
+
+	d.Dispatch(context.Background(), &Notification{Channel: "events"})
+	d.Wait()
+
+	assert.Equal(t, int32(3), calls.Load())
+	assert.Zero(t, metrics.GetStatistics(0).TotalDropped)
+}
+
+func TestDispatcher_BoundedDropsWhenBufferFull(t *testing.T) {
+	var dropped atomic.Int32
+	hooks := &Hooks{OnDrop: func(n *Notification) { dropped.Add(1) }}
+	d, subMgr, metrics := newTestDispatcher(t,
+		WithDispatchWorkers(1),
+		WithBufferSize(1),
+		WithHooks(hooks),
+	)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	subMgr.Add("events", func(ctx context.Context, n *Notification) error {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		return nil
+	}, nil)
+
+	// First delivery occupies the only worker
+	d.Dispatch(context.Background(), &Notification{Channel: "events"})
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("worker did not pick up the first notification")
+	}
+
+	// Second fills the buffer, the remaining two are dropped
+	for i := 0; i < 3; i++ {
+		d.Dispatch(context.Background(), &Notification{Channel: "events"})
+	}
+
+	close(release)
+	d.Wait()
+
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, int32(2), dropped.Load())
+	assert.Equal(t, int64(2), metrics.GetStatistics(0).TotalDropped)
+}
+
+func TestDispatcher_CloseStopsWorkers(t *testing.T) {
+	d, _, _ := newTestDispatcher(t, WithDispatchWorkers(4))
+
+	done := make(chan struct{})
+	go func() {
+		d.Close()
+		d.Close()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Close did not stop workers")
+	}
+}
+
+func TestConfig_ValidateDispatchWorkers(t *testing.T) {
+	config := DefaultConfig()
+	config.DispatchWorkers = -1
+	assert.Error(t, config.Validate())
+}
@@ -56,7 +56,23 @@ func ErrCallback(channel string, err error) error {
 	return fmt.Errorf("pgnotify: callback error for channel %q: %w", channel, err)
 }
 
+// ErrRelisten wraps errors that occur while re-registering a listener after a reconnect.
+func ErrRelisten(channel string, err error) error {
+	return fmt.Errorf("pgnotify: failed to re-register listener on channel %q: %w", channel, err)
+}
+
 // ErrConnection wraps connection-related errors.
 func ErrConnection(operation string, err error) error {
 	return fmt.Errorf("pgnotify: connection %s failed: %w", operation, err)
 }
+
+// ErrCompressedPayloadTooLarge is returned when a payload is still too large after compression.
+// It wraps ErrPayloadTooLarge.
+func ErrCompressedPayloadTooLarge(size, compressedSize, maxSize int) error {
+	return fmt.Errorf("%w: %d bytes compressed to %d, limit is %d", ErrPayloadTooLarge, size, compressedSize, maxSize)
+}
+
+// ErrDecompress wraps errors that occur while decoding a compressed payload.
+func ErrDecompress(channel string, err error) error {
+	return fmt.Errorf("pgnotify: failed to decompress payload on channel %q: %w", channel, err)
+}
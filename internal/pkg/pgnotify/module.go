@@ -18,6 +18,11 @@ func ProvidePgxProvider(ctx context.Context, dsn string) (*PgxProvider, error) {
 	return NewPgxProvider(ctx, dsn)
 }
 
+// ProvidePqProvider provides a PqProvider instance backed by database/sql and lib/pq.
+func ProvidePqProvider(ctx context.Context, dsn string) (*PqProvider, error) {
+	return NewPqProvider(ctx, dsn)
+}
+
 // ProvideNotifierWithConfig provides a Notifier instance with custom configuration.
 func ProvideNotifierWithConfig(provider ConnectionProvider, config *Config) (Notifier, error) {
 	return NewNotifier(
@@ -30,7 +35,12 @@ func ProvideNotifierWithConfig(provider ConnectionProvider, config *Config) (Not
 		WithPingInterval(config.PingInterval),
 		WithCallbackTimeout(config.CallbackTimeout),
 		WithBufferSize(config.BufferSize),
+		WithDispatchWorkers(config.DispatchWorkers),
+		WithCompression(config.Compression),
+		WithCompressionThreshold(config.CompressionThreshold),
 		WithHooks(config.Hooks),
+		WithMetricsObserver(config.MetricsObserver),
 		WithShutdownTimeout(config.ShutdownTimeout),
+		WithRelisten(config.RelistenConcurrency, config.RelistenTimeout),
 	)
 }
@@ -2,7 +2,9 @@ package pgnotify
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -41,7 +43,7 @@ func NewNotifier(provider ConnectionProvider, opts ...Option) (Notifier, error)
 	}
 
 	subMgr := newSubscriptionManager()
-	metrics := newMetricsCollector()
+	metrics := newMetricsCollector(config.MetricsObserver)
 	dispatcher := newDispatcher(config, subMgr, metrics)
 
 	return &notifier{
@@ -60,15 +62,20 @@ func (n *notifier) Publish(ctx context.Context, channel string, payload string)
 		return ErrChannelEmpty
 	}
 
-	if len(payload) > n.config.MaxPayloadSize {
-		return ErrPayloadTooLarge
+	// Compress if enabled and enforce the size limit on what is actually sent
+	payload, err := n.config.encodePayload(payload)
+	if err != nil {
+		if errors.Is(err, ErrPayloadTooLarge) {
+			return err
+		}
+		return ErrPublish(channel, err)
 	}
 
 	if !n.provider.IsConnected() {
 		return ErrNotConnected
 	}
 
-	err := n.provider.Notify(ctx, channel, payload)
+	err = n.provider.Notify(ctx, channel, payload)
 	if err != nil {
 		n.logger.Error("failed to publish",
 			slog.String("channel", channel),
@@ -124,10 +131,14 @@ func (n *notifier) Subscribe(ctx context.Context, channel string, callback Callb
 	return sub, nil
 }
 
-// unsubscribe removes a subscription for the given channel.
-func (n *notifier) unsubscribe(channel string) error {
-	// Check if this was the last subscription for this channel
-	if !n.subMgr.HasChannel(channel) {
+// unsubscribe removes a subscription and sends UNLISTEN if it was the last one
+// on its channel. Other subscriptions on the channel keep receiving notifications.
+func (n *notifier) unsubscribe(sub *subscription) error {
+	channel := sub.channel
+	last := n.subMgr.Remove(channel, sub)
+
+	// Only stop listening once nobody is subscribed to the channel
+	if last {
 		// Send UNLISTEN if connected
 		if n.provider.IsConnected() {
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -219,7 +230,7 @@ func (n *notifier) listenerLoop() {
 		}
 
 		if notification != nil {
-			n.metrics.IncrementNotifications()
+			n.metrics.IncrementNotifications(notification.Channel)
 			n.dispatcher.Dispatch(n.ctx, notification)
 		}
 	}
@@ -372,23 +383,115 @@ func (n *notifier) reconnect() {
 }
 
 // reregisterListeners re-registers all LISTEN commands after reconnection.
-func (n *notifier) reregisterListeners() {
+// A BatchListener gets every channel in one command. Otherwise, or when the
+// batch fails, up to RelistenConcurrency channels are re-registered at once,
+// each within RelistenTimeout; a BatchListener runs commands in turn, so its
+// channels are retried one at a time and no timeout runs while a channel
+// waits for the connection. Every channel is attempted; the failures are
+// reported through the OnError hook and returned by channel.
+func (n *notifier) reregisterListeners() map[string]error {
 	channels := n.subMgr.Channels()
+	if len(channels) == 0 {
+		return nil
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	workers := min(n.config.RelistenConcurrency, len(channels))
+	if batch, ok := n.provider.(BatchListener); ok {
+		if n.relistenAll(batch, channels) == nil {
+			return nil
+		}
+		workers = 1
+	}
+
+	rounds := (len(channels) + workers - 1) / workers
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rounds)*n.config.RelistenTimeout)
 	defer cancel()
 
+	var mu sync.Mutex
+	failed := make(map[string]error)
+
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for channel := range work {
+				err := n.relisten(ctx, channel)
+				if err == nil {
+					continue
+				}
+
+				mu.Lock()
+				failed[channel] = err
+				mu.Unlock()
+			}
+		}()
+	}
+
 	for _, channel := range channels {
-		err := n.provider.Listen(ctx, channel)
-		if err != nil {
-			n.logger.Error("failed to re-register listener",
-				slog.String("channel", channel),
-				slog.String("error", err.Error()))
-		} else {
-			n.logger.Debug("re-registered listener",
-				slog.String("channel", channel))
+		work <- channel
+	}
+	close(work)
+	wg.Wait()
+
+	if len(failed) > 0 {
+		failedChannels := make([]string, 0, len(failed))
+		for channel := range failed {
+			failedChannels = append(failedChannels, channel)
 		}
+		sort.Strings(failedChannels)
+		n.logger.Error("failed to re-register listeners",
+			slog.Int("failed", len(failed)),
+			slog.Int("total", len(channels)),
+			slog.Any("channels", failedChannels))
+	}
+
+	return failed
+}
+
+// relistenAll re-issues LISTEN for every channel in one command within
+// RelistenTimeout. A failure is only logged: the caller retries each channel
+// to find and report the ones that fail.
+func (n *notifier) relistenAll(batch BatchListener, channels []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), n.config.RelistenTimeout)
+	defer cancel()
+
+	if err := batch.ListenAll(ctx, channels); err != nil {
+		n.logger.Warn("failed to re-register listeners in one batch, retrying each channel",
+			slog.Int("channels", len(channels)),
+			slog.String("error", err.Error()))
+		return err
 	}
+
+	n.logger.Debug("re-registered listeners",
+		slog.Int("channels", len(channels)))
+	return nil
+}
+
+// relisten re-issues LISTEN for one channel within RelistenTimeout.
+func (n *notifier) relisten(ctx context.Context, channel string) error {
+	ctx, cancel := context.WithTimeout(ctx, n.config.RelistenTimeout)
+	defer cancel()
+
+	err := n.provider.Listen(ctx, channel)
+	if err == nil {
+		n.logger.Debug("re-registered listener",
+			slog.String("channel", channel))
+		return nil
+	}
+
+	err = ErrRelisten(channel, err)
+	n.logger.Error("failed to re-register listener",
+		slog.String("channel", channel),
+		slog.String("error", err.Error()))
+
+	if n.config.Hooks.OnError != nil {
+		n.dispatcher.safeCallHook(func() {
+			n.config.Hooks.OnError(err, channel)
+		})
+	}
+	return err
 }
 
 // Shutdown gracefully stops the notifier.
@@ -409,6 +512,7 @@ func (n *notifier) Shutdown(ctx context.Context) error {
 	go func() {
 		n.wg.Wait()
 		n.dispatcher.Wait()
+		n.dispatcher.Close()
 		close(done)
 	}()
 
@@ -417,6 +521,14 @@ func (n *notifier) Shutdown(ctx context.Context) error {
 		n.logger.Info("graceful shutdown completed")
 	case <-ctx.Done():
 		n.logger.Warn("shutdown timeout exceeded")
+
+		// Don't leave the worker pool or the connection behind; callbacks
+		// still running finish on their own
+		n.dispatcher.stopWorkers()
+		if err := n.provider.Close(); err != nil {
+			n.logger.Error("failed to close connection",
+				slog.String("error", err.Error()))
+		}
 		return ErrShutdownTimeout
 	}
 
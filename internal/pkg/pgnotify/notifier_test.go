@@ -0,0 +1,377 @@
+package pgnotify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider is an in-memory ConnectionProvider: Notify on a listened
+// channel is delivered to WaitForNotification
+type fakeProvider struct {
+	mu        sync.Mutex
+	listening map[string]bool
+	unlistens []string
+	queue     chan *Notification
+}
+
+func newFakeProvider() *fakeProvider {
+	return &fakeProvider{
+		listening: make(map[string]bool),
+		queue:     make(chan *Notification, 16),
+	}
+}
+
+func (p *fakeProvider) Listen(ctx context.Context, channel string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listening[channel] = true
+	return nil
+}
+
+func (p *fakeProvider) Unlisten(ctx context.Context, channel string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.listening, channel)
+	p.unlistens = append(p.unlistens, channel)
+	return nil
+}
+
+func (p *fakeProvider) Unlistens() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.unlistens...)
+}
+
+func (p *fakeProvider) Notify(ctx context.Context, channel string, payload string) error {
+	p.mu.Lock()
+	listening := p.listening[channel]
+	p.mu.Unlock()
+
+	if listening {
+		p.queue <- &Notification{Channel: channel, Payload: payload, ReceivedAt: time.Now()}
+	}
+	return nil
+}
+
+func (p *fakeProvider) WaitForNotification(ctx context.Context) (*Notification, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case n := <-p.queue:
+		return n, nil
+	}
+}
+
+func (p *fakeProvider) Ping(ctx context.Context) error      { return nil }
+func (p *fakeProvider) Close() error                        { return nil }
+func (p *fakeProvider) IsConnected() bool                   { return true }
+func (p *fakeProvider) Reconnect(ctx context.Context) error { return nil }
+
+func startTestNotifier(t *testing.T, provider ConnectionProvider, opts ...Option) *notifier {
+	t.Helper()
+
+	opts = append([]Option{WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil)))}, opts...)
+	n, err := NewNotifier(provider, opts...)
+	require.NoError(t, err)
+
+	go n.Start(context.Background())
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		n.Shutdown(ctx)
+	})
+
+	return n.(*notifier)
+}
+
+func TestNotifier_UnsubscribeKeepsOtherSubscribers(t *testing.T) {
+	provider := newFakeProvider()
+	n := startTestNotifier(t, provider)
+	ctx := context.Background()
+
+	var first, second atomic.Int32
+	subA, err := n.Subscribe(ctx, "events", func(ctx context.Context, _ *Notification) error {
+		first.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+	subB, err := n.Subscribe(ctx, "events", func(ctx context.Context, _ *Notification) error {
+		second.Add(1)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, subA.Unsubscribe())
+	assert.Empty(t, provider.Unlistens(), "UNLISTEN sent while a subscriber remains")
+	assert.Equal(t, 1, n.subMgr.Count())
+
+	require.NoError(t, n.Publish(ctx, "events", "hello"))
+	require.Eventually(t, func() bool { return second.Load() == 1 }, time.Second, time.Millisecond)
+
+	// Give a duplicate delivery a chance to show up
+	time.Sleep(20 * time.Millisecond)
+	n.dispatcher.Wait()
+	assert.Equal(t, int32(1), second.Load())
+	assert.Zero(t, first.Load())
+
+	// The last subscriber leaving stops listening, once
+	require.NoError(t, subB.Unsubscribe())
+	require.NoError(t, subB.Unsubscribe())
+	assert.Equal(t, []string{"events"}, provider.Unlistens())
+	assert.False(t, n.subMgr.HasChannel("events"))
+}
+
+// relistenProvider fails LISTEN on "fail-*" channels and hangs on "hang-*"
+// ones once failing is set, recording every attempt
+type relistenProvider struct {
+	*fakeProvider
+	failing  atomic.Bool
+	attempts sync.Map
+	inFlight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (p *relistenProvider) Listen(ctx context.Context, channel string) error {
+	if !p.failing.Load() {
+		return p.fakeProvider.Listen(ctx, channel)
+	}
+
+	p.attempts.Store(channel, true)
+	n := p.inFlight.Add(1)
+	defer p.inFlight.Add(-1)
+	for {
+		peak := p.peak.Load()
+		if n <= peak || p.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(channel, "fail-"):
+		return errors.New("permission denied")
+	case strings.HasPrefix(channel, "hang-"):
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	time.Sleep(time.Millisecond)
+	return p.fakeProvider.Listen(ctx, channel)
+}
+
+func TestNotifier_ReregisterListenersReportsFailures(t *testing.T) {
+	provider := &relistenProvider{fakeProvider: newFakeProvider()}
+
+	var hookMu sync.Mutex
+	hookErrors := make(map[string]error)
+	n := startTestNotifier(t, provider,
+		WithRelisten(10, 50*time.Millisecond),
+		WithHooks(&Hooks{OnError: func(err error, channel string) {
+			hookMu.Lock()
+			hookErrors[channel] = err
+			hookMu.Unlock()
+		}}))
+
+	// The hanging channels alone would outlast a fixed deadline if run in turn
+	var channels []string
+	for i := 0; i < 10; i++ {
+		channels = append(channels, fmt.Sprintf("hang-%d", i), fmt.Sprintf("fail-%d", i))
+	}
+	for i := 0; i < 280; i++ {
+		channels = append(channels, fmt.Sprintf("ok-%d", i))
+	}
+	for _, channel := range channels {
+		_, err := n.Subscribe(context.Background(), channel, func(ctx context.Context, _ *Notification) error { return nil })
+		require.NoError(t, err)
+	}
+
+	provider.failing.Store(true)
+	failed := n.reregisterListeners()
+
+	for _, channel := range channels {
+		_, attempted := provider.attempts.Load(channel)
+		assert.True(t, attempted, "channel %s was not attempted", channel)
+	}
+	assert.LessOrEqual(t, provider.peak.Load(), int32(10))
+
+	require.Len(t, failed, 20)
+	for i := 0; i < 10; i++ {
+		assert.ErrorIs(t, failed[fmt.Sprintf("hang-%d", i)], context.DeadlineExceeded)
+		assert.ErrorContains(t, failed[fmt.Sprintf("fail-%d", i)], "permission denied")
+	}
+
+	hookMu.Lock()
+	defer hookMu.Unlock()
+	assert.Len(t, hookErrors, 20)
+	assert.ErrorContains(t, hookErrors["fail-0"], `re-register listener on channel "fail-0"`)
+}
+
+// serialProvider runs one command at a time on its connection, like
+// PgxProvider, taking commandTime per command; once failBad is set, LISTEN
+// on "bad-*" channels fails, and so does any batch containing one
+type serialProvider struct {
+	*fakeProvider
+	commandTime time.Duration
+	failBad     bool
+	exec        sync.Mutex
+	batches     atomic.Int32
+}
+
+func (p *serialProvider) Listen(ctx context.Context, channel string) error {
+	p.exec.Lock()
+	defer p.exec.Unlock()
+
+	if err := p.run(ctx); err != nil {
+		return err
+	}
+	if p.failBad && strings.HasPrefix(channel, "bad-") {
+		return errors.New("syntax error")
+	}
+	return p.fakeProvider.Listen(ctx, channel)
+}
+
+func (p *serialProvider) ListenAll(ctx context.Context, channels []string) error {
+	p.exec.Lock()
+	defer p.exec.Unlock()
+
+	p.batches.Add(1)
+	if err := p.run(ctx); err != nil {
+		return err
+	}
+	for _, channel := range channels {
+		if p.failBad && strings.HasPrefix(channel, "bad-") {
+			return errors.New("syntax error")
+		}
+	}
+	for _, channel := range channels {
+		_ = p.fakeProvider.Listen(ctx, channel)
+	}
+	return nil
+}
+
+func (p *serialProvider) run(ctx context.Context) error {
+	select {
+	case <-time.After(p.commandTime):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// disconnect forgets every LISTEN and makes each command take commandTime
+func (p *serialProvider) disconnect(commandTime time.Duration, failBad bool) {
+	p.exec.Lock()
+	defer p.exec.Unlock()
+	p.commandTime = commandTime
+	p.failBad = failBad
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.listening = make(map[string]bool)
+}
+
+func (p *serialProvider) isListening(channel string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.listening[channel]
+}
+
+func subscribeChannels(t *testing.T, n *notifier, channels []string) {
+	t.Helper()
+	for _, channel := range channels {
+		_, err := n.Subscribe(context.Background(), channel, func(ctx context.Context, _ *Notification) error { return nil })
+		require.NoError(t, err)
+	}
+}
+
+func TestNotifier_ReregisterListenersBatchesOnSerialConnection(t *testing.T) {
+	provider := &serialProvider{fakeProvider: newFakeProvider()}
+	n := startTestNotifier(t, provider, WithRelisten(8, 50*time.Millisecond))
+
+	var channels []string
+	for i := 0; i < 20; i++ {
+		channels = append(channels, fmt.Sprintf("ok-%d", i))
+	}
+	subscribeChannels(t, n, channels)
+
+	// Queued one at a time behind a shared timeout, most would time out waiting
+	provider.disconnect(20*time.Millisecond, false)
+	failed := n.reregisterListeners()
+
+	assert.Empty(t, failed)
+	assert.Equal(t, int32(1), provider.batches.Load())
+	for _, channel := range channels {
+		assert.True(t, provider.isListening(channel), "channel %s was not re-registered", channel)
+	}
+}
+
+func TestNotifier_ReregisterListenersFailedBatchRetriesEachChannel(t *testing.T) {
+	provider := &serialProvider{fakeProvider: newFakeProvider()}
+	n := startTestNotifier(t, provider, WithRelisten(8, 50*time.Millisecond))
+
+	channels := []string{"bad-0"}
+	for i := 0; i < 10; i++ {
+		channels = append(channels, fmt.Sprintf("ok-%d", i))
+	}
+	subscribeChannels(t, n, channels)
+
+	provider.disconnect(20*time.Millisecond, true)
+	failed := n.reregisterListeners()
+
+	require.Len(t, failed, 1)
+	assert.ErrorContains(t, failed["bad-0"], "syntax error")
+	for _, channel := range channels[1:] {
+		assert.True(t, provider.isListening(channel), "channel %s was not re-registered", channel)
+	}
+}
+
+// closeCountingProvider counts Close calls
+type closeCountingProvider struct {
+	*fakeProvider
+	closed atomic.Int32
+}
+
+func (p *closeCountingProvider) Close() error {
+	p.closed.Add(1)
+	return nil
+}
+
+func TestNotifier_ShutdownTimeoutStopsDispatchWorkers(t *testing.T) {
+	provider := &closeCountingProvider{fakeProvider: newFakeProvider()}
+	n := startTestNotifier(t, provider, WithDispatchWorkers(2))
+	ctx := context.Background()
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	_, err := n.Subscribe(ctx, "events", func(ctx context.Context, _ *Notification) error {
+		close(started)
+		<-release
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, n.Publish(ctx, "events", "hello"))
+	<-started
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, n.Shutdown(shutdownCtx), ErrShutdownTimeout)
+	assert.Equal(t, int32(1), provider.closed.Load())
+
+	// Idle workers are told to exit without waiting for the slow callback
+	select {
+	case <-n.dispatcher.stop:
+	default:
+		t.Fatal("dispatch workers not stopped after shutdown timed out")
+	}
+	close(release)
+}
@@ -0,0 +1,42 @@
+package pgnotify
+
+// MetricsObserver receives metric events as they happen, so they can be
+// pushed to a metrics system such as Prometheus. GetStatistics keeps working
+// regardless of the observer.
+//
+// Methods are called synchronously from the notifier's goroutines and
+// should return quickly. A panicking observer is recovered and ignored.
+type MetricsObserver interface {
+	// OnNotification is called for each notification received
+	OnNotification(channel string)
+
+	// OnError is called for each callback error, panic or undecodable payload
+	OnError(channel string)
+
+	// OnDropped is called for each delivery dropped because the dispatch buffer was full
+	OnDropped(channel string)
+
+	// OnReconnect is called for each failed reconnection attempt
+	OnReconnect()
+
+	// OnConnectedChanged is called when the connection state changes
+	OnConnectedChanged(connected bool)
+}
+
+// NoOpMetricsObserver is a MetricsObserver that does nothing.
+type NoOpMetricsObserver struct{}
+
+// OnNotification implements MetricsObserver.
+func (NoOpMetricsObserver) OnNotification(channel string) {}
+
+// OnError implements MetricsObserver.
+func (NoOpMetricsObserver) OnError(channel string) {}
+
+// OnDropped implements MetricsObserver.
+func (NoOpMetricsObserver) OnDropped(channel string) {}
+
+// OnReconnect implements MetricsObserver.
+func (NoOpMetricsObserver) OnReconnect() {}
+
+// OnConnectedChanged implements MetricsObserver.
+func (NoOpMetricsObserver) OnConnectedChanged(connected bool) {}
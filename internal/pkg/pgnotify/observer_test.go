@@ -0,0 +1,99 @@
+package pgnotify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingObserver records every observer call as a string
+type recordingObserver struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (o *recordingObserver) record(event string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.events = append(o.events, event)
+}
+
+func (o *recordingObserver) Events() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]string(nil), o.events...)
+}
+
+func (o *recordingObserver) OnNotification(channel string) { o.record("notification:" + channel) }
+func (o *recordingObserver) OnError(channel string)        { o.record("error:" + channel) }
+func (o *recordingObserver) OnDropped(channel string)      { o.record("dropped:" + channel) }
+func (o *recordingObserver) OnReconnect()                  { o.record("reconnect") }
+func (o *recordingObserver) OnConnectedChanged(connected bool) {
+	if connected {
+		o.record("connected")
+	} else {
+		o.record("disconnected")
+	}
+}
+
+func TestMetricsCollector_NotifiesObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	m := newMetricsCollector(observer)
+
+	m.IncrementNotifications("events")
+	m.IncrementErrors("events")
+	m.IncrementDropped("events")
+	m.IncrementReconnects()
+	m.SetConnected(true)
+	m.SetConnected(true) // no change, not reported
+	m.SetConnected(false)
+
+	assert.Equal(t, []string{
+		"notification:events",
+		"error:events",
+		"dropped:events",
+		"reconnect",
+		"connected",
+		"disconnected",
+	}, observer.Events())
+
+	stats := m.GetStatistics(0)
+	assert.Equal(t, int64(1), stats.TotalNotifications)
+	assert.Equal(t, int64(1), stats.TotalErrors)
+	assert.Equal(t, int64(1), stats.TotalDropped)
+	assert.Equal(t, int64(1), stats.TotalReconnects)
+}
+
+// panickingObserver panics on every call
+type panickingObserver struct{ NoOpMetricsObserver }
+
+func (panickingObserver) OnNotification(channel string) { panic("boom") }
+
+func TestMetricsCollector_RecoversObserverPanic(t *testing.T) {
+	m := newMetricsCollector(panickingObserver{})
+
+	assert.NotPanics(t, func() { m.IncrementNotifications("events") })
+	assert.Equal(t, int64(1), m.GetStatistics(0).TotalNotifications)
+}
+
+func TestMetricsCollector_NilObserverIsNoOp(t *testing.T) {
+	m := newMetricsCollector(nil)
+	assert.NotPanics(t, func() { m.IncrementErrors("events") })
+}
+
+func TestDispatcher_ReportsCallbackErrorsToObserver(t *testing.T) {
+	observer := &recordingObserver{}
+	d, subMgr, _ := newTestDispatcher(t, WithMetricsObserver(observer))
+
+	subMgr.Add("events", func(ctx context.Context, n *Notification) error {
+		return errors.New("failed")
+	}, nil)
+
+	d.Dispatch(context.Background(), &Notification{Channel: "events"})
+	d.Wait()
+
+	assert.Equal(t, []string{"error:events"}, observer.Events())
+}
@@ -2,6 +2,7 @@ package pgnotify
 
 import (
 	"context"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +15,8 @@ type PgxProvider struct {
 	pool *pgxpool.Pool
 	mu   sync.RWMutex
 	conn *pgxpool.Conn
+	// execMu serializes commands on conn, which can run only one at a time
+	execMu sync.Mutex
 }
 
 // NewPgxProvider creates a new PgxProvider with the given DSN.
@@ -46,10 +49,36 @@ func (p *PgxProvider) Listen(ctx context.Context, channel string) error {
 		return ErrNotConnected
 	}
 
+	p.execMu.Lock()
+	defer p.execMu.Unlock()
+
 	_, err := p.conn.Exec(ctx, "LISTEN "+channel)
 	return err
 }
 
+// ListenAll sends the LISTEN commands for every channel in one round trip.
+// Either every channel is listened on or, if one fails, none is.
+func (p *PgxProvider) ListenAll(ctx context.Context, channels []string) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.conn == nil {
+		return ErrNotConnected
+	}
+
+	commands := make([]string, len(channels))
+	for i, channel := range channels {
+		commands[i] = "LISTEN " + channel
+	}
+
+	p.execMu.Lock()
+	defer p.execMu.Unlock()
+
+	// Without arguments pgx uses the simple protocol, which allows several statements
+	_, err := p.conn.Exec(ctx, strings.Join(commands, "; "))
+	return err
+}
+
 // Unlisten sends an UNLISTEN command to PostgreSQL.
 func (p *PgxProvider) Unlisten(ctx context.Context, channel string) error {
 	p.mu.RLock()
@@ -59,6 +88,9 @@ func (p *PgxProvider) Unlisten(ctx context.Context, channel string) error {
 		return ErrNotConnected
 	}
 
+	p.execMu.Lock()
+	defer p.execMu.Unlock()
+
 	_, err := p.conn.Exec(ctx, "UNLISTEN "+channel)
 	return err
 }
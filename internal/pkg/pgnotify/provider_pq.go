@@ -0,0 +1,299 @@
+package pgnotify
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// PqProvider implements ConnectionProvider using database/sql and lib/pq.
+//
+// LISTEN/UNLISTEN and notifications go through a dedicated pq.Listener;
+// NOTIFY goes through a *sql.DB. The listener reconnects on its own and
+// re-issues LISTEN for its channels, so Reconnect only waits for that to
+// happen instead of opening a second connection.
+type PqProvider struct {
+	dsn    string
+	db     *sql.DB
+	ownsDB bool
+
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+
+	mu          sync.Mutex
+	listener    *pq.Listener
+	connected   bool
+	connectedCh chan struct{} // closed while connected
+	lastErr     error
+}
+
+// PqProviderOption configures a PqProvider.
+type PqProviderOption func(*PqProvider)
+
+// WithPqReconnectInterval sets the pq.Listener's own reconnect backoff bounds.
+func WithPqReconnectInterval(min, max time.Duration) PqProviderOption {
+	return func(p *PqProvider) {
+		p.minReconnectInterval = min
+		p.maxReconnectInterval = max
+	}
+}
+
+// NewPqProvider creates a PqProvider that opens its own *sql.DB for the DSN.
+func NewPqProvider(ctx context.Context, dsn string, opts ...PqProviderOption) (*PqProvider, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, ErrConnection("open", err)
+	}
+
+	p, err := newPqProvider(ctx, db, dsn, true, opts...)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+// NewPqProviderWithDB creates a PqProvider that publishes through an existing
+// *sql.DB. The DSN is still needed for the dedicated listener connection.
+// Close does not close db.
+func NewPqProviderWithDB(ctx context.Context, db *sql.DB, dsn string, opts ...PqProviderOption) (*PqProvider, error) {
+	return newPqProvider(ctx, db, dsn, false, opts...)
+}
+
+// newPqProvider validates the DSN and waits for the listener's first connection.
+func newPqProvider(ctx context.Context, db *sql.DB, dsn string, ownsDB bool, opts ...PqProviderOption) (*PqProvider, error) {
+	p := newPqProviderState(dsn, db, ownsDB)
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	// Fail fast on a bad DSN; the listener would otherwise retry forever
+	if err := db.PingContext(ctx); err != nil {
+		return nil, ErrConnection("ping", err)
+	}
+
+	if err := p.startListener(ctx); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// newPqProviderState creates a disconnected provider without a listener.
+func newPqProviderState(dsn string, db *sql.DB, ownsDB bool) *PqProvider {
+	return &PqProvider{
+		dsn:                  dsn,
+		db:                   db,
+		ownsDB:               ownsDB,
+		minReconnectInterval: 1 * time.Second,
+		maxReconnectInterval: 30 * time.Second,
+		connectedCh:          make(chan struct{}),
+	}
+}
+
+// startListener creates the pq.Listener and waits until it is connected.
+func (p *PqProvider) startListener(ctx context.Context) error {
+	listener := pq.NewListener(p.dsn, p.minReconnectInterval, p.maxReconnectInterval, p.handleEvent)
+
+	p.mu.Lock()
+	p.listener = listener
+	p.mu.Unlock()
+
+	if err := p.waitConnected(ctx); err != nil {
+		p.mu.Lock()
+		p.listener = nil
+		p.mu.Unlock()
+		listener.Close()
+		return err
+	}
+
+	return nil
+}
+
+// handleEvent tracks connection state from the listener's callbacks.
+func (p *PqProvider) handleEvent(event pq.ListenerEventType, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	switch event {
+	case pq.ListenerEventConnected, pq.ListenerEventReconnected:
+		if !p.connected {
+			p.connected = true
+			close(p.connectedCh)
+		}
+		p.lastErr = nil
+	case pq.ListenerEventDisconnected:
+		if p.connected {
+			p.connected = false
+			p.connectedCh = make(chan struct{})
+		}
+		p.lastErr = err
+	case pq.ListenerEventConnectionAttemptFailed:
+		p.lastErr = err
+	}
+}
+
+// waitConnected blocks until the listener reports a connection or ctx is done.
+func (p *PqProvider) waitConnected(ctx context.Context) error {
+	p.mu.Lock()
+	ch := p.connectedCh
+	p.mu.Unlock()
+
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		p.mu.Lock()
+		err := p.lastErr
+		p.mu.Unlock()
+		if err == nil {
+			err = ctx.Err()
+		}
+		return ErrConnection("listener connect", err)
+	}
+}
+
+// getListener returns the current listener, or nil after Close.
+func (p *PqProvider) getListener() *pq.Listener {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.listener
+}
+
+// Listen sends a LISTEN command to PostgreSQL.
+// Listening on a channel the listener already tracks is not an error, so the
+// notifier can re-register channels after a reconnect.
+func (p *PqProvider) Listen(ctx context.Context, channel string) error {
+	listener := p.getListener()
+	if listener == nil {
+		return ErrNotConnected
+	}
+
+	err := callWithContext(ctx, func() error {
+		return listener.Listen(channel)
+	})
+	if errors.Is(err, pq.ErrChannelAlreadyOpen) {
+		return nil
+	}
+	return err
+}
+
+// Unlisten sends an UNLISTEN command to PostgreSQL.
+func (p *PqProvider) Unlisten(ctx context.Context, channel string) error {
+	listener := p.getListener()
+	if listener == nil {
+		return ErrNotConnected
+	}
+
+	err := callWithContext(ctx, func() error {
+		return listener.Unlisten(channel)
+	})
+	if errors.Is(err, pq.ErrChannelNotOpen) {
+		return nil
+	}
+	return err
+}
+
+// Notify sends a NOTIFY command to PostgreSQL.
+func (p *PqProvider) Notify(ctx context.Context, channel string, payload string) error {
+	if p.db == nil {
+		return ErrNotConnected
+	}
+
+	_, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", channel, payload)
+	return err
+}
+
+// WaitForNotification waits for a notification from PostgreSQL.
+// It returns a nil notification after the listener reconnects, since
+// notifications may have been missed while disconnected.
+func (p *PqProvider) WaitForNotification(ctx context.Context) (*Notification, error) {
+	listener := p.getListener()
+	if listener == nil {
+		return nil, ErrNotConnected
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case n, ok := <-listener.NotificationChannel():
+		if !ok {
+			return nil, ErrNotConnected
+		}
+		if n == nil {
+			return nil, nil
+		}
+		return &Notification{
+			Channel:    n.Channel,
+			Payload:    n.Extra,
+			ReceivedAt: time.Now(),
+		}, nil
+	}
+}
+
+// Ping checks if the listener connection is still alive.
+func (p *PqProvider) Ping(ctx context.Context) error {
+	listener := p.getListener()
+	if listener == nil {
+		return ErrNotConnected
+	}
+
+	return callWithContext(ctx, listener.Ping)
+}
+
+// Close closes the listener, and the *sql.DB if the provider opened it.
+func (p *PqProvider) Close() error {
+	p.mu.Lock()
+	listener := p.listener
+	p.listener = nil
+	p.mu.Unlock()
+
+	var errs []error
+	if listener != nil {
+		errs = append(errs, listener.Close())
+	}
+	if p.ownsDB && p.db != nil {
+		errs = append(errs, p.db.Close())
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsConnected returns true if the listener connection is active.
+func (p *PqProvider) IsConnected() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.listener != nil && p.connected
+}
+
+// Reconnect waits for the pq.Listener to re-establish its connection.
+// The listener reconnects by itself, so opening another connection here
+// would race with it. A new listener is only created after Close.
+func (p *PqProvider) Reconnect(ctx context.Context) error {
+	if p.getListener() == nil {
+		return p.startListener(ctx)
+	}
+
+	return p.waitConnected(ctx)
+}
+
+// callWithContext runs a blocking pq.Listener call, returning early if ctx
+// is done. The call itself keeps running in the background in that case.
+func callWithContext(ctx context.Context, fn func() error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- fn()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,89 @@
+package pgnotify
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPqProvider_TracksListenerEvents(t *testing.T) {
+	p := newPqProviderState("", nil, false)
+
+	p.handleEvent(pq.ListenerEventConnected, nil)
+	assert.True(t, p.connected)
+
+	p.handleEvent(pq.ListenerEventDisconnected, errors.New("connection reset"))
+	assert.False(t, p.connected)
+
+	p.handleEvent(pq.ListenerEventReconnected, nil)
+	assert.True(t, p.connected)
+}
+
+func TestPqProvider_ReconnectWaitsForListener(t *testing.T) {
+	p := newPqProviderState("", nil, false)
+	p.handleEvent(pq.ListenerEventConnected, nil)
+	p.handleEvent(pq.ListenerEventDisconnected, errors.New("connection reset"))
+
+	done := make(chan error, 1)
+	go func() { done <- p.waitConnected(context.Background()) }()
+
+	select {
+	case <-done:
+		t.Fatal("waitConnected returned before the listener reconnected")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	p.handleEvent(pq.ListenerEventReconnected, nil)
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waitConnected did not return after reconnect")
+	}
+}
+
+func TestPqProvider_ReconnectTimeoutReportsLastError(t *testing.T) {
+	p := newPqProviderState("", nil, false)
+	attemptErr := errors.New("connection refused")
+	p.handleEvent(pq.ListenerEventConnectionAttemptFailed, attemptErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := p.waitConnected(ctx)
+	assert.ErrorIs(t, err, attemptErr)
+}
+
+func TestPqProvider_NotConnectedWithoutListener(t *testing.T) {
+	p := newPqProviderState("", nil, false)
+	ctx := context.Background()
+
+	assert.False(t, p.IsConnected())
+	assert.ErrorIs(t, p.Listen(ctx, "events"), ErrNotConnected)
+	assert.ErrorIs(t, p.Unlisten(ctx, "events"), ErrNotConnected)
+	assert.ErrorIs(t, p.Ping(ctx), ErrNotConnected)
+	assert.ErrorIs(t, p.Notify(ctx, "events", "x"), ErrNotConnected)
+	_, err := p.WaitForNotification(ctx)
+	assert.ErrorIs(t, err, ErrNotConnected)
+	assert.NoError(t, p.Close())
+}
+
+func TestCallWithContext(t *testing.T) {
+	assert.NoError(t, callWithContext(context.Background(), func() error { return nil }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	block := make(chan struct{})
+	defer close(block)
+	err := callWithContext(ctx, func() error {
+		<-block
+		return nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -29,7 +29,8 @@ func (s *subscription) Channel() string {
 	return s.channel
 }
 
-// Unsubscribe removes this subscription and sends UNLISTEN to PostgreSQL.
+// Unsubscribe removes this subscription. UNLISTEN is only sent to PostgreSQL
+// when no other subscription remains on the channel.
 func (s *subscription) Unsubscribe() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -39,7 +40,7 @@ func (s *subscription) Unsubscribe() error {
 	}
 
 	s.active = false
-	return s.notifier.unsubscribe(s.channel)
+	return s.notifier.unsubscribe(s)
 }
 
 // IsActive returns true if the subscription is still active.
@@ -87,24 +88,29 @@ func (sm *subscriptionManager) Add(channel string, callback CallbackFunc, notifi
 }
 
 // Remove removes a subscription for the given channel.
-func (sm *subscriptionManager) Remove(channel string, sub *subscription) {
+// It returns true if sub was the last subscription on the channel.
+func (sm *subscriptionManager) Remove(channel string, sub *subscription) bool {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
 
+	removed := false
 	subs := sm.subscriptions[channel]
 	for i, s := range subs {
 		if s == sub {
 			// Remove by swapping with last element
 			subs[i] = subs[len(subs)-1]
 			sm.subscriptions[channel] = subs[:len(subs)-1]
+			removed = true
 			break
 		}
 	}
 
 	// Clean up empty channel entries
-	if len(sm.subscriptions[channel]) == 0 {
+	if removed && len(sm.subscriptions[channel]) == 0 {
 		delete(sm.subscriptions, channel)
+		return true
 	}
+	return false
 }
 
 // Get returns all subscriptions for a given channel.
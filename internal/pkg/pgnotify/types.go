@@ -60,6 +60,9 @@ type Hooks struct {
 	// OnError is called when an error occurs during notification processing
 	OnError func(err error, channel string)
 
+	// OnDrop is called when a notification cannot be buffered for dispatch
+	OnDrop func(notification *Notification)
+
 	// OnSubscribe is called when a new subscription is created
 	OnSubscribe func(channel string)
 
@@ -111,6 +114,14 @@ type ConnectionProvider interface {
 	Reconnect(ctx context.Context) error
 }
 
+// BatchListener is implemented by providers that run commands one at a time
+// on a single connection. ListenAll LISTENs on every channel in one command,
+// so re-registration after a reconnect takes one round trip instead of a
+// queue of commands whose timeouts run while they wait their turn.
+type BatchListener interface {
+	ListenAll(ctx context.Context, channels []string) error
+}
+
 // Statistics holds runtime statistics for the notifier.
 type Statistics struct {
 	// TotalNotifications is the total number of notifications received
@@ -122,6 +133,9 @@ type Statistics struct {
 	// TotalReconnects is the total number of reconnection attempts
 	TotalReconnects int64
 
+	// TotalDropped is the total number of deliveries dropped because the dispatch buffer was full
+	TotalDropped int64
+
 	// ActiveSubscriptions is the current number of active subscriptions
 	ActiveSubscriptions int
 
@@ -0,0 +1,17 @@
+package rate
+
+import "time"
+
+// Clock is a source of the current time
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+// RealClock is a Clock backed by the system time
+type RealClock struct{}
+
+// Now returns time.Now()
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
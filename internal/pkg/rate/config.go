@@ -25,6 +25,14 @@ type LimiterConfig struct {
 	// FailOpen determines behavior when storage is unavailable
 	FailOpen bool `json:"fail_open" yaml:"fail_open" mapstructure:"fail_open"`
 
+	// HashKeys replaces keys with a salted hash before they are stored,
+	// logged or used as metric labels
+	HashKeys bool `json:"hash_keys" yaml:"hash_keys" mapstructure:"hash_keys"`
+
+	// HashSalt is the salt used when HashKeys is enabled; required then, since
+	// an unsalted hash of an email or IP is easily reversed
+	HashSalt string `json:"hash_salt" yaml:"hash_salt" mapstructure:"hash_salt"`
+
 	// Storage configuration
 	Storage StorageConfig `json:"storage" yaml:"storage" mapstructure:"storage"`
 }
@@ -76,12 +84,16 @@ func (c *LimiterConfig) Validate() error {
 
 	strategy := Strategy(c.Strategy)
 	switch strategy {
-	case StrategyTokenBucket, StrategyLeakyBucket, StrategyFixedWindow, StrategySlidingWindow:
+	case StrategyTokenBucket, StrategyLeakyBucket, StrategyFixedWindow, StrategySlidingWindow, StrategyGCRA:
 		// Valid strategy
 	default:
 		return fmt.Errorf("invalid strategy: %s", c.Strategy)
 	}
 
+	if c.HashKeys && c.HashSalt == "" {
+		return fmt.Errorf("hash salt is required when hash keys is enabled")
+	}
+
 	if err := c.Storage.Validate(); err != nil {
 		return fmt.Errorf("invalid storage config: %w", err)
 	}
@@ -111,8 +111,7 @@ func ExampleLimiter_Reserve() {
 		fmt.Printf("Reservation delayed by %v\n", reservation.Delay)
 		// Wait for reservation
 		if err := reservation.Wait(ctx); err != nil {
-			// Timeout or cancelled
-			reservation.Cancel()
+			// Timeout or cancelled; a denied reservation holds no tokens
 			return
 		}
 		// Now process
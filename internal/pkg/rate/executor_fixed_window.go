@@ -1,9 +1,6 @@
 package rate
 
-import (
-	"context"
-	"time"
-)
+import "context"
 
 // FixedWindowExecutor implements the fixed window counter algorithm
 type FixedWindowExecutor struct {
@@ -28,7 +25,7 @@ func NewFixedWindowExecutor(logger Logger, metrics MetricsCollector) *FixedWindo
 // Execute implements the fixed window counter algorithm
 // Divides time into fixed windows and counts requests per window
 func (e *FixedWindowExecutor) Execute(ctx context.Context, key string, n int, cfg *Config, storage Storage) (*Result, error) {
-	now := time.Now()
+	now := cfg.now()
 
 	// Calculate current window start
 	windowStart := now.Truncate(cfg.Interval)
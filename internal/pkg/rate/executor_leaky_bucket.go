@@ -30,7 +30,7 @@ func NewLeakyBucketExecutor(logger Logger, metrics MetricsCollector) *LeakyBucke
 // In leaky bucket, requests "leak" out at a constant rate
 // New requests are added to the bucket; if bucket overflows, requests are rejected
 func (e *LeakyBucketExecutor) Execute(ctx context.Context, key string, n int, cfg *Config, storage Storage) (*Result, error) {
-	now := time.Now()
+	now := cfg.now()
 
 	// Get current state
 	state, err := storage.Get(ctx, key)
@@ -28,7 +28,7 @@ func NewSlidingWindowExecutor(logger Logger, metrics MetricsCollector) *SlidingW
 // Execute implements the sliding window log algorithm
 // Maintains a log of request timestamps and slides the window
 func (e *SlidingWindowExecutor) Execute(ctx context.Context, key string, n int, cfg *Config, storage Storage) (*Result, error) {
-	now := time.Now()
+	now := cfg.now()
 	windowStart := now.Add(-cfg.Interval)
 
 	// Get current state
@@ -84,10 +84,11 @@ func (e *SlidingWindowExecutor) Execute(ctx context.Context, key string, n int,
 
 		remaining := cfg.Burst - len(validTimestamps)
 		return &Result{
-			Allowed:   true,
-			Limit:     cfg.Burst,
-			Remaining: remaining,
-			ResetAt:   now.Add(cfg.Interval),
+			Allowed:    true,
+			Limit:      cfg.Burst,
+			Remaining:  remaining,
+			ResetAt:    now.Add(cfg.Interval),
+			consumedAt: now,
 		}, nil
 	}
 
@@ -0,0 +1,132 @@
+package rate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// slidingWindowScript trims expired members from a ZSET of request
+// timestamps (Unix microseconds), then adds n members if they fit the limit.
+// It returns {allowed, count, oldest}, where oldest is 0 for an empty window.
+var slidingWindowScript = redis.NewScript(`
+	local now = tonumber(ARGV[1])
+	local window = tonumber(ARGV[2])
+	local limit = tonumber(ARGV[3])
+	local n = tonumber(ARGV[4])
+	local ttl_ms = tonumber(ARGV[5])
+	local nonce = ARGV[6]
+
+	redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now - window)
+	local count = redis.call('ZCARD', KEYS[1])
+
+	local oldest = 0
+	local first = redis.call('ZRANGE', KEYS[1], 0, 0, 'WITHSCORES')
+	if #first > 0 then
+		oldest = tonumber(first[2])
+	end
+
+	if n == 0 then
+		if count < limit then
+			return {1, count, oldest}
+		end
+		return {0, count, oldest}
+	end
+
+	if count + n > limit then
+		return {0, count, oldest}
+	end
+
+	-- Members must be unique, so several requests in the same microsecond don't
+	-- collapse; ARGV[1] is used as-is to avoid Lua's float formatting
+	for i = 1, n do
+		redis.call('ZADD', KEYS[1], ARGV[1], ARGV[1] .. ':' .. nonce .. ':' .. i)
+	end
+	redis.call('PEXPIRE', KEYS[1], ttl_ms)
+	if oldest == 0 then
+		oldest = now
+	end
+	return {1, count + n, oldest}
+`)
+
+// RedisSlidingWindowExecutor implements the sliding window log algorithm on a
+// Redis sorted set. Trimming, counting and adding run in one Lua script, so
+// concurrent requests can't overshoot the limit the way a serialized State can.
+// Storages other than *RedisStorage fall back to SlidingWindowExecutor.
+type RedisSlidingWindowExecutor struct {
+	logger   Logger
+	metrics  MetricsCollector
+	fallback *SlidingWindowExecutor
+}
+
+// NewRedisSlidingWindowExecutor creates a new Redis sliding window executor
+func NewRedisSlidingWindowExecutor(logger Logger, metrics MetricsCollector) *RedisSlidingWindowExecutor {
+	if logger == nil {
+		logger = &NoOpLogger{}
+	}
+	if metrics == nil {
+		metrics = &NoOpMetrics{}
+	}
+	return &RedisSlidingWindowExecutor{
+		logger:   logger,
+		metrics:  metrics,
+		fallback: NewSlidingWindowExecutor(logger, metrics),
+	}
+}
+
+// Execute implements the sliding window log algorithm using a ZSET
+func (e *RedisSlidingWindowExecutor) Execute(ctx context.Context, key string, n int, cfg *Config, storage Storage) (*Result, error) {
+	rs, ok := storage.(*RedisStorage)
+	if !ok {
+		return e.fallback.Execute(ctx, key, n, cfg, storage)
+	}
+
+	now := cfg.now()
+	ttl := cfg.TTL
+	if ttl < cfg.Interval {
+		ttl = cfg.Interval
+	}
+
+	nonce := rand.Int63()
+	result, err := slidingWindowScript.Run(ctx, rs.client, []string{rs.makeKey(key)},
+		now.UnixMicro(), cfg.Interval.Microseconds(), cfg.Burst, n, ttl.Milliseconds(), nonce,
+	).Int64Slice()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	}
+
+	allowed := result[0] == 1
+	remaining := max(cfg.Burst-int(result[1]), 0)
+
+	if allowed {
+		// The members the script added, so a refund can remove exactly these
+		members := make([]string, 0, n)
+		for i := 1; i <= n; i++ {
+			members = append(members, fmt.Sprintf("%d:%d:%d", now.UnixMicro(), nonce, i))
+		}
+		return &Result{
+			Allowed:   true,
+			Limit:     cfg.Burst,
+			Remaining: remaining,
+			ResetAt:   now.Add(cfg.Interval),
+			members:   members,
+		}, nil
+	}
+
+	// Limit exceeded - retry once the oldest request slides out
+	retryAfter := cfg.Interval
+	if result[2] > 0 {
+		retryAfter = max(time.UnixMicro(result[2]).Add(cfg.Interval).Sub(now), 0)
+	}
+
+	return &Result{
+		Allowed:    false,
+		Limit:      cfg.Burst,
+		Remaining:  remaining,
+		RetryAfter: retryAfter,
+		ResetAt:    now.Add(retryAfter),
+	}, nil
+}
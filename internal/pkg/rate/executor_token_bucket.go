@@ -28,7 +28,7 @@ func NewTokenBucketExecutor(logger Logger, metrics MetricsCollector) *TokenBucke
 
 // Execute implements the token bucket algorithm
 func (e *TokenBucketExecutor) Execute(ctx context.Context, key string, n int, cfg *Config, storage Storage) (*Result, error) {
-	now := time.Now()
+	now := cfg.now()
 
 	// Get current state
 	state, err := storage.Get(ctx, key)
@@ -0,0 +1,22 @@
+package rate
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// KeyHasher transforms a raw rate limit key before it is used for storage,
+// metrics and logging
+type KeyHasher func(key string) string
+
+// NewSaltedKeyHasher returns a KeyHasher that replaces keys with their
+// HMAC-SHA256 digest so identifiers such as emails or IPs are never
+// stored in the backend or exposed in metric labels
+func NewSaltedKeyHasher(salt string) KeyHasher {
+	return func(key string) string {
+		mac := hmac.New(sha256.New, []byte(salt))
+		mac.Write([]byte(key))
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+}
@@ -3,6 +3,10 @@ package rate
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
 	"time"
 )
 
@@ -23,6 +27,17 @@ type Limiter interface {
 	// AllowN checks if N requests are allowed and consumes N tokens if they are
 	AllowN(ctx context.Context, key string, n int) (bool, error)
 
+	// AllowResult is Allow but returns the full Result, including the
+	// remaining tokens, reset time and retry-after
+	AllowResult(ctx context.Context, key string) (*Result, error)
+
+	// AllowNResult is AllowN but returns the full Result
+	AllowNResult(ctx context.Context, key string, n int) (*Result, error)
+
+	// AllowAll checks a request against several keys and consumes a token
+	// from each only if every key allows it
+	AllowAll(ctx context.Context, keys []string) (bool, error)
+
 	// Check checks if a request would be allowed without consuming a token
 	Check(ctx context.Context, key string) (bool, error)
 
@@ -32,6 +47,9 @@ type Limiter interface {
 	// ReserveN reserves N tokens and returns a Reservation
 	ReserveN(ctx context.Context, key string, n int) (*Reservation, error)
 
+	// WaitN blocks until N tokens are granted or the context ends
+	WaitN(ctx context.Context, key string, n int) error
+
 	// Reset resets the rate limit for a specific key
 	Reset(ctx context.Context, key string) error
 
@@ -53,32 +71,52 @@ type Reservation struct {
 	// Limit is the rate limit configuration
 	Limit *Config
 
-	// cancel is called to cancel the reservation
+	// cancel returns the tokens a granted reservation took; nil when it took none
 	cancel func()
 }
 
-// Cancel cancels the reservation and returns the tokens
+// Cancel cancels the reservation. A granted reservation returns its tokens,
+// once; a denied one took no tokens, so there is nothing to return.
 func (r *Reservation) Cancel() {
 	if r.cancel != nil {
-		r.cancel()
+		cancel := r.cancel
+		r.cancel = nil
+		cancel()
 	}
 }
 
 // Wait waits until the reservation becomes valid or context is cancelled
 func (r *Reservation) Wait(ctx context.Context) error {
-	if r.Delay <= 0 {
-		return nil
+	_, err := r.WaitFor(ctx, 0)
+	return err
+}
+
+// WaitDuration returns the larger of the reservation delay and extra,
+// e.g. a Retry-After reported by a downstream service
+func (r *Reservation) WaitDuration(extra time.Duration) time.Duration {
+	if extra > r.Delay {
+		return extra
+	}
+	return r.Delay
+}
+
+// WaitFor waits for the larger of the reservation delay and extra, or until
+// the context is cancelled. It returns the duration it intended to wait.
+func (r *Reservation) WaitFor(ctx context.Context, extra time.Duration) (time.Duration, error) {
+	wait := r.WaitDuration(extra)
+	if wait <= 0 {
+		return 0, nil
 	}
 
-	timer := time.NewTimer(r.Delay)
+	timer := time.NewTimer(wait)
 	defer timer.Stop()
 
 	select {
 	case <-timer.C:
-		return nil
+		return wait, nil
 	case <-ctx.Done():
 		r.Cancel()
-		return ctx.Err()
+		return wait, ctx.Err()
 	}
 }
 
@@ -103,6 +141,18 @@ type Config struct {
 	// If true, allows requests when storage fails
 	// If false, denies requests when storage fails
 	FailOpen bool
+
+	// Clock is the time source used by the executors
+	// Defaults to the system clock when nil
+	Clock Clock
+}
+
+// now returns the current time from the configured clock
+func (c *Config) now() time.Time {
+	if c.Clock == nil {
+		return time.Now()
+	}
+	return c.Clock.Now()
 }
 
 // Validate validates the configuration
@@ -134,6 +184,9 @@ const (
 
 	// StrategySlidingWindow uses the sliding window log algorithm
 	StrategySlidingWindow Strategy = "sliding_window"
+
+	// StrategyGCRA uses the generic cell rate algorithm
+	StrategyGCRA Strategy = "gcra"
 )
 
 // Result contains the result of a rate limit check
@@ -152,6 +205,12 @@ type Result struct {
 
 	// ResetAt is when the rate limit resets
 	ResetAt time.Time
+
+	// consumedAt is the log timestamp a sliding window request added, and
+	// members are the ZSET members it added on Redis; a refund removes
+	// exactly these rather than whichever entries are newest
+	consumedAt time.Time
+	members    []string
 }
 
 // Executor executes rate limiting logic for a specific strategy
@@ -197,6 +256,9 @@ type State struct {
 
 	// Timestamps is a list of request timestamps (for sliding window)
 	Timestamps []time.Time
+
+	// TAT is the theoretical arrival time of the next request (for GCRA)
+	TAT time.Time
 }
 
 // limiterImpl is the default implementation of Limiter
@@ -206,8 +268,48 @@ type limiterImpl struct {
 	executor Executor
 	logger   Logger
 	metrics  MetricsCollector
+	hasher   KeyHasher
+	resolver ConfigResolver
+
+	// resolved caches validated copies of resolver configs by their limit
+	// fields, so an edited config is revalidated and equal ones share an entry
+	resolvedMu sync.RWMutex
+	resolved   map[resolvedKey]*Config
+}
+
+// resolvedKey is the part of a resolved config that decides its validity and
+// its prepared copy
+type resolvedKey struct {
+	strategy Strategy
+	rate     int
+	burst    int
+	interval time.Duration
+	ttl      time.Duration
+	failOpen bool
+	clock    Clock
 }
 
+// newResolvedKey returns the cache key for cfg; ok is false when its clock
+// can't be compared and the config must be validated uncached
+func newResolvedKey(cfg *Config) (resolvedKey, bool) {
+	if cfg.Clock != nil && !reflect.TypeOf(cfg.Clock).Comparable() {
+		return resolvedKey{}, false
+	}
+	return resolvedKey{
+		strategy: cfg.Strategy,
+		rate:     cfg.Rate,
+		burst:    cfg.Burst,
+		interval: cfg.Interval,
+		ttl:      cfg.TTL,
+		failOpen: cfg.FailOpen,
+		clock:    cfg.Clock,
+	}, true
+}
+
+// maxResolvedConfigs bounds the resolved cache; it is emptied when full so a
+// resolver with many distinct limits can't grow it without limit
+const maxResolvedConfigs = 1024
+
 // New creates a new rate limiter
 func New(config *Config, storage Storage, opts ...Option) (Limiter, error) {
 	if err := config.Validate(); err != nil {
@@ -235,7 +337,14 @@ func New(config *Config, storage Storage, opts ...Option) (Limiter, error) {
 	case StrategyFixedWindow:
 		l.executor = NewFixedWindowExecutor(l.logger, l.metrics)
 	case StrategySlidingWindow:
-		l.executor = NewSlidingWindowExecutor(l.logger, l.metrics)
+		// Redis keeps the log in a sorted set instead of a serialized State
+		if _, ok := storage.(*RedisStorage); ok {
+			l.executor = NewRedisSlidingWindowExecutor(l.logger, l.metrics)
+		} else {
+			l.executor = NewSlidingWindowExecutor(l.logger, l.metrics)
+		}
+	case StrategyGCRA:
+		l.executor = NewGCRAExecutor(l.logger, l.metrics)
 	default:
 		return nil, ErrInvalidConfig
 	}
@@ -250,17 +359,39 @@ func (l *limiterImpl) Allow(ctx context.Context, key string) (bool, error) {
 
 // AllowN implements Limiter.AllowN
 func (l *limiterImpl) AllowN(ctx context.Context, key string, n int) (bool, error) {
-	result, err := l.executor.Execute(ctx, key, n, l.config, l.storage)
+	result, err := l.AllowNResult(ctx, key, n)
+	if err != nil {
+		return false, err
+	}
+	return result.Allowed, nil
+}
+
+// AllowResult implements Limiter.AllowResult
+func (l *limiterImpl) AllowResult(ctx context.Context, key string) (*Result, error) {
+	return l.AllowNResult(ctx, key, 1)
+}
+
+// AllowNResult implements Limiter.AllowNResult
+// When storage fails and FailOpen is set, the request is allowed with a
+// Result that only carries the limit, since the real state is unknown.
+func (l *limiterImpl) AllowNResult(ctx context.Context, key string, n int) (*Result, error) {
+	cfg, err := l.resolveConfig(key)
+	if err != nil {
+		return nil, err
+	}
+
+	key = l.hashKey(key)
+	result, err := l.executor.Execute(ctx, key, n, cfg, l.storage)
 	if err != nil {
 		l.logger.Error("rate limit execution failed", "key", key, "error", err)
 		l.metrics.RecordError(l.config.Strategy, err)
 
 		// Handle fail-open/fail-close
-		if errors.Is(err, ErrStorageUnavailable) && l.config.FailOpen {
+		if errors.Is(err, ErrStorageUnavailable) && cfg.FailOpen {
 			l.metrics.RecordFailOpen(l.config.Strategy)
-			return true, nil
+			return &Result{Allowed: true, Limit: cfg.Rate}, nil
 		}
-		return false, err
+		return nil, err
 	}
 
 	l.metrics.RecordRequest(l.config.Strategy, result.Allowed)
@@ -271,15 +402,150 @@ func (l *limiterImpl) AllowN(ctx context.Context, key string, n int) (bool, erro
 		l.metrics.RecordDenied(l.config.Strategy, key, result.RetryAfter)
 	}
 
-	return result.Allowed, nil
+	return result, nil
+}
+
+// AllowAll implements Limiter.AllowAll
+// Keys are consumed in order; if any key denies or fails, tokens already
+// consumed from the preceding keys are refunded before returning.
+func (l *limiterImpl) AllowAll(ctx context.Context, keys []string) (bool, error) {
+	type consumedKey struct {
+		key    string
+		cfg    *Config
+		result *Result
+	}
+	consumed := make([]consumedKey, 0, len(keys))
+
+	rollback := func() {
+		for _, c := range consumed {
+			if err := l.refund(ctx, c.cfg, c.key, 1, c.result); err != nil {
+				l.logger.Warn("rate limit refund failed", "key", c.key, "error", err)
+			}
+		}
+	}
+
+	for _, key := range keys {
+		cfg, err := l.resolveConfig(key)
+		if err != nil {
+			rollback()
+			return false, err
+		}
+		key = l.hashKey(key)
+
+		result, err := l.executor.Execute(ctx, key, 1, cfg, l.storage)
+		if err != nil {
+			l.logger.Error("rate limit execution failed", "key", key, "error", err)
+			l.metrics.RecordError(l.config.Strategy, err)
+
+			if errors.Is(err, ErrStorageUnavailable) && cfg.FailOpen {
+				l.metrics.RecordFailOpen(l.config.Strategy)
+				continue
+			}
+			rollback()
+			return false, err
+		}
+
+		l.metrics.RecordRequest(l.config.Strategy, result.Allowed)
+
+		if !result.Allowed {
+			l.metrics.RecordDenied(l.config.Strategy, key, result.RetryAfter)
+			rollback()
+			return false, nil
+		}
+
+		l.metrics.RecordAllowed(l.config.Strategy, key)
+		consumed = append(consumed, consumedKey{key: key, cfg: cfg, result: result})
+	}
+
+	return true, nil
+}
+
+// refund returns n tokens that result consumed from an already hashed key
+func (l *limiterImpl) refund(ctx context.Context, cfg *Config, key string, n int, result *Result) error {
+	if gs, ok := l.storage.(GCRAStorage); ok && l.config.Strategy == StrategyGCRA {
+		emission, tolerance := gcraParams(cfg)
+		_, _, err := gs.UpdateTAT(ctx, key, cfg.now(), -n, emission, tolerance, cfg.TTL)
+		return err
+	}
+
+	if rs, ok := l.storage.(*RedisStorage); ok && l.config.Strategy == StrategySlidingWindow {
+		return rs.removeMembers(ctx, key, result.members)
+	}
+
+	op := refundOp{strategy: l.config.Strategy, n: n, consumedAt: result.consumedAt}
+	op.burst = cfg.Burst
+	if l.config.Strategy == StrategyGCRA {
+		op.emission, _ = gcraParams(cfg)
+	}
+
+	// Built-in storages apply the refund atomically; others fall back to a
+	// read-modify-write that can race with concurrent requests
+	if rs, ok := l.storage.(refundStorage); ok {
+		return rs.refund(ctx, key, op)
+	}
+
+	state, err := l.storage.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return nil
+	}
+	op.apply(state)
+
+	return l.storage.Set(ctx, key, state, cfg.TTL)
+}
+
+// refundOp describes tokens to return to a key's state
+type refundOp struct {
+	strategy   Strategy
+	n          int
+	burst      int
+	emission   time.Duration
+	consumedAt time.Time
+}
+
+// refundStorage is implemented by storages that can apply a refundOp atomically
+type refundStorage interface {
+	refund(ctx context.Context, key string, op refundOp) error
+}
+
+// apply returns the refunded tokens to state
+func (op refundOp) apply(state *State) {
+	switch op.strategy {
+	case StrategyTokenBucket:
+		state.Tokens = math.Min(state.Tokens+float64(op.n), float64(op.burst))
+	case StrategyLeakyBucket:
+		state.Tokens = math.Max(state.Tokens-float64(op.n), 0)
+	case StrategyFixedWindow:
+		state.Counter = max(state.Counter-int64(op.n), 0)
+	case StrategySlidingWindow:
+		// Drop the entries this request logged, newest first, leaving entries
+		// other requests added since in place
+		removed := 0
+		for i := len(state.Timestamps) - 1; i >= 0 && removed < op.n; i-- {
+			if state.Timestamps[i].Equal(op.consumedAt) {
+				state.Timestamps = append(state.Timestamps[:i], state.Timestamps[i+1:]...)
+				removed++
+			}
+		}
+	case StrategyGCRA:
+		state.TAT = state.TAT.Add(-time.Duration(op.n) * op.emission)
+	}
 }
 
 // Check implements Limiter.Check
 func (l *limiterImpl) Check(ctx context.Context, key string) (bool, error) {
+	cfg, err := l.resolveConfig(key)
+	if err != nil {
+		return false, err
+	}
+	key = l.hashKey(key)
+
 	// For check, we execute with 0 tokens to avoid consuming
-	result, err := l.executor.Execute(ctx, key, 0, l.config, l.storage)
+	result, err := l.executor.Execute(ctx, key, 0, cfg, l.storage)
 	if err != nil {
-		if errors.Is(err, ErrStorageUnavailable) && l.config.FailOpen {
+		if errors.Is(err, ErrStorageUnavailable) && cfg.FailOpen {
 			return true, nil
 		}
 		return false, err
@@ -294,10 +560,16 @@ func (l *limiterImpl) Reserve(ctx context.Context, key string) (*Reservation, er
 
 // ReserveN implements Limiter.ReserveN
 func (l *limiterImpl) ReserveN(ctx context.Context, key string, n int) (*Reservation, error) {
-	result, err := l.executor.Execute(ctx, key, n, l.config, l.storage)
+	cfg, err := l.resolveConfig(key)
 	if err != nil {
-		if errors.Is(err, ErrStorageUnavailable) && l.config.FailOpen {
-			return &Reservation{OK: true, Tokens: n, Limit: l.config}, nil
+		return &Reservation{OK: false}, err
+	}
+
+	key = l.hashKey(key)
+	result, err := l.executor.Execute(ctx, key, n, cfg, l.storage)
+	if err != nil {
+		if errors.Is(err, ErrStorageUnavailable) && cfg.FailOpen {
+			return &Reservation{OK: true, Tokens: n, Limit: cfg}, nil
 		}
 		return &Reservation{OK: false}, err
 	}
@@ -306,24 +578,62 @@ func (l *limiterImpl) ReserveN(ctx context.Context, key string, n int) (*Reserva
 		OK:     result.Allowed,
 		Delay:  result.RetryAfter,
 		Tokens: n,
-		Limit:  l.config,
+		Limit:  cfg,
 	}
 
-	// Add cancel function if not immediately allowed
-	if !result.Allowed && result.RetryAfter > 0 {
+	// Only a granted reservation took tokens, so only it has any to return
+	if result.Allowed {
 		reservation.cancel = func() {
-			// Return tokens by incrementing the state
 			// This is a best-effort operation
-			_, _ = l.storage.Increment(context.Background(), key, n, l.config.TTL)
+			_ = l.refund(context.Background(), cfg, key, n, result)
 		}
 	}
 
 	return reservation, nil
 }
 
+// WaitN implements Limiter.WaitN
+// Tokens aren't held while a denied reservation waits, so it reserves again
+// once the delay has passed until the request is granted.
+func (l *limiterImpl) WaitN(ctx context.Context, key string, n int) error {
+	cfg, err := l.resolveConfig(key)
+	if err != nil {
+		return err
+	}
+	if n > cfg.Burst {
+		return fmt.Errorf("%w: %d tokens exceed burst of %d", ErrRateLimitExceeded, n, cfg.Burst)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		reservation, err := l.ReserveN(ctx, key, n)
+		if err != nil {
+			return err
+		}
+		if reservation.OK {
+			return nil
+		}
+		if reservation.Delay <= 0 {
+			return ErrRateLimitExceeded
+		}
+
+		// Don't sleep until the deadline only to fail anyway
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < reservation.Delay {
+			return context.DeadlineExceeded
+		}
+
+		if err := reservation.Wait(ctx); err != nil {
+			return err
+		}
+	}
+}
+
 // Reset implements Limiter.Reset
 func (l *limiterImpl) Reset(ctx context.Context, key string) error {
-	return l.storage.Delete(ctx, key)
+	return l.storage.Delete(ctx, l.hashKey(key))
 }
 
 // Close implements Limiter.Close
@@ -331,6 +641,67 @@ func (l *limiterImpl) Close() error {
 	return l.storage.Close()
 }
 
+// resolveConfig returns the config for a raw key
+// Without a resolver, or when it returns nil, the base config applies.
+func (l *limiterImpl) resolveConfig(key string) (*Config, error) {
+	if l.resolver == nil {
+		return l.config, nil
+	}
+	cfg := l.resolver(key)
+	if cfg == nil || cfg == l.config {
+		return l.config, nil
+	}
+
+	// Read the fields once; the resolver may hand out a config it edits
+	copied := *cfg
+	cacheKey, cacheable := newResolvedKey(&copied)
+	if cacheable {
+		l.resolvedMu.RLock()
+		prepared, ok := l.resolved[cacheKey]
+		l.resolvedMu.RUnlock()
+		if ok {
+			return prepared, nil
+		}
+	}
+
+	// Work on the copy so a strategy or clock filled in here doesn't leak back
+	if copied.Strategy == "" {
+		copied.Strategy = l.config.Strategy
+	}
+	if copied.Strategy != l.config.Strategy {
+		return nil, fmt.Errorf("%w: resolved strategy %q differs from limiter strategy %q",
+			ErrInvalidConfig, copied.Strategy, l.config.Strategy)
+	}
+	if copied.Clock == nil {
+		copied.Clock = l.config.Clock
+	}
+	if err := copied.Validate(); err != nil {
+		return nil, fmt.Errorf("resolved config for key: %w", err)
+	}
+	if !cacheable {
+		return &copied, nil
+	}
+
+	l.resolvedMu.Lock()
+	defer l.resolvedMu.Unlock()
+	if prepared, ok := l.resolved[cacheKey]; ok {
+		return prepared, nil
+	}
+	if l.resolved == nil || len(l.resolved) >= maxResolvedConfigs {
+		l.resolved = make(map[resolvedKey]*Config)
+	}
+	l.resolved[cacheKey] = &copied
+	return &copied, nil
+}
+
+// hashKey applies the configured KeyHasher, if any
+func (l *limiterImpl) hashKey(key string) string {
+	if l.hasher == nil {
+		return key
+	}
+	return l.hasher(key)
+}
+
 // Option is a functional option for configuring a Limiter
 type Option func(*limiterImpl)
 
@@ -347,3 +718,31 @@ func WithMetrics(metrics MetricsCollector) Option {
 		l.metrics = metrics
 	}
 }
+
+// ConfigResolver returns the config for a raw (unhashed) key, or nil to use
+// the limiter's base config. It must use the base strategy; Strategy and
+// Clock may be left empty to inherit them. Resolved configs are validated
+// once per distinct set of limits and cached by value, so a resolver may
+// build configs per call or edit a shared one in place; an edited config is
+// revalidated on its next use.
+type ConfigResolver func(key string) *Config
+
+// WithConfigResolver resolves the config per key, e.g. to give paid plans
+// higher limits than free ones from the same limiter
+func WithConfigResolver(resolver ConfigResolver) Option {
+	return func(l *limiterImpl) {
+		l.resolvedMu.Lock()
+		defer l.resolvedMu.Unlock()
+		// Configs validated for a previous resolver no longer apply
+		l.resolver = resolver
+		l.resolved = nil
+	}
+}
+
+// WithKeyHasher sets a hasher applied to every key before it reaches
+// storage, metrics or logs
+func WithKeyHasher(hasher KeyHasher) Option {
+	return func(l *limiterImpl) {
+		l.hasher = hasher
+	}
+}
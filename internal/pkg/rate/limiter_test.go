@@ -2,8 +2,16 @@ package rate
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/redis/go-redis/v9"
 )
 
 func TestTokenBucketMemory(t *testing.T) {
@@ -162,6 +170,58 @@ func TestSlidingWindowMemory(t *testing.T) {
 	}
 }
 
+func TestGCRAMemory(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyGCRA,
+		Rate:     10,
+		Burst:    20,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		FailOpen: false,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	// Test allowing requests within burst
+	for i := 0; i < 20; i++ {
+		allowed, err := limiter.Allow(ctx, "test-key")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !allowed {
+			t.Errorf("request %d should be allowed", i)
+		}
+	}
+
+	// Test denying requests after burst
+	allowed, err := limiter.Allow(ctx, "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if allowed {
+		t.Error("request should be denied after burst exhausted")
+	}
+
+	// Wait and check if the schedule drains
+	time.Sleep(1 * time.Second)
+	allowed, err = limiter.Allow(ctx, "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Error("request should be allowed after the schedule drains")
+	}
+}
+
 func TestReservation(t *testing.T) {
 	storage := NewMemoryStorage()
 	defer storage.Close()
@@ -262,6 +322,60 @@ func TestAllowN(t *testing.T) {
 	}
 }
 
+func TestAllowNResult(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyTokenBucket,
+		Rate:     10,
+		Burst:    10,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		Clock:    clock,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	result, err := limiter.AllowNResult(ctx, "test-key", 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Allowed || result.Limit != 10 || result.Remaining != 6 {
+		t.Errorf("expected allowed with 6 of 10 remaining, got %+v", result)
+	}
+
+	result, err = limiter.AllowNResult(ctx, "test-key", 8)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Fatal("request should be denied")
+	}
+	// 2 missing tokens at 10/s
+	if result.RetryAfter != 200*time.Millisecond {
+		t.Errorf("expected 200ms retry-after, got %v", result.RetryAfter)
+	}
+	if !result.ResetAt.Equal(clock.Now().Add(200 * time.Millisecond)) {
+		t.Errorf("unexpected reset time %v", result.ResetAt)
+	}
+
+	result, err = limiter.AllowResult(ctx, "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Allowed || result.Remaining != 5 {
+		t.Errorf("expected allowed with 5 remaining, got %+v", result)
+	}
+}
+
 func TestReset(t *testing.T) {
 	storage := NewMemoryStorage()
 	defer storage.Close()
@@ -310,54 +424,1159 @@ func TestReset(t *testing.T) {
 	}
 }
 
-func BenchmarkTokenBucketMemory(b *testing.B) {
+// recordingMetrics captures the keys passed to the metrics collector
+type recordingMetrics struct {
+	NoOpMetrics
+	keys []string
+}
+
+func (m *recordingMetrics) RecordAllowed(strategy Strategy, key string) {
+	m.keys = append(m.keys, key)
+}
+
+func (m *recordingMetrics) RecordDenied(strategy Strategy, key string, retryAfter time.Duration) {
+	m.keys = append(m.keys, key)
+}
+
+func TestKeyHashing(t *testing.T) {
 	storage := NewMemoryStorage()
 	defer storage.Close()
 
 	config := &Config{
-		Strategy: StrategyTokenBucket,
-		Rate:     1000000,
-		Burst:    1000000,
-		Interval: 1 * time.Second,
-		TTL:      5 * time.Second,
+		Strategy: StrategyFixedWindow,
+		Rate:     1,
+		Burst:    1,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
 		FailOpen: false,
 	}
 
-	limiter, _ := New(config, storage)
+	hasher := NewSaltedKeyHasher("pepper")
+	metrics := &recordingMetrics{}
+
+	limiter, err := New(config, storage, WithKeyHasher(hasher), WithMetrics(metrics))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
 	defer limiter.Close()
 
 	ctx := context.Background()
+	rawKey := "alice@example.com"
+	hashedKey := hasher(rawKey)
 
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			limiter.Allow(ctx, "bench-key")
+	if hashedKey == rawKey || hashedKey != hasher(rawKey) {
+		t.Fatalf("hasher should be deterministic and transform the key")
+	}
+
+	allowed, err := limiter.Allow(ctx, rawKey)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Error("first request should be allowed")
+	}
+
+	// The raw key must never reach storage
+	if state, _ := storage.Get(ctx, rawKey); state != nil {
+		t.Error("raw key should not be stored")
+	}
+	if state, _ := storage.Get(ctx, hashedKey); state == nil {
+		t.Error("hashed key should be stored")
+	}
+
+	// Limiting still applies per identity
+	allowed, _ = limiter.Allow(ctx, rawKey)
+	if allowed {
+		t.Error("second request for the same identity should be denied")
+	}
+	allowed, _ = limiter.Allow(ctx, "bob@example.com")
+	if !allowed {
+		t.Error("a different identity should not share the limit")
+	}
+
+	for _, key := range metrics.keys {
+		if key == rawKey || key == "bob@example.com" {
+			t.Errorf("metrics received raw key %q", key)
 		}
-	})
+	}
+
+	// Reset must operate on the hashed key too
+	if err := limiter.Reset(ctx, rawKey); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	allowed, _ = limiter.Allow(ctx, rawKey)
+	if !allowed {
+		t.Error("request should be allowed after reset")
+	}
 }
 
-func BenchmarkFixedWindowMemory(b *testing.B) {
+func TestNewLimiterFromConfig_HashKeysRequiresSalt(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := DefaultConfig()
+	config.HashKeys = true
+
+	_, err := NewLimiterFromConfig(LimiterParams{Config: config, Storage: storage})
+	if err == nil || !strings.Contains(err.Error(), "hash salt is required") {
+		t.Fatalf("expected a missing salt error, got %v", err)
+	}
+
+	config.HashSalt = "pepper"
+	limiter, err := NewLimiterFromConfig(LimiterParams{Config: config, Storage: storage})
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	limiter.Close()
+}
+
+func TestConfigResolver(t *testing.T) {
 	storage := NewMemoryStorage()
 	defer storage.Close()
 
 	config := &Config{
 		Strategy: StrategyFixedWindow,
-		Rate:     1000000,
-		Burst:    1000000,
-		Interval: 1 * time.Second,
-		TTL:      5 * time.Second,
-		FailOpen: false,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+	}
+	premium := &Config{
+		Rate:     5,
+		Burst:    5,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
 	}
 
-	limiter, _ := New(config, storage)
+	var mu sync.Mutex
+	calls := 0
+	resolver := func(key string) *Config {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		if key == "premium" {
+			return premium
+		}
+		return nil
+	}
+
+	limiter, err := New(config, storage, WithConfigResolver(resolver))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
 	defer limiter.Close()
 
 	ctx := context.Background()
 
-	b.ResetTimer()
-	b.RunParallel(func(pb *testing.PB) {
-		for pb.Next() {
-			limiter.Allow(ctx, "bench-key")
+	count := func(key string) int {
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			ok, err := limiter.Allow(ctx, key)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok {
+				allowed++
+			}
 		}
-	})
+		return allowed
+	}
+
+	if got := count("premium"); got != 5 {
+		t.Errorf("premium key: expected 5 allowed, got %d", got)
+	}
+	if got := count("free"); got != 2 {
+		t.Errorf("unresolved key: expected base limit of 2, got %d", got)
+	}
+
+	result, err := limiter.AllowNResult(ctx, "premium", 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Limit != 5 {
+		t.Errorf("expected resolved limit 5, got %d", result.Limit)
+	}
+
+	r, err := limiter.Reserve(ctx, "premium-other")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Limit != config {
+		t.Error("reservation for an unresolved key should use the base config")
+	}
+
+	// The caller's config is left untouched
+	if premium.Strategy != "" {
+		t.Errorf("resolver config should not be modified, got strategy %q", premium.Strategy)
+	}
+
+	// The shared premium config is validated once and reused
+	impl := limiter.(*limiterImpl)
+	first, _ := impl.resolveConfig("premium")
+	second, _ := impl.resolveConfig("premium")
+	if first != second || len(impl.resolved) != 1 {
+		t.Errorf("expected one cached premium config, got %d entries", len(impl.resolved))
+	}
+}
+
+func TestConfigResolver_FreshConfigPerCall(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+	}
+	// A resolver that allocates per call must still get its limit applied
+	resolver := func(key string) *Config {
+		return &Config{Rate: 3, Burst: 3, Interval: 1 * time.Minute, TTL: 2 * time.Minute}
+	}
+
+	limiter, err := New(config, storage, WithConfigResolver(resolver))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+	allowed := 0
+	for i := 0; i < 10; i++ {
+		ok, err := limiter.Allow(ctx, "user")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if ok {
+			allowed++
+		}
+	}
+	if allowed != 3 {
+		t.Errorf("expected 3 allowed, got %d", allowed)
+	}
+
+	// Equal configs share one cache entry however they were allocated
+	if got := len(limiter.(*limiterImpl).resolved); got != 1 {
+		t.Errorf("expected one cached config, got %d", got)
+	}
+}
+
+func TestConfigResolver_EditedInPlace(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+	}
+	plan := &Config{Rate: 3, Burst: 3, Interval: 1 * time.Minute, TTL: 2 * time.Minute}
+
+	limiter, err := New(config, storage, WithConfigResolver(func(key string) *Config {
+		return plan
+	}))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	impl := limiter.(*limiterImpl)
+	cfg, err := impl.resolveConfig("user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Rate != 3 {
+		t.Fatalf("expected rate 3, got %d", cfg.Rate)
+	}
+
+	// A plan change made on the shared config applies on the next call
+	plan.Rate, plan.Burst = 7, 7
+	cfg, err = impl.resolveConfig("user")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Rate != 7 || cfg.Burst != 7 {
+		t.Errorf("expected the edited limit of 7, got rate %d burst %d", cfg.Rate, cfg.Burst)
+	}
+
+	// An edit that makes the config invalid is caught too
+	plan.Burst = 1
+	if _, err := impl.resolveConfig("user"); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig after an invalid edit, got %v", err)
+	}
+}
+
+func TestConfigResolver_CacheIsBounded(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+	}
+	// Every key gets its own limit, so each one is a distinct cache entry
+	resolver := func(key string) *Config {
+		rate, _ := strconv.Atoi(key)
+		return &Config{Rate: rate, Burst: rate, Interval: 1 * time.Minute, TTL: 2 * time.Minute}
+	}
+
+	limiter, err := New(config, storage, WithConfigResolver(resolver))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	impl := limiter.(*limiterImpl)
+	for i := 1; i <= maxResolvedConfigs*2; i++ {
+		if _, err := impl.resolveConfig(strconv.Itoa(i)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(impl.resolved) > maxResolvedConfigs {
+			t.Fatalf("cache grew to %d entries, limit is %d", len(impl.resolved), maxResolvedConfigs)
+		}
+	}
+}
+
+func TestConfigResolver_Invalid(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyTokenBucket,
+		Rate:     10,
+		Burst:    10,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+	}
+	configs := map[string]*Config{
+		"bad-burst":    {Rate: 10, Burst: 1, Interval: time.Second},
+		"bad-strategy": {Strategy: StrategyFixedWindow, Rate: 10, Burst: 10, Interval: time.Second},
+	}
+
+	limiter, err := New(config, storage, WithConfigResolver(func(key string) *Config {
+		return configs[key]
+	}))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+	for key := range configs {
+		if _, err := limiter.Allow(ctx, key); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig from Allow, got %v", key, err)
+		}
+		if _, err := limiter.Check(ctx, key); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig from Check, got %v", key, err)
+		}
+		if _, err := limiter.Reserve(ctx, key); !errors.Is(err, ErrInvalidConfig) {
+			t.Errorf("%s: expected ErrInvalidConfig from Reserve, got %v", key, err)
+		}
+	}
+
+	// A bad key fails the whole batch without consuming the good ones
+	if _, err := limiter.AllowAll(ctx, []string{"ok", "bad-burst"}); !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("expected ErrInvalidConfig from AllowAll, got %v", err)
+	}
+	state, err := storage.Get(ctx, "ok")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if state != nil && state.Tokens != float64(config.Burst) {
+		t.Errorf("expected the consumed token to be refunded, got %v tokens", state.Tokens)
+	}
+}
+
+func TestAllowAll(t *testing.T) {
+	strategies := []Strategy{
+		StrategyTokenBucket,
+		StrategyLeakyBucket,
+		StrategyFixedWindow,
+		StrategySlidingWindow,
+		StrategyGCRA,
+	}
+
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			storage := NewMemoryStorage()
+			defer storage.Close()
+
+			config := &Config{
+				Strategy: strategy,
+				Rate:     2,
+				Burst:    2,
+				Interval: 1 * time.Minute,
+				TTL:      2 * time.Minute,
+				FailOpen: false,
+			}
+
+			limiter, err := New(config, storage)
+			if err != nil {
+				t.Fatalf("failed to create limiter: %v", err)
+			}
+			defer limiter.Close()
+
+			ctx := context.Background()
+
+			// Exhaust the endpoint key so it denies
+			for i := 0; i < 2; i++ {
+				limiter.Allow(ctx, "endpoint")
+			}
+
+			allowed, err := limiter.AllowAll(ctx, []string{"user", "tenant", "endpoint"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed {
+				t.Fatal("AllowAll should be denied when one key denies")
+			}
+
+			// The user and tenant keys must have been refunded
+			allowed, err = limiter.AllowN(ctx, "user", 2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !allowed {
+				t.Error("user key should have been refunded")
+			}
+			allowed, err = limiter.AllowN(ctx, "tenant", 2)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !allowed {
+				t.Error("tenant key should have been refunded")
+			}
+		})
+	}
+}
+
+func TestAllowAll_AllAllow(t *testing.T) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     1,
+		Burst:    1,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+		FailOpen: false,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	allowed, err := limiter.AllowAll(ctx, []string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Fatal("AllowAll should be allowed when every key allows")
+	}
+
+	// Each key consumed its single token
+	if allowed, _ := limiter.Allow(ctx, "a"); allowed {
+		t.Error("key a should be exhausted")
+	}
+	if allowed, _ := limiter.Allow(ctx, "b"); allowed {
+		t.Error("key b should be exhausted")
+	}
+}
+
+func TestReservationWaitFor(t *testing.T) {
+	ctx := context.Background()
+
+	// Reservation delay is larger
+	res := &Reservation{Delay: 50 * time.Millisecond}
+	start := time.Now()
+	wait, err := res.WaitFor(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wait != 50*time.Millisecond {
+		t.Errorf("expected wait of 50ms, got %v", wait)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("returned too early after %v", elapsed)
+	}
+
+	// Downstream Retry-After is larger
+	res = &Reservation{Delay: 10 * time.Millisecond}
+	start = time.Now()
+	wait, err = res.WaitFor(ctx, 60*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if wait != 60*time.Millisecond {
+		t.Errorf("expected wait of 60ms, got %v", wait)
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("returned too early after %v", elapsed)
+	}
+
+	// Nothing to wait for
+	res = &Reservation{}
+	wait, err = res.WaitFor(ctx, 0)
+	if err != nil || wait != 0 {
+		t.Errorf("expected immediate return, got %v, %v", wait, err)
+	}
+}
+
+func TestReservationWaitFor_ContextCancelled(t *testing.T) {
+	cancelled := false
+	res := &Reservation{
+		Delay:  10 * time.Millisecond,
+		cancel: func() { cancelled = true },
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	wait, err := res.WaitFor(ctx, 5*time.Second)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if wait != 5*time.Second {
+		t.Errorf("expected computed wait of 5s, got %v", wait)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("WaitFor ignored cancellation, took %v", elapsed)
+	}
+	if !cancelled {
+		t.Error("reservation should be cancelled when the context ends")
+	}
+}
+
+func newWaitNLimiter(t *testing.T) Limiter {
+	t.Helper()
+	storage := NewMemoryStorage()
+	t.Cleanup(func() { storage.Close() })
+
+	// 100 tokens per second refills one token every 10ms
+	limiter, err := New(&Config{
+		Strategy: StrategyTokenBucket,
+		Rate:     100,
+		Burst:    100,
+		Interval: time.Second,
+	}, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	t.Cleanup(func() { limiter.Close() })
+	return limiter
+}
+
+func TestWaitN(t *testing.T) {
+	limiter := newWaitNLimiter(t)
+	ctx := context.Background()
+
+	if err := limiter.WaitN(ctx, "api", 100); err != nil {
+		t.Fatalf("full burst should be granted immediately: %v", err)
+	}
+
+	start := time.Now()
+	if err := limiter.WaitN(ctx, "api", 5); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected to wait for 5 tokens to refill, returned after %v", elapsed)
+	}
+
+	if allowed, _ := limiter.Allow(ctx, "api"); allowed {
+		t.Error("WaitN should have consumed the refilled tokens")
+	}
+}
+
+func TestWaitN_ContextCancelled(t *testing.T) {
+	limiter := newWaitNLimiter(t)
+	if err := limiter.WaitN(context.Background(), "api", 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The 50 tokens take 500ms to refill, so the deadline can't be met
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if err := limiter.WaitN(ctx, "api", 50); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+		t.Errorf("WaitN ignored the deadline, took %v", elapsed)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if err := limiter.WaitN(ctx, "api", 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context canceled, got %v", err)
+	}
+}
+
+func TestWaitN_ExceedsBurst(t *testing.T) {
+	limiter := newWaitNLimiter(t)
+	if err := limiter.WaitN(context.Background(), "api", 101); !errors.Is(err, ErrRateLimitExceeded) {
+		t.Errorf("expected ErrRateLimitExceeded for n > burst, got %v", err)
+	}
+}
+
+// newFixedWindowLimiter returns a limiter allowing 10 requests a second and its storage
+func newFixedWindowLimiter(t *testing.T) (Limiter, *MemoryStorage) {
+	t.Helper()
+	storage := NewMemoryStorage()
+	t.Cleanup(func() { storage.Close() })
+
+	limiter, err := New(&Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     10,
+		Burst:    10,
+		Interval: time.Second,
+	}, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	t.Cleanup(func() { limiter.Close() })
+	return limiter, storage
+}
+
+func windowCounter(t *testing.T, storage *MemoryStorage, key string) int64 {
+	t.Helper()
+	state, err := storage.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("failed to read state: %v", err)
+	}
+	if state == nil {
+		return 0
+	}
+	return state.Counter
+}
+
+func TestWaitN_FailureLeavesStateUnchanged(t *testing.T) {
+	limiter, storage := newFixedWindowLimiter(t)
+	if err := limiter.WaitN(context.Background(), "api", 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The deadline is sooner than the window reset
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := limiter.WaitN(ctx, "api", 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if got := windowCounter(t, storage, "api"); got != 10 {
+		t.Errorf("counter after a timed-out WaitN = %d, want 10", got)
+	}
+
+	// Cancelled while waiting for the window to reset
+	ctx, cancel = context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := limiter.WaitN(ctx, "api", 5); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected cancellation, got %v", err)
+	}
+	if got := windowCounter(t, storage, "api"); got != 10 {
+		t.Errorf("counter after a cancelled WaitN = %d, want 10", got)
+	}
+}
+
+func TestReservationCancel_ReturnsGrantedTokensOnce(t *testing.T) {
+	limiter, storage := newFixedWindowLimiter(t)
+	ctx := context.Background()
+
+	granted, err := limiter.ReserveN(ctx, "api", 4)
+	if err != nil || !granted.OK {
+		t.Fatalf("expected a granted reservation, got %+v, %v", granted, err)
+	}
+	granted.Cancel()
+	granted.Cancel()
+	if got := windowCounter(t, storage, "api"); got != 0 {
+		t.Errorf("counter after cancelling a granted reservation = %d, want 0", got)
+	}
+
+	if _, err := limiter.ReserveN(ctx, "api", 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	denied, err := limiter.ReserveN(ctx, "api", 3)
+	if err != nil || denied.OK {
+		t.Fatalf("expected a denied reservation, got %+v, %v", denied, err)
+	}
+	denied.Cancel()
+	if got := windowCounter(t, storage, "api"); got != 10 {
+		t.Errorf("counter after cancelling a denied reservation = %d, want 10", got)
+	}
+}
+
+// fakeClock is a manually advanced Clock for deterministic tests
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestTokenBucketRefill_FakeClock(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyTokenBucket,
+		Rate:     10,
+		Burst:    10,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		Clock:    clock,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	allowed, _ := limiter.AllowN(ctx, "test-key", 10)
+	if !allowed {
+		t.Fatal("burst should be allowed")
+	}
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Fatal("request should be denied after burst exhausted")
+	}
+
+	// 100ms refills exactly one token at 10/s
+	clock.Advance(100 * time.Millisecond)
+	if allowed, _ := limiter.Allow(ctx, "test-key"); !allowed {
+		t.Error("one token should have been refilled")
+	}
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Error("only one token should have been refilled")
+	}
+
+	clock.Advance(1 * time.Second)
+	if allowed, _ := limiter.AllowN(ctx, "test-key", 10); !allowed {
+		t.Error("bucket should be full after a full interval")
+	}
+}
+
+func TestFixedWindowRollover_FakeClock(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     5,
+		Burst:    5,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+		Clock:    clock,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	if allowed, _ := limiter.AllowN(ctx, "test-key", 5); !allowed {
+		t.Fatal("window capacity should be allowed")
+	}
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Fatal("request should be denied once the window is full")
+	}
+
+	clock.Advance(59 * time.Second)
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Error("request should still be denied within the same window")
+	}
+
+	clock.Advance(1 * time.Second)
+	if allowed, _ := limiter.Allow(ctx, "test-key"); !allowed {
+		t.Error("request should be allowed in the next window")
+	}
+}
+
+func TestSlidingWindowSlide_FakeClock(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategySlidingWindow,
+		Rate:     2,
+		Burst:    2,
+		Interval: 10 * time.Second,
+		TTL:      20 * time.Second,
+		Clock:    clock,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "test-key")
+	clock.Advance(5 * time.Second)
+	limiter.Allow(ctx, "test-key")
+
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Fatal("request should be denied with a full window")
+	}
+
+	// The first request falls out of the window
+	clock.Advance(5*time.Second + time.Millisecond)
+	if allowed, _ := limiter.Allow(ctx, "test-key"); !allowed {
+		t.Error("request should be allowed after the oldest entry slides out")
+	}
+}
+
+func TestGCRAEmission_FakeClock(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyGCRA,
+		Rate:     10,
+		Burst:    10,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		Clock:    clock,
+	}
+
+	limiter, err := New(config, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	allowed, _ := limiter.AllowN(ctx, "test-key", 10)
+	if !allowed {
+		t.Fatal("burst should be allowed")
+	}
+
+	reservation, err := limiter.Reserve(ctx, "test-key")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reservation.OK || reservation.Delay != 100*time.Millisecond {
+		t.Fatalf("expected denial with 100ms delay, got ok=%v delay=%v", reservation.OK, reservation.Delay)
+	}
+
+	// One emission interval at 10/s frees exactly one slot
+	clock.Advance(100 * time.Millisecond)
+	if allowed, _ := limiter.Allow(ctx, "test-key"); !allowed {
+		t.Error("one request should be allowed after an emission interval")
+	}
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Error("only one request should be allowed after an emission interval")
+	}
+
+	clock.Advance(1 * time.Second)
+	if allowed, _ := limiter.AllowN(ctx, "test-key", 10); !allowed {
+		t.Error("full burst should be available after a full interval")
+	}
+}
+
+// plainStorage hides MemoryStorage's GCRAStorage implementation
+type plainStorage struct {
+	Storage
+}
+
+func TestGCRA_GetSetFallback(t *testing.T) {
+	clock := newFakeClock()
+	memory := NewMemoryStorage(WithStorageClock(clock))
+	defer memory.Close()
+
+	config := &Config{
+		Strategy: StrategyGCRA,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		Clock:    clock,
+	}
+
+	limiter, err := New(config, plainStorage{memory})
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+
+	ctx := context.Background()
+
+	if allowed, _ := limiter.AllowN(ctx, "test-key", 2); !allowed {
+		t.Fatal("burst should be allowed")
+	}
+	if allowed, _ := limiter.Check(ctx, "test-key"); allowed {
+		t.Error("check should report the burst as exhausted")
+	}
+
+	clock.Advance(500 * time.Millisecond)
+	if allowed, _ := limiter.Allow(ctx, "test-key"); !allowed {
+		t.Error("one request should be allowed after an emission interval")
+	}
+	if allowed, _ := limiter.Allow(ctx, "test-key"); allowed {
+		t.Error("only one request should be allowed after an emission interval")
+	}
+}
+
+func TestSlidingWindowExecutorSelection(t *testing.T) {
+	config := &Config{
+		Strategy: StrategySlidingWindow,
+		Rate:     5,
+		Burst:    5,
+		Interval: 1 * time.Second,
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:0"})
+	defer client.Close()
+
+	limiter, err := New(config, NewRedisStorage(client, ""))
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	if _, ok := limiter.(*limiterImpl).executor.(*RedisSlidingWindowExecutor); !ok {
+		t.Error("redis storage should use the sorted set executor")
+	}
+
+	memory := NewMemoryStorage()
+	defer memory.Close()
+
+	limiter, err = New(config, memory)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	if _, ok := limiter.(*limiterImpl).executor.(*SlidingWindowExecutor); !ok {
+		t.Error("memory storage should use the state-based executor")
+	}
+}
+
+func TestRedisSlidingWindowExecutor_FallsBackWithoutRedis(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategySlidingWindow,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		Clock:    clock,
+	}
+
+	executor := NewRedisSlidingWindowExecutor(nil, nil)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		result, err := executor.Execute(ctx, "test-key", 1, config, storage)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !result.Allowed {
+			t.Errorf("request %d should be allowed", i)
+		}
+	}
+
+	result, err := executor.Execute(ctx, "test-key", 1, config, storage)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Allowed {
+		t.Error("request should be denied after the window is full")
+	}
+}
+
+func TestMemoryStorageExpiry_FakeClock(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	ctx := context.Background()
+	storage.Set(ctx, "key", &State{Counter: 1}, time.Second)
+
+	if state, _ := storage.Get(ctx, "key"); state == nil {
+		t.Fatal("state should exist before expiry")
+	}
+
+	clock.Advance(2 * time.Second)
+	if state, _ := storage.Get(ctx, "key"); state != nil {
+		t.Error("state should be expired")
+	}
+}
+
+func TestMemoryStorageCleanup_FakeClock(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 1000; i++ {
+		storage.Set(ctx, fmt.Sprintf("ip:%d", i), &State{Counter: 1}, time.Second)
+	}
+	storage.Set(ctx, "long-lived", &State{Counter: 1}, time.Hour)
+
+	if removed := storage.cleanup(); removed != 0 {
+		t.Errorf("nothing should be swept before expiry, removed %d", removed)
+	}
+
+	clock.Advance(2 * time.Second)
+	if removed := storage.cleanup(); removed != 1000 {
+		t.Errorf("expected 1000 keys swept, got %d", removed)
+	}
+	if storage.Len() != 1 {
+		t.Errorf("expected only the long-lived key left, got %d", storage.Len())
+	}
+}
+
+func TestMemoryStorageCleanupLoop(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock), WithCleanupInterval(5*time.Millisecond))
+	defer storage.Close()
+
+	ctx := context.Background()
+	for i := 0; i < 100; i++ {
+		storage.Set(ctx, fmt.Sprintf("ip:%d", i), &State{Counter: 1}, time.Second)
+	}
+	clock.Advance(2 * time.Second)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for storage.Len() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("janitor did not sweep expired keys, %d left", storage.Len())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func BenchmarkTokenBucketMemory(b *testing.B) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyTokenBucket,
+		Rate:     1000000,
+		Burst:    1000000,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		FailOpen: false,
+	}
+
+	limiter, _ := New(config, storage)
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			limiter.Allow(ctx, "bench-key")
+		}
+	})
+}
+
+func BenchmarkFixedWindowMemory(b *testing.B) {
+	storage := NewMemoryStorage()
+	defer storage.Close()
+
+	config := &Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     1000000,
+		Burst:    1000000,
+		Interval: 1 * time.Second,
+		TTL:      5 * time.Second,
+		FailOpen: false,
+	}
+
+	limiter, _ := New(config, storage)
+	defer limiter.Close()
+
+	ctx := context.Background()
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			limiter.Allow(ctx, "bench-key")
+		}
+	})
+}
+
+// BenchmarkSlidingWindowRedis compares the sorted set executor with the
+// serialized State log. It needs a Redis server at RATE_BENCH_REDIS_ADDR.
+func BenchmarkSlidingWindowRedis(b *testing.B) {
+	addr := os.Getenv("RATE_BENCH_REDIS_ADDR")
+	if addr == "" {
+		b.Skip("RATE_BENCH_REDIS_ADDR not set")
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	defer client.Close()
+
+	ctx := context.Background()
+	if err := client.Ping(ctx).Err(); err != nil {
+		b.Skipf("redis unavailable: %v", err)
+	}
+
+	config := &Config{
+		Strategy: StrategySlidingWindow,
+		Rate:     1000,
+		Burst:    1000,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+	}
+
+	executors := map[string]Executor{
+		"zset":       NewRedisSlidingWindowExecutor(nil, nil),
+		"serialized": NewSlidingWindowExecutor(nil, nil),
+	}
+
+	for name, executor := range executors {
+		b.Run(name, func(b *testing.B) {
+			storage := NewRedisStorage(client, "ratelimit:bench:"+name)
+			defer storage.Delete(ctx, "bench-key")
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// Keep the window near capacity so the serialized log stays large
+				if i%config.Burst == 0 {
+					storage.Delete(ctx, "bench-key")
+				}
+				if _, err := executor.Execute(ctx, "bench-key", 1, config, storage); err != nil {
+					b.Fatalf("unexpected error: %v", err)
+				}
+			}
+		})
+	}
 }
@@ -0,0 +1,142 @@
+package rate
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"myapp/internal/pkg/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+// EchoKeyFunc extracts the rate limit key from an Echo context
+type EchoKeyFunc func(echo.Context) string
+
+// EchoOnLimitedFunc is called when an Echo request is rate limited
+type EchoOnLimitedFunc func(c echo.Context, result *Result) error
+
+// EchoSkipFunc determines if rate limiting should be skipped for a request
+type EchoSkipFunc func(echo.Context) bool
+
+// EchoUserIDFunc returns the authenticated user's ID, or false if there is none
+type EchoUserIDFunc func(echo.Context) (string, bool)
+
+// echoMiddleware holds the Echo middleware settings
+type echoMiddleware struct {
+	limiter   Limiter
+	keyFunc   EchoKeyFunc
+	onLimited EchoOnLimitedFunc
+	skipFunc  EchoSkipFunc
+}
+
+// EchoMiddlewareOption is a functional option for EchoMiddleware
+type EchoMiddlewareOption func(*echoMiddleware)
+
+// WithEchoKeyFunc sets the key extraction function
+func WithEchoKeyFunc(fn EchoKeyFunc) EchoMiddlewareOption {
+	return func(m *echoMiddleware) {
+		m.keyFunc = fn
+	}
+}
+
+// WithEchoOnLimited sets the rate limit exceeded handler
+func WithEchoOnLimited(fn EchoOnLimitedFunc) EchoMiddlewareOption {
+	return func(m *echoMiddleware) {
+		m.onLimited = fn
+	}
+}
+
+// WithEchoSkipFunc sets the skip function
+func WithEchoSkipFunc(fn EchoSkipFunc) EchoMiddlewareOption {
+	return func(m *echoMiddleware) {
+		m.skipFunc = fn
+	}
+}
+
+// EchoMiddleware creates an Echo rate limiting middleware
+// It sets the same X-RateLimit-* headers as HTTPMiddleware and answers
+// limited requests with a 429 in the server.ErrorResponse format.
+func EchoMiddleware(limiter Limiter, opts ...EchoMiddlewareOption) echo.MiddlewareFunc {
+	m := &echoMiddleware{
+		limiter:   limiter,
+		keyFunc:   EchoIPKeyFunc(),
+		onLimited: DefaultEchoOnLimitedFunc,
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if m.skipFunc != nil && m.skipFunc(c) {
+				return next(c)
+			}
+
+			key := m.keyFunc(c)
+
+			start := time.Now()
+			result, err := m.limiter.AllowResult(c.Request().Context(), key)
+			duration := time.Since(start)
+
+			if err != nil {
+				// The limiter's fail-open/fail-close has already been applied.
+				// Storage errors can name backend addresses, so keep them in the log.
+				c.Logger().Errorf("rate limit check failed: %v", err)
+				return server.ErrorResponse(c, http.StatusInternalServerError, nil, "Rate limit check failed")
+			}
+
+			setRateLimitHeaders(c.Response(), result)
+
+			if !result.Allowed {
+				return m.onLimited(c, result)
+			}
+
+			if l, ok := m.limiter.(*limiterImpl); ok && l.metrics != nil {
+				l.metrics.RecordLatency(l.config.Strategy, duration)
+			}
+
+			return next(c)
+		}
+	}
+}
+
+// EchoIPKeyFunc creates a key function that uses Echo's real client IP
+// RealIP honours the Echo instance's IPExtractor and proxy headers.
+func EchoIPKeyFunc() EchoKeyFunc {
+	return func(c echo.Context) string {
+		return c.RealIP()
+	}
+}
+
+// EchoPathKeyFunc creates a key function that combines the client IP and route path
+func EchoPathKeyFunc() EchoKeyFunc {
+	return func(c echo.Context) string {
+		return fmt.Sprintf("%s:%s", c.RealIP(), c.Path())
+	}
+}
+
+// EchoUserKeyFunc creates a key function for authenticated users
+// Unauthenticated requests fall back to the client IP. Pass a getter backed
+// by the auth package, e.g. auth.GetUserFromContext.
+func EchoUserKeyFunc(userID EchoUserIDFunc) EchoKeyFunc {
+	return func(c echo.Context) string {
+		if id, ok := userID(c); ok && id != "" {
+			return fmt.Sprintf("user:%s", id)
+		}
+		return c.RealIP()
+	}
+}
+
+// DefaultEchoOnLimitedFunc is the default Echo rate limit exceeded handler
+func DefaultEchoOnLimitedFunc(c echo.Context, result *Result) error {
+	retryAfter := strconv.FormatInt(int64(result.RetryAfter.Seconds()), 10)
+	c.Response().Header().Set("X-RateLimit-Retry-After", retryAfter)
+	c.Response().Header().Set("Retry-After", retryAfter)
+
+	return server.ErrorResponse(c, http.StatusTooManyRequests, map[string]int64{
+		"retry_after": int64(result.RetryAfter.Seconds()),
+	}, "Rate limit exceeded")
+}
@@ -0,0 +1,161 @@
+package rate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+func newEchoTestLimiter(t *testing.T) Limiter {
+	t.Helper()
+
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	t.Cleanup(func() { storage.Close() })
+
+	limiter, err := New(&Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     1,
+		Burst:    1,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+		Clock:    clock,
+	}, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	t.Cleanup(func() { limiter.Close() })
+	return limiter
+}
+
+func serveEcho(e *echo.Echo, remoteAddr, user string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/api", nil)
+	req.RemoteAddr = remoteAddr
+	if user != "" {
+		req.Header.Set("X-User", user)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestEchoMiddleware_LimitsByIP(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware(newEchoTestLimiter(t)))
+	e.GET("/api", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := serveEcho(e, "10.0.0.1:1234", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Limit"); got != "1" {
+		t.Errorf("expected limit 1, got %q", got)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected remaining 0, got %q", got)
+	}
+
+	rec = serveEcho(e, "10.0.0.1:1234", "")
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+	if rec.Header().Get("X-RateLimit-Reset") == "" {
+		t.Error("expected a reset header on 429")
+	}
+
+	var body struct {
+		Success bool             `json:"success"`
+		Error   map[string]int64 `json:"error"`
+		Message string           `json:"message"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("expected a JSON body: %v", err)
+	}
+	if body.Success || body.Message != "Rate limit exceeded" {
+		t.Errorf("unexpected body: %s", rec.Body.String())
+	}
+	if _, ok := body.Error["retry_after"]; !ok {
+		t.Errorf("expected retry_after in error, got %s", rec.Body.String())
+	}
+
+	// Another client has its own budget
+	if rec := serveEcho(e, "10.0.0.2:1234", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for a different IP, got %d", rec.Code)
+	}
+}
+
+func TestEchoMiddleware_UserKey(t *testing.T) {
+	userID := func(c echo.Context) (string, bool) {
+		id := c.Request().Header.Get("X-User")
+		return id, id != ""
+	}
+
+	e := echo.New()
+	e.Use(EchoMiddleware(newEchoTestLimiter(t), WithEchoKeyFunc(EchoUserKeyFunc(userID))))
+	e.GET("/api", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	if rec := serveEcho(e, "10.0.0.1:1234", "42"); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	// Same user from another IP shares the budget
+	if rec := serveEcho(e, "10.0.0.9:1234", "42"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("expected 429 for the same user, got %d", rec.Code)
+	}
+	// Anonymous requests fall back to the IP
+	if rec := serveEcho(e, "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+		t.Errorf("expected 200 for an anonymous request, got %d", rec.Code)
+	}
+}
+
+func TestEchoMiddleware_Skip(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware(newEchoTestLimiter(t), WithEchoSkipFunc(func(c echo.Context) bool {
+		return true
+	})))
+	e.GET("/api", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		if rec := serveEcho(e, "10.0.0.1:1234", ""); rec.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i, rec.Code)
+		}
+	}
+}
+
+// failingLimiter fails every check the way an unreachable backend would
+type failingLimiter struct{ Limiter }
+
+func (failingLimiter) AllowResult(ctx context.Context, key string) (*Result, error) {
+	return nil, fmt.Errorf("%w: dial tcp 10.0.0.5:6379: connection refused", ErrStorageUnavailable)
+}
+
+func TestEchoMiddleware_StorageErrorIsNotExposed(t *testing.T) {
+	e := echo.New()
+	e.Use(EchoMiddleware(failingLimiter{}))
+	e.GET("/api", func(c echo.Context) error {
+		return c.NoContent(http.StatusOK)
+	})
+
+	rec := serveEcho(e, "10.0.0.1:1234", "")
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rec.Code)
+	}
+	if body := rec.Body.String(); strings.Contains(body, "10.0.0.5") || strings.Contains(body, "connection refused") {
+		t.Errorf("response exposes the storage error: %s", body)
+	}
+}
@@ -78,7 +78,7 @@ func (m *HTTPMiddleware) Middleware(next http.Handler) http.Handler {
 
 		// Check rate limit
 		start := time.Now()
-		reservation, err := m.limiter.Reserve(r.Context(), key)
+		result, err := m.limiter.AllowResult(r.Context(), key)
 		duration := time.Since(start)
 
 		if err != nil {
@@ -88,23 +88,13 @@ func (m *HTTPMiddleware) Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		if !reservation.OK {
-			// Rate limited
-			result := &Result{
-				Allowed:    false,
-				RetryAfter: reservation.Delay,
-			}
+		setRateLimitHeaders(w, result)
+
+		if !result.Allowed {
 			m.onLimited(w, r, result)
 			return
 		}
 
-		// Add rate limit headers
-		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(reservation.Limit.Rate))
-		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(reservation.Tokens))
-		if reservation.Delay > 0 {
-			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(reservation.Delay).Unix(), 10))
-		}
-
 		// Record latency if metrics available
 		if m, ok := m.limiter.(*limiterImpl); ok && m.metrics != nil {
 			m.metrics.RecordLatency(m.config.Strategy, duration)
@@ -114,6 +104,15 @@ func (m *HTTPMiddleware) Middleware(next http.Handler) http.Handler {
 	})
 }
 
+// setRateLimitHeaders writes X-RateLimit-* headers from a limiter result
+func setRateLimitHeaders(w http.ResponseWriter, result *Result) {
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	if !result.ResetAt.IsZero() {
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(result.ResetAt.Unix(), 10))
+	}
+}
+
 // DefaultKeyFunc extracts the client IP as the rate limit key
 func DefaultKeyFunc(r *http.Request) string {
 	// Try X-Forwarded-For first
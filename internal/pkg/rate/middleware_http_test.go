@@ -0,0 +1,67 @@
+package rate
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHTTPMiddleware_RateLimitHeaders(t *testing.T) {
+	clock := newFakeClock()
+	storage := NewMemoryStorage(WithStorageClock(clock))
+	defer storage.Close()
+
+	limiter, err := New(&Config{
+		Strategy: StrategyFixedWindow,
+		Rate:     2,
+		Burst:    2,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+		Clock:    clock,
+	}, storage)
+	if err != nil {
+		t.Fatalf("failed to create limiter: %v", err)
+	}
+	defer limiter.Close()
+
+	handler := NewHTTPMiddleware(limiter).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for want := 1; want >= 0; want-- {
+		rec := serve()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("expected limit 2, got %q", got)
+		}
+		if got := rec.Header().Get("X-RateLimit-Remaining"); got != strconv.Itoa(want) {
+			t.Errorf("expected remaining %d, got %q", want, got)
+		}
+		if rec.Header().Get("X-RateLimit-Reset") == "" {
+			t.Error("expected a reset header")
+		}
+	}
+
+	rec := serve()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rec.Code)
+	}
+	if got := rec.Header().Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("expected remaining 0, got %q", got)
+	}
+	if rec.Header().Get("Retry-After") == "" {
+		t.Error("expected a Retry-After header")
+	}
+}
@@ -43,6 +43,10 @@ func NewLimiterFromConfig(params LimiterParams) (Limiter, error) {
 		opts = append(opts, WithMetrics(params.Metrics))
 	}
 
+	if params.Config.HashKeys {
+		opts = append(opts, WithKeyHasher(NewSaltedKeyHasher(params.Config.HashSalt)))
+	}
+
 	return New(params.Config.ToConfig(), params.Storage, opts...)
 }
 
@@ -6,12 +6,18 @@ import (
 	"time"
 )
 
+// DefaultCleanupInterval is how often MemoryStorage sweeps expired keys
+const DefaultCleanupInterval = 1 * time.Minute
+
 // MemoryStorage implements Storage interface using in-memory map
 type MemoryStorage struct {
-	mu   sync.RWMutex
-	data map[string]*storageEntry
-	done chan struct{}
-	wg   sync.WaitGroup
+	mu              sync.RWMutex
+	data            map[string]*storageEntry
+	clock           Clock
+	cleanupInterval time.Duration
+	done            chan struct{}
+	once            sync.Once
+	wg              sync.WaitGroup
 }
 
 type storageEntry struct {
@@ -19,11 +25,40 @@ type storageEntry struct {
 	expiresAt time.Time
 }
 
+// MemoryStorageOption is a functional option for MemoryStorage
+type MemoryStorageOption func(*MemoryStorage)
+
+// WithStorageClock sets the time source used for key expiry
+func WithStorageClock(clock Clock) MemoryStorageOption {
+	return func(s *MemoryStorage) {
+		s.clock = clock
+	}
+}
+
+// WithCleanupInterval sets how often expired keys are swept from memory
+// Expired keys are never returned either way; sweeping only frees memory,
+// so keys that are seen once (e.g. client IPs) don't accumulate.
+func WithCleanupInterval(interval time.Duration) MemoryStorageOption {
+	return func(s *MemoryStorage) {
+		s.cleanupInterval = interval
+	}
+}
+
 // NewMemoryStorage creates a new in-memory storage
-func NewMemoryStorage() *MemoryStorage {
+// A background janitor sweeps expired keys until Close.
+func NewMemoryStorage(opts ...MemoryStorageOption) *MemoryStorage {
 	s := &MemoryStorage{
-		data: make(map[string]*storageEntry),
-		done: make(chan struct{}),
+		data:            make(map[string]*storageEntry),
+		clock:           RealClock{},
+		cleanupInterval: DefaultCleanupInterval,
+		done:            make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.cleanupInterval <= 0 {
+		s.cleanupInterval = DefaultCleanupInterval
 	}
 
 	// Start cleanup goroutine
@@ -44,7 +79,7 @@ func (s *MemoryStorage) Get(ctx context.Context, key string) (*State, error) {
 	}
 
 	// Check if expired
-	if time.Now().After(entry.expiresAt) {
+	if s.clock.Now().After(entry.expiresAt) {
 		return nil, nil
 	}
 
@@ -54,6 +89,7 @@ func (s *MemoryStorage) Get(ctx context.Context, key string) (*State, error) {
 		LastUpdate:  entry.state.LastUpdate,
 		Counter:     entry.state.Counter,
 		WindowStart: entry.state.WindowStart,
+		TAT:         entry.state.TAT,
 	}
 
 	if entry.state.Timestamps != nil {
@@ -75,6 +111,7 @@ func (s *MemoryStorage) Set(ctx context.Context, key string, state *State, ttl t
 		LastUpdate:  state.LastUpdate,
 		Counter:     state.Counter,
 		WindowStart: state.WindowStart,
+		TAT:         state.TAT,
 	}
 
 	if state.Timestamps != nil {
@@ -82,9 +119,9 @@ func (s *MemoryStorage) Set(ctx context.Context, key string, state *State, ttl t
 		copy(stateCopy.Timestamps, state.Timestamps)
 	}
 
-	expiresAt := time.Now().Add(ttl)
+	expiresAt := s.clock.Now().Add(ttl)
 	if ttl <= 0 {
-		expiresAt = time.Now().Add(24 * time.Hour) // Default 24 hour expiry
+		expiresAt = s.clock.Now().Add(24 * time.Hour) // Default 24 hour expiry
 	}
 
 	s.data[key] = &storageEntry{
@@ -101,17 +138,17 @@ func (s *MemoryStorage) Increment(ctx context.Context, key string, n int, ttl ti
 	defer s.mu.Unlock()
 
 	entry, exists := s.data[key]
-	if !exists || time.Now().After(entry.expiresAt) {
+	if !exists || s.clock.Now().After(entry.expiresAt) {
 		// Create new entry
-		expiresAt := time.Now().Add(ttl)
+		expiresAt := s.clock.Now().Add(ttl)
 		if ttl <= 0 {
-			expiresAt = time.Now().Add(24 * time.Hour)
+			expiresAt = s.clock.Now().Add(24 * time.Hour)
 		}
 
 		s.data[key] = &storageEntry{
 			state: &State{
 				Counter:    int64(n),
-				LastUpdate: time.Now(),
+				LastUpdate: s.clock.Now(),
 			},
 			expiresAt: expiresAt,
 		}
@@ -120,16 +157,50 @@ func (s *MemoryStorage) Increment(ctx context.Context, key string, n int, ttl ti
 
 	// Increment existing entry
 	entry.state.Counter += int64(n)
-	entry.state.LastUpdate = time.Now()
+	entry.state.LastUpdate = s.clock.Now()
 
 	// Extend TTL
 	if ttl > 0 {
-		entry.expiresAt = time.Now().Add(ttl)
+		entry.expiresAt = s.clock.Now().Add(ttl)
 	}
 
 	return entry.state.Counter, nil
 }
 
+// UpdateTAT implements GCRAStorage atomically under the storage lock
+func (s *MemoryStorage) UpdateTAT(ctx context.Context, key string, now time.Time, n int, emission, tolerance, ttl time.Duration) (bool, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var stored time.Time
+	if entry, exists := s.data[key]; exists && !s.clock.Now().After(entry.expiresAt) {
+		stored = entry.state.TAT
+	}
+
+	allowed, tat, write := applyGCRA(stored, now, n, emission, tolerance)
+	if write {
+		s.data[key] = &storageEntry{
+			state:     &State{TAT: tat, LastUpdate: now},
+			expiresAt: s.clock.Now().Add(gcraTTL(tat, now, ttl)),
+		}
+	}
+
+	return allowed, tat, nil
+}
+
+// refund implements refundStorage under the storage lock
+func (s *MemoryStorage) refund(ctx context.Context, key string, op refundOp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.data[key]
+	if !exists || s.clock.Now().After(entry.expiresAt) {
+		return nil
+	}
+	op.apply(entry.state)
+	return nil
+}
+
 // Delete removes the state for a key
 func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
 	s.mu.Lock()
@@ -141,7 +212,7 @@ func (s *MemoryStorage) Delete(ctx context.Context, key string) error {
 
 // Close closes the storage backend
 func (s *MemoryStorage) Close() error {
-	close(s.done)
+	s.once.Do(func() { close(s.done) })
 	s.wg.Wait()
 	return nil
 }
@@ -155,7 +226,7 @@ func (s *MemoryStorage) Ping(ctx context.Context) error {
 func (s *MemoryStorage) cleanupLoop() {
 	defer s.wg.Done()
 
-	ticker := time.NewTicker(1 * time.Minute)
+	ticker := time.NewTicker(s.cleanupInterval)
 	defer ticker.Stop()
 
 	for {
@@ -168,17 +239,20 @@ func (s *MemoryStorage) cleanupLoop() {
 	}
 }
 
-// cleanup removes expired entries
-func (s *MemoryStorage) cleanup() {
+// cleanup removes expired entries and returns how many were removed
+func (s *MemoryStorage) cleanup() int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	now := time.Now()
+	now := s.clock.Now()
+	removed := 0
 	for key, entry := range s.data {
 		if now.After(entry.expiresAt) {
 			delete(s.data, key)
+			removed++
 		}
 	}
+	return removed
 }
 
 // Len returns the number of entries (for testing)
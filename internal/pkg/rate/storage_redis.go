@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -96,6 +97,49 @@ func (s *RedisStorage) Increment(ctx context.Context, key string, n int, ttl tim
 	return result, nil
 }
 
+// gcraScript applies GCRA to a TAT stored as Unix microseconds, which keeps
+// the value within Lua's exact integer range
+var gcraScript = redis.NewScript(`
+	local now = tonumber(ARGV[1])
+	local n = tonumber(ARGV[2])
+	local emission = tonumber(ARGV[3])
+	local tolerance = tonumber(ARGV[4])
+	local ttl_ms = tonumber(ARGV[5])
+
+	local tat = tonumber(redis.call('GET', KEYS[1]))
+	if tat == nil or tat < now then
+		tat = now
+	end
+	if n == 0 then
+		return {1, tat}
+	end
+
+	local next_tat = tat + n * emission
+	if n > 0 and next_tat - now > tolerance then
+		return {0, tat}
+	end
+
+	-- Keep the key until its TAT has passed so it can't expire into a fresh burst
+	local expire_ms = math.max(ttl_ms, math.ceil((next_tat - now) / 1000), 1)
+	redis.call('SET', KEYS[1], next_tat, 'PX', expire_ms)
+	return {1, next_tat}
+`)
+
+// UpdateTAT implements GCRAStorage atomically using a Lua script
+// The key holds a bare timestamp, so Get on a GCRA key returns an error.
+func (s *RedisStorage) UpdateTAT(ctx context.Context, key string, now time.Time, n int, emission, tolerance, ttl time.Duration) (bool, time.Time, error) {
+	fullKey := s.makeKey(key)
+
+	result, err := gcraScript.Run(ctx, s.client, []string{fullKey},
+		now.UnixMicro(), n, emission.Microseconds(), tolerance.Microseconds(), ttl.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	}
+
+	return result[0] == 1, time.UnixMicro(result[1]), nil
+}
+
 // Delete removes the state for a key
 func (s *RedisStorage) Delete(ctx context.Context, key string) error {
 	fullKey := s.makeKey(key)
@@ -107,6 +151,84 @@ func (s *RedisStorage) Delete(ctx context.Context, key string) error {
 	return nil
 }
 
+// removeMembers removes the given members from a sliding window ZSET
+func (s *RedisStorage) removeMembers(ctx context.Context, key string, members []string) error {
+	if len(members) == 0 {
+		return nil
+	}
+	args := make([]any, len(members))
+	for i, m := range members {
+		args[i] = m
+	}
+	if err := s.client.ZRem(ctx, s.makeKey(key), args...).Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
+// refundScript applies a refundOp to a JSON encoded State in place, keeping
+// the key's TTL. An empty Timestamps list is written back as null because
+// cjson can't tell an empty array from an empty object.
+var refundScript = redis.NewScript(`
+	local data = redis.call('GET', KEYS[1])
+	if not data then
+		return 0
+	end
+	local state = cjson.decode(data)
+	local strategy = ARGV[1]
+	local n = tonumber(ARGV[2])
+
+	if strategy == 'token_bucket' then
+		state.Tokens = math.min(state.Tokens + n, tonumber(ARGV[3]))
+	elseif strategy == 'leaky_bucket' then
+		state.Tokens = math.max(state.Tokens - n, 0)
+	elseif strategy == 'fixed_window' then
+		state.Counter = math.max(state.Counter - n, 0)
+	elseif strategy == 'sliding_window' then
+		local ts = state.Timestamps
+		if type(ts) == 'table' then
+			local removed = 0
+			for i = #ts, 1, -1 do
+				if removed < n and ts[i] == ARGV[4] then
+					table.remove(ts, i)
+					removed = removed + 1
+				end
+			end
+			if #ts == 0 then
+				state.Timestamps = cjson.null
+			end
+		end
+	else
+		return redis.error_reply('unsupported refund strategy ' .. strategy)
+	end
+
+	local ttl = redis.call('PTTL', KEYS[1])
+	if ttl > 0 then
+		redis.call('SET', KEYS[1], cjson.encode(state), 'PX', ttl)
+	else
+		redis.call('SET', KEYS[1], cjson.encode(state))
+	end
+	return 1
+`)
+
+// refund implements refundStorage with a Lua script, so tokens returned by
+// one request can't overwrite tokens consumed by another in between
+func (s *RedisStorage) refund(ctx context.Context, key string, op refundOp) error {
+	// Timestamps are compared in their JSON form, as Set stored them
+	consumedAt, err := json.Marshal(op.consumedAt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal timestamp: %w", err)
+	}
+
+	err = refundScript.Run(ctx, s.client, []string{s.makeKey(key)},
+		string(op.strategy), op.n, op.burst, strings.Trim(string(consumedAt), `"`),
+	).Err()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrStorageUnavailable, err)
+	}
+	return nil
+}
+
 // Close closes the storage backend
 func (s *RedisStorage) Close() error {
 	// Don't close the client as it might be shared
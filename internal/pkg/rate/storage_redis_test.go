@@ -0,0 +1,349 @@
+package rate
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func newMiniRedisStorage(t *testing.T) (*RedisStorage, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisStorage(client, "test"), mr
+}
+
+func TestAllowAll_Redis(t *testing.T) {
+	strategies := []Strategy{
+		StrategyTokenBucket,
+		StrategyLeakyBucket,
+		StrategyFixedWindow,
+		StrategySlidingWindow,
+		StrategyGCRA,
+	}
+
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			storage, _ := newMiniRedisStorage(t)
+
+			config := &Config{
+				Strategy: strategy,
+				Rate:     2,
+				Burst:    2,
+				Interval: 1 * time.Minute,
+				TTL:      2 * time.Minute,
+			}
+
+			limiter, err := New(config, storage)
+			if err != nil {
+				t.Fatalf("failed to create limiter: %v", err)
+			}
+			defer limiter.Close()
+
+			ctx := context.Background()
+
+			// Exhaust the endpoint key so it denies
+			for i := 0; i < 2; i++ {
+				limiter.Allow(ctx, "endpoint")
+			}
+
+			allowed, err := limiter.AllowAll(ctx, []string{"user", "tenant", "endpoint"})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if allowed {
+				t.Fatal("AllowAll should be denied when one key denies")
+			}
+
+			for _, key := range []string{"user", "tenant"} {
+				allowed, err = limiter.AllowN(ctx, key, 2)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !allowed {
+					t.Errorf("%s key should have been refunded", key)
+				}
+			}
+		})
+	}
+}
+
+func TestRefund_KeepsTTL_Redis(t *testing.T) {
+	storage, mr := newMiniRedisStorage(t)
+	ctx := context.Background()
+
+	if err := storage.Set(ctx, "k", &State{Counter: 2}, time.Minute); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := storage.refund(ctx, "k", refundOp{strategy: StrategyFixedWindow, n: 1}); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+
+	state, err := storage.Get(ctx, "k")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if state.Counter != 1 {
+		t.Errorf("expected counter 1, got %d", state.Counter)
+	}
+	if ttl := mr.TTL("test:k"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("expected the TTL to be kept, got %v", ttl)
+	}
+
+	// A missing key stays missing
+	if err := storage.refund(ctx, "missing", refundOp{strategy: StrategyFixedWindow, n: 1}); err != nil {
+		t.Fatalf("refund: %v", err)
+	}
+	if mr.Exists("test:missing") {
+		t.Error("refunding a missing key should not create it")
+	}
+}
+
+// TestRefund_Concurrent refunds from many goroutines at once; a
+// read-modify-write refund would lose some of the updates
+func TestRefund_Concurrent(t *testing.T) {
+	redisStorage, _ := newMiniRedisStorage(t)
+	memoryStorage := NewMemoryStorage()
+	defer memoryStorage.Close()
+
+	storages := map[string]Storage{
+		"memory": memoryStorage,
+		"redis":  redisStorage,
+	}
+
+	for name, storage := range storages {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := storage.Set(ctx, "k", &State{Counter: 100}, time.Minute); err != nil {
+				t.Fatalf("set: %v", err)
+			}
+
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					op := refundOp{strategy: StrategyFixedWindow, n: 1}
+					if err := storage.(refundStorage).refund(ctx, "k", op); err != nil {
+						t.Errorf("refund: %v", err)
+					}
+				}()
+			}
+			wg.Wait()
+
+			state, err := storage.Get(ctx, "k")
+			if err != nil {
+				t.Fatalf("get: %v", err)
+			}
+			if state.Counter != 50 {
+				t.Errorf("expected counter 50, got %d", state.Counter)
+			}
+		})
+	}
+}
+
+// TestRefund_SlidingWindowRemovesOwnEntry refunds an older request after a
+// newer one was logged; only the refunded request's entry may go
+func TestRefund_SlidingWindowRemovesOwnEntry(t *testing.T) {
+	config := &Config{
+		Strategy: StrategySlidingWindow,
+		Rate:     5,
+		Burst:    5,
+		Interval: 1 * time.Minute,
+		TTL:      2 * time.Minute,
+	}
+
+	t.Run("memory", func(t *testing.T) {
+		clock := newFakeClock()
+		storage := NewMemoryStorage(WithStorageClock(clock))
+		defer storage.Close()
+
+		cfg := *config
+		cfg.Clock = clock
+		l, err := New(&cfg, storage)
+		if err != nil {
+			t.Fatalf("failed to create limiter: %v", err)
+		}
+		limiter := l.(*limiterImpl)
+		ctx := context.Background()
+
+		first, err := limiter.executor.Execute(ctx, "k", 1, &cfg, storage)
+		if err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		clock.Advance(time.Second)
+		if _, err := limiter.executor.Execute(ctx, "k", 1, &cfg, storage); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+
+		if err := limiter.refund(ctx, &cfg, "k", 1, first); err != nil {
+			t.Fatalf("refund: %v", err)
+		}
+
+		state, _ := storage.Get(ctx, "k")
+		if len(state.Timestamps) != 1 || !state.Timestamps[0].Equal(clock.Now()) {
+			t.Errorf("expected only the newer entry to remain, got %v", state.Timestamps)
+		}
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		clock := newFakeClock()
+		storage, mr := newMiniRedisStorage(t)
+
+		cfg := *config
+		cfg.Clock = clock
+		l, err := New(&cfg, storage)
+		if err != nil {
+			t.Fatalf("failed to create limiter: %v", err)
+		}
+		limiter := l.(*limiterImpl)
+		ctx := context.Background()
+
+		first, err := limiter.executor.Execute(ctx, "k", 1, &cfg, storage)
+		if err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+		clock.Advance(time.Second)
+		if _, err := limiter.executor.Execute(ctx, "k", 1, &cfg, storage); err != nil {
+			t.Fatalf("execute: %v", err)
+		}
+
+		if err := limiter.refund(ctx, &cfg, "k", 1, first); err != nil {
+			t.Fatalf("refund: %v", err)
+		}
+
+		members, err := mr.ZMembers("test:k")
+		if err != nil {
+			t.Fatalf("zmembers: %v", err)
+		}
+		newer := clock.Now().UnixMicro()
+		if len(members) != 1 || !strings.HasPrefix(members[0], strconv.FormatInt(newer, 10)+":") {
+			t.Errorf("expected only the newer entry to remain, got %v", members)
+		}
+	})
+}
+
+// TestUpdateTAT_Redis runs the GCRA script with a burst of 3 and one
+// request per second
+func TestUpdateTAT_Redis(t *testing.T) {
+	const (
+		emission  = time.Second
+		tolerance = 3 * time.Second
+		ttl       = time.Minute
+	)
+	start := time.UnixMicro(time.Now().UnixMicro())
+
+	t.Run("burst", func(t *testing.T) {
+		storage, mr := newMiniRedisStorage(t)
+		ctx := context.Background()
+
+		for i := 1; i <= 3; i++ {
+			allowed, tat, err := storage.UpdateTAT(ctx, "k", start, 1, emission, tolerance, ttl)
+			if err != nil {
+				t.Fatalf("update %d: %v", i, err)
+			}
+			if !allowed {
+				t.Fatalf("request %d of the burst was denied", i)
+			}
+			if want := start.Add(time.Duration(i) * emission); !tat.Equal(want) {
+				t.Errorf("request %d: expected TAT %v, got %v", i, want, tat)
+			}
+		}
+
+		if got := mr.TTL("test:k"); got < ttl {
+			t.Errorf("expected the key to live at least the configured TTL, got %v", got)
+		}
+	})
+
+	t.Run("denied", func(t *testing.T) {
+		storage, mr := newMiniRedisStorage(t)
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			if _, _, err := storage.UpdateTAT(ctx, "k", start, 1, emission, tolerance, ttl); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+		}
+		stored, err := mr.Get("test:k")
+		if err != nil {
+			t.Fatalf("get: %v", err)
+		}
+
+		allowed, tat, err := storage.UpdateTAT(ctx, "k", start, 1, emission, tolerance, ttl)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if allowed {
+			t.Fatal("a request past the burst should be denied")
+		}
+		if want := start.Add(3 * emission); !tat.Equal(want) {
+			t.Errorf("expected the current TAT %v, got %v", want, tat)
+		}
+		if after, _ := mr.Get("test:k"); after != stored {
+			t.Errorf("a denied request changed the TAT from %s to %s", stored, after)
+		}
+
+		// A batch that doesn't fit is denied whole
+		allowed, _, err = storage.UpdateTAT(ctx, "fresh", start, 4, emission, tolerance, ttl)
+		if err != nil {
+			t.Fatalf("update: %v", err)
+		}
+		if allowed {
+			t.Error("a batch larger than the burst should be denied")
+		}
+		if mr.Exists("test:fresh") {
+			t.Error("a denied batch should not create the key")
+		}
+	})
+
+	t.Run("steady state", func(t *testing.T) {
+		storage, _ := newMiniRedisStorage(t)
+		ctx := context.Background()
+
+		for i := 0; i < 3; i++ {
+			if _, _, err := storage.UpdateTAT(ctx, "k", start, 1, emission, tolerance, ttl); err != nil {
+				t.Fatalf("update: %v", err)
+			}
+		}
+
+		// Once the burst is spent, each emission interval frees one request
+		now := start
+		for i := 0; i < 5; i++ {
+			now = now.Add(emission)
+			allowed, _, err := storage.UpdateTAT(ctx, "k", now, 1, emission, tolerance, ttl)
+			if err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if !allowed {
+				t.Fatalf("request at +%v should be allowed", now.Sub(start))
+			}
+
+			allowed, _, err = storage.UpdateTAT(ctx, "k", now, 1, emission, tolerance, ttl)
+			if err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if allowed {
+				t.Fatalf("a second request at +%v should be denied", now.Sub(start))
+			}
+		}
+
+		// An idle key starts over with a full burst
+		idle := now.Add(time.Hour)
+		for i := 1; i <= 3; i++ {
+			allowed, _, err := storage.UpdateTAT(ctx, "k", idle, 1, emission, tolerance, ttl)
+			if err != nil {
+				t.Fatalf("update: %v", err)
+			}
+			if !allowed {
+				t.Fatalf("request %d after idling was denied", i)
+			}
+		}
+	})
+}
@@ -7,11 +7,37 @@ import (
 	"time"
 )
 
+// Strategy determines how the delay grows between attempts
+type Strategy string
+
+const (
+	// StrategyExponential waits BaseDelay * Multiplier^(attempt-1); the zero value
+	StrategyExponential Strategy = ""
+	// StrategyLinear waits BaseDelay * attempt
+	StrategyLinear Strategy = "linear"
+	// StrategyConstant waits BaseDelay before every retry
+	StrategyConstant Strategy = "constant"
+)
+
+// jitterFraction is how far Jitter moves a delay either way
+const jitterFraction = 0.2
+
+// random is the jitter source, replaceable in tests
+var random = rand.Float64
+
+// Policy describes how often and how long to wait between attempts
 type Policy struct {
-	BaseDelay   time.Duration
-	MaxDelay    time.Duration
-	Jitter      bool
+	BaseDelay time.Duration
+	// MaxDelay caps any single delay, jitter included (0 = no cap)
+	MaxDelay time.Duration
+	// Jitter spreads each delay over +/-20% so callers don't retry in step
+	Jitter bool
+	// MaxAttempts is the total number of attempts, the first included (0 = unlimited)
 	MaxAttempts int
+	// Strategy controls how the delay grows (zero value = exponential)
+	Strategy Strategy
+	// Multiplier is the exponential growth factor (0 = 2, values below 1 act as 1)
+	Multiplier float64
 }
 
 func ExponentialBackoff(base, max time.Duration, jitter bool, maxAttempts int) Policy {
@@ -23,21 +49,79 @@ func ExponentialBackoff(base, max time.Duration, jitter bool, maxAttempts int) P
 	}
 }
 
-func (p Policy) nextDelay(attempt int) time.Duration {
+// LinearBackoff waits step, 2*step, 3*step... capped at max
+func LinearBackoff(step, max time.Duration, jitter bool, maxAttempts int) Policy {
+	return Policy{
+		BaseDelay:   step,
+		MaxDelay:    max,
+		Jitter:      jitter,
+		MaxAttempts: maxAttempts,
+		Strategy:    StrategyLinear,
+	}
+}
+
+// ConstantBackoff waits delay before every retry
+func ConstantBackoff(delay time.Duration, jitter bool, maxAttempts int) Policy {
+	return Policy{
+		BaseDelay:   delay,
+		Jitter:      jitter,
+		MaxAttempts: maxAttempts,
+		Strategy:    StrategyConstant,
+	}
+}
+
+// Delay returns the wait before retry number attempt (1 for the first retry)
+func (p Policy) Delay(attempt int) time.Duration {
 	if attempt <= 0 {
 		attempt = 1
 	}
-	// 2^(attempt-1) * base
-	factor := math.Pow(2, float64(attempt-1))
-	delay := time.Duration(float64(p.BaseDelay) * factor)
-	if delay > p.MaxDelay {
-		delay = p.MaxDelay
+
+	base := float64(p.BaseDelay)
+	var delay float64
+	switch p.Strategy {
+	case StrategyConstant:
+		delay = base
+	case StrategyLinear:
+		delay = base * float64(attempt)
+	default:
+		multiplier := p.Multiplier
+		if multiplier == 0 {
+			multiplier = 2
+		}
+		// multiplier^(attempt-1) * base
+		delay = base * math.Pow(math.Max(multiplier, 1), float64(attempt-1))
 	}
+
 	if p.Jitter {
-		j := rand.Float64()*0.4 + 0.8 // [0.8, 1.2)
-		delay = time.Duration(float64(delay) * j)
+		// Scale into [0.8, 1.2)
+		delay *= 1 - jitterFraction + 2*jitterFraction*random()
+	}
+	if max := float64(p.MaxDelay); max > 0 && delay > max {
+		delay = max
+	}
+	// Guard against overflow for large attempt counts without a cap
+	if delay >= math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// Allow reports whether another attempt may follow the given number of attempts made
+func (p Policy) Allow(attempts int) bool {
+	return p.MaxAttempts == 0 || attempts < p.MaxAttempts
+}
+
+// Wait sleeps for Delay(attempt) or until ctx is done, returning ctx.Err() in the latter case
+func (p Policy) Wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.Delay(attempt))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return delay
 }
 
 // Do runs fn with retry upon error while isRetryable(err) is true
@@ -58,13 +142,11 @@ func Do[T any](ctx context.Context, policy Policy, fn func(context.Context) (T,
 		if isRetryable != nil && !isRetryable(err) {
 			break
 		}
-		delay := policy.nextDelay(attempt)
-		timer := time.NewTimer(delay)
-		select {
-		case <-ctx.Done():
-			timer.Stop()
-			return zero, ctx.Err()
-		case <-timer.C:
+		if !policy.Allow(attempt) {
+			break
+		}
+		if err := policy.Wait(ctx, attempt); err != nil {
+			return zero, err
 		}
 	}
 	return zero, lastErr
@@ -0,0 +1,229 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDelay_Sequences(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []time.Duration
+	}{
+		{
+			name:   "constant",
+			policy: ConstantBackoff(time.Second, false, 0),
+			want:   []time.Duration{time.Second, time.Second, time.Second, time.Second},
+		},
+		{
+			name:   "linear",
+			policy: LinearBackoff(time.Second, 0, false, 0),
+			want:   []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second},
+		},
+		{
+			name:   "linear capped",
+			policy: LinearBackoff(time.Second, 2500*time.Millisecond, false, 0),
+			want:   []time.Duration{time.Second, 2 * time.Second, 2500 * time.Millisecond, 2500 * time.Millisecond},
+		},
+		{
+			name:   "exponential",
+			policy: ExponentialBackoff(100*time.Millisecond, 0, false, 0),
+			want:   []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond},
+		},
+		{
+			name:   "exponential multiplier 3",
+			policy: Policy{BaseDelay: time.Second, Multiplier: 3},
+			want:   []time.Duration{time.Second, 3 * time.Second, 9 * time.Second, 27 * time.Second},
+		},
+		{
+			name:   "exponential capped",
+			policy: ExponentialBackoff(time.Second, 5*time.Second, false, 0),
+			want:   []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second},
+		},
+		{
+			name:   "multiplier below one acts as one",
+			policy: Policy{BaseDelay: time.Second, Multiplier: 0.5},
+			want:   []time.Duration{time.Second, time.Second, time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i, want := range tt.want {
+				assert.Equal(t, want, tt.policy.Delay(i+1), "attempt %d", i+1)
+			}
+		})
+	}
+}
+
+func TestDelay_AttemptBelowOne(t *testing.T) {
+	p := LinearBackoff(time.Second, 0, false, 0)
+	assert.Equal(t, time.Second, p.Delay(0))
+	assert.Equal(t, time.Second, p.Delay(-3))
+}
+
+// withRandom makes the jitter source return r for the rest of the test
+func withRandom(t *testing.T, r float64) {
+	t.Helper()
+	original := random
+	random = func() float64 { return r }
+	t.Cleanup(func() { random = original })
+}
+
+func TestDelay_Jitter(t *testing.T) {
+	tests := []struct {
+		name   string
+		random float64
+		want   time.Duration
+	}{
+		{name: "lowest", random: 0, want: 800 * time.Millisecond},
+		{name: "middle", random: 0.5, want: time.Second},
+		{name: "highest", random: 0.999, want: 1199600 * time.Microsecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withRandom(t, tt.random)
+			p := ConstantBackoff(time.Second, true, 0)
+			assert.InDelta(t, float64(tt.want), float64(p.Delay(1)), float64(time.Microsecond))
+		})
+	}
+}
+
+func TestDelay_JitterRespectsCap(t *testing.T) {
+	withRandom(t, 0.99)
+	p := Policy{BaseDelay: time.Second, MaxDelay: time.Second, Jitter: true, Strategy: StrategyConstant}
+	assert.Equal(t, time.Second, p.Delay(1))
+}
+
+func TestDelay_JitterRandomBounds(t *testing.T) {
+	p := ConstantBackoff(time.Second, true, 0)
+	for i := 0; i < 1000; i++ {
+		d := p.Delay(1)
+		assert.GreaterOrEqual(t, d, 800*time.Millisecond)
+		assert.Less(t, d, 1200*time.Millisecond)
+	}
+}
+
+func TestDelay_NoOverflow(t *testing.T) {
+	p := ExponentialBackoff(time.Second, 0, false, 0)
+	assert.Equal(t, time.Duration(math.MaxInt64), p.Delay(200))
+}
+
+func TestAllow(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAttempts int
+		attempts    int
+		want        bool
+	}{
+		{name: "unlimited", maxAttempts: 0, attempts: 1000, want: true},
+		{name: "below max", maxAttempts: 3, attempts: 2, want: true},
+		{name: "at max", maxAttempts: 3, attempts: 3, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Policy{MaxAttempts: tt.maxAttempts}
+			assert.Equal(t, tt.want, p.Allow(tt.attempts))
+		})
+	}
+}
+
+func TestWait_ContextCancelled(t *testing.T) {
+	p := ConstantBackoff(time.Hour, false, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	err := p.Wait(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestWait_Elapses(t *testing.T) {
+	assert.NoError(t, ConstantBackoff(time.Millisecond, false, 0).Wait(context.Background(), 1))
+}
+
+func TestDo(t *testing.T) {
+	errTransient := errors.New("transient")
+	errFatal := errors.New("fatal")
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		errs        []error
+		isRetryable func(error) bool
+		wantErr     error
+		wantCalls   int
+	}{
+		{name: "succeeds first try", maxAttempts: 3, errs: []error{nil}, wantCalls: 1},
+		{name: "succeeds after retries", maxAttempts: 3, errs: []error{errTransient, errTransient, nil}, wantCalls: 3},
+		{name: "exhausts attempts", maxAttempts: 2, errs: []error{errTransient, errTransient, nil}, wantErr: errTransient, wantCalls: 2},
+		{
+			name:        "stops on non-retryable",
+			maxAttempts: 5,
+			errs:        []error{errTransient, errFatal, nil},
+			isRetryable: func(err error) bool { return !errors.Is(err, errFatal) },
+			wantErr:     errFatal,
+			wantCalls:   2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			got, err := Do(context.Background(), ConstantBackoff(time.Millisecond, false, tt.maxAttempts), func(ctx context.Context) (int, error) {
+				err := tt.errs[calls]
+				calls++
+				return calls, err
+			}, tt.isRetryable)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantCalls, got)
+			}
+			assert.Equal(t, tt.wantCalls, calls)
+		})
+	}
+}
+
+func TestDo_ContextCancelledDuringWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	_, err := Do(ctx, ConstantBackoff(time.Hour, false, 0), func(ctx context.Context) (struct{}, error) {
+		calls++
+		cancel()
+		return struct{}{}, errors.New("transient")
+	}, nil)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDo_ContextAlreadyCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err := Do(ctx, Policy{}, func(ctx context.Context) (struct{}, error) {
+		called = true
+		return struct{}{}, nil
+	}, nil)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.False(t, called)
+}
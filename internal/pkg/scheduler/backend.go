@@ -13,6 +13,9 @@ type BackendProvider interface {
 	// LoadJobs retrieves all jobs from the backend.
 	LoadJobs(ctx context.Context) ([]*Job, error)
 
+	// FindJobs returns jobs matching the filter, sorted by next run time.
+	FindJobs(ctx context.Context, filter JobFilter) ([]*Job, error)
+
 	// LoadJob retrieves a specific job by name.
 	LoadJob(ctx context.Context, jobName string) (*Job, error)
 
@@ -32,6 +35,12 @@ type BackendProvider interface {
 	// RefreshLock extends the TTL of an existing lock.
 	RefreshLock(ctx context.Context, lockKey string, ttl time.Duration, owner string) error
 
+	// AppendJobRun records a job execution, keeping at most maxRuns per job.
+	AppendJobRun(ctx context.Context, run *JobRun, maxRuns int) error
+
+	// GetJobRuns returns up to limit of the most recent runs of a job, newest first.
+	GetJobRuns(ctx context.Context, jobName string, limit int) ([]*JobRun, error)
+
 	// GetJobsDueForExecution returns jobs that should be executed now.
 	GetJobsDueForExecution(ctx context.Context, now time.Time) ([]*Job, error)
 
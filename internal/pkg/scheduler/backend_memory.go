@@ -12,6 +12,8 @@ type MemoryBackend struct {
 	mu    sync.RWMutex
 	jobs  map[string]*Job
 	locks map[string]*LockInfo
+	runs  map[string][]*JobRun
+	clock Clock
 }
 
 // NewMemoryBackend creates a new in-memory backend.
@@ -19,19 +21,28 @@ func NewMemoryBackend() *MemoryBackend {
 	return &MemoryBackend{
 		jobs:  make(map[string]*Job),
 		locks: make(map[string]*LockInfo),
+		runs:  make(map[string][]*JobRun),
+		clock: RealClock{},
 	}
 }
 
+func (m *MemoryBackend) setClock(clock Clock) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.clock = clock
+}
+
 func (m *MemoryBackend) SaveJob(ctx context.Context, job *Job) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Create a copy to avoid external modifications
 	jobCopy := *job
-	jobCopy.Metadata.UpdatedAt = time.Now()
+	now := m.clock.Now()
+	jobCopy.Metadata.UpdatedAt = now
 
 	if _, exists := m.jobs[job.Name]; !exists {
-		jobCopy.Metadata.CreatedAt = time.Now()
+		jobCopy.Metadata.CreatedAt = now
 	}
 
 	m.jobs[job.Name] = &jobCopy
@@ -51,6 +62,22 @@ func (m *MemoryBackend) LoadJobs(ctx context.Context) ([]*Job, error) {
 	return jobs, nil
 }
 
+func (m *MemoryBackend) FindJobs(ctx context.Context, filter JobFilter) ([]*Job, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var jobs []*Job
+	for _, job := range m.jobs {
+		if filter.Matches(job) {
+			jobCopy := *job
+			jobs = append(jobs, &jobCopy)
+		}
+	}
+
+	SortJobsByNextRun(jobs)
+	return jobs, nil
+}
+
 func (m *MemoryBackend) LoadJob(ctx context.Context, jobName string) (*Job, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -73,7 +100,7 @@ func (m *MemoryBackend) UpdateMetadata(ctx context.Context, jobName string, meta
 		return ErrJobNotFound
 	}
 
-	metadata.UpdatedAt = time.Now()
+	metadata.UpdatedAt = m.clock.Now()
 	job.Metadata = *metadata
 	return nil
 }
@@ -87,6 +114,7 @@ func (m *MemoryBackend) DeleteJob(ctx context.Context, jobName string) error {
 	}
 
 	delete(m.jobs, jobName)
+	delete(m.runs, jobName)
 	return nil
 }
 
@@ -96,7 +124,7 @@ func (m *MemoryBackend) AcquireLock(ctx context.Context, lockKey string, ttl tim
 
 	// Clean up expired locks
 	if lock, exists := m.locks[lockKey]; exists {
-		if time.Now().After(lock.ExpiresAt) {
+		if m.clock.Now().After(lock.ExpiresAt) {
 			delete(m.locks, lockKey)
 		} else if lock.Owner != owner {
 			return false, nil
@@ -107,7 +135,7 @@ func (m *MemoryBackend) AcquireLock(ctx context.Context, lockKey string, ttl tim
 	m.locks[lockKey] = &LockInfo{
 		Key:       lockKey,
 		Owner:     owner,
-		ExpiresAt: time.Now().Add(ttl),
+		ExpiresAt: m.clock.Now().Add(ttl),
 	}
 
 	return true, nil
@@ -143,10 +171,42 @@ func (m *MemoryBackend) RefreshLock(ctx context.Context, lockKey string, ttl tim
 		return fmt.Errorf("lock owned by %s, not %s", lock.Owner, owner)
 	}
 
-	lock.ExpiresAt = time.Now().Add(ttl)
+	lock.ExpiresAt = m.clock.Now().Add(ttl)
 	return nil
 }
 
+func (m *MemoryBackend) AppendJobRun(ctx context.Context, run *JobRun, maxRuns int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	runCopy := *run
+	runs := append(m.runs[run.JobName], &runCopy)
+	if maxRuns > 0 && len(runs) > maxRuns {
+		runs = runs[len(runs)-maxRuns:]
+	}
+	m.runs[run.JobName] = runs
+	return nil
+}
+
+func (m *MemoryBackend) GetJobRuns(ctx context.Context, jobName string, limit int) ([]*JobRun, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stored := m.runs[jobName]
+	if limit <= 0 || limit > len(stored) {
+		limit = len(stored)
+	}
+
+	// Stored oldest first; return newest first
+	runs := make([]*JobRun, 0, limit)
+	for i := len(stored) - 1; i >= len(stored)-limit; i-- {
+		runCopy := *stored[i]
+		runs = append(runs, &runCopy)
+	}
+
+	return runs, nil
+}
+
 func (m *MemoryBackend) GetJobsDueForExecution(ctx context.Context, now time.Time) ([]*Job, error) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -175,5 +235,6 @@ func (m *MemoryBackend) Close() error {
 
 	m.jobs = make(map[string]*Job)
 	m.locks = make(map[string]*LockInfo)
+	m.runs = make(map[string][]*JobRun)
 	return nil
 }
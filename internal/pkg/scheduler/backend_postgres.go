@@ -0,0 +1,470 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"myapp/internal/pkg/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PostgresBackend implements BackendProvider on top of PostgreSQL.
+// Tables are created by the SQL files in the migration directory.
+type PostgresBackend struct {
+	db     *gorm.DB
+	logger Logger
+	clock  Clock
+}
+
+// NewPostgresBackend creates a new PostgreSQL backend.
+func NewPostgresBackend(db *database.Database) *PostgresBackend {
+	return &PostgresBackend{
+		db:     db.DB,
+		logger: &NoOpLogger{},
+		clock:  RealClock{},
+	}
+}
+
+// WithLogger sets the logger that reports stored jobs which can't be decoded.
+func (p *PostgresBackend) WithLogger(logger Logger) *PostgresBackend {
+	p.logger = logger
+	return p
+}
+
+func (p *PostgresBackend) setClock(clock Clock) {
+	p.clock = clock
+}
+
+// postgresJob is the scheduler_jobs row.
+type postgresJob struct {
+	Name            string `gorm:"primaryKey"`
+	Schedule        []byte `gorm:"type:jsonb"`
+	RetryPolicy     []byte `gorm:"type:jsonb"`
+	TimeoutMs       int64
+	MissedRunPolicy string
+	Status          string
+	NextRunAt       *time.Time
+	LastRunAt       *time.Time
+	LastError       string
+	RunCount        int64
+	FailCount       int64
+	LockedBy        string
+	LockedUntil     *time.Time
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (postgresJob) TableName() string {
+	return "scheduler_jobs"
+}
+
+// postgresLock is the scheduler_locks lease row.
+type postgresLock struct {
+	LockKey   string `gorm:"primaryKey"`
+	Owner     string
+	ExpiresAt time.Time
+}
+
+func (postgresLock) TableName() string {
+	return "scheduler_locks"
+}
+
+// postgresJobRun is the scheduler_job_runs row.
+type postgresJobRun struct {
+	ID         int64 `gorm:"primaryKey"`
+	JobName    string
+	InstanceID string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	DurationMs int64
+	Status     string
+	Error      string
+}
+
+func (postgresJobRun) TableName() string {
+	return "scheduler_job_runs"
+}
+
+func (p *PostgresBackend) SaveJob(ctx context.Context, job *Job) error {
+	now := p.clock.Now()
+	job.Metadata.UpdatedAt = now
+	if job.Metadata.CreatedAt.IsZero() {
+		job.Metadata.CreatedAt = now
+	}
+
+	row, err := toPostgresJob(job)
+	if err != nil {
+		return err
+	}
+
+	// Keep the original created_at when the job already exists
+	err = p.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "name"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"schedule", "retry_policy", "timeout_ms", "missed_run_policy",
+			"status", "next_run_at", "last_run_at", "last_error", "run_count",
+			"fail_count", "locked_by", "locked_until", "updated_at",
+		}),
+	}).Create(row).Error
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+
+	return nil
+}
+
+func (p *PostgresBackend) LoadJobs(ctx context.Context) ([]*Job, error) {
+	var rows []*postgresJob
+	if err := p.db.WithContext(ctx).Order("name").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load jobs: %w", err)
+	}
+
+	return p.fromPostgresJobs(ctx, rows), nil
+}
+
+func (p *PostgresBackend) FindJobs(ctx context.Context, filter JobFilter) ([]*Job, error) {
+	query := p.db.WithContext(ctx).Model(&postgresJob{})
+
+	if filter.NamePrefix != "" {
+		query = query.Where("name LIKE ? ESCAPE '\\'", escapeLike(filter.NamePrefix)+"%")
+	}
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, 0, len(filter.Statuses))
+		for _, status := range filter.Statuses {
+			statuses = append(statuses, string(status))
+		}
+		query = query.Where("status IN ?", statuses)
+	}
+	if !filter.DueBefore.IsZero() {
+		query = query.Where("next_run_at < ?", filter.DueBefore)
+	}
+
+	var rows []*postgresJob
+	if err := query.Order("next_run_at ASC NULLS LAST, name").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to find jobs: %w", err)
+	}
+
+	return p.fromPostgresJobs(ctx, rows), nil
+}
+
+func (p *PostgresBackend) LoadJob(ctx context.Context, jobName string) (*Job, error) {
+	var row postgresJob
+	err := p.db.WithContext(ctx).Where("name = ?", jobName).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job: %w", err)
+	}
+
+	return fromPostgresJob(&row)
+}
+
+func (p *PostgresBackend) UpdateMetadata(ctx context.Context, jobName string, metadata *JobMetadata) error {
+	metadata.UpdatedAt = p.clock.Now()
+
+	result := p.db.WithContext(ctx).Model(&postgresJob{}).
+		Where("name = ?", jobName).
+		Updates(map[string]interface{}{
+			"status":       string(metadata.Status),
+			"next_run_at":  nullableTime(metadata.NextRunAt),
+			"last_run_at":  metadata.LastRunAt,
+			"last_error":   metadata.LastError,
+			"run_count":    metadata.RunCount,
+			"fail_count":   metadata.FailCount,
+			"locked_by":    metadata.LockedBy,
+			"locked_until": metadata.LockedUntil,
+			"updated_at":   metadata.UpdatedAt,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update job metadata: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobNotFound
+	}
+
+	return nil
+}
+
+func (p *PostgresBackend) DeleteJob(ctx context.Context, jobName string) error {
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("job_name = ?", jobName).Delete(&postgresJobRun{}).Error; err != nil {
+			return fmt.Errorf("failed to delete job runs: %w", err)
+		}
+		if err := tx.Where("name = ?", jobName).Delete(&postgresJob{}).Error; err != nil {
+			return fmt.Errorf("failed to delete job: %w", err)
+		}
+		return nil
+	})
+}
+
+// AcquireLock takes or renews an expired lease row. The row is read with
+// FOR UPDATE SKIP LOCKED so a competing acquirer backs off instead of waiting.
+func (p *PostgresBackend) AcquireLock(ctx context.Context, lockKey string, ttl time.Duration, owner string) (bool, error) {
+	now := p.clock.Now()
+	acquired := false
+
+	err := p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Make sure the lease row exists; an expired lease can be taken over
+		err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&postgresLock{LockKey: lockKey, Owner: "", ExpiresAt: now}).Error
+		if err != nil {
+			return err
+		}
+
+		var lock postgresLock
+		result := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("lock_key = ?", lockKey).
+			Limit(1).
+			Find(&lock)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Another instance is acquiring this lock right now
+			return nil
+		}
+
+		if lock.Owner != "" && lock.Owner != owner && lock.ExpiresAt.After(now) {
+			return nil
+		}
+
+		err = tx.Model(&postgresLock{}).
+			Where("lock_key = ?", lockKey).
+			Updates(map[string]interface{}{
+				"owner":      owner,
+				"expires_at": now.Add(ttl),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		acquired = true
+		return nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", err)
+	}
+
+	return acquired, nil
+}
+
+func (p *PostgresBackend) ReleaseLock(ctx context.Context, lockKey string, owner string) error {
+	result := p.db.WithContext(ctx).
+		Where("lock_key = ? AND owner = ?", lockKey, owner).
+		Delete(&postgresLock{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to release lock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+func (p *PostgresBackend) RefreshLock(ctx context.Context, lockKey string, ttl time.Duration, owner string) error {
+	now := p.clock.Now()
+
+	result := p.db.WithContext(ctx).Model(&postgresLock{}).
+		Where("lock_key = ? AND owner = ? AND expires_at > ?", lockKey, owner, now).
+		Update("expires_at", now.Add(ttl))
+	if result.Error != nil {
+		return fmt.Errorf("failed to refresh lock: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrLockNotHeld
+	}
+
+	return nil
+}
+
+func (p *PostgresBackend) AppendJobRun(ctx context.Context, run *JobRun, maxRuns int) error {
+	row := &postgresJobRun{
+		JobName:    run.JobName,
+		InstanceID: run.InstanceID,
+		StartedAt:  run.StartedAt,
+		FinishedAt: run.FinishedAt,
+		DurationMs: run.Duration.Milliseconds(),
+		Status:     string(run.Status),
+		Error:      run.Error,
+	}
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(row).Error; err != nil {
+			return fmt.Errorf("failed to append job run: %w", err)
+		}
+		if maxRuns <= 0 {
+			return nil
+		}
+
+		// Trim to the newest maxRuns records
+		keep := tx.Model(&postgresJobRun{}).
+			Select("id").
+			Where("job_name = ?", run.JobName).
+			Order("id DESC").
+			Limit(maxRuns)
+		err := tx.Where("job_name = ? AND id NOT IN (?)", run.JobName, keep).
+			Delete(&postgresJobRun{}).Error
+		if err != nil {
+			return fmt.Errorf("failed to trim job runs: %w", err)
+		}
+		return nil
+	})
+}
+
+func (p *PostgresBackend) GetJobRuns(ctx context.Context, jobName string, limit int) ([]*JobRun, error) {
+	query := p.db.WithContext(ctx).Where("job_name = ?", jobName).Order("id DESC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var rows []*postgresJobRun
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load job runs: %w", err)
+	}
+
+	runs := make([]*JobRun, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, &JobRun{
+			JobName:    row.JobName,
+			InstanceID: row.InstanceID,
+			StartedAt:  row.StartedAt,
+			FinishedAt: row.FinishedAt,
+			Duration:   time.Duration(row.DurationMs) * time.Millisecond,
+			Status:     JobStatus(row.Status),
+			Error:      row.Error,
+		})
+	}
+
+	return runs, nil
+}
+
+// GetJobsDueForExecution uses the partial next_run_at index instead of
+// loading every job.
+func (p *PostgresBackend) GetJobsDueForExecution(ctx context.Context, now time.Time) ([]*Job, error) {
+	var rows []*postgresJob
+	err := p.db.WithContext(ctx).
+		Where("next_run_at <= ? AND status NOT IN ?", now,
+			[]string{string(JobStatusPaused), string(JobStatusCancelled)}).
+		Order("next_run_at").
+		Find(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to load due jobs: %w", err)
+	}
+
+	return p.fromPostgresJobs(ctx, rows), nil
+}
+
+// Close is a no-op; the connection is owned by the database package.
+func (p *PostgresBackend) Close() error {
+	return nil
+}
+
+func toPostgresJob(job *Job) (*postgresJob, error) {
+	schedule, err := json.Marshal(job.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal schedule: %w", err)
+	}
+
+	var retryPolicy []byte
+	if job.RetryPolicy != nil {
+		if retryPolicy, err = json.Marshal(job.RetryPolicy); err != nil {
+			return nil, fmt.Errorf("failed to marshal retry policy: %w", err)
+		}
+	}
+
+	return &postgresJob{
+		Name:            job.Name,
+		Schedule:        schedule,
+		RetryPolicy:     retryPolicy,
+		TimeoutMs:       job.Timeout.Milliseconds(),
+		MissedRunPolicy: string(job.MissedRunPolicy),
+		Status:          string(job.Metadata.Status),
+		NextRunAt:       nullableTime(job.Metadata.NextRunAt),
+		LastRunAt:       job.Metadata.LastRunAt,
+		LastError:       job.Metadata.LastError,
+		RunCount:        job.Metadata.RunCount,
+		FailCount:       job.Metadata.FailCount,
+		LockedBy:        job.Metadata.LockedBy,
+		LockedUntil:     job.Metadata.LockedUntil,
+		CreatedAt:       job.Metadata.CreatedAt,
+		UpdatedAt:       job.Metadata.UpdatedAt,
+	}, nil
+}
+
+func fromPostgresJob(row *postgresJob) (*Job, error) {
+	schedule, err := UnmarshalSchedule(row.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: job %q: %v", ErrInvalidJobData, row.Name, err)
+	}
+
+	var retryPolicy *RetryPolicy
+	if len(row.RetryPolicy) > 0 {
+		retryPolicy = &RetryPolicy{}
+		if err := json.Unmarshal(row.RetryPolicy, retryPolicy); err != nil {
+			return nil, fmt.Errorf("%w: job %q: invalid retry policy: %v", ErrInvalidJobData, row.Name, err)
+		}
+	}
+
+	job := &Job{
+		Name:            row.Name,
+		Schedule:        schedule,
+		RetryPolicy:     retryPolicy,
+		Timeout:         time.Duration(row.TimeoutMs) * time.Millisecond,
+		MissedRunPolicy: MissedRunPolicy(row.MissedRunPolicy),
+		Metadata: JobMetadata{
+			Status:      JobStatus(row.Status),
+			LastRunAt:   row.LastRunAt,
+			LastError:   row.LastError,
+			RunCount:    row.RunCount,
+			FailCount:   row.FailCount,
+			CreatedAt:   row.CreatedAt,
+			UpdatedAt:   row.UpdatedAt,
+			LockedBy:    row.LockedBy,
+			LockedUntil: row.LockedUntil,
+		},
+	}
+	if row.NextRunAt != nil {
+		job.Metadata.NextRunAt = *row.NextRunAt
+	}
+
+	return job, nil
+}
+
+// fromPostgresJobs decodes rows, logging and skipping the ones that fail so
+// one corrupt row doesn't stop every other job from running.
+func (p *PostgresBackend) fromPostgresJobs(ctx context.Context, rows []*postgresJob) []*Job {
+	jobs := make([]*Job, 0, len(rows))
+	for _, row := range rows {
+		job, err := fromPostgresJob(row)
+		if err != nil {
+			p.logger.Error(ctx, "skipping job that could not be decoded", map[string]interface{}{
+				"job":   row.Name,
+				"error": err.Error(),
+			})
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// escapeLike escapes LIKE wildcards so the value matches literally.
+func escapeLike(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
+
+// nullableTime maps the zero time to NULL.
+func nullableTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
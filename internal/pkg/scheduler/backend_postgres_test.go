@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/pkg/migration"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// jobRowsConn answers every SELECT with the same scheduler_jobs rows.
+type jobRowsConn struct {
+	rows    [][]driver.Value
+	queries []string
+}
+
+var jobRowColumns = []string{"name", "schedule", "retry_policy", "timeout_ms", "status", "next_run_at"}
+
+func (c *jobRowsConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *jobRowsConn) Close() error                              { return nil }
+func (c *jobRowsConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *jobRowsConn) Commit() error                             { return nil }
+func (c *jobRowsConn) Rollback() error                           { return nil }
+
+func (c *jobRowsConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.queries = append(c.queries, query)
+	if !strings.HasPrefix(query, "SELECT") {
+		return &jobRows{}, nil
+	}
+	return &jobRows{rows: c.rows}, nil
+}
+
+type jobRows struct {
+	rows [][]driver.Value
+	next int
+}
+
+func (r *jobRows) Columns() []string { return jobRowColumns }
+func (r *jobRows) Close() error      { return nil }
+func (r *jobRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+	copy(dest, r.rows[r.next])
+	r.next++
+	return nil
+}
+
+type jobRowsConnector struct{ conn *jobRowsConn }
+
+func (c jobRowsConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c jobRowsConnector) Driver() driver.Driver                        { return nil }
+
+func newTestPostgresBackend(t *testing.T, conn *jobRowsConn) *PostgresBackend {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(jobRowsConnector{conn: conn})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	return NewPostgresBackend(&database.Database{DB: db})
+}
+
+func TestPostgresBackend_SkipsAndLogsUndecodableJobs(t *testing.T) {
+	next := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	conn := &jobRowsConn{rows: [][]driver.Value{
+		{"good", []byte(`{"type":"interval","interval":"1m0s"}`), nil, int64(30000), "pending", next},
+		{"bad-schedule", []byte(`{"type":"lunar"}`), nil, int64(30000), "pending", next},
+		{"bad-retry", []byte(`{"type":"interval","interval":"1m0s"}`), []byte(`{"max_retries":"three"}`), int64(30000), "pending", next},
+	}}
+	logger := &recordingLogger{}
+	backend := newTestPostgresBackend(t, conn).WithLogger(logger)
+	ctx := context.Background()
+
+	for name, load := range map[string]func() ([]*Job, error){
+		"LoadJobs": func() ([]*Job, error) { return backend.LoadJobs(ctx) },
+		"FindJobs": func() ([]*Job, error) { return backend.FindJobs(ctx, JobFilter{}) },
+		"GetJobsDueForExecution": func() ([]*Job, error) {
+			return backend.GetJobsDueForExecution(ctx, next)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			logger.errors = nil
+
+			jobs, err := load()
+			require.NoError(t, err)
+			require.Len(t, jobs, 1)
+			assert.Equal(t, "good", jobs[0].Name)
+			assert.Equal(t, 30*time.Second, jobs[0].Timeout)
+			assert.True(t, next.Equal(jobs[0].Metadata.NextRunAt))
+
+			require.Len(t, logger.errors, 2)
+			assert.Equal(t, "bad-schedule", logger.errors[0]["job"])
+			assert.Equal(t, "bad-retry", logger.errors[1]["job"])
+		})
+	}
+}
+
+func TestPostgresBackend_LoadJobReportsUndecodableJob(t *testing.T) {
+	conn := &jobRowsConn{rows: [][]driver.Value{
+		{"bad-schedule", []byte(`{"type":"lunar"}`), nil, int64(0), "pending", nil},
+	}}
+	backend := newTestPostgresBackend(t, conn)
+
+	_, err := backend.LoadJob(context.Background(), "bad-schedule")
+	assert.ErrorIs(t, err, ErrInvalidJobData)
+	assert.ErrorContains(t, err, `job "bad-schedule"`)
+}
+
+// newPostgresBackends returns n backends, each with its own connection pool
+// like separate scheduler instances, on a fresh, migrated schema of the
+// database in TEST_POSTGRES_DSN. The test is skipped when it is unset.
+func newPostgresBackends(t *testing.T, n int) []*PostgresBackend {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	adminDB, err := admin.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { adminDB.Close() })
+
+	schema := fmt.Sprintf("scheduler_test_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	scoped := withSearchPath(dsn, schema)
+
+	// The migrator closes the connection it is given
+	migrateDB, err := sql.Open("pgx", scoped)
+	require.NoError(t, err)
+	require.NoError(t, migration.RunMigrations(migrateDB, "migration", zap.NewNop()))
+
+	backends := make([]*PostgresBackend, n)
+	for i := range backends {
+		db, err := gorm.Open(postgres.Open(scoped), &gorm.Config{Logger: gormlogger.Discard})
+		require.NoError(t, err)
+		sqlDB, err := db.DB()
+		require.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+		backends[i] = NewPostgresBackend(&database.Database{DB: db})
+	}
+	return backends
+}
+
+// withSearchPath points every connection opened with dsn at schema
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+func TestPostgresBackend_LeaseHasOneOwner(t *testing.T) {
+	backends := newPostgresBackends(t, 2)
+	ctx := context.Background()
+	const lockKey = "job:nightly-report"
+
+	// Instances race for the same job; the row lock lets exactly one win
+	var (
+		wg      sync.WaitGroup
+		winners atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			acquired, err := backends[i%2].AcquireLock(ctx, lockKey, time.Minute, fmt.Sprintf("instance-%d", i))
+			assert.NoError(t, err)
+			if acquired {
+				winners.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), winners.Load())
+
+	// A held lease isn't handed to anyone else
+	for _, backend := range backends {
+		acquired, err := backend.AcquireLock(ctx, lockKey, time.Minute, "late-instance")
+		require.NoError(t, err)
+		assert.False(t, acquired)
+	}
+}
+
+func TestPostgresBackend_ExpiredLeaseIsTakenOver(t *testing.T) {
+	backends := newPostgresBackends(t, 2)
+	first, second := backends[0], backends[1]
+	ctx := context.Background()
+	const lockKey = "job:nightly-report"
+
+	start := time.Now()
+	firstClock, secondClock := newFakeClock(start), newFakeClock(start)
+	first.setClock(firstClock)
+	second.setClock(secondClock)
+
+	acquired, err := first.AcquireLock(ctx, lockKey, time.Minute, "instance-1")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = second.AcquireLock(ctx, lockKey, time.Minute, "instance-2")
+	require.NoError(t, err)
+	assert.False(t, acquired, "the lease was taken before it expired")
+
+	// Once the lease expires, e.g. because its owner died, it can be taken over
+	secondClock.Advance(2 * time.Minute)
+	acquired, err = second.AcquireLock(ctx, lockKey, time.Minute, "instance-2")
+	require.NoError(t, err)
+	assert.True(t, acquired)
+
+	// The previous owner no longer holds it
+	firstClock.Advance(2 * time.Minute)
+	assert.ErrorIs(t, first.RefreshLock(ctx, lockKey, time.Minute, "instance-1"), ErrLockNotHeld)
+	assert.ErrorIs(t, first.ReleaseLock(ctx, lockKey, "instance-1"), ErrLockNotHeld)
+	acquired, err = first.AcquireLock(ctx, lockKey, time.Minute, "instance-1")
+	require.NoError(t, err)
+	assert.False(t, acquired)
+}
@@ -3,7 +3,9 @@ package scheduler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -13,25 +15,41 @@ const (
 	redisJobPrefix  = "scheduler:job:"
 	redisLockPrefix = "scheduler:lock:"
 	redisJobsSet    = "scheduler:jobs"
+	redisRunsPrefix = "scheduler:runs:"
 )
 
 // RedisBackend implements BackendProvider using Redis.
 type RedisBackend struct {
 	client *redis.Client
+	logger Logger
+	clock  Clock
 }
 
 // NewRedisBackend creates a new Redis backend.
 func NewRedisBackend(client *redis.Client) *RedisBackend {
 	return &RedisBackend{
 		client: client,
+		logger: &NoOpLogger{},
+		clock:  RealClock{},
 	}
 }
 
+// WithLogger sets the logger that reports stored jobs which can't be decoded.
+func (r *RedisBackend) WithLogger(logger Logger) *RedisBackend {
+	r.logger = logger
+	return r
+}
+
+func (r *RedisBackend) setClock(clock Clock) {
+	r.clock = clock
+}
+
 func (r *RedisBackend) SaveJob(ctx context.Context, job *Job) error {
 	jobKey := redisJobPrefix + job.Name
 
 	// Update timestamps
-	job.Metadata.UpdatedAt = time.Now()
+	now := r.clock.Now()
+	job.Metadata.UpdatedAt = now
 
 	// Check if job exists to set created timestamp
 	exists, err := r.client.Exists(ctx, jobKey).Result()
@@ -39,7 +57,7 @@ func (r *RedisBackend) SaveJob(ctx context.Context, job *Job) error {
 		return fmt.Errorf("failed to check job existence: %w", err)
 	}
 	if exists == 0 {
-		job.Metadata.CreatedAt = time.Now()
+		job.Metadata.CreatedAt = now
 	}
 
 	// Serialize job metadata (handler cannot be serialized)
@@ -69,17 +87,86 @@ func (r *RedisBackend) LoadJobs(ctx context.Context) ([]*Job, error) {
 	jobs := make([]*Job, 0, len(jobNames))
 
 	for _, name := range jobNames {
-		job, err := r.LoadJob(ctx, name)
+		job, err := r.loadListedJob(ctx, name)
 		if err != nil {
-			// Skip jobs that couldn't be loaded
+			return nil, err
+		}
+		if job != nil {
+			jobs = append(jobs, job)
+		}
+	}
+
+	return jobs, nil
+}
+
+// loadListedJob loads a job named in the jobs set. It returns nil without an
+// error for a job deleted since it was listed, and for one that can't be
+// decoded, which is logged and skipped so it doesn't stop the other jobs.
+func (r *RedisBackend) loadListedJob(ctx context.Context, name string) (*Job, error) {
+	job, err := r.LoadJob(ctx, name)
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		return nil, nil
+	case errors.Is(err, ErrInvalidJobData):
+		r.logger.Error(ctx, "skipping job that could not be decoded", map[string]interface{}{
+			"job":   name,
+			"error": err.Error(),
+		})
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	return job, nil
+}
+
+// FindJobs narrows candidates by name prefix with SSCAN MATCH, then applies
+// the remaining filters to the loaded jobs.
+func (r *RedisBackend) FindJobs(ctx context.Context, filter JobFilter) ([]*Job, error) {
+	match := "*"
+	if filter.NamePrefix != "" {
+		match = escapeRedisGlob(filter.NamePrefix) + "*"
+	}
+
+	// SSCAN may return a member more than once while the set is rehashed
+	var jobs []*Job
+	seen := make(map[string]struct{})
+	iter := r.client.SScan(ctx, redisJobsSet, 0, match, 100).Iterator()
+	for iter.Next(ctx) {
+		name := iter.Val()
+		if _, ok := seen[name]; ok {
 			continue
 		}
-		jobs = append(jobs, job)
+		seen[name] = struct{}{}
+
+		job, err := r.loadListedJob(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if job != nil && filter.Matches(job) {
+			jobs = append(jobs, job)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan jobs: %w", err)
 	}
 
+	SortJobsByNextRun(jobs)
 	return jobs, nil
 }
 
+// escapeRedisGlob escapes glob metacharacters for use in a MATCH pattern.
+func escapeRedisGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
 func (r *RedisBackend) LoadJob(ctx context.Context, jobName string) (*Job, error) {
 	jobKey := redisJobPrefix + jobName
 
@@ -93,7 +180,7 @@ func (r *RedisBackend) LoadJob(ctx context.Context, jobName string) (*Job, error
 
 	var job Job
 	if err := json.Unmarshal([]byte(data), &job); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal job: %w", err)
+		return nil, fmt.Errorf("%w: job %q: %v", ErrInvalidJobData, jobName, err)
 	}
 
 	return &job, nil
@@ -105,7 +192,7 @@ func (r *RedisBackend) UpdateMetadata(ctx context.Context, jobName string, metad
 		return err
 	}
 
-	metadata.UpdatedAt = time.Now()
+	metadata.UpdatedAt = r.clock.Now()
 	job.Metadata = *metadata
 
 	return r.SaveJob(ctx, job)
@@ -116,6 +203,7 @@ func (r *RedisBackend) DeleteJob(ctx context.Context, jobName string) error {
 
 	pipe := r.client.Pipeline()
 	pipe.Del(ctx, jobKey)
+	pipe.Del(ctx, redisRunsPrefix+jobName)
 	pipe.SRem(ctx, redisJobsSet, jobName)
 
 	if _, err := pipe.Exec(ctx); err != nil {
@@ -185,6 +273,52 @@ func (r *RedisBackend) RefreshLock(ctx context.Context, lockKey string, ttl time
 	return nil
 }
 
+func (r *RedisBackend) AppendJobRun(ctx context.Context, run *JobRun, maxRuns int) error {
+	runsKey := redisRunsPrefix + run.JobName
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job run: %w", err)
+	}
+
+	// Newest first; trim to cap history
+	pipe := r.client.Pipeline()
+	pipe.LPush(ctx, runsKey, data)
+	if maxRuns > 0 {
+		pipe.LTrim(ctx, runsKey, 0, int64(maxRuns-1))
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append job run: %w", err)
+	}
+
+	return nil
+}
+
+func (r *RedisBackend) GetJobRuns(ctx context.Context, jobName string, limit int) ([]*JobRun, error) {
+	stop := int64(-1)
+	if limit > 0 {
+		stop = int64(limit - 1)
+	}
+
+	items, err := r.client.LRange(ctx, redisRunsPrefix+jobName, 0, stop).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load job runs: %w", err)
+	}
+
+	runs := make([]*JobRun, 0, len(items))
+	for _, item := range items {
+		var run JobRun
+		if err := json.Unmarshal([]byte(item), &run); err != nil {
+			// Skip records that couldn't be decoded
+			continue
+		}
+		runs = append(runs, &run)
+	}
+
+	return runs, nil
+}
+
 func (r *RedisBackend) GetJobsDueForExecution(ctx context.Context, now time.Time) ([]*Job, error) {
 	jobs, err := r.LoadJobs(ctx)
 	if err != nil {
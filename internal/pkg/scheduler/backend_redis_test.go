@@ -0,0 +1,234 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingLogger keeps the messages logged at error level.
+type recordingLogger struct {
+	NoOpLogger
+	mu     sync.Mutex
+	errors []map[string]interface{}
+}
+
+func (l *recordingLogger) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.errors = append(l.errors, fields)
+}
+
+func newTestRedisBackend(t *testing.T) (*RedisBackend, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	backend := NewRedisBackend(redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+	t.Cleanup(func() { backend.Close() })
+	return backend, mr
+}
+
+func testJob(name string, status JobStatus, nextRun time.Time) *Job {
+	return &Job{
+		Name:        name,
+		Schedule:    NewIntervalSchedule(time.Minute),
+		RetryPolicy: &RetryPolicy{MaxRetries: 2, InitialInterval: time.Second},
+		Timeout:     30 * time.Second,
+		Metadata:    JobMetadata{Status: status, NextRunAt: nextRun},
+	}
+}
+
+func TestRedisBackend_SaveAndLoadJob(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	ctx := context.Background()
+	next := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, backend.SaveJob(ctx, testJob("report", JobStatusPending, next)))
+
+	job, err := backend.LoadJob(ctx, "report")
+	require.NoError(t, err)
+	assert.Equal(t, "interval", job.Schedule.Type())
+	assert.Equal(t, 30*time.Second, job.Timeout)
+	require.NotNil(t, job.RetryPolicy)
+	assert.Equal(t, 2, job.RetryPolicy.MaxRetries)
+	assert.True(t, next.Equal(job.Metadata.NextRunAt))
+	assert.False(t, job.Metadata.CreatedAt.IsZero())
+
+	_, err = backend.LoadJob(ctx, "missing")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+
+	require.NoError(t, backend.DeleteJob(ctx, "report"))
+	_, err = backend.LoadJob(ctx, "report")
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestRedisBackend_StampsWithClock(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	backend.setClock(clock)
+	ctx := context.Background()
+
+	require.NoError(t, backend.SaveJob(ctx, testJob("report", JobStatusPending, start)))
+
+	clock.Advance(time.Minute)
+	job, err := backend.LoadJob(ctx, "report")
+	require.NoError(t, err)
+	require.NoError(t, backend.UpdateMetadata(ctx, "report", &job.Metadata))
+
+	job, err = backend.LoadJob(ctx, "report")
+	require.NoError(t, err)
+	assert.True(t, start.Equal(job.Metadata.CreatedAt))
+	assert.True(t, start.Add(time.Minute).Equal(job.Metadata.UpdatedAt))
+}
+
+func TestRedisBackend_LoadJobsSkipsUndecodableJobs(t *testing.T) {
+	backend, mr := newTestRedisBackend(t)
+	logger := &recordingLogger{}
+	backend.WithLogger(logger)
+	ctx := context.Background()
+
+	require.NoError(t, backend.SaveJob(ctx, testJob("good", JobStatusPending, time.Now())))
+	mr.Set(redisJobPrefix+"corrupt", `{"name":"corrupt","schedule":{"type":"lunar"}}`)
+	mr.SAdd(redisJobsSet, "corrupt")
+	// Listed but deleted before it was read
+	mr.SAdd(redisJobsSet, "deleted")
+
+	jobs, err := backend.LoadJobs(ctx)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "good", jobs[0].Name)
+
+	require.Len(t, logger.errors, 1, "only the undecodable job is reported")
+	assert.Equal(t, "corrupt", logger.errors[0]["job"])
+
+	_, err = backend.LoadJob(ctx, "corrupt")
+	assert.ErrorIs(t, err, ErrInvalidJobData)
+}
+
+func TestRedisBackend_FindJobs(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, backend.SaveJob(ctx, testJob("report:daily", JobStatusPending, now.Add(2*time.Hour))))
+	require.NoError(t, backend.SaveJob(ctx, testJob("report:hourly", JobStatusPending, now.Add(time.Hour))))
+	require.NoError(t, backend.SaveJob(ctx, testJob("report:weekly", JobStatusPaused, now.Add(time.Hour))))
+	require.NoError(t, backend.SaveJob(ctx, testJob("cleanup", JobStatusPending, now)))
+
+	jobs, err := backend.FindJobs(ctx, JobFilter{NamePrefix: "report:", Statuses: []JobStatus{JobStatusPending}})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "report:hourly", jobs[0].Name, "sorted by next run")
+	assert.Equal(t, "report:daily", jobs[1].Name)
+
+	jobs, err = backend.FindJobs(ctx, JobFilter{DueBefore: now.Add(90 * time.Minute)})
+	require.NoError(t, err)
+	assert.Len(t, jobs, 3)
+}
+
+// repeatScanHook repeats every SSCAN page, as Redis may while rehashing.
+type repeatScanHook struct{}
+
+func (repeatScanHook) DialHook(next redis.DialHook) redis.DialHook { return next }
+
+func (repeatScanHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		if scan, ok := cmd.(*redis.ScanCmd); ok && err == nil {
+			page, cursor := scan.Val()
+			scan.SetVal(append(page, page...), cursor)
+		}
+		return err
+	}
+}
+
+func (repeatScanHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return next
+}
+
+func TestRedisBackend_FindJobsDeduplicatesScan(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, backend.SaveJob(ctx, testJob("a", JobStatusPending, now)))
+	require.NoError(t, backend.SaveJob(ctx, testJob("b", JobStatusPending, now.Add(time.Minute))))
+	backend.client.AddHook(repeatScanHook{})
+
+	jobs, err := backend.FindJobs(ctx, JobFilter{})
+	require.NoError(t, err)
+	require.Len(t, jobs, 2)
+	assert.Equal(t, "a", jobs[0].Name)
+	assert.Equal(t, "b", jobs[1].Name)
+}
+
+func TestRedisBackend_GetJobsDueForExecution(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, backend.SaveJob(ctx, testJob("due", JobStatusPending, now)))
+	require.NoError(t, backend.SaveJob(ctx, testJob("later", JobStatusPending, now.Add(time.Minute))))
+	require.NoError(t, backend.SaveJob(ctx, testJob("paused", JobStatusPaused, now.Add(-time.Minute))))
+
+	jobs, err := backend.GetJobsDueForExecution(ctx, now)
+	require.NoError(t, err)
+	require.Len(t, jobs, 1)
+	assert.Equal(t, "due", jobs[0].Name)
+}
+
+func TestRedisBackend_Locks(t *testing.T) {
+	backend, mr := newTestRedisBackend(t)
+	ctx := context.Background()
+
+	ok, err := backend.AcquireLock(ctx, "job", time.Minute, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	ok, err = backend.AcquireLock(ctx, "job", time.Minute, "b")
+	require.NoError(t, err)
+	assert.False(t, ok, "a held lock can't be taken")
+
+	assert.ErrorIs(t, backend.RefreshLock(ctx, "job", time.Minute, "b"), ErrLockNotHeld)
+	assert.ErrorIs(t, backend.ReleaseLock(ctx, "job", "b"), ErrLockNotHeld)
+
+	mr.FastForward(30 * time.Second)
+	require.NoError(t, backend.RefreshLock(ctx, "job", time.Minute, "a"))
+	assert.Equal(t, time.Minute, mr.TTL(redisLockPrefix+"job"))
+
+	require.NoError(t, backend.ReleaseLock(ctx, "job", "a"))
+	ok, err = backend.AcquireLock(ctx, "job", time.Minute, "b")
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// An expired lock can be taken over
+	mr.FastForward(2 * time.Minute)
+	ok, err = backend.AcquireLock(ctx, "job", time.Minute, "c")
+	require.NoError(t, err)
+	assert.True(t, ok)
+}
+
+func TestRedisBackend_JobRuns(t *testing.T) {
+	backend, _ := newTestRedisBackend(t)
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		run := &JobRun{JobName: "report", InstanceID: "i1", Status: JobStatusCompleted, Duration: time.Duration(i) * time.Second}
+		require.NoError(t, backend.AppendJobRun(ctx, run, 3))
+	}
+
+	runs, err := backend.GetJobRuns(ctx, "report", 0)
+	require.NoError(t, err)
+	require.Len(t, runs, 3, "history is capped at maxRuns")
+	assert.Equal(t, 4*time.Second, runs[0].Duration, "newest first")
+
+	runs, err = backend.GetJobRuns(ctx, "report", 1)
+	require.NoError(t, err)
+	assert.Len(t, runs, 1)
+}
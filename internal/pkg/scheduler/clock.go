@@ -0,0 +1,23 @@
+package scheduler
+
+import "time"
+
+// Clock is a source of the current time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+// RealClock is a Clock backed by the system time.
+type RealClock struct{}
+
+// Now returns time.Now().
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// clockSetter is implemented by backends that stamp metadata and lock expiry.
+// NewScheduler hands them its Clock so both agree on the time.
+type clockSetter interface {
+	setClock(clock Clock)
+}
@@ -5,15 +5,24 @@ import "time"
 // SchedulerConfig holds all configuration for the scheduler.
 type SchedulerConfig struct {
 	// Scheduler settings
-	TickInterval  time.Duration `json:"tick_interval" yaml:"tick_interval"`
-	MaxConcurrent int           `json:"max_concurrent" yaml:"max_concurrent"`
+	TickInterval   time.Duration `json:"tick_interval" yaml:"tick_interval"`
+	MaxConcurrent  int           `json:"max_concurrent" yaml:"max_concurrent"`
+	MaxJobHistory  int           `json:"max_job_history" yaml:"max_job_history"`
+	MaxCatchUpRuns int           `json:"max_catch_up_runs" yaml:"max_catch_up_runs"`
+
+	// Worker pool saturation: "queue" (default) or "skip"
+	OnPoolFull     PoolFullPolicy `json:"on_pool_full" yaml:"on_pool_full"`
+	MaxPendingJobs int            `json:"max_pending_jobs" yaml:"max_pending_jobs"`
+
+	// Delete one-time jobs from the backend after they run successfully
+	RemoveCompletedOnceJobs bool `json:"remove_completed_once_jobs" yaml:"remove_completed_once_jobs"`
 
 	// Lock settings
 	LockTTL             time.Duration `json:"lock_ttl" yaml:"lock_ttl"`
 	LockRefreshInterval time.Duration `json:"lock_refresh_interval" yaml:"lock_refresh_interval"`
 
 	// Backend settings
-	BackendType string `json:"backend_type" yaml:"backend_type"` // "redis" or "memory"
+	BackendType string `json:"backend_type" yaml:"backend_type"` // "redis", "postgres" or "memory"
 
 	// Redis settings (if backend_type is "redis")
 	RedisAddr     string `json:"redis_addr" yaml:"redis_addr"`
@@ -26,6 +35,10 @@ func DefaultSchedulerConfig() *SchedulerConfig {
 	return &SchedulerConfig{
 		TickInterval:        5 * time.Second,
 		MaxConcurrent:       10,
+		MaxJobHistory:       DefaultMaxJobHistory,
+		MaxCatchUpRuns:      DefaultMaxCatchUpRuns,
+		OnPoolFull:          PoolFullQueue,
+		MaxPendingJobs:      DefaultMaxPendingJobs,
 		LockTTL:             30 * time.Second,
 		LockRefreshInterval: 10 * time.Second,
 		BackendType:         "memory",
@@ -45,6 +58,22 @@ func (c *SchedulerConfig) Validate() error {
 		c.MaxConcurrent = 10
 	}
 
+	if c.MaxJobHistory <= 0 {
+		c.MaxJobHistory = DefaultMaxJobHistory
+	}
+
+	if c.MaxCatchUpRuns <= 0 {
+		c.MaxCatchUpRuns = DefaultMaxCatchUpRuns
+	}
+
+	if c.OnPoolFull != PoolFullQueue && c.OnPoolFull != PoolFullSkip {
+		c.OnPoolFull = PoolFullQueue
+	}
+
+	if c.MaxPendingJobs <= 0 {
+		c.MaxPendingJobs = DefaultMaxPendingJobs
+	}
+
 	if c.LockTTL <= 0 {
 		c.LockTTL = 30 * time.Second
 	}
@@ -57,7 +86,7 @@ func (c *SchedulerConfig) Validate() error {
 		c.LockRefreshInterval = c.LockTTL / 3
 	}
 
-	if c.BackendType != "redis" && c.BackendType != "memory" {
+	if c.BackendType != "redis" && c.BackendType != "postgres" && c.BackendType != "memory" {
 		c.BackendType = "memory"
 	}
 
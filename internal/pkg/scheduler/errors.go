@@ -9,11 +9,14 @@ var (
 	ErrInvalidHandler  = errors.New("handler cannot be nil")
 	ErrInvalidTimeout  = errors.New("timeout must be greater than zero")
 
+	ErrInvalidMissedRunPolicy = errors.New("invalid missed run policy")
+
 	// Job operation errors
 	ErrJobAlreadyExists  = errors.New("job already exists")
 	ErrJobNotFound       = errors.New("job not found")
 	ErrJobAlreadyRunning = errors.New("job is already running")
 	ErrJobPaused         = errors.New("job is paused")
+	ErrJobTimeout        = errors.New("job execution timed out")
 
 	// Scheduler errors
 	ErrSchedulerNotStarted     = errors.New("scheduler not started")
@@ -27,4 +30,5 @@ var (
 	// Backend errors
 	ErrBackendNotAvailable    = errors.New("backend not available")
 	ErrBackendOperationFailed = errors.New("backend operation failed")
+	ErrInvalidJobData         = errors.New("stored job could not be decoded")
 )
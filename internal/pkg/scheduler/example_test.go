@@ -3,6 +3,7 @@ package scheduler_test
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"myapp/internal/pkg/scheduler"
@@ -59,6 +60,24 @@ func Example_basicUsage() {
 	sched.Stop(shutdownCtx)
 }
 
+// manualClock is a Clock that only moves when advanced.
+type manualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
 // Example_cronSchedule demonstrates using cron expressions.
 func Example_cronSchedule() {
 	backend := scheduler.NewMemoryBackend()
@@ -67,7 +86,12 @@ func Example_cronSchedule() {
 	executor := scheduler.NewDefaultJobExecutor(logger, metrics)
 	lock := scheduler.NewDistributedLock(backend, logger, metrics)
 
+	// Use a manual clock one second before the top of the hour
+	clock := &manualClock{now: time.Date(2024, 1, 1, 8, 59, 59, 0, time.UTC)}
+
 	config := scheduler.DefaultConfig()
+	config.TickInterval = 10 * time.Millisecond
+	config.Clock = clock
 	sched := scheduler.NewScheduler(backend, executor, lock, logger, metrics, config)
 
 	// Create a cron schedule - runs every hour at minute 0
@@ -90,6 +114,14 @@ func Example_cronSchedule() {
 	sched.Register(job)
 	sched.Start(context.Background())
 
+	// Move past the top of the hour and let the scheduler tick
+	clock.Advance(time.Second)
+	time.Sleep(50 * time.Millisecond)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	sched.Stop(shutdownCtx)
+
 	// Output:
 	// Generating hourly report...
 }
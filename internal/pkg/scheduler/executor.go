@@ -134,7 +134,7 @@ func (e *DefaultJobExecutor) executeWithTimeout(ctx context.Context, job *Job, a
 	case <-timeoutCtx.Done():
 		if timeoutCtx.Err() == context.DeadlineExceeded {
 			e.metrics.JobTimedOut(job.Name)
-			return fmt.Errorf("job execution timeout after %s", job.Timeout)
+			return fmt.Errorf("%w after %s", ErrJobTimeout, job.Timeout)
 		}
 		return timeoutCtx.Err()
 	case err := <-errChan:
@@ -2,6 +2,10 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -25,8 +29,102 @@ type Job struct {
 	Timeout     time.Duration `json:"timeout"`
 	Handler     JobHandler    `json:"-"`
 	Metadata    JobMetadata   `json:"metadata"`
+	// MissedRunPolicy controls what happens when schedule slots were missed,
+	// e.g. while no scheduler instance was running. Defaults to RunOnce.
+	MissedRunPolicy MissedRunPolicy `json:"missed_run_policy,omitempty"`
 }
 
+// UnmarshalJSON decodes a job, rebuilding its Schedule with UnmarshalSchedule.
+// The handler is not serialized and must be restored from the local registration.
+func (j *Job) UnmarshalJSON(data []byte) error {
+	type jobAlias Job
+	aux := struct {
+		*jobAlias
+		Schedule json.RawMessage `json:"schedule"`
+	}{jobAlias: (*jobAlias)(j)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.Schedule) > 0 && string(aux.Schedule) != "null" {
+		schedule, err := UnmarshalSchedule(aux.Schedule)
+		if err != nil {
+			return fmt.Errorf("failed to unmarshal schedule: %w", err)
+		}
+		j.Schedule = schedule
+	}
+
+	return nil
+}
+
+// JobFilter selects jobs in GetJobs. Zero fields match every job.
+type JobFilter struct {
+	// Statuses matches jobs in any of the given statuses.
+	Statuses []JobStatus
+	// NamePrefix matches jobs whose name starts with the prefix.
+	NamePrefix string
+	// DueBefore matches jobs whose next run is strictly before this time.
+	DueBefore time.Time
+}
+
+// Matches reports whether the job satisfies the filter.
+func (f JobFilter) Matches(job *Job) bool {
+	if f.NamePrefix != "" && !strings.HasPrefix(job.Name, f.NamePrefix) {
+		return false
+	}
+
+	if len(f.Statuses) > 0 {
+		found := false
+		for _, status := range f.Statuses {
+			if job.Metadata.Status == status {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if !f.DueBefore.IsZero() {
+		next := job.Metadata.NextRunAt
+		if next.IsZero() || !next.Before(f.DueBefore) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SortJobsByNextRun orders jobs by next run time, soonest first. Jobs with no
+// next run sort last; ties are broken by name.
+func SortJobsByNextRun(jobs []*Job) {
+	sort.SliceStable(jobs, func(a, b int) bool {
+		ta, tb := jobs[a].Metadata.NextRunAt, jobs[b].Metadata.NextRunAt
+		switch {
+		case ta.IsZero() != tb.IsZero():
+			return tb.IsZero()
+		case !ta.Equal(tb):
+			return ta.Before(tb)
+		default:
+			return jobs[a].Name < jobs[b].Name
+		}
+	})
+}
+
+// MissedRunPolicy defines how a job catches up on missed schedule slots.
+type MissedRunPolicy string
+
+const (
+	// MissedRunPolicyRunOnce runs the job once, however many slots were missed.
+	MissedRunPolicyRunOnce MissedRunPolicy = "run_once"
+	// MissedRunPolicySkip drops missed slots and waits for the next future one.
+	MissedRunPolicySkip MissedRunPolicy = "skip"
+	// MissedRunPolicyRunAll runs the job once per missed slot, up to MaxCatchUpRuns.
+	MissedRunPolicyRunAll MissedRunPolicy = "run_all"
+)
+
 // JobMetadata contains runtime information about a job.
 type JobMetadata struct {
 	Status      JobStatus  `json:"status"`
@@ -41,6 +139,17 @@ type JobMetadata struct {
 	LockedUntil *time.Time `json:"locked_until,omitempty"`
 }
 
+// JobRun records a single execution of a job.
+type JobRun struct {
+	JobName    string        `json:"job_name"`
+	InstanceID string        `json:"instance_id"`
+	StartedAt  time.Time     `json:"started_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Duration   time.Duration `json:"duration"`
+	Status     JobStatus     `json:"status"`
+	Error      string        `json:"error,omitempty"`
+}
+
 // JobHandler is the user-defined function executed by the scheduler.
 // It should be idempotent to handle at-least-once delivery semantics.
 type JobHandler func(ctx context.Context) error
@@ -128,5 +237,31 @@ func (j *Job) Validate() error {
 		return ErrInvalidTimeout
 	}
 
+	switch j.MissedRunPolicy {
+	case "", MissedRunPolicyRunOnce, MissedRunPolicySkip, MissedRunPolicyRunAll:
+	default:
+		return ErrInvalidMissedRunPolicy
+	}
+
 	return nil
 }
+
+// dueSlots counts the schedule slots from NextRunAt up to now, inclusive,
+// stopping once limit is reached. A job that is simply due returns 1.
+func (j *Job) dueSlots(now time.Time, limit int) int {
+	slot := j.Metadata.NextRunAt
+	if slot.IsZero() || slot.After(now) {
+		return 0
+	}
+
+	count := 1
+	for count < limit {
+		next := j.Schedule.NextRun(slot)
+		if next.IsZero() || !next.After(slot) || next.After(now) {
+			break
+		}
+		count++
+		slot = next
+	}
+	return count
+}
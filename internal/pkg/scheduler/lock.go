@@ -98,11 +98,15 @@ func (d *DistributedLock) AcquireAndExecute(
 
 	// Start lock refresh goroutine
 	refreshCtx, cancelRefresh := context.WithCancel(ctx)
-	defer cancelRefresh()
 
 	refreshDone := make(chan struct{})
 	go d.refreshLockPeriodically(refreshCtx, lockKey, owner, job.Name, refreshDone)
-	defer func() { <-refreshDone }()
+
+	// Stop refreshing before waiting for the refresh goroutine to exit
+	defer func() {
+		cancelRefresh()
+		<-refreshDone
+	}()
 
 	// Execute job
 	return executor.Execute(ctx, job)
@@ -21,6 +21,11 @@ type MetricsCollector interface {
 	JobsRegistered(count int)
 	JobsQueued(count int)
 	JobsRunning(count int)
+
+	// Worker pool saturation metrics
+	JobSkipped(jobName string)
+	JobDeferred(jobName string)
+	JobsPending(count int)
 }
 
 // NoOpMetrics is a metrics collector that does nothing.
@@ -38,3 +43,6 @@ func (n *NoOpMetrics) LockRefreshed(jobName string)                        {}
 func (n *NoOpMetrics) JobsRegistered(count int)                            {}
 func (n *NoOpMetrics) JobsQueued(count int)                                {}
 func (n *NoOpMetrics) JobsRunning(count int)                               {}
+func (n *NoOpMetrics) JobSkipped(jobName string)                           {}
+func (n *NoOpMetrics) JobDeferred(jobName string)                          {}
+func (n *NoOpMetrics) JobsPending(count int)                               {}
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduler_job_runs;
+DROP TABLE IF EXISTS scheduler_locks;
+DROP TABLE IF EXISTS scheduler_jobs;
@@ -0,0 +1,45 @@
+-- Create scheduler_jobs table
+CREATE TABLE IF NOT EXISTS scheduler_jobs (
+    name              VARCHAR(255) PRIMARY KEY,
+    schedule          JSONB        NOT NULL,
+    retry_policy      JSONB,
+    timeout_ms        BIGINT       NOT NULL,
+    missed_run_policy VARCHAR(20)  NOT NULL DEFAULT '',
+    status            VARCHAR(20)  NOT NULL DEFAULT 'pending',
+    next_run_at       TIMESTAMPTZ,
+    last_run_at       TIMESTAMPTZ,
+    last_error        TEXT         NOT NULL DEFAULT '',
+    run_count         BIGINT       NOT NULL DEFAULT 0,
+    fail_count        BIGINT       NOT NULL DEFAULT 0,
+    locked_by         VARCHAR(255) NOT NULL DEFAULT '',
+    locked_until      TIMESTAMPTZ,
+    created_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Due-job lookup only scans runnable jobs
+CREATE INDEX IF NOT EXISTS idx_scheduler_jobs_next_run_at
+    ON scheduler_jobs (next_run_at)
+    WHERE status NOT IN ('paused', 'cancelled');
+
+-- Lease rows used for distributed locking
+CREATE TABLE IF NOT EXISTS scheduler_locks (
+    lock_key   VARCHAR(255) PRIMARY KEY,
+    owner      VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMPTZ  NOT NULL
+);
+
+-- Execution history, newest rows have the highest id
+CREATE TABLE IF NOT EXISTS scheduler_job_runs (
+    id          BIGSERIAL PRIMARY KEY,
+    job_name    VARCHAR(255) NOT NULL,
+    instance_id VARCHAR(255) NOT NULL,
+    started_at  TIMESTAMPTZ  NOT NULL,
+    finished_at TIMESTAMPTZ  NOT NULL,
+    duration_ms BIGINT       NOT NULL,
+    status      VARCHAR(20)  NOT NULL,
+    error       TEXT         NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduler_job_runs_job_name_id
+    ON scheduler_job_runs (job_name, id DESC);
@@ -3,6 +3,8 @@ package scheduler
 import (
 	"fmt"
 
+	"myapp/internal/pkg/database"
+
 	"github.com/redis/go-redis/v9"
 	"go.uber.org/fx"
 )
@@ -45,8 +47,14 @@ func NewSchedulerFromConfig(params SchedulerParams) (Scheduler, error) {
 		WithRefreshInterval(params.Config.LockRefreshInterval)
 
 	config := &Config{
-		TickInterval:  params.Config.TickInterval,
-		MaxConcurrent: params.Config.MaxConcurrent,
+		TickInterval:   params.Config.TickInterval,
+		MaxConcurrent:  params.Config.MaxConcurrent,
+		MaxJobHistory:  params.Config.MaxJobHistory,
+		MaxCatchUpRuns: params.Config.MaxCatchUpRuns,
+		OnPoolFull:     params.Config.OnPoolFull,
+		MaxPendingJobs: params.Config.MaxPendingJobs,
+
+		RemoveCompletedOnceJobs: params.Config.RemoveCompletedOnceJobs,
 	}
 
 	return NewScheduler(params.Backend, executor, lock, logger, metrics, config), nil
@@ -107,7 +115,9 @@ type BackendParams struct {
 	fx.In
 
 	Config      *SchedulerConfig
-	RedisClient *redis.Client `optional:"true"`
+	RedisClient *redis.Client      `optional:"true"`
+	Database    *database.Database `optional:"true"`
+	Logger      Logger             `optional:"true"`
 }
 
 // NewBackendFromConfig creates a new backend from configuration.
@@ -116,6 +126,11 @@ func NewBackendFromConfig(params BackendParams) (BackendProvider, error) {
 		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
+	logger := params.Logger
+	if logger == nil {
+		logger = &NoOpLogger{}
+	}
+
 	switch params.Config.BackendType {
 	case "redis":
 		if params.RedisClient == nil {
@@ -125,9 +140,15 @@ func NewBackendFromConfig(params BackendParams) (BackendProvider, error) {
 				Password: params.Config.RedisPassword,
 				DB:       params.Config.RedisDB,
 			})
-			return NewRedisBackend(client), nil
+			return NewRedisBackend(client).WithLogger(logger), nil
+		}
+		return NewRedisBackend(params.RedisClient).WithLogger(logger), nil
+
+	case "postgres":
+		if params.Database == nil {
+			return nil, fmt.Errorf("postgres backend requires a database connection")
 		}
-		return NewRedisBackend(params.RedisClient), nil
+		return NewPostgresBackend(params.Database).WithLogger(logger), nil
 
 	case "memory":
 		return NewMemoryBackend(), nil
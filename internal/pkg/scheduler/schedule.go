@@ -3,6 +3,7 @@ package scheduler
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand/v2"
 	"time"
 
 	"github.com/robfig/cron/v3"
@@ -67,8 +68,11 @@ func (c *CronSchedule) MarshalJSON() ([]byte, error) {
 }
 
 // IntervalSchedule represents an interval-based schedule.
+// When MaxJitter is set, each NextRun adds a random offset in [0, MaxJitter)
+// so instances sharing the same job don't all fire on the same tick.
 type IntervalSchedule struct {
-	Interval time.Duration
+	Interval  time.Duration
+	MaxJitter time.Duration
 }
 
 // NewIntervalSchedule creates a new interval schedule.
@@ -78,11 +82,27 @@ func NewIntervalSchedule(interval time.Duration) *IntervalSchedule {
 	}
 }
 
+// NewIntervalScheduleWithJitter creates an interval schedule with a random
+// per-cycle offset of up to maxJitter.
+func NewIntervalScheduleWithJitter(interval, maxJitter time.Duration) *IntervalSchedule {
+	return &IntervalSchedule{
+		Interval:  interval,
+		MaxJitter: maxJitter,
+	}
+}
+
 func (i *IntervalSchedule) NextRun(from time.Time) time.Time {
-	return from.Add(i.Interval)
+	next := from.Add(i.Interval)
+	if i.MaxJitter > 0 {
+		next = next.Add(rand.N(i.MaxJitter))
+	}
+	return next
 }
 
 func (i *IntervalSchedule) String() string {
+	if i.MaxJitter > 0 {
+		return fmt.Sprintf("every %s (jitter %s)", i.Interval, i.MaxJitter)
+	}
 	return fmt.Sprintf("every %s", i.Interval)
 }
 
@@ -91,10 +111,14 @@ func (i *IntervalSchedule) Type() string {
 }
 
 func (i *IntervalSchedule) MarshalJSON() ([]byte, error) {
-	return json.Marshal(map[string]interface{}{
+	data := map[string]interface{}{
 		"type":     i.Type(),
 		"interval": i.Interval.String(),
-	})
+	}
+	if i.MaxJitter > 0 {
+		data["max_jitter"] = i.MaxJitter.String()
+	}
+	return json.Marshal(data)
 }
 
 // OnceSchedule represents a one-time schedule at a specific time.
@@ -139,3 +163,50 @@ func (o *OnceSchedule) MarshalJSON() ([]byte, error) {
 func (o *OnceSchedule) MarkRan() {
 	o.ran = true
 }
+
+// UnmarshalSchedule rebuilds a Schedule from the JSON produced by its MarshalJSON.
+func UnmarshalSchedule(data []byte) (Schedule, error) {
+	var raw struct {
+		Type       string `json:"type"`
+		Expression string `json:"expression"`
+		Interval   string `json:"interval"`
+		MaxJitter  string `json:"max_jitter"`
+		RunAt      string `json:"run_at"`
+		Ran        bool   `json:"ran"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	switch ScheduleType(raw.Type) {
+	case ScheduleTypeCron:
+		return NewCronSchedule(raw.Expression)
+
+	case ScheduleTypeInterval:
+		interval, err := time.ParseDuration(raw.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid interval: %w", err)
+		}
+		var jitter time.Duration
+		if raw.MaxJitter != "" {
+			if jitter, err = time.ParseDuration(raw.MaxJitter); err != nil {
+				return nil, fmt.Errorf("invalid max jitter: %w", err)
+			}
+		}
+		return NewIntervalScheduleWithJitter(interval, jitter), nil
+
+	case ScheduleTypeOnce:
+		runAt, err := time.Parse(time.RFC3339, raw.RunAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid run_at: %w", err)
+		}
+		schedule := NewOnceSchedule(runAt)
+		if raw.Ran {
+			schedule.MarkRan()
+		}
+		return schedule, nil
+
+	default:
+		return nil, fmt.Errorf("unknown schedule type: %q", raw.Type)
+	}
+}
@@ -2,6 +2,7 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -19,6 +20,8 @@ type Scheduler interface {
 	Remove(jobName string) error
 	GetJob(jobName string) (*Job, error)
 	GetAllJobs() ([]*Job, error)
+	GetJobs(filter JobFilter) ([]*Job, error)
+	GetJobHistory(jobName string, limit int) ([]*JobRun, error)
 }
 
 // DefaultScheduler is the default implementation of Scheduler.
@@ -28,6 +31,7 @@ type DefaultScheduler struct {
 	lock     *DistributedLock
 	logger   Logger
 	metrics  MetricsCollector
+	clock    Clock
 
 	instanceID   string
 	tickInterval time.Duration
@@ -41,19 +45,75 @@ type DefaultScheduler struct {
 	// Worker pool
 	workerPool    chan struct{}
 	maxConcurrent int
+
+	// maxHistory caps the run records kept per job
+	maxHistory int
+
+	// maxCatchUpRuns caps executions for jobs using MissedRunPolicyRunAll
+	maxCatchUpRuns int
+
+	// Jobs waiting for a worker slot, by name in FIFO order
+	onPoolFull     PoolFullPolicy
+	maxPendingJobs int
+	pendingMu      sync.Mutex
+	pending        []string
+
+	// removeCompletedOnce deletes one-time jobs from the backend after a
+	// successful run
+	removeCompletedOnce bool
 }
 
+// PoolFullPolicy decides what happens to a due job when no worker slot is free.
+type PoolFullPolicy string
+
+const (
+	// PoolFullQueue keeps the job in a bounded FIFO queue that is served
+	// first on the next tick.
+	PoolFullQueue PoolFullPolicy = "queue"
+	// PoolFullSkip drops the job for this tick.
+	PoolFullSkip PoolFullPolicy = "skip"
+)
+
+// DefaultMaxPendingJobs is the default size of the pending job queue.
+const DefaultMaxPendingJobs = 100
+
+// DefaultMaxJobHistory is the default number of run records kept per job.
+const DefaultMaxJobHistory = 100
+
+// DefaultMaxCatchUpRuns is the default cap on catch-up executions per tick.
+const DefaultMaxCatchUpRuns = 10
+
 // Config holds scheduler configuration.
 type Config struct {
 	TickInterval  time.Duration
 	MaxConcurrent int
+	// Clock is the time source used for due checks, metadata timestamps and,
+	// in the built-in backends, lock expiry. Defaults to RealClock.
+	Clock Clock
+	// MaxJobHistory is the number of run records kept per job.
+	MaxJobHistory int
+	// MaxCatchUpRuns caps how many missed slots a MissedRunPolicyRunAll job replays.
+	MaxCatchUpRuns int
+	// OnPoolFull controls due jobs that find no free worker slot. Defaults to PoolFullQueue.
+	OnPoolFull PoolFullPolicy
+	// MaxPendingJobs bounds the queue used by PoolFullQueue.
+	MaxPendingJobs int
+	// RemoveCompletedOnceJobs deletes a one-time job, with its run history,
+	// from the backend once it has run successfully. Failed one-time jobs
+	// are kept so the error can be inspected.
+	RemoveCompletedOnceJobs bool
 }
 
 // DefaultConfig returns default scheduler configuration.
 func DefaultConfig() *Config {
 	return &Config{
-		TickInterval:  5 * time.Second,
-		MaxConcurrent: 10,
+		TickInterval:   5 * time.Second,
+		MaxConcurrent:  10,
+		Clock:          RealClock{},
+		MaxJobHistory:  DefaultMaxJobHistory,
+		MaxCatchUpRuns: DefaultMaxCatchUpRuns,
+		OnPoolFull:     PoolFullQueue,
+		MaxPendingJobs: DefaultMaxPendingJobs,
 	}
 }
 
@@ -78,18 +138,53 @@ func NewScheduler(
 		metrics = &NoOpMetrics{}
 	}
 
+	clock := config.Clock
+	if clock == nil {
+		clock = RealClock{}
+	}
+	if setter, ok := backend.(clockSetter); ok {
+		setter.setClock(clock)
+	}
+
+	maxHistory := config.MaxJobHistory
+	if maxHistory <= 0 {
+		maxHistory = DefaultMaxJobHistory
+	}
+
+	maxCatchUpRuns := config.MaxCatchUpRuns
+	if maxCatchUpRuns <= 0 {
+		maxCatchUpRuns = DefaultMaxCatchUpRuns
+	}
+
+	onPoolFull := config.OnPoolFull
+	if onPoolFull == "" {
+		onPoolFull = PoolFullQueue
+	}
+
+	maxPendingJobs := config.MaxPendingJobs
+	if maxPendingJobs <= 0 {
+		maxPendingJobs = DefaultMaxPendingJobs
+	}
+
 	return &DefaultScheduler{
-		backend:       backend,
-		executor:      executor,
-		lock:          lock,
-		logger:        logger,
-		metrics:       metrics,
-		instanceID:    uuid.New().String(),
-		tickInterval:  config.TickInterval,
-		maxConcurrent: config.MaxConcurrent,
-		jobs:          make(map[string]*Job),
-		stopChan:      make(chan struct{}),
-		workerPool:    make(chan struct{}, config.MaxConcurrent),
+		backend:        backend,
+		executor:       executor,
+		lock:           lock,
+		logger:         logger,
+		metrics:        metrics,
+		clock:          clock,
+		instanceID:     uuid.New().String(),
+		tickInterval:   config.TickInterval,
+		maxConcurrent:  config.MaxConcurrent,
+		jobs:           make(map[string]*Job),
+		stopChan:       make(chan struct{}),
+		workerPool:     make(chan struct{}, config.MaxConcurrent),
+		maxHistory:     maxHistory,
+		maxCatchUpRuns: maxCatchUpRuns,
+		onPoolFull:     onPoolFull,
+		maxPendingJobs: maxPendingJobs,
+
+		removeCompletedOnce: config.RemoveCompletedOnceJobs,
 	}
 }
 
@@ -108,7 +203,7 @@ func (s *DefaultScheduler) Register(job *Job) error {
 	}
 
 	// Initialize metadata
-	now := time.Now()
+	now := s.clock.Now()
 	job.Metadata = JobMetadata{
 		Status:    JobStatusPending,
 		NextRunAt: job.Schedule.NextRun(now),
@@ -202,9 +297,6 @@ func (s *DefaultScheduler) Stop(ctx context.Context) error {
 	// Wait for graceful shutdown or timeout
 	select {
 	case <-done:
-		s.logger.Info(ctx, "scheduler stopped gracefully", map[string]interface{}{
-			"instance_id": s.instanceID,
-		})
 	case <-ctx.Done():
 		s.logger.Warn(ctx, "scheduler stop timeout", map[string]interface{}{
 			"instance_id": s.instanceID,
@@ -212,9 +304,60 @@ func (s *DefaultScheduler) Stop(ctx context.Context) error {
 		return ctx.Err()
 	}
 
+	if err := s.drainWorkerPool(ctx); err != nil {
+		s.logger.Warn(ctx, "scheduler stop timeout draining worker pool", map[string]interface{}{
+			"instance_id": s.instanceID,
+			"in_use":      len(s.workerPool),
+		})
+		return err
+	}
+
+	s.logger.Info(ctx, "scheduler stopped gracefully", map[string]interface{}{
+		"instance_id": s.instanceID,
+	})
+
+	return nil
+}
+
+// drainWorkerPool takes every worker slot, so it only returns once all jobs
+// have released theirs, then frees them again for a later Start. Jobs queued
+// for a slot are dropped; they are still due and run on the next start.
+func (s *DefaultScheduler) drainWorkerPool(ctx context.Context) error {
+	defer s.setPending(nil)
+
+	taken := 0
+	defer func() {
+		for ; taken > 0; taken-- {
+			<-s.workerPool
+		}
+	}()
+
+	for taken < cap(s.workerPool) {
+		select {
+		case s.workerPool <- struct{}{}:
+			taken++
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
 	return nil
 }
 
+// stopping reports whether Stop has been called since the last Start.
+func (s *DefaultScheduler) stopping() bool {
+	s.mu.RLock()
+	stopChan := s.stopChan
+	s.mu.RUnlock()
+
+	select {
+	case <-stopChan:
+		return true
+	default:
+		return false
+	}
+}
+
 // Pause pauses a job.
 func (s *DefaultScheduler) Pause(jobName string) error {
 	s.mu.Lock()
@@ -226,7 +369,7 @@ func (s *DefaultScheduler) Pause(jobName string) error {
 	}
 
 	job.Metadata.Status = JobStatusPaused
-	job.Metadata.UpdatedAt = time.Now()
+	job.Metadata.UpdatedAt = s.clock.Now()
 
 	if err := s.backend.UpdateMetadata(context.Background(), jobName, &job.Metadata); err != nil {
 		return fmt.Errorf("failed to update job metadata: %w", err)
@@ -254,8 +397,8 @@ func (s *DefaultScheduler) Resume(jobName string) error {
 	}
 
 	job.Metadata.Status = JobStatusPending
-	job.Metadata.NextRunAt = job.Schedule.NextRun(time.Now())
-	job.Metadata.UpdatedAt = time.Now()
+	job.Metadata.NextRunAt = job.Schedule.NextRun(s.clock.Now())
+	job.Metadata.UpdatedAt = s.clock.Now()
 
 	if err := s.backend.UpdateMetadata(context.Background(), jobName, &job.Metadata); err != nil {
 		return fmt.Errorf("failed to update job metadata: %w", err)
@@ -321,6 +464,34 @@ func (s *DefaultScheduler) GetAllJobs() ([]*Job, error) {
 	return jobs, nil
 }
 
+// GetJobs returns jobs matching the filter from the backend, sorted by next run time.
+func (s *DefaultScheduler) GetJobs(filter JobFilter) ([]*Job, error) {
+	jobs, err := s.backend.FindJobs(context.Background(), filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// GetJobHistory returns up to limit of the most recent runs of a job, newest first.
+func (s *DefaultScheduler) GetJobHistory(jobName string, limit int) ([]*JobRun, error) {
+	s.mu.RLock()
+	_, exists := s.jobs[jobName]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrJobNotFound
+	}
+
+	runs, err := s.backend.GetJobRuns(context.Background(), jobName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job history: %w", err)
+	}
+
+	return runs, nil
+}
+
 func (s *DefaultScheduler) loadJobsFromBackend(ctx context.Context) error {
 	jobs, err := s.backend.LoadJobs(ctx)
 	if err != nil {
@@ -356,7 +527,7 @@ func (s *DefaultScheduler) run(ctx context.Context) {
 }
 
 func (s *DefaultScheduler) tick(ctx context.Context) {
-	now := time.Now()
+	now := s.clock.Now()
 
 	// Get jobs due for execution
 	dueJobs, err := s.backend.GetJobsDueForExecution(ctx, now)
@@ -368,6 +539,7 @@ func (s *DefaultScheduler) tick(ctx context.Context) {
 	}
 
 	if len(dueJobs) == 0 {
+		s.setPending(nil)
 		return
 	}
 
@@ -377,28 +549,123 @@ func (s *DefaultScheduler) tick(ctx context.Context) {
 
 	s.metrics.JobsQueued(len(dueJobs))
 
-	// Execute due jobs
-	for _, job := range dueJobs {
+	var pending []string
+
+	// Execute due jobs, serving those left waiting last tick first
+	for _, job := range s.orderByPending(dueJobs) {
+		// Don't start more jobs once Stop is draining the pool
+		if s.stopping() {
+			s.logger.Debug(ctx, "scheduler stopping, leaving remaining due jobs", nil)
+			return
+		}
+
 		// Create a copy to avoid race conditions
 		jobCopy := *job
 
+		// Persistent backends can't store handlers; use the local registration
+		if jobCopy.Handler == nil {
+			s.mu.RLock()
+			localJob, exists := s.jobs[job.Name]
+			s.mu.RUnlock()
+			if !exists {
+				s.logger.Debug(ctx, "job not registered on this instance, skipping", map[string]interface{}{
+					"job": job.Name,
+				})
+				continue
+			}
+			jobCopy.Handler = localJob.Handler
+		}
+
+		runs := 1
+		switch jobCopy.MissedRunPolicy {
+		case MissedRunPolicySkip:
+			if jobCopy.dueSlots(now, 2) > 1 {
+				s.skipMissedRuns(ctx, &jobCopy, now)
+				continue
+			}
+		case MissedRunPolicyRunAll:
+			runs = jobCopy.dueSlots(now, s.maxCatchUpRuns)
+		}
+
 		// Acquire worker slot
 		select {
 		case s.workerPool <- struct{}{}:
 			s.wg.Add(1)
-			go s.executeJob(ctx, &jobCopy)
+			go s.executeJob(ctx, &jobCopy, runs)
 		default:
+			if s.onPoolFull == PoolFullQueue && len(pending) < s.maxPendingJobs {
+				pending = append(pending, job.Name)
+				s.metrics.JobDeferred(job.Name)
+				s.logger.Debug(ctx, "worker pool full, queueing job", map[string]interface{}{
+					"job": job.Name,
+				})
+				continue
+			}
+
+			s.metrics.JobSkipped(job.Name)
 			s.logger.Warn(ctx, "worker pool full, skipping job", map[string]interface{}{
 				"job": job.Name,
 			})
 		}
 	}
+
+	s.setPending(pending)
 }
 
-func (s *DefaultScheduler) executeJob(ctx context.Context, job *Job) {
+// orderByPending puts jobs queued on a previous tick first, in FIFO order.
+// Queued jobs that are no longer due drop out.
+func (s *DefaultScheduler) orderByPending(dueJobs []*Job) []*Job {
+	s.pendingMu.Lock()
+	pending := s.pending
+	s.pendingMu.Unlock()
+
+	if len(pending) == 0 {
+		return dueJobs
+	}
+
+	byName := make(map[string]*Job, len(dueJobs))
+	for _, job := range dueJobs {
+		byName[job.Name] = job
+	}
+
+	ordered := make([]*Job, 0, len(dueJobs))
+	for _, name := range pending {
+		if job, ok := byName[name]; ok {
+			ordered = append(ordered, job)
+			delete(byName, name)
+		}
+	}
+	for _, job := range dueJobs {
+		if _, ok := byName[job.Name]; ok {
+			ordered = append(ordered, job)
+		}
+	}
+
+	return ordered
+}
+
+func (s *DefaultScheduler) setPending(pending []string) {
+	s.pendingMu.Lock()
+	s.pending = pending
+	s.pendingMu.Unlock()
+
+	s.metrics.JobsPending(len(pending))
+}
+
+// executeJob runs a job the given number of times back to back, stopping early
+// if another instance holds the lock.
+func (s *DefaultScheduler) executeJob(ctx context.Context, job *Job, runs int) {
 	defer s.wg.Done()
 	defer func() { <-s.workerPool }()
 
+	for i := 0; i < runs; i++ {
+		if err := s.executeOnce(ctx, job); err == ErrLockAcquisitionFailed {
+			return
+		}
+	}
+}
+
+func (s *DefaultScheduler) executeOnce(ctx context.Context, job *Job) error {
 	s.logger.Info(ctx, "executing job", map[string]interface{}{
 		"job":      job.Name,
 		"instance": s.instanceID,
@@ -407,7 +674,7 @@ func (s *DefaultScheduler) executeJob(ctx context.Context, job *Job) {
 	// Update job metadata
 	job.Metadata.Status = JobStatusRunning
 	job.Metadata.LockedBy = s.instanceID
-	now := time.Now()
+	now := s.clock.Now()
 	job.Metadata.LastRunAt = &now
 	job.Metadata.RunCount++
 
@@ -422,11 +689,39 @@ func (s *DefaultScheduler) executeJob(ctx context.Context, job *Job) {
 	err := s.lock.AcquireAndExecute(ctx, job, s.instanceID, s.executor)
 
 	// Update job after execution
-	s.updateJobAfterExecution(ctx, job, err)
+	s.updateJobAfterExecution(ctx, job, now, err)
+
+	return err
 }
 
-func (s *DefaultScheduler) updateJobAfterExecution(ctx context.Context, job *Job, execErr error) {
-	now := time.Now()
+// skipMissedRuns advances a job past its missed slots without executing it.
+func (s *DefaultScheduler) skipMissedRuns(ctx context.Context, job *Job, now time.Time) {
+	missedAt := job.Metadata.NextRunAt
+	job.Metadata.NextRunAt = job.Schedule.NextRun(now)
+	job.Metadata.UpdatedAt = now
+
+	s.logger.Info(ctx, "skipping missed job runs", map[string]interface{}{
+		"job":         job.Name,
+		"missed_at":   missedAt,
+		"next_run_at": job.Metadata.NextRunAt,
+	})
+
+	if err := s.backend.UpdateMetadata(ctx, job.Name, &job.Metadata); err != nil {
+		s.logger.Error(ctx, "failed to update job metadata", map[string]interface{}{
+			"job":   job.Name,
+			"error": err.Error(),
+		})
+	}
+
+	s.mu.Lock()
+	if localJob, exists := s.jobs[job.Name]; exists {
+		localJob.Metadata = job.Metadata
+	}
+	s.mu.Unlock()
+}
+
+func (s *DefaultScheduler) updateJobAfterExecution(ctx context.Context, job *Job, startedAt time.Time, execErr error) {
+	now := s.clock.Now()
 
 	if execErr != nil {
 		if execErr == ErrLockAcquisitionFailed {
@@ -441,7 +736,11 @@ func (s *DefaultScheduler) updateJobAfterExecution(ctx context.Context, job *Job
 		job.Metadata.LastError = execErr.Error()
 		job.Metadata.FailCount++
 
-		s.logger.Error(ctx, "job execution failed", map[string]interface{}{
+		msg := "job execution failed"
+		if errors.Is(execErr, ErrJobTimeout) {
+			msg = "job execution timed out"
+		}
+		s.logger.Error(ctx, msg, map[string]interface{}{
 			"job":   job.Name,
 			"error": execErr.Error(),
 		})
@@ -454,14 +753,29 @@ func (s *DefaultScheduler) updateJobAfterExecution(ctx context.Context, job *Job
 		})
 	}
 
+	s.recordRun(ctx, job, startedAt, now, execErr)
+
+	// A one-time job has had its run, whatever the outcome; without this
+	// NextRun keeps returning RunAt until the clock has moved past it
+	if once, ok := job.Schedule.(*OnceSchedule); ok {
+		once.MarkRan()
+	}
+
 	// Calculate next run time
 	nextRun := job.Schedule.NextRun(now)
 	if nextRun.IsZero() {
-		// One-time job, mark as completed
+		// One-time job, mark as completed and clear the run time so it is
+		// never due again, including after a reload
 		job.Metadata.Status = JobStatusCompleted
+		job.Metadata.NextRunAt = time.Time{}
 		s.logger.Info(ctx, "one-time job completed, will not reschedule", map[string]interface{}{
 			"job": job.Name,
 		})
+
+		if s.removeCompletedOnce && execErr == nil {
+			s.removeCompletedJob(ctx, job.Name)
+			return
+		}
 	} else {
 		// Reset status to pending for next run
 		if job.Metadata.Status == JobStatusCompleted {
@@ -489,3 +803,48 @@ func (s *DefaultScheduler) updateJobAfterExecution(ctx context.Context, job *Job
 	}
 	s.mu.Unlock()
 }
+
+// removeCompletedJob deletes a finished one-time job from the backend and
+// the local registry.
+func (s *DefaultScheduler) removeCompletedJob(ctx context.Context, jobName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.backend.DeleteJob(ctx, jobName); err != nil && !errors.Is(err, ErrJobNotFound) {
+		s.logger.Error(ctx, "failed to remove completed one-time job", map[string]interface{}{
+			"job":   jobName,
+			"error": err.Error(),
+		})
+		return
+	}
+
+	delete(s.jobs, jobName)
+	s.metrics.JobsRegistered(len(s.jobs))
+
+	s.logger.Info(ctx, "completed one-time job removed", map[string]interface{}{
+		"job": jobName,
+	})
+}
+
+// recordRun appends a run record for an execution on this instance.
+func (s *DefaultScheduler) recordRun(ctx context.Context, job *Job, startedAt, finishedAt time.Time, execErr error) {
+	run := &JobRun{
+		JobName:    job.Name,
+		InstanceID: s.instanceID,
+		StartedAt:  startedAt,
+		FinishedAt: finishedAt,
+		Duration:   finishedAt.Sub(startedAt),
+		Status:     JobStatusCompleted,
+	}
+	if execErr != nil {
+		run.Status = JobStatusFailed
+		run.Error = execErr.Error()
+	}
+
+	if err := s.backend.AppendJobRun(ctx, run, s.maxHistory); err != nil {
+		s.logger.Error(ctx, "failed to record job run", map[string]interface{}{
+			"job":   job.Name,
+			"error": err.Error(),
+		})
+	}
+}
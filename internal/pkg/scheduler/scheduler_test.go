@@ -0,0 +1,764 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a manually advanced Clock for deterministic tests.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newTestScheduler(clock Clock) (*DefaultScheduler, *MemoryBackend) {
+	backend := NewMemoryBackend()
+	logger := &NoOpLogger{}
+	metrics := &NoOpMetrics{}
+	executor := NewDefaultJobExecutor(logger, metrics)
+	lock := NewDistributedLock(backend, logger, metrics)
+
+	config := DefaultConfig()
+	config.Clock = clock
+
+	return NewScheduler(backend, executor, lock, logger, metrics, config), backend
+}
+
+func TestScheduler_IntervalJob_FakeClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	sched, _ := newTestScheduler(clock)
+
+	var runs atomic.Int32
+	job := &Job{
+		Name:     "interval",
+		Schedule: NewIntervalSchedule(time.Minute),
+		Timeout:  time.Second,
+		Handler: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	}
+	require.NoError(t, sched.Register(job))
+	assert.Equal(t, start.Add(time.Minute), job.Metadata.NextRunAt)
+
+	ctx := context.Background()
+
+	// Nothing is due before the interval elapses
+	clock.Advance(30 * time.Second)
+	sched.tick(ctx)
+	sched.wg.Wait()
+	assert.Equal(t, int32(0), runs.Load())
+
+	// The job fires once the interval has elapsed
+	clock.Advance(30 * time.Second)
+	sched.tick(ctx)
+	sched.wg.Wait()
+	assert.Equal(t, int32(1), runs.Load())
+
+	got, err := sched.GetJob("interval")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), got.Metadata.RunCount)
+	assert.Equal(t, start.Add(2*time.Minute), got.Metadata.NextRunAt)
+	require.NotNil(t, got.Metadata.LastRunAt)
+	assert.Equal(t, start.Add(time.Minute), *got.Metadata.LastRunAt)
+
+	// And again after the next interval
+	clock.Advance(time.Minute)
+	sched.tick(ctx)
+	sched.wg.Wait()
+	assert.Equal(t, int32(2), runs.Load())
+
+	got, err = sched.GetJob("interval")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), got.Metadata.RunCount)
+}
+
+func TestScheduler_BackendUsesSchedulerClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	sched, backend := newTestScheduler(clock)
+	ctx := context.Background()
+
+	require.NoError(t, sched.Register(&Job{
+		Name:     "stamped",
+		Schedule: NewIntervalSchedule(time.Minute),
+		Timeout:  time.Second,
+		Handler:  func(ctx context.Context) error { return nil },
+	}))
+	stored, err := backend.LoadJob(ctx, "stamped")
+	require.NoError(t, err)
+	assert.Equal(t, start, stored.Metadata.CreatedAt)
+	assert.Equal(t, start, stored.Metadata.UpdatedAt)
+
+	// Lock expiry follows the clock too
+	acquired, err := backend.AcquireLock(ctx, "stamped", time.Minute, "a")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	acquired, err = backend.AcquireLock(ctx, "stamped", time.Minute, "b")
+	require.NoError(t, err)
+	assert.False(t, acquired, "the lock has not expired yet")
+
+	clock.Advance(2 * time.Minute)
+	acquired, err = backend.AcquireLock(ctx, "stamped", time.Minute, "b")
+	require.NoError(t, err)
+	assert.True(t, acquired, "the lock expired on the scheduler clock")
+}
+
+func TestScheduler_DefaultsToRealClock(t *testing.T) {
+	sched := NewScheduler(NewMemoryBackend(), nil, nil, nil, nil, &Config{
+		TickInterval:  time.Second,
+		MaxConcurrent: 1,
+	})
+
+	assert.IsType(t, RealClock{}, sched.clock)
+}
+
+func TestScheduler_GetJobHistory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	backend := NewMemoryBackend()
+	logger := &NoOpLogger{}
+	metrics := &NoOpMetrics{}
+
+	config := DefaultConfig()
+	config.Clock = clock
+	config.MaxJobHistory = 3
+	sched := NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+		NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+
+	var runs atomic.Int32
+	job := &Job{
+		Name:        "history",
+		Schedule:    NewIntervalSchedule(time.Minute),
+		Timeout:     time.Second,
+		RetryPolicy: &RetryPolicy{MaxRetries: 0},
+		Handler: func(ctx context.Context) error {
+			// Every second run fails
+			if runs.Add(1)%2 == 0 {
+				return errors.New("boom")
+			}
+			return nil
+		},
+	}
+	require.NoError(t, sched.Register(job))
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		clock.Advance(time.Minute)
+		sched.tick(ctx)
+		sched.wg.Wait()
+	}
+	require.Equal(t, int32(4), runs.Load())
+
+	// Capped at MaxJobHistory, newest first
+	history, err := sched.GetJobHistory("history", 10)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+
+	assert.Equal(t, start.Add(4*time.Minute), history[0].StartedAt)
+	assert.Equal(t, JobStatusFailed, history[0].Status)
+	assert.Contains(t, history[0].Error, "boom")
+	assert.Equal(t, start.Add(3*time.Minute), history[1].StartedAt)
+	assert.Equal(t, JobStatusCompleted, history[1].Status)
+	assert.Empty(t, history[1].Error)
+	assert.Equal(t, start.Add(2*time.Minute), history[2].StartedAt)
+	assert.Equal(t, "history", history[2].JobName)
+
+	// Limit returns only the most recent runs
+	history, err = sched.GetJobHistory("history", 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, start.Add(4*time.Minute), history[0].StartedAt)
+
+	_, err = sched.GetJobHistory("missing", 10)
+	assert.ErrorIs(t, err, ErrJobNotFound)
+}
+
+func TestIntervalSchedule_Jitter(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	plain := NewIntervalSchedule(time.Minute)
+	assert.Equal(t, from.Add(time.Minute), plain.NextRun(from))
+	assert.Equal(t, "every 1m0s", plain.String())
+
+	jittered := NewIntervalScheduleWithJitter(time.Minute, 10*time.Second)
+	assert.Equal(t, "every 1m0s (jitter 10s)", jittered.String())
+
+	seen := make(map[time.Time]struct{})
+	for range 50 {
+		next := jittered.NextRun(from)
+		assert.False(t, next.Before(from.Add(time.Minute)))
+		assert.True(t, next.Before(from.Add(time.Minute+10*time.Second)))
+		seen[next] = struct{}{}
+	}
+	// Jitter is recomputed on every call
+	assert.Greater(t, len(seen), 1)
+}
+
+func TestScheduler_MissedRunPolicy(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		policy   MissedRunPolicy
+		wantRuns int32
+	}{
+		{"default runs once", "", 1},
+		{"run once", MissedRunPolicyRunOnce, 1},
+		{"skip", MissedRunPolicySkip, 0},
+		{"run all capped", MissedRunPolicyRunAll, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock(start)
+			backend := NewMemoryBackend()
+			logger := &NoOpLogger{}
+			metrics := &NoOpMetrics{}
+
+			config := DefaultConfig()
+			config.Clock = clock
+			config.MaxCatchUpRuns = 3
+			sched := NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+				NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+
+			var runs atomic.Int32
+			job := &Job{
+				Name:            "catch-up",
+				Schedule:        NewIntervalSchedule(time.Minute),
+				Timeout:         time.Second,
+				MissedRunPolicy: tt.policy,
+				Handler: func(ctx context.Context) error {
+					runs.Add(1)
+					return nil
+				},
+			}
+			require.NoError(t, sched.Register(job))
+
+			// Five slots are missed while the scheduler is down
+			clock.Advance(5*time.Minute + 30*time.Second)
+			sched.tick(context.Background())
+			sched.wg.Wait()
+
+			assert.Equal(t, tt.wantRuns, runs.Load())
+
+			got, err := sched.GetJob("catch-up")
+			require.NoError(t, err)
+			assert.Equal(t, clock.Now().Add(time.Minute), got.Metadata.NextRunAt)
+		})
+	}
+}
+
+func TestJob_DueSlots(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	job := &Job{
+		Schedule: NewIntervalSchedule(time.Minute),
+		Metadata: JobMetadata{NextRunAt: start},
+	}
+
+	assert.Equal(t, 0, job.dueSlots(start.Add(-time.Second), 10))
+	assert.Equal(t, 1, job.dueSlots(start.Add(30*time.Second), 10))
+	assert.Equal(t, 4, job.dueSlots(start.Add(3*time.Minute), 10))
+	assert.Equal(t, 2, job.dueSlots(start.Add(time.Hour), 2))
+
+	once := &Job{
+		Schedule: NewOnceSchedule(start),
+		Metadata: JobMetadata{NextRunAt: start},
+	}
+	assert.Equal(t, 1, once.dueSlots(start.Add(time.Hour), 10))
+}
+
+func TestJob_ValidateMissedRunPolicy(t *testing.T) {
+	job := &Job{
+		Name:            "job",
+		Schedule:        NewIntervalSchedule(time.Minute),
+		Timeout:         time.Second,
+		Handler:         func(ctx context.Context) error { return nil },
+		MissedRunPolicy: "sometimes",
+	}
+	assert.ErrorIs(t, job.Validate(), ErrInvalidMissedRunPolicy)
+
+	job.MissedRunPolicy = MissedRunPolicySkip
+	assert.NoError(t, job.Validate())
+}
+
+func TestUnmarshalSchedule_RoundTrip(t *testing.T) {
+	cron, err := NewCronSchedule("*/5 * * * *")
+	require.NoError(t, err)
+	once := NewOnceSchedule(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	once.MarkRan()
+
+	schedules := []Schedule{
+		cron,
+		NewIntervalSchedule(time.Minute),
+		NewIntervalScheduleWithJitter(time.Minute, 5*time.Second),
+		once,
+	}
+
+	for _, schedule := range schedules {
+		data, err := json.Marshal(schedule)
+		require.NoError(t, err)
+
+		got, err := UnmarshalSchedule(data)
+		require.NoError(t, err)
+		assert.Equal(t, schedule.String(), got.String())
+		assert.Equal(t, schedule.Type(), got.Type())
+	}
+
+	_, err = UnmarshalSchedule([]byte(`{"type":"weekly"}`))
+	assert.Error(t, err)
+}
+
+// poolMetrics counts worker pool saturation events.
+type poolMetrics struct {
+	NoOpMetrics
+	skipped  atomic.Int32
+	deferred atomic.Int32
+}
+
+func (m *poolMetrics) JobSkipped(jobName string)  { m.skipped.Add(1) }
+func (m *poolMetrics) JobDeferred(jobName string) { m.deferred.Add(1) }
+
+func TestScheduler_PoolFull(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	setup := func(policy PoolFullPolicy) (*DefaultScheduler, *fakeClock, *poolMetrics, chan string, chan struct{}) {
+		clock := newFakeClock(start)
+		backend := NewMemoryBackend()
+		logger := &NoOpLogger{}
+		metrics := &poolMetrics{}
+
+		config := DefaultConfig()
+		config.Clock = clock
+		config.MaxConcurrent = 1
+		config.OnPoolFull = policy
+		sched := NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+			NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+
+		started := make(chan string, 10)
+		release := make(chan struct{})
+		for _, name := range []string{"a", "b", "c"} {
+			require.NoError(t, sched.Register(&Job{
+				Name:     name,
+				Schedule: NewIntervalSchedule(time.Hour),
+				Timeout:  5 * time.Second,
+				Handler: func(ctx context.Context) error {
+					started <- name
+					<-release
+					return nil
+				},
+			}))
+		}
+
+		clock.Advance(time.Hour)
+		return sched, clock, metrics, started, release
+	}
+
+	t.Run("queue serves waiting jobs in FIFO order", func(t *testing.T) {
+		sched, _, metrics, started, release := setup(PoolFullQueue)
+		ctx := context.Background()
+
+		sched.tick(ctx)
+		first := <-started
+
+		sched.pendingMu.Lock()
+		queued := append([]string(nil), sched.pending...)
+		sched.pendingMu.Unlock()
+		require.Len(t, queued, 2)
+		assert.NotContains(t, queued, first)
+		assert.Equal(t, int32(2), metrics.deferred.Load())
+		assert.Equal(t, int32(0), metrics.skipped.Load())
+
+		release <- struct{}{}
+		sched.wg.Wait()
+
+		// The oldest queued job gets the free slot
+		sched.tick(ctx)
+		assert.Equal(t, queued[0], <-started)
+
+		sched.pendingMu.Lock()
+		assert.Equal(t, queued[1:], sched.pending)
+		sched.pendingMu.Unlock()
+
+		release <- struct{}{}
+		sched.wg.Wait()
+
+		sched.tick(ctx)
+		assert.Equal(t, queued[1], <-started)
+		release <- struct{}{}
+		sched.wg.Wait()
+	})
+
+	t.Run("skip drops jobs for the tick", func(t *testing.T) {
+		sched, _, metrics, started, release := setup(PoolFullSkip)
+
+		sched.tick(context.Background())
+		<-started
+
+		sched.pendingMu.Lock()
+		assert.Empty(t, sched.pending)
+		sched.pendingMu.Unlock()
+		assert.Equal(t, int32(2), metrics.skipped.Load())
+		assert.Equal(t, int32(0), metrics.deferred.Load())
+
+		release <- struct{}{}
+		sched.wg.Wait()
+	})
+}
+
+func TestScheduler_GetJobs_FilterAndSort(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sched, _ := newTestScheduler(newFakeClock(start))
+
+	noop := func(ctx context.Context) error { return nil }
+	for _, job := range []*Job{
+		{Name: "report:daily", Schedule: NewIntervalSchedule(24 * time.Hour)},
+		{Name: "report:hourly", Schedule: NewIntervalSchedule(time.Hour)},
+		{Name: "report:minutely", Schedule: NewIntervalSchedule(time.Minute)},
+		{Name: "cleanup", Schedule: NewIntervalSchedule(30 * time.Minute)},
+	} {
+		job.Timeout = time.Second
+		job.Handler = noop
+		require.NoError(t, sched.Register(job))
+	}
+	require.NoError(t, sched.Pause("report:hourly"))
+
+	names := func(jobs []*Job) []string {
+		out := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			out = append(out, job.Name)
+		}
+		return out
+	}
+
+	// No filter returns everything sorted by next run
+	jobs, err := sched.GetJobs(JobFilter{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"report:minutely", "cleanup", "report:hourly", "report:daily"}, names(jobs))
+
+	jobs, err = sched.GetJobs(JobFilter{NamePrefix: "report:"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"report:minutely", "report:hourly", "report:daily"}, names(jobs))
+
+	jobs, err = sched.GetJobs(JobFilter{Statuses: []JobStatus{JobStatusPaused}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"report:hourly"}, names(jobs))
+
+	jobs, err = sched.GetJobs(JobFilter{DueBefore: start.Add(time.Hour)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"report:minutely", "cleanup"}, names(jobs))
+
+	jobs, err = sched.GetJobs(JobFilter{
+		NamePrefix: "report:",
+		Statuses:   []JobStatus{JobStatusPending},
+		DueBefore:  start.Add(2 * time.Hour),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"report:minutely"}, names(jobs))
+}
+
+func TestJob_JSONRoundTrip(t *testing.T) {
+	job := &Job{
+		Name:            "roundtrip",
+		Schedule:        NewIntervalSchedule(time.Minute),
+		Timeout:         time.Second,
+		MissedRunPolicy: MissedRunPolicySkip,
+		Metadata:        JobMetadata{Status: JobStatusPending, RunCount: 3},
+	}
+
+	data, err := json.Marshal(job)
+	require.NoError(t, err)
+
+	var got Job
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, "roundtrip", got.Name)
+	require.NotNil(t, got.Schedule)
+	assert.Equal(t, job.Schedule.String(), got.Schedule.String())
+	assert.Equal(t, MissedRunPolicySkip, got.MissedRunPolicy)
+	assert.Equal(t, int64(3), got.Metadata.RunCount)
+}
+
+func TestScheduler_JobTimeoutCancelsHandler(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	sched, _ := newTestScheduler(clock)
+
+	cancelled := make(chan error, 1)
+	job := &Job{
+		Name:        "slow",
+		Schedule:    NewIntervalSchedule(time.Minute),
+		Timeout:     50 * time.Millisecond,
+		RetryPolicy: &RetryPolicy{MaxRetries: 0},
+		Handler: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				cancelled <- ctx.Err()
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		},
+	}
+	require.NoError(t, sched.Register(job))
+
+	clock.Advance(time.Minute)
+	sched.tick(context.Background())
+	sched.wg.Wait()
+
+	// The handler saw its context cancelled by the deadline
+	select {
+	case err := <-cancelled:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("handler context was not cancelled")
+	}
+
+	got, err := sched.GetJob("slow")
+	require.NoError(t, err)
+	assert.Equal(t, JobStatusFailed, got.Metadata.Status)
+	assert.Equal(t, int64(1), got.Metadata.FailCount)
+	assert.Contains(t, got.Metadata.LastError, ErrJobTimeout.Error())
+
+	history, err := sched.GetJobHistory("slow", 1)
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, JobStatusFailed, history[0].Status)
+}
+
+func TestDefaultJobExecutor_ReturnsErrJobTimeout(t *testing.T) {
+	executor := NewDefaultJobExecutor(&NoOpLogger{}, &NoOpMetrics{})
+
+	err := executor.Execute(context.Background(), &Job{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Handler: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	assert.ErrorIs(t, err, ErrJobTimeout)
+}
+
+func TestScheduler_StopDrainsWorkerPool(t *testing.T) {
+	baseline := runtime.NumGoroutine()
+
+	backend := NewMemoryBackend()
+	logger := &NoOpLogger{}
+	metrics := &NoOpMetrics{}
+
+	config := DefaultConfig()
+	config.TickInterval = time.Millisecond
+	config.MaxConcurrent = 5
+	sched := NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+		NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+
+	var started, running atomic.Int32
+	for i := 0; i < 20; i++ {
+		require.NoError(t, sched.Register(&Job{
+			Name:     fmt.Sprintf("job-%d", i),
+			Schedule: NewIntervalSchedule(time.Millisecond),
+			Timeout:  5 * time.Second,
+			Handler: func(ctx context.Context) error {
+				started.Add(1)
+				running.Add(1)
+				defer running.Add(-1)
+				time.Sleep(20 * time.Millisecond)
+				return nil
+			},
+		}))
+	}
+
+	require.NoError(t, sched.Start(context.Background()))
+
+	// Stop while the pool is full and ticks keep finding due jobs
+	require.Eventually(t, func() bool { return started.Load() >= 10 }, 5*time.Second, time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, sched.Stop(ctx))
+
+	assert.Zero(t, running.Load(), "Stop returned with jobs still running")
+	assert.Zero(t, len(sched.workerPool), "worker pool slots were not released")
+	sched.pendingMu.Lock()
+	assert.Empty(t, sched.pending)
+	sched.pendingMu.Unlock()
+
+	// No job starts after Stop returns
+	startedAtStop := started.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, startedAtStop, started.Load())
+
+	// Every goroutine the scheduler started has exited. It's polled by
+	// hand because assert.Eventually runs its condition on a goroutine.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > baseline && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), baseline, "goroutines leaked")
+}
+
+func TestScheduler_StopTimesOutWhileJobsRun(t *testing.T) {
+	backend := NewMemoryBackend()
+	logger := &NoOpLogger{}
+	metrics := &NoOpMetrics{}
+
+	config := DefaultConfig()
+	config.TickInterval = time.Millisecond
+	config.MaxConcurrent = 1
+	sched := NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+		NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	require.NoError(t, sched.Register(&Job{
+		Name:     "slow",
+		Schedule: NewIntervalSchedule(time.Millisecond),
+		Timeout:  5 * time.Second,
+		Handler: func(ctx context.Context) error {
+			select {
+			case started <- struct{}{}:
+			default:
+			}
+			<-release
+			return nil
+		},
+	}))
+
+	require.NoError(t, sched.Start(context.Background()))
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sched.Stop(ctx), context.DeadlineExceeded)
+
+	close(release)
+	sched.wg.Wait()
+	assert.Zero(t, len(sched.workerPool))
+}
+
+func TestScheduler_OnceJobCompletion(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	for _, remove := range []bool{false, true} {
+		t.Run(fmt.Sprintf("remove=%v", remove), func(t *testing.T) {
+			clock := newFakeClock(start)
+			backend := NewMemoryBackend()
+			newScheduler := func() *DefaultScheduler {
+				logger, metrics := &NoOpLogger{}, &NoOpMetrics{}
+				config := DefaultConfig()
+				config.Clock = clock
+				config.RemoveCompletedOnceJobs = remove
+				return NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+					NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+			}
+
+			var runs atomic.Int32
+			handler := func(ctx context.Context) error {
+				runs.Add(1)
+				return nil
+			}
+
+			sched := newScheduler()
+			require.NoError(t, sched.Register(&Job{
+				Name:     "once",
+				Schedule: NewOnceSchedule(start.Add(time.Minute)),
+				Timeout:  time.Second,
+				Handler:  handler,
+			}))
+
+			clock.Advance(time.Minute)
+			sched.tick(ctx)
+			sched.wg.Wait()
+			require.Equal(t, int32(1), runs.Load())
+
+			clock.Advance(time.Minute)
+			sched.tick(ctx)
+			sched.wg.Wait()
+			assert.Equal(t, int32(1), runs.Load(), "a completed once-job must not run again")
+
+			stored, err := backend.LoadJob(ctx, "once")
+			if remove {
+				assert.ErrorIs(t, err, ErrJobNotFound)
+				_, err = sched.GetJob("once")
+				assert.ErrorIs(t, err, ErrJobNotFound)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, JobStatusCompleted, stored.Metadata.Status)
+				assert.True(t, stored.Metadata.NextRunAt.IsZero())
+			}
+
+			// A restarted scheduler on the same backend doesn't run it either
+			reloaded := newScheduler()
+			require.NoError(t, reloaded.loadJobsFromBackend(ctx))
+			if job, ok := reloaded.jobs["once"]; ok {
+				job.Handler = handler
+			}
+			assert.Equal(t, !remove, len(reloaded.jobs) == 1)
+
+			clock.Advance(time.Minute)
+			reloaded.tick(ctx)
+			reloaded.wg.Wait()
+			assert.Equal(t, int32(1), runs.Load())
+		})
+	}
+}
+
+func TestScheduler_FailedOnceJobIsKept(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+	backend := NewMemoryBackend()
+	logger, metrics := &NoOpLogger{}, &NoOpMetrics{}
+	config := DefaultConfig()
+	config.Clock = clock
+	config.RemoveCompletedOnceJobs = true
+	sched := NewScheduler(backend, NewDefaultJobExecutor(logger, metrics),
+		NewDistributedLock(backend, logger, metrics), logger, metrics, config)
+
+	require.NoError(t, sched.Register(&Job{
+		Name:        "once",
+		Schedule:    NewOnceSchedule(start.Add(time.Minute)),
+		Timeout:     time.Second,
+		RetryPolicy: &RetryPolicy{MaxRetries: 0},
+		Handler: func(ctx context.Context) error {
+			return errors.New("boom")
+		},
+	}))
+
+	clock.Advance(time.Minute)
+	sched.tick(context.Background())
+	sched.wg.Wait()
+
+	stored, err := backend.LoadJob(context.Background(), "once")
+	require.NoError(t, err)
+	assert.Contains(t, stored.Metadata.LastError, "boom")
+	assert.True(t, stored.Metadata.NextRunAt.IsZero())
+}
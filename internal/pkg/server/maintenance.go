@@ -0,0 +1,194 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+// MaintenanceAdminPath is where the maintenance toggle is registered
+const MaintenanceAdminPath = "/admin/maintenance"
+
+// RouteMatcher reports whether a request is affected by maintenance mode
+type RouteMatcher func(method, path string) bool
+
+// WriteRoutes matches every request that may change state, i.e. anything but
+// GET, HEAD and OPTIONS, so reads and health checks keep working
+func WriteRoutes(method, path string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	return true
+}
+
+// MatchRoute matches requests under pathPrefix using one of methods
+// No methods matches every method.
+func MatchRoute(pathPrefix string, methods ...string) RouteMatcher {
+	return func(method, path string) bool {
+		if !strings.HasPrefix(path, pathPrefix) {
+			return false
+		}
+		if len(methods) == 0 {
+			return true
+		}
+		for _, m := range methods {
+			if m == method {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MaintenanceConfig configures maintenance mode
+type MaintenanceConfig struct {
+	// Enabled starts in maintenance mode
+	Enabled bool
+	// RetryAfter is sent as Retry-After on rejected requests (0 = omitted)
+	RetryAfter time.Duration
+	// Matchers select the rejected requests; a request matching any is
+	// rejected. Defaults to WriteRoutes.
+	Matchers []RouteMatcher
+}
+
+// DefaultMaintenanceConfig returns a disabled maintenance mode that rejects writes
+func DefaultMaintenanceConfig() MaintenanceConfig {
+	return MaintenanceConfig{
+		RetryAfter: 2 * time.Minute,
+		Matchers:   []RouteMatcher{WriteRoutes},
+	}
+}
+
+// MaintenanceMode rejects matching requests with 503 while enabled
+// It can be toggled at runtime and is safe for concurrent use.
+type MaintenanceMode struct {
+	enabled    atomic.Bool
+	retryAfter time.Duration
+	matchers   []RouteMatcher
+	exempt     map[string]bool
+}
+
+// NewMaintenanceMode creates a maintenance mode from config
+func NewMaintenanceMode(cfg MaintenanceConfig) *MaintenanceMode {
+	if len(cfg.Matchers) == 0 {
+		cfg.Matchers = DefaultMaintenanceConfig().Matchers
+	}
+
+	m := &MaintenanceMode{
+		retryAfter: cfg.RetryAfter,
+		matchers:   cfg.Matchers,
+		exempt:     make(map[string]bool),
+	}
+	m.enabled.Store(cfg.Enabled)
+	return m
+}
+
+// Enable turns maintenance mode on
+func (m *MaintenanceMode) Enable() {
+	m.enabled.Store(true)
+}
+
+// Disable turns maintenance mode off
+func (m *MaintenanceMode) Disable() {
+	m.enabled.Store(false)
+}
+
+// Enabled reports whether maintenance mode is on
+func (m *MaintenanceMode) Enabled() bool {
+	return m.enabled.Load()
+}
+
+// Middleware short-circuits matching requests with 503 and Retry-After
+// Routes are matched on the registered path, so it must run after routing,
+// i.e. be added with Use rather than Pre.
+func (m *MaintenanceMode) Middleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !m.Enabled() || !m.matches(c) {
+				return next(c)
+			}
+
+			if m.retryAfter > 0 {
+				seconds := int((m.retryAfter + time.Second - 1) / time.Second)
+				c.Response().Header().Set("Retry-After", strconv.Itoa(seconds))
+			}
+			return ErrorResponse(c, http.StatusServiceUnavailable, nil, "Service is under maintenance")
+		}
+	}
+}
+
+// matches reports whether the request is rejected in maintenance mode
+func (m *MaintenanceMode) matches(c echo.Context) bool {
+	path := c.Path()
+	if path == "" {
+		path = c.Request().URL.Path
+	}
+	if m.exempt[path] {
+		return false
+	}
+
+	method := c.Request().Method
+	for _, match := range m.matchers {
+		if match(method, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// maintenanceRequest is the body of a maintenance toggle request
+type maintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// RegisterRoutes registers GET and PUT MaintenanceAdminPath on g to read and
+// toggle maintenance mode. The routes are never rejected by Middleware.
+// They change how the whole service behaves, so guard them with middlewares.
+func (m *MaintenanceMode) RegisterRoutes(g *echo.Group, middlewares ...echo.MiddlewareFunc) {
+	routes := []*echo.Route{
+		g.GET(MaintenanceAdminPath, m.statusHandler, middlewares...),
+		g.PUT(MaintenanceAdminPath, m.toggleHandler, middlewares...),
+	}
+	for _, route := range routes {
+		m.exempt[route.Path] = true
+	}
+}
+
+// statusHandler reports whether maintenance mode is on
+func (m *MaintenanceMode) statusHandler(c echo.Context) error {
+	return SuccessResponse(c, http.StatusOK, map[string]bool{"enabled": m.Enabled()}, "Maintenance status")
+}
+
+// toggleHandler turns maintenance mode on or off
+func (m *MaintenanceMode) toggleHandler(c echo.Context) error {
+	var req maintenanceRequest
+	if err := c.Bind(&req); err != nil || req.Enabled == nil {
+		return ErrorResponse(c, http.StatusBadRequest, nil, "enabled is required")
+	}
+
+	if *req.Enabled {
+		m.Enable()
+	} else {
+		m.Disable()
+	}
+	return SuccessResponse(c, http.StatusOK, map[string]bool{"enabled": m.Enabled()}, "Maintenance status updated")
+}
+
+// AdminTokenMiddleware allows only requests carrying token in X-Admin-Token
+func AdminTokenMiddleware(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got := c.Request().Header.Get("X-Admin-Token")
+			if token == "" || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return ErrorResponse(c, http.StatusUnauthorized, nil, "Invalid admin token")
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/config"
+	"myapp/internal/pkg/logger"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newMaintenanceTestServer(maintenance config.MaintenanceConfig) *Server {
+	cfg := &config.Config{}
+	cfg.Server.Maintenance = maintenance
+	s := NewEchoServer(cfg, &logger.Logger{Logger: zap.NewNop()})
+
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	s.GetEcho().GET("/api/v1/items", ok)
+	s.GetEcho().POST("/api/v1/items", ok)
+	s.GetEcho().DELETE("/api/v1/items/:id", ok)
+	return s
+}
+
+func serve(s *Server, method, target, body string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	s.GetEcho().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMaintenance_RejectsWritesOnly(t *testing.T) {
+	s := newMaintenanceTestServer(config.MaintenanceConfig{Enabled: true, RetryAfterSec: 120})
+
+	for _, tc := range []struct{ method, target string }{
+		{http.MethodPost, "/api/v1/items"},
+		{http.MethodDelete, "/api/v1/items/1"},
+	} {
+		rec := serve(s, tc.method, tc.target, "", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, "%s %s", tc.method, tc.target)
+		assert.Equal(t, "120", rec.Header().Get("Retry-After"))
+	}
+
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/api/v1/items", "", nil).Code)
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodGet, "/health", "", nil).Code)
+}
+
+func TestMaintenance_Disabled(t *testing.T) {
+	s := newMaintenanceTestServer(config.MaintenanceConfig{})
+
+	rec := serve(s, http.MethodPost, "/api/v1/items", "", nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("Retry-After"))
+}
+
+func TestMaintenance_AdminToggle(t *testing.T) {
+	s := newMaintenanceTestServer(config.MaintenanceConfig{AdminToken: "secret"})
+	admin := map[string]string{"X-Admin-Token": "secret"}
+
+	rec := serve(s, http.MethodPut, MaintenanceAdminPath, `{"enabled":true}`, map[string]string{"X-Admin-Token": "wrong"})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.False(t, s.Maintenance().Enabled())
+
+	rec = serve(s, http.MethodPut, MaintenanceAdminPath, `{"enabled":true}`, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, s.Maintenance().Enabled())
+	assert.Equal(t, http.StatusServiceUnavailable, serve(s, http.MethodPost, "/api/v1/items", "", nil).Code)
+
+	// The toggle itself stays reachable so maintenance can be turned off
+	rec = serve(s, http.MethodPut, MaintenanceAdminPath, `{"enabled":false}`, admin)
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, s.Maintenance().Enabled())
+	assert.Equal(t, http.StatusOK, serve(s, http.MethodPost, "/api/v1/items", "", nil).Code)
+
+	rec = serve(s, http.MethodPut, MaintenanceAdminPath, `{}`, admin)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestMaintenance_NoAdminTokenNoToggle(t *testing.T) {
+	s := newMaintenanceTestServer(config.MaintenanceConfig{})
+
+	rec := serve(s, http.MethodPut, MaintenanceAdminPath, `{"enabled":true}`, nil)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMaintenance_CustomMatchers(t *testing.T) {
+	m := NewMaintenanceMode(MaintenanceConfig{
+		Enabled:    true,
+		RetryAfter: 1500 * time.Millisecond,
+		Matchers:   []RouteMatcher{MatchRoute("/api/v1/items", http.MethodPost)},
+	})
+	e := echo.New()
+	e.Use(m.Middleware())
+	ok := func(c echo.Context) error { return c.NoContent(http.StatusOK) }
+	e.POST("/api/v1/items", ok)
+	e.POST("/api/v1/orders", ok)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/items", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	// Retry-After is rounded up to whole seconds
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"))
+
+	rec = httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+)
+
+// PaginationConfig holds the defaults and bounds for list endpoints
+type PaginationConfig struct {
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// DefaultPaginationConfig returns pagination bounds suitable for most endpoints
+func DefaultPaginationConfig() PaginationConfig {
+	return PaginationConfig{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	}
+}
+
+// Pagination is a normalized page request
+type Pagination struct {
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset"`
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ParsePagination reads page, limit, offset and cursor query parameters
+// Limit is clamped to cfg.MaxLimit; offset takes precedence over page when both are set
+func ParsePagination(c echo.Context, cfg PaginationConfig) (Pagination, error) {
+	if cfg.DefaultLimit <= 0 {
+		cfg.DefaultLimit = DefaultPaginationConfig().DefaultLimit
+	}
+	if cfg.MaxLimit <= 0 {
+		cfg.MaxLimit = DefaultPaginationConfig().MaxLimit
+	}
+
+	p := Pagination{
+		Page:   1,
+		Limit:  cfg.DefaultLimit,
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	if v := c.QueryParam("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 {
+			return Pagination{}, fmt.Errorf("invalid limit %q: must be a positive integer", v)
+		}
+		p.Limit = limit
+	}
+	if p.Limit > cfg.MaxLimit {
+		p.Limit = cfg.MaxLimit
+	}
+
+	if v := c.QueryParam("page"); v != "" {
+		page, err := strconv.Atoi(v)
+		if err != nil || page < 1 {
+			return Pagination{}, fmt.Errorf("invalid page %q: must be a positive integer", v)
+		}
+		p.Page = page
+	}
+	p.Offset = (p.Page - 1) * p.Limit
+
+	if v := c.QueryParam("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return Pagination{}, fmt.Errorf("invalid offset %q: must be a non-negative integer", v)
+		}
+		p.Offset = offset
+		p.Page = offset/p.Limit + 1
+	}
+
+	return p, nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPaginationContext(query string) echo.Context {
+	req := httptest.NewRequest(http.MethodGet, "/items?"+query, nil)
+	return echo.New().NewContext(req, httptest.NewRecorder())
+}
+
+func TestParsePagination_Defaults(t *testing.T) {
+	p, err := ParsePagination(newPaginationContext(""), DefaultPaginationConfig())
+	require.NoError(t, err)
+	assert.Equal(t, Pagination{Page: 1, Limit: 20, Offset: 0}, p)
+}
+
+func TestParsePagination_ZeroConfigUsesDefaults(t *testing.T) {
+	p, err := ParsePagination(newPaginationContext("limit=500"), PaginationConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 100, p.Limit)
+}
+
+func TestParsePagination_PageToOffset(t *testing.T) {
+	p, err := ParsePagination(newPaginationContext("page=3&limit=10"), DefaultPaginationConfig())
+	require.NoError(t, err)
+	assert.Equal(t, Pagination{Page: 3, Limit: 10, Offset: 20}, p)
+}
+
+func TestParsePagination_OffsetOverridesPage(t *testing.T) {
+	p, err := ParsePagination(newPaginationContext("page=5&offset=25&limit=10"), DefaultPaginationConfig())
+	require.NoError(t, err)
+	assert.Equal(t, 25, p.Offset)
+	assert.Equal(t, 3, p.Page)
+}
+
+func TestParsePagination_ClampsLimit(t *testing.T) {
+	cfg := PaginationConfig{DefaultLimit: 10, MaxLimit: 50}
+
+	p, err := ParsePagination(newPaginationContext("limit=1000&page=2"), cfg)
+	require.NoError(t, err)
+	assert.Equal(t, 50, p.Limit)
+	assert.Equal(t, 50, p.Offset)
+}
+
+func TestParsePagination_Cursor(t *testing.T) {
+	p, err := ParsePagination(newPaginationContext("cursor=abc123"), DefaultPaginationConfig())
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", p.Cursor)
+}
+
+func TestParsePagination_InvalidInputs(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"non-numeric limit", "limit=ten", "invalid limit"},
+		{"zero limit", "limit=0", "invalid limit"},
+		{"negative limit", "limit=-5", "invalid limit"},
+		{"non-numeric page", "page=first", "invalid page"},
+		{"zero page", "page=0", "invalid page"},
+		{"negative offset", "offset=-1", "invalid offset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePagination(newPaginationContext(tt.query), DefaultPaginationConfig())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
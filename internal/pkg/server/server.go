@@ -16,9 +16,10 @@ import (
 
 // Server wraps Echo server
 type Server struct {
-	echo   *echo.Echo
-	config *config.Config
-	logger *logger.Logger
+	echo        *echo.Echo
+	config      *config.Config
+	logger      *logger.Logger
+	maintenance *MaintenanceMode
 }
 
 // NewEchoServer creates a new Echo server instance
@@ -36,18 +37,34 @@ func NewEchoServer(cfg *config.Config, log *logger.Logger) *Server {
 	// Add middleware
 	setupMiddleware(e, log)
 
+	// Maintenance mode, toggled by config or at runtime
+	maintenance := newMaintenanceMode(cfg.Server.Maintenance)
+	e.Use(maintenance.Middleware())
+	if token := cfg.Server.Maintenance.AdminToken; token != "" {
+		maintenance.RegisterRoutes(e.Group(""), AdminTokenMiddleware(token))
+	}
+
 	// Health check endpoint
 	e.GET("/health", healthCheckHandler)
 
-	log.Info("Echo server initialized")
+	log.Info("Echo server initialized", zap.Bool("maintenance", maintenance.Enabled()))
 
 	return &Server{
-		echo:   e,
-		config: cfg,
-		logger: log,
+		echo:        e,
+		config:      cfg,
+		logger:      log,
+		maintenance: maintenance,
 	}
 }
 
+// newMaintenanceMode creates the maintenance mode from the server config
+func newMaintenanceMode(cfg config.MaintenanceConfig) *MaintenanceMode {
+	maintenanceCfg := DefaultMaintenanceConfig()
+	maintenanceCfg.Enabled = cfg.Enabled
+	maintenanceCfg.RetryAfter = time.Duration(cfg.RetryAfterSec) * time.Second
+	return NewMaintenanceMode(maintenanceCfg)
+}
+
 // setupMiddleware configures Echo middleware
 func setupMiddleware(e *echo.Echo, log *logger.Logger) {
 	// Recover middleware
@@ -115,6 +132,11 @@ func (s *Server) GetEcho() *echo.Echo {
 	return s.echo
 }
 
+// Maintenance returns the server's maintenance mode
+func (s *Server) Maintenance() *MaintenanceMode {
+	return s.maintenance
+}
+
 // Start starts the HTTP server
 func (s *Server) Start() error {
 	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
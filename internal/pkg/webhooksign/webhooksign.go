@@ -0,0 +1,167 @@
+// Package webhooksign signs and verifies webhook requests with HMAC-SHA256.
+//
+// The signature travels in the Header as "t=<unix seconds>,v1=<hex digest>",
+// where the digest covers "<t>.<body>". Binding the timestamp into the MAC lets
+// receivers reject replayed requests; several v1 entries may be sent while a
+// secret is being rotated.
+package webhooksign
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header is the HTTP header carrying the signature
+const Header = "X-Webhook-Signature"
+
+// DefaultTolerance is how far a timestamp may be from now before Verify rejects it
+const DefaultTolerance = 5 * time.Minute
+
+var (
+	// ErrMalformedSignature indicates the header value can't be parsed
+	ErrMalformedSignature = errors.New("webhooksign: malformed signature")
+
+	// ErrInvalidSignature indicates no signature matches the body and secret
+	ErrInvalidSignature = errors.New("webhooksign: signature mismatch")
+
+	// ErrStaleTimestamp indicates the timestamp is outside the tolerance,
+	// e.g. a replayed request
+	ErrStaleTimestamp = errors.New("webhooksign: timestamp outside tolerance")
+)
+
+// verifyOptions holds the Verify settings
+type verifyOptions struct {
+	tolerance time.Duration
+	now       func() time.Time
+}
+
+// Option is a functional option for Verify.
+type Option func(*verifyOptions)
+
+// WithTolerance sets how old, or how far in the future, a timestamp may be.
+func WithTolerance(tolerance time.Duration) Option {
+	return func(o *verifyOptions) {
+		o.tolerance = tolerance
+	}
+}
+
+// WithNow sets the clock Verify compares timestamps against.
+func WithNow(now func() time.Time) Option {
+	return func(o *verifyOptions) {
+		o.now = now
+	}
+}
+
+// Sign returns the header value for body signed with secret at the current time.
+func Sign(body, secret []byte) string {
+	return SignAt(body, secret, time.Now())
+}
+
+// SignAt returns the header value for body signed with secret at ts.
+func SignAt(body, secret []byte, ts time.Time) string {
+	timestamp := strconv.FormatInt(ts.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(digest(body, secret, timestamp))
+}
+
+// Verify checks signature against body and secret. It returns
+// ErrMalformedSignature, ErrStaleTimestamp or ErrInvalidSignature on failure.
+func Verify(body []byte, signature string, secret []byte, opts ...Option) error {
+	options := verifyOptions{tolerance: DefaultTolerance, now: time.Now}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	timestamp, sigs, err := parse(signature)
+	if err != nil {
+		return err
+	}
+
+	// The MAC is checked first so a forged timestamp can't be probed
+	want := digest(body, secret, timestamp)
+	matched := false
+	for _, sig := range sigs {
+		if hmac.Equal(sig, want) {
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrInvalidSignature
+	}
+
+	unix, _ := strconv.ParseInt(timestamp, 10, 64)
+	age := options.now().Sub(time.Unix(unix, 0))
+	if age > options.tolerance || age < -options.tolerance {
+		return fmt.Errorf("%w: signed %s ago", ErrStaleTimestamp, age.Round(time.Second))
+	}
+	return nil
+}
+
+// SignRequest sets the signature header for body on req.
+func SignRequest(req *http.Request, body, secret []byte) {
+	req.Header.Set(Header, Sign(body, secret))
+}
+
+// VerifyRequest reads the request body, verifies it against the signature
+// header and returns it. The body is replaced so handlers can read it again.
+func VerifyRequest(r *http.Request, secret []byte, opts ...Option) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("webhooksign: failed to read body: %w", err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := Verify(body, r.Header.Get(Header), secret, opts...); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// parse splits a header value into its timestamp and v1 signatures
+// Unknown keys are ignored so newer schemes can be sent alongside v1.
+func parse(signature string) (string, [][]byte, error) {
+	var (
+		timestamp string
+		sigs      [][]byte
+	)
+	for _, part := range strings.Split(signature, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return "", nil, ErrMalformedSignature
+		}
+		switch key {
+		case "t":
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return "", nil, ErrMalformedSignature
+			}
+			timestamp = value
+		case "v1":
+			sig, err := hex.DecodeString(value)
+			if err != nil {
+				return "", nil, ErrMalformedSignature
+			}
+			sigs = append(sigs, sig)
+		}
+	}
+	if timestamp == "" || len(sigs) == 0 {
+		return "", nil, ErrMalformedSignature
+	}
+	return timestamp, sigs, nil
+}
+
+// digest returns the HMAC-SHA256 of "<timestamp>.<body>"
+func digest(body, secret []byte, timestamp string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
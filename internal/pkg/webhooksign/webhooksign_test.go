@@ -0,0 +1,117 @@
+package webhooksign
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSecret = []byte("whsec_test")
+	testBody   = []byte(`{"event":"delivered","target_id":42}`)
+	signedAt   = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+)
+
+func at(ts time.Time) Option {
+	return WithNow(func() time.Time { return ts })
+}
+
+func TestVerify_Valid(t *testing.T) {
+	sig := SignAt(testBody, testSecret, signedAt)
+	assert.True(t, strings.HasPrefix(sig, "t=1714564800,v1="))
+
+	assert.NoError(t, Verify(testBody, sig, testSecret, at(signedAt.Add(time.Minute))))
+}
+
+func TestVerify_WrongSecret(t *testing.T) {
+	sig := SignAt(testBody, testSecret, signedAt)
+
+	err := Verify(testBody, sig, []byte("other"), at(signedAt))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestVerify_TamperedBody(t *testing.T) {
+	sig := SignAt(testBody, testSecret, signedAt)
+	tampered := bytes.Replace(testBody, []byte("42"), []byte("43"), 1)
+
+	err := Verify(tampered, sig, testSecret, at(signedAt))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestVerify_TamperedTimestamp(t *testing.T) {
+	sig := SignAt(testBody, testSecret, signedAt)
+	// Moving the timestamp forward to dodge the replay check breaks the MAC
+	forged := strings.Replace(sig, "t=1714564800", "t=1714568400", 1)
+
+	err := Verify(testBody, forged, testSecret, at(signedAt.Add(time.Hour)))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestVerify_Replayed(t *testing.T) {
+	sig := SignAt(testBody, testSecret, signedAt)
+
+	err := Verify(testBody, sig, testSecret, at(signedAt.Add(10*time.Minute)))
+	assert.ErrorIs(t, err, ErrStaleTimestamp)
+
+	// A larger tolerance accepts it
+	assert.NoError(t, Verify(testBody, sig, testSecret, at(signedAt.Add(10*time.Minute)), WithTolerance(time.Hour)))
+}
+
+func TestVerify_FutureTimestamp(t *testing.T) {
+	sig := SignAt(testBody, testSecret, signedAt.Add(time.Hour))
+
+	err := Verify(testBody, sig, testSecret, at(signedAt))
+	assert.ErrorIs(t, err, ErrStaleTimestamp)
+}
+
+func TestVerify_RotatedSecrets(t *testing.T) {
+	oldSig := SignAt(testBody, []byte("old"), signedAt)
+	newSig := SignAt(testBody, testSecret, signedAt)
+	_, newV1, _ := strings.Cut(newSig, ",")
+	combined := oldSig + "," + newV1
+
+	assert.NoError(t, Verify(testBody, combined, testSecret, at(signedAt)))
+	assert.NoError(t, Verify(testBody, combined, []byte("old"), at(signedAt)))
+}
+
+func TestVerify_Malformed(t *testing.T) {
+	for _, sig := range []string{
+		"",
+		"v1=abcd",
+		"t=1714564800",
+		"t=soon,v1=abcd",
+		"t=1714564800,v1=not-hex",
+		"garbage",
+	} {
+		err := Verify(testBody, sig, testSecret, at(signedAt))
+		assert.ErrorIs(t, err, ErrMalformedSignature, sig)
+	}
+}
+
+func TestVerifyRequest(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(testBody))
+	SignRequest(req, testBody, testSecret)
+
+	body, err := VerifyRequest(req, testSecret)
+	require.NoError(t, err)
+	assert.Equal(t, testBody, body)
+
+	// The handler can still read the body
+	again, err := io.ReadAll(req.Body)
+	require.NoError(t, err)
+	assert.Equal(t, testBody, again)
+}
+
+func TestVerifyRequest_Unsigned(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks", bytes.NewReader(testBody))
+
+	_, err := VerifyRequest(req, testSecret)
+	assert.ErrorIs(t, err, ErrMalformedSignature)
+}
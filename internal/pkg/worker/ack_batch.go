@@ -0,0 +1,133 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// DefaultAckFlushInterval is used when AckBatchSize is set without an interval
+const DefaultAckFlushInterval = 100 * time.Millisecond
+
+// ackBatcher buffers acks and sends them through a BatchAcker once size acks
+// are pending or every interval, whichever comes first. Acks still buffered
+// when the process dies are never sent, so those tasks stay pending in the
+// queue and are redelivered rather than lost.
+type ackBatcher struct {
+	acker    BatchAcker
+	size     int
+	interval time.Duration
+	timeout  time.Duration
+	logger   *logger.Logger
+
+	mu      sync.Mutex
+	pending []*Task
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+func newAckBatcher(acker BatchAcker, size int, interval, timeout time.Duration, log *logger.Logger) *ackBatcher {
+	if interval <= 0 {
+		interval = DefaultAckFlushInterval
+	}
+
+	return &ackBatcher{
+		acker:    acker,
+		size:     size,
+		interval: interval,
+		timeout:  timeout,
+		logger:   log,
+		pending:  make([]*Task, 0, size),
+		stopCh:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Add buffers an ack, flushing the batch once it reaches size
+// With ctx already done the ack stays buffered for the shutdown flush.
+func (b *ackBatcher) Add(ctx context.Context, task *Task) error {
+	b.mu.Lock()
+	b.pending = append(b.pending, task)
+	var batch []*Task
+	if len(b.pending) >= b.size && ctx.Err() == nil {
+		batch = b.take()
+	}
+	b.mu.Unlock()
+
+	return b.send(ctx, batch)
+}
+
+// Flush sends all buffered acks
+func (b *ackBatcher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	batch := b.take()
+	b.mu.Unlock()
+
+	return b.send(ctx, batch)
+}
+
+// Pending returns the number of buffered acks
+func (b *ackBatcher) Pending() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.pending)
+}
+
+// take swaps out the buffered acks; callers hold mu
+func (b *ackBatcher) take() []*Task {
+	if len(b.pending) == 0 {
+		return nil
+	}
+	batch := b.pending
+	b.pending = make([]*Task, 0, b.size)
+	return batch
+}
+
+// send acks a batch; on failure the tasks stay pending and are redelivered
+func (b *ackBatcher) send(ctx context.Context, batch []*Task) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	if err := b.acker.AckBatch(ctx, batch); err != nil {
+		b.logger.Error("Failed to acknowledge task batch", zap.Int("count", len(batch)), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// run flushes partial batches every interval until Stop
+func (b *ackBatcher) run() {
+	defer close(b.done)
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-b.stopCh:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+			_ = b.Flush(ctx)
+			cancel()
+		}
+	}
+}
+
+// Stop ends the flush loop and sends whatever is still buffered
+func (b *ackBatcher) Stop(ctx context.Context) error {
+	select {
+	case <-b.stopCh:
+	default:
+		close(b.stopCh)
+	}
+	<-b.done
+
+	return b.Flush(ctx)
+}
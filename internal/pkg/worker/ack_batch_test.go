@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRedisProvider_AckBatchPipelinesPerStream(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks:bulk", "workers", "worker-1")
+	config.Streams = []string{"tasks:critical", "tasks:bulk"}
+	config.EnableAutoClaim = false
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	ctx := context.Background()
+	for _, stream := range []string{"tasks:critical", "tasks:bulk", "tasks:bulk"} {
+		_, err := provider.EnqueueTaskToStream(ctx, stream, &Task{Payload: []byte("{}")})
+		require.NoError(t, err)
+	}
+
+	var tasks []*Task
+	for i := 0; i < 3; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		tasks = append(tasks, task)
+	}
+
+	require.NoError(t, provider.AckBatch(ctx, tasks))
+
+	acked, acks, pipelines := fake.ackStats()
+	assert.Equal(t, 3, acked)
+	assert.Equal(t, 2, acks, "one XACK per stream")
+	assert.Equal(t, 1, pipelines, "one round trip")
+	assert.Empty(t, fake.messages("tasks:critical"))
+	assert.Empty(t, fake.messages("tasks:bulk"))
+}
+
+// newBatchAckWorker runs a worker over the fake Redis provider with batched acks
+func newBatchAckWorker(t *testing.T, batchSize, tasks int) (*Worker, *fakeStreams, *atomic.Int32) {
+	t.Helper()
+
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	ctx := context.Background()
+	for i := 0; i < tasks; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{
+			Payload:  []byte(fmt.Sprintf("task-%d", i)),
+			Metadata: map[string]string{"type": "email"},
+		})
+		require.NoError(t, err)
+	}
+
+	w := New(provider, Config{
+		Concurrency:      1,
+		ShutdownTimeout:  time.Second,
+		PollInterval:     time.Millisecond,
+		AckBatchSize:     batchSize,
+		AckFlushInterval: time.Hour,
+	}, &logger.Logger{Logger: zap.NewNop()})
+
+	processed := &atomic.Int32{}
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		processed.Add(1)
+		return nil
+	}))
+
+	return w, fake, processed
+}
+
+func TestWorker_BatchedAcksFlushOnThreshold(t *testing.T) {
+	w, fake, processed := newBatchAckWorker(t, 2, 3)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	require.Eventually(t, func() bool { return processed.Load() == 3 }, 2*time.Second, time.Millisecond)
+
+	// The first two went out together; the third waits for the next batch
+	acked, acks, pipelines := fake.ackStats()
+	assert.Equal(t, 2, acked)
+	assert.Equal(t, 1, acks)
+	assert.Equal(t, 1, pipelines)
+	assert.Equal(t, 1, w.acks.Pending())
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+
+	acked, _, _ = fake.ackStats()
+	assert.Equal(t, 3, acked)
+}
+
+func TestWorker_BatchedAcksFlushOnShutdown(t *testing.T) {
+	w, fake, processed := newBatchAckWorker(t, 10, 3)
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	require.Eventually(t, func() bool { return processed.Load() == 3 }, 2*time.Second, time.Millisecond)
+
+	acked, _, _ := fake.ackStats()
+	assert.Zero(t, acked, "below the threshold nothing is sent yet")
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+
+	acked, acks, pipelines := fake.ackStats()
+	assert.Equal(t, 3, acked)
+	assert.Equal(t, 1, acks)
+	assert.Equal(t, 1, pipelines)
+	assert.Empty(t, fake.messages("tasks"))
+}
+
+func TestAckBatcher_UnflushedAcksLeaveTasksPending(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(fmt.Sprintf("task-%d", i))})
+		require.NoError(t, err)
+	}
+
+	batcher := newAckBatcher(provider, 10, time.Hour, time.Second, &logger.Logger{Logger: zap.NewNop()})
+	for i := 0; i < 2; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		require.NoError(t, batcher.Add(ctx, task))
+	}
+
+	// The process dies here without flushing: nothing was acked or deleted,
+	// so both messages stay pending for XAUTOCLAIM to redeliver
+	acked, acks, _ := fake.ackStats()
+	assert.Zero(t, acked)
+	assert.Zero(t, acks)
+	assert.Len(t, fake.messages("tasks"), 2)
+}
+
+func TestWorker_AckBatchSizeIgnoredWithoutBatchAcker(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{Concurrency: 1, PollInterval: time.Millisecond, AckBatchSize: 10}, log)
+	assert.Nil(t, w.acks)
+
+	var handled atomic.Int32
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		handled.Add(1)
+		return nil
+	}))
+
+	runTasks(t, w, provider, []*Task{{Payload: []byte("{}"), Metadata: map[string]string{"type": "email"}}}, func() bool {
+		return handled.Load() == 1
+	})
+}
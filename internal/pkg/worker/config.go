@@ -40,6 +40,40 @@ type Config struct {
 
 	// ErrorBackoff is the delay after a fetch error
 	ErrorBackoff time.Duration
+
+	// MaxInFlight caps the number of tasks processed at the same time,
+	// independent of Concurrency. Zero means no limit beyond Concurrency.
+	MaxInFlight int64
+
+	// DLQAlertThreshold is the number of dead-lettered tasks within
+	// DLQAlertWindow that triggers OnDLQAlert. Zero disables alerting.
+	DLQAlertThreshold int
+
+	// DLQAlertWindow is the sliding window for DLQAlertThreshold
+	DLQAlertWindow time.Duration
+
+	// OnDLQAlert is called once each time the threshold is crossed.
+	// When nil, the worker logs an error instead.
+	OnDLQAlert func(DLQAlert)
+
+	// TypeResolver types tasks that have no "type" metadata. When it is nil
+	// or returns "", the task goes to the default handler, if registered.
+	TypeResolver TypeResolver
+
+	// AckBatchSize buffers acks and sends them together once this many are
+	// pending. Zero acks each task on its own. Needs a provider that
+	// implements BatchAcker; others keep acking one task at a time.
+	AckBatchSize int
+
+	// AckFlushInterval sends a partial ack batch after this long.
+	// Defaults to DefaultAckFlushInterval when AckBatchSize is set.
+	AckFlushInterval time.Duration
+
+	// PartitionKey, when set, processes tasks with the same key one at a
+	// time in fetch order. Tasks are hashed by key onto Concurrency
+	// partitions, each served by one goroutine, so different keys still
+	// run in parallel.
+	PartitionKey PartitionKeyFunc
 }
 
 // DefaultConfig returns a Config with sensible defaults
@@ -52,5 +86,6 @@ func DefaultConfig() Config {
 		ShutdownTimeout: 30 * time.Second,
 		PollInterval:    1 * time.Second,
 		ErrorBackoff:    5 * time.Second,
+		DLQAlertWindow:  DefaultDLQAlertWindow,
 	}
 }
@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultDLQAlertWindow is the window DLQAlertThreshold is measured over
+const DefaultDLQAlertWindow = time.Minute
+
+// DLQAlert describes a dead-letter rate that crossed the configured threshold
+type DLQAlert struct {
+	// Count is the number of tasks dead-lettered within Window
+	Count int
+
+	// Threshold is the configured DLQAlertThreshold
+	Threshold int
+
+	// Window is the sliding window Count was measured over
+	Window time.Duration
+
+	// At is when the threshold was crossed
+	At time.Time
+}
+
+// DLQStats reports dead-letter activity for monitoring
+type DLQStats struct {
+	// Total is the number of tasks dead-lettered since the worker was created
+	Total int64
+
+	// InWindow is the number of tasks dead-lettered within the alert window
+	InWindow int
+
+	// Alerting is true while the rate is at or above the threshold
+	Alerting bool
+}
+
+// dlqMonitor tracks dead-lettered tasks over a sliding window and fires the
+// alert callback once each time the threshold is crossed. It re-arms when the
+// rate drops back below the threshold.
+type dlqMonitor struct {
+	threshold int
+	window    time.Duration
+	onAlert   func(DLQAlert)
+	now       func() time.Time
+
+	total atomic.Int64
+
+	mu       sync.Mutex
+	events   []time.Time
+	alerting bool
+}
+
+func newDLQMonitor(threshold int, window time.Duration, onAlert func(DLQAlert)) *dlqMonitor {
+	if window <= 0 {
+		window = DefaultDLQAlertWindow
+	}
+	return &dlqMonitor{
+		threshold: threshold,
+		window:    window,
+		onAlert:   onAlert,
+		now:       time.Now,
+	}
+}
+
+// record counts one dead-lettered task
+func (m *dlqMonitor) record() {
+	m.total.Add(1)
+
+	m.mu.Lock()
+	now := m.now()
+	m.events = append(m.prune(now), now)
+	count := len(m.events)
+
+	var alert *DLQAlert
+	if m.threshold > 0 && count >= m.threshold && !m.alerting {
+		m.alerting = true
+		alert = &DLQAlert{Count: count, Threshold: m.threshold, Window: m.window, At: now}
+	}
+	m.mu.Unlock()
+
+	// Call outside the lock so a slow callback doesn't block other workers
+	if alert != nil && m.onAlert != nil {
+		m.onAlert(*alert)
+	}
+}
+
+// stats returns the current totals, re-arming the alert if the rate has dropped
+func (m *dlqMonitor) stats() DLQStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = m.prune(m.now())
+	return DLQStats{
+		Total:    m.total.Load(),
+		InWindow: len(m.events),
+		Alerting: m.alerting,
+	}
+}
+
+// prune drops events older than the window and re-arms the alert
+// Callers must hold mu
+func (m *dlqMonitor) prune(now time.Time) []time.Time {
+	cutoff := now.Add(-m.window)
+	i := 0
+	for i < len(m.events) && !m.events[i].After(cutoff) {
+		i++
+	}
+	events := m.events[i:]
+
+	if m.threshold > 0 && len(events) < m.threshold {
+		m.alerting = false
+	}
+	return events
+}
+
+// deadLetter records why the task failed on it, sends it to the DLQ and
+// records it for rate alerting
+func (w *Worker) deadLetter(ctx context.Context, task *Task, reason string) error {
+	task.LastError = reason
+	if err := w.provider.Nack(ctx, task, false); err != nil {
+		return err
+	}
+	w.dlq.record()
+	return nil
+}
+
+// dlqAlertHandler returns the configured alert callback, or one that logs
+func (w *Worker) dlqAlertHandler() func(DLQAlert) {
+	if w.config.OnDLQAlert != nil {
+		return w.config.OnDLQAlert
+	}
+	return func(alert DLQAlert) {
+		w.logger.Error("Dead-letter rate exceeded threshold",
+			zap.Int("count", alert.Count),
+			zap.Int("threshold", alert.Threshold),
+			zap.Duration("window", alert.Window),
+		)
+	}
+}
+
+// DLQStats returns dead-letter counts for metrics and health checks
+func (w *Worker) DLQStats() DLQStats {
+	return w.dlq.stats()
+}
@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestDLQMonitor_FiresOnceWhenThresholdCrossed(t *testing.T) {
+	var alerts []DLQAlert
+	m := newDLQMonitor(5, time.Minute, func(a DLQAlert) { alerts = append(alerts, a) })
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 4; i++ {
+		m.record()
+		now = now.Add(time.Second)
+	}
+	assert.Empty(t, alerts)
+
+	// Crossing fires once, however many more events follow
+	for i := 0; i < 20; i++ {
+		m.record()
+		now = now.Add(time.Second)
+	}
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 5, alerts[0].Count)
+	assert.Equal(t, 5, alerts[0].Threshold)
+	assert.Equal(t, time.Minute, alerts[0].Window)
+
+	stats := m.stats()
+	assert.Equal(t, int64(24), stats.Total)
+	assert.Equal(t, 24, stats.InWindow)
+	assert.True(t, stats.Alerting)
+}
+
+func TestDLQMonitor_RearmsAfterRateDrops(t *testing.T) {
+	alerts := 0
+	m := newDLQMonitor(3, time.Minute, func(DLQAlert) { alerts++ })
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	m.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		m.record()
+	}
+	assert.Equal(t, 1, alerts)
+
+	// The window slides past the burst
+	now = now.Add(2 * time.Minute)
+	stats := m.stats()
+	assert.Zero(t, stats.InWindow)
+	assert.False(t, stats.Alerting)
+
+	for i := 0; i < 3; i++ {
+		m.record()
+	}
+	assert.Equal(t, 2, alerts)
+}
+
+func TestDLQMonitor_DisabledWithoutThreshold(t *testing.T) {
+	alerts := 0
+	m := newDLQMonitor(0, 0, func(DLQAlert) { alerts++ })
+
+	for i := 0; i < 100; i++ {
+		m.record()
+	}
+	assert.Zero(t, alerts)
+	assert.Equal(t, int64(100), m.stats().Total)
+}
+
+func TestWorker_DLQAlertFiresOnce(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{BufferSize: 50}, log)
+
+	var (
+		mu     sync.Mutex
+		alerts []DLQAlert
+	)
+	w := New(provider, Config{
+		Concurrency:       4,
+		ShutdownTimeout:   time.Second,
+		PollInterval:      time.Millisecond,
+		DLQAlertThreshold: 10,
+		DLQAlertWindow:    time.Minute,
+		OnDLQAlert: func(a DLQAlert) {
+			mu.Lock()
+			defer mu.Unlock()
+			alerts = append(alerts, a)
+		},
+	}, log)
+
+	// No handler is registered, so every task is dead-lettered
+	for i := 0; i < 30; i++ {
+		_, err := provider.EnqueueTask(context.Background(), &Task{
+			Payload:  []byte("{}"),
+			Metadata: map[string]string{"type": "unhandled"},
+		})
+		require.NoError(t, err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	require.Eventually(t, func() bool {
+		return len(provider.DeadLetters()) == 30
+	}, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, alerts, 1)
+	assert.Equal(t, 10, alerts[0].Count)
+
+	stats := w.DLQStats()
+	assert.Equal(t, int64(30), stats.Total)
+	assert.True(t, stats.Alerting)
+}
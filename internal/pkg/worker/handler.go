@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"time"
 )
 
 // Handler defines the interface for processing tasks
@@ -17,3 +18,69 @@ type HandlerFunc func(ctx context.Context, task *Task) error
 func (f HandlerFunc) Process(ctx context.Context, task *Task) error {
 	return f(ctx, task)
 }
+
+// TypeResolver derives the task type for a task without "type" metadata,
+// for producers that encode it elsewhere. It returns "" if it can't tell.
+type TypeResolver func(task *Task) string
+
+// StreamTypeResolver types tasks by the Redis stream they were read from,
+// using a map of stream name to task type
+func StreamTypeResolver(streamTypes map[string]string) TypeResolver {
+	return func(task *Task) string {
+		return streamTypes[task.Metadata[streamMetadataKey]]
+	}
+}
+
+// RetryBackoffError lets a handler choose the base retry delay for a failed
+// attempt, e.g. per downstream dependency. The worker's BackoffStrategy still
+// applies on top of it.
+type RetryBackoffError struct {
+	Err         error
+	BaseBackoff time.Duration
+}
+
+// Error implements the error interface
+func (e *RetryBackoffError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *RetryBackoffError) Unwrap() error {
+	return e.Err
+}
+
+// WithRetryBackoff wraps err so the task is retried using base instead of
+// Config.BaseBackoff. A nil err or a non-positive base returns err unchanged.
+func WithRetryBackoff(err error, base time.Duration) error {
+	if err == nil || base <= 0 {
+		return err
+	}
+	return &RetryBackoffError{Err: err, BaseBackoff: base}
+}
+
+// RequeueError asks the worker to put the task back after a delay without
+// counting a retry, for a task that couldn't run yet rather than one that
+// failed, e.g. because a downstream has no free capacity
+type RequeueError struct {
+	Err   error
+	After time.Duration
+}
+
+// Error implements the error interface
+func (e *RequeueError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *RequeueError) Unwrap() error {
+	return e.Err
+}
+
+// Requeue wraps err so the task is put back to run again after delay,
+// keeping its retry count. A nil err is returned unchanged.
+func Requeue(err error, after time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RequeueError{Err: err, After: after}
+}
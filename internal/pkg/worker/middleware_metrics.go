@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"sync"
 	"time"
 
@@ -10,7 +11,30 @@ import (
 	"go.uber.org/zap"
 )
 
+// Task statuses passed to MetricsRecorder.RecordTask
+const (
+	TaskStatusSuccess = "success"
+	TaskStatusError   = "error"
+	TaskStatusPanic   = "panic"
+)
+
+// MetricsRecorder receives per-task metrics from MetricsMiddleware
+// Implementations map it onto their metrics system, e.g. a Prometheus
+// counter labeled by type and status, a duration histogram labeled by type,
+// and a retry counter.
+type MetricsRecorder interface {
+	// RecordTask is called once per processed task; retry is the task's retry count
+	RecordTask(taskType, status string, duration time.Duration, retry int)
+}
+
+// NoOpMetricsRecorder discards all metrics
+type NoOpMetricsRecorder struct{}
+
+// RecordTask implements MetricsRecorder
+func (NoOpMetricsRecorder) RecordTask(taskType, status string, duration time.Duration, retry int) {}
+
 // MetricsCollector holds basic metrics for worker tasks
+// It is the in-memory MetricsRecorder.
 type MetricsCollector struct {
 	mu            sync.RWMutex
 	taskProcessed map[string]map[string]int64 // taskType -> status -> count
@@ -52,6 +76,13 @@ func (mc *MetricsCollector) RecordTask(taskType, status string, duration time.Du
 	}
 }
 
+// TaskCount returns how many tasks of a type finished with a status
+func (mc *MetricsCollector) TaskCount(taskType, status string) int64 {
+	mc.mu.RLock()
+	defer mc.mu.RUnlock()
+	return mc.taskProcessed[taskType][status]
+}
+
 // LogMetrics logs current metrics
 func (mc *MetricsCollector) LogMetrics() {
 	mc.mu.RLock()
@@ -68,9 +99,10 @@ func (mc *MetricsCollector) LogMetrics() {
 	}
 }
 
-// MetricsMiddleware creates a middleware that collects basic metrics
-// For production use, consider integrating with Prometheus or other metrics systems
-func MetricsMiddleware(collector *MetricsCollector) Middleware {
+// MetricsMiddleware creates a middleware that records processed and failed
+// counts, durations and retries per task type
+// For production use, pass a recorder backed by Prometheus or another metrics system
+func MetricsMiddleware(recorder MetricsRecorder) Middleware {
 	return func(next Handler) Handler {
 		return HandlerFunc(func(ctx context.Context, task *Task) error {
 			taskType := task.Metadata["type"]
@@ -84,12 +116,14 @@ func MetricsMiddleware(collector *MetricsCollector) Middleware {
 			duration := time.Since(start)
 
 			// Record metrics
-			status := "success"
-			if err != nil {
-				status = "error"
+			status := TaskStatusSuccess
+			if errors.Is(err, ErrTaskPanicked) {
+				status = TaskStatusPanic
+			} else if err != nil {
+				status = TaskStatusError
 			}
 
-			collector.RecordTask(taskType, status, duration, task.Retry)
+			recorder.RecordTask(taskType, status, duration, task.Retry)
 
 			return err
 		})
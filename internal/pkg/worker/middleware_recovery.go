@@ -2,6 +2,7 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"runtime/debug"
 
@@ -10,7 +11,12 @@ import (
 	"go.uber.org/zap"
 )
 
+// ErrTaskPanicked is returned by RecoveryMiddleware when a handler panics
+// The worker sends such tasks to the DLQ without retrying them.
+var ErrTaskPanicked = errors.New("task handler panicked")
+
 // RecoveryMiddleware creates a middleware that recovers from panics
+// A panic becomes an ErrTaskPanicked error instead of crashing the worker goroutine.
 func RecoveryMiddleware(log *logger.Logger) Middleware {
 	return func(next Handler) Handler {
 		return HandlerFunc(func(ctx context.Context, task *Task) (err error) {
@@ -27,7 +33,7 @@ func RecoveryMiddleware(log *logger.Logger) Middleware {
 					)
 
 					// Convert panic to error
-					err = fmt.Errorf("panic recovered: %v", r)
+					err = fmt.Errorf("%w: %v", ErrTaskPanicked, r)
 				}
 			}()
 
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// recordedTask is one RecordTask call
+type recordedTask struct {
+	taskType string
+	status   string
+	retry    int
+}
+
+// fakeRecorder is a MetricsRecorder that keeps every call
+type fakeRecorder struct {
+	mu    sync.Mutex
+	calls []recordedTask
+}
+
+func (r *fakeRecorder) RecordTask(taskType, status string, duration time.Duration, retry int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, recordedTask{taskType, status, retry})
+}
+
+func TestMetricsMiddleware_RecordsByTypeAndStatus(t *testing.T) {
+	recorder := &fakeRecorder{}
+	log := &logger.Logger{Logger: zap.NewNop()}
+
+	handler := Chain(
+		MetricsMiddleware(recorder),
+		RecoveryMiddleware(log),
+	)(HandlerFunc(func(ctx context.Context, task *Task) error {
+		switch string(task.Payload) {
+		case "fail":
+			return errors.New("boom")
+		case "panic":
+			panic("bad payload")
+		}
+		return nil
+	}))
+
+	ctx := context.Background()
+	require.NoError(t, handler.Process(ctx, &Task{Payload: []byte("ok"), Metadata: map[string]string{"type": "email"}}))
+	require.Error(t, handler.Process(ctx, &Task{Payload: []byte("fail"), Retry: 2, Metadata: map[string]string{"type": "email"}}))
+	require.ErrorIs(t, handler.Process(ctx, &Task{Payload: []byte("panic"), Metadata: map[string]string{"type": "sms"}}), ErrTaskPanicked)
+	require.NoError(t, handler.Process(ctx, &Task{Payload: []byte("ok")}))
+
+	assert.Equal(t, []recordedTask{
+		{"email", TaskStatusSuccess, 0},
+		{"email", TaskStatusError, 2},
+		{"sms", TaskStatusPanic, 0},
+		{"unknown", TaskStatusSuccess, 0},
+	}, recorder.calls)
+}
+
+func TestWorker_PanickingTaskGoesToDLQWithoutRetry(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{Concurrency: 1, PollInterval: time.Millisecond}, log)
+	w.Use(RecoveryMiddleware(log))
+
+	var calls atomic.Int32
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		calls.Add(1)
+		panic("handler bug")
+	}))
+
+	task := &Task{Payload: []byte("{}"), MaxRetry: 3, Metadata: map[string]string{"type": "email"}}
+	runTasks(t, w, provider, []*Task{task}, func() bool {
+		return len(provider.DeadLetters()) == 1
+	})
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, 0, provider.DeadLetters()[0].Retry)
+}
@@ -17,7 +17,7 @@ var Module = fx.Module("worker")
 type Params struct {
 	fx.In
 
-	Redis  *redisv9.Client
+	Redis  *redisv9.Client `optional:"true"`
 	Logger *logger.Logger
 	Config *WorkerModuleConfig `optional:"true"`
 }
@@ -27,8 +27,8 @@ type WorkerModuleConfig struct {
 	// Worker configuration
 	Worker Config
 
-	// Redis provider configuration
-	Redis RedisProviderConfig
+	// Provider selects and configures the task queue transport
+	Provider ProviderConfig
 
 	// Enable default middlewares
 	EnableLogging  bool
@@ -41,8 +41,12 @@ type WorkerModuleConfig struct {
 // DefaultWorkerModuleConfig returns a config with sensible defaults
 func DefaultWorkerModuleConfig() *WorkerModuleConfig {
 	return &WorkerModuleConfig{
-		Worker:         DefaultConfig(),
-		Redis:          DefaultRedisProviderConfig("tasks", "workers", "worker-1"),
+		Worker: DefaultConfig(),
+		Provider: ProviderConfig{
+			Type:   ProviderTypeRedis,
+			Memory: DefaultMemoryProviderConfig(),
+			Redis:  DefaultRedisProviderConfig("tasks", "workers", "worker-1"),
+		},
 		EnableLogging:  true,
 		EnableMetrics:  true,
 		EnableRecovery: true,
@@ -51,7 +55,7 @@ func DefaultWorkerModuleConfig() *WorkerModuleConfig {
 	}
 }
 
-// NewWorker creates a new worker with Redis provider
+// NewWorker creates a new worker with the provider selected by config
 func NewWorker(p Params) (*Worker, error) {
 	// Use default config if not provided
 	config := p.Config
@@ -59,8 +63,11 @@ func NewWorker(p Params) (*Worker, error) {
 		config = DefaultWorkerModuleConfig()
 	}
 
-	// Create Redis provider
-	provider, err := NewRedisProvider(p.Redis, config.Redis, p.Logger)
+	// Create provider
+	provider, err := NewProvider(config.Provider, ProviderDeps{
+		Redis:  p.Redis,
+		Logger: p.Logger,
+	})
 	if err != nil {
 		return nil, err
 	}
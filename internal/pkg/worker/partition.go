@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"hash/fnv"
+
+	"myapp/internal/pkg/logger"
+
+	"go.uber.org/zap"
+)
+
+// partitionBuffer is how many fetched tasks may wait on each partition.
+// It lets the dispatcher move past a busy partition for a while before a
+// run of tasks for one key holds up the others.
+const partitionBuffer = 16
+
+// PartitionKeyFunc returns the key whose tasks must be processed in order,
+// e.g. a user ID. Tasks with an empty key are spread round-robin.
+type PartitionKeyFunc func(task *Task) string
+
+// MetadataPartitionKey partitions tasks by a metadata field
+func MetadataPartitionKey(field string) PartitionKeyFunc {
+	return func(task *Task) string {
+		return task.Metadata[field]
+	}
+}
+
+// startPartitions starts one dispatcher that fetches tasks and one goroutine
+// per partition that processes its tasks sequentially
+func (w *Worker) startPartitions(ctx context.Context) {
+	partitions := make([]chan *Task, w.config.Concurrency)
+	for i := range partitions {
+		partitions[i] = make(chan *Task, partitionBuffer)
+		w.wg.Add(1)
+		go w.partitionLoop(ctx, i, partitions[i])
+	}
+
+	w.wg.Add(1)
+	go w.dispatchLoop(ctx, partitions)
+}
+
+// dispatchLoop fetches tasks and routes each to its key's partition
+func (w *Worker) dispatchLoop(ctx context.Context, partitions []chan *Task) {
+	defer w.wg.Done()
+	defer func() {
+		for _, ch := range partitions {
+			close(ch)
+		}
+	}()
+
+	log := w.logger.With(zap.String("role", "dispatcher"))
+	log.Info("Dispatcher started", zap.Int("partitions", len(partitions)))
+
+	next := 0
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Dispatcher stopping: context cancelled")
+			return
+		case <-w.stopCh:
+			log.Info("Dispatcher stopping: stop signal")
+			return
+		default:
+		}
+
+		task := w.fetchNext(ctx, log)
+		if task == nil {
+			continue
+		}
+
+		var index int
+		if key := w.config.PartitionKey(task); key != "" {
+			index = partitionIndex(key, len(partitions))
+		} else {
+			index = next % len(partitions)
+			next++
+		}
+
+		select {
+		case partitions[index] <- task:
+		case <-ctx.Done():
+			w.requeueUnstarted(task, log)
+			return
+		case <-w.stopCh:
+			w.requeueUnstarted(task, log)
+			return
+		}
+	}
+}
+
+// partitionLoop processes one partition's tasks in the order they arrive
+// Tasks still waiting when the worker stops are requeued, not processed.
+func (w *Worker) partitionLoop(ctx context.Context, partition int, tasks <-chan *Task) {
+	defer w.wg.Done()
+
+	log := w.logger.With(zap.Int("partition", partition))
+	log.Info("Worker started")
+
+	for task := range tasks {
+		select {
+		case <-ctx.Done():
+			w.requeueUnstarted(task, log)
+			continue
+		case <-w.stopCh:
+			w.requeueUnstarted(task, log)
+			continue
+		default:
+		}
+
+		w.processTask(ctx, task, log)
+	}
+}
+
+// requeueUnstarted returns a fetched task that was never processed to the queue
+func (w *Worker) requeueUnstarted(task *Task, log *logger.Logger) {
+	if err := w.provider.Nack(context.Background(), task, true); err != nil {
+		log.Error("Failed to requeue task", zap.String("task_id", task.ID), zap.Error(err))
+	}
+}
+
+// partitionIndex hashes a key onto one of n partitions
+func partitionIndex(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(n))
+}
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWorker_PartitionKeyKeepsPerKeyOrder(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{
+		Concurrency:     4,
+		ShutdownTimeout: time.Second,
+		PollInterval:    time.Millisecond,
+		PartitionKey:    MetadataPartitionKey("user_id"),
+	}, log)
+
+	var (
+		mu    sync.Mutex
+		order = make(map[string][]string)
+		total int
+	)
+	releaseFirst := make(chan struct{})
+	var releaseOnce sync.Once
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		// The first task for alice is slow; without partitioning her
+		// second task would overtake it on another goroutine
+		if string(task.Payload) == "alice-1" {
+			<-releaseFirst
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		user := task.Metadata["user_id"]
+		order[user] = append(order[user], string(task.Payload))
+		total++
+		return nil
+	}))
+
+	var tasks []*Task
+	for i := 1; i <= 3; i++ {
+		for _, user := range []string{"alice", "bob"} {
+			tasks = append(tasks, &Task{
+				Payload:  []byte(fmt.Sprintf("%s-%d", user, i)),
+				Metadata: map[string]string{"type": "email", "user_id": user},
+			})
+		}
+	}
+
+	runTasks(t, w, provider, tasks, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		// Other users are not held up by alice's slow task
+		if len(order["bob"]) == 3 && len(order["alice"]) == 0 {
+			releaseOnce.Do(func() { close(releaseFirst) })
+		}
+		return total == 6
+	})
+
+	assert.Equal(t, []string{"alice-1", "alice-2", "alice-3"}, order["alice"])
+	assert.Equal(t, []string{"bob-1", "bob-2", "bob-3"}, order["bob"])
+}
+
+func TestWorker_PartitionRequeuesUnstartedTasksOnStop(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{
+		Concurrency:     1,
+		ShutdownTimeout: time.Second,
+		PollInterval:    time.Millisecond,
+		PartitionKey:    MetadataPartitionKey("user_id"),
+	}, log)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var processed sync.Map
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		processed.Store(string(task.Payload), true)
+		if string(task.Payload) == "task-1" {
+			close(started)
+			<-release
+		}
+		return nil
+	}))
+
+	for i := 1; i <= 3; i++ {
+		_, err := provider.EnqueueTask(context.Background(), &Task{
+			Payload:  []byte(fmt.Sprintf("task-%d", i)),
+			Metadata: map[string]string{"type": "email", "user_id": "alice"},
+		})
+		require.NoError(t, err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+	<-started
+
+	// Let the dispatcher move the rest onto the partition, then stop
+	time.Sleep(20 * time.Millisecond)
+	stopErr := make(chan error, 1)
+	go func() { stopErr <- w.Stop(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	require.NoError(t, <-stopErr)
+	require.NoError(t, <-errCh)
+
+	// Only the task that had started ran; the others are back on the queue
+	_, ran2 := processed.Load("task-2")
+	_, ran3 := processed.Load("task-3")
+	assert.False(t, ran2)
+	assert.False(t, ran3)
+
+	var requeued []string
+	for {
+		task, err := provider.Fetch(context.Background())
+		require.NoError(t, err)
+		if task == nil {
+			break
+		}
+		requeued = append(requeued, string(task.Payload))
+	}
+	assert.Equal(t, []string{"task-2", "task-3"}, requeued)
+}
+
+func TestPartitionIndex_Stable(t *testing.T) {
+	for _, key := range []string{"alice", "bob", "42"} {
+		first := partitionIndex(key, 8)
+		assert.GreaterOrEqual(t, first, 0)
+		assert.Less(t, first, 8)
+		assert.Equal(t, first, partitionIndex(key, 8))
+	}
+}
@@ -2,8 +2,31 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 )
 
+// ErrMalformedTask is returned by Fetch for a message that cannot be turned
+// into a task. Match it with errors.Is; use errors.As with *MalformedTaskError
+// to get the partially decoded task.
+var ErrMalformedTask = errors.New("malformed task")
+
+// MalformedTaskError reports a fetched message that cannot be processed.
+// Task carries the ID and whatever could be decoded, so the worker can
+// send it to the dead letter queue instead of leaving it pending.
+type MalformedTaskError struct {
+	Task   *Task
+	Reason string
+}
+
+func (e *MalformedTaskError) Error() string {
+	return fmt.Sprintf("%s %s: %s", ErrMalformedTask, e.Task.ID, e.Reason)
+}
+
+func (e *MalformedTaskError) Unwrap() error {
+	return ErrMalformedTask
+}
+
 // Provider defines the interface for task queue providers
 type Provider interface {
 	// Fetch retrieves the next task from the queue
@@ -20,3 +43,17 @@ type Provider interface {
 	// Close cleans up provider resources
 	Close() error
 }
+
+// ProviderShutdownHook is optionally implemented by providers that need to
+// flush or persist pending tasks on graceful shutdown. The worker calls it
+// after all workers have stopped and before Close. ctx expires after the
+// configured ShutdownTimeout.
+type ProviderShutdownHook interface {
+	Shutdown(ctx context.Context) error
+}
+
+// BatchAcker is optionally implemented by providers that can acknowledge
+// several tasks in one round trip. The worker uses it when AckBatchSize is set.
+type BatchAcker interface {
+	AckBatch(ctx context.Context, tasks []*Task) error
+}
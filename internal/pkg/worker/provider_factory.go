@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"myapp/internal/pkg/logger"
+
+	redisv9 "github.com/redis/go-redis/v9"
+)
+
+// ProviderType identifies a task queue transport
+type ProviderType string
+
+const (
+	// ProviderTypeMemory uses the in-process MemoryProvider
+	ProviderTypeMemory ProviderType = "memory"
+
+	// ProviderTypeRedis uses Redis Streams
+	ProviderTypeRedis ProviderType = "redis"
+
+	// ProviderTypeFile uses JSON files on disk, for local development
+	ProviderTypeFile ProviderType = "file"
+
+	// ProviderTypeNATS uses NATS; a factory must be registered with RegisterProviderFactory
+	ProviderTypeNATS ProviderType = "nats"
+)
+
+// ErrUnknownProviderType is returned for provider types without a factory
+var ErrUnknownProviderType = errors.New("unknown provider type")
+
+// ProviderConfig selects and configures the task queue provider
+type ProviderConfig struct {
+	// Type selects the provider implementation
+	Type ProviderType
+
+	// Memory configures the in-memory provider
+	Memory MemoryProviderConfig
+
+	// Redis configures the Redis Streams provider
+	Redis RedisProviderConfig
+
+	// File configures the file provider
+	File FileProviderConfig
+
+	// NATS configures the NATS provider
+	NATS NATSProviderConfig
+}
+
+// NATSProviderConfig holds configuration for a NATS provider
+type NATSProviderConfig struct {
+	// URL is the NATS server URL
+	URL string
+
+	// Subject is the subject tasks are published to
+	Subject string
+
+	// Queue is the queue group shared by workers
+	Queue string
+}
+
+// ProviderDeps holds the shared clients a provider may need
+type ProviderDeps struct {
+	Redis  *redisv9.Client
+	Logger *logger.Logger
+}
+
+// ProviderFactory constructs a Provider from config
+type ProviderFactory func(config ProviderConfig, deps ProviderDeps) (Provider, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[ProviderType]ProviderFactory{
+		ProviderTypeMemory: newMemoryProviderFromConfig,
+		ProviderTypeRedis:  newRedisProviderFromConfig,
+		ProviderTypeFile:   newFileProviderFromConfig,
+	}
+)
+
+// RegisterProviderFactory registers or replaces the factory for a provider type
+// Transports with extra dependencies (e.g. NATS) register themselves from their own package
+func RegisterProviderFactory(providerType ProviderType, factory ProviderFactory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	factories[providerType] = factory
+}
+
+// Validate checks that the config is complete for the selected provider type
+func (c ProviderConfig) Validate() error {
+	switch c.Type {
+	case "":
+		return fmt.Errorf("provider type is required")
+	case ProviderTypeMemory:
+		if c.Memory.BufferSize < 0 {
+			return fmt.Errorf("memory provider buffer size must not be negative")
+		}
+	case ProviderTypeRedis:
+		if c.Redis.Stream == "" {
+			return fmt.Errorf("redis provider stream is required")
+		}
+		if c.Redis.Group == "" {
+			return fmt.Errorf("redis provider group is required")
+		}
+		if c.Redis.Consumer == "" {
+			return fmt.Errorf("redis provider consumer is required")
+		}
+	case ProviderTypeFile:
+		if c.File.Dir == "" {
+			return fmt.Errorf("file provider dir is required")
+		}
+	case ProviderTypeNATS:
+		if c.NATS.URL == "" {
+			return fmt.Errorf("nats provider url is required")
+		}
+		if c.NATS.Subject == "" {
+			return fmt.Errorf("nats provider subject is required")
+		}
+	}
+	return nil
+}
+
+// NewProvider validates the config and constructs the selected provider
+func NewProvider(config ProviderConfig, deps ProviderDeps) (Provider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid provider config: %w", err)
+	}
+
+	factoriesMu.RLock()
+	factory, ok := factories[config.Type]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q (supported: %s, %s, %s, %s)",
+			ErrUnknownProviderType, config.Type, ProviderTypeMemory, ProviderTypeRedis, ProviderTypeFile, ProviderTypeNATS)
+	}
+
+	return factory(config, deps)
+}
+
+// newMemoryProviderFromConfig is the factory for ProviderTypeMemory
+func newMemoryProviderFromConfig(config ProviderConfig, deps ProviderDeps) (Provider, error) {
+	return NewMemoryProvider(config.Memory, deps.Logger), nil
+}
+
+// newFileProviderFromConfig is the factory for ProviderTypeFile
+func newFileProviderFromConfig(config ProviderConfig, deps ProviderDeps) (Provider, error) {
+	return NewFileProvider(config.File, deps.Logger)
+}
+
+// newRedisProviderFromConfig is the factory for ProviderTypeRedis
+func newRedisProviderFromConfig(config ProviderConfig, deps ProviderDeps) (Provider, error) {
+	if deps.Redis == nil {
+		return nil, fmt.Errorf("redis provider requires a redis client")
+	}
+	return NewRedisProvider(deps.Redis, config.Redis, deps.Logger)
+}
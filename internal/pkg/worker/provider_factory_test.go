@@ -0,0 +1,126 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myapp/internal/pkg/logger"
+
+	redisv9 "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testDeps() ProviderDeps {
+	return ProviderDeps{Logger: &logger.Logger{Logger: zap.NewNop()}}
+}
+
+// stubProvider is a Provider that does nothing
+type stubProvider struct{}
+
+func (stubProvider) Fetch(ctx context.Context) (*Task, error)                 { return nil, nil }
+func (stubProvider) Ack(ctx context.Context, task *Task) error                { return nil }
+func (stubProvider) Nack(ctx context.Context, task *Task, requeue bool) error { return nil }
+func (stubProvider) Close() error                                             { return nil }
+
+func TestNewProvider_Memory(t *testing.T) {
+	provider, err := NewProvider(ProviderConfig{
+		Type:   ProviderTypeMemory,
+		Memory: MemoryProviderConfig{BufferSize: 2},
+	}, testDeps())
+	require.NoError(t, err)
+
+	mem, ok := provider.(*MemoryProvider)
+	require.True(t, ok, "expected *MemoryProvider, got %T", provider)
+
+	ctx := context.Background()
+	_, err = mem.EnqueueTask(ctx, &Task{Payload: []byte("hello")})
+	require.NoError(t, err)
+
+	task, err := mem.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("hello"), task.Payload)
+
+	task, err = mem.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+}
+
+func TestNewProvider_Redis(t *testing.T) {
+	cfg := ProviderConfig{
+		Type:  ProviderTypeRedis,
+		Redis: DefaultRedisProviderConfig("tasks", "workers", "worker-1"),
+	}
+
+	// Without a client the redis factory is selected and rejects the deps
+	_, err := NewProvider(cfg, testDeps())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "requires a redis client")
+
+	// With an unreachable client construction reaches NewRedisProvider
+	deps := testDeps()
+	deps.Redis = redisv9.NewClient(&redisv9.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer deps.Redis.Close()
+
+	_, err = NewProvider(cfg, deps)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to ensure consumer group")
+}
+
+func TestNewProvider_NATS(t *testing.T) {
+	cfg := ProviderConfig{
+		Type: ProviderTypeNATS,
+		NATS: NATSProviderConfig{URL: "nats://localhost:4222", Subject: "tasks"},
+	}
+
+	// No built-in NATS factory
+	_, err := NewProvider(cfg, testDeps())
+	assert.True(t, errors.Is(err, ErrUnknownProviderType))
+
+	RegisterProviderFactory(ProviderTypeNATS, func(config ProviderConfig, deps ProviderDeps) (Provider, error) {
+		assert.Equal(t, "tasks", config.NATS.Subject)
+		return stubProvider{}, nil
+	})
+	t.Cleanup(func() {
+		factoriesMu.Lock()
+		delete(factories, ProviderTypeNATS)
+		factoriesMu.Unlock()
+	})
+
+	provider, err := NewProvider(cfg, testDeps())
+	require.NoError(t, err)
+	assert.IsType(t, stubProvider{}, provider)
+}
+
+func TestNewProvider_UnknownType(t *testing.T) {
+	_, err := NewProvider(ProviderConfig{Type: "kafka"}, testDeps())
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrUnknownProviderType))
+	assert.Contains(t, err.Error(), `"kafka"`)
+}
+
+func TestProviderConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  ProviderConfig
+		want string
+	}{
+		{"missing type", ProviderConfig{}, "provider type is required"},
+		{"negative buffer", ProviderConfig{Type: ProviderTypeMemory, Memory: MemoryProviderConfig{BufferSize: -1}}, "buffer size"},
+		{"redis without stream", ProviderConfig{Type: ProviderTypeRedis, Redis: RedisProviderConfig{Group: "g", Consumer: "c"}}, "stream is required"},
+		{"file without dir", ProviderConfig{Type: ProviderTypeFile}, "dir is required"},
+		{"nats without url", ProviderConfig{Type: ProviderTypeNATS, NATS: NATSProviderConfig{Subject: "tasks"}}, "url is required"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewProvider(tt.cfg, testDeps())
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid provider config")
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
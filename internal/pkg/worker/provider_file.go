@@ -0,0 +1,390 @@
+package worker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	filePendingName  = "pending.jsonl"
+	fileInFlightName = "inflight.jsonl"
+	fileDLQName      = "dlq.jsonl"
+	fileLockName     = ".lock"
+)
+
+// FileProviderConfig holds configuration for the file provider
+type FileProviderConfig struct {
+	// Dir is the directory holding the queue files; it is created if missing
+	Dir string
+
+	// InFlightTimeout is how long a fetched task may stay unacked before a
+	// Fetch, by this or another process sharing Dir, moves it back to
+	// pending. Defaults to 5 minutes; keep it above the longest task.
+	InFlightTimeout time.Duration
+}
+
+// inFlightEntry is a line of the in-flight file: a fetched task and when
+// its lease runs out
+type inFlightEntry struct {
+	Task       *Task     `json:"task"`
+	LeaseUntil time.Time `json:"lease_until"`
+}
+
+// FileProvider implements the Provider interface with newline-delimited JSON
+// files, so tasks survive restarts without Redis. Pending, in-flight and
+// dead-lettered tasks are kept in separate files, and every operation holds
+// an exclusive lock on the directory so several processes can share it.
+// It rewrites whole files on each operation and is meant for local
+// development and debugging, not throughput.
+type FileProvider struct {
+	dir             string
+	inFlightTimeout time.Duration
+	logger          *logger.Logger
+
+	// now is the clock used for ScheduledAt, replaceable in tests
+	now func() time.Time
+
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewFileProvider creates a file provider in config.Dir
+// Tasks whose in-flight lease has run out, e.g. because the process that
+// fetched them stopped, are moved back to pending. Tasks still leased by
+// another process sharing the directory are left alone.
+func NewFileProvider(config FileProviderConfig, log *logger.Logger) (*FileProvider, error) {
+	if config.Dir == "" {
+		return nil, fmt.Errorf("file provider dir is required")
+	}
+	if config.InFlightTimeout <= 0 {
+		config.InFlightTimeout = 5 * time.Minute
+	}
+	if err := os.MkdirAll(config.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create file provider dir: %w", err)
+	}
+
+	p := &FileProvider{
+		dir:             config.Dir,
+		inFlightTimeout: config.InFlightTimeout,
+		logger:          log,
+		now:             time.Now,
+	}
+
+	recovered := 0
+	err := p.withLock(func() error {
+		var err error
+		recovered, err = p.recoverExpired()
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to recover in-flight tasks: %w", err)
+	}
+
+	log.Info("File provider initialized", zap.String("dir", config.Dir), zap.Int("recovered", recovered))
+	return p, nil
+}
+
+// Fetch moves the oldest due pending task to the in-flight file, leased
+// for InFlightTimeout. Expired leases are recovered first.
+func (p *FileProvider) Fetch(ctx context.Context) (*Task, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var task *Task
+	err := p.withLock(func() error {
+		if recovered, err := p.recoverExpired(); err != nil {
+			return err
+		} else if recovered > 0 {
+			p.logger.Warn("Recovered expired in-flight tasks", zap.Int("count", recovered))
+		}
+
+		pending, err := p.readTasks(filePendingName)
+		if err != nil {
+			return err
+		}
+
+		now := p.now()
+		for i, t := range pending {
+			if !t.ScheduledAt.IsZero() && t.ScheduledAt.After(now) {
+				continue
+			}
+			entry := &inFlightEntry{Task: t, LeaseUntil: now.Add(p.inFlightTimeout)}
+			if err := appendLines(p.dir, fileInFlightName, entry); err != nil {
+				return err
+			}
+			task = t
+			return p.writeTasks(filePendingName, append(pending[:i:i], pending[i+1:]...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch task: %w", err)
+	}
+	return task, nil
+}
+
+// Ack removes a processed task from the in-flight file
+func (p *FileProvider) Ack(ctx context.Context, task *Task) error {
+	err := p.withLock(func() error {
+		_, err := p.removeInFlight(task.ID)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to ack task: %w", err)
+	}
+	return nil
+}
+
+// Nack moves a task from the in-flight file back to pending, or to the DLQ
+func (p *FileProvider) Nack(ctx context.Context, task *Task, requeue bool) error {
+	target := fileDLQName
+	if requeue {
+		target = filePendingName
+	}
+
+	err := p.withLock(func() error {
+		if _, err := p.removeInFlight(task.ID); err != nil {
+			return err
+		}
+		return p.appendTasks(target, task)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to nack task: %w", err)
+	}
+
+	if !requeue {
+		p.logger.Warn("Task sent to DLQ",
+			zap.String("task_id", task.ID),
+			zap.Int("retry", task.Retry),
+			zap.String("last_error", task.LastError),
+		)
+	}
+	return nil
+}
+
+// Close marks the provider closed; the files are left in place
+func (p *FileProvider) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.logger.Info("File provider closed")
+	return nil
+}
+
+// EnqueueTask is a helper method to enqueue a new task
+func (p *FileProvider) EnqueueTask(ctx context.Context, task *Task) (string, error) {
+	if task.ID == "" {
+		task.ID = uuid.NewString()
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = p.now()
+	}
+
+	if err := p.withLock(func() error { return p.appendTasks(filePendingName, task) }); err != nil {
+		return "", fmt.Errorf("failed to enqueue task: %w", err)
+	}
+	return task.ID, nil
+}
+
+// DeadLetters returns the tasks that were sent to the DLQ
+func (p *FileProvider) DeadLetters() ([]*Task, error) {
+	var tasks []*Task
+	err := p.withLock(func() error {
+		var err error
+		tasks, err = p.readTasks(fileDLQName)
+		return err
+	})
+	return tasks, err
+}
+
+// withLock runs fn holding the provider mutex and the directory file lock
+func (p *FileProvider) withLock(fn func() error) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrProviderClosed
+	}
+
+	lock, err := os.OpenFile(filepath.Join(p.dir, fileLockName), os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	if err := lockFile(lock); err != nil {
+		return fmt.Errorf("failed to lock %s: %w", p.dir, err)
+	}
+	defer unlockFile(lock)
+
+	return fn()
+}
+
+// recoverExpired moves in-flight tasks whose lease has run out back to
+// pending and returns how many were moved
+func (p *FileProvider) recoverExpired() (int, error) {
+	inFlight, err := p.readInFlight()
+	if err != nil {
+		return 0, err
+	}
+
+	now := p.now()
+	var expired []*Task
+	live := inFlight[:0:0]
+	for _, entry := range inFlight {
+		if entry.LeaseUntil.After(now) {
+			live = append(live, entry)
+		} else {
+			expired = append(expired, entry.Task)
+		}
+	}
+	if len(expired) == 0 {
+		return 0, nil
+	}
+
+	if err := p.appendTasks(filePendingName, expired...); err != nil {
+		return 0, err
+	}
+	return len(expired), writeLines(p.dir, fileInFlightName, live)
+}
+
+// removeInFlight drops a task from the in-flight file, reporting whether it was there
+func (p *FileProvider) removeInFlight(id string) (bool, error) {
+	inFlight, err := p.readInFlight()
+	if err != nil {
+		return false, err
+	}
+	for i, entry := range inFlight {
+		if entry.Task.ID == id {
+			return true, writeLines(p.dir, fileInFlightName, append(inFlight[:i:i], inFlight[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// readInFlight reads the in-flight file
+// Lines holding a bare task, as written before leases, have no lease and
+// are recovered by the next Fetch.
+func (p *FileProvider) readInFlight() ([]*inFlightEntry, error) {
+	var entries []*inFlightEntry
+	err := p.readLines(fileInFlightName, func(line []byte) error {
+		var entry inFlightEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return err
+		}
+		if entry.Task == nil {
+			entry = inFlightEntry{Task: &Task{}}
+			if err := json.Unmarshal(line, entry.Task); err != nil {
+				return err
+			}
+		}
+		entries = append(entries, &entry)
+		return nil
+	})
+	return entries, err
+}
+
+// readTasks reads a queue file; a missing file is empty
+func (p *FileProvider) readTasks(name string) ([]*Task, error) {
+	var tasks []*Task
+	err := p.readLines(name, func(line []byte) error {
+		var task Task
+		if err := json.Unmarshal(line, &task); err != nil {
+			return err
+		}
+		tasks = append(tasks, &task)
+		return nil
+	})
+	return tasks, err
+}
+
+// readLines calls decode for each non-empty line of a queue file; a missing
+// file is empty. Lines that fail to decode are logged and skipped.
+func (p *FileProvider) readLines(name string, decode func(line []byte) error) error {
+	data, err := os.ReadFile(filepath.Join(p.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := decode(scanner.Bytes()); err != nil {
+			p.logger.Warn("Skipping malformed task line",
+				zap.String("file", name), zap.Int("line", line), zap.Error(err))
+		}
+	}
+	return scanner.Err()
+}
+
+// writeTasks replaces a queue file atomically
+func (p *FileProvider) writeTasks(name string, tasks []*Task) error {
+	return writeLines(p.dir, name, tasks)
+}
+
+// writeLines replaces a queue file atomically with one JSON line per value
+func writeLines[T any](dir, name string, values []T) error {
+	var buf bytes.Buffer
+	for _, value := range values {
+		line, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+
+	path := filepath.Join(dir, name)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// appendTasks appends tasks to a queue file
+func (p *FileProvider) appendTasks(name string, tasks ...*Task) error {
+	return appendLines(p.dir, name, tasks...)
+}
+
+// appendLines appends one JSON line per value to a queue file
+func appendLines[T any](dir, name string, values ...T) error {
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		line, err := json.Marshal(value)
+		if err != nil {
+			f.Close()
+			return err
+		}
+		if _, err := f.Write(append(line, '\n')); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package worker
+
+import "os"
+
+// lockFile is a no-op where flock is unavailable; the provider mutex still
+// serializes access within one process
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
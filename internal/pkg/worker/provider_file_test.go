@@ -0,0 +1,219 @@
+package worker
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestFileProvider(t *testing.T, dir string) *FileProvider {
+	t.Helper()
+
+	provider, err := NewFileProvider(FileProviderConfig{Dir: dir}, &logger.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+func TestFileProvider_FetchAckInOrder(t *testing.T) {
+	provider := newTestFileProvider(t, t.TempDir())
+	ctx := context.Background()
+
+	for _, payload := range []string{"first", "second"} {
+		_, err := provider.EnqueueTask(ctx, &Task{
+			Payload:  []byte(payload),
+			Metadata: map[string]string{"type": "email"},
+			Timeout:  time.Minute,
+		})
+		require.NoError(t, err)
+	}
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("first"), task.Payload)
+	assert.Equal(t, "email", task.Metadata["type"])
+	assert.Equal(t, time.Minute, task.Timeout)
+	require.NoError(t, provider.Ack(ctx, task))
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("second"), task.Payload)
+	require.NoError(t, provider.Ack(ctx, task))
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	inFlight, err := provider.readTasks(fileInFlightName)
+	require.NoError(t, err)
+	assert.Empty(t, inFlight)
+}
+
+func TestFileProvider_Nack(t *testing.T) {
+	provider := newTestFileProvider(t, t.TempDir())
+	ctx := context.Background()
+
+	_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte("retry-me")})
+	require.NoError(t, err)
+
+	// Requeue returns the task to pending
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	task.IncrementRetry()
+	require.NoError(t, provider.Nack(ctx, task, true))
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, 1, task.Retry)
+
+	// Without requeue it goes to the DLQ file
+	require.NoError(t, provider.Nack(ctx, task, false))
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	dlq, err := provider.DeadLetters()
+	require.NoError(t, err)
+	require.Len(t, dlq, 1)
+	assert.Equal(t, []byte("retry-me"), dlq[0].Payload)
+}
+
+func TestFileProvider_HonorsScheduledAt(t *testing.T) {
+	provider := newTestFileProvider(t, t.TempDir())
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte("later"), ScheduledAt: now.Add(time.Minute)})
+	require.NoError(t, err)
+	_, err = provider.EnqueueTask(ctx, &Task{Payload: []byte("now")})
+	require.NoError(t, err)
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("now"), task.Payload)
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	now = now.Add(time.Minute)
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("later"), task.Payload)
+}
+
+func TestFileProvider_DurableAcrossRestart(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	first := newTestFileProvider(t, dir)
+	for _, payload := range []string{"in-flight", "pending"} {
+		_, err := first.EnqueueTask(ctx, &Task{Payload: []byte(payload)})
+		require.NoError(t, err)
+	}
+
+	// Fetched but never acked, as if the process crashed
+	task, err := first.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	require.NoError(t, first.Close())
+
+	second := newTestFileProvider(t, dir)
+	now := time.Now()
+	second.now = func() time.Time { return now }
+
+	// The crashed fetch still holds its lease
+	task, err = second.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("pending"), task.Payload)
+	require.NoError(t, second.Ack(ctx, task))
+
+	task, err = second.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	// Once it runs out the task is delivered again
+	now = now.Add(5*time.Minute + time.Second)
+	task, err = second.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("in-flight"), task.Payload)
+	require.NoError(t, second.Ack(ctx, task))
+}
+
+func TestFileProvider_StartupLeavesOtherProcessesTasksInFlight(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	running := newTestFileProvider(t, dir)
+	_, err := running.EnqueueTask(ctx, &Task{Payload: []byte("busy")})
+	require.NoError(t, err)
+	task, err := running.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+
+	// Another process starting on the same directory
+	starting := newTestFileProvider(t, dir)
+	stolen, err := starting.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, stolen, "a task leased by a running process must not be redelivered")
+
+	require.NoError(t, running.Ack(ctx, task))
+	inFlight, err := starting.readInFlight()
+	require.NoError(t, err)
+	assert.Empty(t, inFlight)
+}
+
+func TestFileProvider_RecoversInFlightTasksWithoutLease(t *testing.T) {
+	dir := t.TempDir()
+	line := `{"id":"old","payload":"b2xk"}` + "\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, fileInFlightName), []byte(line), 0o644))
+
+	provider := newTestFileProvider(t, dir)
+
+	task, err := provider.Fetch(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "old", task.ID)
+	assert.Equal(t, []byte("old"), task.Payload)
+}
+
+func TestFileProvider_SkipsMalformedLines(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, filePendingName), []byte("{not json\n"), 0o644))
+
+	provider := newTestFileProvider(t, dir)
+	ctx := context.Background()
+
+	_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte("ok")})
+	require.NoError(t, err)
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("ok"), task.Payload)
+}
+
+func TestFileProvider_ClosedRejectsOperations(t *testing.T) {
+	provider := newTestFileProvider(t, t.TempDir())
+	require.NoError(t, provider.Close())
+
+	_, err := provider.EnqueueTask(context.Background(), &Task{Payload: []byte("late")})
+	assert.ErrorIs(t, err, ErrProviderClosed)
+}
@@ -0,0 +1,143 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// ErrProviderClosed is returned when enqueuing to a closed provider
+var ErrProviderClosed = errors.New("provider is closed")
+
+// MemoryProviderConfig holds configuration for the in-memory provider
+type MemoryProviderConfig struct {
+	// BufferSize is the maximum number of queued tasks
+	BufferSize int
+}
+
+// DefaultMemoryProviderConfig returns a config with sensible defaults
+func DefaultMemoryProviderConfig() MemoryProviderConfig {
+	return MemoryProviderConfig{
+		BufferSize: 1000,
+	}
+}
+
+// MemoryProvider implements the Provider interface using an in-process queue
+// Tasks are lost on restart, so it is intended for development and tests
+type MemoryProvider struct {
+	tasks  chan *Task
+	logger *logger.Logger
+
+	mu     sync.Mutex
+	dlq    []*Task
+	closed bool
+}
+
+// NewMemoryProvider creates a new in-memory provider
+func NewMemoryProvider(config MemoryProviderConfig, log *logger.Logger) *MemoryProvider {
+	bufferSize := config.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = DefaultMemoryProviderConfig().BufferSize
+	}
+
+	log.Info("Memory provider initialized", zap.Int("buffer_size", bufferSize))
+
+	return &MemoryProvider{
+		tasks:  make(chan *Task, bufferSize),
+		logger: log,
+	}
+}
+
+// Fetch retrieves the next task without blocking
+func (p *MemoryProvider) Fetch(ctx context.Context) (*Task, error) {
+	select {
+	case task := <-p.tasks:
+		return task, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+		return nil, nil
+	}
+}
+
+// Ack acknowledges successful processing of a task
+func (p *MemoryProvider) Ack(ctx context.Context, task *Task) error {
+	return nil
+}
+
+// Nack negatively acknowledges a task
+func (p *MemoryProvider) Nack(ctx context.Context, task *Task, requeue bool) error {
+	if requeue {
+		return p.enqueue(ctx, task)
+	}
+
+	p.mu.Lock()
+	p.dlq = append(p.dlq, task)
+	p.mu.Unlock()
+
+	p.logger.Warn("Task sent to DLQ",
+		zap.String("task_id", task.ID),
+		zap.Int("retry", task.Retry),
+		zap.String("last_error", task.LastError),
+	)
+	return nil
+}
+
+// Close cleans up the provider resources
+func (p *MemoryProvider) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.logger.Info("Memory provider closed")
+	return nil
+}
+
+// EnqueueTask is a helper method to enqueue a new task
+func (p *MemoryProvider) EnqueueTask(ctx context.Context, task *Task) (string, error) {
+	if task.ID == "" {
+		task.ID = uuid.NewString()
+	}
+	if task.CreatedAt.IsZero() {
+		task.CreatedAt = time.Now()
+	}
+
+	if err := p.enqueue(ctx, task); err != nil {
+		return "", err
+	}
+	return task.ID, nil
+}
+
+// DeadLetters returns the tasks that were sent to the DLQ
+func (p *MemoryProvider) DeadLetters() []*Task {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	tasks := make([]*Task, len(p.dlq))
+	copy(tasks, p.dlq)
+	return tasks
+}
+
+// enqueue adds a task to the queue, blocking while the buffer is full
+func (p *MemoryProvider) enqueue(ctx context.Context, task *Task) error {
+	p.mu.Lock()
+	closed := p.closed
+	p.mu.Unlock()
+	if closed {
+		return ErrProviderClosed
+	}
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to enqueue task: %w", ctx.Err())
+	}
+}
@@ -3,8 +3,11 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"myapp/internal/pkg/logger"
@@ -13,11 +16,18 @@ import (
 	"go.uber.org/zap"
 )
 
+// streamMetadataKey is the task metadata key holding the stream a task was read from
+const streamMetadataKey = "_stream"
+
 // RedisProviderConfig holds configuration for the Redis provider
 type RedisProviderConfig struct {
-	// Stream is the Redis stream name
+	// Stream is the Redis stream name (the stream EnqueueTask writes to)
 	Stream string
 
+	// Streams lists the streams to read in priority order, highest first
+	// Higher-priority streams are drained before lower ones; defaults to Stream
+	Streams []string
+
 	// Group is the consumer group name
 	Group string
 
@@ -44,6 +54,24 @@ type RedisProviderConfig struct {
 
 	// MaxLen is the maximum length of the stream (0 for unlimited)
 	MaxLen int64
+
+	// DelayedSet is the sorted set holding tasks scheduled for later,
+	// scored by execute-at time in milliseconds; defaults to Stream + ":delayed"
+	DelayedSet string
+
+	// DelayedPollInterval is how often due delayed tasks are moved to their stream
+	DelayedPollInterval time.Duration
+
+	// DelayedBatchSize is the maximum number of delayed tasks moved per poll
+	DelayedBatchSize int64
+
+	// RetainAcked keeps acknowledged messages in the stream, trimmed by
+	// MaxLen, instead of deleting them, so ReplaySince can re-deliver them
+	RetainAcked bool
+
+	// ReplayMarkerTTL is how long ReplaySince remembers a replayed message,
+	// so replaying an overlapping range doesn't deliver it twice
+	ReplayMarkerTTL time.Duration
 }
 
 // DefaultRedisProviderConfig returns a config with sensible defaults
@@ -59,6 +87,12 @@ func DefaultRedisProviderConfig(stream, group, consumer string) RedisProviderCon
 		EnableAutoClaim: true,
 		DLQStream:       stream + ":dlq",
 		MaxLen:          10000,
+
+		DelayedSet:          stream + ":delayed",
+		DelayedPollInterval: 1 * time.Second,
+		DelayedBatchSize:    100,
+
+		ReplayMarkerTTL: 7 * 24 * time.Hour,
 	}
 }
 
@@ -67,19 +101,49 @@ type RedisProvider struct {
 	client *redisv9.Client
 	config RedisProviderConfig
 	logger *logger.Logger
+
+	// now is the clock used for delayed tasks, replaceable in tests
+	now func() time.Time
+
+	stopMover chan struct{}
+	moverDone chan struct{}
+	closeOnce sync.Once
+
+	// buffers holds messages read in a batch but not yet returned by Fetch,
+	// keyed by stream so each priority is served in order
+	bufferMu sync.Mutex
+	buffers  map[string][]streamMessage
 }
 
 // NewRedisProvider creates a new Redis provider
 func NewRedisProvider(client *redisv9.Client, config RedisProviderConfig, log *logger.Logger) (*RedisProvider, error) {
+	if config.DelayedSet == "" {
+		config.DelayedSet = config.Stream + ":delayed"
+	}
+	if config.DelayedPollInterval <= 0 {
+		config.DelayedPollInterval = 1 * time.Second
+	}
+	if config.DelayedBatchSize <= 0 {
+		config.DelayedBatchSize = 100
+	}
+	if config.ReplayMarkerTTL <= 0 {
+		config.ReplayMarkerTTL = 7 * 24 * time.Hour
+	}
+
 	provider := &RedisProvider{
-		client: client,
-		config: config,
-		logger: log,
+		client:    client,
+		config:    config,
+		logger:    log,
+		now:       time.Now,
+		stopMover: make(chan struct{}),
+		moverDone: make(chan struct{}),
 	}
 
-	// Ensure consumer group exists
-	if err := provider.ensureGroup(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to ensure consumer group: %w", err)
+	// Ensure a consumer group exists on every stream
+	for _, stream := range provider.streams() {
+		if err := provider.ensureGroup(context.Background(), stream); err != nil {
+			return nil, fmt.Errorf("failed to ensure consumer group on %s: %w", stream, err)
+		}
 	}
 
 	// Ensure DLQ stream exists
@@ -89,16 +153,36 @@ func NewRedisProvider(client *redisv9.Client, config RedisProviderConfig, log *l
 
 	log.Info("Redis provider initialized",
 		zap.String("stream", config.Stream),
+		zap.Strings("streams", provider.streams()),
 		zap.String("group", config.Group),
 		zap.String("consumer", config.Consumer),
+		zap.String("delayed_set", config.DelayedSet),
 	)
 
+	go provider.runDelayedMover()
+
 	return provider, nil
 }
 
-// ensureGroup ensures the consumer group exists
-func (p *RedisProvider) ensureGroup(ctx context.Context) error {
-	err := p.client.XGroupCreateMkStream(ctx, p.config.Stream, p.config.Group, "$").Err()
+// streams returns the streams to read in priority order
+func (p *RedisProvider) streams() []string {
+	if len(p.config.Streams) > 0 {
+		return p.config.Streams
+	}
+	return []string{p.config.Stream}
+}
+
+// taskStream returns the stream a task was read from
+func (p *RedisProvider) taskStream(task *Task) string {
+	if stream := task.Metadata[streamMetadataKey]; stream != "" {
+		return stream
+	}
+	return p.config.Stream
+}
+
+// ensureGroup ensures the consumer group exists on a stream
+func (p *RedisProvider) ensureGroup(ctx context.Context, stream string) error {
+	err := p.client.XGroupCreateMkStream(ctx, stream, p.config.Group, "$").Err()
 	if err != nil && err.Error() != "BUSYGROUP Consumer Group name already exists" {
 		return err
 	}
@@ -114,26 +198,187 @@ func (p *RedisProvider) ensureDLQGroup(ctx context.Context) error {
 	return nil
 }
 
-// Fetch retrieves the next task from the Redis stream
+// streamMessage is a message read from a stream but not yet handed out
+type streamMessage struct {
+	stream string
+	msg    redisv9.XMessage
+
+	// bufferedAt is when the message was put in the buffer
+	bufferedAt time.Time
+}
+
+// Fetch retrieves the next task, draining higher-priority streams first
+// A read returns up to Count messages (ClaimCount when claiming); the first
+// is returned and the rest are buffered per stream. Each stream is tried in
+// priority order: its buffered messages first, then stale messages to claim,
+// then new ones, so a lower-priority buffer never delays a higher-priority
+// stream.
 func (p *RedisProvider) Fetch(ctx context.Context) (*Task, error) {
-	// Try to claim stale messages first (if enabled)
-	if p.config.EnableAutoClaim {
-		task, err := p.claimStaleMessage(ctx)
-		if err != nil {
-			p.logger.Warn("Failed to claim stale message", zap.Error(err))
+	streams := p.streams()
+
+	for _, stream := range streams {
+		if task, err, ok := p.nextBuffered(ctx, stream); ok {
+			return task, err
 		}
-		if task != nil {
-			return task, nil
+
+		if p.config.EnableAutoClaim {
+			msgs, err := p.claimStaleMessages(ctx, stream)
+			if err != nil {
+				p.logger.Warn("Failed to claim stale message", zap.String("stream", stream), zap.Error(err))
+			}
+			if len(msgs) > 0 {
+				return p.deliver(msgs)
+			}
 		}
+
+		// With several streams, poll each in priority order without blocking
+		if len(streams) > 1 {
+			msgs, err := p.readStreams(ctx, []string{stream}, -1)
+			if err != nil {
+				return nil, err
+			}
+			if len(msgs) > 0 {
+				return p.deliver(msgs)
+			}
+		}
+	}
+
+	// Nothing ready: block on all streams until a message arrives
+	msgs, err := p.readStreams(ctx, streams, p.config.Block)
+	if err != nil || len(msgs) == 0 {
+		return nil, err
+	}
+	return p.deliver(msgs)
+}
+
+// deliver buffers all but the first message and returns the first as a task
+func (p *RedisProvider) deliver(msgs []streamMessage) (*Task, error) {
+	now := p.now()
+
+	p.bufferMu.Lock()
+	if p.buffers == nil {
+		p.buffers = make(map[string][]streamMessage)
+	}
+	for _, m := range msgs[1:] {
+		m.bufferedAt = now
+		p.buffers[m.stream] = append(p.buffers[m.stream], m)
 	}
+	p.bufferMu.Unlock()
 
-	// Read new messages
-	streams, err := p.client.XReadGroup(ctx, &redisv9.XReadGroupArgs{
+	return p.messageToTask(msgs[0].stream, msgs[0].msg)
+}
+
+// nextBuffered pops the next buffered message of a stream; ok is false when
+// its buffer is empty
+// A message buffered for half of ClaimMinIdle or longer is re-claimed first:
+// that resets its idle time so it isn't auto-claimed while it is handled, and
+// a message another consumer already claimed and acked is dropped.
+func (p *RedisProvider) nextBuffered(ctx context.Context, stream string) (task *Task, err error, ok bool) {
+	for {
+		p.bufferMu.Lock()
+		buffered := p.buffers[stream]
+		if len(buffered) == 0 {
+			p.bufferMu.Unlock()
+			return nil, nil, false
+		}
+		next := buffered[0]
+		p.buffers[stream] = buffered[1:]
+		p.bufferMu.Unlock()
+
+		if p.stillOwned(ctx, next) {
+			task, err = p.messageToTask(next.stream, next.msg)
+			return task, err, true
+		}
+		p.logger.Warn("Dropped buffered message claimed by another consumer",
+			zap.String("stream", next.stream),
+			zap.String("message_id", next.msg.ID),
+		)
+	}
+}
+
+// stillOwned reports whether a buffered message is still pending on this
+// consumer, re-claiming it once it has waited half of ClaimMinIdle
+func (p *RedisProvider) stillOwned(ctx context.Context, m streamMessage) bool {
+	waited := p.now().Sub(m.bufferedAt)
+	if waited < p.config.ClaimMinIdle/2 {
+		return true
+	}
+
+	// Only a message idle for as long as it was buffered is still ours:
+	// a claim by another consumer would have reset its idle time
+	ids, err := p.client.XClaimJustID(ctx, &redisv9.XClaimArgs{
+		Stream:   m.stream,
 		Group:    p.config.Group,
 		Consumer: p.config.Consumer,
-		Streams:  []string{p.config.Stream, ">"},
+		MinIdle:  waited,
+		Messages: []string{m.msg.ID},
+	}).Result()
+	if err != nil {
+		p.logger.Warn("Failed to re-claim buffered message",
+			zap.String("stream", m.stream),
+			zap.String("message_id", m.msg.ID),
+			zap.Error(err),
+		)
+		return true
+	}
+	return len(ids) > 0
+}
+
+// Buffered returns the number of fetched messages waiting to be handed out
+func (p *RedisProvider) Buffered() int {
+	p.bufferMu.Lock()
+	defer p.bufferMu.Unlock()
+
+	total := 0
+	for _, buffered := range p.buffers {
+		total += len(buffered)
+	}
+	return total
+}
+
+// Shutdown implements ProviderShutdownHook by returning buffered messages to
+// their streams, so they are not stuck pending on this consumer until another
+// one claims them. Malformed buffered messages go to the DLQ.
+func (p *RedisProvider) Shutdown(ctx context.Context) error {
+	p.bufferMu.Lock()
+	var buffered []streamMessage
+	for _, stream := range p.streams() {
+		buffered = append(buffered, p.buffers[stream]...)
+	}
+	p.buffers = nil
+	p.bufferMu.Unlock()
+
+	var errs []error
+	for _, m := range buffered {
+		task, err := p.messageToTask(m.stream, m.msg)
+		if malformed := (*MalformedTaskError)(nil); errors.As(err, &malformed) {
+			errs = append(errs, p.Nack(ctx, malformed.Task, false))
+			continue
+		}
+		errs = append(errs, p.Nack(ctx, task, true))
+	}
+
+	if len(buffered) > 0 {
+		p.logger.Info("Returned buffered tasks to their streams", zap.Int("count", len(buffered)))
+	}
+	return errors.Join(errs...)
+}
+
+// readStreams reads new messages from the given streams
+// Messages are ordered by stream priority, then by ID within a stream
+func (p *RedisProvider) readStreams(ctx context.Context, streams []string, block time.Duration) ([]streamMessage, error) {
+	args := make([]string, 0, len(streams)*2)
+	args = append(args, streams...)
+	for range streams {
+		args = append(args, ">")
+	}
+
+	result, err := p.client.XReadGroup(ctx, &redisv9.XReadGroupArgs{
+		Group:    p.config.Group,
+		Consumer: p.config.Consumer,
+		Streams:  args,
 		Count:    p.config.Count,
-		Block:    p.config.Block,
+		Block:    block,
 	}).Result()
 
 	if err != nil {
@@ -144,19 +389,25 @@ func (p *RedisProvider) Fetch(ctx context.Context) (*Task, error) {
 		return nil, fmt.Errorf("failed to read from stream: %w", err)
 	}
 
-	if len(streams) == 0 || len(streams[0].Messages) == 0 {
-		return nil, nil
+	var msgs []streamMessage
+	for _, stream := range streams {
+		for _, xs := range result {
+			if xs.Stream != stream {
+				continue
+			}
+			for _, msg := range xs.Messages {
+				msgs = append(msgs, streamMessage{stream: stream, msg: msg})
+			}
+		}
 	}
 
-	// Convert first message to task
-	msg := streams[0].Messages[0]
-	return p.messageToTask(msg)
+	return msgs, nil
 }
 
-// claimStaleMessage attempts to claim a stale message from another consumer
-func (p *RedisProvider) claimStaleMessage(ctx context.Context) (*Task, error) {
-	msgs, _, err := p.client.XAutoClaim(ctx, &redisv9.XAutoClaimArgs{
-		Stream:   p.config.Stream,
+// claimStaleMessages attempts to claim stale messages from other consumers
+func (p *RedisProvider) claimStaleMessages(ctx context.Context, stream string) ([]streamMessage, error) {
+	claimed, _, err := p.client.XAutoClaim(ctx, &redisv9.XAutoClaimArgs{
+		Stream:   stream,
 		Group:    p.config.Group,
 		Consumer: p.config.Consumer,
 		MinIdle:  p.config.ClaimMinIdle,
@@ -168,78 +419,171 @@ func (p *RedisProvider) claimStaleMessage(ctx context.Context) (*Task, error) {
 		return nil, err
 	}
 
-	if len(msgs) == 0 {
-		return nil, nil
+	msgs := make([]streamMessage, 0, len(claimed))
+	for _, msg := range claimed {
+		msgs = append(msgs, streamMessage{stream: stream, msg: msg})
 	}
+	return msgs, nil
+}
 
-	// Return first claimed message
-	return p.messageToTask(msgs[0])
+// reservedMessageFields are stream fields that map to Task fields, so a flat
+// metadata value with the same name is never copied into Metadata
+var reservedMessageFields = map[string]bool{
+	"payload":         true,
+	"created_at":      true,
+	"retry":           true,
+	"max_retry":       true,
+	"timeout":         true,
+	"metadata":        true,
+	"scheduled_at":    true,
+	"last_error":      true,
+	dlqSourceField:    true,
+	streamMetadataKey: true,
 }
 
 // messageToTask converts a Redis stream message to a Task
-func (p *RedisProvider) messageToTask(msg redisv9.XMessage) (*Task, error) {
+// Malformed optional fields are logged and skipped. A message without a
+// string payload returns a *MalformedTaskError so the worker can DLQ it.
+func (p *RedisProvider) messageToTask(stream string, msg redisv9.XMessage) (*Task, error) {
 	task := &Task{
 		ID:       msg.ID,
 		Metadata: make(map[string]string),
 	}
+	log := p.logger.With(zap.String("task_id", msg.ID), zap.String("stream", stream))
 
-	// Extract task fields from message values
-	if payload, ok := msg.Values["payload"].(string); ok {
-		task.Payload = []byte(payload)
+	skipField := func(field string, err error) {
+		log.Warn("Skipping malformed task field", zap.String("field", field), zap.Error(err))
 	}
 
-	if createdAt, ok := msg.Values["created_at"].(string); ok {
+	stringField := func(field string) (string, bool) {
+		val, ok := msg.Values[field]
+		if !ok {
+			return "", false
+		}
+		str, ok := val.(string)
+		if !ok {
+			skipField(field, fmt.Errorf("unexpected type %T", val))
+		}
+		return str, ok
+	}
+
+	// Extract task fields from message values
+	payload, hasPayload := stringField("payload")
+	task.Payload = []byte(payload)
+
+	if createdAt, ok := stringField("created_at"); ok {
 		if t, err := time.Parse(time.RFC3339, createdAt); err == nil {
 			task.CreatedAt = t
+		} else {
+			skipField("created_at", err)
 		}
 	}
+	if task.CreatedAt.IsZero() {
+		// Fall back to the stream ID so expiry checks still work
+		task.CreatedAt = streamIDTime(msg.ID)
+	}
 
-	if retry, ok := msg.Values["retry"].(string); ok {
-		if r, err := strconv.Atoi(retry); err == nil {
+	if retry, ok := stringField("retry"); ok {
+		if r, err := strconv.Atoi(retry); err == nil && r >= 0 {
 			task.Retry = r
+		} else {
+			skipField("retry", fmt.Errorf("invalid retry %q", retry))
 		}
 	}
 
-	if maxRetry, ok := msg.Values["max_retry"].(string); ok {
-		if mr, err := strconv.Atoi(maxRetry); err == nil {
+	if maxRetry, ok := stringField("max_retry"); ok {
+		if mr, err := strconv.Atoi(maxRetry); err == nil && mr >= 0 {
 			task.MaxRetry = mr
+		} else {
+			skipField("max_retry", fmt.Errorf("invalid max_retry %q", maxRetry))
 		}
 	}
 
-	if timeout, ok := msg.Values["timeout"].(string); ok {
-		if d, err := time.ParseDuration(timeout); err == nil {
+	if timeout, ok := stringField("timeout"); ok {
+		if d, err := time.ParseDuration(timeout); err == nil && d >= 0 {
 			task.Timeout = d
+		} else {
+			skipField("timeout", fmt.Errorf("invalid timeout %q", timeout))
 		}
 	}
 
+	if scheduledAt, ok := stringField("scheduled_at"); ok {
+		if t, err := time.Parse(time.RFC3339, scheduledAt); err == nil {
+			task.ScheduledAt = t
+		} else {
+			skipField("scheduled_at", err)
+		}
+	}
+
+	if lastError, ok := stringField("last_error"); ok {
+		task.LastError = lastError
+	}
+
 	// Extract metadata
-	if metadataStr, ok := msg.Values["metadata"].(string); ok {
-		if err := json.Unmarshal([]byte(metadataStr), &task.Metadata); err != nil {
-			p.logger.Warn("Failed to unmarshal metadata", zap.Error(err))
+	if metadataStr, ok := stringField("metadata"); ok {
+		var metadata map[string]string
+		if err := json.Unmarshal([]byte(metadataStr), &metadata); err != nil {
+			skipField("metadata", err)
+		}
+		for key, val := range metadata {
+			if key != streamMetadataKey {
+				task.Metadata[key] = val
+			}
 		}
 	}
 
 	// Add individual metadata fields (for backward compatibility)
+	// The JSON metadata wins, and reserved fields are never copied
 	for key, val := range msg.Values {
-		if key != "payload" && key != "created_at" && key != "retry" && key != "max_retry" && key != "timeout" && key != "metadata" {
-			if strVal, ok := val.(string); ok {
-				task.Metadata[key] = strVal
-			}
+		if reservedMessageFields[key] {
+			continue
+		}
+		if _, exists := task.Metadata[key]; exists {
+			continue
+		}
+		if strVal, ok := val.(string); ok {
+			task.Metadata[key] = strVal
 		}
 	}
 
+	// Remember the source stream for Ack, Nack and requeue
+	task.Metadata[streamMetadataKey] = stream
+
+	if !hasPayload {
+		return nil, &MalformedTaskError{Task: task, Reason: "missing payload"}
+	}
+
 	return task, nil
 }
 
+// streamIDTime returns the time encoded in a stream ID ("<ms>-<seq>"),
+// or the zero time if the ID is not in that form
+func streamIDTime(id string) time.Time {
+	ms, _, found := strings.Cut(id, "-")
+	if !found {
+		return time.Time{}
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.UnixMilli(n)
+}
+
 // Ack acknowledges successful processing of a task
 func (p *RedisProvider) Ack(ctx context.Context, task *Task) error {
-	_, err := p.client.XAck(ctx, p.config.Stream, p.config.Group, task.ID).Result()
+	stream := p.taskStream(task)
+	_, err := p.client.XAck(ctx, stream, p.config.Group, task.ID).Result()
 	if err != nil {
 		return fmt.Errorf("failed to ack message: %w", err)
 	}
 
+	if p.config.RetainAcked {
+		return nil
+	}
+
 	// Delete the message from the stream
-	_, delErr := p.client.XDel(ctx, p.config.Stream, task.ID).Result()
+	_, delErr := p.client.XDel(ctx, stream, task.ID).Result()
 	if delErr != nil {
 		p.logger.Warn("Failed to delete acked message", zap.String("task_id", task.ID), zap.Error(delErr))
 	}
@@ -247,16 +591,63 @@ func (p *RedisProvider) Ack(ctx context.Context, task *Task) error {
 	return nil
 }
 
+// AckBatch acknowledges several tasks with one pipelined XACK and XDEL per stream
+func (p *RedisProvider) AckBatch(ctx context.Context, tasks []*Task) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	// Group IDs by source stream, keeping first-seen stream order
+	var streams []string
+	ids := make(map[string][]string)
+	for _, task := range tasks {
+		stream := p.taskStream(task)
+		if _, ok := ids[stream]; !ok {
+			streams = append(streams, stream)
+		}
+		ids[stream] = append(ids[stream], task.ID)
+	}
+
+	pipe := p.client.Pipeline()
+	acks := make([]*redisv9.IntCmd, len(streams))
+	dels := make([]*redisv9.IntCmd, len(streams))
+	for i, stream := range streams {
+		acks[i] = pipe.XAck(ctx, stream, p.config.Group, ids[stream]...)
+		if !p.config.RetainAcked {
+			dels[i] = pipe.XDel(ctx, stream, ids[stream]...)
+		}
+	}
+	// Per-command errors are checked below
+	_, _ = pipe.Exec(ctx)
+
+	var errs []error
+	for i, stream := range streams {
+		if err := acks[i].Err(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to ack %d messages on %s: %w", len(ids[stream]), stream, err))
+			continue
+		}
+		if dels[i] == nil {
+			continue
+		}
+		if err := dels[i].Err(); err != nil {
+			p.logger.Warn("Failed to delete acked messages", zap.String("stream", stream), zap.Int("count", len(ids[stream])), zap.Error(err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // Nack negatively acknowledges a task
 func (p *RedisProvider) Nack(ctx context.Context, task *Task, requeue bool) error {
 	// Always ack the original message first
-	_, err := p.client.XAck(ctx, p.config.Stream, p.config.Group, task.ID).Result()
+	stream := p.taskStream(task)
+	_, err := p.client.XAck(ctx, stream, p.config.Group, task.ID).Result()
 	if err != nil {
 		p.logger.Warn("Failed to ack message before nack", zap.String("task_id", task.ID), zap.Error(err))
 	}
 
 	// Delete from original stream
-	_, delErr := p.client.XDel(ctx, p.config.Stream, task.ID).Result()
+	_, delErr := p.client.XDel(ctx, stream, task.ID).Result()
 	if delErr != nil {
 		p.logger.Warn("Failed to delete nacked message", zap.String("task_id", task.ID), zap.Error(delErr))
 	}
@@ -270,18 +661,25 @@ func (p *RedisProvider) Nack(ctx context.Context, task *Task, requeue bool) erro
 	}
 }
 
-// requeue adds a task back to the stream for retry
+// requeue adds a task back to its source stream for retry
 func (p *RedisProvider) requeue(ctx context.Context, task *Task) error {
-	values := p.taskToValues(task)
-
-	// Calculate delay if scheduled
-	if !task.ScheduledAt.IsZero() && task.ScheduledAt.After(time.Now()) {
-		// For delayed tasks, we could use a separate delayed stream or store delay in metadata
-		values["scheduled_at"] = task.ScheduledAt.Format(time.RFC3339)
+	// Retries with a backoff wait in the delayed set
+	if p.isDelayed(task) {
+		if _, err := p.schedule(ctx, p.taskStream(task), task); err != nil {
+			return fmt.Errorf("failed to requeue task: %w", err)
+		}
+		p.logger.Info("Task requeued with delay",
+			zap.String("task_id", task.ID),
+			zap.Int("retry", task.Retry),
+			zap.Time("scheduled_at", task.ScheduledAt),
+		)
+		return nil
 	}
 
+	values := p.taskToValues(task)
+
 	_, err := p.client.XAdd(ctx, &redisv9.XAddArgs{
-		Stream: p.config.Stream,
+		Stream: p.taskStream(task),
 		MaxLen: p.config.MaxLen,
 		Approx: true,
 		Values: values,
@@ -299,6 +697,7 @@ func (p *RedisProvider) requeue(ctx context.Context, task *Task) error {
 func (p *RedisProvider) sendToDLQ(ctx context.Context, task *Task) error {
 	values := p.taskToValues(task)
 	values["dlq_timestamp"] = time.Now().Format(time.RFC3339)
+	values[dlqSourceField] = p.taskStream(task)
 
 	_, err := p.client.XAdd(ctx, &redisv9.XAddArgs{
 		Stream: p.config.DLQStream,
@@ -311,7 +710,11 @@ func (p *RedisProvider) sendToDLQ(ctx context.Context, task *Task) error {
 		return fmt.Errorf("failed to send task to DLQ: %w", err)
 	}
 
-	p.logger.Warn("Task sent to DLQ", zap.String("task_id", task.ID), zap.Int("retry", task.Retry))
+	p.logger.Warn("Task sent to DLQ",
+		zap.String("task_id", task.ID),
+		zap.Int("retry", task.Retry),
+		zap.String("last_error", task.LastError),
+	)
 	return nil
 }
 
@@ -328,14 +731,28 @@ func (p *RedisProvider) taskToValues(task *Task) map[string]interface{} {
 		values["timeout"] = task.Timeout.String()
 	}
 
-	// Serialize metadata as JSON
-	if len(task.Metadata) > 0 {
-		if metadataBytes, err := json.Marshal(task.Metadata); err == nil {
+	if !task.ScheduledAt.IsZero() {
+		values["scheduled_at"] = task.ScheduledAt.Format(time.RFC3339Nano)
+	}
+
+	if task.LastError != "" {
+		values["last_error"] = task.LastError
+	}
+
+	// Serialize metadata as JSON, leaving out the internal source stream
+	metadata := make(map[string]string, len(task.Metadata))
+	for key, val := range task.Metadata {
+		if key != streamMetadataKey {
+			metadata[key] = val
+		}
+	}
+	if len(metadata) > 0 {
+		if metadataBytes, err := json.Marshal(metadata); err == nil {
 			values["metadata"] = string(metadataBytes)
 		}
 
 		// Also add individual fields for easy querying
-		for key, val := range task.Metadata {
+		for key, val := range metadata {
 			values[key] = val
 		}
 	}
@@ -345,6 +762,11 @@ func (p *RedisProvider) taskToValues(task *Task) map[string]interface{} {
 
 // Close cleans up the provider resources
 func (p *RedisProvider) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.stopMover)
+		<-p.moverDone
+	})
+
 	// Redis client is shared, so we don't close it here
 	p.logger.Info("Redis provider closed")
 	return nil
@@ -352,14 +774,33 @@ func (p *RedisProvider) Close() error {
 
 // EnqueueTask is a helper method to enqueue a new task
 func (p *RedisProvider) EnqueueTask(ctx context.Context, task *Task) (string, error) {
+	return p.EnqueueTaskToStream(ctx, p.config.Stream, task)
+}
+
+// EnqueueTaskToStream enqueues a task on a specific stream, e.g. a priority stream
+func (p *RedisProvider) EnqueueTaskToStream(ctx context.Context, stream string, task *Task) (string, error) {
 	if task.CreatedAt.IsZero() {
 		task.CreatedAt = time.Now()
 	}
 
+	// Tasks scheduled for later wait in the delayed set
+	if p.isDelayed(task) {
+		id, err := p.schedule(ctx, stream, task)
+		if err != nil {
+			return "", fmt.Errorf("failed to enqueue task: %w", err)
+		}
+		p.logger.Info("Task scheduled",
+			zap.String("task_id", id),
+			zap.String("stream", stream),
+			zap.Time("scheduled_at", task.ScheduledAt),
+		)
+		return id, nil
+	}
+
 	values := p.taskToValues(task)
 
 	id, err := p.client.XAdd(ctx, &redisv9.XAddArgs{
-		Stream: p.config.Stream,
+		Stream: stream,
 		MaxLen: p.config.MaxLen,
 		Approx: true,
 		Values: values,
@@ -369,6 +810,6 @@ func (p *RedisProvider) EnqueueTask(ctx context.Context, task *Task) (string, er
 		return "", fmt.Errorf("failed to enqueue task: %w", err)
 	}
 
-	p.logger.Info("Task enqueued", zap.String("task_id", id))
+	p.logger.Info("Task enqueued", zap.String("task_id", id), zap.String("stream", stream))
 	return id, nil
 }
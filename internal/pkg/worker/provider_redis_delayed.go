@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	redisv9 "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// delayedEntry is the sorted set member for a task waiting for its ScheduledAt
+// ID keeps otherwise identical tasks from collapsing into one member
+type delayedEntry struct {
+	ID     string            `json:"id"`
+	Stream string            `json:"stream"`
+	Values map[string]string `json:"values"`
+}
+
+// isDelayed reports whether a task is scheduled for the future
+func (p *RedisProvider) isDelayed(task *Task) bool {
+	return !task.ScheduledAt.IsZero() && task.ScheduledAt.After(p.now())
+}
+
+// schedule adds a task to the delayed set, scored by its ScheduledAt
+func (p *RedisProvider) schedule(ctx context.Context, stream string, task *Task) (string, error) {
+	values := make(map[string]string)
+	for key, val := range p.taskToValues(task) {
+		values[key] = fmt.Sprint(val)
+	}
+
+	entry := delayedEntry{
+		ID:     uuid.NewString(),
+		Stream: stream,
+		Values: values,
+	}
+	member, err := json.Marshal(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal delayed task: %w", err)
+	}
+
+	err = p.client.ZAdd(ctx, p.config.DelayedSet, redisv9.Z{
+		Score:  float64(task.ScheduledAt.UnixMilli()),
+		Member: string(member),
+	}).Err()
+	if err != nil {
+		return "", fmt.Errorf("failed to add delayed task: %w", err)
+	}
+
+	return entry.ID, nil
+}
+
+// runDelayedMover periodically moves due delayed tasks onto their streams
+func (p *RedisProvider) runDelayedMover() {
+	defer close(p.moverDone)
+
+	ticker := time.NewTicker(p.config.DelayedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stopMover:
+			return
+		case <-ticker.C:
+			if _, err := p.MoveDueTasks(context.Background()); err != nil {
+				p.logger.Warn("Failed to move delayed tasks", zap.Error(err))
+			}
+		}
+	}
+}
+
+// moveDueScript moves due members of the delayed set (KEYS[1]) onto their
+// streams in one step: each is removed from the set and added to its stream
+// together, so a task is never lost between the two or moved twice by
+// providers polling the same set. ARGV holds the max score, the batch size
+// and the stream MAXLEN (0 = unbounded). It returns the number moved and the
+// members that could not be decoded, which are dropped.
+// The streams are named inside the members, so on Redis Cluster they must
+// share the delayed set's hash slot, e.g. through a hash tag.
+var moveDueScript = redisv9.NewScript(`
+	local members = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, ARGV[2])
+	local maxlen = tonumber(ARGV[3])
+	local moved = 0
+	local malformed = {}
+
+	for _, member in ipairs(members) do
+		redis.call('ZREM', KEYS[1], member)
+
+		local ok, entry = pcall(cjson.decode, member)
+		if ok and type(entry) == 'table' and type(entry.stream) == 'string' and type(entry.values) == 'table' then
+			local args = {entry.stream}
+			if maxlen > 0 then
+				table.insert(args, 'MAXLEN')
+				table.insert(args, '~')
+				table.insert(args, maxlen)
+			end
+			table.insert(args, '*')
+			for field, value in pairs(entry.values) do
+				table.insert(args, field)
+				table.insert(args, value)
+			end
+			redis.call('XADD', unpack(args))
+			moved = moved + 1
+		else
+			table.insert(malformed, member)
+		end
+	end
+
+	return {moved, malformed}
+`)
+
+// MoveDueTasks moves delayed tasks whose ScheduledAt has passed onto their
+// streams and returns how many were moved. Up to DelayedBatchSize tasks are
+// moved atomically by one script, so with several providers polling the same
+// set only one of them moves a given task.
+func (p *RedisProvider) MoveDueTasks(ctx context.Context) (int, error) {
+	res, err := moveDueScript.Run(ctx, p.client,
+		[]string{p.config.DelayedSet},
+		p.now().UnixMilli(),
+		p.config.DelayedBatchSize,
+		p.config.MaxLen,
+	).Slice()
+	if err != nil {
+		return 0, fmt.Errorf("failed to move delayed tasks: %w", err)
+	}
+	if len(res) != 2 {
+		return 0, fmt.Errorf("failed to move delayed tasks: unexpected reply %v", res)
+	}
+
+	moved, _ := res[0].(int64)
+	malformed, _ := res[1].([]interface{})
+	for _, member := range malformed {
+		p.logger.Error("Dropped malformed delayed task", zap.Any("member", member))
+	}
+
+	if moved > 0 {
+		p.logger.Info("Moved delayed tasks", zap.Int64("count", moved))
+	}
+	return int(moved), nil
+}
@@ -0,0 +1,121 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	redisv9 "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// dlqSourceField is the DLQ message field holding the stream a task came from
+const dlqSourceField = "dlq_source_stream"
+
+// PeekDLQ returns up to count tasks from the dead letter queue, oldest first,
+// without removing them
+func (p *RedisProvider) PeekDLQ(ctx context.Context, count int) ([]*Task, error) {
+	msgs, err := p.readDLQ(ctx, count)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := make([]*Task, 0, len(msgs))
+	for _, msg := range msgs {
+		task, err := p.messageToTask(p.config.DLQStream, msg)
+		if malformed := (*MalformedTaskError)(nil); errors.As(err, &malformed) {
+			// Show what we have; operators inspect the DLQ precisely for these
+			task = malformed.Task
+		} else if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, nil
+}
+
+// replayDLQScript moves one message from the DLQ (KEYS[1]) to its stream
+// (KEYS[2]) in one step. The message is deleted first and only added when
+// it was still there, so a task is neither lost nor replayed twice by
+// concurrent replays. ARGV holds the DLQ group, the message ID, the stream
+// MAXLEN (0 = unbounded) and then the field/value pairs to add.
+var replayDLQScript = redisv9.NewScript(`
+	if redis.call('XDEL', KEYS[1], ARGV[2]) == 0 then
+		return 0
+	end
+	-- In case a consumer read it; the group may not exist
+	redis.pcall('XACK', KEYS[1], ARGV[1], ARGV[2])
+
+	local args = {KEYS[2]}
+	local maxlen = tonumber(ARGV[3])
+	if maxlen > 0 then
+		table.insert(args, 'MAXLEN')
+		table.insert(args, '~')
+		table.insert(args, maxlen)
+	end
+	table.insert(args, '*')
+	for i = 4, #ARGV do
+		table.insert(args, ARGV[i])
+	end
+	redis.call('XADD', unpack(args))
+	return 1
+`)
+
+// ReplayDLQ moves up to count tasks from the dead letter queue back to the
+// stream they failed on, oldest first, with their retry counter reset to 0.
+// It returns how many tasks were replayed. Use it to recover after fixing
+// the cause of the failures.
+func (p *RedisProvider) ReplayDLQ(ctx context.Context, count int) (int, error) {
+	msgs, err := p.readDLQ(ctx, count)
+	if err != nil {
+		return 0, err
+	}
+
+	replayed := 0
+	for _, msg := range msgs {
+		stream := p.config.Stream
+		args := []interface{}{p.config.Group, msg.ID, p.config.MaxLen}
+		for key, val := range msg.Values {
+			switch key {
+			case dlqSourceField:
+				if source, ok := val.(string); ok && source != "" {
+					stream = source
+				}
+			case "dlq_timestamp", "scheduled_at", "retry":
+				// Dropped so the task is delivered now, with a fresh retry budget
+			default:
+				args = append(args, key, val)
+			}
+		}
+		args = append(args, "retry", "0")
+
+		moved, err := replayDLQScript.Run(ctx, p.client, []string{p.config.DLQStream, stream}, args...).Int()
+		if err != nil {
+			return replayed, fmt.Errorf("failed to replay DLQ message %s: %w", msg.ID, err)
+		}
+		if moved == 0 {
+			// Another replay moved it first
+			continue
+		}
+
+		replayed++
+	}
+
+	if replayed > 0 {
+		p.logger.Info("Replayed DLQ tasks", zap.Int("count", replayed), zap.String("dlq_stream", p.config.DLQStream))
+	}
+	return replayed, nil
+}
+
+// readDLQ returns up to count of the oldest DLQ messages
+func (p *RedisProvider) readDLQ(ctx context.Context, count int) ([]redisv9.XMessage, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	msgs, err := p.client.XRangeN(ctx, p.config.DLQStream, "-", "+", int64(count)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read DLQ: %w", err)
+	}
+	return msgs, nil
+}
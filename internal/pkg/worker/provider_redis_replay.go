@@ -0,0 +1,266 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	redisv9 "github.com/redis/go-redis/v9"
+	"go.uber.org/zap"
+)
+
+// replayOfField is the stream field of a replayed message holding the ID of
+// the message it copies; tasks see it as Metadata["replay_of"]
+const replayOfField = "replay_of"
+
+// replayBatchSize is how many stream entries ReplaySince reads per XRANGE
+const replayBatchSize = 100
+
+// ErrReplayNeedsRetainAcked is returned by ReplaySince when acknowledged
+// messages are deleted from the stream, leaving nothing to replay
+var ErrReplayNeedsRetainAcked = errors.New("replay needs RetainAcked")
+
+// replayMessageScript adds a copy of a message to its stream (KEYS[2])
+// unless the marker key (KEYS[1]) shows it was replayed, and sets the
+// marker, in one step: concurrent replays add each copy once, and a failed
+// XADD leaves no marker behind. ARGV holds the marker value, its TTL in
+// milliseconds (0 = no expiry), the stream MAXLEN (0 = unbounded) and then
+// the field/value pairs to add.
+var replayMessageScript = redisv9.NewScript(`
+	if redis.call('EXISTS', KEYS[1]) == 1 then
+		return 0
+	end
+
+	local args = {KEYS[2]}
+	local maxlen = tonumber(ARGV[3])
+	if maxlen > 0 then
+		table.insert(args, 'MAXLEN')
+		table.insert(args, '~')
+		table.insert(args, maxlen)
+	end
+	table.insert(args, '*')
+	for i = 4, #ARGV do
+		table.insert(args, ARGV[i])
+	end
+	redis.call('XADD', unpack(args))
+
+	local ttl = tonumber(ARGV[2])
+	if ttl > 0 then
+		redis.call('SET', KEYS[1], ARGV[1], 'PX', ttl)
+	else
+		redis.call('SET', KEYS[1], ARGV[1])
+	end
+	return 1
+`)
+
+// ReplayOption configures ReplaySince
+type ReplayOption func(*replayOptions)
+
+type replayOptions struct {
+	dryRun bool
+	limit  int
+}
+
+// WithReplayDryRun counts the messages ReplaySince would replay without
+// enqueuing them or marking them replayed
+func WithReplayDryRun() ReplayOption {
+	return func(o *replayOptions) {
+		o.dryRun = true
+	}
+}
+
+// WithReplayLimit stops ReplaySince after n messages are replayed (0 = no limit)
+func WithReplayLimit(n int) ReplayOption {
+	return func(o *replayOptions) {
+		o.limit = n
+	}
+}
+
+// ReplayResult reports what ReplaySince did, or would do in a dry run
+type ReplayResult struct {
+	// Matched is the number of delivered messages found from the start ID on
+	Matched int
+	// Replayed is the number of messages enqueued again
+	Replayed int
+	// Skipped is the number of messages already replayed by an earlier call
+	Skipped int
+	// Pending is the number of delivered messages left alone because they
+	// are not acknowledged yet; their consumer or the auto-claim still owns them
+	Pending int
+}
+
+// StreamIDFromTime returns the first stream ID at t, to replay from a time
+func StreamIDFromTime(t time.Time) string {
+	return strconv.FormatInt(t.UnixMilli(), 10) + "-0"
+}
+
+// ReplaySince enqueues again the messages of every stream, from startID up to
+// the last one delivered to the consumer group, oldest first. Use it to
+// recover after a bug mis-processed tasks. Messages not yet delivered are
+// left alone, since the group will still receive them, and so are messages
+// still pending in the group.
+//
+// Acknowledged messages are only in the stream with RetainAcked set;
+// without it ReplaySince returns ErrReplayNeedsRetainAcked. Each
+// copy gets its retry counter reset and a replay_of field naming the
+// original, and each original is replayed at most once within
+// ReplayMarkerTTL, so overlapping replays don't deliver a task twice.
+// Copies made by earlier replays are not replayed again.
+func (p *RedisProvider) ReplaySince(ctx context.Context, startID string, opts ...ReplayOption) (*ReplayResult, error) {
+	if !p.config.RetainAcked {
+		return nil, ErrReplayNeedsRetainAcked
+	}
+
+	var options replayOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	result := &ReplayResult{}
+	for _, stream := range p.streams() {
+		if err := p.replayStream(ctx, stream, startID, options, result); err != nil {
+			return result, err
+		}
+	}
+
+	if result.Replayed > 0 || result.Skipped > 0 || result.Pending > 0 {
+		p.logger.Info("Replayed stream tasks",
+			zap.String("start_id", startID),
+			zap.Bool("dry_run", options.dryRun),
+			zap.Int("matched", result.Matched),
+			zap.Int("replayed", result.Replayed),
+			zap.Int("skipped", result.Skipped),
+			zap.Int("pending", result.Pending),
+		)
+	}
+	return result, nil
+}
+
+// replayStream replays the delivered messages of one stream from startID on
+func (p *RedisProvider) replayStream(ctx context.Context, stream, startID string, options replayOptions, result *ReplayResult) error {
+	end, err := p.lastDeliveredID(ctx, stream)
+	if err != nil {
+		return err
+	}
+	if end == "" || end == "0-0" {
+		return nil
+	}
+
+	start := startID
+	for {
+		msgs, err := p.client.XRangeN(ctx, stream, start, end, replayBatchSize).Result()
+		if err != nil {
+			return fmt.Errorf("failed to read %s for replay: %w", stream, err)
+		}
+
+		if len(msgs) == 0 {
+			return nil
+		}
+		pending, err := p.pendingIDs(ctx, stream, msgs[0].ID, msgs[len(msgs)-1].ID)
+		if err != nil {
+			return err
+		}
+
+		for _, msg := range msgs {
+			if _, isCopy := msg.Values[replayOfField]; isCopy {
+				continue
+			}
+			if pending[msg.ID] {
+				result.Pending++
+				continue
+			}
+			if options.limit > 0 && result.Replayed >= options.limit {
+				return nil
+			}
+			result.Matched++
+
+			replayed, err := p.replayMessage(ctx, stream, msg, options.dryRun)
+			if err != nil {
+				return err
+			}
+			if replayed {
+				result.Replayed++
+			} else {
+				result.Skipped++
+			}
+		}
+
+		if len(msgs) < replayBatchSize {
+			return nil
+		}
+		start = "(" + msgs[len(msgs)-1].ID
+	}
+}
+
+// replayMessage enqueues a copy of msg unless it was replayed before
+func (p *RedisProvider) replayMessage(ctx context.Context, stream string, msg redisv9.XMessage, dryRun bool) (bool, error) {
+	marker := p.replayMarkerKey(stream, msg.ID)
+
+	if dryRun {
+		exists, err := p.client.Exists(ctx, marker).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to check replay of %s: %w", msg.ID, err)
+		}
+		return exists == 0, nil
+	}
+
+	args := []interface{}{time.Now().Format(time.RFC3339), p.config.ReplayMarkerTTL.Milliseconds(), p.config.MaxLen}
+	for key, val := range msg.Values {
+		if key != "scheduled_at" && key != "retry" {
+			args = append(args, key, val)
+		}
+	}
+	args = append(args, "retry", "0", replayOfField, msg.ID)
+
+	added, err := replayMessageScript.Run(ctx, p.client, []string{marker, stream}, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to replay message %s: %w", msg.ID, err)
+	}
+	return added == 1, nil
+}
+
+// pendingIDs returns the IDs from start to end that were delivered to the
+// consumer group but not acknowledged
+func (p *RedisProvider) pendingIDs(ctx context.Context, stream, start, end string) (map[string]bool, error) {
+	pending := make(map[string]bool)
+	for {
+		entries, err := p.client.XPendingExt(ctx, &redisv9.XPendingExtArgs{
+			Stream: stream,
+			Group:  p.config.Group,
+			Start:  start,
+			End:    end,
+			Count:  replayBatchSize,
+		}).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pending messages of %s: %w", stream, err)
+		}
+		for _, entry := range entries {
+			pending[entry.ID] = true
+		}
+		if len(entries) < replayBatchSize {
+			return pending, nil
+		}
+		start = "(" + entries[len(entries)-1].ID
+	}
+}
+
+// lastDeliveredID returns the last ID delivered to the consumer group on stream
+func (p *RedisProvider) lastDeliveredID(ctx context.Context, stream string) (string, error) {
+	groups, err := p.client.XInfoGroups(ctx, stream).Result()
+	if err != nil {
+		return "", fmt.Errorf("failed to read consumer groups of %s: %w", stream, err)
+	}
+	for _, group := range groups {
+		if group.Name == p.config.Group {
+			return group.LastDeliveredID, nil
+		}
+	}
+	return "", nil
+}
+
+// replayMarkerKey is the key recording that a message was replayed
+func (p *RedisProvider) replayMarkerKey(stream, id string) string {
+	return stream + ":replayed:" + id
+}
@@ -0,0 +1,1183 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/alicebob/miniredis/v2"
+	redisv9 "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeStreams is a go-redis hook that serves the stream commands used by
+// RedisProvider from memory, so tests don't need a Redis server
+type fakeStreams struct {
+	mu      sync.Mutex
+	seq     int
+	streams map[string][]redisv9.XMessage
+	// delivered tracks the last message index handed to the consumer group per stream
+	delivered map[string]int
+	// zsets holds sorted sets as member -> score
+	zsets map[string]map[string]float64
+	// deleted holds XDEL'd message IDs per stream
+	deleted map[string]map[string]bool
+	// stale holds messages pending on another consumer, returned by XAUTOCLAIM
+	stale map[string][]redisv9.XMessage
+	// reads counts XREADGROUP calls
+	reads int
+	// acked holds XACK'd message IDs per stream
+	acked map[string]map[string]bool
+	// acks counts XACK commands, pipelines counts pipelined round trips
+	acks      int
+	pipelines int
+}
+
+func newFakeStreams() *fakeStreams {
+	return &fakeStreams{
+		streams:   make(map[string][]redisv9.XMessage),
+		delivered: make(map[string]int),
+		zsets:     make(map[string]map[string]float64),
+		deleted:   make(map[string]map[string]bool),
+		stale:     make(map[string][]redisv9.XMessage),
+		acked:     make(map[string]map[string]bool),
+	}
+}
+
+func (f *fakeStreams) DialHook(next redisv9.DialHook) redisv9.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return nil, fmt.Errorf("fakeStreams: unexpected dial")
+	}
+}
+
+func (f *fakeStreams) ProcessPipelineHook(next redisv9.ProcessPipelineHook) redisv9.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redisv9.Cmder) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.pipelines++
+		var firstErr error
+		for _, cmd := range cmds {
+			if err := f.process(cmd); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		return firstErr
+	}
+}
+
+func (f *fakeStreams) ProcessHook(next redisv9.ProcessHook) redisv9.ProcessHook {
+	return func(ctx context.Context, cmd redisv9.Cmder) error {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return f.process(cmd)
+	}
+}
+
+// process serves one command; callers hold mu
+func (f *fakeStreams) process(cmd redisv9.Cmder) error {
+	args := make([]string, len(cmd.Args()))
+	for i, arg := range cmd.Args() {
+		args[i] = fmt.Sprint(arg)
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "xgroup":
+		cmd.(*redisv9.StatusCmd).SetVal("OK")
+	case "xadd":
+		cmd.(*redisv9.StringCmd).SetVal(f.xadd(args))
+	case "xreadgroup":
+		f.xreadgroup(cmd.(*redisv9.XStreamSliceCmd), args)
+	case "xautoclaim":
+		f.xautoclaim(cmd.(*redisv9.XAutoClaimCmd), args)
+	case "xack":
+		cmd.(*redisv9.IntCmd).SetVal(f.xack(args))
+	case "xdel":
+		cmd.(*redisv9.IntCmd).SetVal(f.xdel(args))
+	case "xrange":
+		cmd.(*redisv9.XMessageSliceCmd).SetVal(f.xrange(args))
+	case "zadd":
+		cmd.(*redisv9.IntCmd).SetVal(f.zadd(args))
+	default:
+		cmd.SetErr(fmt.Errorf("fakeStreams: unsupported command %s", args[0]))
+	}
+	return cmd.Err()
+}
+
+func (f *fakeStreams) xadd(args []string) string {
+	stream := args[1]
+	i := 2
+	for args[i] != "*" {
+		i++
+	}
+
+	values := make(map[string]interface{})
+	for i++; i+1 < len(args); i += 2 {
+		values[args[i]] = args[i+1]
+	}
+
+	f.seq++
+	id := fmt.Sprintf("%d-0", f.seq)
+	f.streams[stream] = append(f.streams[stream], redisv9.XMessage{ID: id, Values: values})
+	return id
+}
+
+func (f *fakeStreams) xreadgroup(cmd *redisv9.XStreamSliceCmd, args []string) {
+	f.reads++
+
+	count := 1
+	i := 0
+	for args[i] != "streams" {
+		if args[i] == "count" {
+			count, _ = strconv.Atoi(args[i+1])
+		}
+		i++
+	}
+	keys := args[i+1:]
+	names := keys[:len(keys)/2]
+
+	var result []redisv9.XStream
+	for _, name := range names {
+		next := f.delivered[name]
+		end := min(next+count, len(f.streams[name]))
+		if next < end {
+			f.delivered[name] = end
+			result = append(result, redisv9.XStream{
+				Stream:   name,
+				Messages: append([]redisv9.XMessage(nil), f.streams[name][next:end]...),
+			})
+		}
+	}
+
+	if len(result) == 0 {
+		cmd.SetErr(redisv9.Nil)
+		return
+	}
+	cmd.SetVal(result)
+}
+
+// xautoclaim hands out the messages queued with addStale, up to COUNT
+func (f *fakeStreams) xautoclaim(cmd *redisv9.XAutoClaimCmd, args []string) {
+	count := len(f.stale[args[1]])
+	for i, arg := range args {
+		if arg == "count" {
+			count, _ = strconv.Atoi(args[i+1])
+		}
+	}
+	count = min(count, len(f.stale[args[1]]))
+
+	claimed := f.stale[args[1]][:count]
+	f.stale[args[1]] = f.stale[args[1]][count:]
+	cmd.SetVal(claimed, "0-0")
+}
+
+// addStale queues a message as pending on another consumer, ready to be claimed
+func (f *fakeStreams) addStale(stream string, values map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	f.stale[stream] = append(f.stale[stream], redisv9.XMessage{ID: fmt.Sprintf("%d-0", f.seq), Values: values})
+}
+
+// readCount returns how many XREADGROUP calls were served
+func (f *fakeStreams) readCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.reads
+}
+
+func (f *fakeStreams) xack(args []string) int64 {
+	f.acks++
+	if f.acked[args[1]] == nil {
+		f.acked[args[1]] = make(map[string]bool)
+	}
+	for _, id := range args[3:] {
+		f.acked[args[1]][id] = true
+	}
+	return int64(len(args) - 3)
+}
+
+// ackStats returns the number of acked messages, XACK commands and pipelines
+func (f *fakeStreams) ackStats() (acked, acks, pipelines int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, ids := range f.acked {
+		acked += len(ids)
+	}
+	return acked, f.acks, f.pipelines
+}
+
+func (f *fakeStreams) xdel(args []string) int64 {
+	if f.deleted[args[1]] == nil {
+		f.deleted[args[1]] = make(map[string]bool)
+	}
+	for _, id := range args[2:] {
+		f.deleted[args[1]][id] = true
+	}
+	return int64(len(args) - 2)
+}
+
+// xrange supports only "-" and "+" bounds with an optional COUNT
+func (f *fakeStreams) xrange(args []string) []redisv9.XMessage {
+	count := -1
+	if len(args) == 6 {
+		count, _ = strconv.Atoi(args[5])
+	}
+
+	var msgs []redisv9.XMessage
+	for _, msg := range f.streams[args[1]] {
+		if count >= 0 && len(msgs) == count {
+			break
+		}
+		if !f.deleted[args[1]][msg.ID] {
+			msgs = append(msgs, msg)
+		}
+	}
+	return msgs
+}
+
+func (f *fakeStreams) zadd(args []string) int64 {
+	set := f.zsets[args[1]]
+	if set == nil {
+		set = make(map[string]float64)
+		f.zsets[args[1]] = set
+	}
+
+	var added int64
+	for i := 2; i+1 < len(args); i += 2 {
+		score, _ := strconv.ParseFloat(args[i], 64)
+		if _, exists := set[args[i+1]]; !exists {
+			added++
+		}
+		set[args[i+1]] = score
+	}
+	return added
+}
+
+func newFakeRedisProvider(t *testing.T, config RedisProviderConfig) *RedisProvider {
+	provider, _ := newFakeRedisProviderWithStreams(t, config)
+	return provider
+}
+
+func newFakeRedisProviderWithStreams(t *testing.T, config RedisProviderConfig) (*RedisProvider, *fakeStreams) {
+	t.Helper()
+
+	fake := newFakeStreams()
+	client := redisv9.NewClient(&redisv9.Options{Addr: "fake:6379"})
+	client.AddHook(fake)
+	t.Cleanup(func() { client.Close() })
+
+	provider, err := NewRedisProvider(client, config, &logger.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	t.Cleanup(func() { provider.Close() })
+	return provider, fake
+}
+
+// addRaw appends a message with arbitrary values, bypassing taskToValues
+func (f *fakeStreams) addRaw(stream string, values map[string]interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.seq++
+	f.streams[stream] = append(f.streams[stream], redisv9.XMessage{ID: fmt.Sprintf("%d-0", f.seq), Values: values})
+}
+
+// messages returns the messages on a stream that have not been deleted
+func (f *fakeStreams) messages(stream string) []redisv9.XMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.xrange([]string{"xrange", stream, "-", "+"})
+}
+
+func TestRedisProvider_Fetch_DrainsHighPriorityFirst(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks:bulk", "workers", "worker-1")
+	config.Streams = []string{"tasks:critical", "tasks:bulk"}
+	config.EnableAutoClaim = false
+	provider := newFakeRedisProvider(t, config)
+
+	ctx := context.Background()
+	enqueue := func(stream, payload string) {
+		_, err := provider.EnqueueTaskToStream(ctx, stream, &Task{Payload: []byte(payload)})
+		require.NoError(t, err)
+	}
+
+	// Interleave so arrival order differs from priority order
+	enqueue("tasks:bulk", "bulk-1")
+	enqueue("tasks:critical", "critical-1")
+	enqueue("tasks:bulk", "bulk-2")
+	enqueue("tasks:critical", "critical-2")
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		got = append(got, string(task.Payload))
+	}
+	assert.Equal(t, []string{"critical-1", "critical-2", "bulk-1", "bulk-2"}, got)
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+}
+
+func TestRedisProvider_Fetch_RecordsSourceStream(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks:bulk", "workers", "worker-1")
+	config.Streams = []string{"tasks:critical", "tasks:bulk"}
+	config.EnableAutoClaim = false
+	provider := newFakeRedisProvider(t, config)
+
+	ctx := context.Background()
+	_, err := provider.EnqueueTask(ctx, &Task{
+		Payload:  []byte("bulk-1"),
+		Metadata: map[string]string{"type": "email"},
+	})
+	require.NoError(t, err)
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "tasks:bulk", provider.taskStream(task))
+	assert.Equal(t, "email", task.Metadata["type"])
+
+	// The internal key is not written back on requeue
+	values := provider.taskToValues(task)
+	assert.NotContains(t, values, streamMetadataKey)
+	assert.NotContains(t, values["metadata"], streamMetadataKey)
+}
+
+func TestRedisProvider_MessageToTask_SkipsMalformedFields(t *testing.T) {
+	provider := newFakeRedisProvider(t, DefaultRedisProviderConfig("tasks", "workers", "worker-1"))
+
+	task, err := provider.messageToTask("tasks", redisv9.XMessage{
+		ID: "1700000000000-0",
+		Values: map[string]interface{}{
+			"payload":    "hello",
+			"created_at": "yesterday",
+			"retry":      "abc",
+			"max_retry":  "-1",
+			"timeout":    "soon",
+			"metadata":   "{not json",
+			"type":       "email",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("hello"), task.Payload)
+	assert.Equal(t, 0, task.Retry)
+	assert.Equal(t, 0, task.MaxRetry)
+	assert.Zero(t, task.Timeout)
+	assert.Equal(t, time.UnixMilli(1700000000000), task.CreatedAt)
+	assert.Equal(t, "email", task.Metadata["type"])
+}
+
+func TestRedisProvider_MessageToTask_ProtectsReservedKeys(t *testing.T) {
+	provider := newFakeRedisProvider(t, DefaultRedisProviderConfig("tasks", "workers", "worker-1"))
+
+	task, err := provider.messageToTask("tasks", redisv9.XMessage{
+		ID: "1-0",
+		Values: map[string]interface{}{
+			"payload":   "hello",
+			"retry":     "2",
+			"metadata":  `{"type":"email","_stream":"tasks:other"}`,
+			"type":      "sms",
+			"_stream":   "tasks:other",
+			"recipient": "user-1",
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, task.Retry)
+	assert.Equal(t, map[string]string{
+		"type":            "email",
+		"recipient":       "user-1",
+		streamMetadataKey: "tasks",
+	}, task.Metadata)
+	assert.NotContains(t, task.Metadata, "payload")
+	assert.NotContains(t, task.Metadata, "retry")
+}
+
+func TestRedisProvider_MessageToTask_MissingPayload(t *testing.T) {
+	provider := newFakeRedisProvider(t, DefaultRedisProviderConfig("tasks", "workers", "worker-1"))
+
+	for name, values := range map[string]map[string]interface{}{
+		"missing":   {"type": "email"},
+		"wrongType": {"payload": 42},
+	} {
+		t.Run(name, func(t *testing.T) {
+			task, err := provider.messageToTask("tasks", redisv9.XMessage{ID: "1-0", Values: values})
+			assert.Nil(t, task)
+			require.ErrorIs(t, err, ErrMalformedTask)
+
+			var malformed *MalformedTaskError
+			require.ErrorAs(t, err, &malformed)
+			assert.Equal(t, "1-0", malformed.Task.ID)
+			assert.Equal(t, "tasks", provider.taskStream(malformed.Task))
+		})
+	}
+}
+
+func TestWorker_SendsMalformedRedisMessageToDLQ(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	fake.addRaw("tasks", map[string]interface{}{"type": "email", "retry": "x"})
+
+	w := New(provider, Config{
+		Concurrency:     1,
+		ShutdownTimeout: time.Second,
+		PollInterval:    time.Millisecond,
+		ErrorBackoff:    time.Millisecond,
+	}, &logger.Logger{Logger: zap.NewNop()})
+
+	var processed atomic.Int32
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		processed.Add(1)
+		return nil
+	}))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	require.Eventually(t, func() bool {
+		return len(fake.messages(config.DLQStream)) == 1
+	}, 2*time.Second, time.Millisecond)
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+
+	assert.Zero(t, processed.Load())
+	dlq := fake.messages(config.DLQStream)[0]
+	assert.Equal(t, "email", dlq.Values["type"])
+	assert.Contains(t, dlq.Values["last_error"], "missing payload")
+}
+
+func TestRedisProvider_DLQKeepsLastError(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	ctx := context.Background()
+	_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte("{}"), Metadata: map[string]string{"type": "email"}})
+	require.NoError(t, err)
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	task.LastError = "smtp 550 mailbox unavailable"
+	require.NoError(t, provider.Nack(ctx, task, false))
+
+	dlq := fake.messages(config.DLQStream)
+	require.Len(t, dlq, 1)
+	assert.Equal(t, "smtp 550 mailbox unavailable", dlq[0].Values["last_error"])
+
+	peeked, err := provider.PeekDLQ(ctx, 1)
+	require.NoError(t, err)
+	require.Len(t, peeked, 1)
+	assert.Equal(t, "smtp 550 mailbox unavailable", peeked[0].LastError)
+	assert.NotContains(t, peeked[0].Metadata, "last_error")
+}
+
+// newMiniRedisProvider returns a provider on a miniredis server, for the
+// paths that run Lua scripts. The provider is closed, stopping its delayed
+// mover, when the test ends.
+func newMiniRedisProvider(t *testing.T, config RedisProviderConfig) (*RedisProvider, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	return newRedisProviderOn(t, mr, config), mr
+}
+
+// streamEntries returns the entries on a miniredis stream, none if it is missing
+func streamEntries(t *testing.T, mr *miniredis.Miniredis, stream string) []miniredis.StreamEntry {
+	t.Helper()
+	if !mr.Exists(stream) {
+		return nil
+	}
+	entries, err := mr.Stream(stream)
+	require.NoError(t, err)
+	return entries
+}
+
+// newRedisProviderOn returns another provider sharing mr
+func newRedisProviderOn(t *testing.T, mr *miniredis.Miniredis, config RedisProviderConfig) *RedisProvider {
+	t.Helper()
+
+	// An empty read blocks for real on miniredis
+	if config.Block > 10*time.Millisecond {
+		config.Block = 10 * time.Millisecond
+	}
+
+	client := redisv9.NewClient(&redisv9.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	provider, err := NewRedisProvider(client, config, &logger.Logger{Logger: zap.NewNop()})
+	require.NoError(t, err)
+	t.Cleanup(func() { provider.Close() })
+	return provider
+}
+
+func TestRedisProvider_DelayedTaskNotFetchedBeforeItsTime(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	// Drive the mover by hand
+	config.DelayedPollInterval = time.Hour
+	provider, _ := newMiniRedisProvider(t, config)
+
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_, err := provider.EnqueueTask(ctx, &Task{
+		Payload:     []byte("later"),
+		Metadata:    map[string]string{"type": "email"},
+		ScheduledAt: now.Add(2 * time.Second),
+	})
+	require.NoError(t, err)
+
+	// Not on the stream yet
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	// Still waiting just before it is due
+	now = now.Add(1900 * time.Millisecond)
+	moved, err := provider.MoveDueTasks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, task)
+
+	// Due after 2s
+	now = now.Add(100 * time.Millisecond)
+	moved, err = provider.MoveDueTasks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("later"), task.Payload)
+	assert.Equal(t, "email", task.Metadata["type"])
+
+	// Moved once only
+	moved, err = provider.MoveDueTasks(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, moved)
+}
+
+func TestRedisProvider_RequeueWithBackoffIsDelayed(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.DelayedPollInterval = time.Hour
+	provider, mr := newMiniRedisProvider(t, config)
+
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	task := &Task{
+		ID:          "1-0",
+		Payload:     []byte("retry-me"),
+		Retry:       1,
+		MaxRetry:    3,
+		Metadata:    map[string]string{streamMetadataKey: "tasks"},
+		ScheduledAt: now.Add(time.Minute),
+	}
+	require.NoError(t, provider.Nack(ctx, task, true))
+	assert.Empty(t, streamEntries(t, mr, "tasks"))
+
+	now = now.Add(time.Minute)
+	moved, err := provider.MoveDueTasks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	fetched, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, fetched)
+	assert.Equal(t, 1, fetched.Retry)
+	assert.WithinDuration(t, task.ScheduledAt, fetched.ScheduledAt, time.Millisecond)
+}
+
+func TestRedisProvider_MoveDueTasksMovesEachTaskOnce(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.DelayedPollInterval = time.Hour
+	config.DelayedBatchSize = 3
+	provider, mr := newMiniRedisProvider(t, config)
+	other := newRedisProviderOn(t, mr, config)
+
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+	other.now = provider.now
+
+	ctx := context.Background()
+	for i := 0; i < 20; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{
+			Payload:     []byte(strconv.Itoa(i)),
+			Metadata:    map[string]string{"type": "email"},
+			ScheduledAt: now.Add(time.Second),
+		})
+		require.NoError(t, err)
+	}
+	now = now.Add(time.Second)
+
+	// Both providers poll until the set is empty
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	for _, p := range []*RedisProvider{provider, other} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				moved, err := p.MoveDueTasks(ctx)
+				if err != nil {
+					t.Errorf("move: %v", err)
+					return
+				}
+				if moved == 0 {
+					return
+				}
+				total.Add(int64(moved))
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 20, total.Load())
+	assert.Len(t, streamEntries(t, mr, "tasks"), 20)
+	assert.False(t, mr.Exists(config.DelayedSet), "the delayed set should be empty")
+}
+
+func TestRedisProvider_MoveDueTasksDropsMalformedEntries(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.DelayedPollInterval = time.Hour
+	provider, mr := newMiniRedisProvider(t, config)
+
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	_, err := mr.ZAdd(config.DelayedSet, float64(now.UnixMilli()), "not json")
+	require.NoError(t, err)
+	_, err = provider.EnqueueTask(ctx, &Task{
+		Payload:     []byte("ok"),
+		Metadata:    map[string]string{"type": "email"},
+		ScheduledAt: now.Add(time.Millisecond),
+	})
+	require.NoError(t, err)
+	now = now.Add(time.Millisecond)
+
+	moved, err := provider.MoveDueTasks(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, moved)
+
+	assert.False(t, mr.Exists(config.DelayedSet), "the malformed entry is dropped, not retried")
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, []byte("ok"), task.Payload)
+}
+
+func TestRedisProvider_DelayedMoverRunsInBackground(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.DelayedPollInterval = 5 * time.Millisecond
+	provider, _ := newMiniRedisProvider(t, config)
+
+	ctx := context.Background()
+	_, err := provider.EnqueueTask(ctx, &Task{
+		Payload:     []byte("soon"),
+		Metadata:    map[string]string{"type": "email"},
+		ScheduledAt: time.Now().Add(20 * time.Millisecond),
+	})
+	require.NoError(t, err)
+
+	var task *Task
+	require.Eventually(t, func() bool {
+		task, err = provider.Fetch(ctx)
+		return err == nil && task != nil
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []byte("soon"), task.Payload)
+}
+
+func TestRedisProvider_PeekAndReplayDLQ(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks:bulk", "workers", "worker-1")
+	config.Streams = []string{"tasks:critical", "tasks:bulk"}
+	config.EnableAutoClaim = false
+	provider, mr := newMiniRedisProvider(t, config)
+
+	ctx := context.Background()
+	for _, stream := range []string{"tasks:critical", "tasks:bulk", "tasks:bulk"} {
+		_, err := provider.EnqueueTaskToStream(ctx, stream, &Task{
+			Payload:  []byte(stream),
+			MaxRetry: 3,
+			Metadata: map[string]string{"type": "email"},
+		})
+		require.NoError(t, err)
+	}
+
+	// Exhaust retries and dead-letter everything
+	for i := 0; i < 3; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		task.Retry = task.MaxRetry
+		require.NoError(t, provider.Nack(ctx, task, false))
+	}
+
+	// Peek does not consume
+	peeked, err := provider.PeekDLQ(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, peeked, 2)
+	assert.Equal(t, []byte("tasks:critical"), peeked[0].Payload)
+	assert.Equal(t, 3, peeked[0].Retry)
+	assert.Equal(t, "email", peeked[0].Metadata["type"])
+	assert.Len(t, streamEntries(t, mr, config.DLQStream), 3)
+
+	// Replay two; one stays in the DLQ
+	replayed, err := provider.ReplayDLQ(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, replayed)
+	assert.Len(t, streamEntries(t, mr, config.DLQStream), 1)
+
+	var got []*Task
+	for i := 0; i < 2; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		got = append(got, task)
+	}
+
+	// Each task returns to the stream it failed on with a fresh retry budget
+	assert.Equal(t, []byte("tasks:critical"), got[0].Payload)
+	assert.Equal(t, "tasks:critical", provider.taskStream(got[0]))
+	assert.Equal(t, []byte("tasks:bulk"), got[1].Payload)
+	assert.Equal(t, "tasks:bulk", provider.taskStream(got[1]))
+	for _, task := range got {
+		assert.Zero(t, task.Retry)
+		assert.Equal(t, 3, task.MaxRetry)
+		assert.NotContains(t, task.Metadata, "dlq_timestamp")
+		assert.NotContains(t, task.Metadata, dlqSourceField)
+	}
+
+	replayed, err = provider.ReplayDLQ(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 1, replayed)
+	assert.Empty(t, streamEntries(t, mr, config.DLQStream))
+}
+
+func TestRedisProvider_ConcurrentReplayDLQMovesEachTaskOnce(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	provider, mr := newMiniRedisProvider(t, config)
+	other := newRedisProviderOn(t, mr, config)
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(strconv.Itoa(i)), Metadata: map[string]string{"type": "email"}})
+		require.NoError(t, err)
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		require.NoError(t, provider.Nack(ctx, task, false))
+	}
+	require.Len(t, streamEntries(t, mr, config.DLQStream), 10)
+
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	for _, p := range []*RedisProvider{provider, other} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			replayed, err := p.ReplayDLQ(ctx, 10)
+			if err != nil {
+				t.Errorf("replay: %v", err)
+			}
+			total.Add(int64(replayed))
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 10, total.Load())
+	assert.Empty(t, streamEntries(t, mr, config.DLQStream))
+	assert.Len(t, streamEntries(t, mr, "tasks"), 10, "each task is replayed once")
+}
+
+func TestRedisProvider_ReplaySince(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.RetainAcked = true
+	provider, mr := newMiniRedisProvider(t, config)
+
+	ctx := context.Background()
+	var ids []string
+	for i := 0; i < 5; i++ {
+		id, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(fmt.Sprintf("task-%d", i)), MaxRetry: 3})
+		require.NoError(t, err)
+		ids = append(ids, id)
+	}
+
+	// Three tasks are processed; the last two are still waiting
+	for i := 0; i < 3; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		task.Retry = 2
+		require.NoError(t, provider.Ack(ctx, task))
+	}
+	require.Len(t, streamEntries(t, mr, "tasks"), 5, "acked messages are retained")
+
+	// A dry run only counts
+	result, err := provider.ReplaySince(ctx, ids[1], WithReplayDryRun())
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayResult{Matched: 2, Replayed: 2}, result)
+	assert.Len(t, streamEntries(t, mr, "tasks"), 5)
+
+	result, err = provider.ReplaySince(ctx, ids[1])
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayResult{Matched: 2, Replayed: 2}, result)
+	assert.Greater(t, mr.TTL(provider.replayMarkerKey("tasks", ids[1])), time.Duration(0))
+
+	// The waiting tasks come first, then the replayed ones
+	var payloads []string
+	var replays []*Task
+	for i := 0; i < 4; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		payloads = append(payloads, string(task.Payload))
+		if task.Metadata[replayOfField] != "" {
+			replays = append(replays, task)
+		}
+		require.NoError(t, provider.Ack(ctx, task))
+	}
+	assert.Equal(t, []string{"task-3", "task-4", "task-1", "task-2"}, payloads)
+	require.Len(t, replays, 2)
+	assert.Equal(t, ids[1], replays[0].Metadata[replayOfField])
+	assert.Equal(t, ids[2], replays[1].Metadata[replayOfField])
+	assert.Zero(t, replays[0].Retry)
+
+	// Replaying an overlapping range delivers each original only once and
+	// leaves the copies alone
+	result, err = provider.ReplaySince(ctx, "-")
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayResult{Matched: 5, Replayed: 3, Skipped: 2}, result)
+
+	var again []string
+	for {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		if task == nil {
+			break
+		}
+		again = append(again, string(task.Payload))
+	}
+	assert.Equal(t, []string{"task-0", "task-3", "task-4"}, again)
+}
+
+func TestRedisProvider_ReplaySince_Limit(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.RetainAcked = true
+	provider, _ := newMiniRedisProvider(t, config)
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte("x")})
+		require.NoError(t, err)
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NoError(t, provider.Ack(ctx, task))
+	}
+
+	result, err := provider.ReplaySince(ctx, "-", WithReplayLimit(2))
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.Replayed)
+
+	result, err = provider.ReplaySince(ctx, "-")
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayResult{Matched: 3, Replayed: 1, Skipped: 2}, result)
+}
+
+func TestRedisProvider_ReplaySince_SkipsPendingMessages(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.RetainAcked = true
+	provider, mr := newMiniRedisProvider(t, config)
+
+	ctx := context.Background()
+	var tasks []*Task
+	for i := 0; i < 3; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(fmt.Sprintf("task-%d", i))})
+		require.NoError(t, err)
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		tasks = append(tasks, task)
+	}
+	// The middle task is still being processed
+	require.NoError(t, provider.Ack(ctx, tasks[0]))
+	require.NoError(t, provider.Ack(ctx, tasks[2]))
+
+	result, err := provider.ReplaySince(ctx, "-")
+	require.NoError(t, err)
+	assert.Equal(t, &ReplayResult{Matched: 2, Replayed: 2, Pending: 1}, result)
+
+	var replayOf []string
+	for _, entry := range streamEntries(t, mr, "tasks")[3:] {
+		for i := 0; i+1 < len(entry.Values); i += 2 {
+			if entry.Values[i] == replayOfField {
+				replayOf = append(replayOf, entry.Values[i+1])
+			}
+		}
+	}
+	assert.Equal(t, []string{tasks[0].ID, tasks[2].ID}, replayOf)
+}
+
+func TestRedisProvider_ConcurrentReplaySinceAddsEachCopyOnce(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.RetainAcked = true
+	provider, mr := newMiniRedisProvider(t, config)
+	other := newRedisProviderOn(t, mr, config)
+
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(strconv.Itoa(i))})
+		require.NoError(t, err)
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NoError(t, provider.Ack(ctx, task))
+	}
+
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	for _, p := range []*RedisProvider{provider, other} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := p.ReplaySince(ctx, "-")
+			if err != nil {
+				t.Errorf("replay: %v", err)
+				return
+			}
+			total.Add(int64(result.Replayed))
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 10, total.Load())
+	assert.Len(t, streamEntries(t, mr, "tasks"), 20, "each task is replayed once")
+}
+
+func TestRedisProvider_ReplaySince_RequiresRetainAcked(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	provider, _ := newMiniRedisProvider(t, config)
+
+	_, err := provider.ReplaySince(context.Background(), "-", WithReplayDryRun())
+	assert.ErrorIs(t, err, ErrReplayNeedsRetainAcked)
+}
+
+func TestStreamIDFromTime(t *testing.T) {
+	assert.Equal(t, "1700000000123-0", StreamIDFromTime(time.UnixMilli(1700000000123)))
+}
+
+func TestRedisProvider_Fetch_ServesBatchFromBuffer(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.Count = 3
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	ctx := context.Background()
+	for i := 1; i <= 5; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(fmt.Sprintf("task-%d", i))})
+		require.NoError(t, err)
+	}
+
+	var got []string
+	for i := 0; i < 5; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		got = append(got, string(task.Payload))
+	}
+	assert.Equal(t, []string{"task-1", "task-2", "task-3", "task-4", "task-5"}, got)
+
+	// Two batched reads instead of five
+	assert.Equal(t, 2, fake.readCount())
+	assert.Zero(t, provider.Buffered())
+}
+
+func TestRedisProvider_Fetch_BuffersClaimedMessages(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.ClaimCount = 10
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	for _, payload := range []string{"stale-1", "stale-2"} {
+		fake.addStale("tasks", map[string]interface{}{"payload": payload})
+	}
+	ctx := context.Background()
+	_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte("fresh")})
+	require.NoError(t, err)
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		got = append(got, string(task.Payload))
+	}
+
+	// Both claimed messages come out of one XAUTOCLAIM before new ones are read
+	assert.Equal(t, []string{"stale-1", "stale-2", "fresh"}, got)
+	assert.Equal(t, 1, fake.readCount())
+}
+
+func TestRedisProvider_ShutdownRequeuesBufferedMessages(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.Count = 10
+	provider, fake := newFakeRedisProviderWithStreams(t, config)
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(fmt.Sprintf("task-%d", i)), Retry: 1, MaxRetry: 3})
+		require.NoError(t, err)
+	}
+	fake.addRaw("tasks", map[string]interface{}{"type": "no-payload"})
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	require.NoError(t, provider.Ack(ctx, task))
+	assert.Equal(t, 3, provider.Buffered())
+
+	require.NoError(t, provider.Shutdown(ctx))
+	assert.Zero(t, provider.Buffered())
+
+	// The two valid tasks are back on the stream with their retry count kept
+	var requeued []string
+	for _, msg := range fake.messages("tasks") {
+		if msg.Values["payload"] == nil {
+			continue
+		}
+		requeued = append(requeued, msg.Values["payload"].(string))
+		assert.Equal(t, "1", msg.Values["retry"])
+	}
+	assert.Equal(t, []string{"task-2", "task-3"}, requeued)
+
+	// The malformed one went to the DLQ
+	dlq := fake.messages(config.DLQStream)
+	require.Len(t, dlq, 1)
+	assert.Equal(t, "no-payload", dlq[0].Values["type"])
+}
+
+// TestRedisProvider_Fetch_BufferKeepsPriority buffers a bulk batch, then
+// enqueues a critical task; it must come out before the rest of the batch
+func TestRedisProvider_Fetch_BufferKeepsPriority(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks:bulk", "workers", "worker-1")
+	config.Streams = []string{"tasks:critical", "tasks:bulk"}
+	config.EnableAutoClaim = false
+	config.Count = 10
+	provider := newFakeRedisProvider(t, config)
+
+	ctx := context.Background()
+	enqueue := func(stream, payload string) {
+		_, err := provider.EnqueueTaskToStream(ctx, stream, &Task{Payload: []byte(payload)})
+		require.NoError(t, err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		enqueue("tasks:bulk", fmt.Sprintf("bulk-%d", i))
+	}
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "bulk-1", string(task.Payload))
+	assert.Equal(t, 2, provider.Buffered())
+
+	enqueue("tasks:critical", "critical-1")
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		task, err := provider.Fetch(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, task)
+		got = append(got, string(task.Payload))
+	}
+	assert.Equal(t, []string{"critical-1", "bulk-2", "bulk-3"}, got)
+}
+
+// TestRedisProvider_Fetch_DropsBufferedMessageClaimedElsewhere lets a
+// buffered message wait past half of ClaimMinIdle while another consumer
+// claims and acks it; it must not be handed out a second time
+func TestRedisProvider_Fetch_DropsBufferedMessageClaimedElsewhere(t *testing.T) {
+	config := DefaultRedisProviderConfig("tasks", "workers", "worker-1")
+	config.EnableAutoClaim = false
+	config.Count = 10
+	config.ClaimMinIdle = time.Minute
+	provider, mr := newMiniRedisProvider(t, config)
+
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	for i := 1; i <= 3; i++ {
+		_, err := provider.EnqueueTask(ctx, &Task{Payload: []byte(fmt.Sprintf("task-%d", i))})
+		require.NoError(t, err)
+	}
+
+	task, err := provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "task-1", string(task.Payload))
+
+	// Another consumer claims task-2 and finishes it
+	entries := streamEntries(t, mr, "tasks")
+	require.Len(t, entries, 3)
+	other := newRedisProviderOn(t, mr, DefaultRedisProviderConfig("tasks", "workers", "worker-2"))
+	stolen, err := other.client.XClaim(ctx, &redisv9.XClaimArgs{
+		Stream:   "tasks",
+		Group:    "workers",
+		Consumer: "worker-2",
+		Messages: []string{entries[1].ID},
+	}).Result()
+	require.NoError(t, err)
+	require.Len(t, stolen, 1)
+	require.NoError(t, other.client.XAck(ctx, "tasks", "workers", entries[1].ID).Err())
+
+	now = now.Add(time.Minute)
+
+	task, err = provider.Fetch(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, task)
+	assert.Equal(t, "task-3", string(task.Payload))
+	assert.Zero(t, provider.Buffered())
+
+	// task-3 was re-claimed by this consumer rather than left to go stale
+	pending, err := provider.client.XPendingExt(ctx, &redisv9.XPendingExtArgs{
+		Stream: "tasks",
+		Group:  "workers",
+		Start:  "-",
+		End:    "+",
+		Count:  10,
+	}).Result()
+	require.NoError(t, err)
+	require.Len(t, pending, 2)
+	assert.Equal(t, "worker-1", pending[1].Consumer)
+	assert.EqualValues(t, 2, pending[1].RetryCount)
+}
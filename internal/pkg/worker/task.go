@@ -29,6 +29,10 @@ type Task struct {
 
 	// ScheduledAt is when the task should be processed (for delayed tasks)
 	ScheduledAt time.Time
+
+	// LastError is why the most recent attempt failed. It is set before the
+	// task is retried or sent to the DLQ, so dead letters say why they died.
+	LastError string
 }
 
 // ShouldRetry returns true if the task can be retried
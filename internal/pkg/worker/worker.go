@@ -2,13 +2,16 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"myapp/internal/pkg/logger"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 )
 
 // Worker manages task processing with concurrency control
@@ -21,6 +24,22 @@ type Worker struct {
 	wg          sync.WaitGroup
 	stopCh      chan struct{}
 	mu          sync.RWMutex
+
+	// lifecycleMu orders starting the loops against closing stopCh, so
+	// no wg.Add can run once Stop has begun waiting
+	lifecycleMu sync.Mutex
+
+	// defaultHandler processes tasks without a type, if set
+	defaultHandler Handler
+
+	// inFlightSem bounds concurrent task processing when MaxInFlight is set
+	inFlightSem *semaphore.Weighted
+	inFlight    atomic.Int64
+
+	dlq *dlqMonitor
+
+	// acks buffers successful acks when AckBatchSize is set
+	acks *ackBatcher
 }
 
 // New creates a new Worker instance
@@ -32,7 +51,7 @@ func New(provider Provider, config Config, log *logger.Logger) *Worker {
 		config.ShutdownTimeout = 30 * time.Second
 	}
 
-	return &Worker{
+	w := &Worker{
 		provider:    provider,
 		registry:    make(map[string]Handler),
 		middlewares: []Middleware{},
@@ -40,6 +59,19 @@ func New(provider Provider, config Config, log *logger.Logger) *Worker {
 		logger:      log,
 		stopCh:      make(chan struct{}),
 	}
+	w.dlq = newDLQMonitor(config.DLQAlertThreshold, config.DLQAlertWindow, w.dlqAlertHandler())
+	if config.MaxInFlight > 0 {
+		w.inFlightSem = semaphore.NewWeighted(config.MaxInFlight)
+	}
+	if config.AckBatchSize > 0 {
+		if acker, ok := provider.(BatchAcker); ok {
+			w.acks = newAckBatcher(acker, config.AckBatchSize, config.AckFlushInterval, config.ShutdownTimeout, log)
+		} else {
+			log.Warn("Provider does not support batched acks, acking tasks individually")
+		}
+	}
+
+	return w
 }
 
 // Register registers a handler for a specific task name
@@ -50,6 +82,15 @@ func (w *Worker) Register(name string, handler Handler) {
 	w.logger.Info("Handler registered", zap.String("name", name))
 }
 
+// RegisterDefault registers the handler for tasks that have no type, even
+// after the TypeResolver has run. Without one such tasks are dead-lettered.
+func (w *Worker) RegisterDefault(handler Handler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.defaultHandler = handler
+	w.logger.Info("Default handler registered")
+}
+
 // Use adds a middleware to the worker
 func (w *Worker) Use(mw Middleware) {
 	w.mu.Lock()
@@ -61,12 +102,26 @@ func (w *Worker) Use(mw Middleware) {
 func (w *Worker) Start(ctx context.Context) error {
 	w.logger.Info("Starting worker", zap.Int("concurrency", w.config.Concurrency))
 
-	// Start worker goroutines
-	for i := 0; i < w.config.Concurrency; i++ {
-		w.wg.Add(1)
-		go w.processLoop(ctx, i)
+	if w.acks != nil {
+		go w.acks.run()
 	}
 
+	// Start worker goroutines, unless Stop already ran
+	w.lifecycleMu.Lock()
+	select {
+	case <-w.stopCh:
+	default:
+		if w.config.PartitionKey != nil {
+			w.startPartitions(ctx)
+		} else {
+			for i := 0; i < w.config.Concurrency; i++ {
+				w.wg.Add(1)
+				go w.processLoop(ctx, i)
+			}
+		}
+	}
+	w.lifecycleMu.Unlock()
+
 	// Wait for context cancellation or stop signal
 	select {
 	case <-ctx.Done():
@@ -80,13 +135,16 @@ func (w *Worker) Start(ctx context.Context) error {
 
 // Stop gracefully stops the worker
 func (w *Worker) Stop(ctx context.Context) error {
+	w.lifecycleMu.Lock()
 	select {
 	case <-w.stopCh:
 		// Already stopped
+		w.lifecycleMu.Unlock()
 		return nil
 	default:
 		close(w.stopCh)
 	}
+	w.lifecycleMu.Unlock()
 
 	// Wait for shutdown with timeout
 	done := make(chan struct{})
@@ -119,20 +177,71 @@ func (w *Worker) shutdown() error {
 		close(done)
 	}()
 
+	var waitErr error
 	select {
 	case <-done:
 		w.logger.Info("All workers finished")
 	case <-ctx.Done():
 		w.logger.Warn("Shutdown timeout exceeded, forcing stop")
-		return ctx.Err()
+		waitErr = ctx.Err()
 	}
 
+	// Tasks that did finish should not be redelivered
+	ackErr := w.flushAcks()
+
+	// Let the provider persist anything still pending, even after a
+	// timeout, so buffered tasks are not lost
+	hookErr := w.runShutdownHook()
+
 	// Close provider
 	if err := w.provider.Close(); err != nil {
 		w.logger.Error("Failed to close provider", zap.Error(err))
-		return err
+		return errors.Join(waitErr, ackErr, hookErr, err)
 	}
 
+	return errors.Join(waitErr, ackErr, hookErr)
+}
+
+// flushAcks sends any buffered acks with a fresh ShutdownTimeout budget
+func (w *Worker) flushAcks() error {
+	if w.acks == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := w.acks.Stop(ctx); err != nil {
+		return fmt.Errorf("flush acks: %w", err)
+	}
+	return nil
+}
+
+// ack acknowledges a processed task, through the ack batch when enabled
+func (w *Worker) ack(ctx context.Context, task *Task) error {
+	if w.acks != nil {
+		return w.acks.Add(ctx, task)
+	}
+	return w.provider.Ack(ctx, task)
+}
+
+// runShutdownHook calls the provider's shutdown hook, if it has one,
+// with a fresh ShutdownTimeout budget
+func (w *Worker) runShutdownHook() error {
+	hook, ok := w.provider.(ProviderShutdownHook)
+	if !ok {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.ShutdownTimeout)
+	defer cancel()
+
+	if err := hook.Shutdown(ctx); err != nil {
+		w.logger.Error("Provider shutdown hook failed", zap.Error(err))
+		return fmt.Errorf("provider shutdown hook: %w", err)
+	}
+
+	w.logger.Info("Provider shutdown hook completed")
 	return nil
 }
 
@@ -155,16 +264,8 @@ func (w *Worker) processLoop(ctx context.Context, workerID int) {
 		}
 
 		// Fetch next task
-		task, err := w.provider.Fetch(ctx)
-		if err != nil {
-			log.Error("Failed to fetch task", zap.Error(err))
-			time.Sleep(w.config.ErrorBackoff)
-			continue
-		}
-
-		// No task available
+		task := w.fetchNext(ctx, log)
 		if task == nil {
-			time.Sleep(w.config.PollInterval)
 			continue
 		}
 
@@ -173,6 +274,33 @@ func (w *Worker) processLoop(ctx context.Context, workerID int) {
 	}
 }
 
+// fetchNext fetches the next task, dead-lettering malformed ones and backing
+// off after an error or an empty queue. It returns nil when there is nothing
+// to process.
+func (w *Worker) fetchNext(ctx context.Context, log *logger.Logger) *Task {
+	task, err := w.provider.Fetch(ctx)
+	if malformed := (*MalformedTaskError)(nil); errors.As(err, &malformed) && malformed.Task != nil {
+		log.Error("Malformed task, sending to DLQ", zap.String("task_id", malformed.Task.ID), zap.Error(err))
+		if nackErr := w.deadLetter(ctx, malformed.Task, err.Error()); nackErr != nil {
+			log.Error("Failed to send malformed task to DLQ", zap.Error(nackErr))
+		}
+		return nil
+	}
+	if err != nil {
+		log.Error("Failed to fetch task", zap.Error(err))
+		time.Sleep(w.config.ErrorBackoff)
+		return nil
+	}
+
+	// No task available
+	if task == nil {
+		time.Sleep(w.config.PollInterval)
+		return nil
+	}
+
+	return task
+}
+
 // processTask handles a single task with timeout and recovery
 func (w *Worker) processTask(ctx context.Context, task *Task, log *logger.Logger) {
 	taskLog := log.With(
@@ -180,33 +308,42 @@ func (w *Worker) processTask(ctx context.Context, task *Task, log *logger.Logger
 		zap.Int("retry", task.Retry),
 	)
 
+	// Wait for an in-flight slot
+	if w.inFlightSem != nil {
+		if err := w.inFlightSem.Acquire(ctx, 1); err != nil {
+			taskLog.Warn("Worker stopping, requeueing task", zap.Error(err))
+			if nackErr := w.provider.Nack(context.Background(), task, true); nackErr != nil {
+				taskLog.Error("Failed to requeue task", zap.Error(nackErr))
+			}
+			return
+		}
+		defer w.inFlightSem.Release(1)
+	}
+	w.inFlight.Add(1)
+	defer w.inFlight.Add(-1)
+
 	// Check if task is expired
 	if task.IsExpired() {
 		taskLog.Warn("Task expired, sending to DLQ")
-		if err := w.provider.Nack(ctx, task, false); err != nil {
+		if err := w.deadLetter(ctx, task, fmt.Sprintf("task expired: timeout %s exceeded", task.Timeout)); err != nil {
 			taskLog.Error("Failed to nack expired task", zap.Error(err))
 		}
 		return
 	}
 
-	// Get task type from metadata
-	taskType := task.Metadata["type"]
-	if taskType == "" {
-		taskLog.Error("Task missing type metadata")
-		if err := w.provider.Nack(ctx, task, false); err != nil {
-			taskLog.Error("Failed to nack invalid task", zap.Error(err))
+	// Get handler for the task type
+	handler, taskType := w.resolveHandler(task)
+	if handler == nil {
+		if taskType == "" {
+			taskLog.Error("Task missing type metadata")
+			if err := w.deadLetter(ctx, task, "task missing type metadata"); err != nil {
+				taskLog.Error("Failed to nack invalid task", zap.Error(err))
+			}
+			return
 		}
-		return
-	}
-
-	// Get handler
-	w.mu.RLock()
-	handler, exists := w.registry[taskType]
-	w.mu.RUnlock()
 
-	if !exists {
 		taskLog.Error("No handler registered for task type", zap.String("type", taskType))
-		if err := w.provider.Nack(ctx, task, false); err != nil {
+		if err := w.deadLetter(ctx, task, fmt.Sprintf("no handler registered for task type %q", taskType)); err != nil {
 			taskLog.Error("Failed to nack unhandled task", zap.Error(err))
 		}
 		return
@@ -240,21 +377,64 @@ func (w *Worker) processTask(ctx context.Context, task *Task, log *logger.Logger
 		w.handleTaskError(ctx, task, err, taskLog)
 	} else {
 		taskLog.Info("Task processed successfully")
-		if ackErr := w.provider.Ack(ctx, task); ackErr != nil {
+		if ackErr := w.ack(ctx, task); ackErr != nil {
 			taskLog.Error("Failed to acknowledge task", zap.Error(ackErr))
 		}
 	}
 }
 
+// resolveHandler returns the handler for a task and its type
+// Tasks without "type" metadata are typed by the TypeResolver, if configured,
+// and otherwise go to the default handler. A nil handler means the task
+// cannot be routed.
+func (w *Worker) resolveHandler(task *Task) (Handler, string) {
+	taskType := task.Metadata["type"]
+	if taskType == "" && w.config.TypeResolver != nil {
+		if taskType = w.config.TypeResolver(task); taskType != "" {
+			// Keep the type for middlewares and across retries
+			if task.Metadata == nil {
+				task.Metadata = make(map[string]string)
+			}
+			task.Metadata["type"] = taskType
+		}
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if taskType == "" {
+		return w.defaultHandler, ""
+	}
+	return w.registry[taskType], taskType
+}
+
 // handleTaskError handles task processing errors with retry logic
 func (w *Worker) handleTaskError(ctx context.Context, task *Task, err error, log *logger.Logger) {
-	// Check if task should be retried
-	if task.ShouldRetry() {
+	task.LastError = err.Error()
+
+	// A task that couldn't run yet goes back without using up a retry
+	var requeueErr *RequeueError
+	if errors.As(err, &requeueErr) {
+		log.Debug("Requeueing task", zap.Duration("after", requeueErr.After))
+		task.ScheduledAt = time.Now().Add(requeueErr.After)
+		if err := w.provider.Nack(ctx, task, true); err != nil {
+			log.Error("Failed to requeue task", zap.Error(err))
+		}
+		return
+	}
+
+	// Check if task should be retried; a panic would most likely repeat
+	if task.ShouldRetry() && !errors.Is(err, ErrTaskPanicked) {
 		log.Info("Retrying task", zap.Int("next_retry", task.Retry+1))
 		task.IncrementRetry()
 
-		// Calculate backoff delay
-		delay := w.calculateBackoff(task.Retry)
+		// Calculate backoff delay, honouring a handler-chosen base
+		base := w.config.BaseBackoff
+		var backoffErr *RetryBackoffError
+		if errors.As(err, &backoffErr) {
+			base = backoffErr.BaseBackoff
+		}
+		delay := w.calculateBackoff(base, task.Retry)
 		task.ScheduledAt = time.Now().Add(delay)
 
 		// Requeue task
@@ -262,26 +442,40 @@ func (w *Worker) handleTaskError(ctx context.Context, task *Task, err error, log
 			log.Error("Failed to requeue task", zap.Error(err))
 		}
 	} else {
-		log.Warn("Task max retries exceeded, sending to DLQ")
+		if errors.Is(err, ErrTaskPanicked) {
+			log.Warn("Task panicked, sending to DLQ")
+		} else {
+			log.Warn("Task max retries exceeded, sending to DLQ")
+		}
 		// Send to dead letter queue
-		if err := w.provider.Nack(ctx, task, false); err != nil {
+		if err := w.deadLetter(ctx, task, err.Error()); err != nil {
 			log.Error("Failed to send task to DLQ", zap.Error(err))
 		}
 	}
 }
 
-// calculateBackoff calculates the backoff delay based on retry count
-func (w *Worker) calculateBackoff(retry int) time.Duration {
+// calculateBackoff calculates the backoff delay from base based on retry count
+func (w *Worker) calculateBackoff(base time.Duration, retry int) time.Duration {
 	switch w.config.BackoffStrategy {
 	case BackoffExponential:
-		return w.config.BaseBackoff * time.Duration(1<<uint(retry))
+		return base * time.Duration(1<<uint(retry))
 	case BackoffLinear:
-		return w.config.BaseBackoff * time.Duration(retry+1)
+		return base * time.Duration(retry+1)
 	default:
-		return w.config.BaseBackoff
+		return base
 	}
 }
 
+// InFlight returns the number of tasks currently being processed
+func (w *Worker) InFlight() int64 {
+	return w.inFlight.Load()
+}
+
+// MaxInFlight returns the configured in-flight limit (0 = unlimited)
+func (w *Worker) MaxInFlight() int64 {
+	return w.config.MaxInFlight
+}
+
 // GetHandler returns the handler for a given task type
 func (w *Worker) GetHandler(name string) (Handler, error) {
 	w.mu.RLock()
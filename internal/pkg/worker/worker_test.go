@@ -0,0 +1,411 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// hookProvider is an empty Provider that records shutdown hook calls
+type hookProvider struct {
+	stubProvider
+
+	mu       sync.Mutex
+	calls    []string
+	deadline time.Time
+	hookErr  error
+}
+
+func (p *hookProvider) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, "shutdown")
+	p.deadline, _ = ctx.Deadline()
+	return p.hookErr
+}
+
+func (p *hookProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, "close")
+	return nil
+}
+
+func runAndStop(t *testing.T, w *Worker) error {
+	t.Helper()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	require.NoError(t, w.Stop(context.Background()))
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop")
+		return nil
+	}
+}
+
+func TestWorker_CallsShutdownHookOnGracefulStop(t *testing.T) {
+	provider := &hookProvider{}
+	w := New(provider, Config{
+		Concurrency:     2,
+		ShutdownTimeout: 3 * time.Second,
+		PollInterval:    time.Millisecond,
+	}, &logger.Logger{Logger: zap.NewNop()})
+
+	start := time.Now()
+	require.NoError(t, runAndStop(t, w))
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	// Hook runs once, before Close
+	assert.Equal(t, []string{"shutdown", "close"}, provider.calls)
+
+	// And gets the configured shutdown timeout
+	require.False(t, provider.deadline.IsZero())
+	assert.WithinDuration(t, start.Add(3*time.Second), provider.deadline, time.Second)
+}
+
+func TestWorker_ShutdownHookErrorIsReturned(t *testing.T) {
+	provider := &hookProvider{hookErr: errors.New("flush failed")}
+	w := New(provider, Config{
+		Concurrency:     1,
+		ShutdownTimeout: time.Second,
+		PollInterval:    time.Millisecond,
+	}, &logger.Logger{Logger: zap.NewNop()})
+
+	err := runAndStop(t, w)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "flush failed")
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	// Provider is still closed
+	assert.Equal(t, []string{"shutdown", "close"}, provider.calls)
+}
+
+// blockingHookProvider hands out one task, then nothing
+type blockingHookProvider struct {
+	hookProvider
+	fetched atomic.Bool
+}
+
+func (p *blockingHookProvider) Fetch(ctx context.Context) (*Task, error) {
+	if p.fetched.CompareAndSwap(false, true) {
+		return &Task{ID: "stuck", Metadata: map[string]string{"type": "stuck"}}, nil
+	}
+	return nil, nil
+}
+
+func TestWorker_CallsShutdownHookAfterShutdownTimeout(t *testing.T) {
+	provider := &blockingHookProvider{}
+	w := New(provider, Config{
+		Concurrency:     1,
+		ShutdownTimeout: 50 * time.Millisecond,
+		PollInterval:    time.Millisecond,
+	}, &logger.Logger{Logger: zap.NewNop()})
+
+	release := make(chan struct{})
+	defer close(release)
+	w.Register("stuck", HandlerFunc(func(ctx context.Context, task *Task) error {
+		<-release
+		return nil
+	}))
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+	require.Eventually(t, func() bool { return w.InFlight() == 1 }, time.Second, time.Millisecond)
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, w.Stop(stopCtx), context.DeadlineExceeded)
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker did not stop")
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	// The hook still runs, then the provider is closed
+	assert.Equal(t, []string{"shutdown", "close"}, provider.calls)
+}
+
+func TestWorker_StopBeforeStart(t *testing.T) {
+	provider := &hookProvider{}
+	w := New(provider, Config{
+		Concurrency:     2,
+		ShutdownTimeout: time.Second,
+		PollInterval:    time.Millisecond,
+	}, &logger.Logger{Logger: zap.NewNop()})
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, w.Start(context.Background()))
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	assert.Equal(t, []string{"shutdown", "close"}, provider.calls)
+}
+
+func TestWorker_MaxInFlightCapsConcurrentTasks(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{BufferSize: 20}, log)
+	w := New(provider, Config{
+		Concurrency:     8,
+		MaxInFlight:     2,
+		ShutdownTimeout: 3 * time.Second,
+		PollInterval:    time.Millisecond,
+	}, log)
+
+	var (
+		mu      sync.Mutex
+		current int
+		peak    int
+		done    int
+	)
+	w.Register("slow", HandlerFunc(func(ctx context.Context, task *Task) error {
+		mu.Lock()
+		current++
+		peak = max(peak, current)
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		current--
+		done++
+		mu.Unlock()
+		return nil
+	}))
+
+	for i := 0; i < 10; i++ {
+		_, err := provider.EnqueueTask(context.Background(), &Task{
+			Payload:  []byte("{}"),
+			Metadata: map[string]string{"type": "slow"},
+		})
+		require.NoError(t, err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	require.Eventually(t, func() bool {
+		assert.LessOrEqual(t, w.InFlight(), int64(2))
+		mu.Lock()
+		defer mu.Unlock()
+		return done == 10
+	}, 5*time.Second, time.Millisecond)
+
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+
+	assert.Equal(t, 2, peak)
+	assert.Equal(t, int64(0), w.InFlight())
+	assert.Equal(t, int64(2), w.MaxInFlight())
+}
+
+// runTasks processes the given tasks on a memory provider and stops the worker
+func runTasks(t *testing.T, w *Worker, provider *MemoryProvider, tasks []*Task, done func() bool) {
+	t.Helper()
+
+	for _, task := range tasks {
+		_, err := provider.EnqueueTask(context.Background(), task)
+		require.NoError(t, err)
+	}
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(context.Background()) }()
+
+	require.Eventually(t, done, 2*time.Second, time.Millisecond)
+	require.NoError(t, w.Stop(context.Background()))
+	require.NoError(t, <-errCh)
+}
+
+func TestWorker_UntypedTaskGoesToDefaultHandler(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{Concurrency: 1, PollInterval: time.Millisecond}, log)
+
+	var handled atomic.Int32
+	w.RegisterDefault(HandlerFunc(func(ctx context.Context, task *Task) error {
+		handled.Add(1)
+		return nil
+	}))
+
+	runTasks(t, w, provider, []*Task{{Payload: []byte("{}")}}, func() bool {
+		return handled.Load() == 1
+	})
+	assert.Empty(t, provider.DeadLetters())
+}
+
+func TestWorker_TypeResolverRoutesUntypedTask(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{
+		Concurrency:  1,
+		PollInterval: time.Millisecond,
+		TypeResolver: StreamTypeResolver(map[string]string{"stream:emails": "email"}),
+	}, log)
+
+	var gotType atomic.Value
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		gotType.Store(task.Metadata["type"])
+		return nil
+	}))
+
+	task := &Task{
+		Payload:  []byte("{}"),
+		Metadata: map[string]string{streamMetadataKey: "stream:emails"},
+	}
+	runTasks(t, w, provider, []*Task{task}, func() bool {
+		return gotType.Load() != nil
+	})
+	assert.Equal(t, "email", gotType.Load())
+}
+
+func TestWorker_UntypedTaskRejectedWithoutFallback(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{
+		Concurrency:  1,
+		PollInterval: time.Millisecond,
+		// Resolves nothing for this task
+		TypeResolver: StreamTypeResolver(map[string]string{"stream:emails": "email"}),
+	}, log)
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		t.Error("untyped task must not be processed")
+		return nil
+	}))
+
+	runTasks(t, w, provider, []*Task{{Payload: []byte("{}")}}, func() bool {
+		return len(provider.DeadLetters()) == 1
+	})
+}
+
+func TestWorker_DeadLetterCarriesFinalError(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := NewMemoryProvider(MemoryProviderConfig{}, log)
+	w := New(provider, Config{
+		Concurrency:  1,
+		PollInterval: time.Millisecond,
+		BaseBackoff:  time.Millisecond,
+	}, log)
+	w.Register("email", HandlerFunc(func(ctx context.Context, task *Task) error {
+		return fmt.Errorf("attempt %d: smtp 550 mailbox unavailable", task.Retry)
+	}))
+
+	tasks := []*Task{
+		{ID: "retried", Payload: []byte("{}"), MaxRetry: 1, Metadata: map[string]string{"type": "email"}},
+		{ID: "unhandled", Payload: []byte("{}"), Metadata: map[string]string{"type": "sms"}},
+	}
+	runTasks(t, w, provider, tasks, func() bool {
+		return len(provider.DeadLetters()) == 2
+	})
+
+	reasons := make(map[string]string)
+	for _, task := range provider.DeadLetters() {
+		reasons[task.ID] = task.LastError
+	}
+	// The error from the final attempt, not the first
+	assert.Equal(t, "attempt 1: smtp 550 mailbox unavailable", reasons["retried"])
+	assert.Equal(t, `no handler registered for task type "sms"`, reasons["unhandled"])
+}
+
+func TestWorker_RetriedTaskCarriesLastError(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := &nackRecorder{}
+	w := New(provider, Config{BaseBackoff: time.Millisecond}, log)
+
+	task := &Task{ID: "t1", MaxRetry: 3}
+	w.handleTaskError(context.Background(), task, errors.New("connection reset"), log)
+
+	require.Len(t, provider.requeued, 1)
+	assert.Equal(t, "connection reset", provider.requeued[0].LastError)
+}
+
+// nackRecorder records the tasks requeued by the worker
+type nackRecorder struct {
+	stubProvider
+	requeued []*Task
+}
+
+func (p *nackRecorder) Nack(ctx context.Context, task *Task, requeue bool) error {
+	if requeue {
+		p.requeued = append(p.requeued, task)
+	}
+	return nil
+}
+
+func TestWorker_RetryBackoffErrorOverridesBase(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{name: "default base", err: errors.New("boom"), want: 2 * time.Second},
+		{name: "override base", err: WithRetryBackoff(errors.New("boom"), 10*time.Second), want: 20 * time.Second},
+		{name: "non-positive override ignored", err: WithRetryBackoff(errors.New("boom"), 0), want: 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &nackRecorder{}
+			w := New(provider, Config{
+				BackoffStrategy: BackoffLinear,
+				BaseBackoff:     time.Second,
+			}, log)
+
+			task := &Task{ID: "t1", MaxRetry: 3}
+			before := time.Now()
+			w.handleTaskError(context.Background(), task, tt.err, log)
+
+			require.Len(t, provider.requeued, 1)
+			// Linear: base * (retry+1) with retry incremented to 1
+			assert.WithinDuration(t, before.Add(tt.want), task.ScheduledAt, time.Second)
+		})
+	}
+}
+
+func TestWorker_RequeueKeepsRetryCount(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := &nackRecorder{}
+	w := New(provider, Config{BaseBackoff: time.Second}, log)
+
+	// Even a task on its last attempt goes back instead of to the DLQ
+	task := &Task{ID: "t1", Retry: 3, MaxRetry: 3}
+	before := time.Now()
+	w.handleTaskError(context.Background(), task, Requeue(errors.New("busy"), 5*time.Second), log)
+
+	require.Len(t, provider.requeued, 1)
+	assert.Equal(t, 3, task.Retry)
+	assert.WithinDuration(t, before.Add(5*time.Second), task.ScheduledAt, time.Second)
+	assert.Nil(t, Requeue(nil, time.Second))
+}
+
+func TestWithRetryBackoff_Unwraps(t *testing.T) {
+	sentinel := errors.New("boom")
+	err := WithRetryBackoff(sentinel, time.Second)
+	assert.ErrorIs(t, err, sentinel)
+	assert.Equal(t, "boom", err.Error())
+	assert.Nil(t, WithRetryBackoff(nil, time.Second))
+}
@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"myapp/internal/pkg/config"
+	"myapp/internal/pkg/server"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+)
+
+// UserContextKey is the echo context key holding the authenticated *UserCtx
+const UserContextKey = "user"
+
+// ErrNoUser is returned when a request carries no authenticated user
+var ErrNoUser = errors.New("no authenticated user in context")
+
+// UserCtx is the authenticated user of a request
+type UserCtx struct {
+	UserID uint
+	Email  string
+	Role   string
+}
+
+// Claims are the JWT claims issued by the auth service
+type Claims struct {
+	UserID uint   `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// AuthHandler validates tokens signed with the shared JWT secret
+type AuthHandler struct {
+	secret []byte
+}
+
+// NewAuthHandler creates an AuthHandler using jwt.secret from cfg
+func NewAuthHandler(cfg *config.Config) *AuthHandler {
+	return &AuthHandler{secret: []byte(cfg.JWT.Secret)}
+}
+
+// ParseToken verifies an HS256 token and returns its claims
+func (h *AuthHandler) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return h.secret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid token: %w", err)
+	}
+	return claims, nil
+}
+
+// JWTMiddleware rejects requests without a valid Bearer token and stores
+// the token's user in the context for GetUserFromContext
+func JWTMiddleware(h *AuthHandler) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			header := c.Request().Header.Get(echo.HeaderAuthorization)
+			tokenString, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || tokenString == "" {
+				return server.ErrorResponse(c, http.StatusUnauthorized, nil, "Missing bearer token")
+			}
+
+			claims, err := h.ParseToken(tokenString)
+			if err != nil {
+				return server.ErrorResponse(c, http.StatusUnauthorized, err.Error(), "Invalid token")
+			}
+
+			c.Set(UserContextKey, &UserCtx{
+				UserID: claims.UserID,
+				Email:  claims.Email,
+				Role:   claims.Role,
+			})
+			return next(c)
+		}
+	}
+}
+
+// GetUserFromContext returns the user stored by JWTMiddleware
+func GetUserFromContext(c echo.Context) (*UserCtx, error) {
+	user, ok := c.Get(UserContextKey).(*UserCtx)
+	if !ok || user == nil {
+		return nil, ErrNoUser
+	}
+	return user, nil
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/config"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signToken(t *testing.T, secret string, claims *Claims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	require.NoError(t, err)
+	return token
+}
+
+func serveWithJWT(h *AuthHandler, authorization string) (*httptest.ResponseRecorder, *UserCtx) {
+	e := echo.New()
+	var user *UserCtx
+	e.GET("/", func(c echo.Context) error {
+		user, _ = GetUserFromContext(c)
+		return c.NoContent(http.StatusOK)
+	}, JWTMiddleware(h))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if authorization != "" {
+		req.Header.Set(echo.HeaderAuthorization, authorization)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	return rec, user
+}
+
+func TestJWTMiddleware(t *testing.T) {
+	h := NewAuthHandler(&config.Config{JWT: config.JWTConfig{Secret: "secret"}})
+	expires := jwt.NewNumericDate(time.Now().Add(time.Hour))
+
+	t.Run("valid token", func(t *testing.T) {
+		token := signToken(t, "secret", &Claims{UserID: 7, Role: "admin", RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expires}})
+
+		rec, user := serveWithJWT(h, "Bearer "+token)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		require.NotNil(t, user)
+		assert.Equal(t, uint(7), user.UserID)
+		assert.Equal(t, "admin", user.Role)
+	})
+
+	t.Run("missing token", func(t *testing.T) {
+		rec, user := serveWithJWT(h, "")
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+		assert.Nil(t, user)
+	})
+
+	t.Run("wrong secret", func(t *testing.T) {
+		token := signToken(t, "other", &Claims{UserID: 7, RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expires}})
+
+		rec, _ := serveWithJWT(h, "Bearer "+token)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("expired token", func(t *testing.T) {
+		expired := jwt.NewNumericDate(time.Now().Add(-time.Minute))
+		token := signToken(t, "secret", &Claims{UserID: 7, RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: expired}})
+
+		rec, _ := serveWithJWT(h, "Bearer "+token)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+func TestGetUserFromContext_NoUser(t *testing.T) {
+	c := echo.New().NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+
+	_, err := GetUserFromContext(c)
+	assert.ErrorIs(t, err, ErrNoUser)
+}
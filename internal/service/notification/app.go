@@ -11,6 +11,7 @@ import (
 	"myapp/internal/pkg/logger"
 	"myapp/internal/pkg/server"
 	workerpkg "myapp/internal/pkg/worker"
+	"myapp/internal/service/auth"
 
 	"myapp/internal/service/notification/channel"
 	"myapp/internal/service/notification/config"
@@ -42,6 +43,7 @@ var NotificationApp = fx.Options(
 		repository.NewNotificationRepository,
 		service.NewNotificationService,
 		handler.NewNotificationHandler,
+		auth.NewAuthHandler,
 		provideInMemoryQueue,
 		provideInMemoryProvider,
 		provideNotificationPoller,
@@ -52,9 +54,18 @@ var NotificationApp = fx.Options(
 	// Register routes
 	fx.Invoke(registerNotificationRoutes),
 
+	// Check target users when the deployment provides a UserChecker
+	fx.Invoke(attachUserChecker),
+
 	// Register worker health provider
 	fx.Invoke(provideWorkerHealthProvider),
 
+	// Report the worker in the admin status endpoint
+	fx.Invoke(attachWorkerState),
+
+	// Verify sender credentials (opt-in)
+	fx.Invoke(runChannelSelfChecks),
+
 	// Start background services
 	fx.Invoke(startBackgroundServices),
 )
@@ -74,6 +85,7 @@ var NotificationAppAPIOnly = fx.Options(
 		repository.NewNotificationRepository,
 		service.NewNotificationService,
 		handler.NewNotificationHandler,
+		auth.NewAuthHandler,
 		// Không include: NewSenderRegistry, provideInMemoryQueue, provideInMemoryProvider,
 		// provideNotificationPoller, provideNotificationWorker
 	),
@@ -81,6 +93,9 @@ var NotificationAppAPIOnly = fx.Options(
 	// Register routes
 	fx.Invoke(registerNotificationRoutes),
 
+	// Check target users when the deployment provides a UserChecker
+	fx.Invoke(attachUserChecker),
+
 	// KHÔNG invoke startBackgroundServices - chỉ chạy API server
 )
 
@@ -103,16 +118,13 @@ type InMemoryQueueParams struct {
 
 // provideInMemoryQueue provides an in-memory queue
 func provideInMemoryQueue(params InMemoryQueueParams) *worker.InMemoryQueue {
-	queueSize := params.Config.Notification.Poller.MaxQueueSize
-	if queueSize <= 0 {
-		queueSize = 2000 // Default
-	}
-	return worker.NewInMemoryQueue(queueSize)
+	return worker.NewInMemoryQueue(params.Config.Notification.Poller.MaxQueueSize)
 }
 
 // InMemoryProviderParams holds dependencies for creating in-memory provider
 type InMemoryProviderParams struct {
 	fx.In
+	Config *config.ServiceConfig
 	Queue  *worker.InMemoryQueue
 	Repo   *repository.NotificationRepository
 	Logger *logger.Logger
@@ -120,11 +132,13 @@ type InMemoryProviderParams struct {
 
 // provideInMemoryProvider provides an in-memory provider
 func provideInMemoryProvider(params InMemoryProviderParams) workerpkg.Provider {
-	return worker.NewInMemoryProvider(
+	provider := worker.NewInMemoryProvider(
 		params.Queue,
 		params.Repo,
 		params.Logger,
 	)
+	provider.SetTaskType(params.Config.Notification.TaskType)
+	return provider
 }
 
 // NotificationPollerParams holds dependencies for creating a notification poller
@@ -176,6 +190,7 @@ type WorkerHealthProviderParams struct {
 	fx.In
 	HealthService *health.Service
 	Worker        *worker.NotificationWorker
+	Poller        *worker.NotificationPoller
 	Config        *config.ServiceConfig
 	Logger        *logger.Logger
 }
@@ -190,19 +205,71 @@ func provideWorkerHealthProvider(params WorkerHealthProviderParams) error {
 	// Create worker health provider
 	maxQueueSize := params.Config.Notification.Poller.MaxQueueSize
 
+	alarm := params.Config.Notification.SuccessRateAlarm
+
 	workerProvider := health.NewWorkerProvider(health.WorkerProviderConfig{
-		Name:           "notification-worker",
-		Checker:        checker,
-		MaxQueueLength: maxQueueSize,
+		Name:                   "notification-worker",
+		Checker:                checker,
+		MaxQueueLength:         maxQueueSize,
+		RequireQueueMetrics:    true, // The worker always has an in-memory queue
+		DegradedSuccessRate:    alarm.DegradedBelow,
+		DownSuccessRate:        alarm.DownBelow,
+		MinSuccessRateAttempts: int64(alarm.MinAttempts),
 	})
 
 	// Register with health service
 	params.HealthService.RegisterProvider(workerProvider)
+	params.HealthService.RegisterProvider(&pollerHealthProvider{poller: params.Poller})
 	params.Logger.Info("Registered notification worker health provider")
 
 	return nil
 }
 
+// attachWorkerState lets the notification service report the worker's state
+func attachWorkerState(svc *service.NotificationService, w *worker.NotificationWorker) {
+	svc.SetWorkerState(w)
+}
+
+// UserCheckerParams holds the optional user checker for the notification service
+// Deployments supply one with fx.Provide(fx.Annotate(newChecker, fx.As(new(service.UserChecker)))).
+type UserCheckerParams struct {
+	fx.In
+	Service *service.NotificationService
+	Checker service.UserChecker `optional:"true"`
+}
+
+// attachUserChecker enables notification.unknown_users when a checker is provided
+func attachUserChecker(params UserCheckerParams) {
+	if params.Checker != nil {
+		params.Service.SetUserChecker(params.Checker)
+	}
+}
+
+// ChannelSelfCheckParams holds dependencies for the channel self-check
+type ChannelSelfCheckParams struct {
+	fx.In
+	HealthService   *health.Service
+	ChannelRegistry *channel.ChannelRegistry
+	Config          *config.ServiceConfig
+	Logger          *logger.Logger
+}
+
+// runChannelSelfChecks verifies enabled senders at startup when
+// senders.self_check_on_startup is set and reports the results to health.
+// Failures are reported, not fatal, so one bad credential doesn't stop the service.
+func runChannelSelfChecks(params ChannelSelfCheckParams) {
+	if !params.Config.Notification.Senders.SelfCheckOnStartup {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results := params.ChannelRegistry.RunSelfChecks(ctx)
+	params.HealthService.RegisterProvider(channel.NewSelfCheckHealthProvider(results))
+	params.Logger.Info("Channel self-checks completed", zap.Int("channels", len(results)))
+}
+
 // workerHealthCheckerAdapter adapts NotificationWorker to WorkerHealthChecker interface
 type workerHealthCheckerAdapter struct {
 	worker *worker.NotificationWorker
@@ -220,11 +287,53 @@ func (a *workerHealthCheckerAdapter) GetQueueCapacity() int {
 	return a.worker.GetQueueCapacity()
 }
 
+func (a *workerHealthCheckerAdapter) GetInFlight() int64 {
+	return a.worker.GetInFlight()
+}
+
+func (a *workerHealthCheckerAdapter) GetMaxInFlight() int64 {
+	return a.worker.GetMaxInFlight()
+}
+
+func (a *workerHealthCheckerAdapter) GetSuccessRate() (float64, int64) {
+	return a.worker.GetSuccessRate()
+}
+
+// pollerHealthProvider reports the poller DOWN after repeated database errors
+type pollerHealthProvider struct {
+	poller *worker.NotificationPoller
+}
+
+func (p *pollerHealthProvider) Name() string {
+	return "notification-poller"
+}
+
+func (p *pollerHealthProvider) Check(ctx context.Context) health.HealthCheckResult {
+	stats := p.poller.Stats()
+	result := health.HealthCheckResult{
+		Name:   p.Name(),
+		Status: health.StatusUp,
+		Details: map[string]interface{}{
+			"poll_errors":        stats.PollErrors,
+			"consecutive_errors": stats.ConsecutiveErrors,
+		},
+		CheckedAt: time.Now(),
+	}
+
+	if !p.poller.IsHealthy() {
+		result.Status = health.StatusDown
+		result.Error = "repeated failures fetching pending deliveries"
+	}
+
+	return result
+}
+
 // NotificationRoutesParams holds dependencies for registering routes
 type NotificationRoutesParams struct {
 	fx.In
 	Server  *server.Server
 	Handler *handler.NotificationHandler
+	Auth    *auth.AuthHandler
 }
 
 // registerNotificationRoutes registers notification routes
@@ -241,6 +350,11 @@ func registerNotificationRoutes(params NotificationRoutesParams) {
 
 	// New token registration route
 	protectedGroup.POST("/tokens/register", params.Handler.RegisterToken)
+
+	// Admin routes need a JWT; the handlers check for the admin role
+	requireJWT := auth.JWTMiddleware(params.Auth)
+	protectedGroup.GET("/reports", params.Handler.GetNotificationReport, requireJWT)
+	protectedGroup.GET("/admin/status", params.Handler.GetStatus, requireJWT)
 }
 
 // BackgroundServicesParams holds dependencies for starting background services
@@ -259,6 +373,9 @@ func startBackgroundServices(params BackgroundServicesParams) {
 	// Create controlled contexts for poller and worker
 	pollerCtx, pollerCancel := context.WithCancel(context.Background())
 	workerCtx, workerCancel := context.WithCancel(context.Background())
+	retentionCtx, retentionCancel := context.WithCancel(context.Background())
+
+	retention := worker.NewRetentionCleaner(params.Repo, params.Config, params.Logger)
 
 	params.Lifecycle.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
@@ -288,9 +405,6 @@ func startBackgroundServices(params BackgroundServicesParams) {
 						return
 					case <-ticker.C:
 						timeoutMinutes := params.Config.Notification.Poller.ProcessingTimeoutMinutes
-						if timeoutMinutes <= 0 {
-							timeoutMinutes = 5 // Default
-						}
 						if err := params.Repo.ResetProcessingToPending(timeoutMinutes); err != nil {
 							params.Logger.Error("Failed to reset stale processing deliveries", zap.Error(err))
 						}
@@ -298,10 +412,21 @@ func startBackgroundServices(params BackgroundServicesParams) {
 				}
 			}()
 
+			// Remove failed deliveries past their retention (opt-in)
+			if retention.Enabled() {
+				go retention.Run(retentionCtx)
+				params.Logger.Info("Failed delivery retention cleanup started",
+					zap.Int("days", params.Config.Notification.FailedRetention.Days),
+					zap.Bool("archive", params.Config.Notification.FailedRetention.Archive),
+				)
+			}
+
 			params.Logger.Info("Background services started")
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
+			retentionCancel()
+
 			// Stop poller
 			if params.Config.Notification.Poller.Enabled {
 				pollerCancel()
@@ -0,0 +1,170 @@
+package notification
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	pkgconfig "myapp/internal/pkg/config"
+	"myapp/internal/pkg/database"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/server"
+	"myapp/internal/service/auth"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/handler"
+	"myapp/internal/service/notification/model"
+	"myapp/internal/service/notification/repository"
+	"myapp/internal/service/notification/service"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/fx"
+	"go.uber.org/fx/fxtest"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// noUsers reports every user as unknown
+type noUsers struct{}
+
+func (noUsers) ExistingUsers(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	return map[string]bool{}, nil
+}
+
+func newRejectingService() *service.NotificationService {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.UnknownUsers = service.UnknownUsersReject
+	return service.NewNotificationService(nil, cfg, &logger.Logger{Logger: zap.NewNop()})
+}
+
+func TestAttachUserChecker(t *testing.T) {
+	dto := model.CreateNotificationDTO{
+		Type:    "order",
+		Targets: []model.NotificationTargetDTO{{UserID: "ghost"}},
+	}
+
+	t.Run("provided checker is used", func(t *testing.T) {
+		var svc *service.NotificationService
+		app := fxtest.New(t,
+			fx.Supply(newRejectingService()),
+			fx.Provide(fx.Annotate(func() noUsers { return noUsers{} }, fx.As(new(service.UserChecker)))),
+			fx.Invoke(attachUserChecker),
+			fx.Populate(&svc),
+		)
+		app.RequireStart().RequireStop()
+
+		_, err := svc.CreateNotification(context.Background(), dto)
+		assert.ErrorIs(t, err, service.ErrUnknownUsers)
+	})
+
+	t.Run("checker is optional", func(t *testing.T) {
+		var svc *service.NotificationService
+		app := fxtest.New(t,
+			fx.Supply(newRejectingService()),
+			fx.Invoke(attachUserChecker),
+			fx.Populate(&svc),
+		)
+		app.RequireStart().RequireStop()
+		require.NotNil(t, svc)
+	})
+}
+
+// emptyConn is a database/sql connection whose queries return no rows
+type emptyConn struct{}
+
+func (emptyConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (emptyConn) Close() error                              { return nil }
+func (emptyConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (emptyConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+type emptyConnector struct{}
+
+func (emptyConnector) Connect(context.Context) (driver.Conn, error) { return emptyConn{}, nil }
+func (emptyConnector) Driver() driver.Driver                        { return nil }
+
+// newRoutedServer registers the notification routes the way the app does,
+// over a database without rows and JWTs signed with secret
+func newRoutedServer(t *testing.T, secret string) *server.Server {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(emptyConnector{})
+	t.Cleanup(func() { sqlDB.Close() })
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	log := &logger.Logger{Logger: zap.NewNop()}
+	appConfig := &pkgconfig.Config{JWT: pkgconfig.JWTConfig{Secret: secret}}
+	repo := repository.NewNotificationRepository(&database.Database{DB: db})
+
+	var srv *server.Server
+	app := fxtest.New(t,
+		fx.Supply(appConfig, log, &config.ServiceConfig{}, repo),
+		fx.Provide(
+			server.NewEchoServer,
+			service.NewNotificationService,
+			handler.NewNotificationHandler,
+			auth.NewAuthHandler,
+		),
+		fx.Invoke(registerNotificationRoutes),
+		fx.Populate(&srv),
+	)
+	app.RequireStart()
+	t.Cleanup(app.RequireStop)
+	return srv
+}
+
+// get requests target from srv with a JWT for role, or none when role is empty
+func get(t *testing.T, srv *server.Server, secret, role, target string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if role != "" {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &auth.Claims{
+			UserID:           1,
+			Role:             role,
+			RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))},
+		}).SignedString([]byte(secret))
+		require.NoError(t, err)
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	srv.GetEcho().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestRegisterNotificationRoutes_AdminEndpoints(t *testing.T) {
+	const secret = "test-secret"
+	srv := newRoutedServer(t, secret)
+
+	for _, target := range []string{
+		"/api/v1/notifications/reports",
+		"/api/v1/notifications/admin/status",
+	} {
+		t.Run(target, func(t *testing.T) {
+			assert.Equal(t, http.StatusUnauthorized, get(t, srv, secret, "", target).Code)
+			assert.Equal(t, http.StatusForbidden, get(t, srv, secret, "user", target).Code)
+			assert.Equal(t, http.StatusOK, get(t, srv, secret, "admin", target).Code)
+		})
+	}
+}
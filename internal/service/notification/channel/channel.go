@@ -1,11 +1,32 @@
 package channel
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
 	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	texttemplate "text/template"
 	"time"
 
 	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/retry"
 	"myapp/internal/service/notification/config"
 	"myapp/internal/service/notification/model"
 	"myapp/internal/service/notification/repository"
@@ -13,6 +34,7 @@ import (
 	expo "github.com/oliveroneill/exponent-server-sdk-golang/sdk"
 
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 // ChannelResult represents the result of sending a notification
@@ -22,80 +44,264 @@ type ChannelResult struct {
 	Error     error
 }
 
+// TokenResult represents the result of sending to a single device token
+type TokenResult struct {
+	Token     string
+	DeviceID  string
+	Success   bool
+	Retryable bool
+	// Unregistered marks a token that will never work again and should be pruned
+	Unregistered bool
+	Error        error
+}
+
 // Channel is the interface for notification channels
 type Channel interface {
 	Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *ChannelResult
+	// SendTokens sends to every token of the target and reports the outcome per token
+	// Channels without device tokens return a single result with an empty Token
+	SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult
 	Name() string
 }
 
+// AggregateTokenResults collapses per-token results into a single ChannelResult
+// The send succeeds if any token succeeded and is retryable if any failure was
+func AggregateTokenResults(results []*TokenResult) *ChannelResult {
+	if len(results) == 0 {
+		return &ChannelResult{
+			Success:   false,
+			Retryable: false,
+			Error:     fmt.Errorf("no tokens to send to"),
+		}
+	}
+
+	retryable := false
+	var errs []error
+	for _, result := range results {
+		if result.Success {
+			return &ChannelResult{Success: true}
+		}
+		if result.Retryable {
+			retryable = true
+		}
+		if result.Error != nil {
+			errs = append(errs, result.Error)
+		}
+	}
+
+	return &ChannelResult{
+		Success:   false,
+		Retryable: retryable,
+		Error:     errors.Join(errs...),
+	}
+}
+
+// DeviceTokenStore finds and deletes device tokens
+type DeviceTokenStore interface {
+	GetDeviceTokenByPushToken(userID, pushToken string) (*model.DeviceToken, error)
+	DeleteDeviceToken(userID, deviceID string) error
+}
+
+// TokenPruner deletes device tokens that channels report as unregistered
+type TokenPruner struct {
+	store  DeviceTokenStore
+	logger *logger.Logger
+
+	// pruned counts tokens deleted so far
+	pruned atomic.Int64
+}
+
+// NewTokenPruner creates a pruner that deletes tokens from store
+func NewTokenPruner(store DeviceTokenStore, log *logger.Logger) *TokenPruner {
+	return &TokenPruner{store: store, logger: log}
+}
+
+// Prune deletes the device row of every Unregistered result and returns how
+// many were deleted. Results without a device ID are matched by push token.
+// A row that is already gone counts as pruned.
+func (p *TokenPruner) Prune(userID, channelName string, results []*TokenResult) int {
+	pruned := 0
+	for _, result := range results {
+		if !result.Unregistered {
+			continue
+		}
+
+		deviceID := result.DeviceID
+		if deviceID == "" {
+			if result.Token == "" {
+				continue
+			}
+			token, err := p.store.GetDeviceTokenByPushToken(userID, result.Token)
+			if err != nil {
+				p.logger.Warn("Failed to look up unregistered device token",
+					zap.String("user_id", userID),
+					zap.String("channel", channelName),
+					zap.Error(err),
+				)
+				continue
+			}
+			deviceID = token.DeviceID
+		}
+
+		err := p.store.DeleteDeviceToken(userID, deviceID)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			p.logger.Warn("Failed to prune unregistered device token",
+				zap.String("user_id", userID),
+				zap.String("device_id", deviceID),
+				zap.String("channel", channelName),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		pruned++
+		p.logger.Info("Pruned unregistered device token",
+			zap.String("user_id", userID),
+			zap.String("device_id", deviceID),
+			zap.String("channel", channelName),
+			zap.Int64("device_tokens_pruned_total", p.pruned.Add(1)),
+		)
+	}
+	return pruned
+}
+
+// PrunedCount returns how many unregistered tokens have been deleted
+func (p *TokenPruner) PrunedCount() int64 {
+	return p.pruned.Load()
+}
+
+// singleTokenResult wraps a ChannelResult for channels without per-token delivery
+func singleTokenResult(result *ChannelResult) []*TokenResult {
+	return []*TokenResult{{
+		Success:   result.Success,
+		Retryable: result.Retryable,
+		Error:     result.Error,
+	}}
+}
+
+// failedTokenResult returns a single failed result not tied to any token
+func failedTokenResult(err error, retryable bool) []*TokenResult {
+	return []*TokenResult{{
+		Success:   false,
+		Retryable: retryable,
+		Error:     err,
+	}}
+}
+
+// sendRetryPolicy is the in-call retry policy shared by channels: linear
+// 1s steps capped at 10s with jitter to avoid synchronized retries. The
+// attempt count comes from each channel's MaxRetries.
+var sendRetryPolicy = retry.LinearBackoff(time.Second, 10*time.Second, true, 0)
+
 // ExpoChannel implements Expo push notification channel
 type ExpoChannel struct {
-	config *config.ExpoConfig
-	client *expo.PushClient
-	logger *logger.Logger
-	repo   *repository.NotificationRepository
+	config  *config.ExpoConfig
+	http    *http.Client
+	limiter *requestLimiter
+	logger  *logger.Logger
+	repo    *repository.NotificationRepository
+	backoff retry.Policy
 }
 
 // NewExpoChannel creates a new Expo channel
+// All sends share one HTTP client and at most MaxConcurrentRequests requests
+// are in flight at a time, however many workers use the channel.
 func NewExpoChannel(config *config.ExpoConfig, log *logger.Logger, repo *repository.NotificationRepository) *ExpoChannel {
-	// Create Expo PushClient with optional HTTP client configuration
-	// If AccessToken is provided, it will be used for authentication
-	var client *expo.PushClient
-	if config.AccessToken != "" {
-		// Note: The SDK may need custom HTTP client setup for access token
-		// For now, we'll use the default client
-		client = expo.NewPushClient(nil)
-	} else {
-		client = expo.NewPushClient(nil)
-	}
+	httpClient := newSenderClient(config.TimeoutSec, config.MaxConcurrentRequests)
 
 	return &ExpoChannel{
-		config: config,
-		client: client,
-		logger: log,
-		repo:   repo,
+		config:  config,
+		http:    httpClient,
+		limiter: newRequestLimiter(config.MaxConcurrentRequests),
+		logger:  log,
+		repo:    repo,
+		backoff: sendRetryPolicy,
 	}
 }
 
+// expoClientConfig configures the Expo SDK client
+// The SDK takes the host and API path separately and appends /push/send, so
+// a configured send URL is split; any other URL keeps the SDK's endpoint.
+func expoClientConfig(cfg *config.ExpoConfig, httpClient *http.Client) *expo.ClientConfig {
+	clientConfig := &expo.ClientConfig{
+		AccessToken: cfg.AccessToken,
+		HTTPClient:  httpClient,
+	}
+
+	base, ok := strings.CutSuffix(cfg.APIURL, "/push/send")
+	if !ok {
+		return clientConfig
+	}
+	u, err := url.Parse(base)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return clientConfig
+	}
+	clientConfig.Host = u.Scheme + "://" + u.Host
+	clientConfig.APIURL = u.Path
+	return clientConfig
+}
+
+// publish sends messages in one request once the limiter has a free slot
+func (c *ExpoChannel) publish(ctx context.Context, messages []expo.PushMessage) ([]expo.PushResponse, error) {
+	if err := pace(ctx, len(messages)); err != nil {
+		return nil, err
+	}
+	if err := c.limiter.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.limiter.release()
+
+	// The SDK builds its request without a context, so ctx and the configured
+	// timeout reach it through the client's transport instead
+	if c.config.TimeoutSec > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(c.config.TimeoutSec)*time.Second)
+		defer cancel()
+	}
+	client := expo.NewPushClient(expoClientConfig(c.config, withRequestContext(ctx, c.http)))
+	return client.PublishMultiple(messages)
+}
+
 // Name returns the channel name
 func (c *ExpoChannel) Name() string {
 	return "expo"
 }
 
 // Send sends a notification via Expo
+// It succeeds if at least one of the user's tokens accepted the message
 func (c *ExpoChannel) Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *ChannelResult {
+	return AggregateTokenResults(c.SendTokens(ctx, target, payload))
+}
+
+// expoDelivery pairs a device token with the Expo message built for it
+type expoDelivery struct {
+	token   *model.DeviceToken
+	message expo.PushMessage
+}
+
+// SendTokens sends a notification via Expo and reports the outcome per token
+// Only tokens that failed with a retryable error are resent on later attempts
+func (c *ExpoChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
 	if !c.config.Enabled {
-		return &ChannelResult{
-			Success:   false,
-			Retryable: false,
-			Error:     fmt.Errorf("expo channel is disabled"),
-		}
+		return failedTokenResult(fmt.Errorf("expo channel is disabled"), false)
 	}
 
 	// Get Expo push tokens from device_tokens table
 	tokens, err := c.repo.GetDeviceTokensByUserID(target.UserID)
 	if err != nil {
-		return &ChannelResult{
-			Success:   false,
-			Retryable: false,
-			Error:     fmt.Errorf("failed to get device tokens: %w", err),
-		}
+		return failedTokenResult(fmt.Errorf("failed to get device tokens: %w", err), false)
 	}
 
 	// Filter for Expo tokens only
-	var expoTokens []string
+	var expoTokens []*model.DeviceToken
 	for _, token := range tokens {
 		if token != nil && token.Type == "expo" && token.PushToken != "" {
-			expoTokens = append(expoTokens, token.PushToken)
+			expoTokens = append(expoTokens, token)
 		}
 	}
 
 	if len(expoTokens) == 0 {
-		return &ChannelResult{
-			Success:   false,
-			Retryable: false,
-			Error:     fmt.Errorf("no expo push tokens found for user_id: %s", target.UserID),
-		}
+		return failedTokenResult(fmt.Errorf("no expo push tokens found for user_id: %s", target.UserID), false)
 	}
 
 	// Extract title and body from payload
@@ -121,15 +327,23 @@ func (c *ExpoChannel) Send(ctx context.Context, target *model.NotificationTarget
 	}
 
 	// Build Expo messages for all tokens using SDK
-	var expoMessages []expo.PushMessage
-	for _, tokenStr := range expoTokens {
+	results := make([]*TokenResult, 0, len(expoTokens))
+	var pending []expoDelivery
+	for _, deviceToken := range expoTokens {
 		// Convert string token to ExponentPushToken
-		token, err := expo.NewExponentPushToken(tokenStr)
+		token, err := expo.NewExponentPushToken(deviceToken.PushToken)
 		if err != nil {
 			c.logger.Warn("Invalid Expo push token",
-				zap.String("token", tokenStr),
+				zap.String("token", deviceToken.PushToken),
 				zap.Error(err),
 			)
+			results = append(results, &TokenResult{
+				Token:     deviceToken.PushToken,
+				DeviceID:  deviceToken.DeviceID,
+				Success:   false,
+				Retryable: false,
+				Error:     fmt.Errorf("invalid expo push token: %w", err),
+			})
 			continue
 		}
 
@@ -163,132 +377,132 @@ func (c *ExpoChannel) Send(ctx context.Context, target *model.NotificationTarget
 			message.ChannelID = channelID
 		}
 
-		expoMessages = append(expoMessages, message)
+		pending = append(pending, expoDelivery{token: deviceToken, message: message})
 	}
 
-	if len(expoMessages) == 0 {
-		return &ChannelResult{
-			Success:   false,
-			Retryable: false,
-			Error:     fmt.Errorf("no valid expo push tokens found for user_id: %s", target.UserID),
-		}
+	maxRetries := c.config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 1
 	}
 
 	// Send messages with retry
 	var lastErr error
-	for attempt := 0; attempt < c.config.MaxRetries; attempt++ {
+	for attempt := 0; attempt < maxRetries && len(pending) > 0; attempt++ {
 		if attempt > 0 {
-			backoff := time.Duration(attempt) * time.Second
-			select {
-			case <-ctx.Done():
-				return &ChannelResult{
-					Success:   false,
-					Retryable: true,
-					Error:     ctx.Err(),
-				}
-			case <-time.After(backoff):
+			if err := c.backoff.Wait(ctx, attempt); err != nil {
+				return appendPendingFailures(results, pending, err)
 			}
 		}
 
-		// Send messages using SDK
-		// Use PublishMultiple to send all messages in one batch
-		responses, err := c.client.PublishMultiple(expoMessages)
+		messages := make([]expo.PushMessage, len(pending))
+		for i, delivery := range pending {
+			messages[i] = delivery.message
+		}
+
+		// Send all pending messages in one batch
+		responses, err := c.publish(ctx, messages)
 		if err != nil {
+			if ctx.Err() != nil {
+				return appendPendingFailures(results, pending, err)
+			}
 			lastErr = err
 			c.logger.Warn("Expo send attempt failed",
 				zap.Int("attempt", attempt+1),
-				zap.Int("message_count", len(expoMessages)),
+				zap.Int("message_count", len(messages)),
 				zap.Error(err),
 			)
-			// Check if error is retryable
-			if attempt < c.config.MaxRetries-1 {
-				continue
-			}
-			break
-		}
-
-		// Check if all messages were sent successfully
-		if len(responses) == len(expoMessages) {
-			allSuccess := true
-			hasError := false
-			for i, response := range responses {
-				if response.Status != expo.SuccessStatus {
-					allSuccess = false
-					hasError = true
-					lastErr = fmt.Errorf("expo response error: %s - %s", response.Status, response.Message)
-					c.logger.Warn("Expo response error",
-						zap.Int("message_index", i),
-						zap.String("status", response.Status),
-						zap.String("message", response.Message),
-						zap.String("id", response.ID),
-					)
-				}
-			}
+			continue
+		}
 
-			if allSuccess {
-				c.logger.Info("Expo notification sent successfully",
-					zap.Int64("target_id", target.ID),
-					zap.String("user_id", target.UserID),
-					zap.Int("token_count", len(expoTokens)),
-				)
-				return &ChannelResult{Success: true}
-			}
+		if len(responses) != len(messages) {
+			// Unexpected: number of responses doesn't match messages
+			lastErr = fmt.Errorf("expo response count mismatch: expected %d, got %d", len(messages), len(responses))
+			c.logger.Warn("Expo send incomplete, retrying",
+				zap.Int("attempt", attempt+1),
+				zap.Int("expected", len(messages)),
+				zap.Int("received", len(responses)),
+				zap.Error(lastErr),
+			)
+			continue
+		}
 
-			// If there are errors, check if retryable
-			if hasError && lastErr != nil {
-				// Check if error is retryable (rate limits, etc.)
-				// DeviceNotRegistered errors are not retryable
-				if attempt < c.config.MaxRetries-1 {
-					// Check if it's a non-retryable error
-					retryable := true
-					for _, response := range responses {
-						if response.Status != expo.SuccessStatus {
-							// DeviceNotRegistered is not retryable
-							if response.Message != "" &&
-								(response.Status == expo.ErrorDeviceNotRegistered ||
-									response.Status == expo.ErrorMessageTooBig) {
-								retryable = false
-								break
-							}
-						}
-					}
-
-					if retryable {
-						c.logger.Warn("Expo send failed, retrying",
-							zap.Int("attempt", attempt+1),
-							zap.Error(lastErr),
-						)
-						continue
-					} else {
-						// Non-retryable error, return immediately
-						return &ChannelResult{
-							Success:   false,
-							Retryable: false,
-							Error:     lastErr,
-						}
-					}
-				}
+		// Classify each response; retryable failures stay pending
+		var retry []expoDelivery
+		for i, response := range responses {
+			delivery := pending[i]
+			result := &TokenResult{
+				Token:    delivery.token.PushToken,
+				DeviceID: delivery.token.DeviceID,
 			}
-		} else {
-			// Unexpected: number of responses doesn't match messages
-			lastErr = fmt.Errorf("expo response count mismatch: expected %d, got %d", len(expoMessages), len(responses))
-			if attempt < c.config.MaxRetries-1 {
-				c.logger.Warn("Expo send incomplete, retrying",
-					zap.Int("attempt", attempt+1),
-					zap.Int("expected", len(expoMessages)),
-					zap.Int("received", len(responses)),
-					zap.Error(lastErr),
-				)
+
+			responseErr := response.ValidateResponse()
+			if responseErr == nil {
+				result.Success = true
+				results = append(results, result)
 				continue
 			}
+
+			lastErr = fmt.Errorf("expo response error: %s - %s", response.Status, response.Message)
+			result.Error = lastErr
+			c.logger.Warn("Expo response error",
+				zap.Int("message_index", i),
+				zap.String("device_id", delivery.token.DeviceID),
+				zap.String("status", response.Status),
+				zap.String("message", response.Message),
+				zap.String("id", response.ID),
+			)
+
+			var notRegistered *expo.DeviceNotRegisteredError
+			var tooBig *expo.MessageTooBigError
+			switch {
+			case errors.As(responseErr, &notRegistered):
+				// The token is dead; never retry it, and the worker prunes it
+				result.Unregistered = true
+				results = append(results, result)
+			case errors.As(responseErr, &tooBig):
+				results = append(results, result)
+			default:
+				retry = append(retry, delivery)
+			}
 		}
+		pending = retry
 	}
 
-	return &ChannelResult{
-		Success:   false,
-		Retryable: true,
-		Error:     fmt.Errorf("expo send failed after %d attempts: %w", c.config.MaxRetries, lastErr),
+	if len(pending) > 0 {
+		results = appendPendingFailures(results, pending,
+			fmt.Errorf("expo send failed after %d attempts: %w", maxRetries, lastErr))
+	}
+
+	succeeded := 0
+	for _, result := range results {
+		if result.Success {
+			succeeded++
+		}
+	}
+	if succeeded > 0 {
+		c.logger.Info("Expo notification sent successfully",
+			zap.Int64("target_id", target.ID),
+			zap.String("user_id", target.UserID),
+			zap.Int("token_count", len(expoTokens)),
+			zap.Int("success_count", succeeded),
+		)
+	}
+
+	return results
+}
+
+// appendPendingFailures records a retryable failure for every pending delivery
+func appendPendingFailures(results []*TokenResult, pending []expoDelivery, err error) []*TokenResult {
+	for _, delivery := range pending {
+		results = append(results, &TokenResult{
+			Token:     delivery.token.PushToken,
+			DeviceID:  delivery.token.DeviceID,
+			Success:   false,
+			Retryable: true,
+			Error:     err,
+		})
 	}
+	return results
 }
 
 // FCMChannel implements Firebase Cloud Messaging channel (placeholder)
@@ -305,6 +519,11 @@ func NewFCMChannel(config *config.FCMConfig, log *logger.Logger) *FCMChannel {
 	}
 }
 
+// SendTokens sends a notification via FCM as a single result
+func (c *FCMChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
+	return singleTokenResult(c.Send(ctx, target, payload))
+}
+
 // Name returns the channel name
 func (c *FCMChannel) Name() string {
 	return "fcm"
@@ -342,6 +561,11 @@ func NewAPNSChannel(config *config.APNSConfig, log *logger.Logger) *APNSChannel
 	}
 }
 
+// SendTokens sends a notification via APNS as a single result
+func (c *APNSChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
+	return singleTokenResult(c.Send(ctx, target, payload))
+}
+
 // Name returns the channel name
 func (c *APNSChannel) Name() string {
 	return "apns"
@@ -365,26 +589,68 @@ func (c *APNSChannel) Send(ctx context.Context, target *model.NotificationTarget
 	}
 }
 
-// EmailChannel implements email channel (placeholder)
+// EmailLookup resolves a user's email address when the payload does not carry one
+type EmailLookup func(ctx context.Context, userID string) (string, error)
+
+// deviceTokenSource lists a user's device tokens
+type deviceTokenSource interface {
+	GetDeviceTokensByUserID(userID string) ([]*model.DeviceToken, error)
+}
+
+// deviceTokenEmailLookup resolves addresses stored as email device tokens
+// (the address in push_token), preferring the most recently seen one
+func deviceTokenEmailLookup(tokens deviceTokenSource) EmailLookup {
+	return func(ctx context.Context, userID string) (string, error) {
+		all, err := tokens.GetDeviceTokensByUserID(userID)
+		if err != nil {
+			return "", err
+		}
+
+		var latest *model.DeviceToken
+		for _, token := range all {
+			if token == nil || token.Type != "email" || token.PushToken == "" {
+				continue
+			}
+			if latest == nil || token.LastSeenAt.After(latest.LastSeenAt) {
+				latest = token
+			}
+		}
+		if latest == nil {
+			return "", nil
+		}
+		return latest.PushToken, nil
+	}
+}
+
+// EmailChannel implements email channel over SMTP
 type EmailChannel struct {
-	config *config.EmailConfig
-	logger *logger.Logger
+	config  *config.EmailConfig
+	logger  *logger.Logger
+	lookup  EmailLookup
+	backoff retry.Policy
 }
 
-// NewEmailChannel creates a new email channel
-func NewEmailChannel(config *config.EmailConfig, log *logger.Logger) *EmailChannel {
+// NewEmailChannel creates a new email channel; lookup may be nil
+func NewEmailChannel(config *config.EmailConfig, log *logger.Logger, lookup EmailLookup) *EmailChannel {
 	return &EmailChannel{
-		config: config,
-		logger: log,
+		config:  config,
+		logger:  log,
+		lookup:  lookup,
+		backoff: sendRetryPolicy,
 	}
 }
 
+// SendTokens sends a notification via email as a single result
+func (c *EmailChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
+	return singleTokenResult(c.Send(ctx, target, payload))
+}
+
 // Name returns the channel name
 func (c *EmailChannel) Name() string {
 	return "email"
 }
 
-// Send sends a notification via email (placeholder implementation)
+// Send sends a notification via email
 func (c *EmailChannel) Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *ChannelResult {
 	if !c.config.Enabled {
 		return &ChannelResult{
@@ -394,51 +660,684 @@ func (c *EmailChannel) Send(ctx context.Context, target *model.NotificationTarge
 		}
 	}
 
-	// TODO: Implement email channel
+	from, err := mail.ParseAddress(c.config.FromEmail)
+	if err != nil {
+		return &ChannelResult{
+			Success:   false,
+			Retryable: false,
+			Error:     fmt.Errorf("invalid from address %q: %w", c.config.FromEmail, err),
+		}
+	}
+
+	address, err := c.resolveRecipient(ctx, payload)
+	if err != nil {
+		// Lookup failures are usually transient (database, user service)
+		return &ChannelResult{
+			Success:   false,
+			Retryable: true,
+			Error:     err,
+		}
+	}
+	if address == "" {
+		return &ChannelResult{
+			Success:   false,
+			Retryable: false,
+			Error:     fmt.Errorf("no email address for user %s", payload.UserID),
+		}
+	}
+
+	to, err := mail.ParseAddress(address)
+	if err != nil {
+		return &ChannelResult{
+			Success:   false,
+			Retryable: false,
+			Error:     fmt.Errorf("invalid email address %q: %w", address, err),
+		}
+	}
+
+	msg, err := buildEmailMessage(from, to, payload)
+	if err != nil {
+		return &ChannelResult{
+			Success:   false,
+			Retryable: false,
+			Error:     fmt.Errorf("failed to render email: %w", err),
+		}
+	}
+
+	// Send with retries on transient failures
+	maxRetries := c.config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 1
+	}
+
+	var lastErr error
+	for i := 0; i < maxRetries; i++ {
+		if i > 0 {
+			if err := c.backoff.Wait(ctx, i); err != nil {
+				return &ChannelResult{
+					Success:   false,
+					Retryable: true,
+					Error:     err,
+				}
+			}
+		}
+
+		if err := pace(ctx, 1); err != nil {
+			return &ChannelResult{
+				Success:   false,
+				Retryable: true,
+				Error:     err,
+			}
+		}
+
+		lastErr = c.sendMail(ctx, from.Address, to.Address, msg)
+		if lastErr == nil {
+			c.logger.Info("Email notification sent",
+				zap.String("user_id", payload.UserID),
+				zap.Int64("target_id", target.ID),
+				zap.String("trace_id", payload.TraceID),
+			)
+			return &ChannelResult{
+				Success:   true,
+				Retryable: false,
+				Error:     nil,
+			}
+		}
+
+		if !isRetryableSMTPError(lastErr) {
+			c.logger.Warn("Email rejected by SMTP server",
+				zap.String("user_id", payload.UserID),
+				zap.Int64("target_id", target.ID),
+				zap.String("trace_id", payload.TraceID),
+				zap.Error(lastErr),
+			)
+			return &ChannelResult{
+				Success:   false,
+				Retryable: false,
+				Error:     lastErr,
+			}
+		}
+
+		c.logger.Warn("Failed to send email, retrying",
+			zap.Int("attempt", i+1),
+			zap.String("trace_id", payload.TraceID),
+			zap.Error(lastErr),
+		)
+	}
+
 	return &ChannelResult{
 		Success:   false,
-		Retryable: false,
-		Error:     fmt.Errorf("email channel not implemented"),
+		Retryable: true,
+		Error:     fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr),
+	}
+}
+
+// resolveRecipient returns the recipient address from the payload or the lookup
+func (c *EmailChannel) resolveRecipient(ctx context.Context, payload model.NotificationPayload) (string, error) {
+	if payload.Email != "" {
+		return payload.Email, nil
+	}
+	if email, ok := payload.Data["email"].(string); ok && email != "" {
+		return email, nil
+	}
+	if c.lookup == nil {
+		return "", nil
+	}
+
+	email, err := c.lookup(ctx, payload.UserID)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up email for user %s: %w", payload.UserID, err)
+	}
+	return email, nil
+}
+
+// sendMail delivers a raw message over SMTP honouring the configured TLS mode
+// A failure once ctx is done, e.g. an i/o timeout at its deadline, wraps ctx's error.
+func (c *EmailChannel) sendMail(ctx context.Context, from, to string, msg []byte) (err error) {
+	defer func() {
+		if ctxErr := contextError(ctx); err != nil && ctxErr != nil && !errors.Is(err, ctxErr) {
+			err = fmt.Errorf("%w: %w", ctxErr, err)
+		}
+	}()
+
+	client, err := c.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
+
+// contextError returns ctx's error, or DeadlineExceeded once its deadline
+// has passed; the conn deadline set from it can fire before ctx's own timer
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok && !time.Now().Before(deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+// dial connects to the SMTP server, negotiating TLS and authenticating as configured
+func (c *EmailChannel) dial(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(c.config.SMTPHost, strconv.Itoa(c.config.SMTPPort))
+	timeout := time.Duration(c.config.TimeoutSec) * time.Second
+	tlsConfig := &tls.Config{ServerName: c.config.SMTPHost}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if c.config.TLSMode == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to SMTP server: %w", err)
+	}
+	// The SMTP conversation ends at the configured timeout or ctx's
+	// deadline, whichever comes first
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	if d, ok := ctx.Deadline(); ok && (deadline.IsZero() || d.Before(deadline)) {
+		deadline = d
+	}
+	if !deadline.IsZero() {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, c.config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if c.config.TLSMode == "starttls" {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if c.config.Username != "" {
+		auth := smtp.PlainAuth("", c.config.Username, c.config.Password, c.config.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+// isRetryableSMTPError reports whether an SMTP error is transient
+// 4xx replies and connection errors are retried, 5xx replies are permanent
+func isRetryableSMTPError(err error) bool {
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		return protoErr.Code >= 400 && protoErr.Code < 500
+	}
+	return true
+}
+
+// buildEmailMessage renders the payload into a MIME message
+// Subject and text are rendered with text/template, html with html/template, all against payload.Data
+func buildEmailMessage(from, to *mail.Address, payload model.NotificationPayload) ([]byte, error) {
+	subject, err := renderTextTemplate("subject", payload.Data["subject"], payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := renderTextTemplate("text", payload.Data["text"], payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	html, err := renderHTMLTemplate("html", payload.Data["html"], payload.Data)
+	if err != nil {
+		return nil, err
+	}
+	if text == "" && html == "" {
+		return nil, fmt.Errorf("payload has neither text nor html body")
+	}
+
+	// A line break in a header value would let payload fields inject headers
+	// (Bcc, a second body), so the first one fails the whole message
+	var buf bytes.Buffer
+	var headerErr error
+	writeHeader := func(key, value string) {
+		if strings.ContainsAny(value, "\r\n") {
+			if headerErr == nil {
+				headerErr = fmt.Errorf("%s header contains a line break", key)
+			}
+			return
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+
+	writeHeader("From", from.String())
+	writeHeader("To", to.String())
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", subject))
+	writeHeader("Date", time.Now().UTC().Format(time.RFC1123Z))
+	writeHeader("MIME-Version", "1.0")
+	if payload.TraceID != "" {
+		writeHeader("X-Trace-ID", payload.TraceID)
+	}
+	if payload.ID != "" {
+		writeHeader("X-Notification-ID", payload.ID)
+	}
+	if headerErr != nil {
+		return nil, headerErr
+	}
+
+	// Single-part message
+	if text == "" || html == "" {
+		contentType := "text/plain; charset=utf-8"
+		body := text
+		if html != "" {
+			contentType = "text/html; charset=utf-8"
+			body = html
+		}
+		writeHeader("Content-Type", contentType)
+		writeHeader("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	// Multipart message with plaintext fallback
+	mw := multipart.NewWriter(&buf)
+	writeHeader("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", mw.Boundary()))
+	buf.WriteString("\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	}
+	for _, part := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(pw, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// renderTextTemplate renders a text template from a payload value
+func renderTextTemplate(name string, value interface{}, data map[string]interface{}) (string, error) {
+	src, _ := value.(string)
+	if src == "" {
+		return "", nil
+	}
+
+	tmpl, err := texttemplate.New(name).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// renderHTMLTemplate renders an HTML template from a payload value
+func renderHTMLTemplate(name string, value interface{}, data map[string]interface{}) (string, error) {
+	src, _ := value.(string)
+	if src == "" {
+		return "", nil
+	}
+
+	tmpl, err := htmltemplate.New(name).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s template: %w", name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render %s template: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+// writeQuotedPrintable writes body using quoted-printable encoding
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// e164Pattern matches an E.164 phone number: a plus sign and up to 15 digits
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+
+// twilioErrUnsubscribed is returned when the recipient has replied STOP
+const twilioErrUnsubscribed = 21610
+
+// twilioError is the error body returned by the Twilio REST API
+type twilioError struct {
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	MoreInfo string `json:"more_info"`
+	Status   int    `json:"status"`
+}
+
+// Error implements the error interface
+func (e *twilioError) Error() string {
+	return fmt.Sprintf("twilio error %d: %s", e.Code, e.Message)
+}
+
+// SMSChannel implements SMS channel via Twilio
+type SMSChannel struct {
+	config  *config.SMSConfig
+	client  *http.Client
+	limiter *requestLimiter
+	logger  *logger.Logger
+	repo    deviceTokenSource
+	backoff retry.Policy
+}
+
+// NewSMSChannel creates a new SMS channel
+// Like Expo, all sends share one HTTP client and MaxConcurrentRequests cap.
+func NewSMSChannel(config *config.SMSConfig, log *logger.Logger, repo *repository.NotificationRepository) *SMSChannel {
+	return &SMSChannel{
+		config:  config,
+		client:  newSenderClient(config.TimeoutSec, config.MaxConcurrentRequests),
+		limiter: newRequestLimiter(config.MaxConcurrentRequests),
+		logger:  log,
+		repo:    repo,
+		backoff: sendRetryPolicy,
+	}
+}
+
+// Name returns the channel name
+func (c *SMSChannel) Name() string {
+	return "sms"
+}
+
+// Send sends a notification via SMS
+// It succeeds if at least one of the user's numbers accepted the message
+func (c *SMSChannel) Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *ChannelResult {
+	return AggregateTokenResults(c.SendTokens(ctx, target, payload))
+}
+
+// SendTokens sends a notification via SMS and reports the outcome per number
+func (c *SMSChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
+	if !c.config.Enabled {
+		return failedTokenResult(fmt.Errorf("sms channel is disabled"), false)
+	}
+
+	// Phone numbers are stored as sms device tokens (E.164 in push_token)
+	tokens, err := c.repo.GetDeviceTokensByUserID(target.UserID)
+	if err != nil {
+		return failedTokenResult(fmt.Errorf("failed to get device tokens: %w", err), false)
+	}
+
+	var numbers []*model.DeviceToken
+	for _, token := range tokens {
+		if token != nil && token.Type == "sms" && token.PushToken != "" {
+			numbers = append(numbers, token)
+		}
+	}
+
+	if len(numbers) == 0 {
+		return failedTokenResult(fmt.Errorf("no sms phone numbers found for user_id: %s", target.UserID), false)
+	}
+
+	body := smsBody(payload)
+	if body == "" {
+		return failedTokenResult(fmt.Errorf("payload has no body for sms"), false)
+	}
+
+	results := make([]*TokenResult, 0, len(numbers))
+	sent := 0
+	for _, number := range numbers {
+		result := c.sendWithRetry(ctx, number, body)
+		results = append(results, result)
+		if result.Success {
+			sent++
+			continue
+		}
+		c.logger.Warn("Failed to send sms",
+			zap.Int64("target_id", target.ID),
+			zap.String("user_id", target.UserID),
+			zap.String("device_id", number.DeviceID),
+			zap.Bool("retryable", result.Retryable),
+			zap.Error(result.Error),
+		)
+	}
+
+	if sent > 0 {
+		c.logger.Info("SMS notification sent successfully",
+			zap.Int64("target_id", target.ID),
+			zap.String("user_id", target.UserID),
+			zap.Int("number_count", sent),
+		)
+	}
+
+	return results
+}
+
+// sendWithRetry sends a single SMS, retrying transient failures
+func (c *SMSChannel) sendWithRetry(ctx context.Context, number *model.DeviceToken, body string) *TokenResult {
+	result := &TokenResult{
+		Token:    number.PushToken,
+		DeviceID: number.DeviceID,
+	}
+
+	// Twilio rejects other formats, so don't spend a request finding out
+	if !e164Pattern.MatchString(number.PushToken) {
+		result.Error = fmt.Errorf("invalid phone number %q: expected E.164, e.g. +15551234567", number.PushToken)
+		return result
+	}
+
+	maxRetries := c.config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = 1
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		if attempt > 0 {
+			if err := c.backoff.Wait(ctx, attempt); err != nil {
+				result.Retryable = true
+				result.Error = err
+				return result
+			}
+		}
+
+		retryable, err := c.sendMessage(ctx, number.PushToken, body)
+		if err == nil {
+			result.Success = true
+			return result
+		}
+		lastErr = err
+
+		if !retryable {
+			// An unsubscribed number (21610) fails permanently too, but the
+			// number is kept: the user can opt back in by replying START
+			result.Error = err
+			return result
+		}
+
+		c.logger.Warn("SMS send attempt failed",
+			zap.Int("attempt", attempt+1),
+			zap.Error(err),
+		)
+	}
+
+	result.Retryable = true
+	result.Error = fmt.Errorf("failed after %d attempts: %w", maxRetries, lastErr)
+	return result
+}
+
+// sendMessage posts a message to the Twilio Messages API
+// It reports whether a failure is worth retrying
+func (c *SMSChannel) sendMessage(ctx context.Context, to, body string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json",
+		strings.TrimRight(c.config.APIURL, "/"), url.PathEscape(c.config.AccountSID))
+
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", c.config.FromNumber)
+	form.Set("Body", body)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return false, fmt.Errorf("failed to build twilio request: %w", err)
+	}
+	req.SetBasicAuth(c.config.AccountSID, c.config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	if err := pace(ctx, 1); err != nil {
+		return true, err
+	}
+	if err := c.limiter.acquire(ctx); err != nil {
+		return true, err
+	}
+	defer c.limiter.release()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("twilio request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return false, nil
+	}
+
+	var apiErr twilioError
+	if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil || apiErr.Code == 0 {
+		// Unparseable error: retry on server errors and throttling only
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, fmt.Errorf("twilio error: status %d", resp.StatusCode)
+	}
+
+	if apiErr.Code == twilioErrUnsubscribed {
+		return false, &apiErr
+	}
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, &apiErr
+}
+
+// smsBody builds the SMS text from the payload title and body
+func smsBody(payload model.NotificationPayload) string {
+	title, _ := payload.Data["title"].(string)
+	body, _ := payload.Data["body"].(string)
+
+	switch {
+	case title != "" && body != "":
+		return title + "\n" + body
+	case body != "":
+		return body
+	default:
+		return title
 	}
 }
 
 // ChannelRegistry manages available channels
 type ChannelRegistry struct {
-	channels map[string]Channel
-	logger   *logger.Logger
+	channels     map[string]Channel
+	transformers map[string][]PayloadTransformer
+	logger       *logger.Logger
 }
 
 // NewChannelRegistry creates a new channel registry
-func NewChannelRegistry(config *config.ServiceConfig, log *logger.Logger, repo *repository.NotificationRepository) *ChannelRegistry {
+// It fails if an enabled sender is missing required configuration, or if SMS
+// is enabled without a repository to look up phone numbers in.
+func NewChannelRegistry(cfg *config.ServiceConfig, log *logger.Logger, repo *repository.NotificationRepository) (*ChannelRegistry, error) {
+	if err := cfg.Notification.Senders.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Notification.Senders.SMS.Enabled && repo == nil {
+		return nil, fmt.Errorf("%w: sms sender is enabled but no notification repository is available",
+			config.ErrInvalidSenderConfig)
+	}
+
 	registry := &ChannelRegistry{
-		channels: make(map[string]Channel),
-		logger:   log,
+		channels:     make(map[string]Channel),
+		transformers: make(map[string][]PayloadTransformer),
+		logger:       log,
 	}
 
 	// Register channels
-	if config.Notification.Senders.Expo.Enabled {
-		registry.channels["expo"] = NewExpoChannel(&config.Notification.Senders.Expo, log, repo)
+	if cfg.Notification.Senders.Expo.Enabled {
+		registry.channels["expo"] = NewExpoChannel(&cfg.Notification.Senders.Expo, log, repo)
 	}
-	if config.Notification.Senders.FCM.Enabled {
-		registry.channels["fcm"] = NewFCMChannel(&config.Notification.Senders.FCM, log)
+	if cfg.Notification.Senders.FCM.Enabled {
+		registry.channels["fcm"] = NewFCMChannel(&cfg.Notification.Senders.FCM, log)
 	}
-	if config.Notification.Senders.APNS.Enabled {
-		registry.channels["apns"] = NewAPNSChannel(&config.Notification.Senders.APNS, log)
+	if cfg.Notification.Senders.APNS.Enabled {
+		registry.channels["apns"] = NewAPNSChannel(&cfg.Notification.Senders.APNS, log)
 	}
-	if config.Notification.Senders.Email.Enabled {
-		registry.channels["email"] = NewEmailChannel(&config.Notification.Senders.Email, log)
+	if cfg.Notification.Senders.Email.Enabled {
+		// Payloads without an address fall back to the user's email device token
+		var lookup EmailLookup
+		if repo != nil {
+			lookup = deviceTokenEmailLookup(repo)
+		}
+		registry.channels["email"] = NewEmailChannel(&cfg.Notification.Senders.Email, log, lookup)
+	}
+	if cfg.Notification.Senders.SMS.Enabled {
+		registry.channels["sms"] = NewSMSChannel(&cfg.Notification.Senders.SMS, log, repo)
 	}
 
-	return registry
+	return registry, nil
 }
 
 // GetChannel returns a channel by name
+// If payload transformers are registered for it, the returned channel runs
+// them before every send.
 func (r *ChannelRegistry) GetChannel(name string) (Channel, bool) {
 	channel, ok := r.channels[name]
+	if ok && len(r.transformers[name]) > 0 {
+		channel = &transformingChannel{Channel: channel, transformers: r.transformers[name]}
+	}
 	return channel, ok
 }
 
-// GetAllChannels returns all registered channels
+// GetAllChannels returns all registered channels, without their payload transformers
 func (r *ChannelRegistry) GetAllChannels() map[string]Channel {
 	return r.channels
 }
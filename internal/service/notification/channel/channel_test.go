@@ -0,0 +1,607 @@
+package channel
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/retry"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+func TestNewChannelRegistry_RejectsIncompleteSender(t *testing.T) {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.Senders.APNS = config.APNSConfig{Enabled: true, KeyID: "key"}
+
+	registry, err := NewChannelRegistry(cfg, &logger.Logger{Logger: zap.NewNop()}, nil)
+	assert.Nil(t, registry)
+	require.ErrorIs(t, err, config.ErrInvalidSenderConfig)
+	assert.Contains(t, err.Error(), "apns sender is enabled but missing bundle_id, key_file, team_id")
+}
+
+func TestNewChannelRegistry_RejectsSMSWithoutRepository(t *testing.T) {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.Senders.SMS = config.SMSConfig{
+		Enabled:    true,
+		APIURL:     "https://api.twilio.com",
+		AccountSID: "AC123",
+		AuthToken:  "token",
+		FromNumber: "+15550000000",
+	}
+
+	registry, err := NewChannelRegistry(cfg, &logger.Logger{Logger: zap.NewNop()}, nil)
+	assert.Nil(t, registry)
+	require.ErrorIs(t, err, config.ErrInvalidSenderConfig)
+	assert.Contains(t, err.Error(), "sms sender is enabled but no notification repository")
+}
+
+func TestNewChannelRegistry_RegistersEnabledSenders(t *testing.T) {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.Senders.Expo = config.ExpoConfig{Enabled: true, APIURL: "https://exp.host/--/api/v2/push/send"}
+
+	registry, err := NewChannelRegistry(cfg, &logger.Logger{Logger: zap.NewNop()}, nil)
+	require.NoError(t, err)
+
+	_, ok := registry.GetChannel("expo")
+	assert.True(t, ok)
+	_, ok = registry.GetChannel("apns")
+	assert.False(t, ok)
+}
+
+// fakeMailServer accepts mail over plain SMTP and records what it received
+type fakeMailServer struct {
+	port int
+	// rcptReply is sent in answer to RCPT TO, "250 OK" if empty
+	rcptReply string
+
+	mu       sync.Mutex
+	from     []string
+	rcpt     []string
+	messages []string
+}
+
+func newFakeMailServer(t *testing.T, rcptReply string) *fakeMailServer {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	s := &fakeMailServer{port: ln.Addr().(*net.TCPAddr).Port, rcptReply: rcptReply}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeMailServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 fake")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = append(s.from, strings.TrimPrefix(cmd, "MAIL FROM:"))
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.mu.Lock()
+			s.rcpt = append(s.rcpt, strings.TrimPrefix(cmd, "RCPT TO:"))
+			s.mu.Unlock()
+			if s.rcptReply != "" {
+				reply(s.rcptReply)
+			} else {
+				reply("250 OK")
+			}
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+			reply("250 OK: queued")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func newTestEmailChannel(port, maxRetries int, lookup EmailLookup) *EmailChannel {
+	cfg := &config.EmailConfig{
+		Enabled:    true,
+		SMTPHost:   "127.0.0.1",
+		SMTPPort:   port,
+		FromEmail:  "Alerts <alerts@example.com>",
+		TLSMode:    "none",
+		TimeoutSec: 5,
+		MaxRetries: maxRetries,
+	}
+	c := NewEmailChannel(cfg, testLogger(), lookup)
+	c.backoff = retry.ExponentialBackoff(time.Millisecond, 0, false, 0)
+	return c
+}
+
+func emailPayload(email string) model.NotificationPayload {
+	return model.NotificationPayload{
+		ID:      "42",
+		UserID:  "user-1",
+		Email:   email,
+		TraceID: "trace-1",
+		Data: map[string]interface{}{
+			"subject": "Hello {{.name}}",
+			"text":    "Hi {{.name}}, your order shipped.",
+			"name":    "Ana",
+		},
+	}
+}
+
+func TestEmailChannel_Send(t *testing.T) {
+	srv := newFakeMailServer(t, "")
+	c := newTestEmailChannel(srv.port, 1, nil)
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload("ana@example.com"))
+	require.True(t, result.Success, "send failed: %v", result.Error)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, []string{"<alerts@example.com>"}, srv.from)
+	assert.Equal(t, []string{"<ana@example.com>"}, srv.rcpt)
+	require.Len(t, srv.messages, 1)
+	assert.Contains(t, srv.messages[0], "Subject: Hello Ana")
+	assert.Contains(t, srv.messages[0], "X-Trace-ID: trace-1")
+	assert.Contains(t, srv.messages[0], "Hi Ana, your order shipped.")
+}
+
+func TestEmailChannel_RejectedRecipientIsPermanent(t *testing.T) {
+	srv := newFakeMailServer(t, "550 5.1.1 No such user")
+	c := newTestEmailChannel(srv.port, 3, nil)
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload("ghost@example.com"))
+	assert.False(t, result.Success)
+	assert.False(t, result.Retryable)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Len(t, srv.rcpt, 1, "a 5xx reply should not be retried")
+	assert.Empty(t, srv.messages)
+}
+
+func TestEmailChannel_TemporaryFailureIsRetryable(t *testing.T) {
+	srv := newFakeMailServer(t, "451 4.3.0 Try again later")
+	c := newTestEmailChannel(srv.port, 1, nil)
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload("ana@example.com"))
+	assert.False(t, result.Success)
+	assert.True(t, result.Retryable)
+}
+
+func TestEmailChannel_RetriesTemporaryFailureWithBackoff(t *testing.T) {
+	srv := newFakeMailServer(t, "451 4.3.0 Try again later")
+	c := newTestEmailChannel(srv.port, 3, nil)
+	c.backoff = retry.ConstantBackoff(20*time.Millisecond, false, 0)
+
+	start := time.Now()
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload("ana@example.com"))
+	assert.False(t, result.Success)
+	assert.True(t, result.Retryable)
+	assert.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond, "retries did not wait for the backoff")
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Len(t, srv.rcpt, 3)
+}
+
+func TestEmailChannel_HeaderInjectionIsPermanent(t *testing.T) {
+	srv := newFakeMailServer(t, "")
+	c := newTestEmailChannel(srv.port, 3, nil)
+
+	payload := emailPayload("ana@example.com")
+	payload.TraceID = "trace-1\r\nBcc: victim@example.com"
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, payload)
+	assert.False(t, result.Success)
+	assert.False(t, result.Retryable)
+	assert.ErrorContains(t, result.Error, "X-Trace-ID header contains a line break")
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Empty(t, srv.rcpt)
+	assert.Empty(t, srv.messages)
+}
+
+func TestBuildEmailMessage_RejectsLineBreaksInHeaders(t *testing.T) {
+	from := &mail.Address{Address: "alerts@example.com"}
+	to := &mail.Address{Address: "ana@example.com"}
+
+	payload := emailPayload("ana@example.com")
+	payload.ID = "42\nBcc: victim@example.com"
+	_, err := buildEmailMessage(from, to, payload)
+	assert.ErrorContains(t, err, "X-Notification-ID header contains a line break")
+
+	// The subject is Q-encoded, so a line break in it stays inside the header
+	payload = emailPayload("ana@example.com")
+	payload.Data["subject"] = "Hello\r\nBcc: victim@example.com"
+	msg, err := buildEmailMessage(from, to, payload)
+	require.NoError(t, err)
+	assert.NotContains(t, string(msg), "\r\nBcc:")
+}
+
+func TestEmailChannel_LooksUpMissingAddress(t *testing.T) {
+	srv := newFakeMailServer(t, "")
+	c := newTestEmailChannel(srv.port, 1, func(_ context.Context, userID string) (string, error) {
+		assert.Equal(t, "user-1", userID)
+		return "looked-up@example.com", nil
+	})
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload(""))
+	require.True(t, result.Success, "send failed: %v", result.Error)
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, []string{"<looked-up@example.com>"}, srv.rcpt)
+}
+
+func TestEmailChannel_LookupErrorIsRetryable(t *testing.T) {
+	c := newTestEmailChannel(0, 1, func(context.Context, string) (string, error) {
+		return "", errors.New("database unavailable")
+	})
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload(""))
+	assert.False(t, result.Success)
+	assert.True(t, result.Retryable)
+	assert.ErrorContains(t, result.Error, "database unavailable")
+}
+
+func TestEmailChannel_NoAddressIsPermanent(t *testing.T) {
+	c := newTestEmailChannel(0, 1, nil)
+
+	result := c.Send(context.Background(), &model.NotificationTarget{ID: 7}, emailPayload(""))
+	assert.False(t, result.Success)
+	assert.False(t, result.Retryable)
+	assert.ErrorContains(t, result.Error, "no email address for user user-1")
+}
+
+// fakeTokenSource serves fixed device tokens
+type fakeTokenSource []*model.DeviceToken
+
+func (f fakeTokenSource) GetDeviceTokensByUserID(string) ([]*model.DeviceToken, error) {
+	return f, nil
+}
+
+func TestDeviceTokenEmailLookup_UsesLatestEmailToken(t *testing.T) {
+	now := time.Now()
+	lookup := deviceTokenEmailLookup(fakeTokenSource{
+		{Type: "expo", PushToken: "ExponentPushToken[abc]", LastSeenAt: now},
+		{Type: "email", PushToken: "old@example.com", LastSeenAt: now.Add(-time.Hour)},
+		{Type: "email", PushToken: "new@example.com", LastSeenAt: now.Add(-time.Minute)},
+	})
+
+	email, err := lookup(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, "new@example.com", email)
+
+	email, err = deviceTokenEmailLookup(fakeTokenSource{{Type: "sms", PushToken: "+15551234567"}})(context.Background(), "user-1")
+	require.NoError(t, err)
+	assert.Empty(t, email)
+}
+
+// fakeTwilioMessages serves the Messages API, answering each recipient with
+// the status and body from replies (201 if absent) and recording the requests
+type fakeTwilioMessages struct {
+	*httptest.Server
+	replies map[string][]string // To -> "status body" per attempt
+
+	mu       sync.Mutex
+	requests []url.Values
+}
+
+func newFakeTwilioMessages(t *testing.T, replies map[string][]string) *fakeTwilioMessages {
+	t.Helper()
+
+	f := &fakeTwilioMessages{replies: replies}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "AC123" || pass != "secret" || r.URL.Path != "/Accounts/AC123/Messages.json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		require.NoError(t, r.ParseForm())
+
+		f.mu.Lock()
+		f.requests = append(f.requests, r.PostForm)
+		to := r.PostForm.Get("To")
+		reply := "201 {\"sid\":\"SM1\"}"
+		if queued := f.replies[to]; len(queued) > 0 {
+			reply, f.replies[to] = queued[0], queued[1:]
+		}
+		f.mu.Unlock()
+
+		status, body, _ := strings.Cut(reply, " ")
+		code, _ := strconv.Atoi(status)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// sentTo lists the To number of every request in order
+func (f *fakeTwilioMessages) sentTo() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	to := make([]string, 0, len(f.requests))
+	for _, form := range f.requests {
+		to = append(to, form.Get("To"))
+	}
+	return to
+}
+
+func newTestSMSChannel(apiURL string, maxRetries int, tokens fakeTokenSource) *SMSChannel {
+	cfg := &config.SMSConfig{
+		Enabled:    true,
+		APIURL:     apiURL,
+		AccountSID: "AC123",
+		AuthToken:  "secret",
+		FromNumber: "+15550000000",
+		TimeoutSec: 5,
+		MaxRetries: maxRetries,
+	}
+	c := NewSMSChannel(cfg, testLogger(), nil)
+	c.repo = tokens
+	c.backoff = retry.ExponentialBackoff(time.Millisecond, 0, false, 0)
+	return c
+}
+
+func smsPayload() model.NotificationPayload {
+	return model.NotificationPayload{
+		UserID: "user-1",
+		Data:   map[string]interface{}{"title": "Order shipped", "body": "Arriving Tuesday"},
+	}
+}
+
+func TestSMSChannel_SendTokens(t *testing.T) {
+	srv := newFakeTwilioMessages(t, nil)
+	c := newTestSMSChannel(srv.URL, 1, fakeTokenSource{
+		{DeviceID: "phone-1", Type: "sms", PushToken: "+15551230001"},
+		{DeviceID: "app-1", Type: "expo", PushToken: "ExponentPushToken[abc]"},
+		{DeviceID: "phone-2", Type: "sms", PushToken: "+447700900123"},
+	})
+
+	results := c.SendTokens(context.Background(), &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.True(t, result.Success, "send to %s failed: %v", result.DeviceID, result.Error)
+	}
+
+	assert.Equal(t, []string{"+15551230001", "+447700900123"}, srv.sentTo())
+	form := srv.requests[0]
+	assert.Equal(t, "+15550000000", form.Get("From"))
+	assert.Equal(t, "Order shipped\nArriving Tuesday", form.Get("Body"))
+}
+
+func TestSMSChannel_RejectsNonE164Numbers(t *testing.T) {
+	srv := newFakeTwilioMessages(t, nil)
+	c := newTestSMSChannel(srv.URL, 3, fakeTokenSource{
+		{DeviceID: "bad-1", Type: "sms", PushToken: "555-1234"},
+		{DeviceID: "bad-2", Type: "sms", PushToken: "15551230001"},
+		{DeviceID: "good", Type: "sms", PushToken: "+15551230001"},
+	})
+
+	results := c.SendTokens(context.Background(), &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+	require.Len(t, results, 3)
+	for _, result := range results[:2] {
+		assert.False(t, result.Success)
+		assert.False(t, result.Retryable, "an invalid number will never succeed")
+		assert.ErrorContains(t, result.Error, "expected E.164")
+	}
+	assert.True(t, results[2].Success)
+	assert.Equal(t, []string{"+15551230001"}, srv.sentTo(), "invalid numbers must not reach Twilio")
+}
+
+func TestSMSChannel_RetriesServerErrors(t *testing.T) {
+	srv := newFakeTwilioMessages(t, map[string][]string{
+		"+15551230001": {`503 {"code":20503,"message":"Service unavailable","status":503}`},
+	})
+	c := newTestSMSChannel(srv.URL, 2, fakeTokenSource{
+		{DeviceID: "phone-1", Type: "sms", PushToken: "+15551230001"},
+	})
+
+	results := c.SendTokens(context.Background(), &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success, "send failed: %v", results[0].Error)
+	assert.Len(t, srv.sentTo(), 2)
+}
+
+func TestSMSChannel_ClientErrorIsPermanent(t *testing.T) {
+	srv := newFakeTwilioMessages(t, map[string][]string{
+		"+15551230001": {`400 {"code":21211,"message":"Invalid 'To' Phone Number","status":400}`},
+	})
+	c := newTestSMSChannel(srv.URL, 3, fakeTokenSource{
+		{DeviceID: "phone-1", Type: "sms", PushToken: "+15551230001"},
+	})
+
+	results := c.SendTokens(context.Background(), &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.False(t, results[0].Retryable)
+	assert.ErrorContains(t, results[0].Error, "twilio error 21211")
+	assert.Len(t, srv.sentTo(), 1, "a 4xx reply should not be retried")
+}
+
+func TestSMSChannel_NoNumbers(t *testing.T) {
+	c := newTestSMSChannel("http://127.0.0.1:0", 1, fakeTokenSource{
+		{DeviceID: "app-1", Type: "expo", PushToken: "ExponentPushToken[abc]"},
+	})
+
+	results := c.SendTokens(context.Background(), &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Retryable)
+	assert.ErrorContains(t, results[0].Error, "no sms phone numbers found for user_id: user-1")
+}
+
+func TestSMSChannel_UnsubscribedNumberFailsWithoutPruning(t *testing.T) {
+	srv := newFakeTwilioMessages(t, map[string][]string{
+		"+15551230001": {`400 {"code":21610,"message":"Attempt to send to unsubscribed recipient","status":400}`},
+	})
+	c := newTestSMSChannel(srv.URL, 3, fakeTokenSource{
+		{DeviceID: "phone-1", Type: "sms", PushToken: "+15551230001"},
+	})
+
+	results := c.SendTokens(context.Background(), &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+	require.Len(t, results, 1)
+	assert.False(t, results[0].Success)
+	assert.False(t, results[0].Retryable, "an unsubscribed number fails the target permanently")
+	assert.False(t, results[0].Unregistered, "the number must be kept so the user can opt back in")
+	assert.Len(t, srv.sentTo(), 1)
+}
+
+func TestAggregateTokenResults(t *testing.T) {
+	dead := &TokenResult{Token: "a", Unregistered: true, Error: errors.New("DeviceNotRegistered")}
+	throttled := &TokenResult{Token: "b", Retryable: true, Error: errors.New("rate limited")}
+	ok := &TokenResult{Token: "c", Success: true}
+
+	tests := []struct {
+		name      string
+		results   []*TokenResult
+		success   bool
+		retryable bool
+	}{
+		{"one token succeeded", []*TokenResult{dead, ok, throttled}, true, false},
+		{"some failure retryable", []*TokenResult{dead, throttled}, false, true},
+		{"all failures permanent", []*TokenResult{dead}, false, false},
+		{"no tokens", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := AggregateTokenResults(tt.results)
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.retryable, result.Retryable)
+			if !tt.success {
+				assert.Error(t, result.Error)
+			}
+		})
+	}
+}
+
+// fakeTokenStore holds device tokens keyed by device ID
+type fakeTokenStore struct {
+	tokens  map[string]*model.DeviceToken
+	deleted []string
+	failOn  string
+}
+
+func (s *fakeTokenStore) GetDeviceTokenByPushToken(userID, pushToken string) (*model.DeviceToken, error) {
+	for _, token := range s.tokens {
+		if token.UserID == userID && token.PushToken == pushToken {
+			return token, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (s *fakeTokenStore) DeleteDeviceToken(userID, deviceID string) error {
+	if deviceID == s.failOn {
+		return errors.New("connection reset")
+	}
+	token, ok := s.tokens[deviceID]
+	if !ok || token.UserID != userID {
+		return gorm.ErrRecordNotFound
+	}
+	delete(s.tokens, deviceID)
+	s.deleted = append(s.deleted, deviceID)
+	return nil
+}
+
+func TestTokenPruner_PrunesEveryDeadTokenInBatch(t *testing.T) {
+	store := &fakeTokenStore{tokens: map[string]*model.DeviceToken{
+		"phone":  {UserID: "user-1", DeviceID: "phone", PushToken: "ExponentPushToken[a]"},
+		"tablet": {UserID: "user-1", DeviceID: "tablet", PushToken: "ExponentPushToken[b]"},
+		"watch":  {UserID: "user-1", DeviceID: "watch", PushToken: "ExponentPushToken[c]"},
+		"laptop": {UserID: "user-1", DeviceID: "laptop", PushToken: "ExponentPushToken[d]"},
+	}}
+	pruner := NewTokenPruner(store, testLogger())
+
+	results := []*TokenResult{
+		{Token: "ExponentPushToken[a]", DeviceID: "phone", Unregistered: true},
+		{Token: "ExponentPushToken[b]", DeviceID: "tablet", Success: true},
+		// No device ID: found by push token
+		{Token: "ExponentPushToken[c]", Unregistered: true},
+		{Token: "ExponentPushToken[d]", DeviceID: "laptop", Unregistered: true},
+		// Already deleted, e.g. by a concurrent send
+		{Token: "ExponentPushToken[e]", DeviceID: "gone", Unregistered: true},
+		// Failed for another reason: kept
+		{Token: "ExponentPushToken[b]", DeviceID: "tablet", Retryable: true},
+	}
+
+	assert.Equal(t, 4, pruner.Prune("user-1", "expo", results))
+	assert.ElementsMatch(t, []string{"phone", "watch", "laptop"}, store.deleted)
+	assert.Contains(t, store.tokens, "tablet")
+	assert.EqualValues(t, 4, pruner.PrunedCount())
+}
+
+func TestTokenPruner_DeleteErrorIsNotCounted(t *testing.T) {
+	store := &fakeTokenStore{
+		tokens: map[string]*model.DeviceToken{
+			"phone":  {UserID: "user-1", DeviceID: "phone"},
+			"tablet": {UserID: "user-1", DeviceID: "tablet"},
+		},
+		failOn: "phone",
+	}
+	pruner := NewTokenPruner(store, testLogger())
+
+	pruned := pruner.Prune("user-1", "expo", []*TokenResult{
+		{DeviceID: "phone", Unregistered: true},
+		{DeviceID: "tablet", Unregistered: true},
+	})
+	assert.Equal(t, 1, pruned, "a failed delete should not stop the rest of the batch")
+	assert.Equal(t, []string{"tablet"}, store.deleted)
+	assert.EqualValues(t, 1, pruner.PrunedCount())
+}
@@ -0,0 +1,109 @@
+package channel
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// requestLimiter caps the requests a channel has in flight to its provider
+// It is shared by every worker goroutine using the channel; a nil limiter
+// allows unlimited requests.
+type requestLimiter struct {
+	slots chan struct{}
+}
+
+// newRequestLimiter creates a limiter allowing max concurrent requests
+// It returns nil when max is zero or negative.
+func newRequestLimiter(max int) *requestLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &requestLimiter{slots: make(chan struct{}, max)}
+}
+
+// acquire waits for a free slot or for ctx to be done
+func (l *requestLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees a slot taken by acquire
+func (l *requestLimiter) release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// SendPacer waits until n more messages may go out to a channel's provider
+type SendPacer func(ctx context.Context, n int) error
+
+// sendPacerKey is the context key for a SendPacer
+type sendPacerKey struct{}
+
+// WithSendPacer returns a context whose sends are paced by pacer
+// Channels call it once per message they hand to their provider, retries
+// included, so a delivery to several tokens counts each of them.
+func WithSendPacer(ctx context.Context, pacer SendPacer) context.Context {
+	return context.WithValue(ctx, sendPacerKey{}, pacer)
+}
+
+// pace waits for the context's SendPacer to allow n messages, if it has one
+func pace(ctx context.Context, n int) error {
+	pacer, ok := ctx.Value(sendPacerKey{}).(SendPacer)
+	if !ok || pacer == nil {
+		return nil
+	}
+	return pacer(ctx, n)
+}
+
+// newSenderClient creates the HTTP client a channel reuses for every send
+// Its transport keeps at most maxConns connections per host (0 = unlimited),
+// so idle connections are reused instead of piling up under load.
+func newSenderClient(timeoutSec, maxConns int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	if maxConns > 0 {
+		transport.MaxConnsPerHost = maxConns
+		transport.MaxIdleConnsPerHost = maxConns
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(timeoutSec) * time.Second,
+	}
+}
+
+// contextTransport sends every request under ctx, for SDKs that build their
+// requests without one
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
+
+// withRequestContext returns a copy of client whose requests run under ctx
+// The copy shares the client's connections but drops its Timeout, which would
+// otherwise be lost on the replaced request context; put it on ctx instead.
+func withRequestContext(ctx context.Context, client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	scoped := *client
+	scoped.Transport = contextTransport{ctx: ctx, base: base}
+	scoped.Timeout = 0
+	return &scoped
+}
@@ -0,0 +1,201 @@
+package channel
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+
+	expo "github.com/oliveroneill/exponent-server-sdk-golang/sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// concurrencyServer records the peak number of in-flight requests and how
+// many connections clients opened
+type concurrencyServer struct {
+	*httptest.Server
+	inFlight atomic.Int32
+	peak     atomic.Int32
+	conns    atomic.Int32
+}
+
+func newConcurrencyServer(t *testing.T, body string) *concurrencyServer {
+	t.Helper()
+
+	s := &concurrencyServer{}
+	s.Server = httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := s.inFlight.Add(1)
+		defer s.inFlight.Add(-1)
+		for {
+			peak := s.peak.Load()
+			if n <= peak || s.peak.CompareAndSwap(peak, n) {
+				break
+			}
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	s.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.conns.Add(1)
+		}
+	}
+	s.Start()
+	t.Cleanup(s.Close)
+	return s
+}
+
+// runConcurrently calls fn from n goroutines at once
+func runConcurrently(n int, fn func()) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fn()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSMSChannel_ConcurrentSendsShareBoundedClient(t *testing.T) {
+	srv := newConcurrencyServer(t, `{"sid":"SM1"}`)
+	cfg := &config.SMSConfig{
+		Enabled:               true,
+		APIURL:                srv.URL,
+		AccountSID:            "AC123",
+		AuthToken:             "secret",
+		FromNumber:            "+15550000000",
+		TimeoutSec:            5,
+		MaxConcurrentRequests: 3,
+	}
+	c := NewSMSChannel(cfg, testLogger(), nil)
+
+	var failed atomic.Int32
+	runConcurrently(20, func() {
+		if _, err := c.sendMessage(context.Background(), "+15551234567", "hello"); err != nil {
+			failed.Add(1)
+		}
+	})
+
+	assert.Zero(t, failed.Load())
+	assert.LessOrEqual(t, srv.peak.Load(), int32(3))
+	assert.LessOrEqual(t, srv.conns.Load(), int32(3), "sends should reuse the channel's connections")
+}
+
+func TestExpoChannel_ConcurrentPublishesShareBoundedClient(t *testing.T) {
+	srv := newConcurrencyServer(t, `{"data":[{"status":"ok","id":"r1"}]}`)
+	cfg := &config.ExpoConfig{
+		Enabled:               true,
+		APIURL:                srv.URL + "/--/api/v2/push/send",
+		TimeoutSec:            5,
+		MaxRetries:            1,
+		MaxConcurrentRequests: 2,
+	}
+	c := NewExpoChannel(cfg, testLogger(), nil)
+
+	token, err := expo.NewExponentPushToken("ExponentPushToken[abc]")
+	require.NoError(t, err)
+	messages := []expo.PushMessage{{To: []expo.ExponentPushToken{token}, Body: "hello"}}
+
+	var failed atomic.Int32
+	runConcurrently(12, func() {
+		if _, err := c.publish(context.Background(), messages); err != nil {
+			failed.Add(1)
+		}
+	})
+
+	assert.Zero(t, failed.Load())
+	assert.LessOrEqual(t, srv.peak.Load(), int32(2))
+	assert.LessOrEqual(t, srv.conns.Load(), int32(2), "publishes should reuse the channel's connections")
+}
+
+func TestRequestLimiter(t *testing.T) {
+	t.Run("nil allows everything", func(t *testing.T) {
+		limiter := newRequestLimiter(0)
+		assert.Nil(t, limiter)
+		require.NoError(t, limiter.acquire(context.Background()))
+		limiter.release()
+	})
+
+	t.Run("waiting honours the context", func(t *testing.T) {
+		limiter := newRequestLimiter(1)
+		require.NoError(t, limiter.acquire(context.Background()))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, limiter.acquire(ctx), context.DeadlineExceeded)
+
+		limiter.release()
+		require.NoError(t, limiter.acquire(context.Background()))
+	})
+}
+
+func TestSendPacer_CountsEveryMessage(t *testing.T) {
+	var paced []int
+	ctx := WithSendPacer(context.Background(), func(_ context.Context, n int) error {
+		paced = append(paced, n)
+		return nil
+	})
+
+	t.Run("expo paces the whole batch", func(t *testing.T) {
+		paced = nil
+		srv := newConcurrencyServer(t, `{"data":[{"status":"ok","id":"r1"},{"status":"ok","id":"r2"},{"status":"ok","id":"r3"}]}`)
+		c := NewExpoChannel(&config.ExpoConfig{
+			Enabled:    true,
+			APIURL:     srv.URL + "/--/api/v2/push/send",
+			TimeoutSec: 5,
+			MaxRetries: 1,
+		}, testLogger(), nil)
+
+		var messages []expo.PushMessage
+		for _, raw := range []string{"ExponentPushToken[a]", "ExponentPushToken[b]", "ExponentPushToken[c]"} {
+			token, err := expo.NewExponentPushToken(raw)
+			require.NoError(t, err)
+			messages = append(messages, expo.PushMessage{To: []expo.ExponentPushToken{token}, Body: "hello"})
+		}
+
+		_, err := c.publish(ctx, messages)
+		require.NoError(t, err)
+		assert.Equal(t, []int{3}, paced)
+	})
+
+	t.Run("sms paces each number", func(t *testing.T) {
+		paced = nil
+		srv := newFakeTwilioMessages(t, nil)
+		c := newTestSMSChannel(srv.URL, 1, fakeTokenSource{
+			{DeviceID: "phone-1", Type: "sms", PushToken: "+15551230001"},
+			{DeviceID: "phone-2", Type: "sms", PushToken: "+447700900123"},
+		})
+
+		results := c.SendTokens(ctx, &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+		require.Len(t, results, 2)
+		assert.Equal(t, []int{1, 1}, paced)
+	})
+
+	t.Run("a pacer error stops the send", func(t *testing.T) {
+		srv := newFakeTwilioMessages(t, nil)
+		c := newTestSMSChannel(srv.URL, 1, fakeTokenSource{
+			{DeviceID: "phone-1", Type: "sms", PushToken: "+15551230001"},
+		})
+		failing := WithSendPacer(context.Background(), func(context.Context, int) error {
+			return context.DeadlineExceeded
+		})
+
+		results := c.SendTokens(failing, &model.NotificationTarget{ID: 7, UserID: "user-1"}, smsPayload())
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Success)
+		assert.True(t, results[0].Retryable)
+		assert.Empty(t, srv.sentTo())
+	})
+}
@@ -0,0 +1,184 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"myapp/internal/pkg/health"
+
+	"go.uber.org/zap"
+)
+
+// defaultExpoReceiptsURL is used when the configured API URL is not the standard send endpoint
+const defaultExpoReceiptsURL = "https://exp.host/--/api/v2/push/getReceipts"
+
+// SelfChecker is implemented by channels that can verify their connectivity
+// and credentials without delivering a notification
+type SelfChecker interface {
+	SelfCheck(ctx context.Context) error
+}
+
+// SelfCheck asks Expo for the receipts of an empty ID list, which checks
+// reachability and the access token without sending anything
+func (c *ExpoChannel) SelfCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, expoReceiptsURL(c.config.APIURL), strings.NewReader(`{"ids":[]}`))
+	if err != nil {
+		return fmt.Errorf("failed to build expo receipts request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if c.config.AccessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("expo receipts request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("expo receipts request returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// expoReceiptsURL derives the getReceipts endpoint from the send endpoint
+func expoReceiptsURL(apiURL string) string {
+	if base, ok := strings.CutSuffix(apiURL, "/send"); ok {
+		return base + "/getReceipts"
+	}
+	return defaultExpoReceiptsURL
+}
+
+// SelfCheck connects and authenticates to the SMTP server and issues NOOP
+func (c *EmailChannel) SelfCheck(ctx context.Context) error {
+	client, err := c.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("smtp self-check failed: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Noop(); err != nil {
+		return fmt.Errorf("smtp NOOP failed: %w", err)
+	}
+	return client.Quit()
+}
+
+// SelfCheck fetches the Twilio account, which checks the account SID and auth token
+func (c *SMSChannel) SelfCheck(ctx context.Context) error {
+	endpoint := fmt.Sprintf("%s/Accounts/%s.json",
+		strings.TrimRight(c.config.APIURL, "/"), url.PathEscape(c.config.AccountSID))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build twilio request: %w", err)
+	}
+	req.SetBasicAuth(c.config.AccountSID, c.config.AuthToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("twilio request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("twilio account lookup returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RunSelfChecks runs the self-check of every registered channel that supports
+// one and returns the outcome by channel name (nil error = passed)
+// Channels without a self-check (FCM and APNS until they are implemented) are skipped.
+func (r *ChannelRegistry) RunSelfChecks(ctx context.Context) map[string]error {
+	results := make(map[string]error)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, ch := range r.channels {
+		checker, ok := ch.(SelfChecker)
+		if !ok {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := checker.SelfCheck(ctx)
+
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+
+			if err != nil {
+				r.logger.Error("Channel self-check failed", zap.String("channel", name), zap.Error(err))
+			} else {
+				r.logger.Info("Channel self-check passed", zap.String("channel", name))
+			}
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
+
+// SelfCheckHealthProvider reports startup self-check results to the health service
+// It is DEGRADED when some channels failed and DOWN when all of them did.
+type SelfCheckHealthProvider struct {
+	results   map[string]error
+	checkedAt time.Time
+}
+
+// NewSelfCheckHealthProvider creates a health provider from RunSelfChecks results
+func NewSelfCheckHealthProvider(results map[string]error) *SelfCheckHealthProvider {
+	return &SelfCheckHealthProvider{
+		results:   results,
+		checkedAt: time.Now(),
+	}
+}
+
+// Name returns the name of the provider
+func (p *SelfCheckHealthProvider) Name() string {
+	return "notification-channels"
+}
+
+// Check returns the stored self-check results
+func (p *SelfCheckHealthProvider) Check(ctx context.Context) health.HealthCheckResult {
+	result := health.HealthCheckResult{
+		Name:      p.Name(),
+		Status:    health.StatusUp,
+		Details:   make(map[string]interface{}, len(p.results)),
+		CheckedAt: p.checkedAt,
+	}
+
+	var failed []string
+	for name, err := range p.results {
+		if err != nil {
+			result.Details[name] = err.Error()
+			failed = append(failed, name)
+		} else {
+			result.Details[name] = "ok"
+		}
+	}
+
+	if len(failed) > 0 {
+		result.Status = health.StatusDegraded
+		if len(failed) == len(p.results) {
+			result.Status = health.StatusDown
+		}
+		slices.Sort(failed)
+		result.Error = "self-check failed for " + strings.Join(failed, ", ")
+	}
+	return result
+}
@@ -0,0 +1,208 @@
+package channel
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"myapp/internal/pkg/health"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func testLogger() *logger.Logger {
+	return &logger.Logger{Logger: zap.NewNop()}
+}
+
+// newFakeExpo serves getReceipts and accepts only the given access token
+func newFakeExpo(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/--/api/v2/push/getReceipts" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newFakeTwilio serves the account resource for the given credentials
+func newFakeTwilio(t *testing.T, sid, authToken string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != sid || pass != authToken || r.URL.Path != "/Accounts/"+sid+".json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"sid":"` + sid + `"}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// newFakeSMTP accepts AUTH PLAIN for user/pass and answers NOOP, returning its port
+func newFakeSMTP(t *testing.T, user, pass string) int {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeSMTP(conn, user, pass)
+		}
+	}()
+
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+func serveFakeSMTP(conn net.Conn, user, pass string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"):
+			reply("250-fake")
+			reply("250 AUTH PLAIN")
+		case strings.HasPrefix(cmd, "AUTH PLAIN "):
+			creds, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(cmd, "AUTH PLAIN "))
+			if string(creds) == "\x00"+user+"\x00"+pass {
+				reply("235 2.7.0 Authentication successful")
+			} else {
+				reply("535 5.7.8 Authentication credentials invalid")
+			}
+		case cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestExpoChannel_SelfCheck(t *testing.T) {
+	srv := newFakeExpo(t, "good-token")
+	cfg := &config.ExpoConfig{Enabled: true, APIURL: srv.URL + "/--/api/v2/push/send", TimeoutSec: 5}
+
+	cfg.AccessToken = "good-token"
+	assert.NoError(t, NewExpoChannel(cfg, testLogger(), nil).SelfCheck(context.Background()))
+
+	cfg.AccessToken = "bad-token"
+	err := NewExpoChannel(cfg, testLogger(), nil).SelfCheck(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+}
+
+func TestSMSChannel_SelfCheck(t *testing.T) {
+	srv := newFakeTwilio(t, "AC123", "secret")
+	cfg := &config.SMSConfig{Enabled: true, APIURL: srv.URL, AccountSID: "AC123", FromNumber: "+15550000000", TimeoutSec: 5}
+
+	cfg.AuthToken = "secret"
+	assert.NoError(t, NewSMSChannel(cfg, testLogger(), nil).SelfCheck(context.Background()))
+
+	cfg.AuthToken = "wrong"
+	err := NewSMSChannel(cfg, testLogger(), nil).SelfCheck(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 401")
+}
+
+func TestEmailChannel_SelfCheck(t *testing.T) {
+	port := newFakeSMTP(t, "mailer", "secret")
+	cfg := &config.EmailConfig{
+		Enabled:    true,
+		SMTPHost:   "127.0.0.1",
+		SMTPPort:   port,
+		Username:   "mailer",
+		FromEmail:  "noreply@example.com",
+		TLSMode:    "none",
+		TimeoutSec: 5,
+	}
+
+	cfg.Password = "secret"
+	assert.NoError(t, NewEmailChannel(cfg, testLogger(), nil).SelfCheck(context.Background()))
+
+	cfg.Password = "wrong"
+	err := NewEmailChannel(cfg, testLogger(), nil).SelfCheck(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "535")
+}
+
+func TestChannelRegistry_SelfChecksReflectedInHealth(t *testing.T) {
+	expoSrv := newFakeExpo(t, "good-token")
+	twilioSrv := newFakeTwilio(t, "AC123", "secret")
+
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.Senders = config.SenderConfig{
+		Expo: config.ExpoConfig{
+			Enabled: true, APIURL: expoSrv.URL + "/--/api/v2/push/send", AccessToken: "good-token", TimeoutSec: 5,
+		},
+		SMS: config.SMSConfig{
+			Enabled: true, APIURL: twilioSrv.URL, AccountSID: "AC123", AuthToken: "wrong", FromNumber: "+15550000000", TimeoutSec: 5,
+		},
+		// No self-check yet, so it is skipped
+		FCM: config.FCMConfig{Enabled: true, ProjectID: "p", CredentialsFile: "creds.json"},
+	}
+
+	// Self-checks never query the repository, so it needs no database
+	registry, err := NewChannelRegistry(cfg, testLogger(), repository.NewNotificationRepository(nil))
+	require.NoError(t, err)
+
+	results := registry.RunSelfChecks(context.Background())
+	require.Len(t, results, 2)
+	assert.NoError(t, results["expo"])
+	assert.Error(t, results["sms"])
+
+	result := NewSelfCheckHealthProvider(results).Check(context.Background())
+	assert.Equal(t, health.StatusDegraded, result.Status)
+	assert.Equal(t, "ok", result.Details["expo"])
+	assert.Contains(t, result.Details["sms"], "status 401")
+	assert.Equal(t, "self-check failed for sms", result.Error)
+}
+
+func TestSelfCheckHealthProvider_Status(t *testing.T) {
+	up := NewSelfCheckHealthProvider(map[string]error{"expo": nil}).Check(context.Background())
+	assert.Equal(t, health.StatusUp, up.Status)
+	assert.Empty(t, up.Error)
+
+	down := NewSelfCheckHealthProvider(map[string]error{
+		"expo": errors.New("unreachable"),
+		"sms":  errors.New("status 401"),
+	}).Check(context.Background())
+	assert.Equal(t, health.StatusDown, down.Status)
+	assert.Equal(t, "self-check failed for expo, sms", down.Error)
+}
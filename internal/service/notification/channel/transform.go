@@ -0,0 +1,79 @@
+package channel
+
+import (
+	"context"
+	"fmt"
+
+	"myapp/internal/service/notification/model"
+)
+
+// PayloadTransformer rewrites a payload before a channel sends it, e.g. to
+// add tracking parameters or rewrite deep links for a deployment
+type PayloadTransformer interface {
+	Transform(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error)
+}
+
+// PayloadTransformerFunc adapts a function to PayloadTransformer
+type PayloadTransformerFunc func(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error)
+
+// Transform calls f
+func (f PayloadTransformerFunc) Transform(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error) {
+	return f(ctx, target, payload)
+}
+
+// AddPayloadTransformer registers a transformer for the named channel
+// Transformers run in the order they were added, each receiving the previous
+// one's output. Register them at startup, before the worker starts sending.
+func (r *ChannelRegistry) AddPayloadTransformer(channelName string, transformer PayloadTransformer) {
+	if r.transformers == nil {
+		r.transformers = make(map[string][]PayloadTransformer)
+	}
+	r.transformers[channelName] = append(r.transformers[channelName], transformer)
+}
+
+// transformingChannel runs payload transformers before delegating to the
+// wrapped channel. A transformer error fails the send without a retry, since
+// the same payload would fail the same way again.
+type transformingChannel struct {
+	Channel
+	transformers []PayloadTransformer
+}
+
+// Send transforms the payload and sends it
+func (c *transformingChannel) Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *ChannelResult {
+	payload, err := c.transform(ctx, target, payload)
+	if err != nil {
+		return &ChannelResult{Success: false, Retryable: false, Error: err}
+	}
+	return c.Channel.Send(ctx, target, payload)
+}
+
+// SendTokens transforms the payload and sends it to every token
+func (c *transformingChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
+	payload, err := c.transform(ctx, target, payload)
+	if err != nil {
+		return failedTokenResult(err, false)
+	}
+	return c.Channel.SendTokens(ctx, target, payload)
+}
+
+// transform runs the transformers on a copy of the payload data, so the
+// caller's payload, which is reused on retries, is left untouched
+func (c *transformingChannel) transform(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error) {
+	if payload.Data != nil {
+		data := make(map[string]interface{}, len(payload.Data))
+		for key, value := range payload.Data {
+			data[key] = value
+		}
+		payload.Data = data
+	}
+
+	for _, transformer := range c.transformers {
+		var err error
+		payload, err = transformer.Transform(ctx, target, payload)
+		if err != nil {
+			return payload, fmt.Errorf("payload transformer for %s channel failed: %w", c.Name(), err)
+		}
+	}
+	return payload, nil
+}
@@ -0,0 +1,91 @@
+package channel
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingChannel records the payloads it is asked to send
+type recordingChannel struct {
+	sent []model.NotificationPayload
+}
+
+func (c *recordingChannel) Name() string { return "recording" }
+
+func (c *recordingChannel) Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *ChannelResult {
+	return AggregateTokenResults(c.SendTokens(ctx, target, payload))
+}
+
+func (c *recordingChannel) SendTokens(_ context.Context, _ *model.NotificationTarget, payload model.NotificationPayload) []*TokenResult {
+	c.sent = append(c.sent, payload)
+	return []*TokenResult{{Token: "t1", Success: true}}
+}
+
+func newRecordingRegistry() (*ChannelRegistry, *recordingChannel) {
+	ch := &recordingChannel{}
+	return &ChannelRegistry{
+		channels: map[string]Channel{"recording": ch},
+		logger:   testLogger(),
+	}, ch
+}
+
+func TestPayloadTransformer_ChannelReceivesTransformedPayload(t *testing.T) {
+	registry, inner := newRecordingRegistry()
+	registry.AddPayloadTransformer("recording", PayloadTransformerFunc(
+		func(_ context.Context, _ *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error) {
+			payload.Data["url"] = payload.Data["url"].(string) + "?utm_source=push"
+			return payload, nil
+		}))
+	registry.AddPayloadTransformer("recording", PayloadTransformerFunc(
+		func(_ context.Context, target *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error) {
+			payload.Data["url"] = payload.Data["url"].(string) + "&user=" + target.UserID
+			return payload, nil
+		}))
+
+	ch, ok := registry.GetChannel("recording")
+	require.True(t, ok)
+
+	payload := model.NotificationPayload{Data: map[string]interface{}{"url": "app://orders/1"}}
+	results := ch.SendTokens(context.Background(), &model.NotificationTarget{UserID: "u1"}, payload)
+
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+	require.Len(t, inner.sent, 1)
+	assert.Equal(t, "app://orders/1?utm_source=push&user=u1", inner.sent[0].Data["url"])
+	assert.Equal(t, "app://orders/1", payload.Data["url"], "the caller's payload must not change")
+	assert.Equal(t, "recording", ch.Name())
+}
+
+func TestPayloadTransformer_ErrorFailsSendWithoutRetry(t *testing.T) {
+	registry, inner := newRecordingRegistry()
+	registry.AddPayloadTransformer("recording", PayloadTransformerFunc(
+		func(_ context.Context, _ *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error) {
+			return payload, errors.New("bad link")
+		}))
+
+	ch, _ := registry.GetChannel("recording")
+	result := ch.Send(context.Background(), &model.NotificationTarget{UserID: "u1"}, model.NotificationPayload{})
+
+	assert.False(t, result.Success)
+	assert.False(t, result.Retryable)
+	assert.ErrorContains(t, result.Error, "bad link")
+	assert.Empty(t, inner.sent)
+}
+
+func TestPayloadTransformer_OnlyWrapsItsChannel(t *testing.T) {
+	registry, inner := newRecordingRegistry()
+	registry.AddPayloadTransformer("other", PayloadTransformerFunc(
+		func(_ context.Context, _ *model.NotificationTarget, payload model.NotificationPayload) (model.NotificationPayload, error) {
+			return payload, errors.New("should not run")
+		}))
+
+	ch, ok := registry.GetChannel("recording")
+	require.True(t, ok)
+	assert.Same(t, inner, ch)
+}
@@ -1,9 +1,24 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
 	"myapp/internal/pkg/config"
 )
 
+// ErrInvalidSenderConfig is returned when an enabled sender is missing required settings
+var ErrInvalidSenderConfig = errors.New("invalid sender config")
+
+// ErrInvalidChannelOverride is returned when a per-channel override is out of range
+var ErrInvalidChannelOverride = errors.New("invalid channel override")
+
+// channelNames lists the senders a channel override may refer to
+var channelNames = []string{"apns", "email", "expo", "fcm", "sms"}
+
 // ServiceConfig embeds the common application config for the notification service
 type ServiceConfig struct {
 	*config.Config
@@ -17,13 +32,173 @@ type NotificationServiceConfig struct {
 
 	// Worker configuration
 	WorkerConcurrency int `mapstructure:"worker_concurrency" default:"10"`
+	// WorkerMaxInFlight caps notifications processed at once (0 = unlimited)
+	WorkerMaxInFlight int64 `mapstructure:"worker_max_in_flight" default:"0"`
+
+	// SendTimeoutSec is the deadline of a single channel send's context, so a
+	// slow channel can't hold a worker goroutine; the send is still waited
+	// for, and a send that failed on the deadline is retried (0 = no limit)
+	SendTimeoutSec int `mapstructure:"send_timeout_sec" default:"30"`
+
+	// IdempotencyFailOpen processes a notification when the idempotency check
+	// itself fails, accepting a small risk of a duplicate send instead of
+	// blocking deliveries during a store outage
+	IdempotencyFailOpen bool `mapstructure:"idempotency_fail_open" default:"false"`
+
+	// OrderPerUser processes each user's deliveries one at a time, in the
+	// order they were dequeued, while different users still run in parallel.
+	// A retried delivery is dequeued again later, so it can land after the
+	// user's newer deliveries; ordering doesn't survive retries.
+	OrderPerUser bool `mapstructure:"order_per_user" default:"false"`
+
+	// TaskType is the worker task type notifications are enqueued and
+	// handled under; change it when sharing a task queue with other services
+	TaskType string `mapstructure:"task_type" default:"notification"`
 
 	// Retry configuration
 	MaxRetries      int `mapstructure:"max_retries" default:"3"`
 	RetryBackoffSec int `mapstructure:"retry_backoff_sec" default:"60"`
 
+	// ChannelOverrides tunes concurrency and retry backoff per sender, keyed
+	// by channel name; channels without an override use the global values
+	ChannelOverrides map[string]ChannelOverride `mapstructure:"channel_overrides"`
+
 	// Sender configuration
 	Senders SenderConfig `mapstructure:"senders"`
+
+	// TokenMasking controls how push tokens are shown in API responses
+	TokenMasking TokenMaskingConfig `mapstructure:"token_masking"`
+
+	// UnknownUsers decides what CreateNotification does with targets whose
+	// user doesn't exist: "allow" creates them as-is, "reject" fails the
+	// request and "flag" stores them as failed so they are never sent.
+	// Checking needs a UserChecker on the service; without one it is "allow".
+	UnknownUsers string `mapstructure:"unknown_users" default:"allow"`
+
+	// PayloadLimits bounds target payloads accepted by CreateNotification
+	PayloadLimits PayloadLimitsConfig `mapstructure:"payload_limits"`
+
+	// CursorSecret signs pagination cursors; required, so a deployment
+	// never signs them with an empty or shared key
+	CursorSecret string `mapstructure:"cursor_secret"`
+
+	// SuccessRateAlarm marks the worker unhealthy when deliveries start failing
+	SuccessRateAlarm SuccessRateAlarmConfig `mapstructure:"success_rate_alarm"`
+
+	// FailedRetention removes failed deliveries once they are old enough
+	FailedRetention FailedRetentionConfig `mapstructure:"failed_retention"`
+}
+
+// FailedRetentionConfig controls the cleanup job for failed deliveries.
+// Days of 0 keeps failed deliveries forever and disables the job.
+type FailedRetentionConfig struct {
+	// Days is how long a delivery stays listed after it failed
+	Days int `mapstructure:"days" default:"0"`
+	// IntervalMinutes is how often the cleanup job runs
+	IntervalMinutes int `mapstructure:"interval_minutes" default:"60"`
+	// BatchSize caps the rows removed per statement so cleanup never holds long locks
+	BatchSize int `mapstructure:"batch_size" default:"1000"`
+	// Archive copies deliveries into notification_delivery_archive before removing them
+	Archive bool `mapstructure:"archive" default:"false"`
+}
+
+// PayloadLimitsConfig bounds the shape of a target payload (0 = built-in default)
+type PayloadLimitsConfig struct {
+	// MaxDepth is the deepest nesting of objects and arrays; the payload itself is depth 1
+	MaxDepth int `mapstructure:"max_depth" default:"10"`
+	// MaxSizeBytes is the largest serialized JSON size
+	MaxSizeBytes int `mapstructure:"max_size_bytes" default:"65536"`
+	// MaxKeys is the most object keys across all nesting levels
+	MaxKeys int `mapstructure:"max_keys" default:"1000"`
+}
+
+// SuccessRateAlarmConfig holds the rolling delivery success-rate thresholds
+// reported through the worker health check. A zero threshold is disabled.
+type SuccessRateAlarmConfig struct {
+	WindowSec int `mapstructure:"window_sec" default:"300"`
+	// DegradedBelow reports DEGRADED when the success rate (0-1) falls below it
+	DegradedBelow float64 `mapstructure:"degraded_below" default:"0.9"`
+	// DownBelow reports DOWN when the success rate (0-1) falls below it
+	DownBelow float64 `mapstructure:"down_below" default:"0.5"`
+	// MinAttempts is how many attempts the window needs before the rate counts
+	MinAttempts int `mapstructure:"min_attempts" default:"20"`
+}
+
+// ChannelOverride holds per-channel worker settings (0 = use the global value)
+type ChannelOverride struct {
+	// Concurrency caps simultaneous sends on the channel; it can't exceed worker_concurrency
+	Concurrency int `mapstructure:"concurrency"`
+	// RetryBackoffSec replaces retry_backoff_sec as the retry base delay
+	RetryBackoffSec int `mapstructure:"retry_backoff_sec"`
+	// SendsPerSecond paces sends to the provider's rate limit (0 = unpaced)
+	SendsPerSecond float64 `mapstructure:"sends_per_second"`
+	// SendBurst is how many sends may go out back to back before pacing applies
+	SendBurst int `mapstructure:"send_burst"`
+}
+
+// ChannelConcurrency returns the send concurrency for a channel
+// Without an override it is WorkerConcurrency, i.e. no channel-specific cap.
+func (c NotificationServiceConfig) ChannelConcurrency(channel string) int {
+	if override, ok := c.ChannelOverrides[channel]; ok && override.Concurrency > 0 {
+		return override.Concurrency
+	}
+	return c.WorkerConcurrency
+}
+
+// ChannelRetryBackoff returns the retry base delay for a channel
+func (c NotificationServiceConfig) ChannelRetryBackoff(channel string) time.Duration {
+	if override, ok := c.ChannelOverrides[channel]; ok && override.RetryBackoffSec > 0 {
+		return time.Duration(override.RetryBackoffSec) * time.Second
+	}
+	return time.Duration(c.RetryBackoffSec) * time.Second
+}
+
+// ValidateChannelOverrides checks that overrides name known channels and stay
+// within range. All problems are reported together.
+func (c NotificationServiceConfig) ValidateChannelOverrides() error {
+	channels := make([]string, 0, len(c.ChannelOverrides))
+	for channel := range c.ChannelOverrides {
+		channels = append(channels, channel)
+	}
+	slices.Sort(channels)
+
+	var errs []error
+	for _, channel := range channels {
+		override := c.ChannelOverrides[channel]
+		if !slices.Contains(channelNames, channel) {
+			errs = append(errs, fmt.Errorf("%w: unknown channel %q, expected one of %s",
+				ErrInvalidChannelOverride, channel, strings.Join(channelNames, ", ")))
+			continue
+		}
+		if override.Concurrency < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s concurrency must not be negative",
+				ErrInvalidChannelOverride, channel))
+		} else if override.Concurrency > c.WorkerConcurrency {
+			errs = append(errs, fmt.Errorf("%w: %s concurrency %d exceeds worker_concurrency %d",
+				ErrInvalidChannelOverride, channel, override.Concurrency, c.WorkerConcurrency))
+		}
+		if override.RetryBackoffSec < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s retry_backoff_sec must not be negative",
+				ErrInvalidChannelOverride, channel))
+		}
+		if override.SendsPerSecond < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s sends_per_second must not be negative",
+				ErrInvalidChannelOverride, channel))
+		}
+		if override.SendBurst < 0 {
+			errs = append(errs, fmt.Errorf("%w: %s send_burst must not be negative",
+				ErrInvalidChannelOverride, channel))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// TokenMaskingConfig holds how many leading and trailing characters of a
+// push token stay visible in responses
+type TokenMaskingConfig struct {
+	VisiblePrefix int `mapstructure:"visible_prefix" default:"8"`
+	VisibleSuffix int `mapstructure:"visible_suffix" default:"4"`
 }
 
 // PollerConfig holds poller-specific configuration
@@ -34,6 +209,11 @@ type PollerConfig struct {
 	MaxQueueSize             int  `mapstructure:"max_queue_size" default:"2000"`
 	BackoffOnEmptySec        int  `mapstructure:"backoff_on_empty_sec" default:"30"`
 	ProcessingTimeoutMinutes int  `mapstructure:"processing_timeout_minutes" default:"5"`
+	// MaxErrorBackoffSec caps the poll interval while the database keeps failing
+	MaxErrorBackoffSec int `mapstructure:"max_error_backoff_sec" default:"60"`
+	// MaxPerUser caps one user's deliveries per batch and interleaves users
+	// of the same priority, so a large backlog can't starve others (0 = off)
+	MaxPerUser int `mapstructure:"max_per_user" default:"0"`
 }
 
 // SenderConfig holds configuration for notification senders
@@ -42,6 +222,79 @@ type SenderConfig struct {
 	FCM   FCMConfig   `mapstructure:"fcm"`
 	APNS  APNSConfig  `mapstructure:"apns"`
 	Email EmailConfig `mapstructure:"email"`
+	SMS   SMSConfig   `mapstructure:"sms"`
+
+	// SelfCheckOnStartup verifies each enabled sender's connectivity and
+	// credentials at startup, without delivering anything, and reports the
+	// results to the health service
+	SelfCheckOnStartup bool `mapstructure:"self_check_on_startup" default:"false"`
+}
+
+// Validate checks that every enabled sender has the settings it needs to send,
+// so misconfiguration fails at startup rather than on the first notification.
+// All problems are reported together.
+func (c SenderConfig) Validate() error {
+	var errs []error
+
+	if c.Expo.Enabled {
+		errs = append(errs, requireFields("expo", map[string]bool{
+			"api_url": c.Expo.APIURL == "",
+		}))
+	}
+	if c.FCM.Enabled {
+		errs = append(errs, requireFields("fcm", map[string]bool{
+			"project_id":       c.FCM.ProjectID == "",
+			"credentials_file": c.FCM.CredentialsFile == "",
+		}))
+	}
+	if c.APNS.Enabled {
+		errs = append(errs, requireFields("apns", map[string]bool{
+			"key_id":    c.APNS.KeyID == "",
+			"team_id":   c.APNS.TeamID == "",
+			"bundle_id": c.APNS.BundleID == "",
+			"key_file":  c.APNS.KeyFile == "",
+		}))
+	}
+	if c.Email.Enabled {
+		errs = append(errs, requireFields("email", map[string]bool{
+			"smtp_host":  c.Email.SMTPHost == "",
+			"smtp_port":  c.Email.SMTPPort <= 0,
+			"from_email": c.Email.FromEmail == "",
+		}))
+		switch c.Email.TLSMode {
+		case "", "none", "starttls", "tls":
+		default:
+			errs = append(errs, fmt.Errorf("%w: email tls_mode %q must be one of none, starttls or tls",
+				ErrInvalidSenderConfig, c.Email.TLSMode))
+		}
+	}
+	if c.SMS.Enabled {
+		errs = append(errs, requireFields("sms", map[string]bool{
+			"api_url":     c.SMS.APIURL == "",
+			"account_sid": c.SMS.AccountSID == "",
+			"auth_token":  c.SMS.AuthToken == "",
+			"from_number": c.SMS.FromNumber == "",
+		}))
+	}
+
+	return errors.Join(errs...)
+}
+
+// requireFields reports the missing fields of an enabled sender, in sorted order
+func requireFields(sender string, missing map[string]bool) error {
+	var fields []string
+	for field, isMissing := range missing {
+		if isMissing {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	slices.Sort(fields)
+	return fmt.Errorf("%w: %s sender is enabled but missing %s",
+		ErrInvalidSenderConfig, sender, strings.Join(fields, ", "))
 }
 
 // ExpoConfig holds Expo push notification configuration
@@ -51,6 +304,9 @@ type ExpoConfig struct {
 	AccessToken string `mapstructure:"access_token"`
 	TimeoutSec  int    `mapstructure:"timeout_sec" default:"30"`
 	MaxRetries  int    `mapstructure:"max_retries" default:"3"`
+	// MaxConcurrentRequests caps in-flight requests to Expo across all workers
+	// (0 = unlimited); Expo recommends at most 6
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests" default:"6"`
 }
 
 // FCMConfig holds Firebase Cloud Messaging configuration
@@ -76,29 +332,66 @@ type APNSConfig struct {
 
 // EmailConfig holds email sender configuration
 type EmailConfig struct {
+	Enabled   bool   `mapstructure:"enabled" default:"false"`
+	SMTPHost  string `mapstructure:"smtp_host"`
+	SMTPPort  int    `mapstructure:"smtp_port"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	FromEmail string `mapstructure:"from_email"`
+	// TLSMode is one of "none", "starttls" or "tls" (implicit TLS, usually port 465)
+	TLSMode    string `mapstructure:"tls_mode" default:"starttls"`
+	TimeoutSec int    `mapstructure:"timeout_sec" default:"30"`
+	MaxRetries int    `mapstructure:"max_retries" default:"3"`
+}
+
+// SMSConfig holds Twilio SMS sender configuration
+type SMSConfig struct {
 	Enabled    bool   `mapstructure:"enabled" default:"false"`
-	SMTPHost   string `mapstructure:"smtp_host"`
-	SMTPPort   int    `mapstructure:"smtp_port"`
-	Username   string `mapstructure:"username"`
-	Password   string `mapstructure:"password"`
-	FromEmail  string `mapstructure:"from_email"`
+	APIURL     string `mapstructure:"api_url" default:"https://api.twilio.com/2010-04-01"`
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
+	FromNumber string `mapstructure:"from_number"`
 	TimeoutSec int    `mapstructure:"timeout_sec" default:"30"`
 	MaxRetries int    `mapstructure:"max_retries" default:"3"`
+	// MaxConcurrentRequests caps in-flight requests to Twilio across all
+	// workers (0 = unlimited); Twilio's default account limit is 100
+	MaxConcurrentRequests int `mapstructure:"max_concurrent_requests" default:"100"`
 }
 
 // NewServiceConfig constructs the notification service config from the common config
+// Keys missing from the config files keep their Defaults, and the result is
+// validated so a bad value fails at startup.
 func NewServiceConfig(cfg *config.Config) (*ServiceConfig, error) {
+	// Get the config manager to access raw config
+	var mgr configUnmarshaler
+	if m := config.GetGlobalConfigManager(); m != nil {
+		mgr = m
+	}
+	return loadServiceConfig(cfg, mgr)
+}
+
+// configUnmarshaler decodes the raw config into a struct
+type configUnmarshaler interface {
+	Unmarshal(target any) error
+}
+
+// loadServiceConfig decodes the service config over the defaults
+func loadServiceConfig(cfg *config.Config, mgr configUnmarshaler) (*ServiceConfig, error) {
 	serviceCfg := &ServiceConfig{
-		Config: cfg,
+		Config:       cfg,
+		Notification: Defaults(),
 	}
 
-	// Get the config manager to access raw config
-	mgr := config.GetGlobalConfigManager()
 	if mgr != nil {
 		if err := mgr.Unmarshal(serviceCfg); err != nil {
 			return nil, err
 		}
 	}
 
+	serviceCfg.Notification.ApplyDefaults()
+	if err := serviceCfg.Notification.Validate(); err != nil {
+		return nil, err
+	}
+
 	return serviceCfg, nil
 }
@@ -0,0 +1,253 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSenderConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		senders SenderConfig
+		want    []string
+	}{
+		{
+			name:    "all disabled",
+			senders: SenderConfig{APNS: APNSConfig{KeyID: ""}},
+		},
+		{
+			name: "complete apns",
+			senders: SenderConfig{APNS: APNSConfig{
+				Enabled: true, KeyID: "key", TeamID: "team", BundleID: "com.example", KeyFile: "key.p8",
+			}},
+		},
+		{
+			name:    "apns missing credentials",
+			senders: SenderConfig{APNS: APNSConfig{Enabled: true, BundleID: "com.example"}},
+			want:    []string{"apns sender is enabled but missing key_file, key_id, team_id"},
+		},
+		{
+			name:    "fcm missing project",
+			senders: SenderConfig{FCM: FCMConfig{Enabled: true, CredentialsFile: "creds.json"}},
+			want:    []string{"fcm sender is enabled but missing project_id"},
+		},
+		{
+			name: "email bad tls mode",
+			senders: SenderConfig{Email: EmailConfig{
+				Enabled: true, SMTPHost: "smtp.example.com", SMTPPort: 587, FromEmail: "a@example.com", TLSMode: "ssl",
+			}},
+			want: []string{`email tls_mode "ssl" must be one of none, starttls or tls`},
+		},
+		{
+			name: "several senders reported together",
+			senders: SenderConfig{
+				Expo: ExpoConfig{Enabled: true},
+				SMS:  SMSConfig{Enabled: true, APIURL: "https://api.twilio.com", AccountSID: "AC1"},
+			},
+			want: []string{
+				"expo sender is enabled but missing api_url",
+				"sms sender is enabled but missing auth_token, from_number",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.senders.Validate()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidSenderConfig)
+			for _, msg := range tt.want {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+func TestNotificationServiceConfig_ChannelOverrides(t *testing.T) {
+	cfg := NotificationServiceConfig{
+		WorkerConcurrency: 10,
+		RetryBackoffSec:   60,
+		ChannelOverrides: map[string]ChannelOverride{
+			"email": {Concurrency: 2, RetryBackoffSec: 300},
+			"expo":  {RetryBackoffSec: 5},
+		},
+	}
+
+	// Full override
+	assert.Equal(t, 2, cfg.ChannelConcurrency("email"))
+	assert.Equal(t, 300*time.Second, cfg.ChannelRetryBackoff("email"))
+
+	// Partial override falls back per field
+	assert.Equal(t, 10, cfg.ChannelConcurrency("expo"))
+	assert.Equal(t, 5*time.Second, cfg.ChannelRetryBackoff("expo"))
+
+	// No override uses the global defaults
+	assert.Equal(t, 10, cfg.ChannelConcurrency("sms"))
+	assert.Equal(t, 60*time.Second, cfg.ChannelRetryBackoff("sms"))
+}
+
+func TestNotificationServiceConfig_ValidateChannelOverrides(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]ChannelOverride
+		want      []string
+	}{
+		{name: "none"},
+		{
+			name:      "valid",
+			overrides: map[string]ChannelOverride{"email": {Concurrency: 2, RetryBackoffSec: 300}, "sms": {}, "fcm": {SendsPerSecond: 50, SendBurst: 10}},
+		},
+		{
+			name:      "unknown channel",
+			overrides: map[string]ChannelOverride{"webhook": {Concurrency: 1}},
+			want:      []string{`unknown channel "webhook"`},
+		},
+		{
+			name: "out of range reported together",
+			overrides: map[string]ChannelOverride{
+				"email": {Concurrency: 20},
+				"expo":  {Concurrency: -1, RetryBackoffSec: -5, SendsPerSecond: -1, SendBurst: -1},
+			},
+			want: []string{
+				"email concurrency 20 exceeds worker_concurrency 10",
+				"expo concurrency must not be negative",
+				"expo retry_backoff_sec must not be negative",
+				"expo sends_per_second must not be negative",
+				"expo send_burst must not be negative",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NotificationServiceConfig{WorkerConcurrency: 10, ChannelOverrides: tt.overrides}
+			err := cfg.ValidateChannelOverrides()
+			if len(tt.want) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidChannelOverride)
+			for _, msg := range tt.want {
+				assert.Contains(t, err.Error(), msg)
+			}
+		})
+	}
+}
+
+// mapUnmarshaler decodes a raw config map like the config manager does
+type mapUnmarshaler map[string]any
+
+func (m mapUnmarshaler) Unmarshal(target any) error {
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		Result:           target,
+		WeaklyTypedInput: true,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(map[string]any(m))
+}
+
+func TestLoadServiceConfig_DefaultsForMissingKeys(t *testing.T) {
+	cfg, err := loadServiceConfig(nil, mapUnmarshaler{
+		"notification": map[string]any{
+			"cursor_secret":      "secret",
+			"worker_concurrency": 4,
+			"send_timeout_sec":   0,
+			"poller": map[string]any{
+				"batch_size": 50,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	n := cfg.Notification
+	assert.Equal(t, 4, n.WorkerConcurrency)
+	assert.Equal(t, 50, n.Poller.BatchSize)
+	// An explicit zero keeps its meaning
+	assert.Equal(t, 0, n.SendTimeoutSec)
+
+	defaults := Defaults()
+	assert.Equal(t, defaults.Poller.MaxQueueSize, n.Poller.MaxQueueSize)
+	assert.Equal(t, defaults.Poller.ProcessingTimeoutMinutes, n.Poller.ProcessingTimeoutMinutes)
+	assert.True(t, n.Poller.Enabled)
+	assert.Equal(t, defaults.RetryBackoffSec, n.RetryBackoffSec)
+	assert.Equal(t, defaults.Senders.Expo.APIURL, n.Senders.Expo.APIURL)
+	assert.Equal(t, "starttls", n.Senders.Email.TLSMode)
+}
+
+func TestLoadServiceConfig_ZeroMeansDefault(t *testing.T) {
+	cfg, err := loadServiceConfig(nil, mapUnmarshaler{
+		"notification": map[string]any{
+			"cursor_secret": "secret",
+			"poller":        map[string]any{"max_queue_size": 0, "processing_timeout_minutes": 0},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 2000, cfg.Notification.Poller.MaxQueueSize)
+	assert.Equal(t, 5, cfg.Notification.Poller.ProcessingTimeoutMinutes)
+}
+
+func TestLoadServiceConfig_RejectsInvalidValues(t *testing.T) {
+	_, err := loadServiceConfig(nil, mapUnmarshaler{
+		"notification": map[string]any{
+			"worker_concurrency": -1,
+			"unknown_users":      "drop",
+			"poller":             map[string]any{"batch_size": -5},
+			"success_rate_alarm": map[string]any{"degraded_below": 0.5, "down_below": 0.8},
+		},
+	})
+	require.ErrorIs(t, err, ErrInvalidConfig)
+
+	msg := err.Error()
+	assert.Contains(t, msg, "worker_concurrency must be positive")
+	assert.Contains(t, msg, `unknown_users "drop"`)
+	assert.Contains(t, msg, "poller.batch_size must be positive")
+	assert.Contains(t, msg, "down_below 0.8 must not exceed degraded_below 0.5")
+}
+
+func TestLoadServiceConfig_RequiresCursorSecret(t *testing.T) {
+	_, err := loadServiceConfig(nil, nil)
+	require.ErrorIs(t, err, ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "cursor_secret is required")
+
+	cfg, err := loadServiceConfig(nil, mapUnmarshaler{
+		"notification": map[string]any{"cursor_secret": "secret"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "secret", cfg.Notification.CursorSecret)
+}
+
+// TestDefaults_MatchStructTags keeps the documented default tags honest
+func TestDefaults_MatchStructTags(t *testing.T) {
+	var walk func(path string, v reflect.Value)
+	walk = func(path string, v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			value := v.Field(i)
+			name := path + field.Tag.Get("mapstructure")
+			if value.Kind() == reflect.Struct {
+				walk(name+".", value)
+				continue
+			}
+			tag, ok := field.Tag.Lookup("default")
+			if !ok {
+				continue
+			}
+			assert.Equal(t, tag, fmt.Sprint(value.Interface()), name)
+		}
+	}
+	walk("", reflect.ValueOf(Defaults()))
+}
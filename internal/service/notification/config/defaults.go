@@ -0,0 +1,160 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidConfig is returned when a notification setting is out of range
+var ErrInvalidConfig = errors.New("invalid notification config")
+
+// Defaults returns the notification config used for keys missing from the
+// config files; the values match the default struct tags
+func Defaults() NotificationServiceConfig {
+	return NotificationServiceConfig{
+		Poller: PollerConfig{
+			Enabled:                  true,
+			PollIntervalSec:          5,
+			BatchSize:                1000,
+			MaxQueueSize:             2000,
+			BackoffOnEmptySec:        30,
+			ProcessingTimeoutMinutes: 5,
+			MaxErrorBackoffSec:       60,
+		},
+		WorkerConcurrency: 10,
+		SendTimeoutSec:    30,
+		MaxRetries:        3,
+		RetryBackoffSec:   60,
+		UnknownUsers:      "allow",
+		TaskType:          "notification",
+		TokenMasking: TokenMaskingConfig{
+			VisiblePrefix: 8,
+			VisibleSuffix: 4,
+		},
+		PayloadLimits: PayloadLimitsConfig{
+			MaxDepth:     10,
+			MaxSizeBytes: 65536,
+			MaxKeys:      1000,
+		},
+		SuccessRateAlarm: SuccessRateAlarmConfig{
+			WindowSec:     300,
+			DegradedBelow: 0.9,
+			DownBelow:     0.5,
+			MinAttempts:   20,
+		},
+		FailedRetention: FailedRetentionConfig{
+			IntervalMinutes: 60,
+			BatchSize:       1000,
+		},
+		Senders: SenderConfig{
+			Expo: ExpoConfig{
+				Enabled:               true,
+				APIURL:                "https://exp.host/--/api/v2/push/send",
+				TimeoutSec:            30,
+				MaxRetries:            3,
+				MaxConcurrentRequests: 6,
+			},
+			FCM:   FCMConfig{TimeoutSec: 30, MaxRetries: 3},
+			APNS:  APNSConfig{TimeoutSec: 30, MaxRetries: 3},
+			Email: EmailConfig{TLSMode: "starttls", TimeoutSec: 30, MaxRetries: 3},
+			SMS: SMSConfig{
+				APIURL:                "https://api.twilio.com/2010-04-01",
+				TimeoutSec:            30,
+				MaxRetries:            3,
+				MaxConcurrentRequests: 100,
+			},
+		},
+	}
+}
+
+// ApplyDefaults replaces unset values that have no meaning of their own,
+// such as a zero batch size, with their defaults. Zeros that mean
+// "disabled" or "unlimited", like send_timeout_sec, are kept.
+func (c *NotificationServiceConfig) ApplyDefaults() {
+	defaults := Defaults()
+
+	setDefault(&c.WorkerConcurrency, defaults.WorkerConcurrency)
+	if c.UnknownUsers == "" {
+		c.UnknownUsers = defaults.UnknownUsers
+	}
+	if c.TaskType == "" {
+		c.TaskType = defaults.TaskType
+	}
+
+	poller := &c.Poller
+	setDefault(&poller.PollIntervalSec, defaults.Poller.PollIntervalSec)
+	setDefault(&poller.BatchSize, defaults.Poller.BatchSize)
+	setDefault(&poller.MaxQueueSize, defaults.Poller.MaxQueueSize)
+	setDefault(&poller.BackoffOnEmptySec, defaults.Poller.BackoffOnEmptySec)
+	setDefault(&poller.ProcessingTimeoutMinutes, defaults.Poller.ProcessingTimeoutMinutes)
+	setDefault(&poller.MaxErrorBackoffSec, defaults.Poller.MaxErrorBackoffSec)
+
+	setDefault(&c.PayloadLimits.MaxDepth, defaults.PayloadLimits.MaxDepth)
+	setDefault(&c.PayloadLimits.MaxSizeBytes, defaults.PayloadLimits.MaxSizeBytes)
+	setDefault(&c.PayloadLimits.MaxKeys, defaults.PayloadLimits.MaxKeys)
+	setDefault(&c.SuccessRateAlarm.WindowSec, defaults.SuccessRateAlarm.WindowSec)
+	setDefault(&c.FailedRetention.IntervalMinutes, defaults.FailedRetention.IntervalMinutes)
+	setDefault(&c.FailedRetention.BatchSize, defaults.FailedRetention.BatchSize)
+}
+
+// setDefault sets *value to def when it is zero
+func setDefault(value *int, def int) {
+	if *value == 0 {
+		*value = def
+	}
+}
+
+// Validate checks that settings are within range, including channel
+// overrides. All problems are reported together.
+func (c NotificationServiceConfig) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidConfig}, args...)...))
+		}
+	}
+
+	check(c.WorkerConcurrency > 0, "worker_concurrency must be positive")
+	check(c.WorkerMaxInFlight >= 0, "worker_max_in_flight must not be negative")
+	check(c.SendTimeoutSec >= 0, "send_timeout_sec must not be negative")
+	check(c.MaxRetries >= 0, "max_retries must not be negative")
+	check(c.RetryBackoffSec >= 0, "retry_backoff_sec must not be negative")
+	check(c.CursorSecret != "", "cursor_secret is required")
+	switch c.UnknownUsers {
+	case "allow", "reject", "flag":
+	default:
+		check(false, "unknown_users %q must be one of allow, reject or flag", c.UnknownUsers)
+	}
+
+	poller := c.Poller
+	check(poller.PollIntervalSec > 0, "poller.poll_interval_sec must be positive")
+	check(poller.BatchSize > 0, "poller.batch_size must be positive")
+	check(poller.MaxQueueSize > 0, "poller.max_queue_size must be positive")
+	check(poller.BackoffOnEmptySec >= 0, "poller.backoff_on_empty_sec must not be negative")
+	check(poller.ProcessingTimeoutMinutes > 0, "poller.processing_timeout_minutes must be positive")
+	check(poller.MaxErrorBackoffSec > 0, "poller.max_error_backoff_sec must be positive")
+	check(poller.MaxPerUser >= 0, "poller.max_per_user must not be negative")
+
+	check(c.TokenMasking.VisiblePrefix >= 0 && c.TokenMasking.VisibleSuffix >= 0,
+		"token_masking visible_prefix and visible_suffix must not be negative")
+	check(c.PayloadLimits.MaxDepth > 0 && c.PayloadLimits.MaxSizeBytes > 0 && c.PayloadLimits.MaxKeys > 0,
+		"payload_limits must be positive")
+	check(c.Senders.Expo.MaxConcurrentRequests >= 0 && c.Senders.SMS.MaxConcurrentRequests >= 0,
+		"senders max_concurrent_requests must not be negative")
+
+	alarm := c.SuccessRateAlarm
+	check(alarm.WindowSec > 0, "success_rate_alarm.window_sec must be positive")
+	check(alarm.MinAttempts >= 0, "success_rate_alarm.min_attempts must not be negative")
+	check(alarm.DownBelow >= 0 && alarm.DegradedBelow <= 1,
+		"success_rate_alarm thresholds must be between 0 and 1")
+	check(alarm.DownBelow <= alarm.DegradedBelow || alarm.DegradedBelow == 0,
+		"success_rate_alarm.down_below %v must not exceed degraded_below %v", alarm.DownBelow, alarm.DegradedBelow)
+
+	retention := c.FailedRetention
+	check(retention.Days >= 0, "failed_retention.days must not be negative")
+	check(retention.IntervalMinutes > 0, "failed_retention.interval_minutes must be positive")
+	check(retention.BatchSize > 0, "failed_retention.batch_size must be positive")
+
+	errs = append(errs, c.ValidateChannelOverrides())
+	return errors.Join(errs...)
+}
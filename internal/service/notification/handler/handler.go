@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"myapp/internal/pkg/logger"
 	"myapp/internal/pkg/server"
@@ -14,6 +16,9 @@ import (
 	"go.uber.org/zap"
 )
 
+// NextCursorHeader carries the cursor for the next page of a list response
+const NextCursorHeader = "X-Next-Cursor"
+
 // NotificationHandler handles notification HTTP requests
 type NotificationHandler struct {
 	service *service.NotificationService
@@ -43,8 +48,14 @@ func (h *NotificationHandler) CreateNotification(c echo.Context) error {
 		return server.ErrorResponse(c, http.StatusBadRequest, nil, "At least one target is required")
 	}
 
-	notif, err := h.service.CreateNotification(dto)
+	notif, err := h.service.CreateNotification(c.Request().Context(), dto)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidPayload) {
+			return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid payload")
+		}
+		if errors.Is(err, service.ErrUnknownUsers) {
+			return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Unknown target users")
+		}
 		h.logger.Error("Failed to create notification", zap.Error(err))
 		return server.ErrorResponse(c, http.StatusInternalServerError, err.Error(), "Failed to create notification")
 	}
@@ -54,22 +65,9 @@ func (h *NotificationHandler) CreateNotification(c echo.Context) error {
 
 // GetFailedNotifications handles retrieval of failed notifications for a user
 func (h *NotificationHandler) GetFailedNotifications(c echo.Context) error {
-	// Get query parameters
-	limitStr := c.QueryParam("limit")
-	offsetStr := c.QueryParam("offset")
-
-	limit := 20
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil && o >= 0 {
-			offset = o
-		}
+	page, err := server.ParsePagination(c, server.DefaultPaginationConfig())
+	if err != nil {
+		return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid pagination parameters")
 	}
 
 	// Get user ID from path or context
@@ -91,12 +89,19 @@ func (h *NotificationHandler) GetFailedNotifications(c echo.Context) error {
 		}
 	}
 
-	notifications, err := h.service.GetFailedNotifications(userID, limit, offset)
+	notifications, next, err := h.service.GetFailedNotifications(c.Request().Context(), userID, page.Limit, page.Offset, page.Cursor)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidCursor) {
+			return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid cursor")
+		}
 		h.logger.Error("Failed to get failed notifications", zap.Error(err))
 		return server.ErrorResponse(c, http.StatusInternalServerError, err.Error(), "Failed to get failed notifications")
 	}
 
+	if next != "" {
+		c.Response().Header().Set(NextCursorHeader, next)
+	}
+
 	return server.SuccessResponse(c, http.StatusOK, notifications, "Failed notifications retrieved successfully")
 }
 
@@ -109,7 +114,7 @@ func (h *NotificationHandler) RetryNotification(c echo.Context) error {
 		return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid notification ID")
 	}
 
-	if err := h.service.RetryNotification(id); err != nil {
+	if err := h.service.RetryNotification(c.Request().Context(), id); err != nil {
 		userID := "unknown"
 		if userCtx, err := auth.GetUserFromContext(c); err == nil {
 			userID = strconv.FormatUint(uint64(userCtx.UserID), 10)
@@ -153,13 +158,16 @@ func (h *NotificationHandler) RegisterToken(c echo.Context) error {
 	}
 
 	// Optional: Verify user from auth context
+	// Only admins may ask for the unmasked push token via ?reveal_token=true
+	reveal := false
 	if userCtx, err := auth.GetUserFromContext(c); err == nil {
 		if dto.UserID != strconv.FormatUint(uint64(userCtx.UserID), 10) && userCtx.Role != "admin" {
 			return server.ErrorResponse(c, http.StatusForbidden, nil, "Forbidden")
 		}
+		reveal = service.CanRevealPushToken(userCtx.Role, c.QueryParam("reveal_token") == "true")
 	}
 
-	result, err := h.service.RegisterDeviceToken(dto)
+	result, err := h.service.RegisterDeviceToken(c.Request().Context(), dto, reveal)
 	if err != nil {
 		h.logger.Error("Failed to register device token", zap.Error(err))
 		return server.ErrorResponse(c, http.StatusInternalServerError, err.Error(), "Failed to register device token")
@@ -168,3 +176,67 @@ func (h *NotificationHandler) RegisterToken(c echo.Context) error {
 	return server.SuccessResponse(c, http.StatusOK, result, "Device token registered successfully")
 }
 
+// GetNotificationReport handles the admin send report over a date range
+// Query params from and to accept YYYY-MM-DD or RFC3339; defaults to the last 7 days.
+// A date-only to includes that whole day.
+func (h *NotificationHandler) GetNotificationReport(c echo.Context) error {
+	userCtx, err := auth.GetUserFromContext(c)
+	if err != nil || userCtx.Role != "admin" {
+		return server.ErrorResponse(c, http.StatusForbidden, nil, "Forbidden")
+	}
+
+	to := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	from := to.AddDate(0, 0, -7)
+
+	if v := c.QueryParam("from"); v != "" {
+		if from, err = parseReportTime(v, false); err != nil {
+			return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid from date")
+		}
+	}
+	if v := c.QueryParam("to"); v != "" {
+		if to, err = parseReportTime(v, true); err != nil {
+			return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid to date")
+		}
+	}
+
+	report, err := h.service.GetNotificationReport(from, to)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidReportRange) {
+			return server.ErrorResponse(c, http.StatusBadRequest, err.Error(), "Invalid date range")
+		}
+		h.logger.Error("Failed to get notification report", zap.Error(err))
+		return server.ErrorResponse(c, http.StatusInternalServerError, err.Error(), "Failed to get notification report")
+	}
+
+	return server.SuccessResponse(c, http.StatusOK, report, "Notification report retrieved successfully")
+}
+
+// GetStatus handles the admin view of the worker, its queue and the
+// delivery backlog, for debugging a growing backlog at runtime
+func (h *NotificationHandler) GetStatus(c echo.Context) error {
+	userCtx, err := auth.GetUserFromContext(c)
+	if err != nil || userCtx.Role != "admin" {
+		return server.ErrorResponse(c, http.StatusForbidden, nil, "Forbidden")
+	}
+
+	status, err := h.service.GetStatus()
+	if err != nil {
+		h.logger.Error("Failed to get notification status", zap.Error(err))
+		return server.ErrorResponse(c, http.StatusInternalServerError, err.Error(), "Failed to get notification status")
+	}
+
+	return server.SuccessResponse(c, http.StatusOK, status, "Notification status retrieved successfully")
+}
+
+// parseReportTime parses a YYYY-MM-DD date or an RFC3339 timestamp
+// With endOfDay set, a date is moved to the start of the next day, so that
+// the exclusive upper bound still covers the whole date.
+func parseReportTime(v string, endOfDay bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
@@ -0,0 +1,317 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/service/auth"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/repository"
+	"myapp/internal/service/notification/service"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// queryResult is what the fake database returns for one statement
+type queryResult struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// fakeConn answers each SELECT with the result registered for the first
+// matching query substring, or fails it with err, and records every query
+// and its arguments
+type fakeConn struct {
+	mu      sync.Mutex
+	results map[string]queryResult
+	err     error
+	queries []string
+	args    [][]driver.NamedValue
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeConn) Close() error                              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *fakeConn) Commit() error                             { return nil }
+func (c *fakeConn) Rollback() error                           { return nil }
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.queries = append(c.queries, query)
+	c.args = append(c.args, args)
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	for match, result := range c.results {
+		if strings.Contains(query, match) {
+			return &fakeRows{result: result}, nil
+		}
+	}
+	return &fakeRows{}, nil
+}
+
+// lastArgs returns the arguments of the last query containing match
+func (c *fakeConn) lastArgs(match string) []driver.NamedValue {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i := len(c.queries) - 1; i >= 0; i-- {
+		if strings.Contains(c.queries[i], match) {
+			return c.args[i]
+		}
+	}
+	return nil
+}
+
+type fakeRows struct {
+	result queryResult
+	next   int
+}
+
+func (r *fakeRows) Columns() []string { return r.result.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.result.rows) {
+		return io.EOF
+	}
+	copy(dest, r.result.rows[r.next])
+	r.next++
+	return nil
+}
+
+type fakeConnector struct{ conn *fakeConn }
+
+func (c fakeConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c fakeConnector) Driver() driver.Driver                        { return nil }
+
+func newTestHandler(t *testing.T, conn *fakeConn) *NotificationHandler {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(fakeConnector{conn: conn})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	log := &logger.Logger{Logger: zap.NewNop()}
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.CursorSecret = "test-secret"
+
+	repo := repository.NewNotificationRepository(&database.Database{DB: db})
+	return NewNotificationHandler(service.NewNotificationService(repo, cfg, log), log)
+}
+
+// serve calls h as the given user; a nil user is unauthenticated
+func serve(h echo.HandlerFunc, user *auth.UserCtx, method, target string) *httptest.ResponseRecorder {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	c := e.NewContext(httptest.NewRequest(method, target, nil), rec)
+	if user != nil {
+		c.Set(auth.UserContextKey, user)
+	}
+	_ = h(c)
+	return rec
+}
+
+var reportColumns = []string{"day", "type", "channel", "status", "count"}
+
+func TestGetNotificationReport_DateOnlyToIncludesWholeDay(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan7 := time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC)
+	conn := &fakeConn{results: map[string]queryResult{
+		"date_trunc": {columns: reportColumns, rows: [][]driver.Value{
+			{jan1, "order_created", "expo", "delivered", int64(3)},
+			{jan7, "order_created", "sms", "failed", int64(2)},
+		}},
+	}}
+	h := newTestHandler(t, conn)
+
+	rec := serve(h.GetNotificationReport, &auth.UserCtx{UserID: 1, Role: "admin"},
+		http.MethodGet, "/reports?from=2024-01-01&to=2024-01-07")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	// The query covers 2024-01-07 up to midnight
+	args := conn.lastArgs("date_trunc")
+	require.Len(t, args, 2)
+	assert.Equal(t, jan1, args[0].Value)
+	assert.Equal(t, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), args[1].Value)
+
+	var body struct {
+		Data struct {
+			Total     int64            `json:"total"`
+			ByChannel map[string]int64 `json:"by_channel"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.EqualValues(t, 5, body.Data.Total)
+	assert.Equal(t, map[string]int64{"expo": 3, "sms": 2}, body.Data.ByChannel)
+}
+
+func TestGetNotificationReport_TimestampToIsExclusive(t *testing.T) {
+	conn := &fakeConn{}
+	h := newTestHandler(t, conn)
+
+	rec := serve(h.GetNotificationReport, &auth.UserCtx{UserID: 1, Role: "admin"},
+		http.MethodGet, "/reports?from=2024-01-01&to=2024-01-07T12:00:00Z")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	args := conn.lastArgs("date_trunc")
+	require.Len(t, args, 2)
+	assert.Equal(t, time.Date(2024, 1, 7, 12, 0, 0, 0, time.UTC), args[1].Value)
+}
+
+func TestGetNotificationReport_SameDayIsOneDay(t *testing.T) {
+	conn := &fakeConn{}
+	h := newTestHandler(t, conn)
+
+	rec := serve(h.GetNotificationReport, &auth.UserCtx{UserID: 1, Role: "admin"},
+		http.MethodGet, "/reports?from=2024-01-07&to=2024-01-07")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	args := conn.lastArgs("date_trunc")
+	require.Len(t, args, 2)
+	assert.Equal(t, time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC), args[0].Value)
+	assert.Equal(t, time.Date(2024, 1, 8, 0, 0, 0, 0, time.UTC), args[1].Value)
+}
+
+func TestGetNotificationReport_Errors(t *testing.T) {
+	admin := &auth.UserCtx{UserID: 1, Role: "admin"}
+
+	tests := []struct {
+		name   string
+		user   *auth.UserCtx
+		target string
+		status int
+	}{
+		{"unauthenticated", nil, "/reports", http.StatusForbidden},
+		{"not an admin", &auth.UserCtx{UserID: 2, Role: "user"}, "/reports", http.StatusForbidden},
+		{"bad from", admin, "/reports?from=yesterday", http.StatusBadRequest},
+		{"bad to", admin, "/reports?to=2024-13-01", http.StatusBadRequest},
+		{"inverted range", admin, "/reports?from=2024-01-08&to=2024-01-01", http.StatusBadRequest},
+		{"range too long", admin, "/reports?from=2023-01-01&to=2024-01-01", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{}
+			h := newTestHandler(t, conn)
+
+			rec := serve(h.GetNotificationReport, tt.user, http.MethodGet, tt.target)
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Nil(t, conn.lastArgs("date_trunc"), "no query should run")
+		})
+	}
+}
+
+// fakeWorkerState is a fixed worker snapshot for the status endpoint
+type fakeWorkerState struct{}
+
+func (fakeWorkerState) IsRunning() bool                  { return true }
+func (fakeWorkerState) GetQueueLength() int              { return 12 }
+func (fakeWorkerState) GetQueueCapacity() int            { return 2000 }
+func (fakeWorkerState) GetQueueOldestAge() time.Duration { return 3 * time.Second }
+func (fakeWorkerState) GetInFlight() int64               { return 4 }
+func (fakeWorkerState) GetSuccessRate() (float64, int64) { return 0.75, 20 }
+
+type statusBody struct {
+	Data struct {
+		Worker *struct {
+			Running           bool    `json:"running"`
+			QueueLength       int     `json:"queue_length"`
+			QueueOldestAgeSec float64 `json:"queue_oldest_age_sec"`
+			InFlight          int64   `json:"in_flight"`
+			ErrorRate         float64 `json:"error_rate"`
+		} `json:"worker"`
+		PendingCount    int64 `json:"pending_count"`
+		ProcessingCount int64 `json:"processing_count"`
+	} `json:"data"`
+}
+
+func TestGetStatus_ReportsBacklogAndWorker(t *testing.T) {
+	conn := &fakeConn{results: map[string]queryResult{
+		"COUNT(*)": {columns: []string{"status", "count"}, rows: [][]driver.Value{
+			{"pending", int64(42)},
+		}},
+	}}
+	h := newTestHandler(t, conn)
+	h.service.SetWorkerState(fakeWorkerState{})
+
+	rec := serve(h.GetStatus, &auth.UserCtx{UserID: 1, Role: "admin"}, http.MethodGet, "/admin/status")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body statusBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.EqualValues(t, 42, body.Data.PendingCount)
+	assert.Zero(t, body.Data.ProcessingCount, "a status without deliveries counts as 0")
+	require.NotNil(t, body.Data.Worker)
+	assert.True(t, body.Data.Worker.Running)
+	assert.Equal(t, 12, body.Data.Worker.QueueLength)
+	assert.Equal(t, 3.0, body.Data.Worker.QueueOldestAgeSec)
+	assert.EqualValues(t, 4, body.Data.Worker.InFlight)
+	assert.InDelta(t, 0.25, body.Data.Worker.ErrorRate, 1e-9)
+
+	// Only the backlog statuses are counted
+	args := conn.lastArgs("COUNT(*)")
+	require.Len(t, args, 2)
+	assert.Equal(t, "pending", args[0].Value)
+	assert.Equal(t, "processing", args[1].Value)
+}
+
+func TestGetStatus_APIOnlyHasNoWorker(t *testing.T) {
+	h := newTestHandler(t, &fakeConn{})
+
+	rec := serve(h.GetStatus, &auth.UserCtx{UserID: 1, Role: "admin"}, http.MethodGet, "/admin/status")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	var body statusBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Nil(t, body.Data.Worker)
+	assert.Zero(t, body.Data.PendingCount)
+}
+
+func TestGetStatus_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    *auth.UserCtx
+		dbErr   error
+		status  int
+		queried bool
+	}{
+		{"unauthenticated", nil, nil, http.StatusForbidden, false},
+		{"not an admin", &auth.UserCtx{UserID: 2, Role: "user"}, nil, http.StatusForbidden, false},
+		{"database error", &auth.UserCtx{UserID: 1, Role: "admin"}, errors.New("connection refused"), http.StatusInternalServerError, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &fakeConn{err: tt.dbErr}
+			h := newTestHandler(t, conn)
+
+			rec := serve(h.GetStatus, tt.user, http.MethodGet, "/admin/status")
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.queried, conn.lastArgs("COUNT(*)") != nil)
+		})
+	}
+}
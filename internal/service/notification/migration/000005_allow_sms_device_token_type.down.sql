@@ -0,0 +1,9 @@
+-- Remove 'sms' device tokens before restoring the original constraint
+DELETE FROM device_tokens WHERE type = 'sms';
+
+ALTER TABLE device_tokens
+    DROP CONSTRAINT IF EXISTS chk_token_type;
+
+ALTER TABLE device_tokens
+    ADD CONSTRAINT chk_token_type
+    CHECK (type IN ('expo', 'fcm', 'apns', 'native'));
@@ -0,0 +1,7 @@
+-- Allow 'sms' device tokens (push_token stores an E.164 phone number)
+ALTER TABLE device_tokens
+    DROP CONSTRAINT IF EXISTS chk_token_type;
+
+ALTER TABLE device_tokens
+    ADD CONSTRAINT chk_token_type
+    CHECK (type IN ('expo', 'fcm', 'apns', 'native', 'sms'));
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_notification_delivery_failed_at;
+DROP TABLE IF EXISTS notification_delivery_archive;
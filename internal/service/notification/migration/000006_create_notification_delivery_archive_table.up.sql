@@ -0,0 +1,22 @@
+-- Archive of failed deliveries removed by the retention cleanup job
+-- Rows are copied as they were when removed; archived_at records the cleanup
+CREATE TABLE notification_delivery_archive (
+    id BIGINT PRIMARY KEY,                          -- id gốc trong notification_delivery
+    target_id BIGINT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    attempt_count INTEGER NOT NULL,
+    retry_count INTEGER NOT NULL,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    delivered_at TIMESTAMP,
+    failed_at TIMESTAMP,
+    archived_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_delivery_archive_archived_at ON notification_delivery_archive(archived_at);
+
+-- Partial index so the cleanup job finds expired failed deliveries without a full scan
+CREATE INDEX idx_notification_delivery_failed_at
+    ON notification_delivery (COALESCE(failed_at, created_at))
+    WHERE status = 'failed';
@@ -0,0 +1,9 @@
+-- Remove 'email' device tokens before restoring the previous constraint
+DELETE FROM device_tokens WHERE type = 'email';
+
+ALTER TABLE device_tokens
+    DROP CONSTRAINT IF EXISTS chk_token_type;
+
+ALTER TABLE device_tokens
+    ADD CONSTRAINT chk_token_type
+    CHECK (type IN ('expo', 'fcm', 'apns', 'native', 'sms'));
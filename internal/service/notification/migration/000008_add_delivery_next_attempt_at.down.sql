@@ -0,0 +1,2 @@
+ALTER TABLE notification_delivery
+    DROP COLUMN IF EXISTS next_attempt_at;
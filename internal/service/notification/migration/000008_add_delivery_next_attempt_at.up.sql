@@ -0,0 +1,4 @@
+-- Retry backoff: a pending delivery isn't polled again before next_attempt_at
+-- NULL means the delivery is due now
+ALTER TABLE notification_delivery
+    ADD COLUMN next_attempt_at TIMESTAMP;
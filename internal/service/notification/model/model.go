@@ -3,6 +3,7 @@ package model
 import (
 	"database/sql/driver"
 	"encoding/json"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -34,8 +35,16 @@ type NotificationTarget struct {
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Undeliverable, when set, is why the target can't be sent; its delivery
+	// is created as failed with this as the error instead of pending
+	Undeliverable string `gorm:"-" json:"-"`
 }
 
+// UndeliverableUnknownUser is the Undeliverable reason, and so the delivery
+// error, of targets whose user doesn't exist
+const UndeliverableUnknownUser = "user not found"
+
 // TableName specifies the table name
 func (NotificationTarget) TableName() string {
 	return "notification_target"
@@ -53,6 +62,9 @@ type NotificationDelivery struct {
 	UpdatedAt    time.Time  `json:"updated_at"`
 	DeliveredAt  *time.Time `json:"delivered_at"`
 	FailedAt     *time.Time `json:"failed_at"`
+
+	// NextAttemptAt holds a pending delivery back from polling until then
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
 }
 
 // TableName specifies the table name
@@ -93,6 +105,8 @@ type NotificationPayload struct {
 	Priority  int                    `json:"priority"`
 	CreatedAt time.Time              `json:"created_at"`
 	TraceID   string                 `json:"trace_id"`
+	// Email is the recipient address for the email channel, if known
+	Email string `json:"email,omitempty"`
 }
 
 // CreateNotificationDTO is the DTO for creating a notification
@@ -139,6 +153,54 @@ type FailedNotificationResponse struct {
 	FailedAt       *time.Time `json:"failed_at"`
 }
 
+// FailedNotificationCursor is a keyset position in a user's failed notifications
+type FailedNotificationCursor struct {
+	FailedAt time.Time `json:"failed_at"`
+	ID       int64     `json:"id"`
+}
+
+// NotificationReportRow is one aggregated bucket of deliveries
+type NotificationReportRow struct {
+	Day     time.Time `json:"day"`
+	Type    string    `json:"type"`
+	Channel string    `json:"channel"`
+	Status  string    `json:"status"`
+	Count   int64     `json:"count"`
+}
+
+// NotificationReport summarizes deliveries over a date range
+type NotificationReport struct {
+	From      time.Time                `json:"from"`
+	To        time.Time                `json:"to"`
+	Total     int64                    `json:"total"`
+	ByType    map[string]int64         `json:"by_type"`
+	ByChannel map[string]int64         `json:"by_channel"`
+	ByStatus  map[string]int64         `json:"by_status"`
+	Daily     []*NotificationReportRow `json:"daily"`
+}
+
+// NotificationStatus is the runtime view of the delivery pipeline for operators
+type NotificationStatus struct {
+	// Worker is nil when this process doesn't run the worker (API-only mode)
+	Worker          *WorkerStatus `json:"worker"`
+	PendingCount    int64         `json:"pending_count"`
+	ProcessingCount int64         `json:"processing_count"`
+	CheckedAt       time.Time     `json:"checked_at"`
+}
+
+// WorkerStatus reports the worker and its in-memory queue
+type WorkerStatus struct {
+	Running           bool    `json:"running"`
+	QueueLength       int     `json:"queue_length"`
+	QueueCapacity     int     `json:"queue_capacity"`
+	QueueOldestAgeSec float64 `json:"queue_oldest_age_sec"`
+	InFlight          int64   `json:"in_flight"`
+	// ErrorRate is the share of failed sends over the success-rate window,
+	// based on RecentAttempts sends
+	ErrorRate      float64 `json:"error_rate"`
+	RecentAttempts int64   `json:"recent_attempts"`
+}
+
 // PendingNotification represents a pending notification with all related data
 type PendingNotification struct {
 	// Delivery info (primary)
@@ -188,8 +250,8 @@ type RegisterTokenDTO struct {
 	UserID    string `json:"user_id" validate:"required"`
 	DeviceID  string `json:"device_id" validate:"required"`
 	PushToken string `json:"push_token" validate:"required"`
-	Type      string `json:"type" validate:"required,oneof=expo fcm apns native"` // Loại token
-	Platform  string `json:"platform" validate:"required,oneof=ios android web"`  // Platform của device
+	Type      string `json:"type" validate:"required,oneof=expo fcm apns native sms email"` // Loại token
+	Platform  string `json:"platform" validate:"required,oneof=ios android web"`            // Platform của device
 }
 
 // RegisterTokenResponse represents the response after token registration
@@ -204,3 +266,13 @@ type RegisterTokenResponse struct {
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
 }
+
+// MaskPushToken keeps the first prefix and last suffix characters of a push
+// token and replaces the rest. Tokens too short to mask partially are fully hidden.
+func MaskPushToken(token string, prefix, suffix int) string {
+	runes := []rune(token)
+	if len(runes) <= prefix+suffix {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:prefix]) + "****" + string(runes[len(runes)-suffix:])
+}
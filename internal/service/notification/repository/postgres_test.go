@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/pkg/migration"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// newPostgresRepository returns a repository on a fresh, migrated schema of
+// the database in TEST_POSTGRES_DSN. The test is skipped when it is unset.
+func newPostgresRepository(t *testing.T) (*NotificationRepository, *gorm.DB) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_POSTGRES_DSN")
+	if dsn == "" {
+		t.Skip("TEST_POSTGRES_DSN not set")
+	}
+
+	admin, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	adminDB, err := admin.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { adminDB.Close() })
+
+	schema := fmt.Sprintf("notification_test_%d", time.Now().UnixNano())
+	require.NoError(t, admin.Exec("CREATE SCHEMA "+schema).Error)
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	scoped := withSearchPath(dsn, schema)
+
+	// The migrator closes the connection it is given
+	migrateDB, err := sql.Open("pgx", scoped)
+	require.NoError(t, err)
+	require.NoError(t, migration.RunMigrations(migrateDB, "../migration", zap.NewNop()))
+
+	db, err := gorm.Open(postgres.Open(scoped), &gorm.Config{Logger: gormlogger.Discard})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return NewNotificationRepository(&database.Database{DB: db}), db
+}
+
+// withSearchPath points every connection opened with dsn at schema
+func withSearchPath(dsn, schema string) string {
+	if strings.Contains(dsn, "://") {
+		u, err := url.Parse(dsn)
+		if err == nil {
+			q := u.Query()
+			q.Set("search_path", schema)
+			u.RawQuery = q.Encode()
+			return u.String()
+		}
+	}
+	return dsn + " search_path=" + schema
+}
+
+// deliverySeed describes one notification with a single target and delivery
+type deliverySeed struct {
+	notificationType string
+	userID           string
+	channel          string
+	status           string
+	lastError        string
+	createdAt        time.Time
+	failedAt         *time.Time
+}
+
+// seedDelivery inserts a notification, its target and delivery, and
+// returns the delivery ID
+func seedDelivery(t *testing.T, db *gorm.DB, seed deliverySeed) int64 {
+	t.Helper()
+
+	if seed.notificationType == "" {
+		seed.notificationType = "order_created"
+	}
+	if seed.userID == "" {
+		seed.userID = "alice"
+	}
+	if seed.status == "" {
+		seed.status = "pending"
+	}
+	if seed.createdAt.IsZero() {
+		seed.createdAt = time.Now().UTC()
+	}
+	payload := "{}"
+	if seed.channel != "" {
+		payload = fmt.Sprintf(`{"sender_type": %q}`, seed.channel)
+	}
+
+	var notificationID, targetID, deliveryID int64
+	require.NoError(t, db.Raw(
+		`INSERT INTO notification (type, created_at, updated_at) VALUES (?, ?, ?) RETURNING id`,
+		seed.notificationType, seed.createdAt, seed.createdAt,
+	).Scan(&notificationID).Error)
+	require.NoError(t, db.Raw(
+		`INSERT INTO notification_target (notification_id, user_id, payload, created_at, updated_at)
+		 VALUES (?, ?, ?::jsonb, ?, ?) RETURNING id`,
+		notificationID, seed.userID, payload, seed.createdAt, seed.createdAt,
+	).Scan(&targetID).Error)
+	require.NoError(t, db.Raw(
+		`INSERT INTO notification_delivery (target_id, status, last_error, created_at, updated_at, failed_at)
+		 VALUES (?, ?, ?, ?, ?, ?) RETURNING id`,
+		targetID, seed.status, seed.lastError, seed.createdAt, seed.createdAt, seed.failedAt,
+	).Scan(&deliveryID).Error)
+
+	return deliveryID
+}
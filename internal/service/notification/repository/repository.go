@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
@@ -9,9 +10,22 @@ import (
 	"myapp/internal/pkg/database"
 	"myapp/internal/service/notification/model"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"gorm.io/gorm"
 )
 
+var (
+	// ErrDeliveryExists is returned when a second delivery is inserted for a target
+	// Each target has exactly one delivery row, which retries update in place.
+	ErrDeliveryExists = errors.New("delivery already exists for target")
+
+	// ErrDeliveryNotFound is returned when a target has no delivery row to update
+	ErrDeliveryNotFound = errors.New("delivery not found for target")
+)
+
+// pgUniqueViolation is the PostgreSQL error code for a unique constraint violation
+const pgUniqueViolation = "23505"
+
 // NotificationRepository handles database operations for notifications
 type NotificationRepository struct {
 	db *database.Database
@@ -22,9 +36,18 @@ func NewNotificationRepository(db *database.Database) *NotificationRepository {
 	return &NotificationRepository{db: db}
 }
 
-// CreateNotification creates a new notification with targets
+// WithContext returns a repository whose queries run under ctx, so a
+// cancelled or timed-out request aborts them, and in the transaction ctx
+// carries, if any
+func (r *NotificationRepository) WithContext(ctx context.Context) *NotificationRepository {
+	return &NotificationRepository{db: r.db.WithContext(ctx)}
+}
+
+// CreateNotification creates a new notification with targets. When the
+// context given to WithContext carries a transaction from
+// Database.WithinTransaction, the rows are written in a savepoint of it.
 func (r *NotificationRepository) CreateNotification(notif *model.Notification, targets []*model.NotificationTarget) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
+	return r.db.WithinTransaction(r.db.Statement.Context, func(_ context.Context, tx *gorm.DB) error {
 		// Create notification
 		if err := tx.Create(notif).Error; err != nil {
 			return fmt.Errorf("failed to create notification: %w", err)
@@ -44,8 +67,14 @@ func (r *NotificationRepository) CreateNotification(notif *model.Notification, t
 				AttemptCount: 0,
 				RetryCount:   0,
 			}
-			if err := tx.Create(delivery).Error; err != nil {
-				return fmt.Errorf("failed to create delivery record: %w", err)
+			if target.Undeliverable != "" {
+				now := time.Now()
+				delivery.Status = "failed"
+				delivery.LastError = target.Undeliverable
+				delivery.FailedAt = &now
+			}
+			if err := createDelivery(tx, delivery); err != nil {
+				return err
 			}
 		}
 
@@ -53,6 +82,25 @@ func (r *NotificationRepository) CreateNotification(notif *model.Notification, t
 	})
 }
 
+// CreateDelivery inserts the delivery row for a target
+// It returns ErrDeliveryExists if the target already has one; retry paths must
+// update that row with ResetDeliveryStatus instead.
+func (r *NotificationRepository) CreateDelivery(delivery *model.NotificationDelivery) error {
+	return createDelivery(r.db.DB, delivery)
+}
+
+// createDelivery inserts a delivery, mapping the unique target_id violation to ErrDeliveryExists
+func createDelivery(tx *gorm.DB, delivery *model.NotificationDelivery) error {
+	if err := tx.Create(delivery).Error; err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return fmt.Errorf("%w: target %d", ErrDeliveryExists, delivery.TargetID)
+		}
+		return fmt.Errorf("failed to create delivery record: %w", err)
+	}
+	return nil
+}
+
 // GetNotificationByID retrieves a notification by ID
 func (r *NotificationRepository) GetNotificationByID(id int64) (*model.Notification, error) {
 	var notif model.Notification
@@ -122,8 +170,10 @@ func (r *NotificationRepository) IncrementRetry(targetID int64) error {
 		}).Error
 }
 
-// GetPendingFailedForUser retrieves failed notifications for a user
-func (r *NotificationRepository) GetPendingFailedForUser(userID string, limit, offset int) ([]*model.FailedNotificationResponse, error) {
+// GetPendingFailedForUser retrieves failed notifications for a user, newest first
+// When after is set, rows are read from after that keyset position and offset is ignored.
+// Targets flagged for an unknown user are left out, since retrying them can't help.
+func (r *NotificationRepository) GetPendingFailedForUser(userID string, limit, offset int, after *model.FailedNotificationCursor) ([]*model.FailedNotificationResponse, error) {
 	var results []*model.FailedNotificationResponse
 
 	query := `
@@ -143,11 +193,24 @@ func (r *NotificationRepository) GetPendingFailedForUser(userID string, limit, o
 		INNER JOIN notification_target nt ON nd.target_id = nt.id
 		INNER JOIN notification n ON nt.notification_id = n.id
 		WHERE nt.user_id = ? AND nd.status = 'failed'
-		ORDER BY nd.failed_at DESC
+			AND nd.last_error IS DISTINCT FROM ?
+	`
+	args := []interface{}{userID, model.UndeliverableUnknownUser}
+
+	// failed_at falls back to created_at so the sort key is never NULL,
+	// and id breaks ties so keyset pages neither skip nor repeat rows
+	if after != nil {
+		query += ` AND (COALESCE(nd.failed_at, nd.created_at), nd.id) < (?, ?)`
+		args = append(args, after.FailedAt, after.ID)
+		offset = 0
+	}
+	query += `
+		ORDER BY COALESCE(nd.failed_at, nd.created_at) DESC, nd.id DESC
 		LIMIT ? OFFSET ?
 	`
+	args = append(args, limit, offset)
 
-	rows, err := r.db.Raw(query, userID, limit, offset).Rows()
+	rows, err := r.db.Raw(query, args...).Rows()
 	if err != nil {
 		return nil, fmt.Errorf("failed to query failed notifications: %w", err)
 	}
@@ -178,6 +241,77 @@ func (r *NotificationRepository) GetPendingFailedForUser(userID string, limit, o
 	return results, nil
 }
 
+// DeleteExpiredFailedDeliveries removes up to limit failed deliveries that
+// failed before cutoff and returns how many were removed. With archive set,
+// the rows are copied into notification_delivery_archive in the same
+// statement, so a delivery is never lost between the copy and the delete.
+// Deliveries in any other status are left alone.
+func (r *NotificationRepository) DeleteExpiredFailedDeliveries(cutoff time.Time, limit int, archive bool) (int64, error) {
+	// failed_at falls back to created_at, matching GetPendingFailedForUser
+	expired := `
+		SELECT id FROM notification_delivery
+		WHERE status = 'failed' AND COALESCE(failed_at, created_at) < ?
+		ORDER BY id
+		LIMIT ?
+	`
+
+	query := `DELETE FROM notification_delivery WHERE id IN (` + expired + `)`
+	if archive {
+		query = `
+			WITH removed AS (
+				DELETE FROM notification_delivery WHERE id IN (` + expired + `)
+				RETURNING *
+			)
+			INSERT INTO notification_delivery_archive (
+				id, target_id, status, attempt_count, retry_count, last_error,
+				created_at, updated_at, delivered_at, failed_at, archived_at
+			)
+			SELECT
+				id, target_id, status, attempt_count, retry_count, last_error,
+				created_at, updated_at, delivered_at, failed_at, ?
+			FROM removed
+		`
+	}
+
+	args := []interface{}{cutoff, limit}
+	if archive {
+		args = append(args, time.Now())
+	}
+
+	result := r.db.Exec(query, args...)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete expired failed deliveries: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetDeliveryReport counts deliveries created in [from, to) grouped by day,
+// notification type, channel and status
+func (r *NotificationRepository) GetDeliveryReport(from, to time.Time) ([]*model.NotificationReportRow, error) {
+	var results []*model.NotificationReportRow
+
+	query := `
+		SELECT
+			date_trunc('day', nd.created_at) AS day,
+			n.type,
+			COALESCE(NULLIF(nt.payload->>'sender_type', ''), 'expo') AS channel,
+			nd.status,
+			COUNT(*) AS count
+		FROM notification_delivery nd
+		INNER JOIN notification_target nt ON nd.target_id = nt.id
+		INNER JOIN notification n ON nt.notification_id = n.id
+		WHERE nd.created_at >= ? AND nd.created_at < ?
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 3, 4
+	`
+
+	if err := r.db.Raw(query, from, to).Scan(&results).Error; err != nil {
+		return nil, fmt.Errorf("failed to query delivery report: %w", err)
+	}
+
+	return results, nil
+}
+
 // GetTargetByID retrieves a target by ID
 func (r *NotificationRepository) GetTargetByID(id int64) (*model.NotificationTarget, error) {
 	var target model.NotificationTarget
@@ -188,21 +322,60 @@ func (r *NotificationRepository) GetTargetByID(id int64) (*model.NotificationTar
 }
 
 // ResetDeliveryStatus resets delivery status to pending for retry
+// The existing row is updated in place; ErrDeliveryNotFound is returned
+// rather than inserting when the target has no delivery.
 func (r *NotificationRepository) ResetDeliveryStatus(targetID int64) error {
-	return r.db.Model(&model.NotificationDelivery{}).
+	result := r.db.Model(&model.NotificationDelivery{}).
 		Where("target_id = ?", targetID).
 		Updates(map[string]interface{}{
-			"status":     "pending",
-			"updated_at": time.Now(),
-			"failed_at":  nil,
-		}).Error
+			"status":          "pending",
+			"updated_at":      time.Now(),
+			"failed_at":       nil,
+			"next_attempt_at": nil,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: target %d", ErrDeliveryNotFound, targetID)
+	}
+	return nil
+}
+
+// ScheduleDeliveryRetry resets delivery status to pending like
+// ResetDeliveryStatus, but GetPendingDeliveries skips it until at
+func (r *NotificationRepository) ScheduleDeliveryRetry(targetID int64, at time.Time) error {
+	result := r.db.Model(&model.NotificationDelivery{}).
+		Where("target_id = ?", targetID).
+		Updates(map[string]interface{}{
+			"status":          "pending",
+			"updated_at":      time.Now(),
+			"failed_at":       nil,
+			"next_attempt_at": at,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: target %d", ErrDeliveryNotFound, targetID)
+	}
+	return nil
 }
 
 // GetPendingDeliveries fetches pending deliveries from notification_delivery table
 // Query bắt đầu từ notification_delivery, join với notification_target và notification
-func (r *NotificationRepository) GetPendingDeliveries(limit int) ([]*model.PendingNotification, error) {
+// A positive maxPerUser takes at most that many deliveries per user and
+// interleaves users within each priority, so one user with a large backlog
+// can't fill the whole batch. Zero keeps strict priority, created_at order.
+// Deliveries whose retry backoff hasn't expired are skipped.
+func (r *NotificationRepository) GetPendingDeliveries(limit, maxPerUser int) ([]*model.PendingNotification, error) {
 	var results []*model.PendingNotification
 
+	// next_attempt_at is written from the application clock, so it is
+	// compared against that clock too
+	const dueCondition = `(nd.next_attempt_at IS NULL OR nd.next_attempt_at <= ?)`
+	now := time.Now()
+
 	query := `
 		SELECT 
 			nd.id as delivery_id,
@@ -231,13 +404,57 @@ func (r *NotificationRepository) GetPendingDeliveries(limit int) ([]*model.Pendi
 		FROM notification_delivery nd
 		INNER JOIN notification_target nt ON nd.target_id = nt.id
 		INNER JOIN notification n ON nt.notification_id = n.id
-		WHERE nd.status = 'pending'
+	`
+	var args []interface{}
+
+	if maxPerUser > 0 {
+		// Take at most maxPerUser deliveries per user, ranked in poll order.
+		// Each user's candidates are cut to maxPerUser before ranking, so a
+		// user with a large backlog doesn't have every pending row ranked.
+		// Row locks can't be taken at a level with window functions, so
+		// ranking happens in a subquery and only the joined tables are locked.
+		query += `
+		INNER JOIN (
+			SELECT
+				candidate.id,
+				ROW_NUMBER() OVER (
+					PARTITION BY users.user_id
+					ORDER BY candidate.priority DESC, candidate.created_at ASC
+				) AS user_rank
+			FROM (
+				SELECT DISTINCT nt.user_id
+				FROM notification_delivery nd
+				INNER JOIN notification_target nt ON nd.target_id = nt.id
+				WHERE nd.status = 'pending' AND ` + dueCondition + `
+			) users
+			CROSS JOIN LATERAL (
+				SELECT nd.id, n.priority, nd.created_at
+				FROM notification_delivery nd
+				INNER JOIN notification_target nt ON nd.target_id = nt.id
+				INNER JOIN notification n ON nt.notification_id = n.id
+				WHERE nt.user_id = users.user_id
+					AND nd.status = 'pending' AND ` + dueCondition + `
+				ORDER BY n.priority DESC, nd.created_at ASC
+				LIMIT ?
+			) candidate
+		) ranked ON ranked.id = nd.id
+		WHERE nd.status = 'pending' AND ` + dueCondition + `
+		ORDER BY n.priority DESC, ranked.user_rank ASC, nd.created_at ASC
+		LIMIT ?
+		FOR UPDATE OF nd, nt, n SKIP LOCKED
+		`
+		args = append(args, now, now, maxPerUser, now, limit)
+	} else {
+		query += `
+		WHERE nd.status = 'pending' AND ` + dueCondition + `
 		ORDER BY n.priority DESC, nd.created_at ASC
 		LIMIT ?
 		FOR UPDATE SKIP LOCKED
-	`
+		`
+		args = append(args, now, limit)
+	}
 
-	rows, err := r.db.Raw(query, limit).Rows()
+	rows, err := r.db.Raw(query, args...).Rows()
 	if err != nil {
 		return nil, fmt.Errorf("failed to query pending deliveries: %w", err)
 	}
@@ -332,6 +549,32 @@ func (r *NotificationRepository) GetPendingDeliveryCount() (int64, error) {
 	return count, err
 }
 
+// CountDeliveriesByStatus returns the number of deliveries in each of statuses
+// Statuses without deliveries are reported as 0.
+func (r *NotificationRepository) CountDeliveriesByStatus(statuses ...string) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.db.Model(&model.NotificationDelivery{}).
+		Select("status, COUNT(*) AS count").
+		Where("status IN ?", statuses).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count deliveries by status: %w", err)
+	}
+
+	counts := make(map[string]int64, len(statuses))
+	for _, status := range statuses {
+		counts[status] = 0
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
 // CheckIdempotency checks if a delivery has already been processed (database-based)
 func (r *NotificationRepository) CheckIdempotency(deliveryID int64) (bool, error) {
 	var count int64
@@ -409,6 +652,15 @@ func (r *NotificationRepository) GetDeviceTokensByUserID(userID string) ([]*mode
 	return tokens, nil
 }
 
+// GetDeviceTokenByPushToken retrieves a user's device token by its push token
+func (r *NotificationRepository) GetDeviceTokenByPushToken(userID, pushToken string) (*model.DeviceToken, error) {
+	var token model.DeviceToken
+	if err := r.db.Where("user_id = ? AND push_token = ?", userID, pushToken).First(&token).Error; err != nil {
+		return nil, fmt.Errorf("failed to get device token: %w", err)
+	}
+	return &token, nil
+}
+
 // DeleteDeviceToken deletes a device token
 func (r *NotificationRepository) DeleteDeviceToken(userID, deviceID string) error {
 	result := r.db.Where("user_id = ? AND device_id = ?", userID, deviceID).Delete(&model.DeviceToken{})
@@ -0,0 +1,581 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/service/notification/model"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// recordingConn is a database/sql connection that records statements
+// Statements starting with INSERT fail with insertErr when it is set,
+// UPDATE statements report rowsAffected and SELECT statements return no rows.
+type recordingConn struct {
+	mu           sync.Mutex
+	statements   []string
+	args         [][]driver.NamedValue
+	insertErr    error
+	rowsAffected int64
+}
+
+func (c *recordingConn) record(query string, args []driver.NamedValue) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statements = append(c.statements, query)
+	c.args = append(c.args, args)
+}
+
+func (c *recordingConn) count(prefix string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for _, stmt := range c.statements {
+		if strings.HasPrefix(strings.TrimSpace(stmt), prefix) {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *recordingConn) Close() error                              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *recordingConn) Commit() error                             { return nil }
+func (c *recordingConn) Rollback() error                           { return nil }
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.record(query, args)
+	if strings.HasPrefix(query, "INSERT") && c.insertErr != nil {
+		return nil, c.insertErr
+	}
+	return driver.RowsAffected(c.rowsAffected), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.record(query, args)
+	if strings.HasPrefix(query, "INSERT") && c.insertErr != nil {
+		return nil, c.insertErr
+	}
+	if strings.HasPrefix(strings.TrimSpace(query), "SELECT") {
+		return &idRows{done: true}, nil
+	}
+	return &idRows{}, nil
+}
+
+// idRows returns a single generated id for INSERT ... RETURNING
+type idRows struct{ done bool }
+
+func (r *idRows) Columns() []string { return []string{"id"} }
+func (r *idRows) Close() error      { return nil }
+func (r *idRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+type recordingConnector struct{ conn *recordingConn }
+
+func (c recordingConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c recordingConnector) Driver() driver.Driver                        { return nil }
+
+func newTestRepository(t *testing.T, conn *recordingConn) *NotificationRepository {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(recordingConnector{conn: conn})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	return NewNotificationRepository(&database.Database{DB: db})
+}
+
+func TestResetDeliveryStatus_UpdatesExistingDelivery(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 1}
+	repo := newTestRepository(t, conn)
+
+	require.NoError(t, repo.ResetDeliveryStatus(42))
+	require.NoError(t, repo.ResetDeliveryStatus(42))
+
+	assert.Equal(t, 2, conn.count("UPDATE"))
+	assert.Zero(t, conn.count("INSERT"), "retry must not insert a second delivery")
+}
+
+func TestResetDeliveryStatus_MissingDelivery(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 0}
+	repo := newTestRepository(t, conn)
+
+	err := repo.ResetDeliveryStatus(42)
+	assert.ErrorIs(t, err, ErrDeliveryNotFound)
+	assert.Zero(t, conn.count("INSERT"), "a missing delivery must not be recreated")
+}
+
+func TestCreateDelivery_DuplicateTarget(t *testing.T) {
+	conn := &recordingConn{insertErr: &pgconn.PgError{
+		Code:           pgUniqueViolation,
+		ConstraintName: "uq_notification_delivery_target_id",
+	}}
+	repo := newTestRepository(t, conn)
+
+	err := repo.CreateDelivery(&model.NotificationDelivery{TargetID: 42, Status: "pending"})
+	assert.ErrorIs(t, err, ErrDeliveryExists)
+	assert.Contains(t, err.Error(), "target 42")
+}
+
+func TestCreateDelivery_OtherErrorsAreNotConflicts(t *testing.T) {
+	conn := &recordingConn{insertErr: &pgconn.PgError{Code: "23503"}}
+	repo := newTestRepository(t, conn)
+
+	err := repo.CreateDelivery(&model.NotificationDelivery{TargetID: 42, Status: "pending"})
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrDeliveryExists)
+}
+
+func TestGetPendingDeliveries_MaxPerUser(t *testing.T) {
+	conn := &recordingConn{}
+	repo := newTestRepository(t, conn)
+
+	_, err := repo.GetPendingDeliveries(100, 5)
+	require.NoError(t, err)
+
+	require.Len(t, conn.statements, 1)
+	query := conn.statements[0]
+	// Each user's candidates are cut to maxPerUser before they are ranked
+	assert.Contains(t, query, "CROSS JOIN LATERAL")
+	assert.Contains(t, query, "LIMIT $3")
+	// Users are interleaved within a priority, in creation order per user
+	assert.Contains(t, query, "ORDER BY n.priority DESC, ranked.user_rank ASC, nd.created_at ASC")
+	// Window functions can't share a level with row locks
+	assert.Contains(t, query, "FOR UPDATE OF nd, nt, n SKIP LOCKED")
+
+	args := conn.args[0]
+	require.Len(t, args, 5)
+	assert.EqualValues(t, 5, args[2].Value)
+	assert.EqualValues(t, 100, args[4].Value)
+}
+
+func TestGetPendingDeliveries_MaxPerUserPostgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	// alice has a backlog far larger than the batch; bob and carol have a few
+	start := time.Now().Add(-time.Hour).UTC()
+	for i := 0; i < 200; i++ {
+		seedDelivery(t, db, deliverySeed{userID: "alice", createdAt: start.Add(time.Duration(i) * time.Second)})
+	}
+	later := start.Add(10 * time.Minute)
+	bob := []int64{
+		seedDelivery(t, db, deliverySeed{userID: "bob", createdAt: later}),
+		seedDelivery(t, db, deliverySeed{userID: "bob", createdAt: later.Add(time.Second)}),
+	}
+	carol := seedDelivery(t, db, deliverySeed{userID: "carol", createdAt: later.Add(2 * time.Second)})
+
+	countByUser := func(pending []*model.PendingNotification) map[string]int {
+		counts := make(map[string]int)
+		for _, p := range pending {
+			counts[p.Target.UserID]++
+		}
+		return counts
+	}
+
+	t.Run("skewed user is capped", func(t *testing.T) {
+		pending, err := repo.GetPendingDeliveries(50, 3)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"alice": 3, "bob": 2, "carol": 1}, countByUser(pending))
+	})
+
+	t.Run("others get slots in a small batch", func(t *testing.T) {
+		pending, err := repo.GetPendingDeliveries(4, 3)
+		require.NoError(t, err)
+		require.Len(t, pending, 4)
+
+		// Every user's first delivery goes out before anyone's second
+		var firstRound []int64
+		for _, p := range pending[:3] {
+			firstRound = append(firstRound, p.DeliveryID)
+		}
+		assert.Contains(t, firstRound, bob[0])
+		assert.Contains(t, firstRound, carol)
+		assert.Equal(t, map[string]int{"alice": 2, "bob": 1, "carol": 1}, countByUser(pending))
+	})
+
+	t.Run("without a cap the backlog fills the batch", func(t *testing.T) {
+		pending, err := repo.GetPendingDeliveries(50, 0)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]int{"alice": 50}, countByUser(pending))
+	})
+}
+
+func TestGetPendingDeliveries_NoPerUserCap(t *testing.T) {
+	conn := &recordingConn{}
+	repo := newTestRepository(t, conn)
+
+	_, err := repo.GetPendingDeliveries(100, 0)
+	require.NoError(t, err)
+
+	require.Len(t, conn.statements, 1)
+	query := conn.statements[0]
+	assert.NotContains(t, query, "ROW_NUMBER")
+	assert.Contains(t, query, "ORDER BY n.priority DESC, nd.created_at ASC")
+
+	args := conn.args[0]
+	require.Len(t, args, 2)
+	assert.EqualValues(t, 100, args[1].Value)
+}
+
+func TestCreateNotification_UndeliverableTargetIsFailed(t *testing.T) {
+	conn := &recordingConn{}
+	repo := newTestRepository(t, conn)
+
+	targets := []*model.NotificationTarget{
+		{UserID: "alice"},
+		{UserID: "ghost", Undeliverable: "user not found"},
+	}
+	require.NoError(t, repo.CreateNotification(&model.Notification{Type: "order"}, targets))
+
+	var statuses, errs []interface{}
+	for i, stmt := range conn.statements {
+		if !strings.HasPrefix(stmt, `INSERT INTO "notification_delivery"`) {
+			continue
+		}
+		// target_id, status, attempt_count, retry_count, last_error, ...
+		statuses = append(statuses, conn.args[i][1].Value)
+		errs = append(errs, conn.args[i][4].Value)
+	}
+	assert.Equal(t, []interface{}{"pending", "failed"}, statuses)
+	assert.Equal(t, []interface{}{"", "user not found"}, errs)
+}
+
+func TestCreateNotification_JoinsCallerTransaction(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 1}
+	repo := newTestRepository(t, conn)
+
+	targets := []*model.NotificationTarget{{UserID: "alice"}}
+	err := repo.db.WithinTransaction(context.Background(), func(ctx context.Context, tx *gorm.DB) error {
+		return repo.WithContext(ctx).CreateNotification(&model.Notification{Type: "order"}, targets)
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, conn.count("SAVEPOINT"), "the notification is written in the caller's transaction")
+}
+
+func TestCountDeliveriesByStatus(t *testing.T) {
+	conn := &recordingConn{}
+	repo := newTestRepository(t, conn)
+
+	counts, err := repo.CountDeliveriesByStatus("pending", "processing")
+	require.NoError(t, err)
+
+	// Statuses without rows are reported as zero
+	assert.Equal(t, map[string]int64{"pending": 0, "processing": 0}, counts)
+
+	require.Len(t, conn.statements, 1)
+	query := conn.statements[0]
+	assert.Contains(t, query, "SELECT status, COUNT(*) AS count")
+	assert.Contains(t, query, "GROUP BY")
+
+	args := conn.args[0]
+	require.Len(t, args, 2)
+	assert.Equal(t, "pending", args[0].Value)
+	assert.Equal(t, "processing", args[1].Value)
+}
+
+func TestCountDeliveriesByStatus_Postgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	for _, status := range []string{"pending", "pending", "pending", "processing", "delivered", "failed"} {
+		seedDelivery(t, db, deliverySeed{status: status})
+	}
+
+	counts, err := repo.CountDeliveriesByStatus("pending", "processing", "cancelled")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int64{"pending": 3, "processing": 1, "cancelled": 0}, counts)
+}
+
+func TestDeleteExpiredFailedDeliveries(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 3}
+	repo := newTestRepository(t, conn)
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	removed, err := repo.DeleteExpiredFailedDeliveries(cutoff, 500, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, removed)
+
+	require.Len(t, conn.statements, 1)
+	query := conn.statements[0]
+	assert.True(t, strings.HasPrefix(strings.TrimSpace(query), "DELETE FROM notification_delivery"))
+	assert.NotContains(t, query, "notification_delivery_archive")
+
+	// Only failed rows qualify, so pending, processing and delivered rows are
+	// kept, and only those that failed strictly before the cutoff
+	assert.Contains(t, query, "status = 'failed'")
+	assert.Contains(t, query, "COALESCE(failed_at, created_at) < $1")
+	assert.Contains(t, query, "LIMIT $2")
+
+	args := conn.args[0]
+	require.Len(t, args, 2)
+	assert.Equal(t, cutoff, args[0].Value)
+	assert.EqualValues(t, 500, args[1].Value)
+}
+
+func TestDeleteExpiredFailedDeliveries_Archive(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 2}
+	repo := newTestRepository(t, conn)
+
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	removed, err := repo.DeleteExpiredFailedDeliveries(cutoff, 500, true)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, removed)
+
+	// Archiving and removing happen in one statement
+	require.Len(t, conn.statements, 1)
+	query := conn.statements[0]
+	assert.Contains(t, query, "DELETE FROM notification_delivery WHERE id IN")
+	assert.Contains(t, query, "RETURNING *")
+	assert.Contains(t, query, "INSERT INTO notification_delivery_archive")
+	assert.Contains(t, query, "status = 'failed'")
+	assert.Contains(t, query, "COALESCE(failed_at, created_at) < $1")
+
+	args := conn.args[0]
+	require.Len(t, args, 3)
+	assert.Equal(t, cutoff, args[0].Value)
+	assert.EqualValues(t, 500, args[1].Value)
+	assert.IsType(t, time.Time{}, args[2].Value)
+}
+
+// seedRetentionDeliveries seeds deliveries on both sides of cutoff and
+// returns the IDs the cleanup must remove and those it must keep
+func seedRetentionDeliveries(t *testing.T, db *gorm.DB, cutoff time.Time) (expired, kept []int64) {
+	t.Helper()
+
+	old := cutoff.Add(-10 * 24 * time.Hour)
+	oldFailure := old.Add(time.Hour)
+	recentFailure := cutoff.Add(24 * time.Hour)
+
+	expired = []int64{
+		seedDelivery(t, db, deliverySeed{status: "failed", lastError: "bounced", createdAt: old, failedAt: &oldFailure}),
+		// Without failed_at, the creation time decides
+		seedDelivery(t, db, deliverySeed{status: "failed", createdAt: old}),
+	}
+	kept = []int64{
+		// Created long ago but failed after the cutoff
+		seedDelivery(t, db, deliverySeed{status: "failed", createdAt: old, failedAt: &recentFailure}),
+		seedDelivery(t, db, deliverySeed{status: "pending", createdAt: old}),
+		seedDelivery(t, db, deliverySeed{status: "processing", createdAt: old}),
+		seedDelivery(t, db, deliverySeed{status: "delivered", createdAt: old}),
+	}
+	return expired, kept
+}
+
+// deliveryIDs returns the IDs left in table, in order
+func deliveryIDs(t *testing.T, db *gorm.DB, table string) []int64 {
+	t.Helper()
+
+	var ids []int64
+	require.NoError(t, db.Raw("SELECT id FROM "+table+" ORDER BY id").Scan(&ids).Error)
+	return ids
+}
+
+func TestDeleteExpiredFailedDeliveries_Postgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	cutoff := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	expired, kept := seedRetentionDeliveries(t, db, cutoff)
+
+	removed, err := repo.DeleteExpiredFailedDeliveries(cutoff, 500, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, len(expired), removed)
+
+	assert.Equal(t, kept, deliveryIDs(t, db, "notification_delivery"))
+	assert.Empty(t, deliveryIDs(t, db, "notification_delivery_archive"))
+}
+
+func TestDeleteExpiredFailedDeliveries_LimitPostgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	cutoff := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	expired, kept := seedRetentionDeliveries(t, db, cutoff)
+
+	// The oldest ID goes first; the rest waits for the next batch
+	removed, err := repo.DeleteExpiredFailedDeliveries(cutoff, 1, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, removed)
+	assert.Equal(t, append([]int64{expired[1]}, kept...), deliveryIDs(t, db, "notification_delivery"))
+
+	removed, err = repo.DeleteExpiredFailedDeliveries(cutoff, 1, false)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, removed)
+	assert.Equal(t, kept, deliveryIDs(t, db, "notification_delivery"))
+}
+
+func TestDeleteExpiredFailedDeliveries_ArchivePostgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	cutoff := time.Now().UTC().Add(-30 * 24 * time.Hour)
+	expired, kept := seedRetentionDeliveries(t, db, cutoff)
+
+	removed, err := repo.DeleteExpiredFailedDeliveries(cutoff, 500, true)
+	require.NoError(t, err)
+	assert.EqualValues(t, len(expired), removed)
+
+	assert.Equal(t, kept, deliveryIDs(t, db, "notification_delivery"))
+	assert.Equal(t, expired, deliveryIDs(t, db, "notification_delivery_archive"))
+
+	var archived struct {
+		Status     string
+		LastError  string
+		ArchivedAt time.Time
+	}
+	require.NoError(t, db.Raw(
+		`SELECT status, last_error, archived_at FROM notification_delivery_archive WHERE id = ?`, expired[0],
+	).Scan(&archived).Error)
+	assert.Equal(t, "failed", archived.Status)
+	assert.Equal(t, "bounced", archived.LastError)
+	assert.False(t, archived.ArchivedAt.IsZero())
+}
+
+func TestWithContext_CancelledContextAbortsQuery(t *testing.T) {
+	conn := &recordingConn{rowsAffected: 1}
+	repo := newTestRepository(t, conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := repo.WithContext(ctx).ResetDeliveryStatus(42)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, conn.statements)
+
+	// The original repository is not bound to the cancelled context
+	require.NoError(t, repo.ResetDeliveryStatus(42))
+	assert.Equal(t, 1, conn.count("UPDATE"))
+}
+
+func TestGetDeliveryReport_Postgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	jan2 := jan1.AddDate(0, 0, 1)
+	jan3 := jan1.AddDate(0, 0, 2)
+
+	seedDelivery(t, db, deliverySeed{status: "delivered", channel: "expo", createdAt: jan1.Add(time.Hour)})
+	seedDelivery(t, db, deliverySeed{status: "delivered", createdAt: jan1.Add(2 * time.Hour)})
+	seedDelivery(t, db, deliverySeed{status: "failed", channel: "sms", createdAt: jan2.Add(23 * time.Hour)})
+	seedDelivery(t, db, deliverySeed{notificationType: "promo", status: "delivered", channel: "email", createdAt: jan2})
+	// Outside [jan1, jan3)
+	seedDelivery(t, db, deliverySeed{status: "delivered", createdAt: jan1.Add(-time.Second)})
+	seedDelivery(t, db, deliverySeed{status: "delivered", createdAt: jan3})
+
+	rows, err := repo.GetDeliveryReport(jan1, jan3)
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	assert.True(t, jan1.Equal(rows[0].Day))
+	assert.Equal(t, "order_created", rows[0].Type)
+	assert.Equal(t, "expo", rows[0].Channel, "a missing sender_type counts as expo")
+	assert.Equal(t, "delivered", rows[0].Status)
+	assert.EqualValues(t, 2, rows[0].Count)
+
+	assert.True(t, jan2.Equal(rows[1].Day))
+	assert.Equal(t, "order_created", rows[1].Type)
+	assert.Equal(t, "sms", rows[1].Channel)
+	assert.Equal(t, "failed", rows[1].Status)
+	assert.EqualValues(t, 1, rows[1].Count)
+
+	assert.True(t, jan2.Equal(rows[2].Day))
+	assert.Equal(t, "promo", rows[2].Type)
+	assert.Equal(t, "email", rows[2].Channel)
+	assert.EqualValues(t, 1, rows[2].Count)
+}
+
+func TestGetPendingDeliveries_SkipsBackedOffDeliveries(t *testing.T) {
+	for _, maxPerUser := range []int{0, 5} {
+		conn := &recordingConn{}
+		repo := newTestRepository(t, conn)
+
+		_, err := repo.GetPendingDeliveries(100, maxPerUser)
+		require.NoError(t, err)
+
+		require.Len(t, conn.statements, 1)
+		assert.Contains(t, conn.statements[0], "nd.next_attempt_at IS NULL OR nd.next_attempt_at <= $1")
+	}
+}
+
+func TestGetPendingDeliveries_BackoffPostgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	targetOf := func(deliveryID int64) int64 {
+		var targetID int64
+		require.NoError(t, db.Raw(`SELECT target_id FROM notification_delivery WHERE id = ?`, deliveryID).Scan(&targetID).Error)
+		return targetID
+	}
+
+	backedOff := seedDelivery(t, db, deliverySeed{userID: "alice", status: "processing"})
+	due := seedDelivery(t, db, deliverySeed{userID: "bob", status: "processing"})
+	require.NoError(t, repo.ScheduleDeliveryRetry(targetOf(backedOff), time.Now().Add(time.Hour)))
+	require.NoError(t, repo.ScheduleDeliveryRetry(targetOf(due), time.Now().Add(-time.Second)))
+
+	for _, maxPerUser := range []int{0, 5} {
+		pending, err := repo.GetPendingDeliveries(100, maxPerUser)
+		require.NoError(t, err)
+		require.Len(t, pending, 1, "maxPerUser %d", maxPerUser)
+		assert.Equal(t, due, pending[0].DeliveryID)
+	}
+
+	// A manual retry clears the backoff
+	require.NoError(t, repo.ResetDeliveryStatus(targetOf(backedOff)))
+	pending, err := repo.GetPendingDeliveries(100, 0)
+	require.NoError(t, err)
+	assert.Len(t, pending, 2)
+}
+
+func TestGetPendingFailedForUser_SkipsUnknownUsers(t *testing.T) {
+	conn := &recordingConn{}
+	repo := newTestRepository(t, conn)
+
+	_, err := repo.GetPendingFailedForUser("ghost", 20, 0, nil)
+	require.NoError(t, err)
+
+	require.Len(t, conn.statements, 1)
+	assert.Contains(t, conn.statements[0], "nd.last_error IS DISTINCT FROM $2")
+	assert.Equal(t, model.UndeliverableUnknownUser, conn.args[0][1].Value)
+}
+
+func TestGetPendingFailedForUser_SkipsUnknownUsersPostgres(t *testing.T) {
+	repo, db := newPostgresRepository(t)
+
+	failedAt := time.Now().UTC()
+	failed := seedDelivery(t, db, deliverySeed{userID: "ghost", status: "failed", lastError: "provider timeout", failedAt: &failedAt})
+	seedDelivery(t, db, deliverySeed{userID: "ghost", status: "failed", lastError: model.UndeliverableUnknownUser, failedAt: &failedAt})
+	seedDelivery(t, db, deliverySeed{userID: "ghost", status: "failed", failedAt: &failedAt})
+
+	results, err := repo.GetPendingFailedForUser("ghost", 20, 0, nil)
+	require.NoError(t, err)
+
+	var ids []int64
+	for _, r := range results {
+		ids = append(ids, r.ID)
+		assert.NotEqual(t, model.UndeliverableUnknownUser, r.LastError)
+	}
+	assert.Len(t, ids, 2, "rows without an error must still be listed")
+	assert.Contains(t, ids, failed)
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"myapp/internal/pkg/cursor"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor is malformed, tampered with or stale
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// newCursorCodec signs cursors with notification.cursor_secret, which
+// config validation requires to be set
+func newCursorCodec(cfg *config.ServiceConfig) *cursor.Codec {
+	var secret string
+	if cfg != nil {
+		secret = cfg.Notification.CursorSecret
+	}
+	return cursor.NewCodec([]byte(secret))
+}
+
+// decodeFailedCursor parses a cursor token from a previous page
+func (s *NotificationService) decodeFailedCursor(token string) (*model.FailedNotificationCursor, error) {
+	var after model.FailedNotificationCursor
+	if err := s.cursors.Decode(token, &after); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return &after, nil
+}
+
+// nextFailedCursor returns the cursor after the last result, or "" when the page isn't full
+func (s *NotificationService) nextFailedCursor(results []*model.FailedNotificationResponse, limit int) (string, error) {
+	if len(results) == 0 || len(results) < limit {
+		return "", nil
+	}
+
+	last := results[len(results)-1]
+	// Mirrors the repository sort key, which falls back to created_at
+	position := model.FailedNotificationCursor{FailedAt: last.CreatedAt, ID: last.ID}
+	if last.FailedAt != nil {
+		position.FailedAt = *last.FailedAt
+	}
+	return s.cursors.Encode(position)
+}
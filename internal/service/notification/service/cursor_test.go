@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pkgconfig "myapp/internal/pkg/config"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCursorTestService(secret string) *NotificationService {
+	cfg := &config.ServiceConfig{Config: &pkgconfig.Config{}}
+	cfg.Notification.CursorSecret = secret
+	return NewNotificationService(nil, cfg, nil)
+}
+
+func TestNextFailedCursor_RoundTrip(t *testing.T) {
+	s := newCursorTestService("cursor-secret")
+	failedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	results := []*model.FailedNotificationResponse{
+		{ID: 9, FailedAt: &failedAt},
+		{ID: 7, FailedAt: &failedAt},
+	}
+
+	next, err := s.nextFailedCursor(results, 2)
+	require.NoError(t, err)
+	require.NotEmpty(t, next)
+
+	after, err := s.decodeFailedCursor(next)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), after.ID)
+	assert.True(t, failedAt.Equal(after.FailedAt))
+}
+
+func TestNextFailedCursor_FallsBackToCreatedAt(t *testing.T) {
+	s := newCursorTestService("cursor-secret")
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	next, err := s.nextFailedCursor([]*model.FailedNotificationResponse{{ID: 3, CreatedAt: createdAt}}, 1)
+	require.NoError(t, err)
+
+	after, err := s.decodeFailedCursor(next)
+	require.NoError(t, err)
+	assert.True(t, createdAt.Equal(after.FailedAt))
+}
+
+func TestNextFailedCursor_EmptyOnLastPage(t *testing.T) {
+	s := newCursorTestService("cursor-secret")
+
+	next, err := s.nextFailedCursor([]*model.FailedNotificationResponse{{ID: 1}}, 20)
+	require.NoError(t, err)
+	assert.Empty(t, next)
+}
+
+func TestGetFailedNotifications_RejectsBadCursor(t *testing.T) {
+	s := newCursorTestService("cursor-secret")
+	other := newCursorTestService("other-secret")
+
+	forged, err := other.nextFailedCursor([]*model.FailedNotificationResponse{{ID: 1}}, 1)
+	require.NoError(t, err)
+
+	for _, token := range []string{"garbage", forged} {
+		_, _, err := s.GetFailedNotifications(context.Background(), "user-1", 20, 0, token)
+		assert.ErrorIs(t, err, ErrInvalidCursor)
+	}
+}
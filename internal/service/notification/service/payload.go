@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"myapp/internal/service/notification/config"
+)
+
+// Built-in payload limits used when the config leaves a limit at 0
+const (
+	DefaultPayloadMaxDepth     = 10
+	DefaultPayloadMaxSizeBytes = 64 * 1024
+	DefaultPayloadMaxKeys      = 1000
+)
+
+// ErrInvalidPayload is returned when a target payload exceeds a configured limit
+var ErrInvalidPayload = errors.New("invalid payload")
+
+// payloadLimits fills unset limits with the built-in defaults
+func payloadLimits(cfg *config.ServiceConfig) config.PayloadLimitsConfig {
+	var limits config.PayloadLimitsConfig
+	if cfg != nil {
+		limits = cfg.Notification.PayloadLimits
+	}
+	if limits.MaxDepth <= 0 {
+		limits.MaxDepth = DefaultPayloadMaxDepth
+	}
+	if limits.MaxSizeBytes <= 0 {
+		limits.MaxSizeBytes = DefaultPayloadMaxSizeBytes
+	}
+	if limits.MaxKeys <= 0 {
+		limits.MaxKeys = DefaultPayloadMaxKeys
+	}
+	return limits
+}
+
+// ValidatePayload checks nesting depth, key count and serialized size
+// The structure is walked first, so deeply nested or huge payloads are
+// rejected before they are serialized.
+func ValidatePayload(payload map[string]interface{}, limits config.PayloadLimitsConfig) error {
+	keys := 0
+	if err := walkPayload(payload, 1, &keys, limits); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("%w: not serializable: %v", ErrInvalidPayload, err)
+	}
+	if len(data) > limits.MaxSizeBytes {
+		return fmt.Errorf("%w: size %d bytes exceeds max_size_bytes %d", ErrInvalidPayload, len(data), limits.MaxSizeBytes)
+	}
+
+	return nil
+}
+
+// walkPayload enforces MaxDepth and MaxKeys, stopping at the first violation
+func walkPayload(value interface{}, depth int, keys *int, limits config.PayloadLimitsConfig) error {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if depth > limits.MaxDepth {
+			return fmt.Errorf("%w: nesting exceeds max_depth %d", ErrInvalidPayload, limits.MaxDepth)
+		}
+		*keys += len(v)
+		if *keys > limits.MaxKeys {
+			return fmt.Errorf("%w: more than max_keys %d keys", ErrInvalidPayload, limits.MaxKeys)
+		}
+		for _, child := range v {
+			if err := walkPayload(child, depth+1, keys, limits); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if depth > limits.MaxDepth {
+			return fmt.Errorf("%w: nesting exceeds max_depth %d", ErrInvalidPayload, limits.MaxDepth)
+		}
+		for _, child := range v {
+			if err := walkPayload(child, depth+1, keys, limits); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
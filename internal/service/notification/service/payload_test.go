@@ -0,0 +1,100 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nestedPayload returns a payload whose objects nest depth levels deep
+func nestedPayload(depth int) map[string]interface{} {
+	payload := map[string]interface{}{"leaf": true}
+	for i := 1; i < depth; i++ {
+		payload = map[string]interface{}{"child": payload}
+	}
+	return payload
+}
+
+func TestValidatePayload(t *testing.T) {
+	limits := config.PayloadLimitsConfig{MaxDepth: 5, MaxSizeBytes: 1024, MaxKeys: 20}
+
+	manyKeys := make(map[string]interface{})
+	for i := 0; i < 21; i++ {
+		manyKeys[strings.Repeat("k", i+1)] = i
+	}
+
+	tests := []struct {
+		name    string
+		payload map[string]interface{}
+		wantErr string
+	}{
+		{
+			name: "normal payload",
+			payload: map[string]interface{}{
+				"title": "Order shipped",
+				"body":  "Your order is on its way",
+				"data":  map[string]interface{}{"order_id": "123", "items": []interface{}{"a", "b"}},
+			},
+		},
+		{name: "empty payload", payload: nil},
+		{name: "at max depth", payload: nestedPayload(5)},
+		{name: "too deep", payload: nestedPayload(6), wantErr: "nesting exceeds max_depth 5"},
+		{
+			name:    "too deep through arrays",
+			payload: map[string]interface{}{"a": []interface{}{[]interface{}{[]interface{}{[]interface{}{[]interface{}{1}}}}}},
+			wantErr: "nesting exceeds max_depth 5",
+		},
+		{name: "too many keys", payload: manyKeys, wantErr: "more than max_keys 20 keys"},
+		{
+			name:    "too large",
+			payload: map[string]interface{}{"body": strings.Repeat("x", 2000)},
+			wantErr: "exceeds max_size_bytes 1024",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePayload(tt.payload, limits)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidPayload)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestPayloadLimits_Defaults(t *testing.T) {
+	limits := payloadLimits(nil)
+	assert.Equal(t, DefaultPayloadMaxDepth, limits.MaxDepth)
+	assert.Equal(t, DefaultPayloadMaxSizeBytes, limits.MaxSizeBytes)
+	assert.Equal(t, DefaultPayloadMaxKeys, limits.MaxKeys)
+
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.PayloadLimits = config.PayloadLimitsConfig{MaxDepth: 3}
+	limits = payloadLimits(cfg)
+	assert.Equal(t, 3, limits.MaxDepth)
+	assert.Equal(t, DefaultPayloadMaxKeys, limits.MaxKeys)
+}
+
+func TestCreateNotification_RejectsInvalidPayload(t *testing.T) {
+	s := &NotificationService{}
+
+	_, err := s.CreateNotification(context.Background(), model.CreateNotificationDTO{
+		Type: "order",
+		Targets: []model.NotificationTargetDTO{
+			{UserID: "1", Payload: map[string]interface{}{"title": "ok"}},
+			{UserID: "2", Payload: nestedPayload(DefaultPayloadMaxDepth + 1)},
+		},
+	})
+
+	require.ErrorIs(t, err, ErrInvalidPayload)
+	assert.Contains(t, err.Error(), "target 1")
+}
@@ -1,9 +1,12 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	"myapp/internal/pkg/cursor"
 	"myapp/internal/pkg/logger"
 	"myapp/internal/service/notification/config"
 	"myapp/internal/service/notification/model"
@@ -15,22 +18,34 @@ import (
 
 // NotificationService handles notification business logic
 type NotificationService struct {
-	repo   *repository.NotificationRepository
-	config *config.ServiceConfig
-	logger *logger.Logger
+	repo    *repository.NotificationRepository
+	config  *config.ServiceConfig
+	logger  *logger.Logger
+	cursors *cursor.Codec
+	users   UserChecker
+	worker  WorkerState
 }
 
 // NewNotificationService creates a new notification service
 func NewNotificationService(repo *repository.NotificationRepository, cfg *config.ServiceConfig, log *logger.Logger) *NotificationService {
 	return &NotificationService{
-		repo:   repo,
-		config: cfg,
-		logger: log,
+		repo:    repo,
+		config:  cfg,
+		logger:  log,
+		cursors: newCursorCodec(cfg),
 	}
 }
 
 // CreateNotification creates a new notification with targets
-func (s *NotificationService) CreateNotification(dto model.CreateNotificationDTO) (*model.Notification, error) {
+func (s *NotificationService) CreateNotification(ctx context.Context, dto model.CreateNotificationDTO) (*model.Notification, error) {
+	// Reject oversized or deeply nested payloads before they are stored
+	limits := payloadLimits(s.config)
+	for i, targetDTO := range dto.Targets {
+		if err := ValidatePayload(targetDTO.Payload, limits); err != nil {
+			return nil, fmt.Errorf("target %d: %w", i, err)
+		}
+	}
+
 	// Create notification
 	notif := &model.Notification{
 		Type:       dto.Type,
@@ -49,8 +64,12 @@ func (s *NotificationService) CreateNotification(dto model.CreateNotificationDTO
 		targets = append(targets, target)
 	}
 
+	if err := s.checkTargetUsers(ctx, targets); err != nil {
+		return nil, err
+	}
+
 	// Save to database
-	if err := s.repo.CreateNotification(notif, targets); err != nil {
+	if err := s.repo.WithContext(ctx).CreateNotification(notif, targets); err != nil {
 		return nil, fmt.Errorf("failed to create notification: %w", err)
 	}
 
@@ -65,7 +84,9 @@ func (s *NotificationService) CreateNotification(dto model.CreateNotificationDTO
 }
 
 // GetFailedNotifications retrieves failed notifications for a user
-func (s *NotificationService) GetFailedNotifications(userID string, limit, offset int) ([]*model.FailedNotificationResponse, error) {
+// A non-empty cursor from a previous page takes precedence over offset. The
+// returned cursor points after the last result and is empty on the last page.
+func (s *NotificationService) GetFailedNotifications(ctx context.Context, userID string, limit, offset int, cursorToken string) ([]*model.FailedNotificationResponse, string, error) {
 	if limit <= 0 {
 		limit = 20
 	}
@@ -73,18 +94,33 @@ func (s *NotificationService) GetFailedNotifications(userID string, limit, offse
 		limit = 100
 	}
 
-	notifications, err := s.repo.GetPendingFailedForUser(userID, limit, offset)
+	var after *model.FailedNotificationCursor
+	if cursorToken != "" {
+		var err error
+		if after, err = s.decodeFailedCursor(cursorToken); err != nil {
+			return nil, "", err
+		}
+	}
+
+	notifications, err := s.repo.WithContext(ctx).GetPendingFailedForUser(userID, limit, offset, after)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get failed notifications: %w", err)
+	}
+
+	next, err := s.nextFailedCursor(notifications, limit)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get failed notifications: %w", err)
+		return nil, "", fmt.Errorf("failed to encode cursor: %w", err)
 	}
 
-	return notifications, nil
+	return notifications, next, nil
 }
 
 // RetryNotification retries a failed notification
-func (s *NotificationService) RetryNotification(targetID int64) error {
+func (s *NotificationService) RetryNotification(ctx context.Context, targetID int64) error {
+	repo := s.repo.WithContext(ctx)
+
 	// Get target
-	target, err := s.repo.GetTargetByID(targetID)
+	target, err := repo.GetTargetByID(targetID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return errors.New("notification target not found")
@@ -93,7 +129,7 @@ func (s *NotificationService) RetryNotification(targetID int64) error {
 	}
 
 	// Get delivery record
-	delivery, err := s.repo.GetDeliveryByTargetID(targetID)
+	delivery, err := repo.GetDeliveryByTargetID(targetID)
 	if err != nil {
 		return fmt.Errorf("failed to get delivery record: %w", err)
 	}
@@ -102,8 +138,13 @@ func (s *NotificationService) RetryNotification(targetID int64) error {
 		return errors.New("notification is not in failed status")
 	}
 
+	// A target flagged for an unknown user would only fail again
+	if delivery.LastError == UnknownUserError {
+		return fmt.Errorf("%w: %s", ErrUnknownUsers, target.UserID)
+	}
+
 	// Reset delivery status
-	if err := s.repo.ResetDeliveryStatus(targetID); err != nil {
+	if err := repo.ResetDeliveryStatus(targetID); err != nil {
 		return fmt.Errorf("failed to reset delivery status: %w", err)
 	}
 
@@ -129,8 +170,9 @@ func (s *NotificationService) GetNotificationByID(id int64) (*model.Notification
 }
 
 // RegisterDeviceToken registers or updates a device token
-func (s *NotificationService) RegisterDeviceToken(dto model.RegisterTokenDTO) (*model.RegisterTokenResponse, error) {
-	token, err := s.repo.RegisterDeviceToken(dto)
+// The push token is masked in the response unless reveal is set
+func (s *NotificationService) RegisterDeviceToken(ctx context.Context, dto model.RegisterTokenDTO, reveal bool) (*model.RegisterTokenResponse, error) {
+	token, err := s.repo.WithContext(ctx).RegisterDeviceToken(dto)
 	if err != nil {
 		return nil, fmt.Errorf("failed to register device token: %w", err)
 	}
@@ -143,16 +185,90 @@ func (s *NotificationService) RegisterDeviceToken(dto model.RegisterTokenDTO) (*
 		zap.String("platform", token.Platform),
 	)
 
+	return s.newRegisterTokenResponse(token, reveal), nil
+}
+
+// newRegisterTokenResponse builds the registration response, masking the push token
+func (s *NotificationService) newRegisterTokenResponse(token *model.DeviceToken, reveal bool) *model.RegisterTokenResponse {
+	pushToken := token.PushToken
+	if !reveal {
+		pushToken = s.maskPushToken(pushToken)
+	}
+
 	return &model.RegisterTokenResponse{
 		ID:         token.ID,
 		UserID:     token.UserID,
 		DeviceID:   token.DeviceID,
-		PushToken:  token.PushToken,
+		PushToken:  pushToken,
 		Type:       token.Type,
 		Platform:   token.Platform,
 		LastSeenAt: token.LastSeenAt,
 		CreatedAt:  token.CreatedAt,
 		UpdatedAt:  token.UpdatedAt,
-	}, nil
+	}
+}
+
+// MaxReportRange bounds the date range of a send report
+const MaxReportRange = 90 * 24 * time.Hour
+
+// ErrInvalidReportRange is returned for empty, inverted or oversized report ranges
+var ErrInvalidReportRange = errors.New("invalid report date range")
+
+// GetNotificationReport aggregates delivery counts created in [from, to)
+func (s *NotificationService) GetNotificationReport(from, to time.Time) (*model.NotificationReport, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidReportRange)
+	}
+	if to.Sub(from) > MaxReportRange {
+		return nil, fmt.Errorf("%w: range exceeds %d days", ErrInvalidReportRange, int(MaxReportRange.Hours()/24))
+	}
+
+	rows, err := s.repo.GetDeliveryReport(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification report: %w", err)
+	}
+
+	return BuildNotificationReport(from, to, rows), nil
+}
+
+// BuildNotificationReport rolls grouped rows up into per-dimension totals
+func BuildNotificationReport(from, to time.Time, rows []*model.NotificationReportRow) *model.NotificationReport {
+	report := &model.NotificationReport{
+		From:      from,
+		To:        to,
+		ByType:    make(map[string]int64),
+		ByChannel: make(map[string]int64),
+		ByStatus:  make(map[string]int64),
+		Daily:     rows,
+	}
+	if report.Daily == nil {
+		report.Daily = []*model.NotificationReportRow{}
+	}
+
+	for _, row := range rows {
+		report.Total += row.Count
+		report.ByType[row.Type] += row.Count
+		report.ByChannel[row.Channel] += row.Count
+		report.ByStatus[row.Status] += row.Count
+	}
+
+	return report
+}
+
+// CanRevealPushToken reports whether a caller with the given role may see an
+// unmasked push token. Only admins who explicitly ask for it are allowed.
+func CanRevealPushToken(role string, requested bool) bool {
+	return requested && role == "admin"
+}
+
+// maskPushToken masks a push token using the configured visible lengths
+func (s *NotificationService) maskPushToken(token string) string {
+	prefix, suffix := 8, 4
+	if s.config != nil {
+		if cfg := s.config.Notification.TokenMasking; cfg.VisiblePrefix > 0 || cfg.VisibleSuffix > 0 {
+			prefix, suffix = cfg.VisiblePrefix, cfg.VisibleSuffix
+		}
+	}
+	return model.MaskPushToken(token, prefix, suffix)
 }
 
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+	"myapp/internal/service/notification/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const testPushToken = "ExponentPushToken[xxxxxxxxxxxxxxxxxxxxxx]"
+
+func TestRegisterTokenResponse_MasksPushTokenByDefault(t *testing.T) {
+	s := &NotificationService{}
+
+	resp := s.newRegisterTokenResponse(&model.DeviceToken{ID: 1, PushToken: testPushToken}, false)
+
+	assert.Equal(t, "Exponent****xxx]", resp.PushToken)
+	assert.NotContains(t, resp.PushToken, "xxxxxxxxxxxx")
+}
+
+func TestRegisterTokenResponse_RevealsPushToken(t *testing.T) {
+	s := &NotificationService{}
+
+	resp := s.newRegisterTokenResponse(&model.DeviceToken{ID: 1, PushToken: testPushToken}, true)
+
+	assert.Equal(t, testPushToken, resp.PushToken)
+}
+
+func TestRegisterTokenResponse_UsesConfiguredMasking(t *testing.T) {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.TokenMasking = config.TokenMaskingConfig{VisiblePrefix: 4, VisibleSuffix: 2}
+	s := &NotificationService{config: cfg}
+
+	resp := s.newRegisterTokenResponse(&model.DeviceToken{PushToken: "abcdefghijkl"}, false)
+
+	assert.Equal(t, "abcd****kl", resp.PushToken)
+}
+
+func TestCanRevealPushToken(t *testing.T) {
+	assert.True(t, CanRevealPushToken("admin", true))
+	assert.False(t, CanRevealPushToken("admin", false))
+	assert.False(t, CanRevealPushToken("user", true))
+	assert.False(t, CanRevealPushToken("", true))
+}
+
+func TestMaskPushToken_ShortTokenFullyHidden(t *testing.T) {
+	assert.Equal(t, "******", model.MaskPushToken("abcdef", 8, 4))
+	assert.Equal(t, "", model.MaskPushToken("", 8, 4))
+}
+
+func TestBuildNotificationReport(t *testing.T) {
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+	day2 := from.AddDate(0, 0, 1)
+
+	rows := []*model.NotificationReportRow{
+		{Day: from, Type: "order", Channel: "expo", Status: "delivered", Count: 5},
+		{Day: from, Type: "order", Channel: "email", Status: "failed", Count: 2},
+		{Day: day2, Type: "promo", Channel: "expo", Status: "delivered", Count: 3},
+		{Day: day2, Type: "order", Channel: "sms", Status: "pending", Count: 1},
+	}
+
+	report := BuildNotificationReport(from, to, rows)
+
+	assert.Equal(t, int64(11), report.Total)
+	assert.Equal(t, map[string]int64{"order": 8, "promo": 3}, report.ByType)
+	assert.Equal(t, map[string]int64{"expo": 8, "email": 2, "sms": 1}, report.ByChannel)
+	assert.Equal(t, map[string]int64{"delivered": 8, "failed": 2, "pending": 1}, report.ByStatus)
+	assert.Len(t, report.Daily, 4)
+	assert.Equal(t, from, report.From)
+	assert.Equal(t, to, report.To)
+}
+
+func TestBuildNotificationReport_Empty(t *testing.T) {
+	report := BuildNotificationReport(time.Now(), time.Now(), nil)
+
+	assert.Zero(t, report.Total)
+	assert.NotNil(t, report.Daily)
+	assert.Empty(t, report.ByType)
+}
+
+func TestGetNotificationReport_InvalidRange(t *testing.T) {
+	s := &NotificationService{}
+	from := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	_, err := s.GetNotificationReport(from, from)
+	require.ErrorIs(t, err, ErrInvalidReportRange)
+
+	_, err = s.GetNotificationReport(from, from.Add(-time.Hour))
+	require.ErrorIs(t, err, ErrInvalidReportRange)
+
+	_, err = s.GetNotificationReport(from, from.Add(MaxReportRange+time.Hour))
+	require.ErrorIs(t, err, ErrInvalidReportRange)
+}
+
+// hangingConn is a database/sql connection whose statements block until
+// their context is done, like a query stuck on a slow database
+type hangingConn struct {
+	started atomic.Int32
+}
+
+func (c *hangingConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *hangingConn) Close() error                              { return nil }
+func (c *hangingConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *hangingConn) Commit() error                             { return nil }
+func (c *hangingConn) Rollback() error                           { return nil }
+
+func (c *hangingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.started.Add(1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (c *hangingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.started.Add(1)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+type hangingConnector struct{ conn *hangingConn }
+
+func (c hangingConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c hangingConnector) Driver() driver.Driver                        { return nil }
+
+func newHangingService(t *testing.T, conn *hangingConn) *NotificationService {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(hangingConnector{conn: conn})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	repo := repository.NewNotificationRepository(&database.Database{DB: db})
+	return &NotificationService{repo: repo, config: &config.ServiceConfig{}}
+}
+
+func TestGetFailedNotifications_CancelAbortsQuery(t *testing.T) {
+	conn := &hangingConn{}
+	s := newHangingService(t, conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		for conn.started.Load() == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		cancel()
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := s.GetFailedNotifications(ctx, "alice", 20, 0, "")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		require.ErrorIs(t, err, context.Canceled)
+		assert.EqualValues(t, 1, conn.started.Load())
+	case <-time.After(5 * time.Second):
+		t.Fatal("query was not aborted by the cancelled context")
+	}
+}
+
+func TestRetryNotification_DeadlineAbortsQuery(t *testing.T) {
+	conn := &hangingConn{}
+	s := newHangingService(t, conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := s.RetryNotification(ctx, 42)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	// The first lookup was aborted, so nothing was reset
+	assert.EqualValues(t, 1, conn.started.Load())
+}
+
+func TestCreateNotification_CancelledContextSkipsInsert(t *testing.T) {
+	conn := &hangingConn{}
+	s := newHangingService(t, conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.CreateNotification(ctx, model.CreateNotificationDTO{
+		Type:    "order",
+		Targets: []model.NotificationTargetDTO{{UserID: "alice"}},
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Zero(t, conn.started.Load(), "no statement runs for a request that is already gone")
+}
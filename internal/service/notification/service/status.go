@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"myapp/internal/service/notification/model"
+)
+
+// WorkerState is the runtime state of the notification worker read by the
+// admin status endpoint; *worker.NotificationWorker implements it
+type WorkerState interface {
+	IsRunning() bool
+	GetQueueLength() int
+	GetQueueCapacity() int
+	GetQueueOldestAge() time.Duration
+	GetInFlight() int64
+	// GetSuccessRate returns the rolling success rate and its attempt count
+	GetSuccessRate() (float64, int64)
+}
+
+// SetWorkerState attaches the worker reported by GetStatus
+// Without one, e.g. in API-only mode, the status has no worker section.
+func (s *NotificationService) SetWorkerState(worker WorkerState) {
+	s.worker = worker
+}
+
+// GetStatus reports the worker, its queue and the delivery backlog
+func (s *NotificationService) GetStatus() (*model.NotificationStatus, error) {
+	counts, err := s.repo.CountDeliveriesByStatus("pending", "processing")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification status: %w", err)
+	}
+	return BuildNotificationStatus(s.worker, counts, time.Now()), nil
+}
+
+// BuildNotificationStatus assembles the status from the worker and the
+// delivery counts by status
+func BuildNotificationStatus(worker WorkerState, counts map[string]int64, now time.Time) *model.NotificationStatus {
+	status := &model.NotificationStatus{
+		PendingCount:    counts["pending"],
+		ProcessingCount: counts["processing"],
+		CheckedAt:       now,
+	}
+	if worker == nil {
+		return status
+	}
+
+	successRate, attempts := worker.GetSuccessRate()
+	status.Worker = &model.WorkerStatus{
+		Running:           worker.IsRunning(),
+		QueueLength:       worker.GetQueueLength(),
+		QueueCapacity:     worker.GetQueueCapacity(),
+		QueueOldestAgeSec: worker.GetQueueOldestAge().Seconds(),
+		InFlight:          worker.GetInFlight(),
+		RecentAttempts:    attempts,
+	}
+	if attempts > 0 {
+		status.Worker.ErrorRate = 1 - successRate
+	}
+	return status
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubWorkerState reports fixed worker values
+type stubWorkerState struct {
+	running     bool
+	queueLength int
+	oldestAge   time.Duration
+	successRate float64
+	attempts    int64
+}
+
+func (s stubWorkerState) IsRunning() bool                  { return s.running }
+func (s stubWorkerState) GetQueueLength() int              { return s.queueLength }
+func (s stubWorkerState) GetQueueCapacity() int            { return 2000 }
+func (s stubWorkerState) GetQueueOldestAge() time.Duration { return s.oldestAge }
+func (s stubWorkerState) GetInFlight() int64               { return 3 }
+func (s stubWorkerState) GetSuccessRate() (float64, int64) { return s.successRate, s.attempts }
+
+func TestBuildNotificationStatus(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	worker := stubWorkerState{
+		running:     true,
+		queueLength: 150,
+		oldestAge:   90 * time.Second,
+		successRate: 0.75,
+		attempts:    40,
+	}
+
+	status := BuildNotificationStatus(worker, map[string]int64{"pending": 1200, "processing": 150}, now)
+
+	assert.Equal(t, int64(1200), status.PendingCount)
+	assert.Equal(t, int64(150), status.ProcessingCount)
+	assert.Equal(t, now, status.CheckedAt)
+
+	require.NotNil(t, status.Worker)
+	assert.True(t, status.Worker.Running)
+	assert.Equal(t, 150, status.Worker.QueueLength)
+	assert.Equal(t, 2000, status.Worker.QueueCapacity)
+	assert.Equal(t, 90.0, status.Worker.QueueOldestAgeSec)
+	assert.Equal(t, int64(3), status.Worker.InFlight)
+	assert.InDelta(t, 0.25, status.Worker.ErrorRate, 1e-9)
+	assert.Equal(t, int64(40), status.Worker.RecentAttempts)
+}
+
+func TestBuildNotificationStatus_IdleWorkerHasNoErrorRate(t *testing.T) {
+	status := BuildNotificationStatus(stubWorkerState{successRate: 1}, nil, time.Now())
+
+	require.NotNil(t, status.Worker)
+	assert.False(t, status.Worker.Running)
+	assert.Zero(t, status.Worker.ErrorRate)
+	assert.Zero(t, status.PendingCount)
+}
+
+func TestBuildNotificationStatus_WithoutWorker(t *testing.T) {
+	status := BuildNotificationStatus(nil, map[string]int64{"pending": 7}, time.Now())
+
+	assert.Nil(t, status.Worker, "API-only mode has no worker section")
+	assert.Equal(t, int64(7), status.PendingCount)
+}
+
+func TestNotificationService_SetWorkerState(t *testing.T) {
+	s := &NotificationService{}
+	s.SetWorkerState(stubWorkerState{running: true})
+
+	assert.True(t, BuildNotificationStatus(s.worker, nil, time.Now()).Worker.Running)
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"myapp/internal/service/notification/model"
+)
+
+// Unknown-user handling modes for notification.unknown_users
+const (
+	UnknownUsersAllow  = "allow"
+	UnknownUsersReject = "reject"
+	UnknownUsersFlag   = "flag"
+)
+
+// UnknownUserError is stored as the delivery error of flagged targets
+// Such deliveries are left out of the failed list and can't be retried.
+const UnknownUserError = model.UndeliverableUnknownUser
+
+// ErrUnknownUsers is returned when targets reference users that don't exist
+var ErrUnknownUsers = errors.New("unknown users")
+
+// UserChecker reports which users exist
+// Implementations are supplied by the deployment, e.g. backed by the user
+// service or its database, and should answer for all IDs in one lookup.
+type UserChecker interface {
+	// ExistingUsers returns the subset of userIDs that exist; the lookup
+	// should stop when ctx, the request's context, is done
+	ExistingUsers(ctx context.Context, userIDs []string) (map[string]bool, error)
+}
+
+// SetUserChecker enables checking target users against checker
+// What happens to unknown users is set by notification.unknown_users.
+func (s *NotificationService) SetUserChecker(checker UserChecker) {
+	s.users = checker
+}
+
+// checkTargetUsers applies the unknown-user mode to targets
+// In reject mode it fails with ErrUnknownUsers listing every unknown user;
+// in flag mode it marks their targets undeliverable.
+func (s *NotificationService) checkTargetUsers(ctx context.Context, targets []*model.NotificationTarget) error {
+	mode := UnknownUsersAllow
+	if s.config != nil && s.config.Notification.UnknownUsers != "" {
+		mode = s.config.Notification.UnknownUsers
+	}
+
+	switch mode {
+	case UnknownUsersAllow:
+		return nil
+	case UnknownUsersReject, UnknownUsersFlag:
+	default:
+		return fmt.Errorf("unknown_users %q must be one of %s, %s or %s",
+			mode, UnknownUsersAllow, UnknownUsersReject, UnknownUsersFlag)
+	}
+	if s.users == nil || len(targets) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool, len(targets))
+	userIDs := make([]string, 0, len(targets))
+	for _, target := range targets {
+		if !seen[target.UserID] {
+			seen[target.UserID] = true
+			userIDs = append(userIDs, target.UserID)
+		}
+	}
+
+	existing, err := s.users.ExistingUsers(ctx, userIDs)
+	if err != nil {
+		return fmt.Errorf("failed to check target users: %w", err)
+	}
+
+	var unknown []string
+	for _, userID := range userIDs {
+		if !existing[userID] {
+			unknown = append(unknown, userID)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	if mode == UnknownUsersReject {
+		return fmt.Errorf("%w: %s", ErrUnknownUsers, strings.Join(unknown, ", "))
+	}
+	for _, target := range targets {
+		if !existing[target.UserID] {
+			target.Undeliverable = UnknownUserError
+		}
+	}
+	return nil
+}
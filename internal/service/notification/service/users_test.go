@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+	"myapp/internal/service/notification/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// fakeUserChecker knows a fixed set of users and records each lookup; a
+// lookup under a done context fails with its error
+type fakeUserChecker struct {
+	users map[string]bool
+	err   error
+	calls [][]string
+}
+
+func (c *fakeUserChecker) ExistingUsers(ctx context.Context, userIDs []string) (map[string]bool, error) {
+	c.calls = append(c.calls, userIDs)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.err != nil {
+		return nil, c.err
+	}
+	existing := make(map[string]bool)
+	for _, id := range userIDs {
+		if c.users[id] {
+			existing[id] = true
+		}
+	}
+	return existing, nil
+}
+
+func newUserCheckService(mode string, checker UserChecker) *NotificationService {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.UnknownUsers = mode
+	s := &NotificationService{config: cfg}
+	s.SetUserChecker(checker)
+	return s
+}
+
+func testTargets(userIDs ...string) []*model.NotificationTarget {
+	targets := make([]*model.NotificationTarget, 0, len(userIDs))
+	for _, id := range userIDs {
+		targets = append(targets, &model.NotificationTarget{UserID: id})
+	}
+	return targets
+}
+
+func TestCreateNotification_RejectsUnknownUsers(t *testing.T) {
+	checker := &fakeUserChecker{users: map[string]bool{"alice": true}}
+	s := newUserCheckService(UnknownUsersReject, checker)
+
+	_, err := s.CreateNotification(context.Background(), model.CreateNotificationDTO{
+		Type: "order",
+		Targets: []model.NotificationTargetDTO{
+			{UserID: "alice"}, {UserID: "ghost"}, {UserID: "ghost"}, {UserID: "nobody"},
+		},
+	})
+
+	require.ErrorIs(t, err, ErrUnknownUsers)
+	assert.Contains(t, err.Error(), "ghost, nobody")
+	// Duplicate users are looked up once, in a single call
+	assert.Equal(t, [][]string{{"alice", "ghost", "nobody"}}, checker.calls)
+}
+
+func TestCheckTargetUsers_FlagsUnknownUsers(t *testing.T) {
+	checker := &fakeUserChecker{users: map[string]bool{"alice": true}}
+	s := newUserCheckService(UnknownUsersFlag, checker)
+	targets := testTargets("alice", "ghost")
+
+	require.NoError(t, s.checkTargetUsers(context.Background(), targets))
+
+	assert.Empty(t, targets[0].Undeliverable)
+	assert.Equal(t, UnknownUserError, targets[1].Undeliverable)
+}
+
+func TestCheckTargetUsers_AllowSkipsLookup(t *testing.T) {
+	checker := &fakeUserChecker{}
+	targets := testTargets("ghost")
+
+	for _, mode := range []string{"", UnknownUsersAllow} {
+		require.NoError(t, newUserCheckService(mode, checker).checkTargetUsers(context.Background(), targets))
+	}
+
+	assert.Empty(t, checker.calls)
+	assert.Empty(t, targets[0].Undeliverable)
+}
+
+func TestCheckTargetUsers_NoChecker(t *testing.T) {
+	s := newUserCheckService(UnknownUsersReject, nil)
+
+	assert.NoError(t, s.checkTargetUsers(context.Background(), testTargets("ghost")))
+}
+
+func TestCheckTargetUsers_CheckerError(t *testing.T) {
+	checker := &fakeUserChecker{err: errors.New("user service unavailable")}
+	s := newUserCheckService(UnknownUsersFlag, checker)
+	targets := testTargets("alice")
+
+	err := s.checkTargetUsers(context.Background(), targets)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrUnknownUsers)
+	assert.Empty(t, targets[0].Undeliverable)
+}
+
+func TestCreateNotification_CancelledRequestStopsUserLookup(t *testing.T) {
+	checker := &fakeUserChecker{users: map[string]bool{"alice": true}}
+	s := newUserCheckService(UnknownUsersReject, checker)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := s.CreateNotification(ctx, model.CreateNotificationDTO{
+		Type:    "order",
+		Targets: []model.NotificationTargetDTO{{UserID: "alice"}},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Len(t, checker.calls, 1)
+}
+
+func TestCheckTargetUsers_InvalidMode(t *testing.T) {
+	s := newUserCheckService("drop", &fakeUserChecker{})
+
+	err := s.checkTargetUsers(context.Background(), testTargets("alice"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown_users "drop"`)
+}
+
+// cannedConn answers SELECTs on a table with fixed columns and one row, and
+// records every other statement
+type cannedConn struct {
+	tables map[string]cannedRow
+	writes []string
+}
+
+type cannedRow struct {
+	columns []string
+	values  []driver.Value
+}
+
+func (c *cannedConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *cannedConn) Close() error                              { return nil }
+func (c *cannedConn) Begin() (driver.Tx, error)                 { return c, nil }
+func (c *cannedConn) Commit() error                             { return nil }
+func (c *cannedConn) Rollback() error                           { return nil }
+
+func (c *cannedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.writes = append(c.writes, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *cannedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	for table, row := range c.tables {
+		if strings.Contains(query, `FROM "`+table+`"`) {
+			return &cannedRows{row: row}, nil
+		}
+	}
+	c.writes = append(c.writes, query)
+	return &cannedRows{done: true}, nil
+}
+
+type cannedRows struct {
+	row  cannedRow
+	done bool
+}
+
+func (r *cannedRows) Columns() []string { return r.row.columns }
+func (r *cannedRows) Close() error      { return nil }
+func (r *cannedRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, r.row.values)
+	return nil
+}
+
+type cannedConnector struct{ conn *cannedConn }
+
+func (c cannedConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c cannedConnector) Driver() driver.Driver                        { return nil }
+
+func newCannedService(t *testing.T, conn *cannedConn) *NotificationService {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(cannedConnector{conn: conn})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	repo := repository.NewNotificationRepository(&database.Database{DB: db})
+	return &NotificationService{repo: repo, config: &config.ServiceConfig{}, logger: &logger.Logger{Logger: zap.NewNop()}}
+}
+
+func TestRetryNotification_RefusesUnknownUser(t *testing.T) {
+	for _, tc := range []struct {
+		lastError string
+		wantErr   bool
+	}{
+		{lastError: UnknownUserError, wantErr: true},
+		{lastError: "provider timeout", wantErr: false},
+	} {
+		conn := &cannedConn{tables: map[string]cannedRow{
+			"notification_target": {
+				columns: []string{"id", "user_id"},
+				values:  []driver.Value{int64(7), "ghost"},
+			},
+			"notification_delivery": {
+				columns: []string{"id", "target_id", "status", "last_error"},
+				values:  []driver.Value{int64(1), int64(7), "failed", tc.lastError},
+			},
+		}}
+		s := newCannedService(t, conn)
+
+		err := s.RetryNotification(context.Background(), 7)
+		if tc.wantErr {
+			require.ErrorIs(t, err, ErrUnknownUsers)
+			assert.Contains(t, err.Error(), "ghost")
+			assert.Empty(t, conn.writes, "a flagged delivery must not be reset")
+		} else {
+			require.NoError(t, err)
+			assert.Len(t, conn.writes, 1)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/worker"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newOrderingTask(deliveryID int64, userID string, createdAt time.Time) *model.NotificationTask {
+	return &model.NotificationTask{
+		DeliveryID:     deliveryID,
+		Delivery:       &model.NotificationDelivery{CreatedAt: createdAt},
+		TargetID:       deliveryID,
+		Target:         &model.NotificationTarget{ID: deliveryID, UserID: userID, CreatedAt: createdAt},
+		NotificationID: deliveryID,
+		Notification:   &model.Notification{ID: deliveryID, Type: "order", CreatedAt: createdAt},
+	}
+}
+
+func TestOrderPerUser_DeliversInCreationOrder(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	queue := NewInMemoryQueue(10)
+	provider := NewInMemoryProvider(queue, nil, log)
+
+	w := worker.New(provider, newWorkerConfig(config.NotificationServiceConfig{
+		WorkerConcurrency: 4,
+		OrderPerUser:      true,
+	}), log)
+
+	var (
+		mu        sync.Mutex
+		delivered []string
+	)
+	firstStarted := make(chan struct{})
+	w.Register("notification", worker.HandlerFunc(func(ctx context.Context, task *worker.Task) error {
+		// "order placed" is slow to send, so "order shipped" would win the
+		// race on another goroutine without per-user ordering
+		if task.Metadata["delivery_id"] == "1" {
+			close(firstStarted)
+			time.Sleep(50 * time.Millisecond)
+		}
+		mu.Lock()
+		delivered = append(delivered, task.Metadata["delivery_id"])
+		mu.Unlock()
+		return nil
+	}))
+
+	created := time.Now()
+	require.True(t, queue.Enqueue(newOrderingTask(1, "user-1", created)))
+
+	// The provider blocks in Fetch, so the worker is stopped by cancelling ctx
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(ctx) }()
+
+	// The second notification arrives while the first is being sent
+	<-firstStarted
+	require.True(t, queue.Enqueue(newOrderingTask(2, "user-1", created.Add(time.Second))))
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(delivered) == 2
+	}, 2*time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-errCh)
+
+	assert.Equal(t, []string{"1", "2"}, delivered)
+}
+
+// requeueProvider hands out tasks in order and puts a requeued task at the
+// back, the way a retried delivery waits behind newer ones for the poller
+type requeueProvider struct {
+	tasks chan *worker.Task
+}
+
+func (p *requeueProvider) Fetch(ctx context.Context) (*worker.Task, error) {
+	select {
+	case task := <-p.tasks:
+		return task, nil
+	default:
+		return nil, nil
+	}
+}
+
+func (p *requeueProvider) Ack(ctx context.Context, task *worker.Task) error { return nil }
+func (p *requeueProvider) Close() error                                     { return nil }
+
+func (p *requeueProvider) Nack(ctx context.Context, task *worker.Task, requeue bool) error {
+	if requeue {
+		p.tasks <- task
+	}
+	return nil
+}
+
+// Ordering is by dequeue, so it doesn't hold across a retry
+func TestOrderPerUser_RetryIsOvertaken(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	provider := &requeueProvider{tasks: make(chan *worker.Task, 10)}
+
+	w := worker.New(provider, newWorkerConfig(config.NotificationServiceConfig{
+		WorkerConcurrency: 4,
+		OrderPerUser:      true,
+	}), log)
+
+	var (
+		mu        sync.Mutex
+		delivered []string
+		failed    bool
+	)
+	w.Register("notification", worker.HandlerFunc(func(ctx context.Context, task *worker.Task) error {
+		mu.Lock()
+		defer mu.Unlock()
+		// "order placed" fails once and is retried
+		if task.Metadata["delivery_id"] == "1" && !failed {
+			failed = true
+			return errors.New("provider unavailable")
+		}
+		delivered = append(delivered, task.Metadata["delivery_id"])
+		return nil
+	}))
+
+	for _, id := range []string{"1", "2"} {
+		provider.tasks <- &worker.Task{
+			ID:       id,
+			Metadata: map[string]string{"delivery_id": id, "user_id": "user-1"},
+			MaxRetry: 3,
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(ctx) }()
+
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(delivered) == 2
+	}, 2*time.Second, time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-errCh)
+
+	// "order shipped" went out before the retried "order placed"
+	assert.Equal(t, []string{"2", "1"}, delivered)
+}
+
+func TestNewWorkerConfig_OrderPerUser(t *testing.T) {
+	cfg := config.NotificationServiceConfig{WorkerConcurrency: 4}
+	assert.Nil(t, newWorkerConfig(cfg).PartitionKey)
+
+	cfg.OrderPerUser = true
+	key := newWorkerConfig(cfg).PartitionKey
+	require.NotNil(t, key)
+	assert.Equal(t, "user-1", key(&worker.Task{Metadata: map[string]string{"user_id": "user-1"}}))
+}
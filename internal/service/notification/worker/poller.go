@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"myapp/internal/pkg/database"
@@ -15,22 +16,42 @@ import (
 	"go.uber.org/zap"
 )
 
+// unhealthyAfterPollErrors is the number of consecutive poll errors after
+// which the poller reports itself unhealthy
+const unhealthyAfterPollErrors = 3
+
+// pollerRepository is the subset of the repository the poller depends on
+type pollerRepository interface {
+	GetPendingDeliveries(limit, maxPerUser int) ([]*model.PendingNotification, error)
+	MarkDeliveriesAsProcessing(deliveryIDs []int64) error
+	ResetDeliveryStatus(targetID int64) error
+}
+
 // NotificationPoller polls the database for pending notifications
 type NotificationPoller struct {
 	db              *database.Database
-	repo            *repository.NotificationRepository
+	repo            pollerRepository
 	queue           *InMemoryQueue
 	config          *config.ServiceConfig
 	logger          *logger.Logger
 	pollInterval    time.Duration
 	batchSize       int
+	maxPerUser      int
 	backoffInterval time.Duration
+	maxErrorBackoff time.Duration
+	stats           PollerStats
 	stopCh          chan struct{}
 	wg              sync.WaitGroup
 	mu              sync.RWMutex
 	running         bool
 }
 
+// PollerStats holds poller error statistics
+type PollerStats struct {
+	PollErrors        int64
+	ConsecutiveErrors int64
+}
+
 // NewNotificationPoller creates a new notification poller
 func NewNotificationPoller(
 	db *database.Database,
@@ -48,7 +69,9 @@ func NewNotificationPoller(
 		logger:          log,
 		pollInterval:    time.Duration(pollerConfig.PollIntervalSec) * time.Second,
 		batchSize:       pollerConfig.BatchSize,
+		maxPerUser:      pollerConfig.MaxPerUser,
 		backoffInterval: time.Duration(pollerConfig.BackoffOnEmptySec) * time.Second,
+		maxErrorBackoff: time.Duration(pollerConfig.MaxErrorBackoffSec) * time.Second,
 		stopCh:          make(chan struct{}),
 		running:         false,
 	}
@@ -67,6 +90,7 @@ func (p *NotificationPoller) Start(ctx context.Context) error {
 	p.logger.Info("Starting notification poller",
 		zap.Duration("poll_interval", p.pollInterval),
 		zap.Int("batch_size", p.batchSize),
+		zap.Int("max_per_user", p.maxPerUser),
 	)
 
 	p.wg.Add(1)
@@ -144,12 +168,31 @@ func (p *NotificationPoller) performPoll(ctx context.Context, emptyCount *int, c
 	}
 
 	// Fetch pending deliveries
-	pending, err := p.repo.GetPendingDeliveries(p.batchSize)
+	pending, err := p.repo.GetPendingDeliveries(p.batchSize, p.maxPerUser)
 	if err != nil {
-		p.logger.Error("Failed to fetch pending deliveries", zap.Error(err))
+		atomic.AddInt64(&p.stats.PollErrors, 1)
+		consecutive := atomic.AddInt64(&p.stats.ConsecutiveErrors, 1)
+
+		// Slow down while the database is failing
+		*currentInterval = p.errorBackoff(consecutive)
+		ticker.Reset(*currentInterval)
+
+		p.logger.Error("Failed to fetch pending deliveries",
+			zap.Error(err),
+			zap.Int64("consecutive_errors", consecutive),
+			zap.Duration("next_poll_in", *currentInterval),
+		)
 		return
 	}
 
+	if failed := atomic.SwapInt64(&p.stats.ConsecutiveErrors, 0); failed > 0 {
+		*currentInterval = p.pollInterval
+		ticker.Reset(*currentInterval)
+		p.logger.Info("Pending deliveries fetch recovered",
+			zap.Int64("failed_polls", failed),
+		)
+	}
+
 	duration := time.Since(startTime)
 
 	if len(pending) == 0 {
@@ -222,3 +265,29 @@ func (p *NotificationPoller) IsRunning() bool {
 	defer p.mu.RUnlock()
 	return p.running
 }
+
+// errorBackoff returns the poll interval after the given number of consecutive
+// errors, doubling the normal interval each time up to maxErrorBackoff
+func (p *NotificationPoller) errorBackoff(consecutive int64) time.Duration {
+	interval := p.pollInterval
+	for i := int64(0); i < consecutive && interval < p.maxErrorBackoff; i++ {
+		interval *= 2
+	}
+	if p.maxErrorBackoff > 0 && interval > p.maxErrorBackoff {
+		interval = p.maxErrorBackoff
+	}
+	return interval
+}
+
+// Stats returns poller error statistics
+func (p *NotificationPoller) Stats() PollerStats {
+	return PollerStats{
+		PollErrors:        atomic.LoadInt64(&p.stats.PollErrors),
+		ConsecutiveErrors: atomic.LoadInt64(&p.stats.ConsecutiveErrors),
+	}
+}
+
+// IsHealthy returns false once several polls in a row have failed
+func (p *NotificationPoller) IsHealthy() bool {
+	return atomic.LoadInt64(&p.stats.ConsecutiveErrors) < unhealthyAfterPollErrors
+}
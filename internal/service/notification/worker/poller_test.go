@@ -0,0 +1,85 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// stubPollerRepository fails the first failures calls to GetPendingDeliveries
+type stubPollerRepository struct {
+	failures int
+	calls    int
+}
+
+func (r *stubPollerRepository) GetPendingDeliveries(limit, maxPerUser int) ([]*model.PendingNotification, error) {
+	r.calls++
+	if r.calls <= r.failures {
+		return nil, errors.New("connection refused")
+	}
+	return nil, nil
+}
+
+func (r *stubPollerRepository) MarkDeliveriesAsProcessing(deliveryIDs []int64) error {
+	return nil
+}
+
+func (r *stubPollerRepository) ResetDeliveryStatus(targetID int64) error {
+	return nil
+}
+
+func TestNotificationPoller_BacksOffOnDBErrors(t *testing.T) {
+	repo := &stubPollerRepository{failures: 4}
+	p := &NotificationPoller{
+		repo:            repo,
+		queue:           NewInMemoryQueue(10),
+		logger:          &logger.Logger{Logger: zap.NewNop()},
+		pollInterval:    time.Second,
+		batchSize:       10,
+		backoffInterval: 30 * time.Second,
+		maxErrorBackoff: 5 * time.Second,
+	}
+
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	ctx := context.Background()
+	interval := p.pollInterval
+	emptyCount := 0
+
+	// Interval doubles on each consecutive error, capped at maxErrorBackoff
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, expected := range want {
+		p.performPoll(ctx, &emptyCount, &interval, ticker)
+		assert.Equal(t, expected, interval, "poll %d", i+1)
+	}
+
+	stats := p.Stats()
+	assert.Equal(t, int64(4), stats.PollErrors)
+	assert.Equal(t, int64(4), stats.ConsecutiveErrors)
+	assert.False(t, p.IsHealthy())
+
+	// First successful poll restores the normal interval
+	p.performPoll(ctx, &emptyCount, &interval, ticker)
+	assert.Equal(t, time.Second, interval)
+
+	stats = p.Stats()
+	assert.Equal(t, int64(4), stats.PollErrors)
+	assert.Equal(t, int64(0), stats.ConsecutiveErrors)
+	assert.True(t, p.IsHealthy())
+}
+
+func TestNotificationPoller_ErrorBackoff(t *testing.T) {
+	p := &NotificationPoller{pollInterval: 5 * time.Second, maxErrorBackoff: time.Minute}
+
+	assert.Equal(t, 10*time.Second, p.errorBackoff(1))
+	assert.Equal(t, 40*time.Second, p.errorBackoff(3))
+	assert.Equal(t, time.Minute, p.errorBackoff(10))
+}
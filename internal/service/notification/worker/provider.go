@@ -3,7 +3,9 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"myapp/internal/pkg/logger"
 	"myapp/internal/pkg/worker"
@@ -13,11 +15,31 @@ import (
 	"go.uber.org/zap"
 )
 
+// DefaultTaskType is the task type notifications use unless
+// notification.task_type overrides it
+const DefaultTaskType = "notification"
+
+// ErrTaskTypeMismatch is returned when the provider enqueues notifications
+// under a task type the worker has no handler for
+var ErrTaskTypeMismatch = errors.New("notification task type mismatch")
+
+// taskTyper is implemented by providers that set the task type themselves
+type taskTyper interface {
+	TaskType() string
+}
+
+// providerRepository is the subset of the repository the provider depends on
+type providerRepository interface {
+	ResetDeliveryStatus(targetID int64) error
+	ScheduleDeliveryRetry(targetID int64, at time.Time) error
+}
+
 // InMemoryProvider implements worker.Provider interface for in-memory queue
 type InMemoryProvider struct {
-	queue  *InMemoryQueue
-	repo   *repository.NotificationRepository
-	logger *logger.Logger
+	queue    *InMemoryQueue
+	repo     providerRepository
+	logger   *logger.Logger
+	taskType string
 }
 
 // NewInMemoryProvider creates a new in-memory provider
@@ -27,12 +49,26 @@ func NewInMemoryProvider(
 	log *logger.Logger,
 ) *InMemoryProvider {
 	return &InMemoryProvider{
-		queue:  queue,
-		repo:   repo,
-		logger: log,
+		queue:    queue,
+		repo:     repo,
+		logger:   log,
+		taskType: DefaultTaskType,
+	}
+}
+
+// SetTaskType sets the task type enqueued tasks carry
+// It must match the type the NotificationWorker registers its handler under.
+func (p *InMemoryProvider) SetTaskType(taskType string) {
+	if taskType != "" {
+		p.taskType = taskType
 	}
 }
 
+// TaskType returns the task type enqueued tasks carry
+func (p *InMemoryProvider) TaskType() string {
+	return p.taskType
+}
+
 // Fetch retrieves the next task from the queue
 func (p *InMemoryProvider) Fetch(ctx context.Context) (*worker.Task, error) {
 	select {
@@ -56,6 +92,8 @@ func (p *InMemoryProvider) Ack(ctx context.Context, task *worker.Task) error {
 }
 
 // Nack negatively acknowledges a task
+// A requeued delivery goes back to pending; with a ScheduledAt in the future
+// the poller leaves it alone until then, so the retry backoff holds.
 func (p *InMemoryProvider) Nack(ctx context.Context, task *worker.Task, requeue bool) error {
 	// Extract delivery ID from metadata
 	deliveryIDStr := task.Metadata["delivery_id"]
@@ -80,7 +118,13 @@ func (p *InMemoryProvider) Nack(ctx context.Context, task *worker.Task, requeue
 			return fmt.Errorf("invalid target_id: %w", err)
 		}
 
-		if err := p.repo.ResetDeliveryStatus(targetID); err != nil {
+		var err error
+		if task.ScheduledAt.After(time.Now()) {
+			err = p.repo.ScheduleDeliveryRetry(targetID, task.ScheduledAt)
+		} else {
+			err = p.repo.ResetDeliveryStatus(targetID)
+		}
+		if err != nil {
 			p.logger.Error("Failed to reset delivery status for retry",
 				zap.Int64("delivery_id", deliveryID),
 				zap.Int64("target_id", targetID),
@@ -92,11 +136,13 @@ func (p *InMemoryProvider) Nack(ctx context.Context, task *worker.Task, requeue
 		p.logger.Info("Delivery reset to pending for retry",
 			zap.Int64("delivery_id", deliveryID),
 			zap.Int64("target_id", targetID),
+			zap.Time("scheduled_at", task.ScheduledAt),
 		)
 	} else {
 		// Mark as failed (already done by worker, but log it)
 		p.logger.Info("Delivery marked as failed",
 			zap.Int64("delivery_id", deliveryID),
+			zap.String("last_error", task.LastError),
 		)
 	}
 
@@ -110,6 +156,43 @@ func (p *InMemoryProvider) Close() error {
 	return nil
 }
 
+// Shutdown drains tasks still in the queue and resets their deliveries to
+// pending so the poller picks them up again after restart
+func (p *InMemoryProvider) Shutdown(ctx context.Context) error {
+	reset := 0
+	for {
+		select {
+		case <-ctx.Done():
+			p.logger.Warn("Shutdown drain interrupted",
+				zap.Int("reset", reset),
+				zap.Int("remaining", p.queue.Length()),
+			)
+			return ctx.Err()
+		default:
+		}
+
+		task := p.queue.Dequeue()
+		if task == nil {
+			break
+		}
+
+		if err := p.repo.ResetDeliveryStatus(task.TargetID); err != nil {
+			p.logger.Error("Failed to reset queued delivery on shutdown",
+				zap.Int64("delivery_id", task.DeliveryID),
+				zap.Int64("target_id", task.TargetID),
+				zap.Error(err),
+			)
+			continue
+		}
+		reset++
+	}
+
+	if reset > 0 {
+		p.logger.Info("Reset queued deliveries to pending on shutdown", zap.Int("count", reset))
+	}
+	return nil
+}
+
 // convertToWorkerTask converts NotificationTask to worker.Task
 func (p *InMemoryProvider) convertToWorkerTask(nt *model.NotificationTask) *worker.Task {
 	// Build payload
@@ -122,6 +205,9 @@ func (p *InMemoryProvider) convertToWorkerTask(nt *model.NotificationTask) *work
 		CreatedAt: nt.Target.CreatedAt,
 		TraceID:   nt.Notification.TraceID,
 	}
+	if email, ok := nt.Target.Payload["email"].(string); ok {
+		payload.Email = email
+	}
 
 	payloadBytes, _ := json.Marshal(payload)
 
@@ -131,7 +217,7 @@ func (p *InMemoryProvider) convertToWorkerTask(nt *model.NotificationTask) *work
 		"target_id":         fmt.Sprintf("%d", nt.TargetID),
 		"notification_id":   fmt.Sprintf("%d", nt.NotificationID),
 		"user_id":           nt.Target.UserID,
-		"type":              "notification",
+		"type":              p.taskType,
 		"notification_type": nt.Notification.Type,
 		"priority":          fmt.Sprintf("%d", nt.Notification.Priority),
 		"trace_id":          nt.Notification.TraceID,
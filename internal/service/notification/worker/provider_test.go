@@ -0,0 +1,148 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/worker"
+	"myapp/internal/service/notification/channel"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+	"myapp/internal/service/notification/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// retryRecorder records how requeued deliveries were put back
+type retryRecorder struct {
+	mu        sync.Mutex
+	reset     []int64
+	scheduled map[int64]time.Time
+}
+
+func (r *retryRecorder) ResetDeliveryStatus(targetID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reset = append(r.reset, targetID)
+	return nil
+}
+
+func (r *retryRecorder) ScheduleDeliveryRetry(targetID int64, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.scheduled == nil {
+		r.scheduled = make(map[int64]time.Time)
+	}
+	r.scheduled[targetID] = at
+	return nil
+}
+
+func (r *retryRecorder) scheduledAt(targetID int64) (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	at, ok := r.scheduled[targetID]
+	return at, ok
+}
+
+func newRecordingProvider(queue *InMemoryQueue) (*InMemoryProvider, *retryRecorder) {
+	repo := &retryRecorder{}
+	provider := NewInMemoryProvider(queue, nil, &logger.Logger{Logger: zap.NewNop()})
+	provider.repo = repo
+	return provider, repo
+}
+
+func TestInMemoryProvider_NackHonoursScheduledAt(t *testing.T) {
+	provider, repo := newRecordingProvider(NewInMemoryQueue(1))
+	ctx := context.Background()
+
+	later := provider.convertToWorkerTask(newOrderingTask(1, "user-1", time.Now()))
+	later.ScheduledAt = time.Now().Add(time.Minute)
+	require.NoError(t, provider.Nack(ctx, later, true))
+
+	now := provider.convertToWorkerTask(newOrderingTask(2, "user-1", time.Now()))
+	require.NoError(t, provider.Nack(ctx, now, true))
+
+	at, ok := repo.scheduledAt(1)
+	require.True(t, ok)
+	assert.True(t, at.Equal(later.ScheduledAt))
+	assert.Equal(t, []int64{2}, repo.reset)
+}
+
+// TestNotificationWorker_FailingChannelWaitsForBackoff fails a send with the
+// channel's backoff; the delivery must be held back for that long rather
+// than go straight back to pending for the next poll
+func TestNotificationWorker_FailingChannelWaitsForBackoff(t *testing.T) {
+	queue := NewInMemoryQueue(10)
+	provider, repo := newRecordingProvider(queue)
+
+	nw := newOverrideWorker()
+	w := worker.New(provider, newWorkerConfig(nw.config.Notification), &logger.Logger{Logger: zap.NewNop()})
+	w.Register(DefaultTaskType, worker.HandlerFunc(func(ctx context.Context, task *worker.Task) error {
+		return nw.withChannelBackoff("email", errors.New("smtp unavailable"))
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(ctx) }()
+
+	failedAt := time.Now()
+	require.True(t, queue.Enqueue(newOrderingTask(1, "user-1", failedAt)))
+
+	require.Eventually(t, func() bool {
+		_, ok := repo.scheduledAt(1)
+		return ok
+	}, 2*time.Second, 5*time.Millisecond)
+
+	at, _ := repo.scheduledAt(1)
+	assert.False(t, at.Before(failedAt.Add(300*time.Second)), "retry scheduled at %v, before the email backoff", at)
+	assert.Empty(t, repo.reset, "the delivery was made due again immediately")
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestSendNotification_BusyChannelIsRequeued(t *testing.T) {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.WorkerConcurrency = 10
+	cfg.Notification.Senders.SMS = config.SMSConfig{
+		Enabled:    true,
+		APIURL:     "http://127.0.0.1:0",
+		AccountSID: "AC123",
+		AuthToken:  "token",
+		FromNumber: "+15550000000",
+	}
+	cfg.Notification.ChannelOverrides = map[string]config.ChannelOverride{
+		"sms": {Concurrency: 1},
+	}
+	log := &logger.Logger{Logger: zap.NewNop()}
+	// The send is requeued before the repository is used, so it needs no database
+	registry, err := channel.NewChannelRegistry(cfg, log, repository.NewNotificationRepository(nil))
+	require.NoError(t, err)
+
+	w := &NotificationWorker{
+		config:          cfg,
+		logger:          log,
+		channelRegistry: registry,
+		channelLimits:   newChannelLimits(cfg.Notification),
+	}
+
+	release, ok := w.acquireChannel("sms")
+	require.True(t, ok)
+	defer release()
+
+	// With no repository, counting an attempt would panic
+	target := &model.NotificationTarget{ID: 1, Payload: model.JSONB{"sender_type": "sms"}}
+	_, err = w.sendNotification(context.Background(), target, model.NotificationPayload{}, 1)
+
+	var requeueErr *worker.RequeueError
+	require.ErrorAs(t, err, &requeueErr)
+	assert.ErrorIs(t, err, ErrChannelBusy)
+	assert.Equal(t, channelBusyRequeueDelay, requeueErr.After)
+}
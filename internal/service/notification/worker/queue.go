@@ -3,6 +3,7 @@ package worker
 import (
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"myapp/internal/service/notification/model"
 )
@@ -13,6 +14,10 @@ type InMemoryQueue struct {
 	mu    sync.RWMutex
 	size  int
 	stats QueueStats
+
+	// enqueuedAt holds enqueue times in a ring indexed by enqueue count,
+	// so the head of the FIFO channel can be dated without reading it
+	enqueuedAt []time.Time
 }
 
 // QueueStats holds queue statistics
@@ -26,17 +31,22 @@ type QueueStats struct {
 // NewInMemoryQueue creates a new in-memory queue
 func NewInMemoryQueue(size int) *InMemoryQueue {
 	return &InMemoryQueue{
-		queue: make(chan *model.NotificationTask, size),
-		size:  size,
-		stats: QueueStats{},
+		queue:      make(chan *model.NotificationTask, size),
+		size:       size,
+		stats:      QueueStats{},
+		enqueuedAt: make([]time.Time, max(size, 1)),
 	}
 }
 
 // Enqueue adds a task to the queue
 // Returns true if successfully enqueued, false if queue is full
 func (q *InMemoryQueue) Enqueue(task *model.NotificationTask) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
 	select {
 	case q.queue <- task:
+		q.enqueuedAt[q.stats.Enqueued%int64(len(q.enqueuedAt))] = time.Now()
 		atomic.AddInt64(&q.stats.Enqueued, 1)
 		atomic.AddInt64(&q.stats.Length, 1)
 		return true
@@ -79,6 +89,22 @@ func (q *InMemoryQueue) Capacity() int {
 	return q.size
 }
 
+// OldestAge returns how long the task at the head of the queue has waited,
+// or 0 when the queue is empty
+// Tasks leave in enqueue order, so the head is the task enqueued Length()
+// enqueues ago. Under concurrent reads the result may be slightly off.
+func (q *InMemoryQueue) OldestAge() time.Duration {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	length := int64(len(q.queue))
+	if length == 0 {
+		return 0
+	}
+	head := atomic.LoadInt64(&q.stats.Enqueued) - length
+	return time.Since(q.enqueuedAt[head%int64(len(q.enqueuedAt))])
+}
+
 // IsFull returns true if queue is full
 func (q *InMemoryQueue) IsFull() bool {
 	return len(q.queue) >= q.size
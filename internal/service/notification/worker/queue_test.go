@@ -0,0 +1,37 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"myapp/internal/service/notification/model"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInMemoryQueue_OldestAge(t *testing.T) {
+	q := NewInMemoryQueue(2)
+	assert.Zero(t, q.OldestAge(), "an empty queue has no oldest task")
+
+	require.True(t, q.Enqueue(&model.NotificationTask{DeliveryID: 1}))
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, q.Enqueue(&model.NotificationTask{DeliveryID: 2}))
+
+	assert.GreaterOrEqual(t, q.OldestAge(), 30*time.Millisecond)
+
+	// Reading the channel directly, as the provider does, moves the head on
+	<-q.GetChannel()
+	assert.Less(t, q.OldestAge(), 30*time.Millisecond)
+
+	// The ring wraps around once earlier slots are free
+	time.Sleep(30 * time.Millisecond)
+	require.True(t, q.Enqueue(&model.NotificationTask{DeliveryID: 3}))
+	assert.GreaterOrEqual(t, q.OldestAge(), 30*time.Millisecond)
+
+	<-q.GetChannel()
+	assert.Less(t, q.OldestAge(), 30*time.Millisecond)
+
+	<-q.GetChannel()
+	assert.Zero(t, q.OldestAge())
+}
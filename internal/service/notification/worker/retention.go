@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"myapp/internal/pkg/logger"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/repository"
+
+	"go.uber.org/zap"
+)
+
+// retentionRepository is the subset of the repository the cleaner depends on
+type retentionRepository interface {
+	DeleteExpiredFailedDeliveries(cutoff time.Time, limit int, archive bool) (int64, error)
+}
+
+// RetentionCleaner removes failed deliveries once they are older than the
+// configured retention, so GetPendingFailedForUser doesn't grow forever
+type RetentionCleaner struct {
+	repo      retentionRepository
+	logger    *logger.Logger
+	retention time.Duration
+	interval  time.Duration
+	batchSize int
+	archive   bool
+	now       func() time.Time
+}
+
+// NewRetentionCleaner creates a cleaner from the failed_retention config
+func NewRetentionCleaner(
+	repo *repository.NotificationRepository,
+	config *config.ServiceConfig,
+	log *logger.Logger,
+) *RetentionCleaner {
+	cfg := config.Notification.FailedRetention
+	return &RetentionCleaner{
+		repo:      repo,
+		logger:    log,
+		retention: time.Duration(cfg.Days) * 24 * time.Hour,
+		interval:  time.Duration(cfg.IntervalMinutes) * time.Minute,
+		batchSize: cfg.BatchSize,
+		archive:   cfg.Archive,
+		now:       time.Now,
+	}
+}
+
+// Enabled reports whether a retention period is configured
+func (c *RetentionCleaner) Enabled() bool {
+	return c.retention > 0
+}
+
+// Run cleans up immediately and then every interval until ctx is done
+func (c *RetentionCleaner) Run(ctx context.Context) {
+	if !c.Enabled() {
+		return
+	}
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := c.Cleanup(ctx); err != nil {
+			c.logger.Error("Failed to clean up expired failed deliveries", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Cleanup removes every failed delivery past the retention, one batch at a
+// time, and returns how many were removed. It stops early when ctx is done.
+func (c *RetentionCleaner) Cleanup(ctx context.Context) (int64, error) {
+	if !c.Enabled() {
+		return 0, nil
+	}
+
+	cutoff := c.now().Add(-c.retention)
+	var total int64
+	for ctx.Err() == nil {
+		removed, err := c.repo.DeleteExpiredFailedDeliveries(cutoff, c.batchSize, c.archive)
+		total += removed
+		if err != nil {
+			return total, err
+		}
+		if removed < int64(c.batchSize) {
+			break
+		}
+	}
+
+	if total > 0 {
+		c.logger.Info("Removed expired failed deliveries",
+			zap.Int64("count", total),
+			zap.Time("cutoff", cutoff),
+			zap.Bool("archived", c.archive),
+		)
+	}
+	return total, ctx.Err()
+}
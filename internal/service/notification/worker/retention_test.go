@@ -0,0 +1,93 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// fakeRetentionRepo removes up to limit of its remaining expired rows per call
+type fakeRetentionRepo struct {
+	remaining int64
+	err       error
+	cutoffs   []time.Time
+	archived  []bool
+}
+
+func (r *fakeRetentionRepo) DeleteExpiredFailedDeliveries(cutoff time.Time, limit int, archive bool) (int64, error) {
+	r.cutoffs = append(r.cutoffs, cutoff)
+	r.archived = append(r.archived, archive)
+	if r.err != nil {
+		return 0, r.err
+	}
+	removed := min(r.remaining, int64(limit))
+	r.remaining -= removed
+	return removed, nil
+}
+
+func newTestCleaner(repo retentionRepository, days, batchSize int) *RetentionCleaner {
+	return &RetentionCleaner{
+		repo:      repo,
+		logger:    &logger.Logger{Logger: zap.NewNop()},
+		retention: time.Duration(days) * 24 * time.Hour,
+		interval:  time.Hour,
+		batchSize: batchSize,
+		archive:   true,
+		now:       func() time.Time { return time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC) },
+	}
+}
+
+func TestRetentionCleaner_RemovesInBatches(t *testing.T) {
+	repo := &fakeRetentionRepo{remaining: 250}
+	cleaner := newTestCleaner(repo, 30, 100)
+
+	removed, err := cleaner.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.EqualValues(t, 250, removed)
+
+	// Two full batches and a short one that ends the run, all with one cutoff
+	require.Len(t, repo.cutoffs, 3)
+	cutoff := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range repo.cutoffs {
+		assert.Equal(t, cutoff, repo.cutoffs[i])
+		assert.True(t, repo.archived[i])
+	}
+}
+
+func TestRetentionCleaner_Disabled(t *testing.T) {
+	repo := &fakeRetentionRepo{remaining: 10}
+	cleaner := newTestCleaner(repo, 0, 100)
+
+	removed, err := cleaner.Cleanup(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, removed)
+	assert.Empty(t, repo.cutoffs, "zero days keeps failed deliveries forever")
+}
+
+func TestRetentionCleaner_Error(t *testing.T) {
+	repo := &fakeRetentionRepo{err: errors.New("connection refused")}
+	cleaner := newTestCleaner(repo, 30, 100)
+
+	_, err := cleaner.Cleanup(context.Background())
+	assert.ErrorContains(t, err, "connection refused")
+	assert.Len(t, repo.cutoffs, 1)
+}
+
+func TestRetentionCleaner_StopsWhenCanceled(t *testing.T) {
+	repo := &fakeRetentionRepo{remaining: 1000}
+	cleaner := newTestCleaner(repo, 30, 100)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := cleaner.Cleanup(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, repo.cutoffs)
+}
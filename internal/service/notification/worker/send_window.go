@@ -0,0 +1,83 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"myapp/internal/pkg/rate"
+	"myapp/internal/service/notification/channel"
+	"myapp/internal/service/notification/config"
+)
+
+// sendWindow paces one channel's sends
+type sendWindow struct {
+	limiter rate.Limiter
+	burst   int
+}
+
+// newSendWindows builds a token bucket for every channel with a sends_per_second
+// override. Each bucket refills one send every 1/sends_per_second and holds up
+// to send_burst sends (at least 1), so a burst is spread out instead of
+// tripping the provider's rate limit.
+func newSendWindows(cfg config.NotificationServiceConfig) (map[string]*sendWindow, error) {
+	windows := make(map[string]*sendWindow)
+	for name, override := range cfg.ChannelOverrides {
+		if override.SendsPerSecond <= 0 {
+			continue
+		}
+
+		burst := max(override.SendBurst, 1)
+		limiter, err := rate.New(&rate.Config{
+			Strategy: rate.StrategyTokenBucket,
+			Rate:     1,
+			Burst:    burst,
+			Interval: time.Duration(float64(time.Second) / override.SendsPerSecond),
+			TTL:      time.Minute,
+		}, rate.NewMemoryStorage())
+		if err != nil {
+			closeSendWindows(windows)
+			return nil, fmt.Errorf("%w: %s send window: %v", config.ErrInvalidChannelOverride, name, err)
+		}
+		windows[name] = &sendWindow{limiter: limiter, burst: burst}
+	}
+	return windows, nil
+}
+
+// closeSendWindows releases the limiters' storage
+func closeSendWindows(windows map[string]*sendWindow) {
+	for _, window := range windows {
+		window.limiter.Close()
+	}
+}
+
+// sendPacer returns the pacer for a channel's sends, nil when it is unpaced
+// The channel calls it for every message it sends, so a delivery to several
+// tokens takes one send per token.
+func (w *NotificationWorker) sendPacer(name string) channel.SendPacer {
+	if _, ok := w.sendWindows[name]; !ok {
+		return nil
+	}
+	return func(ctx context.Context, n int) error {
+		return w.waitSendWindow(ctx, name, n)
+	}
+}
+
+// waitSendWindow blocks until the channel's pacing allows n more sends
+// More sends than the burst are taken a burst at a time. Channels without a
+// sends_per_second override return immediately.
+func (w *NotificationWorker) waitSendWindow(ctx context.Context, name string, n int) error {
+	window, ok := w.sendWindows[name]
+	if !ok {
+		return nil
+	}
+
+	for n > 0 {
+		take := min(n, window.burst)
+		if err := window.limiter.WaitN(ctx, name, take); err != nil {
+			return fmt.Errorf("waiting for %s send window: %w", name, err)
+		}
+		n -= take
+	}
+	return nil
+}
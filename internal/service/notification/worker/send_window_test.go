@@ -0,0 +1,128 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"myapp/internal/service/notification/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSendWindowWorker(t *testing.T, overrides map[string]config.ChannelOverride) *NotificationWorker {
+	t.Helper()
+
+	windows, err := newSendWindows(config.NotificationServiceConfig{ChannelOverrides: overrides})
+	require.NoError(t, err)
+	t.Cleanup(func() { closeSendWindows(windows) })
+
+	return &NotificationWorker{sendWindows: windows}
+}
+
+func TestWaitSendWindow_PacesToConfiguredRate(t *testing.T) {
+	w := newSendWindowWorker(t, map[string]config.ChannelOverride{
+		"expo": {SendsPerSecond: 20, SendBurst: 1},
+	})
+
+	// One immediate send, then one every 50ms
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		require.NoError(t, w.waitSendWindow(context.Background(), "expo", 1))
+	}
+	elapsed := time.Since(start)
+
+	assert.GreaterOrEqual(t, elapsed, 190*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
+
+func TestWaitSendWindow_SpreadsConcurrentBurst(t *testing.T) {
+	w := newSendWindowWorker(t, map[string]config.ChannelOverride{
+		"fcm": {SendsPerSecond: 20, SendBurst: 2},
+	})
+
+	var (
+		mu    sync.Mutex
+		sends []time.Duration
+		wg    sync.WaitGroup
+	)
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, w.waitSendWindow(context.Background(), "fcm", 1))
+			mu.Lock()
+			sends = append(sends, time.Since(start))
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	// The burst of 2 goes out at once; the other 4 follow at 50ms intervals
+	immediate := 0
+	for _, at := range sends {
+		if at < 25*time.Millisecond {
+			immediate++
+		}
+	}
+	assert.Equal(t, 2, immediate)
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+}
+
+func TestWaitSendWindow_UnpacedChannel(t *testing.T) {
+	w := newSendWindowWorker(t, map[string]config.ChannelOverride{
+		"expo": {SendsPerSecond: 1},
+		"sms":  {Concurrency: 1},
+	})
+
+	start := time.Now()
+	for i := 0; i < 100; i++ {
+		require.NoError(t, w.waitSendWindow(context.Background(), "sms", 1))
+	}
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+}
+
+func TestWaitSendWindow_ContextCancelled(t *testing.T) {
+	w := newSendWindowWorker(t, map[string]config.ChannelOverride{
+		"expo": {SendsPerSecond: 0.1},
+	})
+	require.NoError(t, w.waitSendWindow(context.Background(), "expo", 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	err := w.waitSendWindow(ctx, "expo", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestWaitSendWindow_CountsEveryToken(t *testing.T) {
+	w := newSendWindowWorker(t, map[string]config.ChannelOverride{
+		"expo": {SendsPerSecond: 20, SendBurst: 2},
+	})
+
+	// Five tokens take as long as five sends: the burst of 2, then 3 at 50ms
+	// intervals, even though they are more than one wait may take at once
+	start := time.Now()
+	require.NoError(t, w.waitSendWindow(context.Background(), "expo", 5))
+	elapsed := time.Since(start)
+
+	assert.GreaterOrEqual(t, elapsed, 140*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+}
+
+func TestSendPacer(t *testing.T) {
+	w := newSendWindowWorker(t, map[string]config.ChannelOverride{
+		"expo": {SendsPerSecond: 20, SendBurst: 1},
+	})
+	assert.Nil(t, w.sendPacer("sms"))
+
+	pacer := w.sendPacer("expo")
+	require.NotNil(t, pacer)
+
+	start := time.Now()
+	require.NoError(t, pacer(context.Background(), 3))
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+}
@@ -0,0 +1,88 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+// successRateBuckets is the number of buckets a window is split into
+const successRateBuckets = 60
+
+// DefaultSuccessRateWindow is used when no window is configured
+const DefaultSuccessRateWindow = 5 * time.Minute
+
+// rateBucket counts delivery attempts within one slice of the window
+type rateBucket struct {
+	start     int64 // bucket start, in bucket widths since the epoch
+	successes int64
+	attempts  int64
+}
+
+// SuccessRateTracker keeps a rolling delivery success rate over a time window
+// The window is split into fixed buckets, so memory stays constant under load
+// and old attempts expire a bucket at a time.
+type SuccessRateTracker struct {
+	mu          sync.Mutex
+	bucketWidth time.Duration
+	buckets     []rateBucket
+	now         func() time.Time
+}
+
+// NewSuccessRateTracker creates a tracker over the given window
+func NewSuccessRateTracker(window time.Duration) *SuccessRateTracker {
+	if window <= 0 {
+		window = DefaultSuccessRateWindow
+	}
+
+	bucketWidth := window / successRateBuckets
+	if bucketWidth <= 0 {
+		bucketWidth = 1
+	}
+
+	return &SuccessRateTracker{
+		bucketWidth: bucketWidth,
+		buckets:     make([]rateBucket, successRateBuckets),
+		now:         time.Now,
+	}
+}
+
+// Record counts one delivery attempt
+func (t *SuccessRateTracker) Record(success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	start := t.now().UnixNano() / int64(t.bucketWidth)
+	bucket := &t.buckets[start%int64(len(t.buckets))]
+	if bucket.start != start {
+		*bucket = rateBucket{start: start}
+	}
+
+	bucket.attempts++
+	if success {
+		bucket.successes++
+	}
+}
+
+// Rate returns successes/attempts within the window and the attempt count
+// With no attempts the rate is 1, so an idle worker is not reported unhealthy.
+func (t *SuccessRateTracker) Rate() (float64, int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	current := t.now().UnixNano() / int64(t.bucketWidth)
+	oldest := current - int64(len(t.buckets)) + 1
+
+	var successes, attempts int64
+	for _, bucket := range t.buckets {
+		if bucket.start < oldest || bucket.start > current {
+			continue
+		}
+		successes += bucket.successes
+		attempts += bucket.attempts
+	}
+
+	if attempts == 0 {
+		return 1, 0
+	}
+	return float64(successes) / float64(attempts), attempts
+}
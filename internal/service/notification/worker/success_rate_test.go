@@ -0,0 +1,105 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/health"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a settable time source for the tracker
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time          { return c.t }
+func (c *fakeClock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestTracker(window time.Duration) (*SuccessRateTracker, *fakeClock) {
+	clock := &fakeClock{t: time.Unix(1_700_000_000, 0)}
+	tracker := NewSuccessRateTracker(window)
+	tracker.now = clock.now
+	return tracker, clock
+}
+
+func TestSuccessRateTracker_Rate(t *testing.T) {
+	tracker, _ := newTestTracker(time.Minute)
+
+	rate, attempts := tracker.Rate()
+	assert.Equal(t, 1.0, rate)
+	assert.Zero(t, attempts)
+
+	for i := 0; i < 3; i++ {
+		tracker.Record(true)
+	}
+	tracker.Record(false)
+
+	rate, attempts = tracker.Rate()
+	assert.Equal(t, 0.75, rate)
+	assert.Equal(t, int64(4), attempts)
+}
+
+func TestSuccessRateTracker_OldAttemptsExpire(t *testing.T) {
+	tracker, clock := newTestTracker(time.Minute)
+
+	for i := 0; i < 10; i++ {
+		tracker.Record(false)
+	}
+	clock.advance(30 * time.Second)
+	tracker.Record(true)
+
+	_, attempts := tracker.Rate()
+	assert.Equal(t, int64(11), attempts)
+
+	// The failures leave the window, the recent success stays
+	clock.advance(45 * time.Second)
+	rate, attempts := tracker.Rate()
+	assert.Equal(t, 1.0, rate)
+	assert.Equal(t, int64(1), attempts)
+
+	clock.advance(time.Minute)
+	_, attempts = tracker.Rate()
+	assert.Zero(t, attempts)
+}
+
+// trackerChecker exposes a tracker to the worker health provider
+type trackerChecker struct{ tracker *SuccessRateTracker }
+
+func (c trackerChecker) IsRunning() bool                  { return true }
+func (c trackerChecker) GetQueueLength() int              { return -1 }
+func (c trackerChecker) GetQueueCapacity() int            { return -1 }
+func (c trackerChecker) GetSuccessRate() (float64, int64) { return c.tracker.Rate() }
+
+func TestSuccessRateTracker_FailuresDegradeThenDownHealth(t *testing.T) {
+	tracker, _ := newTestTracker(5 * time.Minute)
+	provider := health.NewWorkerProvider(health.WorkerProviderConfig{
+		Name:                   "notification-worker",
+		Checker:                trackerChecker{tracker: tracker},
+		DegradedSuccessRate:    0.9,
+		DownSuccessRate:        0.5,
+		MinSuccessRateAttempts: 20,
+	})
+	check := func() health.HealthStatus {
+		return provider.Check(context.Background()).Status
+	}
+
+	for i := 0; i < 20; i++ {
+		tracker.Record(true)
+	}
+	assert.Equal(t, health.StatusUp, check())
+
+	// 20/25 = 0.8
+	for i := 0; i < 5; i++ {
+		tracker.Record(false)
+	}
+	assert.Equal(t, health.StatusDegraded, check())
+
+	// 20/45 ≈ 0.44
+	for i := 0; i < 20; i++ {
+		tracker.Record(false)
+	}
+	rate, _ := tracker.Rate()
+	assert.InDelta(t, 0.444, rate, 0.001)
+	assert.Equal(t, health.StatusDown, check())
+}
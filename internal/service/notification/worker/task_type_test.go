@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/worker"
+	"myapp/internal/service/notification/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewNotificationWorker_RegistersConfiguredTaskType(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	queue := NewInMemoryQueue(10)
+
+	for _, taskType := range []string{"", "push-notification"} {
+		provider := NewInMemoryProvider(queue, nil, log)
+		provider.SetTaskType(taskType)
+
+		cfg := &config.ServiceConfig{}
+		cfg.Notification.WorkerConcurrency = 1
+		cfg.Notification.TaskType = taskType
+
+		w, err := NewNotificationWorker(provider, cfg, log, nil, nil, queue)
+		require.NoError(t, err)
+
+		_, err = w.worker.GetHandler(provider.TaskType())
+		assert.NoError(t, err, "enqueued type %q has no handler", provider.TaskType())
+	}
+}
+
+func TestNewNotificationWorker_RejectsTaskTypeMismatch(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	queue := NewInMemoryQueue(10)
+	provider := NewInMemoryProvider(queue, nil, log)
+
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.WorkerConcurrency = 1
+	cfg.Notification.TaskType = "push-notification"
+
+	_, err := NewNotificationWorker(provider, cfg, log, nil, nil, queue)
+	assert.ErrorIs(t, err, ErrTaskTypeMismatch)
+}
+
+func TestEnqueuedTasks_RouteToRegisteredHandler(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	queue := NewInMemoryQueue(10)
+	provider := NewInMemoryProvider(queue, nil, log)
+	provider.SetTaskType("push-notification")
+
+	cfg := config.NotificationServiceConfig{WorkerConcurrency: 1, TaskType: "push-notification"}
+	w := worker.New(provider, newWorkerConfig(cfg), log)
+
+	received := make(chan *worker.Task, 1)
+	w.Register(taskTypeOf(cfg), worker.HandlerFunc(func(ctx context.Context, task *worker.Task) error {
+		received <- task
+		return nil
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(ctx) }()
+
+	require.True(t, queue.Enqueue(newOrderingTask(7, "user-1", time.Now())))
+
+	select {
+	case task := <-received:
+		assert.Equal(t, "push-notification", task.Metadata["type"])
+		assert.Equal(t, "7", task.Metadata["delivery_id"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("enqueued task was not routed to the notification handler")
+	}
+
+	cancel()
+	require.NoError(t, <-errCh)
+}
+
+func TestNewWorkerConfig_ResolvesUntypedDeliveries(t *testing.T) {
+	resolve := newWorkerConfig(config.NotificationServiceConfig{WorkerConcurrency: 1}).TypeResolver
+	require.NotNil(t, resolve)
+
+	assert.Equal(t, DefaultTaskType, resolve(&worker.Task{Metadata: map[string]string{"delivery_id": "1"}}))
+	assert.Empty(t, resolve(&worker.Task{Metadata: map[string]string{"other": "x"}}))
+}
@@ -3,6 +3,7 @@ package worker
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync/atomic"
 	"time"
@@ -15,8 +16,19 @@ import (
 	"myapp/internal/service/notification/repository"
 
 	"go.uber.org/zap"
+	"golang.org/x/sync/semaphore"
 )
 
+// ErrSendTimeout is reported when a channel send exceeds the per-send timeout
+var ErrSendTimeout = errors.New("notification send timed out")
+
+// ErrChannelBusy is reported when every send slot of a channel is taken
+var ErrChannelBusy = errors.New("notification channel busy")
+
+// channelBusyRequeueDelay is how long a delivery for a busy channel waits
+// before it is fetched again
+const channelBusyRequeueDelay = time.Second
+
 // NotificationWorker processes notifications from in-memory queue
 type NotificationWorker struct {
 	worker          *worker.Worker
@@ -25,6 +37,23 @@ type NotificationWorker struct {
 	repo            *repository.NotificationRepository
 	channelRegistry *channel.ChannelRegistry
 	queue           *InMemoryQueue
+	tokenPruner     *channel.TokenPruner
+
+	// channelLimits caps concurrent sends for channels with a concurrency override
+	channelLimits map[string]*semaphore.Weighted
+	// sendWindows paces sends for channels with a sends_per_second override
+	sendWindows map[string]*sendWindow
+
+	// successRate tracks the rolling delivery success rate for health checks
+	successRate *SuccessRateTracker
+
+	// metrics counts processed tasks per type and status
+	metrics *worker.MetricsCollector
+
+	// checkIdempotency reports whether a delivery was already processed
+	checkIdempotency func(deliveryID int64) (bool, error)
+	// idempotencyFailOpens counts deliveries processed despite a failed idempotency check
+	idempotencyFailOpens atomic.Int64
 
 	// Health check fields
 	// Use atomic for lock-free reads (faster than RLock for simple bool)
@@ -40,31 +69,87 @@ func NewNotificationWorker(
 	channelRegistry *channel.ChannelRegistry,
 	queue *InMemoryQueue,
 ) (*NotificationWorker, error) {
+	if err := config.Notification.ValidateChannelOverrides(); err != nil {
+		return nil, err
+	}
+	taskType := taskTypeOf(config.Notification)
+	if p, ok := workerProvider.(taskTyper); ok && p.TaskType() != taskType {
+		return nil, fmt.Errorf("%w: provider enqueues %q but the worker handles %q",
+			ErrTaskTypeMismatch, p.TaskType(), taskType)
+	}
+	sendWindows, err := newSendWindows(config.Notification)
+	if err != nil {
+		return nil, err
+	}
+
 	w := &NotificationWorker{
 		config:          config,
 		logger:          log,
 		repo:            repo,
 		channelRegistry: channelRegistry,
 		queue:           queue,
+		tokenPruner:     channel.NewTokenPruner(repo, log),
+		channelLimits:   newChannelLimits(config.Notification),
+		sendWindows:     sendWindows,
+		successRate:     NewSuccessRateTracker(time.Duration(config.Notification.SuccessRateAlarm.WindowSec) * time.Second),
+		metrics:         worker.NewMetricsCollector(log),
 		running:         0, // 0 = not running
 	}
+	w.checkIdempotency = repo.CheckIdempotency
 
 	// Create worker
+	w.worker = worker.New(workerProvider, newWorkerConfig(config.Notification), log)
+
+	// Record per-type metrics and recover handler panics (the task goes to
+	// the DLQ). Metrics wraps Recovery so a panic is recorded as such.
+	w.worker.Use(worker.MetricsMiddleware(w.metrics))
+	w.worker.Use(worker.RecoveryMiddleware(log))
+
+	// Register handler
+	w.worker.Register(taskType, w)
+
+	return w, nil
+}
+
+// newWorkerConfig maps the notification settings onto the generic worker
+func newWorkerConfig(cfg config.NotificationServiceConfig) worker.Config {
 	workerConfig := worker.Config{
-		Concurrency:     config.Notification.WorkerConcurrency,
+		Concurrency:     cfg.WorkerConcurrency,
+		MaxInFlight:     cfg.WorkerMaxInFlight,
 		PollInterval:    100 * time.Millisecond,
 		ErrorBackoff:    1 * time.Second,
 		ShutdownTimeout: 30 * time.Second,
-		BaseBackoff:     time.Duration(config.Notification.RetryBackoffSec) * time.Second,
+		BaseBackoff:     time.Duration(cfg.RetryBackoffSec) * time.Second,
 		BackoffStrategy: worker.BackoffExponential,
 	}
 
-	w.worker = worker.New(workerProvider, workerConfig, log)
+	// A delivery that lost its type would otherwise be dead-lettered as
+	// having no handler, so route it to the notification handler
+	taskType := taskTypeOf(cfg)
+	workerConfig.TypeResolver = func(task *worker.Task) string {
+		if task.Metadata["delivery_id"] != "" {
+			return taskType
+		}
+		return ""
+	}
 
-	// Register handler
-	w.worker.Register("notification", w)
+	// Partition by user so one user's deliveries run one at a time, in the
+	// order they're dequeued. That isn't always creation order: the poller
+	// ranks by priority first, and a retried or requeued delivery goes back
+	// behind the user's newer ones.
+	if cfg.OrderPerUser {
+		workerConfig.PartitionKey = worker.MetadataPartitionKey("user_id")
+	}
 
-	return w, nil
+	return workerConfig
+}
+
+// taskTypeOf returns the configured task type, or DefaultTaskType if unset
+func taskTypeOf(cfg config.NotificationServiceConfig) string {
+	if cfg.TaskType != "" {
+		return cfg.TaskType
+	}
+	return DefaultTaskType
 }
 
 // Process implements worker.Handler interface
@@ -96,10 +181,9 @@ func (w *NotificationWorker) Process(ctx context.Context, task *worker.Task) err
 	}
 
 	// Check idempotency (database-based)
-	alreadyProcessed, err := w.repo.CheckIdempotency(deliveryID)
+	alreadyProcessed, err := w.isAlreadyProcessed(deliveryID)
 	if err != nil {
-		w.logger.Error("Failed to check idempotency", zap.Error(err), zap.Int64("delivery_id", deliveryID))
-		return fmt.Errorf("failed to check idempotency: %w", err)
+		return err
 	}
 
 	if alreadyProcessed {
@@ -119,15 +203,33 @@ func (w *NotificationWorker) Process(ctx context.Context, task *worker.Task) err
 	return err
 }
 
+// isAlreadyProcessed runs the idempotency check
+// If the check fails and idempotency_fail_open is set, the delivery proceeds
+// as not yet processed and the fail-open is counted; otherwise the error is
+// returned so the task is retried.
+func (w *NotificationWorker) isAlreadyProcessed(deliveryID int64) (bool, error) {
+	alreadyProcessed, err := w.checkIdempotency(deliveryID)
+	if err == nil {
+		return alreadyProcessed, nil
+	}
+
+	if w.config.Notification.IdempotencyFailOpen {
+		w.idempotencyFailOpens.Add(1)
+		w.logger.Warn("Idempotency check failed, processing anyway (fail-open)",
+			zap.Error(err),
+			zap.Int64("delivery_id", deliveryID),
+		)
+		return false, nil
+	}
+
+	w.logger.Error("Failed to check idempotency", zap.Error(err), zap.Int64("delivery_id", deliveryID))
+	return false, fmt.Errorf("failed to check idempotency: %w", err)
+}
+
 // sendNotification sends a notification using the appropriate channel
 func (w *NotificationWorker) sendNotification(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload, deliveryID int64) (interface{}, error) {
 	startTime := time.Now()
 
-	// Increment attempt count
-	if err := w.repo.IncrementAttempt(target.ID, ""); err != nil {
-		w.logger.Warn("Failed to increment attempt count", zap.Error(err))
-	}
-
 	// Determine channel type from payload or target
 	channelType := "expo" // Default
 	if st, ok := target.Payload["sender_type"].(string); ok && st != "" {
@@ -135,18 +237,45 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, target *model
 	}
 
 	// Get channel
-	channel, ok := w.channelRegistry.GetChannel(channelType)
+	ch, ok := w.channelRegistry.GetChannel(channelType)
 	if !ok {
 		err := fmt.Errorf("channel not found: %s", channelType)
 		w.logger.Error("Channel not found", zap.String("channel_type", channelType))
 		w.repo.IncrementAttempt(target.ID, err.Error())
+		w.successRate.Record(false)
 		return nil, err
 	}
 
-	// Send notification
-	result := channel.Send(ctx, target, payload)
+	// Respect the channel's own concurrency cap, if it has one. A busy
+	// channel hands the delivery back rather than holding a worker slot,
+	// and that doesn't count as an attempt.
+	release, ok := w.acquireChannel(ch.Name())
+	if !ok {
+		return nil, worker.Requeue(fmt.Errorf("%w: %s", ErrChannelBusy, ch.Name()), channelBusyRequeueDelay)
+	}
+	defer release()
+
+	// Increment attempt count
+	if err := w.repo.IncrementAttempt(target.ID, ""); err != nil {
+		w.logger.Warn("Failed to increment attempt count", zap.Error(err))
+	}
+
+	// Stay under the provider's rate limit instead of bursting into 429s;
+	// the channel paces each message it sends, one per token
+	sendCtx := ctx
+	if pacer := w.sendPacer(ch.Name()); pacer != nil {
+		sendCtx = channel.WithSendPacer(ctx, pacer)
+	}
+
+	// Send notification per token so one dead token doesn't fail the delivery
+	sendTimeout := time.Duration(w.config.Notification.SendTimeoutSec) * time.Second
+	tokenResults := sendTokensWithTimeout(sendCtx, ch, target, payload, sendTimeout)
 	duration := time.Since(startTime)
 
+	w.tokenPruner.Prune(target.UserID, ch.Name(), tokenResults)
+	result := channel.AggregateTokenResults(tokenResults)
+	w.successRate.Record(result.Success)
+
 	if result.Success {
 		// Mark as delivered
 		if err := w.repo.MarkDelivered(target.ID); err != nil {
@@ -157,7 +286,7 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, target *model
 			zap.Int64("delivery_id", deliveryID),
 			zap.Int64("target_id", target.ID),
 			zap.String("user_id", target.UserID),
-			zap.String("channel", channel.Name()),
+			zap.String("channel", ch.Name()),
 			zap.String("trace_id", payload.TraceID),
 			zap.Duration("duration_ms", duration),
 		)
@@ -180,7 +309,7 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, target *model
 		zap.Int64("delivery_id", deliveryID),
 		zap.Int64("target_id", target.ID),
 		zap.String("user_id", target.UserID),
-		zap.String("channel", channel.Name()),
+		zap.String("channel", ch.Name()),
 		zap.Bool("retryable", result.Retryable),
 		zap.String("error", errorMsg),
 		zap.Duration("duration_ms", duration),
@@ -189,10 +318,71 @@ func (w *NotificationWorker) sendNotification(ctx context.Context, target *model
 	// If retryable, the worker will handle retry (reset to pending)
 	// If not retryable, mark as failed
 	if !result.Retryable {
-		return nil, fmt.Errorf("non-retryable error: %w", result.Error)
+		return nil, w.withChannelBackoff(ch.Name(), fmt.Errorf("non-retryable error: %w", result.Error))
 	}
 
-	return nil, fmt.Errorf("retryable error: %w", result.Error)
+	return nil, w.withChannelBackoff(ch.Name(), fmt.Errorf("retryable error: %w", result.Error))
+}
+
+// newChannelLimits builds a semaphore for every channel whose concurrency
+// override is below the worker concurrency; other channels share the pool
+func newChannelLimits(cfg config.NotificationServiceConfig) map[string]*semaphore.Weighted {
+	limits := make(map[string]*semaphore.Weighted)
+	for name := range cfg.ChannelOverrides {
+		if n := cfg.ChannelConcurrency(name); n > 0 && n < cfg.WorkerConcurrency {
+			limits[name] = semaphore.NewWeighted(int64(n))
+		}
+	}
+	return limits
+}
+
+// acquireChannel takes a send slot on the channel without waiting and
+// returns its release func; ok is false when every slot is taken
+func (w *NotificationWorker) acquireChannel(name string) (release func(), ok bool) {
+	limit, limited := w.channelLimits[name]
+	if !limited {
+		return func() {}, true
+	}
+	if !limit.TryAcquire(1) {
+		return nil, false
+	}
+	return func() { limit.Release(1) }, true
+}
+
+// withChannelBackoff makes the worker retry with the channel's base backoff
+func (w *NotificationWorker) withChannelBackoff(name string, err error) error {
+	return worker.WithRetryBackoff(err, w.config.Notification.ChannelRetryBackoff(name))
+}
+
+// sendTokensWithTimeout sends through the channel with a per-send deadline
+// The channel gets a context that expires after timeout, and the send is
+// always waited for: its outcome is never dropped, so a send that succeeds
+// late isn't retried into a duplicate, and the caller's channel slot stays
+// taken until it returns. Failures caused by the deadline wrap ErrSendTimeout.
+func sendTokensWithTimeout(ctx context.Context, ch channel.Channel, target *model.NotificationTarget, payload model.NotificationPayload, timeout time.Duration) []*channel.TokenResult {
+	if timeout <= 0 {
+		return ch.SendTokens(ctx, target, payload)
+	}
+
+	deadline := time.Now().Add(timeout)
+	sendCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	results := ch.SendTokens(sendCtx, target, payload)
+
+	// Only this deadline is a timeout, not the caller's cancellation. A
+	// socket deadline set from it can end the send before sendCtx's timer
+	// fires, so the clock decides too.
+	timedOut := errors.Is(sendCtx.Err(), context.DeadlineExceeded) || !time.Now().Before(deadline)
+	if ctx.Err() != nil || !timedOut {
+		return results
+	}
+	for _, result := range results {
+		if !result.Success && errors.Is(result.Error, context.DeadlineExceeded) {
+			result.Error = fmt.Errorf("%w: %s after %s: %w", ErrSendTimeout, ch.Name(), timeout, result.Error)
+		}
+	}
+	return results
 }
 
 // Start starts the worker
@@ -216,7 +406,9 @@ func (w *NotificationWorker) Stop(ctx context.Context) error {
 
 	atomic.StoreInt32(&w.running, 0) // Set to stopped
 
-	return w.worker.Stop(ctx)
+	err := w.worker.Stop(ctx)
+	closeSendWindows(w.sendWindows)
+	return err
 }
 
 // IsRunning returns true if the worker is currently running
@@ -240,3 +432,33 @@ func (w *NotificationWorker) GetQueueCapacity() int {
 	}
 	return w.queue.Capacity()
 }
+
+// GetQueueOldestAge returns how long the oldest queued notification has waited
+func (w *NotificationWorker) GetQueueOldestAge() time.Duration {
+	if w.queue == nil {
+		return 0
+	}
+	return w.queue.OldestAge()
+}
+
+// GetInFlight returns the number of notifications currently being processed
+func (w *NotificationWorker) GetInFlight() int64 {
+	return w.worker.InFlight()
+}
+
+// GetMaxInFlight returns the configured in-flight limit (0 = unlimited)
+func (w *NotificationWorker) GetMaxInFlight() int64 {
+	return w.worker.MaxInFlight()
+}
+
+// GetIdempotencyFailOpens returns how many deliveries were processed after
+// a failed idempotency check
+func (w *NotificationWorker) GetIdempotencyFailOpens() int64 {
+	return w.idempotencyFailOpens.Load()
+}
+
+// GetSuccessRate returns the rolling delivery success rate and the number of
+// attempts it is based on
+func (w *NotificationWorker) GetSuccessRate() (float64, int64) {
+	return w.successRate.Rate()
+}
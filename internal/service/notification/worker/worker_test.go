@@ -0,0 +1,403 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"myapp/internal/pkg/database"
+	"myapp/internal/pkg/health"
+	"myapp/internal/pkg/logger"
+	"myapp/internal/pkg/worker"
+	"myapp/internal/service/notification/channel"
+	"myapp/internal/service/notification/config"
+	"myapp/internal/service/notification/model"
+	"myapp/internal/service/notification/repository"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// blockingChannel blocks in SendTokens until its context ends, or until
+// release is closed when ignoreContext is set
+type blockingChannel struct {
+	ignoreContext bool
+	release       chan struct{}
+	ctxErr        chan error
+}
+
+func newBlockingChannel(ignoreContext bool) *blockingChannel {
+	return &blockingChannel{
+		ignoreContext: ignoreContext,
+		release:       make(chan struct{}),
+		ctxErr:        make(chan error, 1),
+	}
+}
+
+func (c *blockingChannel) Name() string { return "blocking" }
+
+func (c *blockingChannel) Send(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) *channel.ChannelResult {
+	return channel.AggregateTokenResults(c.SendTokens(ctx, target, payload))
+}
+
+func (c *blockingChannel) SendTokens(ctx context.Context, target *model.NotificationTarget, payload model.NotificationPayload) []*channel.TokenResult {
+	if c.ignoreContext {
+		<-c.release
+		return []*channel.TokenResult{{Success: true}}
+	}
+	<-ctx.Done()
+	c.ctxErr <- ctx.Err()
+	return []*channel.TokenResult{{Success: false, Retryable: true, Error: ctx.Err()}}
+}
+
+func TestSendTokensWithTimeout_CancelsSlowSend(t *testing.T) {
+	ch := newBlockingChannel(false)
+
+	start := time.Now()
+	results := sendTokensWithTimeout(context.Background(), ch, &model.NotificationTarget{}, model.NotificationPayload{}, 50*time.Millisecond)
+	elapsed := time.Since(start)
+
+	// The channel saw its context expire at the deadline
+	select {
+	case err := <-ch.ctxErr:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(time.Second):
+		t.Fatal("channel context was not cancelled")
+	}
+	assert.GreaterOrEqual(t, elapsed, 50*time.Millisecond)
+	assert.Less(t, elapsed, time.Second)
+
+	result := channel.AggregateTokenResults(results)
+	assert.False(t, result.Success)
+	assert.True(t, result.Retryable)
+	assert.ErrorIs(t, result.Error, ErrSendTimeout)
+	assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
+}
+
+// expoTokenConn is a database/sql connection that answers every query with
+// one Expo device token
+type expoTokenConn struct{ token string }
+
+func (c *expoTokenConn) Prepare(query string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *expoTokenConn) Close() error                              { return nil }
+func (c *expoTokenConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+
+func (c *expoTokenConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return &expoTokenRows{token: c.token}, nil
+}
+
+type expoTokenRows struct {
+	token string
+	done  bool
+}
+
+func (r *expoTokenRows) Columns() []string {
+	return []string{"id", "user_id", "device_id", "push_token", "type"}
+}
+func (r *expoTokenRows) Close() error { return nil }
+func (r *expoTokenRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	copy(dest, []driver.Value{int64(1), "user-1", "device-1", r.token, "expo"})
+	return nil
+}
+
+type expoTokenConnector struct{ conn *expoTokenConn }
+
+func (c expoTokenConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c expoTokenConnector) Driver() driver.Driver                        { return nil }
+
+func newExpoTokenRepository(t *testing.T, token string) *repository.NotificationRepository {
+	t.Helper()
+
+	sqlDB := sql.OpenDB(expoTokenConnector{conn: &expoTokenConn{token: token}})
+	t.Cleanup(func() { sqlDB.Close() })
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{
+		DisableAutomaticPing: true,
+		Logger:               gormlogger.Discard,
+	})
+	require.NoError(t, err)
+
+	return repository.NewNotificationRepository(&database.Database{DB: db})
+}
+
+func TestSendTokensWithTimeout_StopsHungExpoRequest(t *testing.T) {
+	hung := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-hung
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(hung) })
+
+	// No configured timeout, so only the per-send deadline can end the request
+	ch := channel.NewExpoChannel(&config.ExpoConfig{
+		Enabled:    true,
+		APIURL:     srv.URL + "/--/api/v2/push/send",
+		MaxRetries: 1,
+	}, &logger.Logger{Logger: zap.NewNop()}, newExpoTokenRepository(t, "ExponentPushToken[abc]"))
+
+	start := time.Now()
+	results := sendTokensWithTimeout(context.Background(), ch, &model.NotificationTarget{UserID: "user-1"}, model.NotificationPayload{}, 50*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second, "the Expo request outlived the per-send deadline")
+
+	result := channel.AggregateTokenResults(results)
+	assert.False(t, result.Success)
+	assert.True(t, result.Retryable)
+	assert.ErrorIs(t, result.Error, ErrSendTimeout)
+}
+
+func TestSendTokensWithTimeout_StopsHungSMTPConversation(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	// Accept connections but never send the SMTP greeting
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	ch := channel.NewEmailChannel(&config.EmailConfig{
+		Enabled:    true,
+		SMTPHost:   "127.0.0.1",
+		SMTPPort:   ln.Addr().(*net.TCPAddr).Port,
+		FromEmail:  "alerts@example.com",
+		TLSMode:    "none",
+		MaxRetries: 1,
+	}, &logger.Logger{Logger: zap.NewNop()}, nil)
+
+	start := time.Now()
+	results := sendTokensWithTimeout(context.Background(), ch, &model.NotificationTarget{}, model.NotificationPayload{
+		Email: "user@example.com",
+		Data:  map[string]interface{}{"subject": "Hi", "text": "Hello"},
+	}, 50*time.Millisecond)
+	assert.Less(t, time.Since(start), time.Second, "the SMTP conversation outlived the per-send deadline")
+
+	result := channel.AggregateTokenResults(results)
+	assert.False(t, result.Success)
+	assert.True(t, result.Retryable)
+	assert.ErrorIs(t, result.Error, ErrSendTimeout)
+}
+
+func TestSendTokensWithTimeout_KeepsLateSuccess(t *testing.T) {
+	ch := newBlockingChannel(true)
+	time.AfterFunc(100*time.Millisecond, func() { close(ch.release) })
+
+	// The send outlives its deadline but succeeds; that must not turn into
+	// a retry and a duplicate notification
+	start := time.Now()
+	results := sendTokensWithTimeout(context.Background(), ch, &model.NotificationTarget{}, model.NotificationPayload{}, 20*time.Millisecond)
+
+	assert.GreaterOrEqual(t, time.Since(start), 100*time.Millisecond, "the send was not waited for")
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+}
+
+func TestSendTokensWithTimeout_ReturnsFastSendResults(t *testing.T) {
+	ch := newBlockingChannel(true)
+	close(ch.release)
+
+	results := sendTokensWithTimeout(context.Background(), ch, &model.NotificationTarget{}, model.NotificationPayload{}, time.Second)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Success)
+}
+
+func TestSendTokensWithTimeout_ParentCancelIsNotATimeout(t *testing.T) {
+	ch := newBlockingChannel(false)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results := sendTokensWithTimeout(ctx, ch, &model.NotificationTarget{}, model.NotificationPayload{}, time.Second)
+	require.Len(t, results, 1)
+	assert.ErrorIs(t, results[0].Error, context.Canceled)
+	assert.NotErrorIs(t, results[0].Error, ErrSendTimeout)
+}
+
+func TestSendTokensWithTimeout_HoldsChannelSlotUntilSendReturns(t *testing.T) {
+	w := newOverrideWorker()
+	ch := newBlockingChannel(true)
+
+	release, ok := w.acquireChannel("email")
+	require.True(t, ok)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer release()
+		sendTokensWithTimeout(context.Background(), ch, &model.NotificationTarget{}, model.NotificationPayload{}, 10*time.Millisecond)
+	}()
+
+	// Past the deadline the send is still running, so its slot stays taken
+	time.Sleep(50 * time.Millisecond)
+	_, ok = w.acquireChannel("email")
+	assert.False(t, ok, "the channel slot was freed while the send was running")
+
+	close(ch.release)
+	<-done
+	release, ok = w.acquireChannel("email")
+	require.True(t, ok)
+	release()
+}
+
+func newOverrideWorker() *NotificationWorker {
+	cfg := &config.ServiceConfig{Notification: config.NotificationServiceConfig{
+		WorkerConcurrency: 10,
+		RetryBackoffSec:   60,
+		ChannelOverrides: map[string]config.ChannelOverride{
+			"email": {Concurrency: 1, RetryBackoffSec: 300},
+		},
+	}}
+	return &NotificationWorker{config: cfg, channelLimits: newChannelLimits(cfg.Notification)}
+}
+
+func TestAcquireChannel_UsesOverrideConcurrency(t *testing.T) {
+	w := newOverrideWorker()
+
+	release, ok := w.acquireChannel("email")
+	require.True(t, ok)
+
+	// The single email slot is taken, and the caller doesn't wait for it
+	_, ok = w.acquireChannel("email")
+	assert.False(t, ok)
+
+	release()
+	release, ok = w.acquireChannel("email")
+	require.True(t, ok)
+	release()
+}
+
+func TestAcquireChannel_NoOverrideIsUnlimited(t *testing.T) {
+	w := newOverrideWorker()
+	assert.NotContains(t, w.channelLimits, "expo")
+
+	for i := 0; i < 20; i++ {
+		_, ok := w.acquireChannel("expo")
+		require.True(t, ok)
+	}
+}
+
+func TestWithChannelBackoff(t *testing.T) {
+	w := newOverrideWorker()
+	sendErr := errors.New("send failed")
+
+	var backoffErr *worker.RetryBackoffError
+	require.ErrorAs(t, w.withChannelBackoff("email", sendErr), &backoffErr)
+	assert.Equal(t, 300*time.Second, backoffErr.BaseBackoff)
+
+	require.ErrorAs(t, w.withChannelBackoff("expo", sendErr), &backoffErr)
+	assert.Equal(t, 60*time.Second, backoffErr.BaseBackoff)
+	assert.ErrorIs(t, backoffErr, sendErr)
+}
+
+func newIdempotencyWorker(failOpen bool, check func(int64) (bool, error)) *NotificationWorker {
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.IdempotencyFailOpen = failOpen
+	return &NotificationWorker{
+		config:           cfg,
+		logger:           &logger.Logger{Logger: zap.NewNop()},
+		checkIdempotency: check,
+	}
+}
+
+func TestIsAlreadyProcessed_StoreFailure(t *testing.T) {
+	storeErr := errors.New("connection refused")
+	failing := func(int64) (bool, error) { return false, storeErr }
+
+	t.Run("fail-closed returns the error", func(t *testing.T) {
+		w := newIdempotencyWorker(false, failing)
+
+		_, err := w.isAlreadyProcessed(1)
+		assert.ErrorIs(t, err, storeErr)
+		assert.Zero(t, w.GetIdempotencyFailOpens())
+	})
+
+	t.Run("fail-open processes the delivery", func(t *testing.T) {
+		w := newIdempotencyWorker(true, failing)
+
+		processed, err := w.isAlreadyProcessed(1)
+		require.NoError(t, err)
+		assert.False(t, processed)
+		assert.Equal(t, int64(1), w.GetIdempotencyFailOpens())
+	})
+}
+
+func TestIsAlreadyProcessed_StoreHealthy(t *testing.T) {
+	for _, failOpen := range []bool{false, true} {
+		w := newIdempotencyWorker(failOpen, func(int64) (bool, error) { return true, nil })
+
+		processed, err := w.isAlreadyProcessed(1)
+		require.NoError(t, err)
+		assert.True(t, processed)
+		assert.Zero(t, w.GetIdempotencyFailOpens())
+	}
+}
+
+func TestHealth_NilQueueReportsUnknown(t *testing.T) {
+	w := &NotificationWorker{
+		worker:      worker.New(nil, worker.Config{}, &logger.Logger{Logger: zap.NewNop()}),
+		successRate: NewSuccessRateTracker(time.Minute),
+		running:     1,
+	}
+	require.Equal(t, -1, w.GetQueueLength())
+	require.Equal(t, -1, w.GetQueueCapacity())
+
+	provider := health.NewWorkerProvider(health.WorkerProviderConfig{
+		Name:                "notification-worker",
+		Checker:             w,
+		MaxQueueLength:      2000,
+		RequireQueueMetrics: true,
+	})
+
+	result := provider.Check(context.Background())
+	assert.Equal(t, health.StatusDegraded, result.Status, "a missing queue must not look like an empty one")
+	assert.Equal(t, "queue metrics unavailable", result.Details["reason"])
+	assert.Equal(t, "unknown", result.Details["queue_length"])
+}
+
+func TestNewNotificationWorker_RecordsPanicMetrics(t *testing.T) {
+	log := &logger.Logger{Logger: zap.NewNop()}
+	queue := NewInMemoryQueue(10)
+	provider := NewInMemoryProvider(queue, nil, log)
+
+	cfg := &config.ServiceConfig{}
+	cfg.Notification.WorkerConcurrency = 1
+	w, err := NewNotificationWorker(provider, cfg, log, nil, nil, queue)
+	require.NoError(t, err)
+
+	// Panic inside the registered handler, behind the real middleware chain
+	w.checkIdempotency = func(int64) (bool, error) { panic("boom") }
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() { errCh <- w.Start(ctx) }()
+
+	require.True(t, queue.Enqueue(newOrderingTask(1, "user-1", time.Now())))
+
+	require.Eventually(t, func() bool {
+		return w.metrics.TaskCount(DefaultTaskType, worker.TaskStatusPanic) == 1
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Zero(t, w.metrics.TaskCount(DefaultTaskType, worker.TaskStatusError))
+
+	cancel()
+	require.NoError(t, <-errCh)
+}